@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// accountsOptions holds the flags of the accounts command
+type accountsOptions struct {
+	*options
+	format  string
+	orphans bool
+}
+
+func newAccountsCmd(parent *options) *cobra.Command {
+	o := &accountsOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "accounts",
+		Short: "List credentialed accounts and the configurations using them",
+		Long: `List the accounts gcloud holds credentials for (gcloud auth list) with the
+configurations whose core/account uses each one. "*" marks the account of the
+active configuration.
+
+Accounts that configurations use without a credential are listed too, so
+--orphans shows what to clean up before wiping stale credentials: credentials
+no configuration uses, and configurations whose account has no credential.
+
+Examples:
+  gcloudctx accounts              # Accounts and their configurations
+  gcloudctx accounts --orphans    # Unused credentials and uncredentialed configurations
+  gcloudctx accounts -o json      # JSON array for tooling`,
+		Args: cobra.NoArgs,
+		RunE: o.runAccounts,
+	}
+	cmd.Flags().StringVarP(&o.format, "output", "o", "", "Output format (json, yaml)")
+	cmd.Flags().BoolVar(&o.orphans, "orphans", false, "Show only unused credentials and configurations without a credential")
+	return cmd
+}
+
+func (o *accountsOptions) runAccounts(cmd *cobra.Command, args []string) error {
+	format, err := output.ValidateOutputFormat(o.format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	stop := output.StartSpinner(querySpinnerMessage)
+	accounts, err := gcloud.ListCredentialedAccounts()
+	var configs []gcloud.Configuration
+	if err == nil {
+		configs, err = gcloud.ListConfigurations()
+	}
+	stop()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	activeAccount := ""
+	for _, config := range configs {
+		if config.IsActive {
+			activeAccount = config.Properties.Core.Account
+		}
+	}
+
+	var usages []gcloud.AccountUsage
+	for _, usage := range gcloud.AccountUsages(accounts, configs) {
+		if !o.orphans || usage.Orphaned() {
+			usages = append(usages, usage)
+		}
+	}
+
+	if output.IsMachineFormat(format) {
+		results := make([]output.AccountOutput, len(usages))
+		for i, usage := range usages {
+			results[i] = output.AccountOutput{
+				Account:        usage.Account,
+				Active:         activeAccount != "" && strings.EqualFold(usage.Account, activeAccount),
+				Credentialed:   usage.Credentialed,
+				Configurations: usage.Configurations,
+			}
+		}
+		return output.PrintAccounts(results, format)
+	}
+
+	if len(usages) == 0 {
+		if o.orphans {
+			fmt.Println("No unused credentials or configurations without a credential")
+		} else {
+			fmt.Println("No credentialed accounts; run 'gcloud auth login' to add one")
+		}
+		return nil
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	bold := color.New(color.Bold).SprintFunc()
+	gray := color.New(color.FgHiBlack).SprintFunc()
+
+	rows := [][]string{{bold("  ACCOUNT"), bold("CREDENTIAL"), bold("CONFIGURATIONS")}}
+	for _, usage := range usages {
+		marker := "  "
+		if activeAccount != "" && strings.EqualFold(usage.Account, activeAccount) {
+			marker = "* "
+		}
+		credential := "yes"
+		if !usage.Credentialed {
+			credential = color.YellowString("missing")
+		}
+		configurations := gray("none")
+		if len(usage.Configurations) > 0 {
+			configurations = strings.Join(usage.Configurations, ", ")
+		}
+		rows = append(rows, []string{marker + usage.Account, credential, configurations})
+	}
+	for _, line := range output.AlignColumns(rows, 2) {
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+)
+
+// newAccountsTestEnv adds credentials for dev's account and an account no
+// configuration uses, leaving prod's account without a credential
+func newAccountsTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	env := newTestEnv(t)
+	env.gcloud.AddAccount("dev@example.com")
+	env.gcloud.AddAccount("old@example.com")
+	env.gcloud.Add("staging", map[string]string{"core/account": "dev@example.com"})
+	return env
+}
+
+func TestAccounts(t *testing.T) {
+	env := newAccountsTestEnv(t)
+
+	res := env.mustRun("accounts")
+
+	lines := strings.Split(strings.TrimSpace(res.stdout), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("accounts printed %q, want a header and three accounts", res.stdout)
+	}
+	for i, want := range [][]string{
+		{"* dev@example.com", "yes", "dev, staging"},
+		{"  old@example.com", "yes", "none"},
+		{"  ops@example.com", "missing", "prod"},
+	} {
+		for _, field := range want {
+			if !strings.Contains(lines[i+1], field) {
+				t.Errorf("row %d = %q, want %q in it", i+1, lines[i+1], field)
+			}
+		}
+	}
+}
+
+func TestAccountsOrphansJSON(t *testing.T) {
+	env := newAccountsTestEnv(t)
+
+	res := env.mustRun("accounts", "--orphans", "-o", "json")
+
+	var got []output.AccountOutput
+	if err := json.Unmarshal([]byte(res.stdout), &got); err != nil {
+		t.Fatalf("accounts -o json printed %q: %v", res.stdout, err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("orphans = %+v, want old@example.com and ops@example.com", got)
+	}
+	if got[0].Account != "old@example.com" || !got[0].Credentialed || len(got[0].Configurations) != 0 {
+		t.Errorf("orphans[0] = %+v, want the unused credential", got[0])
+	}
+	if got[1].Account != "ops@example.com" || got[1].Credentialed || strings.Join(got[1].Configurations, ",") != "prod" {
+		t.Errorf("orphans[1] = %+v, want prod's account without a credential", got[1])
+	}
+	if !strings.Contains(res.stdout, `"configurations": []`) {
+		t.Errorf("stdout = %q, want an empty configurations array rather than null", res.stdout)
+	}
+}
+
+func TestAccountsMarksActiveAccount(t *testing.T) {
+	env := newAccountsTestEnv(t)
+	env.mustRun("prod")
+
+	res := env.mustRun("accounts", "-o", "json")
+
+	var got []output.AccountOutput
+	if err := json.Unmarshal([]byte(res.stdout), &got); err != nil {
+		t.Fatalf("accounts -o json printed %q: %v", res.stdout, err)
+	}
+	for _, account := range got {
+		if account.Active != (account.Account == "ops@example.com") {
+			t.Errorf("%s active = %v, want only prod's account active", account.Account, account.Active)
+		}
+	}
+}
+
+func TestAccountsNoOrphans(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.AddAccount("dev@example.com")
+	env.gcloud.AddAccount("ops@example.com")
+
+	res := env.mustRun("accounts", "--orphans")
+
+	if !strings.Contains(res.stdout, "No unused credentials") {
+		t.Errorf("stdout = %q, want nothing to clean up reported", res.stdout)
+	}
+}
@@ -0,0 +1,362 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/internal/prompt"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// adcTimeLayout formats snapshot timestamps in listings
+const adcTimeLayout = "2006-01-02 15:04"
+
+func newADCCmd(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "adc",
+		Short: "Manage saved Application Default Credentials",
+		Long: `Sync Application Default Credentials (application_default_credentials.json)
+for the active configuration, and save, list, verify and delete per-configuration
+snapshots of them.
+
+Snapshots contain refresh tokens and are stored readable only by you in
+~/.gcloudctx_adc_snapshots. Refresh tokens can be revoked or expire under
+organization policy; 'gcloudctx adc refresh' finds dead snapshots before you
+rely on them.`,
+	}
+	cmd.AddCommand(newADCSyncCmd(o), newADCSaveCmd(o), newADCListCmd(o), newADCRefreshCmd(o), newADCDeleteCmd(o))
+	return cmd
+}
+
+func newADCSyncCmd(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Log in to Application Default Credentials for the active configuration",
+		Long: `Run 'gcloud auth application-default login' for the active configuration, as
+switching with --sync-adc does, impersonating the service account recorded when
+the configuration was imported unless --impersonate-service-account is given.
+
+Over SSH, or on Linux without a display, gcloud prints a URL to open in a
+browser on another machine instead of launching one here; --no-browser and
+--no-browser=false choose explicitly.
+
+Examples:
+  gcloudctx adc sync
+  gcloudctx adc sync --no-browser && gcloudctx adc save`,
+		Args: cobra.NoArgs,
+		RunE: o.runADCSync,
+	}
+	cmd.Flags().StringVar(&o.impersonate, "impersonate-service-account", "", "Service account to impersonate for ADC")
+	cmd.Flags().BoolVar(&o.noBrowser, "no-browser", false, noBrowserUsage)
+	return cmd
+}
+
+func newADCSaveCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "save [configuration-name]",
+		Short: "Save the current ADC as the snapshot of a configuration",
+		Long: `Save the current Application Default Credentials as the snapshot of a
+configuration (the active configuration by default), replacing any earlier one.
+
+Examples:
+  gcloudctx prod --sync-adc && gcloudctx adc save prod`,
+		Args:              cobra.MaximumNArgs(1),
+		RunE:              o.runADCSave,
+		ValidArgsFunction: completeConfigNames,
+	}
+}
+
+func newADCListCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List ADC snapshots and when they were last verified",
+		Args:  cobra.NoArgs,
+		RunE:  o.runADCList,
+	}
+}
+
+// adcRefreshOptions holds the flags of the adc refresh command
+type adcRefreshOptions struct {
+	*options
+	all bool
+}
+
+func newADCRefreshCmd(parent *options) *cobra.Command {
+	o := &adcRefreshOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "refresh [--all | configuration-name]",
+		Short: "Verify that ADC snapshots can still obtain tokens",
+		Long: `Verify that ADC snapshots can still obtain access tokens.
+
+For each snapshot, the credentials are copied into a temporary gcloud
+configuration directory and 'gcloud auth application-default print-access-token'
+runs against that copy, so your live ADC file is never read or written. The
+snapshot's last-verified time (shown by 'gcloudctx adc list') is updated.
+
+Dead snapshots are reported; in a terminal you are asked whether to delete each
+one, otherwise log in again and re-save it.
+
+Examples:
+  gcloudctx adc refresh prod
+  gcloudctx adc refresh --all`,
+		Args:              cobra.MaximumNArgs(1),
+		RunE:              o.runADCRefresh,
+		ValidArgsFunction: completeSnapshotNames,
+	}
+	cmd.Flags().BoolVar(&o.all, "all", false, "Verify every snapshot")
+	return cmd
+}
+
+func newADCDeleteCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete <configuration-name>",
+		Short:             "Delete the ADC snapshot of a configuration",
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.runADCDelete,
+		ValidArgsFunction: completeSnapshotNames,
+	}
+}
+
+// completeSnapshotNames provides completion for configurations with an ADC snapshot
+func completeSnapshotNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	store, err := adc.DefaultSnapshotStore()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	snapshots, err := store.List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, len(snapshots))
+	for i, snapshot := range snapshots {
+		names[i] = snapshot.Config
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func (o *options) runADCSave(cmd *cobra.Command, args []string) error {
+	configName := ""
+	if len(args) > 0 {
+		configName = args[0]
+		exists, err := gcloud.ConfigurationExists(configName)
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		if !exists {
+			err := fmt.Errorf("configuration %q does not exist", configName)
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+	} else {
+		name, err := gcloud.ActiveConfigName()
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		configName = name
+	}
+
+	livePath, err := adcFilePath()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	credentials, err := os.ReadFile(livePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = fmt.Errorf("no Application Default Credentials at %s (run 'gcloudctx %s --sync-adc' first)", livePath, configName)
+		}
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	store, err := adc.DefaultSnapshotStore()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if err := store.Save(configName, credentials, time.Now()); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	output.PrintSuccess(fmt.Sprintf("saved ADC snapshot for configuration %q", configName), !o.noColor)
+	return nil
+}
+
+func (o *options) runADCSync(cmd *cobra.Command, args []string) error {
+	configName, err := gcloud.ActiveConfigName()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	fmt.Printf("Syncing Application Default Credentials for %q...\n", configName)
+	if err := gcloud.SyncADC(o.adcImpersonation(configName, false), o.adcNoBrowser(os.Stdout)); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	output.PrintSuccess("ADC synced successfully", !o.noColor)
+	return nil
+}
+
+func (o *options) runADCList(cmd *cobra.Command, args []string) error {
+	store, err := adc.DefaultSnapshotStore()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	snapshots, err := store.List()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Println("No ADC snapshots (save one with 'gcloudctx adc save')")
+		return nil
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	gray := color.New(color.FgHiBlack).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	rows := [][]string{{"CONFIGURATION", "SAVED", "LAST VERIFIED", "STATUS"}}
+	for _, snapshot := range snapshots {
+		m := snapshot.Metadata
+		verified := gray("never")
+		if !m.LastVerified.IsZero() {
+			verified = m.LastVerified.Local().Format(adcTimeLayout)
+		}
+
+		status := gray("unverified")
+		switch {
+		case m.Dead():
+			status = red("dead")
+		case !m.LastVerified.IsZero():
+			status = green("ok")
+		}
+
+		rows = append(rows, []string{snapshot.Config, m.SavedAt.Local().Format(adcTimeLayout), verified, status})
+	}
+
+	for _, line := range output.AlignColumns(rows, 2) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func (o *adcRefreshOptions) runADCRefresh(cmd *cobra.Command, args []string) error {
+	if o.all == (len(args) > 0) {
+		err := fmt.Errorf("specify either a configuration or --all")
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	store, err := adc.DefaultSnapshotStore()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	targets := args
+	if o.all {
+		snapshots, err := store.List()
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("No ADC snapshots to refresh")
+			return nil
+		}
+		targets = make([]string, len(snapshots))
+		for i, snapshot := range snapshots {
+			targets[i] = snapshot.Config
+		}
+	}
+
+	liveConfigDir, err := gcloud.ConfigDir()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	var dead []adc.VerifyResult
+	for _, target := range targets {
+		result, err := store.Verify(target, liveConfigDir, adc.GcloudExecutor, time.Now())
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		if result.Err != nil {
+			output.PrintError(fmt.Sprintf("ADC snapshot for %q is dead: %s", target, firstLine(result.Err.Error())), !o.noColor)
+			dead = append(dead, result)
+			continue
+		}
+		output.PrintSuccess(fmt.Sprintf("ADC snapshot for %q is valid", target), !o.noColor)
+	}
+
+	if len(dead) == 0 {
+		return nil
+	}
+
+	interactive := prompt.IsTerminal()
+	for _, result := range dead {
+		if interactive && confirmDeleteSnapshot(result.Config) {
+			if err := store.Delete(result.Config); err != nil {
+				output.PrintError(err.Error(), !o.noColor)
+				continue
+			}
+			output.PrintSuccess(fmt.Sprintf("deleted ADC snapshot for %q", result.Config), !o.noColor)
+			continue
+		}
+		fmt.Printf("To replace it: gcloudctx %s --sync-adc && gcloudctx adc save %s\n", result.Config, result.Config)
+	}
+
+	return fmt.Errorf("%d ADC snapshot(s) are dead", len(dead))
+}
+
+// confirmDeleteSnapshot asks whether to delete the dead snapshot of configName
+func confirmDeleteSnapshot(configName string) bool {
+	confirmed, err := prompt.Ask(os.Stdout, fmt.Sprintf("Delete the dead ADC snapshot for %q?", configName), true, false)
+	return err == nil && confirmed
+}
+
+// firstLine returns the first line of s; gcloud errors can span many lines
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+func (o *options) runADCDelete(cmd *cobra.Command, args []string) error {
+	store, err := adc.DefaultSnapshotStore()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if _, err := store.Get(args[0]); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if err := store.Delete(args[0]); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	output.PrintSuccess(fmt.Sprintf("deleted ADC snapshot for %q", args[0]), !o.noColor)
+	return nil
+}
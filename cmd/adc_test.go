@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+// setDisplay makes the machine look like a desktop, or like an SSH session
+func setDisplay(t *testing.T, ssh bool) {
+	t.Helper()
+	t.Setenv("DISPLAY", ":0")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("SSH_TTY", "")
+	if ssh {
+		t.Setenv("SSH_CONNECTION", "10.0.0.1 50000 10.0.0.2 22")
+	} else {
+		t.Setenv("SSH_CONNECTION", "")
+	}
+}
+
+func TestADCSync(t *testing.T) {
+	env := newTestEnv(t)
+	calls := env.interactiveGcloud()
+	setDisplay(t, false)
+
+	res := env.mustRun("adc", "sync")
+
+	if got := calls(); got != "auth application-default login\n" {
+		t.Errorf("gcloud ran with %q, want a login launching a browser", got)
+	}
+	if !strings.Contains(res.stdout, `Syncing Application Default Credentials for "dev"...`) || strings.Contains(res.stdout, "Note:") {
+		t.Errorf("stdout = %q, want the sync announced without a note", res.stdout)
+	}
+	// gcloud's output must not mix with gcloudctx's own on stdout
+	if strings.Contains(res.stdout, "Credentials saved") || !strings.Contains(res.stderr, "Credentials saved") {
+		t.Errorf("gcloud's output should go to stderr\nstdout: %s\nstderr: %s", res.stdout, res.stderr)
+	}
+}
+
+func TestADCSyncNoBrowser(t *testing.T) {
+	tests := []struct {
+		name     string
+		ssh      bool
+		args     []string
+		want     string
+		wantNote bool
+	}{
+		{"flag", false, []string{"adc", "sync", "--no-browser"}, "auth application-default login --no-launch-browser\n", false},
+		{"ssh", true, []string{"adc", "sync"}, "auth application-default login --no-launch-browser\n", true},
+		{"ssh with a browser", true, []string{"adc", "sync", "--no-browser=false"}, "auth application-default login\n", false},
+		{"switch over ssh", true, []string{"prod", "--sync-adc"}, "auth application-default login --no-launch-browser\n", true},
+		{"switch", false, []string{"prod", "--sync-adc", "--no-browser", "--impersonate-service-account", "sa@p.iam.gserviceaccount.com"},
+			"auth application-default login --impersonate-service-account sa@p.iam.gserviceaccount.com --no-launch-browser\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			calls := env.interactiveGcloud()
+			setDisplay(t, tt.ssh)
+
+			res := env.mustRun(tt.args...)
+
+			if got := calls(); got != tt.want {
+				t.Errorf("gcloud ran with %q, want %q", got, tt.want)
+			}
+			if note := strings.Contains(res.stdout, "Note: this is an SSH session"); note != tt.wantNote {
+				t.Errorf("stdout = %q, want a note: %v", res.stdout, tt.wantNote)
+			}
+		})
+	}
+}
+
+func TestImportSyncADCNoBrowser(t *testing.T) {
+	env := newTestEnv(t)
+	calls := env.interactiveGcloud()
+	setDisplay(t, true)
+	path := env.writeFile("staging.yaml", "name: staging\nproperties:\n  core:\n    project: staging-project\n")
+
+	res := env.mustRun("import", path, "--activate", "--sync-adc", "-o", "json")
+
+	if got := calls(); got != "auth application-default login --no-launch-browser\n" {
+		t.Errorf("gcloud ran with %q, want a login without a browser", got)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(res.stdout), "{") || strings.Contains(res.stdout, "Note:") || strings.Contains(res.stdout, "Credentials saved") {
+		t.Errorf("stdout should hold only the JSON result, got %q", res.stdout)
+	}
+	if !strings.Contains(res.stderr, "Note: this is an SSH session") {
+		t.Errorf("stderr = %q, want the note", res.stderr)
+	}
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/inflight"
+	"github.com/Okabe-Junya/gcloudctx/pkg/manifest"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
+	"github.com/spf13/cobra"
+)
+
+// applyOptions holds the flags of the apply command
+type applyOptions struct {
+	*options
+	prune  bool
+	dryRun bool
+	force  bool
+}
+
+func newApplyCmd(parent *options) *cobra.Command {
+	o := &applyOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "apply <manifest>",
+		Short: "Reconcile configurations with a team manifest",
+		Long: `Reconcile local configurations with a team manifest: a YAML or JSON list of
+configurations in the export format (name, account, project, region, zone).
+
+Missing configurations are created and properties that drifted are updated;
+a field left out of the manifest leaves the local value alone. Local
+configurations the manifest does not list are reported, and deleted with
+--prune ("default" is never pruned). --dry-run shows what would change.
+
+Pruning deletes like 'gcloudctx delete': each configuration is confirmed
+unless --force is given, protected configurations need their name typed even
+with --force, and what gcloudctx remembers about them (history, note, ADC
+hint and snapshot) is forgotten.
+
+Example manifest (team.yaml):
+
+  - name: prod
+    project: team-prod
+    region: europe-west1
+  - name: staging
+    project: team-staging
+    region: europe-west1
+
+Examples:
+  gcloudctx apply team.yaml --dry-run
+  gcloudctx apply team.yaml
+  gcloudctx apply team.yaml --prune
+  gcloudctx apply team.yaml --prune --force`,
+		Args: cobra.ExactArgs(1),
+		RunE: o.runApply,
+	}
+	cmd.Flags().BoolVar(&o.prune, "prune", false, "Delete local configurations the manifest does not list")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Show what would change without changing anything")
+	cmd.Flags().BoolVarP(&o.force, "force", "f", false, "Prune without confirmation (protected configurations still ask)")
+	return cmd
+}
+
+// loadManifestPlan reads a manifest and plans it against the local configurations
+func loadManifestPlan(path string) ([]manifest.Item, error) {
+	entries, err := manifest.Load(path, os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Plan(entries, configs), nil
+}
+
+func (o *applyOptions) runApply(cmd *cobra.Command, args []string) error {
+	items, err := loadManifestPlan(args[0])
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	// Record the creations and prunes while they run so concurrent completion
+	// and switches account for them
+	var plan *inflight.Plan
+	var store *inflight.Store
+	if !o.dryRun {
+		plan, store = beginInflightPlan(manifestInflightPlan(items, o.prune))
+		defer endInflightPlan(plan, store)
+	}
+
+	failed := 0
+	for i := range items {
+		item := &items[i]
+		result, err := o.applyManifestItem(item)
+		renewInflightPlan(plan, store)
+		if err != nil {
+			failed++
+			fmt.Printf("%s failed: %v\n", item.Name, err)
+			continue
+		}
+		fmt.Printf("%s %s\n", item.Name, result)
+	}
+
+	if failed > 0 {
+		err := fmt.Errorf("%d of %d configurations failed to apply", failed, len(items))
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	return nil
+}
+
+// manifestInflightPlan lists the configurations applying items creates, and
+// the ones it prunes when prune is set
+func manifestInflightPlan(items []manifest.Item, prune bool) inflight.Plan {
+	plan := inflight.Plan{Operation: "apply"}
+	for _, item := range items {
+		switch {
+		case item.Action == manifest.ActionCreate:
+			plan.Creates = append(plan.Creates, item.Name)
+		case item.Action == manifest.ActionExtra && prune:
+			plan.Deletes = append(plan.Deletes, item.Name)
+		}
+	}
+	return plan
+}
+
+// applyManifestItem carries out one planned item, unless --dry-run is set, and
+// describes the outcome
+func (o *applyOptions) applyManifestItem(item *manifest.Item) (string, error) {
+	switch item.Action {
+	case manifest.ActionCreate:
+		if err := gcloud.ValidateConfigurationName(item.Name); err != nil {
+			return "", err
+		}
+		if o.dryRun {
+			return "would create", nil
+		}
+		if err := gcloud.ImportConfiguration(item.Name, item.Settings()); err != nil {
+			return "", err
+		}
+		saveManifestADCHint(item)
+		return "created", nil
+
+	case manifest.ActionUpdate:
+		changes := manifest.FormatChanges(item.Changes)
+		if o.dryRun {
+			return fmt.Sprintf("would update (%s)", changes), nil
+		}
+		settings := item.Settings()
+		if err := gcloud.SetProperties(item.Name, settings); err != nil {
+			return "", err
+		}
+		if err := gcloud.VerifyProperties(item.Name, settings); err != nil {
+			return "", err
+		}
+		saveManifestADCHint(item)
+		return fmt.Sprintf("updated (%s)", changes), nil
+
+	case manifest.ActionExtra:
+		if !o.prune {
+			return "not in manifest (use --prune to delete)", nil
+		}
+		if o.dryRun {
+			return "would prune", nil
+		}
+		confirmed, err := o.confirmDeletion(item.Name, o.force)
+		if err != nil {
+			return "", err
+		}
+		if !confirmed {
+			return "kept (pruning canceled)", nil
+		}
+		if err := gcloud.DeleteConfiguration(item.Name); err != nil {
+			return "", err
+		}
+		forgetConfigurationState(item.Name)
+		return "pruned", nil
+	}
+
+	return "unchanged", nil
+}
+
+// saveManifestADCHint records how ADC is synced for a configuration the manifest manages
+func saveManifestADCHint(item *manifest.Item) {
+	if !item.Entry.SyncADC && item.Entry.ImpersonateServiceAccount == "" {
+		return
+	}
+	hint := adc.Hint{SyncADC: item.Entry.SyncADC, ImpersonateServiceAccount: item.Entry.ImpersonateServiceAccount}
+	if err := adc.SaveHint(item.Name, hint); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to save ADC hint: %w", err))
+	}
+}
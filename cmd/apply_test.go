@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/prompt"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/inflight"
+)
+
+func TestApplyPruneForgetsConfigurationState(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("sandbox", map[string]string{"core/project": "sandbox-project"})
+	if err := adc.SaveHint("sandbox", adc.Hint{ImpersonateServiceAccount: "sa@sandbox-project.iam.gserviceaccount.com"}); err != nil {
+		t.Fatal(err)
+	}
+	store, err := adc.DefaultSnapshotStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("sandbox", []byte(`{"refresh_token": "secret"}`), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	manifest := env.writeFile("team.yaml", "- name: dev\n- name: prod\n")
+
+	env.mustRun("apply", manifest, "--prune", "--force")
+
+	if slices.Contains(env.gcloud.Names(), "sandbox") {
+		t.Fatalf("configurations = %v, want sandbox pruned", env.gcloud.Names())
+	}
+	if hint, err := adc.GetHint("sandbox"); err != nil || hint != (adc.Hint{}) {
+		t.Errorf("ADC hint after prune = %+v, %v; want none", hint, err)
+	}
+	if _, err := store.Get("sandbox"); !errors.Is(err, adc.ErrNoSnapshot) {
+		t.Errorf("ADC snapshot after prune: %v, want ErrNoSnapshot", err)
+	}
+}
+
+func TestApplyPruneConfirms(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("sandbox", nil)
+	env.gcloud.Add("vault", nil)
+	env.mustRun("protect", "vault")
+	manifest := env.writeFile("team.yaml", "- name: dev\n- name: prod\n")
+
+	// --force skips the question for sandbox, but vault is protected
+	res := env.run("y\n", "apply", manifest, "--prune", "--force")
+
+	if res.err != nil {
+		t.Fatalf("apply: %v", res.err)
+	}
+	if names := env.gcloud.Names(); slices.Contains(names, "sandbox") || !slices.Contains(names, "vault") {
+		t.Errorf("configurations = %v, want sandbox pruned and vault kept", names)
+	}
+	if !strings.Contains(res.stdout, "vault kept") {
+		t.Errorf("stdout = %q, want vault reported as kept", res.stdout)
+	}
+
+	res = env.run("n\n", "apply", manifest, "--prune")
+	if res.err != nil || !slices.Contains(env.gcloud.Names(), "vault") {
+		t.Errorf("declined prune = %v, configurations %v; want vault kept", res.err, env.gcloud.Names())
+	}
+}
+
+func TestApplyPruneTypedAnswersInOrder(t *testing.T) {
+	env := newTestEnv(t)
+	for _, name := range []string{"archive", "vault"} {
+		env.gcloud.Add(name, nil)
+		env.mustRun("protect", name)
+	}
+	manifest := env.writeFile("team.yaml", "- name: dev\n- name: prod\n")
+
+	// Each protected configuration reads its own line of the piped answers
+	res := env.run("archive\nvault\n", "apply", manifest, "--prune", "--force")
+
+	if res.err != nil {
+		t.Fatalf("apply: %v", res.err)
+	}
+	if names := env.gcloud.Names(); slices.Contains(names, "archive") || slices.Contains(names, "vault") {
+		t.Errorf("configurations = %v, want archive and vault pruned", names)
+	}
+}
+
+// planRecordingTTY answers prompts with "y" and records the in-flight plans
+// active when it is asked
+type planRecordingTTY struct {
+	*strings.Reader
+	plans []inflight.Plan
+}
+
+func (p *planRecordingTTY) Read(b []byte) (int, error) {
+	if p.plans == nil {
+		store, err := inflight.DefaultStore()
+		if err != nil {
+			return 0, err
+		}
+		if p.plans, err = store.Active(); err != nil {
+			return 0, err
+		}
+	}
+	return p.Reader.Read(b)
+}
+
+func (p *planRecordingTTY) Write(b []byte) (int, error) { return len(b), nil }
+func (p *planRecordingTTY) Close() error                { return nil }
+
+func TestApplyRecordsInflightPlan(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("sandbox", nil)
+	manifest := env.writeFile("team.yaml", "- name: dev\n- name: prod\n- name: staging\n  project: staging-project\n")
+	env.terminal = false
+	tty := &planRecordingTTY{Reader: strings.NewReader("y\n")}
+	prompt.OpenTerminal = func() (io.ReadWriteCloser, error) { return tty, nil }
+
+	env.mustRun("apply", manifest, "--prune")
+
+	if len(tty.plans) != 1 {
+		t.Fatalf("in-flight plans while pruning = %+v, want the apply plan", tty.plans)
+	}
+	if plan := tty.plans[0]; plan.Operation != "apply" || !slices.Equal(plan.Creates, []string{"staging"}) || !slices.Equal(plan.Deletes, []string{"sandbox"}) {
+		t.Errorf("in-flight plan = %+v, want staging created and sandbox deleted", plan)
+	}
+	store, err := inflight.DefaultStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plans, err := store.Active(); err != nil || len(plans) != 0 {
+		t.Errorf("in-flight plans after apply = %+v, %v; want none", plans, err)
+	}
+}
@@ -2,79 +2,352 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/cache"
 	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
 	"github.com/Okabe-Junya/gcloudctx/pkg/history"
 	"github.com/Okabe-Junya/gcloudctx/pkg/local"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
-var autoCmd = &cobra.Command{
-	Use:   "auto",
-	Short: "Automatically switch to the configuration for the current directory",
-	Long: `Automatically detect and switch to the configuration specified in .gcloudctx file.
+func newAutoCmd(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auto",
+		Short: "Automatically switch to the configuration for the current directory",
+		Long: `Automatically detect and switch to the configuration specified in .gcloudctx file.
 
 This command searches for a .gcloudctx file starting from the current directory
 and walking up to the root. If found, it switches to the specified configuration.
 
+When several .gcloudctx files exist along the path (e.g. a vendored repository
+inside a monorepo), the nearest one applies. The first time files naming other
+configurations are ignored this way, a notice lists them; 'gcloudctx check'
+shows all of them at any time.
+
+A .gcloudctx file can also set the project of the active configuration, with
+or without naming a configuration:
+
+  configuration: dev
+  project: my-service
+
+The configuration and the project are looked up independently, so a file with
+only a project in a service directory keeps the configuration named farther up.
+The project it replaces is remembered, and 'gcloudctx project -' restores it.
+
 This is useful for automatically switching configurations when changing directories.
-You can add this to your shell's cd hook for automatic switching.
+You can add this to your shell's cd hook for automatic switching. When the
+configuration is already active, gcloud is not run at all, so the hook costs
+only a few file reads.
+Terminals pinned with 'gcloudctx pin-terminal' are never switched, and
+protected configurations are refused unless auto.allow_protected is set in
+~/.gcloudctx.yaml.
+
+With -o json or -o yaml, the switch result is printed on stdout and messages
+go to stderr. Nothing is printed when no .gcloudctx file is found or the
+terminal is pinned.
 
 Examples:
   gcloudctx auto              # Switch based on .gcloudctx file
+  gcloudctx auto -o json      # {"previous":"dev","current":"prod","changed":true,...}
   
   # Add to your shell for automatic switching:
   # Bash/Zsh:
   #   cd() { builtin cd "$@" && gcloudctx auto 2>/dev/null; }
   # Fish:
-  #   function cd; builtin cd $argv; and gcloudctx auto 2>/dev/null; end`,
-	Args: cobra.NoArgs,
-	RunE: runAuto,
+  #   function cd; builtin cd $argv; and gcloudctx auto 2>/dev/null; end
+  # PowerShell ($PROFILE):
+  #   function Set-LocationGcloudctx { Set-Location @args; if ($?) { gcloudctx auto 2>$null } }
+  #   Set-Alias cd Set-LocationGcloudctx -Option AllScope`,
+		Args: cobra.NoArgs,
+		RunE: o.runAuto,
+	}
+	cmd.Flags().StringVarP(&o.outputFormat, "output", "o", "", "Output format for the switch result (json, yaml)")
+	cmd.Flags().BoolVar(&o.noHooks, "no-hooks", false, "Do not run post-switch hooks")
+	cmd.Flags().BoolVar(&o.noHistory, "no-history", false, "Do not record the switch in the history or usage statistics")
+	cmd.Flags().BoolVar(&o.forceSwitch, "force", false, "Switch even when a pre-switch guard refuses")
+	return cmd
 }
 
-func init() {
-	rootCmd.AddCommand(autoCmd)
-}
+func (o *options) runAuto(cmd *cobra.Command, args []string) error {
+	format, machineOutput, err := o.switchOutputFormat()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	// Hook output goes to stderr when stdout carries the result
+	messages := os.Stdout
+	if machineOutput {
+		messages = os.Stderr
+	}
 
-func runAuto(cmd *cobra.Command, args []string) error {
-	// Find local config
-	configName, dir, err := local.FindLocalConfig()
+	// A pinned terminal ignores .gcloudctx files
+	if pin := terminalPin(); pin != nil {
+		touchTerminalPin()
+		o.printPinnedNotice(pin)
+		return nil
+	}
+
+	// Find local config; the nearest file applies and shadows the others
+	matches, err := local.FindLocalConfigs()
 	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if len(matches) == 0 {
 		// Silent fail - this is expected when no .gcloudctx file exists
 		return nil
 	}
+	rememberMatchDirs(matches)
+	effective, shadowed := local.ClassifyShadowing(matches)
+	if effective.Err != nil {
+		output.PrintError(effective.Err.Error(), !o.noColor)
+		return effective.Err
+	}
+	projectFile, setsProject := local.EffectiveProject(matches)
 
-	// Check if configuration exists
-	if !gcloud.ConfigurationExists(configName) {
-		output.PrintError(fmt.Sprintf("configuration %q (from %s/.gcloudctx) does not exist", configName, dir), !noColorFlag)
-		return fmt.Errorf("configuration not found")
+	if disagreeing := local.Disagreeing(shadowed); len(disagreeing) > 0 {
+		o.printShadowNoticeOnce(effective, disagreeing)
 	}
 
-	// Get current configuration
-	currentConfig, err := gcloud.GetActiveConfiguration()
+	// Serialize with switches in other terminals and read the active
+	// configuration under the lock
+	lock, err := lockState()
 	if err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
+	defer lock.Unlock()
 
-	// Already on the target configuration
-	if currentConfig.Name == configName {
-		return nil
+	// The active configuration is read from gcloud's state files, so a
+	// shell hook staying on the same configuration never runs gcloud
+	currentName, err := gcloud.ActiveConfigName()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
 	}
 
-	// Save current configuration to history
-	if err := history.SavePreviousConfig(currentConfig.Name); err != nil {
-		// Non-fatal error, just warn
-		fmt.Printf("Warning: failed to save history: %v\n", err)
+	result := &output.SwitchResult{Previous: currentName, Current: currentName}
+	if effective.Config != "" && effective.Config != currentName {
+		if result, err = o.autoSwitch(effective, messages); err != nil {
+			return err
+		}
+	}
+	if setsProject {
+		if err := o.autoSetProject(projectFile, result, messages); err != nil {
+			return err
+		}
+	}
+
+	if machineOutput {
+		return output.PrintSwitchResult(result, format)
+	}
+	return nil
+}
+
+// autoSwitch switches to the configuration named by the .gcloudctx file in effect
+// Errors are printed before they are returned
+func (o *options) autoSwitch(effective local.Match, messages io.Writer) (*output.SwitchResult, error) {
+	configName, dir := effective.Config, effective.Dir
+
+	// Check if configuration exists
+	exists, err := configurationExists(configName)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return nil, err
+	}
+	if !exists {
+		output.PrintError(fmt.Sprintf("configuration %q (from %s/.gcloudctx) does not exist", configName, dir), !o.noColor)
+		return nil, fmt.Errorf("configuration not found")
+	}
+
+	// Nobody is asked to confirm an automatic switch, so protected
+	// configurations are off limits unless explicitly allowed
+	if o.isProtectedConfiguration(configName) && !o.autoAllowProtected {
+		err := fmt.Errorf("configuration %q (from %s/.gcloudctx) is protected; switch with 'gcloudctx %s' or set auto.allow_protected in ~/.gcloudctx.yaml", configName, dir, configName)
+		output.PrintError(err.Error(), !o.noColor)
+		return nil, err
+	}
+
+	currentConfig, targetConfig, err := gcloud.ResolveSwitch(configName)
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return nil, err
+	}
+	if targetConfig == nil {
+		err := fmt.Errorf("configuration %q not found", configName)
+		output.PrintError(err.Error(), !o.noColor)
+		return nil, err
+	}
+
+	// Guards run before anything changes, so a refused switch records nothing
+	if err := o.runPreSwitchGuards(currentConfig.Name, targetConfig, messages); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return nil, err
 	}
 
 	// Activate the target configuration
 	if err := gcloud.ActivateConfiguration(configName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
+		return nil, err
+	}
+
+	// Hooks run before history is written so a rolled back switch leaves no trace
+	if err := o.runPostSwitchHooks(currentConfig.Name, targetConfig, messages); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return nil, err
+	}
+
+	// Save the previous configuration to history
+	o.recordSwitch(currentConfig.Name, configName)
+
+	output.PrintSuccess(fmt.Sprintf("switched to configuration %s (from %s)", output.StyledName(configName), dir), !o.noColor)
+
+	return &output.SwitchResult{
+		Previous: currentConfig.Name,
+		Current:  configName,
+		Changed:  true,
+		Diff:     gcloud.DiffConfigurations(currentConfig, targetConfig),
+	}, nil
+}
+
+// autoSetProject sets the project named by the .gcloudctx file in effect on the
+// active configuration, recording the project it replaces for 'gcloudctx project -'
+// Changing the project goes through the same protection and pre-switch guards
+// as switching configurations. Errors are printed before they are returned.
+func (o *options) autoSetProject(projectFile local.Match, result *output.SwitchResult, messages io.Writer) error {
+	project, dir := projectFile.Project, projectFile.Dir
+
+	// The project is read from gcloud's files, so staying on it never runs gcloud
+	active, err := gcloud.GetActiveConfigurationFast()
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	currentProject := active.Properties.Core.Project
+	if currentProject == project {
+		return nil
+	}
+
+	if o.isProtectedConfiguration(active.Name) && !o.autoAllowProtected {
+		err := fmt.Errorf("configuration %q is protected; set project %q (from %s/.gcloudctx) with 'gcloudctx project %s' or set auto.allow_protected in ~/.gcloudctx.yaml", active.Name, project, dir, project)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
-	output.PrintSuccess(fmt.Sprintf("switched to configuration %q (from %s)", configName, dir), !noColorFlag)
+	// Guards see the configuration staying the same with the new project
+	target := *active
+	target.Properties.Core.Project = project
+	if err := o.runPreSwitchGuards(active.Name, &target, messages); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if err := gcloud.SetProject(project); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if currentProject != "" {
+		if err := history.SavePreviousProject(active.Name, currentProject); err != nil {
+			// Non-fatal error, just warn
+			statedir.Warn(os.Stderr, fmt.Errorf("failed to save project history: %w", err))
+		}
+	}
+
+	output.PrintSuccess(fmt.Sprintf("set project to %q (from %s; 'gcloudctx project -' restores %s)", project, dir, describeProject(currentProject)), !o.noColor)
+
+	result.Changed = true
+	for i := range result.Diff {
+		if result.Diff[i].Property == "core/project" {
+			result.Diff[i].To = project
+			return nil
+		}
+	}
+	result.Diff = append(result.Diff, gcloud.PropertyDiff{Property: "core/project", From: currentProject, To: project})
 	return nil
 }
+
+// describeProject names a project in a message, or says there is none
+func describeProject(project string) string {
+	if project == "" {
+		return "no project"
+	}
+	return fmt.Sprintf("%q", project)
+}
+
+// rememberMatchDirs remembers the directories of readable .gcloudctx files for 'gcloudctx jump'
+func rememberMatchDirs(matches []local.Match) {
+	var dirs []string
+	for _, match := range matches {
+		if match.Err == nil {
+			dirs = append(dirs, match.Dir)
+		}
+	}
+	if len(dirs) > 0 {
+		rememberJumpDirs(dirs...)
+	}
+}
+
+// configurationExists reports whether the named configuration exists
+// Its file answers without running gcloud; gcloud is only asked when there is none
+func configurationExists(name string) (bool, error) {
+	if exists, err := gcloud.ConfigurationFileExists(name); err == nil && exists {
+		return true, nil
+	}
+	return gcloud.ConfigurationExists(name)
+}
+
+// shadowNoticeCacheKey records the shadowing layouts auto has already reported
+const shadowNoticeCacheKey = "shadow-notices"
+
+// shadowNoticeTTL is how long a reported layout stays quiet
+const shadowNoticeTTL = 365 * 24 * time.Hour
+
+// printShadowNoticeOnce tells the user (dimmed, on stderr) which .gcloudctx files
+// are ignored in favor of effective, once per combination of files and configurations
+func (o *options) printShadowNoticeOnce(effective local.Match, disagreeing []local.Shadowed) {
+	parts := []string{effective.Path() + "=" + effective.Config}
+	for _, s := range disagreeing {
+		parts = append(parts, s.Path()+"="+s.Config)
+	}
+	signature := strings.Join(parts, "\x00")
+
+	seen := map[string]bool{}
+	if _, err := cache.Load(shadowNoticeCacheKey, shadowNoticeTTL, &seen); err == nil && seen[signature] {
+		return
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	gray := color.New(color.FgHiBlack).SprintFunc()
+	fmt.Fprintln(os.Stderr, gray(fmt.Sprintf("using %q from %s; ignoring:", effective.Config, effective.Path())))
+	for _, s := range disagreeing {
+		fmt.Fprintln(os.Stderr, gray("  "+describeShadowed(s)))
+	}
+	fmt.Fprintln(os.Stderr, gray("(shown once; run 'gcloudctx check' to see this again)"))
+
+	seen[signature] = true
+	if err := cache.Save(shadowNoticeCacheKey, seen); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to save notice state: %w", err))
+	}
+}
+
+// describeShadowed describes a shadowed .gcloudctx file and the configuration it names
+func describeShadowed(s local.Shadowed) string {
+	if s.Err != nil {
+		return fmt.Sprintf("%s (unreadable)", s.Path())
+	}
+	return fmt.Sprintf("%s (%s)", s.Path(), s.Config)
+}
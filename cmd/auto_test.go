@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/session"
+)
+
+// autoNoOpBudget is how long 'gcloudctx auto' may take when nothing changes,
+// so that it can run on every prompt or cd without being noticed
+const autoNoOpBudget = 50 * time.Millisecond
+
+func TestAutoNoOpRunsNoGcloud(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeFile(".gcloudctx", "dev\n")
+
+	const runs = 20
+	timings := make([]time.Duration, runs)
+	for i := range timings {
+		start := time.Now()
+		env.mustRun("auto")
+		timings[i] = time.Since(start)
+	}
+
+	if calls := env.gcloud.Calls(); len(calls) != 0 {
+		t.Errorf("no-op auto ran gcloud %d times: %q", len(calls), calls)
+	}
+
+	slices.Sort(timings)
+	median := timings[runs/2]
+	t.Logf("no-op auto over %d runs: median %s, slowest %s (budget %s)", runs, median, timings[runs-1], autoNoOpBudget)
+	if median > autoNoOpBudget {
+		t.Errorf("no-op auto took %s, want under %s", median, autoNoOpBudget)
+	}
+}
+
+func TestAutoSwitch(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeFile(".gcloudctx", "prod\n")
+
+	res := env.mustRun("auto")
+
+	if env.gcloud.Active() != "prod" {
+		t.Errorf("active = %q, want prod", env.gcloud.Active())
+	}
+	if !strings.Contains(res.stdout, `switched to configuration "prod"`) {
+		t.Errorf("stdout = %q, want the switch reported", res.stdout)
+	}
+	want := []string{
+		"config configurations list --format=json",
+		"config configurations activate prod",
+	}
+	if calls := env.gcloud.Calls(); !slices.Equal(calls, want) {
+		t.Errorf("auto ran %q, want %q", calls, want)
+	}
+}
+
+func TestAutoJSON(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeFile(".gcloudctx", "prod\n")
+
+	res := env.mustRun("auto", "-o", "json")
+
+	var switched output.SwitchResult
+	if err := json.Unmarshal([]byte(res.stdout), &switched); err != nil {
+		t.Fatalf("stdout is not a switch result: %v\n%s", err, res.stdout)
+	}
+	if switched.Previous != "dev" || switched.Current != "prod" || !switched.Changed || len(switched.Diff) != 2 {
+		t.Errorf("switch result = %+v, want dev to prod with two changed properties", switched)
+	}
+	if !strings.Contains(res.stderr, "switched to configuration") {
+		t.Errorf("stderr = %q, want the message", res.stderr)
+	}
+}
+
+func TestAutoWithoutFile(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("auto")
+
+	if res.stdout != "" || env.gcloud.Active() != "dev" {
+		t.Errorf("auto without .gcloudctx printed %q and activated %q", res.stdout, env.gcloud.Active())
+	}
+}
+
+func TestAutoMissingConfiguration(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeFile(".gcloudctx", "staging\n")
+
+	res := env.run("", "auto")
+
+	if res.err == nil {
+		t.Fatal("auto succeeded for a missing configuration")
+	}
+	if !strings.Contains(res.stdout, `configuration "staging"`) || !strings.Contains(res.stdout, "does not exist") {
+		t.Errorf("stdout = %q, want the missing configuration named", res.stdout)
+	}
+}
+
+func TestAutoListFailure(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeFile(".gcloudctx", "staging\n")
+	env.gcloud.Fail("config configurations list", "ERROR: (gcloud.config.configurations.list) Unable to read the configurations directory.")
+
+	res := env.run("", "auto")
+
+	if res.err == nil {
+		t.Fatal("auto succeeded although gcloud failed")
+	}
+	if strings.Contains(res.stdout, "does not exist") || !strings.Contains(res.stdout, "Unable to read the configurations directory") {
+		t.Errorf("stdout = %q, want gcloud's failure instead of a missing configuration", res.stdout)
+	}
+}
+
+func TestAutoSetsProject(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeFile(".gcloudctx", "project: my-service\n")
+
+	res := env.mustRun("auto")
+
+	if props, _ := env.gcloud.Properties("dev"); props["core/project"] != "my-service" || env.gcloud.Active() != "dev" {
+		t.Errorf("dev has project %q and %q is active, want my-service on dev", props["core/project"], env.gcloud.Active())
+	}
+	if !strings.Contains(res.stdout, `set project to "my-service"`) {
+		t.Errorf("stdout = %q, want the project change reported", res.stdout)
+	}
+
+	// Staying on the project runs no gcloud
+	before := len(env.gcloud.Calls())
+	env.mustRun("auto")
+	if calls := env.gcloud.Calls()[before:]; len(calls) != 0 {
+		t.Errorf("no-op auto ran %q", calls)
+	}
+
+	// The project it replaced is one 'gcloudctx project -' away
+	env.mustRun("project", "-")
+	if props, _ := env.gcloud.Properties("dev"); props["core/project"] != "dev-project" {
+		t.Errorf("after 'project -' dev has project %q, want dev-project", props["core/project"])
+	}
+}
+
+func TestAutoSwitchesAndSetsProject(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeFile(".gcloudctx", "configuration: prod\nproject: prod-service\n")
+
+	res := env.mustRun("auto", "-o", "json")
+
+	var switched output.SwitchResult
+	if err := json.Unmarshal([]byte(res.stdout), &switched); err != nil {
+		t.Fatalf("stdout is not a switch result: %v\n%s", err, res.stdout)
+	}
+	if switched.Previous != "dev" || switched.Current != "prod" || !switched.Changed {
+		t.Errorf("switch result = %+v, want dev to prod", switched)
+	}
+	for _, diff := range switched.Diff {
+		if diff.Property == "core/project" && (diff.From != "dev-project" || diff.To != "prod-service") {
+			t.Errorf("project diff = %+v, want dev-project to prod-service", diff)
+		}
+	}
+	if props, _ := env.gcloud.Properties("prod"); env.gcloud.Active() != "prod" || props["core/project"] != "prod-service" {
+		t.Errorf("%q is active and prod has project %q, want prod with prod-service", env.gcloud.Active(), props["core/project"])
+	}
+}
+
+func TestAutoProjectOnProtectedConfiguration(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("protect", "dev")
+	env.writeFile(".gcloudctx", "project: my-service\n")
+
+	res := env.run("", "auto")
+
+	if res.err == nil || !strings.Contains(res.stdout, "is protected") {
+		t.Errorf("auto = %v, stdout %q; want the protected configuration refused", res.err, res.stdout)
+	}
+	if props, _ := env.gcloud.Properties("dev"); props["core/project"] != "dev-project" {
+		t.Errorf("dev has project %q, want it unchanged", props["core/project"])
+	}
+}
+
+func TestAutoKeepsPinInUse(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeFile(".gcloudctx", "prod\n")
+	t.Setenv(session.EnvSession, "tty1")
+
+	// Pinned a day ago and last seen two hours ago: not expired yet
+	pinnedAt := time.Now().Add(-session.DefaultTTL + time.Minute)
+	lastSeen := time.Now().Add(-2 * time.Hour)
+	pins, err := json.Marshal(map[string]session.Pin{"tty1": {Config: "dev", PinnedAt: pinnedAt, LastSeen: lastSeen}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := session.GetPinsFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pins, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	res := env.mustRun("auto")
+
+	if env.gcloud.Active() != "dev" || !strings.Contains(res.stderr, "terminal pinned") {
+		t.Fatalf("auto in a pinned terminal: active %q, stderr %q; want no switch", env.gcloud.Active(), res.stderr)
+	}
+	store, err := session.DefaultPinStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pin, err := store.Get("tty1")
+	if err != nil || pin == nil || !pin.LastSeen.After(lastSeen.Add(time.Hour)) {
+		t.Errorf("pin after auto = %+v, %v; want it seen now", pin, err)
+	}
+}
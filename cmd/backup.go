@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/backup"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/spf13/cobra"
+)
+
+// backupOptions holds the flags of the backup command
+type backupOptions struct {
+	*options
+	outputPath string
+	includeADC bool
+}
+
+func newBackupCmd(parent *options) *cobra.Command {
+	o := &backupOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "backup -o <file>",
+		Short: "Archive all configurations and gcloudctx state",
+		Long: `Write a .tar.gz archive holding every configuration (gcloud's properties
+file as it is, and the export file format), the active configuration, and
+gcloudctx's own state: history, settings, notes, terminal pins, and ADC hints.
+Restore it with 'gcloudctx restore'.
+
+With --include-adc, the Application Default Credentials file is archived too.
+It contains a refresh token: keep the archive private. The archive is written
+readable only by you.
+
+Examples:
+  gcloudctx backup -o gcloudctx-backup.tar.gz
+  gcloudctx backup -o gcloudctx-backup.tar.gz --include-adc`,
+		Args: cobra.NoArgs,
+		RunE: o.runBackup,
+	}
+	cmd.Flags().StringVarP(&o.outputPath, "output", "o", "", "Archive file to write (required)")
+	cmd.Flags().BoolVar(&o.includeADC, "include-adc", false, "Also archive Application Default Credentials")
+	_ = cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+func (o *backupOptions) runBackup(cmd *cobra.Command, args []string) error {
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	archive := &backup.Archive{
+		Manifest:   backup.Manifest{CreatedAt: time.Now().UTC()},
+		Properties: map[string][]byte{},
+		State:      map[string][]byte{},
+	}
+	for i := range configs {
+		if configs[i].IsActive {
+			archive.Manifest.Active = configs[i].Name
+		}
+		archive.Configurations = append(archive.Configurations, buildExportConfig(&configs[i]))
+
+		// Names restore would skip cannot be read from the configurations directory either
+		if gcloud.ValidateExistingConfigurationName(configs[i].Name) != nil {
+			continue
+		}
+		properties, err := gcloud.ReadConfigurationFile(configs[i].Name)
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		if properties != nil {
+			archive.Properties[configs[i].Name] = properties
+		}
+	}
+
+	for _, entry := range ownedStateFiles {
+		if !entry.portable {
+			continue
+		}
+		path, err := entry.path()
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			output.PrintError(fmt.Sprintf("failed to read %s: %v", entry.description, err), !o.noColor)
+			return err
+		}
+		archive.State[filepath.Base(path)] = data
+	}
+
+	if o.includeADC {
+		adcPath, err := adcFilePath()
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		data, err := os.ReadFile(adcPath)
+		if err != nil {
+			output.PrintError(fmt.Sprintf("failed to read Application Default Credentials: %v", err), !o.noColor)
+			return err
+		}
+		archive.ADC = data
+	}
+
+	var buf bytes.Buffer
+	if err := backup.Write(&buf, archive); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if err := backup.WritePrivateFile(o.outputPath, buf.Bytes()); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	summary := fmt.Sprintf("backed up %d configuration(s) and %d state file(s) to %s", len(archive.Configurations), len(archive.State), o.outputPath)
+	if archive.ADC != nil {
+		summary += " (including ADC)"
+	}
+	output.PrintSuccess(summary, !o.noColor)
+	return nil
+}
+
+// adcFilePath returns the path of the Application Default Credentials file
+func adcFilePath() (string, error) {
+	dir, err := gcloud.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, gcloud.ADCFileName), nil
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/backup"
+)
+
+func TestBackupRestoreKeepsEveryProperty(t *testing.T) {
+	env := newTestEnv(t)
+	prod := map[string]string{
+		"core/project":                     "prod-project",
+		"core/account":                     "ops@example.com",
+		"auth/impersonate_service_account": "deployer@prod-project.iam.gserviceaccount.com",
+		"proxy/address":                    "proxy.internal",
+		"api_endpoint_overrides/compute":   "https://compute.internal/",
+		"billing/quota_project":            "billing-project",
+		"snacks/flavor":                    "salty",
+	}
+	env.gcloud.Add("prod", prod)
+	archive := filepath.Join(env.workDir, "backup.tar.gz")
+	env.mustRun("backup", "-o", archive)
+
+	// Restoring over the changed configuration brings back every archived property
+	env.gcloud.Add("prod", map[string]string{"core/project": "other-project", "compute/region": "us-east1"})
+	env.mustRun("restore", archive, "--yes")
+
+	got, ok := env.gcloud.Properties("prod")
+	if !ok || !maps.Equal(got, prod) {
+		t.Errorf("prod after restore = %v, want %v", got, prod)
+	}
+}
+
+func TestBackupStateFilesAreRestorable(t *testing.T) {
+	newTestEnv(t)
+
+	for _, entry := range ownedStateFiles {
+		if !entry.portable {
+			continue
+		}
+		path, err := entry.path()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := filepath.Base(path); !slices.Contains(backup.StateFiles, name) {
+			t.Errorf("%s (%s) is backed up but not in backup.StateFiles", entry.description, name)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/interactive"
+)
+
+// bootstrapHint tells the user how to create a first configuration
+const bootstrapHint = "create one with 'gcloudctx create default --activate' or run 'gcloud init'"
+
+// withBootstrapHint adds bootstrapHint to the error of a command that found no
+// configurations at all, as on a machine where 'gcloud init' never ran
+func withBootstrapHint(err error) error {
+	if errors.Is(err, gcloud.ErrNoConfigurations) {
+		return fmt.Errorf("%w; %s", err, bootstrapHint)
+	}
+	return err
+}
+
+// offerBootstrap is what a bare gcloudctx does when gcloud has no configurations
+// On a terminal it offers to set up the first one; otherwise it says how to
+func (o *options) offerBootstrap() error {
+	if !interactive.IsTerminal() {
+		err := fmt.Errorf("gcloud has no configurations yet; %s", bootstrapHint)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	return o.bootstrap(bufio.NewReader(os.Stdin), os.Stderr)
+}
+
+// bootstrap creates and activates a first configuration with the answers read
+// from in, then optionally logs in with 'gcloud auth login' and sets the project
+// Prompts go to out
+func (o *options) bootstrap(in *bufio.Reader, out io.Writer) error {
+	fmt.Fprintln(out, "gcloud has no configurations yet.")
+	if !askYesNo(in, out, "Create one now? (Y/n): ") {
+		fmt.Fprintf(out, "To do it later, %s.\n", bootstrapHint)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Configuration name [%s]: ", gcloud.ReservedConfigurationName)
+	name, ok := readAnswer(in)
+	if !ok {
+		return nil
+	}
+	if name == "" {
+		name = gcloud.ReservedConfigurationName
+	}
+	if err := gcloud.ValidateConfigurationName(name); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if err := gcloud.CreateConfiguration(name); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if err := gcloud.ActivateConfiguration(name); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	recordUsage(name)
+	output.PrintSuccess(fmt.Sprintf("created and activated configuration %q", name), !o.noColor)
+
+	if askYesNo(in, out, "Log in with 'gcloud auth login' now? (Y/n): ") {
+		if err := gcloud.Login(); err != nil {
+			// Non-fatal error, just warn
+			fmt.Fprintf(os.Stderr, "Warning: %v; run 'gcloud auth login' later\n", err)
+		}
+	}
+
+	fmt.Fprint(out, "Project ID (leave empty to skip): ")
+	if project, _ := readAnswer(in); project != "" {
+		if err := gcloud.SetProject(project); err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		output.PrintSuccess(fmt.Sprintf("set project to %q", project), !o.noColor)
+	}
+	return nil
+}
+
+// askYesNo asks a question answered with yes unless the answer starts with n
+// End of input answers no
+func askYesNo(in *bufio.Reader, out io.Writer, question string) bool {
+	fmt.Fprint(out, question)
+	answer, ok := readAnswer(in)
+	return ok && !strings.HasPrefix(strings.ToLower(answer), "n")
+}
+
+// readAnswer reads one line of input, trimmed; ok is false at the end of input
+func readAnswer(in *bufio.Reader) (answer string, ok bool) {
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", false
+	}
+	return strings.TrimSpace(line), true
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+func TestNoConfigurationsHint(t *testing.T) {
+	tests := [][]string{
+		{},
+		{"-c"},
+		{"--info"},
+		{"prod"},
+		{"project"},
+		{"project", "my-project"},
+		{"env"},
+		{"export"},
+	}
+
+	for _, args := range tests {
+		t.Run(strings.Join(append([]string{"gcloudctx"}, args...), " "), func(t *testing.T) {
+			env := newEmptyTestEnv(t)
+
+			res := env.run("", args...)
+
+			if res.err == nil {
+				t.Fatal("command succeeded without any configuration")
+			}
+			if printed := res.stdout + res.stderr; !strings.Contains(printed, "gcloudctx create default --activate") {
+				t.Errorf("output = %q, want it to point at creating a configuration", printed)
+			}
+		})
+	}
+}
+
+func TestListNoConfigurations(t *testing.T) {
+	env := newEmptyTestEnv(t)
+
+	res := env.mustRun("-l")
+
+	if !strings.Contains(res.stdout, "No configurations found") || !strings.Contains(res.stdout, "gcloudctx create default --activate") {
+		t.Errorf("stdout = %q, want the hint", res.stdout)
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	env := newEmptyTestEnv(t)
+
+	// Create, name it staging, skip the login, set a project
+	runBootstrap(t, "\nstaging\nn\nmy-project\n")
+
+	if env.gcloud.Active() != "staging" {
+		t.Errorf("active = %q, want staging", env.gcloud.Active())
+	}
+	if properties, _ := env.gcloud.Properties("staging"); properties["core/project"] != "my-project" {
+		t.Errorf("staging properties = %v, want project my-project", properties)
+	}
+	if slices.ContainsFunc(env.gcloud.Calls(), func(call string) bool { return strings.HasPrefix(call, "auth") }) {
+		t.Errorf("gcloud calls = %q, want no login after declining it", env.gcloud.Calls())
+	}
+}
+
+func TestBootstrapDefaults(t *testing.T) {
+	env := newEmptyTestEnv(t)
+
+	// Accept creating it under the default name, then end of input
+	runBootstrap(t, "y\n\n")
+
+	if env.gcloud.Active() != "default" {
+		t.Errorf("active = %q, want default", env.gcloud.Active())
+	}
+}
+
+func TestBootstrapDeclined(t *testing.T) {
+	for _, input := range []string{"n\n", ""} {
+		env := newEmptyTestEnv(t)
+
+		prompts := runBootstrap(t, input)
+
+		if names := env.gcloud.Names(); len(names) != 0 {
+			t.Errorf("bootstrap(%q) created %v, want nothing", input, names)
+		}
+		if !strings.Contains(prompts, "gcloudctx create default --activate") {
+			t.Errorf("bootstrap(%q) printed %q, want the hint", input, prompts)
+		}
+	}
+}
+
+// runBootstrap answers the bootstrap prompts with input and returns the prompts
+func runBootstrap(t *testing.T, input string) string {
+	t.Helper()
+	output.SetMessageOutput(io.Discard)
+	t.Cleanup(func() { output.SetMessageOutput(os.Stdout) })
+
+	var prompts strings.Builder
+	if err := (&options{noColor: true}).bootstrap(bufio.NewReader(strings.NewReader(input)), &prompts); err != nil {
+		t.Fatalf("bootstrap(%q) error = %v", input, err)
+	}
+	return prompts.String()
+}
+
+func TestDoctor(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("doctor")
+
+	for _, want := range []string{"active configuration", "dev", "dev-project", "dev@example.com", "ready to use"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("stdout = %q, want it to mention %q", res.stdout, want)
+		}
+	}
+}
+
+func TestDoctorNoConfigurations(t *testing.T) {
+	env := newEmptyTestEnv(t)
+
+	res := env.run("", "doctor")
+
+	if res.err == nil {
+		t.Fatal("doctor succeeded without any configuration")
+	}
+	if !strings.Contains(res.stdout, "'gcloud init' never ran") || !strings.Contains(res.stdout, "gcloudctx create default --activate") {
+		t.Errorf("stdout = %q, want the bootstrap state called out", res.stdout)
+	}
+}
+
+func TestDoctorConfigDirMismatch(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.ReportConfigDir("/snap/google-cloud-cli/config")
+
+	res := env.mustRun("doctor")
+
+	if !strings.Contains(res.stdout, "/snap/google-cloud-cli/config (expected "+env.configDir+")") || !strings.Contains(res.stdout, "warning") {
+		t.Errorf("stdout = %q, want a warning about the directory gcloud reported", res.stdout)
+	}
+}
+
+func TestDoctorGcloudOverride(t *testing.T) {
+	env := newTestEnv(t)
+	override := filepath.Join(t.TempDir(), "gcloud")
+	if err := os.WriteFile(override, []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(gcloud.EnvGcloudPath, override)
+
+	res := env.mustRun("doctor")
+
+	for _, want := range []string{override + " ($GCLOUDCTX_GCLOUD_PATH)", "other gcloud installs"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("stdout = %q, want it to mention %q", res.stdout, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/local"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func newCheckCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "check",
+		Short: "Check the .gcloudctx files that apply to the current directory",
+		Long: `List every .gcloudctx file from the current directory up to the root and
+report problems with them.
+
+The nearest file is in effect; files farther up are shadowed. Shadowed files
+naming a different configuration are flagged, since 'gcloudctx auto' ignores
+them and this often means a nested repository overrides its parent. The command
+fails when a file is unreadable, the configuration in effect does not exist, or
+shadowed files disagree with the one in effect.
+
+Files that only set a project are listed too; the nearest one sets the project
+whatever file names the configuration.
+
+Examples:
+  gcloudctx check`,
+		Args: cobra.NoArgs,
+		RunE: o.runCheck,
+	}
+}
+
+func (o *options) runCheck(cmd *cobra.Command, args []string) error {
+	matches, err := local.FindLocalConfigs()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Printf("No %s file applies to this directory\n", local.ConfigFileName)
+		return nil
+	}
+
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	exists := map[string]bool{}
+	for _, config := range configs {
+		exists[config.Name] = true
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	gray := color.New(color.FgHiBlack).SprintFunc()
+
+	problems := 0
+	effective, shadowed := local.ClassifyShadowing(matches)
+	disagrees := map[string]bool{}
+	for _, s := range shadowed {
+		disagrees[s.Dir] = s.Disagrees
+	}
+	projectFile, _ := local.EffectiveProject(matches)
+
+	orDash := func(value string) string {
+		if value == "" {
+			return "-"
+		}
+		return value
+	}
+
+	rows := [][]string{{"FILE", "CONFIGURATION", "PROJECT", "STATUS"}}
+	for _, match := range matches {
+		row := []string{match.Path(), orDash(match.Config), orDash(match.Project)}
+		inEffect := match.Dir == effective.Dir || (match.Config == "" && match.Dir == projectFile.Dir)
+		switch {
+		case match.Err != nil && inEffect:
+			row = append(row, red("in effect, unreadable"))
+			problems++
+		case match.Err != nil:
+			row = append(row, red("shadowed, unreadable"))
+			problems++
+		case match.Config == "" && inEffect:
+			// Files that only set a project do not shadow configurations
+			row = append(row, green("in effect"))
+		case match.Config == "":
+			row = append(row, gray("shadowed"))
+		case inEffect && !exists[match.Config]:
+			row = append(row, red("in effect, configuration does not exist"))
+			problems++
+		case inEffect:
+			row = append(row, green("in effect"))
+		case disagrees[match.Dir]:
+			row = append(row, red("shadowed, disagrees"))
+			problems++
+		default:
+			row = append(row, gray("shadowed, agrees"))
+		}
+		rows = append(rows, row)
+	}
+
+	for _, line := range output.AlignColumns(rows, 2) {
+		fmt.Println(line)
+	}
+	// Explain unreadable files below the table
+	separated := false
+	for _, match := range matches {
+		if match.Err == nil {
+			continue
+		}
+		if !separated {
+			fmt.Println()
+			separated = true
+		}
+		output.PrintError(match.Err.Error(), !o.noColor)
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("found %d problem(s) with %s files", problems, local.ConfigFileName)
+	}
+	output.PrintSuccess(fmt.Sprintf("%s files are consistent", local.ConfigFileName), !o.noColor)
+	return nil
+}
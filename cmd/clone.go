@@ -8,14 +8,18 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var (
-	cloneActivateFlag bool
-)
+// cloneOptions holds the flags of the clone command
+type cloneOptions struct {
+	*options
+	activate bool
+}
 
-var cloneCmd = &cobra.Command{
-	Use:   "clone <source-name> <target-name>",
-	Short: "Clone an existing gcloud configuration",
-	Long: `Clone an existing gcloud configuration to create a new one.
+func newCloneCmd(parent *options) *cobra.Command {
+	o := &cloneOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "clone <source-name> <target-name>",
+		Short: "Clone an existing gcloud configuration",
+		Long: `Clone an existing gcloud configuration to create a new one.
 
 This creates a new configuration with all properties copied from the source.
 The source configuration remains unchanged.
@@ -23,14 +27,12 @@ The source configuration remains unchanged.
 Examples:
   gcloudctx clone production production-test
   gcloudctx clone my-config my-config-backup --activate`,
-	Args:              cobra.ExactArgs(2),
-	RunE:              runClone,
-	ValidArgsFunction: completeConfigNamesForClone,
-}
-
-func init() {
-	cloneCmd.Flags().BoolVar(&cloneActivateFlag, "activate", false, "Activate the newly cloned configuration")
-	rootCmd.AddCommand(cloneCmd)
+		Args:              cobra.ExactArgs(2),
+		RunE:              o.runClone,
+		ValidArgsFunction: completeConfigNamesForClone,
+	}
+	cmd.Flags().BoolVar(&o.activate, "activate", false, "Activate the newly cloned configuration")
+	return cmd
 }
 
 // completeConfigNamesForClone provides completion for clone command
@@ -40,44 +42,48 @@ func completeConfigNamesForClone(cmd *cobra.Command, args []string, toComplete s
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
-	configs, err := gcloud.ListConfigurations()
+	configs, err := completionConfigurations()
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
 	var names []string
-	for _, config := range configs {
-		names = append(names, config.Name)
+	for i := range configs {
+		names = append(names, output.CompletionEntry(&configs[i]))
 	}
 
-	return names, cobra.ShellCompDirectiveNoFileComp
+	return pendingOperations().FilterCompletions(names), cobra.ShellCompDirectiveNoFileComp
 }
 
-func runClone(cmd *cobra.Command, args []string) error {
+func (o *cloneOptions) runClone(cmd *cobra.Command, args []string) error {
 	sourceName := args[0]
 	targetName := args[1]
 
 	// Validate target configuration name before making gcloud calls
 	if err := gcloud.ValidateConfigurationName(targetName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
 	// Clone the configuration
-	if err := gcloud.CloneConfiguration(sourceName, targetName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+	stop := output.StartSpinner(fmt.Sprintf("Cloning %s...", sourceName))
+	err := gcloud.CloneConfiguration(sourceName, targetName)
+	stop()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
-	output.PrintSuccess(fmt.Sprintf("cloned configuration %q to %q", sourceName, targetName), !noColorFlag)
+	output.PrintSuccess(fmt.Sprintf("cloned configuration %q to %q", sourceName, targetName), !o.noColor)
 
 	// Activate if requested
-	if cloneActivateFlag {
+	if o.activate {
 		if err := gcloud.ActivateConfiguration(targetName); err != nil {
-			output.PrintError(err.Error(), !noColorFlag)
+			output.PrintError(err.Error(), !o.noColor)
 			return err
 		}
-		output.PrintSuccess(fmt.Sprintf("activated configuration %q", targetName), !noColorFlag)
+		recordUsage(targetName)
+		output.PrintSuccess(fmt.Sprintf("activated configuration %q", targetName), !o.noColor)
 	}
 
 	return nil
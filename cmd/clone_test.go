@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("clone", "prod", "prod-copy")
+
+	source, _ := env.gcloud.Properties("prod")
+	clone, ok := env.gcloud.Properties("prod-copy")
+	if !ok || !maps.Equal(clone, source) {
+		t.Errorf("clone properties = %v (exists %v), want %v", clone, ok, source)
+	}
+	if env.gcloud.Active() != "dev" {
+		t.Errorf("active = %q after clone, want dev unchanged", env.gcloud.Active())
+	}
+	if !strings.Contains(res.stdout, `cloned configuration "prod" to "prod-copy"`) {
+		t.Errorf("stdout = %q, want the clone reported", res.stdout)
+	}
+}
+
+func TestCloneActivate(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.mustRun("clone", "prod", "prod-copy", "--activate")
+
+	if env.gcloud.Active() != "prod-copy" {
+		t.Errorf("active = %q, want prod-copy", env.gcloud.Active())
+	}
+}
+
+func TestCloneMissingSource(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "clone", "staging", "staging-copy")
+
+	if res.err == nil || !strings.Contains(res.stdout, `source configuration "staging" does not exist`) {
+		t.Errorf("cloning a missing configuration = %v, stdout %q; want an error", res.err, res.stdout)
+	}
+	if _, ok := env.gcloud.Properties("staging-copy"); ok {
+		t.Error("staging-copy was created")
+	}
+}
+
+func TestCloneExistingTarget(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "clone", "prod", "dev")
+
+	if res.err == nil || !strings.Contains(res.stdout, "already exists") {
+		t.Errorf("cloning onto an existing configuration = %v, stdout %q; want an error", res.err, res.stdout)
+	}
+	if properties, _ := env.gcloud.Properties("dev"); properties["core/project"] != "dev-project" {
+		t.Errorf("dev properties = %v, want them untouched", properties)
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// completeDataCommand is the hidden command printing the completion data for tools
+const completeDataCommand = "__complete-data"
+
+// newCompleteDataCmd returns a hidden command printing the data shell
+// completion uses, for editor plugins and other tools
+func newCompleteDataCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   completeDataCommand,
+		Short: "Print configuration data for external tools",
+		Long: `Print one line per configuration with the data shell completion uses:
+
+  name<TAB>project<TAB>account<TAB>active
+
+active is "true" or "false" and unset values are empty. The fields and their
+order are stable across versions; new fields are only ever appended. Values are
+never colored, truncated or quoted, and never contain tabs or line breaks.
+
+The list comes from the completion cache, refreshed whenever gcloud's
+configuration files change, so it is fast enough to run on every keystroke.`,
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE:   o.runCompleteData,
+	}
+}
+
+func (o *options) runCompleteData(cmd *cobra.Command, args []string) error {
+	// Errors must not end up among the data lines
+	output.SetMessageOutput(os.Stderr)
+
+	configs, err := completionConfigurations()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	return output.WriteCompletionData(os.Stdout, configs)
+}
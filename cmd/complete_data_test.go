@@ -0,0 +1,26 @@
+package cmd
+
+import "testing"
+
+func TestCompleteData(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("empty", nil)
+
+	res := env.mustRun("__complete-data")
+
+	want := "dev\tdev-project\tdev@example.com\ttrue\n" +
+		"empty\t\t\tfalse\n" +
+		"prod\tprod-project\tops@example.com\tfalse\n"
+	if res.stdout != want {
+		t.Errorf("__complete-data printed\n%q\nwant\n%q", res.stdout, want)
+	}
+
+	// A second run is served from the cache
+	calls := len(env.gcloud.Calls())
+	if again := env.mustRun("__complete-data"); again.stdout != want {
+		t.Errorf("__complete-data from the cache printed\n%q\nwant\n%q", again.stdout, want)
+	}
+	if extra := env.gcloud.Calls()[calls:]; len(extra) != 0 {
+		t.Errorf("__complete-data ran gcloud %q, want the cached list", extra)
+	}
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/spf13/cobra"
+)
+
+// copyPropertyOptions holds the flags of the copy-property command
+type copyPropertyOptions struct {
+	*options
+	all   bool
+	exact bool
+}
+
+func newCopyPropertyCmd(parent *options) *cobra.Command {
+	o := &copyPropertyOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "copy-property <source> <target> [property...]",
+		Short: "Copy properties from one configuration to another",
+		Long: `Copy selected properties, such as the region and zone, from one
+configuration to another. Properties are given as SECTION/NAME; a bare name
+refers to the core section (e.g. "project" is core/project).
+
+Properties the source does not set are skipped with a notice. With --exact
+they are unset on the target instead, so the target ends up matching the
+source for every listed property. --all copies every property the source sets
+(with --exact, properties only the target sets are unset as well).
+
+Examples:
+  gcloudctx copy-property prod staging compute/region compute/zone
+  gcloudctx copy-property prod staging compute/zone --exact
+  gcloudctx copy-property prod staging --all`,
+		Args:              cobra.MinimumNArgs(2),
+		RunE:              o.runCopyProperty,
+		ValidArgsFunction: completeCopyProperty,
+	}
+	cmd.Flags().BoolVar(&o.all, "all", false, "Copy every property set on the source")
+	cmd.Flags().BoolVar(&o.exact, "exact", false, "Unset properties on the target that the source does not set")
+	return cmd
+}
+
+// completeCopyProperty completes configuration names for the source and target,
+// then property names: those the source sets plus common ones not given yet
+func completeCopyProperty(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	configs, err := completionConfigurations()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	if len(args) < 2 {
+		var names []string
+		for i := range configs {
+			if len(args) == 1 && configs[i].Name == args[0] {
+				continue
+			}
+			names = append(names, output.CompletionEntry(&configs[i]))
+		}
+		return pendingOperations().FilterCompletions(names), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	candidates := slices.Clone(gcloud.CommonProperties)
+	for i := range configs {
+		if configs[i].Name == args[0] {
+			candidates = append(candidates, gcloud.SetPropertyNames(&configs[i])...)
+		}
+	}
+	slices.Sort(candidates)
+
+	var properties []string
+	for _, property := range slices.Compact(candidates) {
+		if !slices.Contains(args[2:], property) {
+			properties = append(properties, property)
+		}
+	}
+	return properties, cobra.ShellCompDirectiveNoFileComp
+}
+
+func (o *copyPropertyOptions) runCopyProperty(cmd *cobra.Command, args []string) error {
+	sourceName, targetName, requested := args[0], args[1], args[2:]
+
+	if o.all && len(requested) > 0 {
+		err := fmt.Errorf("--all cannot be combined with a property list")
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if !o.all && len(requested) == 0 {
+		err := fmt.Errorf("no properties given (list them or use --all)")
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if sourceName == targetName {
+		err := fmt.Errorf("source and target are the same configuration %q", sourceName)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	var properties []string
+	for _, property := range requested {
+		normalized, err := gcloud.NormalizeProperty(property)
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		if !slices.Contains(properties, normalized) {
+			properties = append(properties, normalized)
+		}
+	}
+
+	// Read both configurations from a single list call
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	source, target := findConfiguration(configs, sourceName), findConfiguration(configs, targetName)
+	if source == nil || target == nil {
+		missing := sourceName
+		if source != nil {
+			missing = targetName
+		}
+		err := fmt.Errorf("configuration %q does not exist", missing)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if o.all {
+		properties = gcloud.SetPropertyNames(source)
+		if o.exact {
+			properties = slices.Compact(slices.Sorted(slices.Values(append(properties, gcloud.SetPropertyNames(target)...))))
+		}
+	}
+
+	plan := gcloud.PlanPropertyCopy(source, target, properties, o.exact)
+	for _, property := range plan.Skipped {
+		fmt.Fprintf(os.Stderr, "Skipped %s: not set on %q (use --exact to unset it on %q)\n", property, sourceName, targetName)
+	}
+	if len(plan.Set) == 0 && len(plan.Unset) == 0 {
+		fmt.Println("Nothing to copy")
+		return nil
+	}
+
+	if err := gcloud.ApplyPropertyCopy(targetName, plan); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	var changes []string
+	if len(plan.Set) > 0 {
+		copied := make([]string, len(plan.Set))
+		for i, setting := range plan.Set {
+			copied[i] = setting.Property
+		}
+		changes = append(changes, "copied "+strings.Join(copied, ", "))
+	}
+	if len(plan.Unset) > 0 {
+		changes = append(changes, "unset "+strings.Join(plan.Unset, ", "))
+	}
+	output.PrintSuccess(fmt.Sprintf("%s on %q (from %q)", strings.Join(changes, "; "), targetName, sourceName), !o.noColor)
+	return nil
+}
+
+// findConfiguration returns the named configuration in configs, or nil
+func findConfiguration(configs []gcloud.Configuration, name string) *gcloud.Configuration {
+	for i := range configs {
+		if configs[i].Name == name {
+			return &configs[i]
+		}
+	}
+	return nil
+}
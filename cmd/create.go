@@ -2,60 +2,140 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
 	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
 	"github.com/spf13/cobra"
 )
 
-var (
-	activateFlag bool
-)
+// createOptions holds the flags of the create command
+type createOptions struct {
+	*options
+	activate bool
+	sanitize bool
+	template string
+	params   []string
+}
 
-var createCmd = &cobra.Command{
-	Use:   "create <configuration-name>",
-	Short: "Create a new gcloud configuration",
-	Long: `Create a new gcloud configuration.
+func newCreateCmd(parent *options) *cobra.Command {
+	o := &createOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "create <configuration-name>",
+		Short: "Create a new gcloud configuration",
+		Long: `Create a new gcloud configuration.
 
 The new configuration will be created and optionally activated.
+With --template, its properties come from a template in
+~/.config/gcloudctx/templates (see 'gcloudctx templates --help').
 
 Examples:
   gcloudctx create my-new-config
-  gcloudctx create my-new-config --activate`,
-	Args: cobra.ExactArgs(1),
-	RunE: runCreate,
-}
-
-func init() {
-	createCmd.Flags().BoolVar(&activateFlag, "activate", false, "Activate the newly created configuration")
-	rootCmd.AddCommand(createCmd)
+  gcloudctx create my-new-config --activate
+  gcloudctx create Prod.EU --sanitize    # Creates 'prod-eu'
+  gcloudctx create my-config --template team-default --param env=staging`,
+		Args: cobra.ExactArgs(1),
+		RunE: o.runCreate,
+	}
+	cmd.Flags().BoolVar(&o.activate, "activate", false, "Activate the newly created configuration")
+	cmd.Flags().BoolVar(&o.sanitize, "sanitize", false, "Turn an invalid name into a valid one (e.g. 'Prod.EU' becomes 'prod-eu')")
+	cmd.Flags().StringVar(&o.template, "template", "", "Set properties from the named template")
+	cmd.Flags().StringArrayVar(&o.params, "param", nil, "Template parameter as KEY=VALUE (repeatable)")
+	_ = cmd.RegisterFlagCompletionFunc("template", completeTemplateNames)
+	return cmd
 }
 
-func runCreate(cmd *cobra.Command, args []string) error {
-	configName := args[0]
-
+func (o *createOptions) runCreate(cmd *cobra.Command, args []string) error {
 	// Validate configuration name before making gcloud calls
-	if err := gcloud.ValidateConfigurationName(configName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+	configName, err := resolveConfigurationName(args[0], o.sanitize, "--sanitize")
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if o.template == "" && len(o.params) > 0 {
+		err := fmt.Errorf("--param requires --template")
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
+	// Render the template before touching gcloud so a bad template creates nothing
+	var resolved *configfile.Resolved
+	if o.template != "" {
+		params, err := parseTemplateParams(o.params)
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		if resolved, err = renderTemplate(o.template, configName, params); err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+	}
+
 	// Create the configuration (gcloud install check is done inside RunGcloudCommand)
-	if err := gcloud.CreateConfiguration(configName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+	if resolved != nil {
+		if err := createFromTemplate(resolved); err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+	} else if err := gcloud.CreateConfiguration(configName); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
-	output.PrintSuccess(fmt.Sprintf("created configuration %q", configName), !noColorFlag)
+	output.PrintSuccess(fmt.Sprintf("created configuration %q", configName), !o.noColor)
 
 	// Activate if requested
-	if activateFlag {
+	if o.activate {
 		if err := gcloud.ActivateConfiguration(configName); err != nil {
-			output.PrintError(err.Error(), !noColorFlag)
+			output.PrintError(err.Error(), !o.noColor)
 			return err
 		}
-		output.PrintSuccess(fmt.Sprintf("activated configuration %q", configName), !noColorFlag)
+		recordUsage(configName)
+		output.PrintSuccess(fmt.Sprintf("activated configuration %q", configName), !o.noColor)
 	}
 
 	return nil
 }
+
+// createFromTemplate creates a configuration with the properties of a rendered
+// template and remembers its ADC hint, like importing a file does
+func createFromTemplate(resolved *configfile.Resolved) error {
+	if err := gcloud.ImportConfiguration(resolved.Name, importPropertySettings(resolved)); err != nil {
+		return err
+	}
+
+	if resolved.SyncADC || resolved.ImpersonateServiceAccount != "" {
+		hint := adc.Hint{SyncADC: resolved.SyncADC, ImpersonateServiceAccount: resolved.ImpersonateServiceAccount}
+		if err := adc.SaveHint(resolved.Name, hint); err != nil {
+			// Non-fatal error, just warn
+			statedir.Warn(os.Stderr, fmt.Errorf("failed to save ADC hint: %w", err))
+		}
+	}
+	return nil
+}
+
+// resolveConfigurationName validates a configuration name, replacing an invalid one with its
+// sanitized form when sanitize is set; otherwise the error suggests the sanitized name and flagName
+func resolveConfigurationName(name string, sanitize bool, flagName string) (string, error) {
+	validationErr := gcloud.ValidateConfigurationName(name)
+	if validationErr == nil {
+		return name, nil
+	}
+
+	sanitized, err := gcloud.SanitizeConfigurationName(name)
+	if err != nil {
+		return "", validationErr
+	}
+
+	if !sanitize {
+		return "", fmt.Errorf("%w (try %q, or pass %s to use it)", validationErr, sanitized, flagName)
+	}
+
+	fmt.Printf("Using sanitized configuration name %q\n", sanitized)
+	return sanitized, nil
+}
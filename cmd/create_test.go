@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("create", "staging")
+
+	if !slices.Contains(env.gcloud.Names(), "staging") {
+		t.Errorf("configurations = %v, want staging created", env.gcloud.Names())
+	}
+	if env.gcloud.Active() != "dev" {
+		t.Errorf("active = %q after create without --activate, want dev", env.gcloud.Active())
+	}
+	if !strings.Contains(res.stdout, `created configuration "staging"`) {
+		t.Errorf("stdout = %q, want the creation reported", res.stdout)
+	}
+}
+
+func TestCreateActivate(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("create", "staging", "--activate")
+
+	if env.gcloud.Active() != "staging" {
+		t.Errorf("active = %q, want staging", env.gcloud.Active())
+	}
+	if !strings.Contains(res.stdout, `activated configuration "staging"`) {
+		t.Errorf("stdout = %q, want the activation reported", res.stdout)
+	}
+}
+
+func TestCreateInvalidName(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "create", "Prod.EU")
+
+	if res.err == nil {
+		t.Fatal("creating an invalid name succeeded")
+	}
+	if !strings.Contains(res.stdout, `"prod-eu"`) {
+		t.Errorf("stdout = %q, want the sanitized name suggested", res.stdout)
+	}
+	if calls := env.gcloud.Calls(); len(calls) != 0 {
+		t.Errorf("an invalid name ran gcloud: %q", calls)
+	}
+}
+
+func TestCreateSanitize(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.mustRun("create", "Prod.EU", "--sanitize")
+
+	if !slices.Contains(env.gcloud.Names(), "prod-eu") {
+		t.Errorf("configurations = %v, want prod-eu created", env.gcloud.Names())
+	}
+}
+
+func TestCreateExisting(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "create", "prod")
+
+	if res.err == nil || !strings.Contains(res.stdout, "already exists") {
+		t.Errorf("creating an existing configuration = %v, stdout %q; want an error", res.err, res.stdout)
+	}
+}
@@ -1,39 +1,54 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/internal/prompt"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
 	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/notes"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
 	"github.com/spf13/cobra"
 )
 
-var (
-	forceFlag bool
-)
+// deleteOptions holds the flags of the delete command
+type deleteOptions struct {
+	*options
+	force bool
+}
 
-var deleteCmd = &cobra.Command{
-	Use:   "delete <configuration-name>",
-	Short: "Delete a gcloud configuration",
-	Long: `Delete a gcloud configuration.
+func newDeleteCmd(parent *options) *cobra.Command {
+	o := &deleteOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "delete <configuration-name>",
+		Short: "Delete a gcloud configuration",
+		Long: `Delete a gcloud configuration.
 
 You cannot delete the currently active configuration.
-Use -f/--force to skip the confirmation prompt.
+Use -f/--force to skip the confirmation prompt; it is the supported way to
+delete from scripts. When stdin is piped the prompt asks on the controlling
+terminal, and reads the answer from stdin only when there is none.
+
+The "default" configuration is reserved by gcloud and other tools expect it
+to exist. Deleting it requires --force and typing its name to confirm.
+Deleting a protected configuration (see 'gcloudctx protect') also requires
+typing its name, with or without --force.
+
+Deleting a configuration also forgets its project and usage history, its
+note, its ADC hint and its ADC snapshot.
 
 Examples:
   gcloudctx delete my-old-config
   gcloudctx delete my-old-config --force`,
-	Args:              cobra.ExactArgs(1),
-	RunE:              runDelete,
-	ValidArgsFunction: completeConfigNamesForDelete,
-}
-
-func init() {
-	deleteCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Skip confirmation prompt")
-	rootCmd.AddCommand(deleteCmd)
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.runDelete,
+		ValidArgsFunction: completeConfigNamesForDelete,
+	}
+	cmd.Flags().BoolVarP(&o.force, "force", "f", false, "Skip confirmation prompt")
+	return cmd
 }
 
 // completeConfigNamesForDelete provides completion for configuration names (excluding active)
@@ -42,46 +57,91 @@ func completeConfigNamesForDelete(cmd *cobra.Command, args []string, toComplete
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
-	configs, err := gcloud.ListConfigurations()
+	configs, err := completionConfigurations()
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
 	var names []string
-	for _, config := range configs {
+	for i := range configs {
 		// Don't suggest the active configuration
-		if !config.IsActive {
-			names = append(names, config.Name)
+		if !configs[i].IsActive {
+			names = append(names, output.CompletionEntry(&configs[i]))
 		}
 	}
 
-	return names, cobra.ShellCompDirectiveNoFileComp
+	return pendingOperations().FilterCompletions(names), cobra.ShellCompDirectiveNoFileComp
 }
 
-func runDelete(cmd *cobra.Command, args []string) error {
+func (o *deleteOptions) runDelete(cmd *cobra.Command, args []string) error {
 	configName := args[0]
 
-	// Confirm deletion if not forced (gcloud install check is done inside RunGcloudCommand)
-	if !forceFlag {
-		fmt.Printf("Are you sure you want to delete configuration %q? (y/N): ", configName)
-		reader := bufio.NewReader(os.Stdin)
-		response, err := reader.ReadString('\n')
-		if err != nil {
-			return err
-		}
-		response = strings.ToLower(strings.TrimSpace(response))
-		if response != "y" && response != "yes" {
-			fmt.Println("Deletion canceled")
-			return nil
-		}
+	if err := gcloud.ValidateDeletion(configName, o.force); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	confirmed, err := o.confirmDeletion(configName, o.force)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Deletion canceled")
+		return nil
 	}
 
 	// Delete the configuration
 	if err := gcloud.DeleteConfiguration(configName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
+	forgetConfigurationState(configName)
 
-	output.PrintSuccess(fmt.Sprintf("deleted configuration %q", configName), !noColorFlag)
+	output.PrintSuccess(fmt.Sprintf("deleted configuration %q", configName), !o.noColor)
 	return nil
 }
+
+// confirmDeletion asks whether to delete the named configuration, unless force is set
+// --force alone is not enough for the reserved configuration and protected
+// ones: their name must be typed to confirm instead
+func (o *options) confirmDeletion(configName string, force bool) (bool, error) {
+	switch {
+	case gcloud.IsReservedName(configName):
+		warning := fmt.Sprintf("Configuration %q is reserved by gcloud; tools that expect it may break once it is gone.", configName)
+		return prompt.AskTyped(os.Stdout, warning, configName)
+	case o.isProtectedConfiguration(configName):
+		warning := fmt.Sprintf("Configuration %q is PROTECTED.", configName)
+		return prompt.AskTyped(os.Stdout, warning, configName)
+	}
+
+	// The gcloud install check is done inside RunGcloudCommand
+	return prompt.Ask(os.Stdout, fmt.Sprintf("Are you sure you want to delete configuration %q?", configName), true, force)
+}
+
+// forgetConfigurationState drops what gcloudctx remembers about a deleted
+// configuration, so a configuration created later under the same name starts
+// without its project history, usage, note, ADC hint or ADC snapshot
+func forgetConfigurationState(configName string) {
+	if err := history.ClearPreviousProject(configName); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to clear project history: %w", err))
+	}
+	if err := history.RemoveUsage(configName); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to clear usage history: %w", err))
+	}
+	if _, err := notes.Remove(configName); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to remove configuration note: %w", err))
+	}
+	if err := adc.ClearHint(configName); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to clear ADC hint: %w", err))
+	}
+	if store, err := adc.DefaultSnapshotStore(); err == nil {
+		if err := store.Delete(configName); err != nil {
+			// Non-fatal error, just warn
+			statedir.Warn(os.Stderr, fmt.Errorf("failed to delete ADC snapshot: %w", err))
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDeleteConfirmed(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("y\n", "delete", "prod")
+
+	if res.err != nil {
+		t.Fatalf("delete: %v", res.err)
+	}
+	if slices.Contains(env.gcloud.Names(), "prod") {
+		t.Errorf("configurations = %v, want prod deleted", env.gcloud.Names())
+	}
+	if !strings.Contains(res.stdout, `deleted configuration "prod"`) {
+		t.Errorf("stdout = %q, want the deletion reported", res.stdout)
+	}
+}
+
+func TestDeleteCanceled(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("n\n", "delete", "prod")
+
+	if res.err != nil {
+		t.Fatalf("delete: %v", res.err)
+	}
+	if !slices.Contains(env.gcloud.Names(), "prod") {
+		t.Error("prod was deleted although the prompt was declined")
+	}
+	if !strings.Contains(res.stdout, "Deletion canceled") {
+		t.Errorf("stdout = %q, want the cancellation reported", res.stdout)
+	}
+}
+
+func TestDeletePipedConfirmation(t *testing.T) {
+	env := newTestEnv(t)
+	env.terminal = false
+
+	res := env.run("y\n", "delete", "prod")
+
+	if res.err != nil {
+		t.Fatalf("delete: %v", res.err)
+	}
+	if slices.Contains(env.gcloud.Names(), "prod") {
+		t.Errorf("configurations = %v, want prod deleted on the piped answer", env.gcloud.Names())
+	}
+}
+
+func TestDeleteAsksOnControllingTerminal(t *testing.T) {
+	env := newTestEnv(t)
+	env.terminal = false
+	env.tty = &fakeTTY{Reader: strings.NewReader("n\n")}
+
+	// The piped "y" is not the answer when a terminal can be asked
+	res := env.run("y\n", "delete", "prod")
+
+	if res.err != nil {
+		t.Fatalf("delete: %v", res.err)
+	}
+	if !slices.Contains(env.gcloud.Names(), "prod") {
+		t.Error("prod was deleted although the terminal declined")
+	}
+	if !strings.Contains(env.tty.questions.String(), `delete configuration "prod"? (y/N)`) {
+		t.Errorf("terminal = %q, want the question asked there", env.tty.questions.String())
+	}
+	if !strings.Contains(res.stdout, "Deletion canceled") {
+		t.Errorf("stdout = %q, want the cancellation reported", res.stdout)
+	}
+}
+
+func TestDeleteForce(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.mustRun("delete", "prod", "--force")
+
+	if slices.Contains(env.gcloud.Names(), "prod") {
+		t.Errorf("configurations = %v, want prod deleted", env.gcloud.Names())
+	}
+}
+
+func TestDeleteActive(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "delete", "dev", "--force")
+
+	if res.err == nil {
+		t.Fatal("deleting the active configuration succeeded")
+	}
+	if !strings.Contains(res.stdout, "cannot delete active configuration") {
+		t.Errorf("stdout = %q, want the reason", res.stdout)
+	}
+	if !slices.Contains(env.gcloud.Names(), "dev") {
+		t.Error("the active configuration was deleted")
+	}
+}
+
+func TestDeleteReservedNeedsForce(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("default", nil)
+
+	res := env.run("y\n", "delete", "default")
+
+	if res.err == nil || !strings.Contains(res.stdout, "--force") {
+		t.Errorf("deleting default without --force = %v, stdout %q; want an error", res.err, res.stdout)
+	}
+	if !slices.Contains(env.gcloud.Names(), "default") {
+		t.Error("default was deleted")
+	}
+}
+
+func TestDeleteProtectedNeedsTypedName(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("protect", "prod")
+
+	res := env.run("y\n", "delete", "prod", "--force")
+	if res.err != nil || !slices.Contains(env.gcloud.Names(), "prod") {
+		t.Fatalf("delete --force answered with y = %v; want prod kept", res.err)
+	}
+
+	res = env.run("prod\n", "delete", "prod", "--force")
+	if res.err != nil || slices.Contains(env.gcloud.Names(), "prod") {
+		t.Errorf("delete with the name typed = %v, configurations %v; want prod deleted", res.err, env.gcloud.Names())
+	}
+	if !strings.Contains(res.stdout, "PROTECTED") {
+		t.Errorf("stdout = %q, want the protection pointed out", res.stdout)
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/spf13/cobra"
+)
+
+// describeOptions holds the flags of the describe command
+type describeOptions struct {
+	*options
+	format string
+	all    bool
+}
+
+func newDescribeCmd(parent *options) *cobra.Command {
+	o := &describeOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "describe [configuration-name]",
+		Short: "Show the details of a configuration",
+		Long: `Show the account, project, region and zone of a configuration.
+
+Without a configuration name, the active configuration is described. With
+--all, every other property the configuration sets (run/region,
+container/cluster and so on) follows, grouped by section, and -o json or yaml
+includes them all under "properties".
+
+Examples:
+  gcloudctx describe              # The active configuration
+  gcloudctx describe prod --all   # Every property of prod
+  gcloudctx describe prod --all -o json`,
+		Args:              cobra.MaximumNArgs(1),
+		RunE:              o.runDescribe,
+		ValidArgsFunction: completeConfigNames,
+	}
+	cmd.Flags().StringVarP(&o.format, "output", "o", "", "Output format (json, yaml)")
+	cmd.Flags().BoolVar(&o.all, "all", false, "Also show every property the configuration sets")
+	return cmd
+}
+
+func (o *describeOptions) runDescribe(cmd *cobra.Command, args []string) error {
+	format, err := output.ValidateOutputFormat(o.format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	var config *gcloud.Configuration
+	if len(args) == 0 {
+		config, err = gcloud.GetActiveConfigurationFast()
+	} else {
+		stop := output.StartSpinner(querySpinnerMessage)
+		config, err = gcloud.GetConfigurationInfo(args[0])
+		stop()
+	}
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if output.IsMachineFormat(format) {
+		return output.PrintConfigurationDocument(config, format, o.all)
+	}
+
+	loadLastUsed()
+	loadNotes()
+	output.PrintConfigurationDetails(config, !o.noColor)
+	if o.all {
+		output.PrintConfigurationSections(config, !o.noColor)
+	}
+	return nil
+}
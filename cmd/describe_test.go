@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDescribeAll(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("ci", map[string]string{
+		"core/project":      "ci-project",
+		"run/region":        "asia-northeast1",
+		"container/cluster": "build",
+	})
+
+	res := env.mustRun("describe", "ci")
+	if !strings.Contains(res.stdout, "ci-project") || strings.Contains(res.stdout, "[run]") {
+		t.Errorf("describe ci = %q, want the core block only", res.stdout)
+	}
+
+	res = env.mustRun("describe", "ci", "--all")
+	for _, want := range []string{"[container]\ncluster: build\n", "[run]\nregion: asia-northeast1\n"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("describe ci --all = %q, want %q", res.stdout, want)
+		}
+	}
+	if strings.Contains(res.stdout, "[core]") {
+		t.Errorf("describe ci --all = %q, want the core properties only in the core block", res.stdout)
+	}
+
+	res = env.mustRun("describe", "ci", "--all", "-o", "json")
+	var document struct {
+		Name       string                       `json:"name"`
+		Properties map[string]map[string]string `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(res.stdout), &document); err != nil {
+		t.Fatalf("describe -o json = %q: %v", res.stdout, err)
+	}
+	if document.Name != "ci" || document.Properties["run"]["region"] != "asia-northeast1" || document.Properties["core"]["project"] != "ci-project" {
+		t.Errorf("describe -o json = %+v, want every property under properties", document)
+	}
+
+	if res := env.mustRun("describe", "ci", "-o", "json"); strings.Contains(res.stdout, "properties") {
+		t.Errorf("describe -o json = %q, want properties only with --all", res.stdout)
+	}
+}
+
+func TestDescribeActive(t *testing.T) {
+	env := newTestEnv(t)
+	if res := env.mustRun("describe"); !strings.Contains(res.stdout, "dev-project") {
+		t.Errorf("describe = %q, want the active configuration", res.stdout)
+	}
+	if res := env.run("", "describe", "missing"); res.err == nil {
+		t.Error("describe missing succeeded, want an error")
+	}
+}
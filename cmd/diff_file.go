@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/manifest"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// diffFileOptions holds the flags of the diff-file command
+type diffFileOptions struct {
+	*options
+	format string
+}
+
+func newDiffFileCmd(parent *options) *cobra.Command {
+	o := &diffFileOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "diff-file <manifest>",
+		Short: "Report configurations that drifted from a team manifest",
+		Long: `Compare a team manifest (see 'gcloudctx apply --help') with the local
+configurations without changing anything, and print a property-level diff for
+each configuration that differs.
+
+The command exits non-zero when a configuration is missing or one of its
+properties differs from the manifest, so it can run from cron or CI. Local
+configurations the manifest does not list are reported separately and do not
+affect the exit status.
+
+Examples:
+  gcloudctx diff-file team.yaml
+  gcloudctx diff-file team.yaml -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: o.runDiffFile,
+	}
+	cmd.Flags().StringVarP(&o.format, "output", "o", "", "Output format (json, yaml)")
+	return cmd
+}
+
+func (o *diffFileOptions) runDiffFile(cmd *cobra.Command, args []string) error {
+	format, err := output.ValidateOutputFormat(o.format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	items, err := loadManifestPlan(args[0])
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	drifts := manifest.Drifts(items)
+
+	if output.IsMachineFormat(format) {
+		if err := output.PrintDrift(drifts, format); err != nil {
+			return err
+		}
+	} else {
+		o.printDrift(drifts, args[0])
+	}
+
+	if manifest.HasDrift(drifts) {
+		return fmt.Errorf("local configurations drifted from %s", args[0])
+	}
+	return nil
+}
+
+// printDrift prints a property table per drifted configuration, then the extra ones
+func (o *options) printDrift(drifts []manifest.Drift, manifestPath string) {
+	if o.noColor {
+		color.NoColor = true
+	}
+	yellow := color.New(color.FgYellow).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	gray := color.New(color.FgHiBlack).SprintFunc()
+
+	var extra []string
+	for _, drift := range drifts {
+		switch drift.Kind {
+		case manifest.DriftMissing:
+			fmt.Printf("%s: %s\n", drift.Name, red("missing"))
+		case manifest.DriftChanged:
+			fmt.Printf("%s: %s\n", drift.Name, yellow("changed"))
+			rows := [][]string{{"PROPERTY", "MANIFEST", "LOCAL"}}
+			for _, property := range drift.Properties {
+				actual := property.Actual
+				if actual == "" {
+					actual = gray("(unset)")
+				}
+				rows = append(rows, []string{property.Property, property.Expected, actual})
+			}
+			for _, line := range output.AlignColumns(rows, 2) {
+				fmt.Println("  " + line)
+			}
+		case manifest.DriftExtra:
+			extra = append(extra, drift.Name)
+		}
+	}
+
+	if !manifest.HasDrift(drifts) {
+		fmt.Printf("All configurations match %s\n", manifestPath)
+	}
+	if len(extra) > 0 {
+		fmt.Fprintf(os.Stderr, "Not in manifest: %s\n", strings.Join(extra, ", "))
+	}
+}
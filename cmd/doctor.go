@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/internal/update"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func newDoctorCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that gcloud and its configurations are ready to use",
+		Long: `Check the gcloud installation and configurations gcloudctx works with.
+
+Each check is reported as ok, a warning, or a problem. The command fails when
+there is a problem, such as gcloud missing from PATH or no configurations at
+all on a machine where 'gcloud init' never ran.
+
+The gcloud executable is $GCLOUDCTX_GCLOUD_PATH, or the first one in PATH. A
+warning points out other installs in PATH and a configuration directory
+reported by gcloud that differs from the expected one, as with a snap package.
+Warnings also point out a missing "default" configuration, which gcloud and
+other tools expect, and a newer gcloudctx release (unless the update check is
+disabled; a failed lookup is never a problem).
+
+Examples:
+  gcloudctx doctor`,
+		Args: cobra.NoArgs,
+		RunE: o.runDoctor,
+	}
+}
+
+// doctorStatus is the outcome of a doctor check
+type doctorStatus int
+
+const (
+	doctorOK doctorStatus = iota
+	doctorWarning
+	doctorProblem
+)
+
+// doctorCheck is one row of the doctor report
+type doctorCheck struct {
+	name   string
+	result string
+	status doctorStatus
+	// advice tells the user what to do about a warning or problem
+	advice string
+}
+
+// runDoctorChecks checks gcloud and its configurations, stopping at the first
+// problem that makes the following checks meaningless
+func runDoctorChecks() []doctorCheck {
+	var checks []doctorCheck
+	if gcloud.Offline() {
+		// First, so nobody takes the skipped checks for passed ones
+		checks = append(checks, doctorCheck{name: "offline mode", result: "on (network checks skipped)", status: doctorWarning,
+			advice: fmt.Sprintf("unset $%s and drop --offline to check the access token", gcloud.EnvOffline)})
+	}
+	checks = append(checks, checkUpdate())
+
+	bin, err := gcloud.ResolveGcloudBinary()
+	if err != nil {
+		if os.Getenv(gcloud.EnvGcloudPath) != "" {
+			return append(checks, doctorCheck{name: "gcloud", result: err.Error(), status: doctorProblem,
+				advice: fmt.Sprintf("point $%s at a gcloud executable or unset it", gcloud.EnvGcloudPath)})
+		}
+		return append(checks, doctorCheck{name: "gcloud", result: "not found on PATH", status: doctorProblem,
+			advice: "install the Google Cloud SDK: https://cloud.google.com/sdk/docs/install"})
+	}
+	if bin.FromEnv {
+		checks = append(checks, doctorCheck{name: "gcloud", result: bin.Path + " ($" + gcloud.EnvGcloudPath + ")"})
+	} else {
+		checks = append(checks, doctorCheck{name: "gcloud", result: bin.Path})
+	}
+	if len(bin.Others) > 0 {
+		checks = append(checks, doctorCheck{name: "other gcloud installs", result: strings.Join(bin.Others, ", "), status: doctorWarning,
+			advice: fmt.Sprintf("each install may keep its own configurations; set $%s to the one to use", gcloud.EnvGcloudPath)})
+	}
+
+	dir, err := gcloud.ConfigDir()
+	if err != nil {
+		return append(checks, doctorCheck{name: "configuration directory", result: err.Error(), status: doctorProblem})
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		checks = append(checks, doctorCheck{name: "configuration directory", result: dir + " (not created yet)", status: doctorWarning})
+	} else {
+		checks = append(checks, doctorCheck{name: "configuration directory", result: dir})
+	}
+	checks = append(checks, checkInstalledConfigDir())
+
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		return append(checks, doctorCheck{name: "configurations", result: firstLine(err.Error()), status: doctorProblem})
+	}
+	if len(configs) == 0 {
+		return append(checks, doctorCheck{name: "configurations", result: "none ('gcloud init' never ran)", status: doctorProblem,
+			advice: bootstrapHint})
+	}
+	checks = append(checks, doctorCheck{name: "configurations", result: strconv.Itoa(len(configs))})
+	checks = append(checks, checkDefaultConfiguration(configs))
+
+	active, err := gcloud.GetActiveConfiguration()
+	if err != nil {
+		return append(checks, doctorCheck{name: "active configuration", result: err.Error(), status: doctorProblem,
+			advice: "activate one with 'gcloudctx NAME'"})
+	}
+	checks = append(checks, doctorCheck{name: "active configuration", result: active.Name})
+
+	for _, property := range []struct{ name, value, advice string }{
+		{"account", active.Properties.Core.Account, "run 'gcloud auth login'"},
+		{"project", active.Properties.Core.Project, "run 'gcloudctx project'"},
+	} {
+		if property.value == "" {
+			checks = append(checks, doctorCheck{name: property.name, result: "not set", status: doctorWarning, advice: property.advice})
+		} else {
+			checks = append(checks, doctorCheck{name: property.name, result: property.value})
+		}
+	}
+	if active.Properties.Core.Account != "" {
+		checks = append(checks, checkAccessToken(false, time.Now()))
+	}
+	checks = append(checks, checkADC())
+
+	return checks
+}
+
+// checkAccessToken reports when the active account's access token expires,
+// refreshing it first when refresh is set; offline, asking is skipped
+func checkAccessToken(refresh bool, now time.Time) doctorCheck {
+	const name = "access token"
+	if gcloud.Offline() {
+		return doctorCheck{name: name, result: "(skipped: offline)"}
+	}
+	expiry, err := gcloud.AccessTokenExpiry(refresh)
+	if errors.Is(err, gcloud.ErrReauthRequired) {
+		return doctorCheck{name: name, result: "expired", status: doctorWarning, advice: "run 'gcloud auth login'"}
+	}
+	if err != nil {
+		return doctorCheck{name: name, result: firstLine(err.Error()), status: doctorWarning}
+	}
+
+	left := expiry.Sub(now).Round(time.Minute)
+	if left <= 0 {
+		return doctorCheck{name: name, result: "expired", status: doctorWarning, advice: "run 'gcloudctx status --refresh'"}
+	}
+	result := "expires in " + formatMinutes(left)
+	if left < gcloud.TokenNearExpiry {
+		return doctorCheck{name: name, result: result, status: doctorWarning, advice: "run 'gcloudctx status --refresh'"}
+	}
+	return doctorCheck{name: name, result: result}
+}
+
+// formatMinutes formats a whole number of minutes as "45m", "1h" or "1h30m"
+func formatMinutes(d time.Duration) string {
+	hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+}
+
+// checkDefaultConfiguration warns when the configuration reserved by gcloud is
+// missing, since gcloud and other tools fall back to it
+func checkDefaultConfiguration(configs []gcloud.Configuration) doctorCheck {
+	const name = "default configuration"
+	if slices.ContainsFunc(configs, func(config gcloud.Configuration) bool { return gcloud.IsReservedName(config.Name) }) {
+		return doctorCheck{name: name, result: gcloud.ReservedConfigurationName}
+	}
+	return doctorCheck{name: name, result: "missing", status: doctorWarning,
+		advice: fmt.Sprintf("gcloud and other tools expect it; run 'gcloudctx create %s'", gcloud.ReservedConfigurationName)}
+}
+
+// checkUpdate reports the running version and whether a newer release is
+// available; a failed lookup is reported but never counts as a warning
+func checkUpdate() doctorCheck {
+	const name = "gcloudctx version"
+	switch {
+	case updateCheckDisabled():
+		return doctorCheck{name: name, result: Version + " (update check disabled)"}
+	case gcloud.Offline():
+		return doctorCheck{name: name, result: Version + " (update check skipped: offline)"}
+	case !update.IsRelease(Version):
+		return doctorCheck{name: name, result: Version + " (development build)"}
+	}
+
+	release, err := updateChecker.LatestCached(context.Background())
+	if err != nil {
+		return doctorCheck{name: name, result: fmt.Sprintf("%s (%s)", Version, firstLine(err.Error()))}
+	}
+	if update.IsNewer(Version, release.Version) {
+		return doctorCheck{name: name, result: fmt.Sprintf("%s (%s is available)", Version, release.Version), status: doctorWarning,
+			advice: "to upgrade: " + update.UpgradeHint(installMethod(), release)}
+	}
+	return doctorCheck{name: name, result: Version + " (latest)"}
+}
+
+// checkADC reports what kind of Application Default Credentials client libraries use
+func checkADC() doctorCheck {
+	const name = "application default credentials"
+	path, ok := activeADCFile()
+	if !ok {
+		return doctorCheck{name: name, result: "not set up"}
+	}
+	info, err := gcloud.ReadADC(path)
+	if err != nil {
+		return doctorCheck{name: name, result: firstLine(err.Error()), status: doctorWarning,
+			advice: "run 'gcloud auth application-default login'"}
+	}
+	return doctorCheck{name: name, result: info.String()}
+}
+
+// checkInstalledConfigDir compares the configuration directory the gcloud binary
+// reports with the one gcloudctx expects, which differ for a snap-packaged SDK
+// or an install whose configurations live somewhere other tools do not look
+func checkInstalledConfigDir() doctorCheck {
+	const name = "gcloud configuration directory"
+	reported, err := gcloud.InstalledConfigDir()
+	if err != nil {
+		return doctorCheck{name: name, result: firstLine(err.Error()), status: doctorWarning}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return doctorCheck{name: name, result: reported}
+	}
+	expected := gcloud.ConfigDirFor(home)
+	if filepath.Clean(reported) != filepath.Clean(expected) {
+		return doctorCheck{name: name, result: fmt.Sprintf("%s (expected %s)", reported, expected), status: doctorWarning,
+			advice: fmt.Sprintf("set $%s=%s so every tool reads the configurations gcloud uses", gcloud.EnvConfigDir, reported)}
+	}
+	return doctorCheck{name: name, result: reported}
+}
+
+func (o *options) runDoctor(cmd *cobra.Command, args []string) error {
+	checks := runDoctorChecks()
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	statusText := map[doctorStatus]string{
+		doctorOK:      color.New(color.FgGreen).Sprint("ok"),
+		doctorWarning: color.New(color.FgYellow).Sprint("warning"),
+		doctorProblem: color.New(color.FgRed).Sprint("problem"),
+	}
+
+	problems := 0
+	rows := [][]string{{"CHECK", "RESULT", "STATUS"}}
+	for _, check := range checks {
+		rows = append(rows, []string{check.name, check.result, statusText[check.status]})
+		if check.status == doctorProblem {
+			problems++
+		}
+	}
+	for _, line := range output.AlignColumns(rows, 2) {
+		fmt.Println(line)
+	}
+
+	// Advice goes below the table, since it is too long for a column
+	separated := false
+	for _, check := range checks {
+		if check.advice == "" || check.status == doctorOK {
+			continue
+		}
+		if !separated {
+			fmt.Println()
+			separated = true
+		}
+		fmt.Printf("%s: %s\n", check.name, check.advice)
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("found %d problem(s)", problems)
+	}
+	output.PrintSuccess("gcloud is ready to use", !o.noColor)
+	return nil
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/update"
+)
+
+// doctorLine returns the line of the doctor table reporting the named check
+func doctorLine(t *testing.T, stdout, name string) string {
+	t.Helper()
+	for line := range strings.SplitSeq(stdout, "\n") {
+		if strings.HasPrefix(line, name+" ") {
+			return line
+		}
+	}
+	t.Fatalf("stdout = %q, want a %q check", stdout, name)
+	return ""
+}
+
+// doctorReleaseServer serves the latest release to the update check of the
+// running build, which claims to be version
+func doctorReleaseServer(t *testing.T, version string, status int, body string) *atomic.Int32 {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	savedVersion, savedChecker := Version, updateChecker
+	Version, updateChecker = version, &update.Checker{APIURL: server.URL}
+	t.Cleanup(func() { Version, updateChecker = savedVersion, savedChecker })
+	t.Setenv(update.EnvDisable, "")
+	return &requests
+}
+
+func TestDoctorMissingDefault(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("doctor")
+	if line := doctorLine(t, res.stdout, "default configuration"); !strings.Contains(line, "missing") || !strings.Contains(line, "warning") {
+		t.Errorf("default configuration check = %q, want a warning that it is missing", line)
+	}
+	if !strings.Contains(res.stdout, "gcloudctx create default") {
+		t.Errorf("stdout = %q, want advice to create the default configuration", res.stdout)
+	}
+
+	env.gcloud.Add("default", map[string]string{"core/project": "default-project"})
+	res = env.mustRun("doctor")
+	if line := doctorLine(t, res.stdout, "default configuration"); strings.Contains(line, "warning") {
+		t.Errorf("default configuration check = %q, want ok once it exists", line)
+	}
+}
+
+func TestDoctorUpdateNotice(t *testing.T) {
+	env := newTestEnv(t)
+	doctorReleaseServer(t, "v1.0.0", http.StatusOK, `{"tag_name": "v1.1.0", "html_url": "https://example.com/v1.1.0"}`)
+
+	res := env.mustRun("doctor")
+
+	if line := doctorLine(t, res.stdout, "gcloudctx version"); !strings.Contains(line, "v1.1.0 is available") || !strings.Contains(line, "warning") {
+		t.Errorf("gcloudctx version check = %q, want a warning about v1.1.0", line)
+	}
+	if !strings.Contains(res.stdout, "gcloudctx version: to upgrade:") {
+		t.Errorf("stdout = %q, want upgrade advice", res.stdout)
+	}
+}
+
+func TestDoctorUpdateCheckFailure(t *testing.T) {
+	env := newTestEnv(t)
+	doctorReleaseServer(t, "v1.0.0", http.StatusInternalServerError, "")
+
+	// A failed lookup is reported without a warning or a failed exit code
+	res := env.mustRun("doctor")
+
+	if line := doctorLine(t, res.stdout, "gcloudctx version"); !strings.Contains(line, "v1.0.0") || strings.Contains(line, "warning") {
+		t.Errorf("gcloudctx version check = %q, want the version without a warning", line)
+	}
+}
+
+func TestDoctorUpdateCheckDisabled(t *testing.T) {
+	env := newTestEnv(t)
+	requests := doctorReleaseServer(t, "v1.0.0", http.StatusOK, `{"tag_name": "v1.1.0"}`)
+	env.writeSettings("disable_update_check: true\n")
+
+	res := env.mustRun("doctor")
+
+	if line := doctorLine(t, res.stdout, "gcloudctx version"); !strings.Contains(line, "update check disabled") {
+		t.Errorf("gcloudctx version check = %q, want the check disabled", line)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("doctor looked up the latest release %d times, want none", n)
+	}
+}
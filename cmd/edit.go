@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/editor"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/spf13/cobra"
+)
+
+func newEditCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit [name]",
+		Short: "Open a configuration file in your editor",
+		Long: `Open the file gcloud stores a configuration's properties in with $VISUAL
+or $EDITOR (vi, or notepad on Windows, when neither is set). Without a name
+the active configuration is edited.
+
+After the editor exits the file is checked the way gcloud parses it. Syntax
+errors are reported so they can be fixed before gcloud trips over them;
+unknown sections and properties only produce warnings. The file is never
+reverted.
+
+Examples:
+  gcloudctx edit prod
+  EDITOR="code --wait" gcloudctx edit`,
+		Args:              cobra.MaximumNArgs(1),
+		RunE:              o.runEdit,
+		ValidArgsFunction: completeConfigNamesForEdit,
+	}
+}
+
+// completeConfigNamesForEdit completes the single configuration name argument
+func completeConfigNamesForEdit(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	configs, err := completionConfigurations()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for i := range configs {
+		names = append(names, output.CompletionEntry(&configs[i]))
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func (o *options) runEdit(cmd *cobra.Command, args []string) error {
+	// The editor writes the file directly, without a gcloud command to refuse
+	if err := gcloud.CheckWritable("editing configuration files"); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	activeName, err := gcloud.ActiveConfigName()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	name := activeName
+	if len(args) > 0 {
+		name = args[0]
+		// The name becomes part of the file path; keep it inside gcloud's directory
+		if err := gcloud.ValidateExistingConfigurationName(name); err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+	}
+
+	path, err := gcloud.ConfigFilePath(name)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = fmt.Errorf("configuration file for %q not found (expected at %s)", name, path)
+		}
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if err := editor.Open(path); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	warnings, err := gcloud.ValidateConfigFile(data)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", path, warning)
+	}
+	if err != nil {
+		err = fmt.Errorf("%s no longer parses, fix it before running gcloud: %w", path, err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	output.PrintSuccess(fmt.Sprintf("Saved configuration %q", name), !o.noColor)
+	if name == activeName {
+		fmt.Fprintln(os.Stderr, "Note: this is the active configuration; long-running gcloud processes (such as 'gcloud interactive') keep the old values until restarted")
+	}
+	return nil
+}
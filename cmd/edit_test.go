@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/editor"
+)
+
+// fakeEditor makes $EDITOR a script appending line to the file it edits and
+// returns the file recording what it opened
+func (e *testEnv) fakeEditor(line string) string {
+	e.t.Helper()
+	if runtime.GOOS == "windows" {
+		e.t.Skip("the editor stub is a shell script")
+	}
+	root := filepath.Dir(e.home)
+	opened := filepath.Join(root, "editor-opened")
+	script := filepath.Join(root, "bin", "fake-editor")
+	contents := "#!/bin/sh\n" +
+		"echo \"$1\" >> '" + opened + "'\n" +
+		"echo '" + line + "' >> \"$1\"\n"
+	if err := os.WriteFile(script, []byte(contents), 0o755); err != nil {
+		e.t.Fatalf("failed to write editor stub: %v", err)
+	}
+	e.t.Setenv(editor.EnvVisual, "")
+	e.t.Setenv(editor.EnvEditor, script)
+	return opened
+}
+
+func TestEditActiveConfiguration(t *testing.T) {
+	env := newTestEnv(t)
+	opened := env.fakeEditor("projcet = typo")
+
+	res := env.mustRun("edit")
+
+	if data, _ := os.ReadFile(opened); !strings.HasSuffix(strings.TrimSpace(string(data)), "config_dev") {
+		t.Errorf("editor opened %q, want dev's file", data)
+	}
+	if !strings.Contains(res.stderr, "unknown property core/projcet") {
+		t.Errorf("stderr = %q, want the unknown key warned about", res.stderr)
+	}
+	if !strings.Contains(res.stderr, "this is the active configuration") {
+		t.Errorf("stderr = %q, want the active configuration reminder", res.stderr)
+	}
+	if !strings.Contains(res.stdout, `Saved configuration "dev"`) {
+		t.Errorf("stdout = %q, want the save reported", res.stdout)
+	}
+}
+
+func TestEditOtherConfiguration(t *testing.T) {
+	env := newTestEnv(t)
+	env.fakeEditor("verbosity = debug")
+
+	res := env.mustRun("edit", "prod")
+
+	if strings.Contains(res.stderr, "Warning") || strings.Contains(res.stderr, "active configuration") {
+		t.Errorf("stderr = %q, want no warning or reminder for a known key in an inactive configuration", res.stderr)
+	}
+}
+
+func TestEditRejectsInvalidName(t *testing.T) {
+	env := newTestEnv(t)
+	opened := env.fakeEditor("x = y")
+
+	res := env.run("", "edit", "../../.zshrc")
+
+	if res.err == nil || !strings.Contains(res.stdout, "must start with a letter or digit") {
+		t.Errorf("edit of a path = %v, stdout %q; want the name refused", res.err, res.stdout)
+	}
+	if _, err := os.Stat(opened); !os.IsNotExist(err) {
+		t.Error("the editor ran for an invalid name")
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/shellenv"
+	"github.com/spf13/cobra"
+)
+
+// envProjectVariable overrides the core/project property for a single shell
+const envProjectVariable = "CLOUDSDK_CORE_PROJECT"
+
+// envOptions holds the flags of the env command
+type envOptions struct {
+	*options
+	shell   string
+	project bool
+	unset   bool
+}
+
+func newEnvCmd(parent *options) *cobra.Command {
+	o := &envOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "env [configuration-name]",
+		Short: "Print shell statements that scope a configuration to the current terminal",
+		Long: `Print statements that set CLOUDSDK_ACTIVE_CONFIG_NAME for the current shell.
+
+gcloud gives this variable precedence over the globally active configuration,
+so evaluating the output switches only the current terminal; other terminals
+keep using the configuration chosen with 'gcloudctx <name>'. Without a
+configuration name, the active configuration is used.
+
+The shell syntax is detected from $SHELL and can be chosen with --shell.
+
+Examples:
+  eval "$(gcloudctx env prod)"                 # Bash / Zsh
+  gcloudctx env prod --shell fish | source     # Fish
+  gcloudctx env prod --shell powershell | Invoke-Expression
+  eval "$(gcloudctx env prod --project)"       # Also set CLOUDSDK_CORE_PROJECT
+  eval "$(gcloudctx env --unset)"              # Follow the global configuration again`,
+		Args:              cobra.MaximumNArgs(1),
+		RunE:              o.runEnv,
+		ValidArgsFunction: completeConfigNames,
+	}
+	cmd.Flags().StringVar(&o.shell, "shell", "", "Shell syntax: bash, zsh, fish or powershell (default: detected from $SHELL)")
+	cmd.Flags().BoolVar(&o.project, "project", false, "Also set CLOUDSDK_CORE_PROJECT to the configuration's project")
+	cmd.Flags().BoolVar(&o.unset, "unset", false, "Print statements removing the variables instead")
+	_ = cmd.RegisterFlagCompletionFunc("shell", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		shells := make([]string, len(shellenv.Shells))
+		for i, shell := range shellenv.Shells {
+			shells[i] = string(shell)
+		}
+		return shells, cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+// runEnv prints statements meant for eval, so errors are only returned
+// (cobra reports them on stderr) and never printed to stdout
+func (o *envOptions) runEnv(cmd *cobra.Command, args []string) error {
+	shell := shellenv.DetectShell(os.Getenv("SHELL"))
+	if o.shell != "" {
+		var err error
+		shell, err = shellenv.ParseShell(o.shell)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.unset {
+		if len(args) > 0 {
+			return fmt.Errorf("--unset does not take a configuration name")
+		}
+		// Always unset both so a project set by an earlier 'env --project' is cleared
+		statements, err := shellenv.Unset(shell, []string{gcloud.EnvActiveConfigName, envProjectVariable})
+		if err != nil {
+			return err
+		}
+		fmt.Print(statements)
+		return nil
+	}
+
+	var config *gcloud.Configuration
+	var err error
+	if len(args) == 0 {
+		config, err = gcloud.GetActiveConfiguration()
+	} else {
+		config, err = gcloud.GetConfigurationInfo(args[0])
+	}
+	if err != nil {
+		return withBootstrapHint(err)
+	}
+
+	vars := []shellenv.Var{{Name: gcloud.EnvActiveConfigName, Value: config.Name}}
+	if o.project {
+		if project := config.Properties.Core.Project; project != "" {
+			vars = append(vars, shellenv.Var{Name: envProjectVariable, Value: project})
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: configuration %q has no project; %s is not set\n", config.Name, envProjectVariable)
+		}
+	}
+
+	statements, err := shellenv.Set(shell, vars)
+	if err != nil {
+		return err
+	}
+	fmt.Print(statements)
+	return nil
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// example is a gcloudctx invocation advertised in a command's help
+type example struct {
+	// command is the path of the command whose help shows the example
+	command string
+	// line is the example line as written
+	line string
+	// args are the arguments after "gcloudctx"
+	args []string
+}
+
+// commandExamples returns the gcloudctx invocations in the Examples sections
+// of cmd and its subcommands
+func commandExamples(t *testing.T, cmd *cobra.Command) []example {
+	t.Helper()
+	var examples []example
+	for _, line := range exampleLines(cmd.Long + "\n" + cmd.Example) {
+		for _, invocation := range gcloudctxInvocations(line) {
+			words, err := splitExampleWords(invocation)
+			if err != nil {
+				t.Errorf("%s: cannot read example %q: %v", cmd.CommandPath(), line, err)
+				continue
+			}
+			examples = append(examples, example{command: cmd.CommandPath(), line: line, args: words[1:]})
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		examples = append(examples, commandExamples(t, sub)...)
+	}
+	return examples
+}
+
+// exampleLines returns the indented lines following "Examples:" in help text,
+// without comment lines
+func exampleLines(help string) []string {
+	var lines []string
+	inExamples := false
+	for line := range strings.Lines(help) {
+		line = strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "Examples:":
+			inExamples = true
+		case !inExamples || trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case line == trimmed:
+			// Unindented prose ends the examples
+			inExamples = false
+		default:
+			lines = append(lines, strings.TrimPrefix(trimmed, "$ "))
+		}
+	}
+	return lines
+}
+
+// gcloudctxInvocations returns every gcloudctx command in a shell line, such as
+// both commands of "gcloudctx a && gcloudctx b" or the one inside "$(...)"
+// A command ends at an unquoted ), |, &, ; or comment
+func gcloudctxInvocations(line string) []string {
+	var invocations []string
+	for start := 0; ; {
+		i := strings.Index(line[start:], "gcloudctx")
+		if i < 0 {
+			return invocations
+		}
+		i += start
+		after := i + len("gcloudctx")
+		if (i > 0 && !strings.ContainsRune(" \t(\"'", rune(line[i-1]))) || (after < len(line) && !strings.ContainsRune(" \t)\"'", rune(line[after]))) {
+			start = after
+			continue
+		}
+
+		end, quote := i, byte(0)
+	scan:
+		for ; end < len(line); end++ {
+			c := line[end]
+			switch {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+			case c == '\'' || c == '"':
+				quote = c
+			case strings.IndexByte(")|&;", c) >= 0:
+				break scan
+			case c == '#' && line[end-1] == ' ':
+				break scan
+			}
+		}
+		invocations = append(invocations, strings.TrimSpace(line[i:end]))
+		start = end
+	}
+}
+
+// splitExampleWords splits a command into words, removing shell quotes
+func splitExampleWords(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord, quote := false, rune(0)
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// parseExample resolves args on a new command tree the way Execute does,
+// returning flag parsing and argument validation errors without running anything
+func parseExample(args []string) error {
+	root := NewRootCommand()
+	cmd, rest, err := root.Find(normalizeHistoryJumpArgs(root, args))
+	if err != nil {
+		return err
+	}
+	if err := cmd.ParseFlags(rest); err != nil {
+		return err
+	}
+	if err := cmd.ValidateArgs(cmd.Flags().Args()); err != nil {
+		return err
+	}
+	if err := cmd.ValidateRequiredFlags(); err != nil {
+		return err
+	}
+	return cmd.ValidateFlagGroups()
+}
+
+// usageErrors are the messages cobra and pflag fail with when a command line
+// does not fit the command, as opposed to the command failing while it runs
+var usageErrors = []string{
+	"unknown command",
+	"unknown flag",
+	"unknown shorthand flag",
+	"flag needs an argument",
+	"for \"-",
+	"accepts ",
+	"requires at least",
+	"requires at most",
+	"received ",
+	"required flag(s)",
+	"if any flags in the group",
+}
+
+// isUsageError reports whether err says the command line itself is wrong
+func isUsageError(err error) bool {
+	for _, message := range usageErrors {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestExamplesInHelp(t *testing.T) {
+	examples := commandExamples(t, NewRootCommand())
+	if len(examples) < 50 {
+		t.Fatalf("found %d examples, want every command's examples", len(examples))
+	}
+
+	for _, ex := range examples {
+		t.Run(strings.Join(append([]string{"gcloudctx"}, ex.args...), " "), func(t *testing.T) {
+			if err := parseExample(ex.args); err != nil {
+				t.Fatalf("%s advertises %q, which does not parse: %v", ex.command, ex.line, err)
+			}
+
+			// Running it may fail for want of files or a terminal, but never
+			// because the command line is wrong
+			env := newTestEnv(t)
+			if res := env.run("", ex.args...); res.err != nil && isUsageError(res.err) {
+				t.Errorf("%s advertises %q, which fails: %v", ex.command, ex.line, res.err)
+			}
+		})
+	}
+}
+
+func TestGcloudctxInvocations(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`gcloudctx auto              # Switch based on .gcloudctx file`, []string{"gcloudctx auto"}},
+		{`gcloudctx prod --sync-adc && gcloudctx adc save prod`, []string{"gcloudctx prod --sync-adc", "gcloudctx adc save prod"}},
+		{`eval "$(gcloudctx env prod --project)"       # Also set CLOUDSDK_CORE_PROJECT`, []string{"gcloudctx env prod --project"}},
+		{`gcloudctx env prod --shell fish | source     # Fish`, []string{"gcloudctx env prod --shell fish"}},
+		{`EDITOR="code --wait" gcloudctx edit`, []string{"gcloudctx edit"}},
+		{`gcloudctx export automation --redact-pattern 'billing|registry\.internal'`, []string{`gcloudctx export automation --redact-pattern 'billing|registry\.internal'`}},
+		{`PS1='[$(gcloudctx prompt)] \w $ '`, []string{"gcloudctx prompt"}},
+		{`cat "$(gcloudctx paths --only history)"`, []string{"gcloudctx paths --only history"}},
+		{`see the gcloudctx-backup.tar.gz file`, nil},
+	}
+
+	for _, tt := range tests {
+		got := gcloudctxInvocations(tt.line)
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("gcloudctxInvocations(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
@@ -4,59 +4,130 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 
 	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
 	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/notes"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
-var (
-	exportFormatFlag string
-	exportOutputFlag string
-)
-
-// ExportConfig represents the exported configuration format
-type ExportConfig struct {
-	Name    string `json:"name" yaml:"name"`
-	Account string `json:"account,omitempty" yaml:"account,omitempty"`
-	Project string `json:"project,omitempty" yaml:"project,omitempty"`
-	Region  string `json:"region,omitempty" yaml:"region,omitempty"`
-	Zone    string `json:"zone,omitempty" yaml:"zone,omitempty"`
+// exportOptions holds the flags of the export command
+type exportOptions struct {
+	*options
+	format        string
+	outputPath    string
+	redactPattern string
+	prefix        string
+	includeNotes  bool
 }
 
-var exportCmd = &cobra.Command{
-	Use:   "export [configuration-name]",
-	Short: "Export a gcloud configuration to a file",
-	Long: `Export a gcloud configuration to YAML or JSON format.
+func newExportCmd(parent *options) *cobra.Command {
+	o := &exportOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "export [configuration-name]",
+		Short: "Export a gcloud configuration to a file",
+		Long: `Export a gcloud configuration to YAML, JSON or environment variable format.
 
 The exported file can be used to import the configuration on another machine
 or share it with team members.
 
+Values matching --redact-pattern (a regular expression) are not written to the
+file. Instead they are replaced with a reference to an environment variable
+named GCLOUDCTX_<PROPERTY> (e.g. GCLOUDCTX_PROJECT):
+
+  project:
+    valueFrom:
+      env: GCLOUDCTX_PROJECT
+
+'gcloudctx import' resolves such references from the importer's environment
+and fails if the variable is unset. You can also write valueFrom references
+by hand, using any variable name.
+
+The file also records how Application Default Credentials should be synced:
+impersonate_service_account comes from the configuration's auth section (or
+from an earlier import), and sync_adc from an earlier import. Importing the
+file remembers both for 'gcloudctx <name> --sync-adc'.
+
+The env and dotenv formats write the properties as environment variables that
+gcloud reads (CLOUDSDK_CORE_PROJECT, CLOUDSDK_COMPUTE_REGION, ...). env writes
+"export" lines quoted for POSIX shells; dotenv writes lines for .env files.
+Empty properties are omitted, and --prefix replaces the CLOUDSDK_ prefix.
+
+With --include-notes, the configuration's note (see 'gcloudctx note') is
+written to the yaml and json formats, so whoever imports the file with
+--include-notes gets the context too.
+
 Examples:
   gcloudctx export production                    # Export to stdout (YAML)
   gcloudctx export production -o config.yaml     # Export to file
   gcloudctx export production --format json      # Export as JSON
-  gcloudctx export                               # Export current configuration`,
-	Args:              cobra.MaximumNArgs(1),
-	RunE:              runExport,
-	ValidArgsFunction: completeConfigNames,
+  eval "$(gcloudctx export production --format env)"  # Set CLOUDSDK_* variables
+  gcloudctx export production --format dotenv -o .env  # Write a .env file
+  gcloudctx export production --format dotenv --prefix TF_VAR_
+  gcloudctx export                               # Export current configuration
+  gcloudctx export automation --redact-pattern 'billing|registry\.internal'
+  gcloudctx export production --include-notes -o production.yaml`,
+		Args:              cobra.MaximumNArgs(1),
+		RunE:              o.runExport,
+		ValidArgsFunction: completeConfigNames,
+	}
+	cmd.Flags().StringVarP(&o.format, "format", "f", "yaml", "Output format (yaml, json, env or dotenv)")
+	cmd.Flags().StringVarP(&o.outputPath, "output", "o", "", "Output file (defaults to stdout)")
+	cmd.Flags().StringVar(&o.redactPattern, "redact-pattern", "", "Replace values matching this regular expression with environment variable references")
+	cmd.Flags().StringVar(&o.prefix, "prefix", configfile.DefaultEnvVarPrefix, "Variable name prefix for the env and dotenv formats")
+	cmd.Flags().BoolVar(&o.includeNotes, "include-notes", false, "Include the configuration's note (yaml and json formats)")
+	_ = cmd.RegisterFlagCompletionFunc("output", o.completeOutputPath)
+	return cmd
 }
 
-func init() {
-	exportCmd.Flags().StringVarP(&exportFormatFlag, "format", "f", "yaml", "Output format (yaml or json)")
-	exportCmd.Flags().StringVarP(&exportOutputFlag, "output", "o", "", "Output file (defaults to stdout)")
-	rootCmd.AddCommand(exportCmd)
+// exportFileNames suggest a file name for each format, given the configuration name
+var exportFileNames = map[string]func(name string) string{
+	"yaml":   func(name string) string { return name + ".yaml" },
+	"json":   func(name string) string { return name + ".json" },
+	"env":    func(name string) string { return name + ".env" },
+	"dotenv": func(string) string { return ".env" },
+}
+
+// completeOutputPath suggests a file named after the exported configuration,
+// such as prod.yaml, falling back to file completion
+func (o *exportOptions) completeOutputPath(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	fileName, ok := exportFileNames[o.format]
+	if !ok {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	} else if active, err := gcloud.ActiveConfigName(); err == nil {
+		name = active
+	}
+	if name == "" {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	suggestion := fileName(name)
+	if !strings.HasPrefix(suggestion, toComplete) {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return []string{suggestion}, cobra.ShellCompDirectiveDefault
 }
 
-func runExport(cmd *cobra.Command, args []string) error {
+func (o *exportOptions) runExport(cmd *cobra.Command, args []string) error {
 	var configName string
 
 	if len(args) == 0 {
 		// Export current configuration
 		currentConfig, err := gcloud.GetActiveConfiguration()
 		if err != nil {
-			output.PrintError(err.Error(), !noColorFlag)
+			err = withBootstrapHint(err)
+			output.PrintError(err.Error(), !o.noColor)
 			return err
 		}
 		configName = currentConfig.Name
@@ -64,25 +135,48 @@ func runExport(cmd *cobra.Command, args []string) error {
 		configName = args[0]
 	}
 
+	// Compile the redaction pattern before making gcloud calls
+	var redactPattern *regexp.Regexp
+	if o.redactPattern != "" {
+		var err error
+		redactPattern, err = regexp.Compile(o.redactPattern)
+		if err != nil {
+			output.PrintError(fmt.Sprintf("invalid --redact-pattern: %v", err), !o.noColor)
+			return err
+		}
+	}
+
+	if err := configfile.ValidateEnvPrefix(o.prefix); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
 	// Get configuration info
 	config, err := gcloud.GetConfigurationInfo(configName)
 	if err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
-	// Build export structure
-	exportConfig := ExportConfig{
-		Name:    config.Name,
-		Account: config.Properties.Core.Account,
-		Project: config.Properties.Core.Project,
-		Region:  config.Properties.Compute.Region,
-		Zone:    config.Properties.Compute.Zone,
+	exportConfig := buildExportConfig(config)
+	if o.includeNotes {
+		texts, err := notes.Load()
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		exportConfig.Note = texts[config.Name]
+	}
+
+	if redactPattern != nil {
+		for _, property := range configfile.Redact(&exportConfig, redactPattern) {
+			fmt.Fprintf(os.Stderr, "Redacted %s (import requires $%s)\n", property, configfile.EnvVarName(property))
+		}
 	}
 
 	// Marshal to the requested format
 	var data []byte
-	switch exportFormatFlag {
+	switch o.format {
 	case "yaml", "yml":
 		data, err = yaml.Marshal(exportConfig)
 	case "json":
@@ -90,26 +184,72 @@ func runExport(cmd *cobra.Command, args []string) error {
 		if err == nil {
 			data = append(data, '\n')
 		}
+	case "env":
+		data, err = o.marshalEnvVars(&exportConfig, configfile.EnvStyleShell)
+	case "dotenv":
+		data, err = o.marshalEnvVars(&exportConfig, configfile.EnvStyleDotenv)
 	default:
-		output.PrintError(fmt.Sprintf("unsupported format: %s (use yaml or json)", exportFormatFlag), !noColorFlag)
+		output.PrintError(fmt.Sprintf("unsupported format: %s (use yaml, json, env or dotenv)", o.format), !o.noColor)
 		return fmt.Errorf("unsupported format")
 	}
 
 	if err != nil {
-		output.PrintError(fmt.Sprintf("failed to marshal configuration: %v", err), !noColorFlag)
+		output.PrintError(fmt.Sprintf("failed to marshal configuration: %v", err), !o.noColor)
 		return err
 	}
 
 	// Write output
-	if exportOutputFlag != "" {
-		if err := os.WriteFile(exportOutputFlag, data, 0o600); err != nil {
-			output.PrintError(fmt.Sprintf("failed to write file: %v", err), !noColorFlag)
+	if o.outputPath != "" {
+		if err := os.WriteFile(o.outputPath, data, 0o600); err != nil {
+			output.PrintError(fmt.Sprintf("failed to write file: %v", err), !o.noColor)
 			return err
 		}
-		output.PrintSuccess(fmt.Sprintf("exported configuration %q to %s", configName, exportOutputFlag), !noColorFlag)
+		output.PrintSuccess(fmt.Sprintf("exported configuration %q to %s", configName, o.outputPath), !o.noColor)
 	} else {
 		fmt.Print(string(data))
 	}
 
 	return nil
 }
+
+// marshalEnvVars renders the configuration as environment variable assignments, one per line
+func (o *exportOptions) marshalEnvVars(config *configfile.Config, style configfile.EnvStyle) ([]byte, error) {
+	lines, err := configfile.EnvVarLines(config, o.prefix, style)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// buildExportConfig converts a configuration to the export file format, including its ADC hints
+func buildExportConfig(config *gcloud.Configuration) configfile.Config {
+	exportConfig := configfile.Config{
+		Name:    config.Name,
+		Account: configfile.Literal(config.Properties.Core.Account),
+		Project: configfile.Literal(config.Properties.Core.Project),
+		Region:  configfile.Literal(config.Properties.Compute.Region),
+		Zone:    configfile.Literal(config.Properties.Compute.Zone),
+	}
+
+	// Carry the ADC hints so the importer knows how to sync credentials
+	hint, err := adc.GetHint(config.Name)
+	if err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to read ADC hint: %w", err))
+	}
+	impersonate, _ := config.Properties.Sections["auth"]["impersonate_service_account"].(string)
+	if impersonate == "" {
+		impersonate = hint.ImpersonateServiceAccount
+	}
+	exportConfig.ImpersonateServiceAccount = configfile.Literal(impersonate)
+	exportConfig.SyncADC = hint.SyncADC
+
+	if disabled, set := config.Properties.UsageReportingDisabled(); set {
+		exportConfig.DisableUsageReporting = &disabled
+	}
+
+	return exportConfig
+}
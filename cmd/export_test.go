@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExportActive(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("export")
+
+	var exported configfile.Config
+	if err := yaml.Unmarshal([]byte(res.stdout), &exported); err != nil {
+		t.Fatalf("stdout is not an export file: %v\n%s", err, res.stdout)
+	}
+	if exported.Name != "dev" || exported.Project.Literal != "dev-project" || exported.Account.Literal != "dev@example.com" {
+		t.Errorf("exported = %+v, want dev", exported)
+	}
+}
+
+func TestExportJSONToFile(t *testing.T) {
+	env := newTestEnv(t)
+	path := filepath.Join(env.workDir, "prod.json")
+
+	env.mustRun("export", "prod", "--format", "json", "--output", path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("export file not written: %v", err)
+	}
+	var exported configfile.Config
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("export file is not JSON: %v\n%s", err, data)
+	}
+	if exported.Name != "prod" || exported.Project.Literal != "prod-project" {
+		t.Errorf("exported = %+v, want prod", exported)
+	}
+}
+
+func TestExportRedacted(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("export", "prod", "--redact-pattern", "prod-project")
+
+	if strings.Contains(res.stdout, "prod-project") || !strings.Contains(res.stdout, "valueFrom") {
+		t.Errorf("stdout = %q, want the project replaced by a reference", res.stdout)
+	}
+	if !strings.Contains(res.stderr, "Redacted") {
+		t.Errorf("stderr = %q, want the redaction reported", res.stderr)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	env := newTestEnv(t)
+	path := filepath.Join(env.workDir, "prod.yaml")
+	env.mustRun("export", "prod", "--output", path)
+
+	env.mustRun("import", path, "--name", "prod-copy")
+
+	source, _ := env.gcloud.Properties("prod")
+	imported, _ := env.gcloud.Properties("prod-copy")
+	if imported["core/project"] != source["core/project"] || imported["core/account"] != source["core/account"] {
+		t.Errorf("imported properties = %v, want those of prod %v", imported, source)
+	}
+}
+
+func TestExportImportImpersonation(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("deploy", map[string]string{
+		"core/project":                     "deploy-project",
+		"auth/impersonate_service_account": "deployer@deploy-project.iam.gserviceaccount.com",
+	})
+	path := filepath.Join(env.workDir, "deploy.yaml")
+	env.mustRun("export", "deploy", "--output", path)
+
+	env.mustRun("import", path, "--name", "deploy-copy")
+
+	imported, _ := env.gcloud.Properties("deploy-copy")
+	if got := imported["auth/impersonate_service_account"]; got != "deployer@deploy-project.iam.gserviceaccount.com" {
+		t.Errorf("imported auth/impersonate_service_account = %q, want the exported service account", got)
+	}
+}
+
+func TestExportMissing(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "export", "staging")
+
+	if res.err == nil || !strings.Contains(res.stdout, `configuration "staging" not found`) {
+		t.Errorf("exporting a missing configuration = %v, stdout %q; want an error", res.err, res.stdout)
+	}
+}
+
+func TestExportImportUsageReporting(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "disabled", value: "True", want: "disable_usage_reporting: true"},
+		{name: "enabled", value: "False", want: "disable_usage_reporting: false"},
+		{name: "absent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			properties := map[string]string{"core/project": "ci-project"}
+			if tt.value != "" {
+				properties["core/disable_usage_reporting"] = tt.value
+			}
+			env.gcloud.Add("ci", properties)
+			path := filepath.Join(env.workDir, "ci.yaml")
+
+			env.mustRun("export", "ci", "--output", path)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != "" && !strings.Contains(string(data), tt.want) {
+				t.Errorf("export file = %q, want %q", data, tt.want)
+			}
+			if tt.want == "" && strings.Contains(string(data), "disable_usage_reporting") {
+				t.Errorf("export file = %q, want usage reporting left out", data)
+			}
+
+			env.mustRun("import", path, "--name", "ci-copy")
+			imported, _ := env.gcloud.Properties("ci-copy")
+			if got, set := imported["core/disable_usage_reporting"]; got != tt.value || set != (tt.value != "") {
+				t.Errorf("imported core/disable_usage_reporting = %q (set %v), want %q", got, set, tt.value)
+			}
+		})
+	}
+}
+
+func TestExportOutputCompletion(t *testing.T) {
+	env := newTestEnv(t)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"export", "prod", "-o", ""}, "prod.yaml\n:0\n"},
+		{[]string{"export", "-o", ""}, "dev.yaml\n:0\n"},
+		{[]string{"export", "prod", "--format", "json", "-o", "pr"}, "prod.json\n:0\n"},
+		{[]string{"export", "prod", "--format", "dotenv", "-o", ""}, ".env\n:0\n"},
+		{[]string{"export", "prod", "-o", "out/"}, ":0\n"},
+	}
+	for _, tt := range tests {
+		res := env.mustRun(append([]string{"__complete"}, tt.args...)...)
+		if res.stdout != tt.want {
+			t.Errorf("completing %q = %q, want %q", tt.args, res.stdout, tt.want)
+		}
+	}
+}
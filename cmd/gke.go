@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/cache"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// gkeClusterTimeout bounds the cluster lookup after a switch; the switch is
+// done by then, so a slow API only costs the suggestion
+const gkeClusterTimeout = 5 * time.Second
+
+// gkeClusterCacheTTL is how long the clusters of a project are cached
+const gkeClusterCacheTTL = 10 * time.Minute
+
+// gkeClusterCacheKey is the cache key of the clusters of project
+func gkeClusterCacheKey(project string) string {
+	return "gke-clusters-" + project
+}
+
+// runGKEIntegration fetches the credentials of the configured cluster of
+// target, or suggests the commands fetching them, as set in the gke settings
+// Failures never fail the switch; they are reported as warnings
+func (o *options) runGKEIntegration(target *gcloud.Configuration, messages io.Writer) {
+	if o.noHooks {
+		return
+	}
+	gke := o.gkeSettings.For(target.Name)
+	project := target.Properties.Core.Project
+	if project == "" || (!gke.Suggest && gke.AutoCredentials == "") {
+		return
+	}
+
+	if gke.AutoCredentials != "" {
+		if gcloud.Offline() {
+			fmt.Fprintf(messages, "GKE credentials for %s (skipped: offline)\n", gke.AutoCredentials)
+			return
+		}
+		cluster, err := gcloud.ParseCluster(gke.AutoCredentials)
+		if err == nil {
+			err = gcloud.GetClusterCredentials(cluster, project)
+		}
+		if err != nil {
+			// Non-fatal error, just warn
+			fmt.Fprintf(os.Stderr, "Warning: gke.auto_credentials of %q: %v\n", target.Name, err)
+			return
+		}
+		output.PrintSuccess(fmt.Sprintf("fetched credentials for GKE cluster %s", cluster), !o.noColor)
+		return
+	}
+
+	clusters, err := projectClusters(project)
+	if errors.Is(err, gcloud.ErrOffline) {
+		fmt.Fprintln(messages, "GKE cluster suggestions (skipped: offline)")
+		return
+	}
+	if err != nil {
+		// Non-fatal error, just warn
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	if len(clusters) == 0 {
+		return
+	}
+	fmt.Fprintf(messages, "GKE clusters in %s; to use one with kubectl:\n", project)
+	for _, cluster := range clusters {
+		fmt.Fprintf(messages, "  gcloud %s\n", strings.Join(gcloud.ClusterCredentialsArgs(cluster, project), " "))
+	}
+}
+
+// projectClusters returns the GKE clusters of project from the cache, or from
+// gcloud within gkeClusterTimeout
+func projectClusters(project string) ([]gcloud.Cluster, error) {
+	var clusters []gcloud.Cluster
+	if found, err := cache.Load(gkeClusterCacheKey(project), gkeClusterCacheTTL, &clusters); err == nil && found {
+		return clusters, nil
+	}
+	if gcloud.Offline() {
+		return nil, fmt.Errorf("%w: the GKE clusters of %q are not cached", gcloud.ErrOffline, project)
+	}
+
+	type listResult struct {
+		clusters []gcloud.Cluster
+		err      error
+	}
+	// The lookup is abandoned rather than killed on timeout; gcloudctx exits
+	// right after the switch anyway
+	done := make(chan listResult, 1)
+	go func() {
+		clusters, err := gcloud.ListClusters(project)
+		done <- listResult{clusters, err}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err != nil {
+			return nil, result.err
+		}
+		_ = cache.Save(gkeClusterCacheKey(project), result.clusters)
+		return result.clusters, nil
+	case <-time.After(gkeClusterTimeout):
+		return nil, fmt.Errorf("listing the GKE clusters of %q took longer than %s; skipped the suggestion", project, gkeClusterTimeout)
+	}
+}
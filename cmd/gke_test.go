@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// containerCalls returns the gcloud container commands env ran
+func containerCalls(env *testEnv) []string {
+	var calls []string
+	for _, call := range env.gcloud.Calls() {
+		if strings.HasPrefix(call, "container ") {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func TestGKESuggest(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeSettings("gke:\n  suggest: true\n")
+	env.gcloud.AddCluster("prod-project", gcloud.Cluster{Name: "main", Location: "europe-west1"})
+	env.gcloud.AddCluster("prod-project", gcloud.Cluster{Name: "batch", Location: "us-central1-a"})
+
+	res := env.mustRun("prod")
+
+	for _, want := range []string{
+		"gcloud container clusters get-credentials main --location europe-west1 --project prod-project",
+		"gcloud container clusters get-credentials batch --location us-central1-a --project prod-project",
+	} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("stdout = %q, want %q", res.stdout, want)
+		}
+	}
+
+	// The clusters are cached, so switching back and forth lists them once
+	env.mustRun("dev")
+	env.mustRun("prod")
+	if calls := containerCalls(env); len(calls) != 2 {
+		t.Errorf("container calls = %q, want one list per project", calls)
+	}
+}
+
+func TestGKEAutoCredentials(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeSettings("gke:\n  configurations:\n    prod:\n      auto_credentials: main@europe-west1\n")
+	env.gcloud.AddCluster("prod-project", gcloud.Cluster{Name: "main", Location: "europe-west1"})
+
+	res := env.mustRun("prod")
+
+	want := "container clusters get-credentials main --location europe-west1 --project prod-project"
+	if calls := containerCalls(env); !slices.Equal(calls, []string{want}) {
+		t.Errorf("container calls = %q, want only %q", calls, want)
+	}
+	if !strings.Contains(res.stdout, "fetched credentials for GKE cluster main@europe-west1") {
+		t.Errorf("stdout = %q, want the credentials reported", res.stdout)
+	}
+
+	// Other configurations are left alone
+	env.mustRun("dev")
+	if calls := containerCalls(env); len(calls) != 1 {
+		t.Errorf("container calls after switching to dev = %q, want none for dev", calls)
+	}
+}
+
+func TestGKEFailuresDoNotFailSwitch(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+	}{
+		{name: "unknown cluster", settings: "gke:\n  configurations:\n    prod:\n      auto_credentials: gone@europe-west1\n"},
+		{name: "invalid cluster", settings: "gke:\n  configurations:\n    prod:\n      auto_credentials: main\n"},
+		{name: "list fails", settings: "gke:\n  suggest: true\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.writeSettings(tt.settings)
+			env.gcloud.Fail("container clusters list", "ERROR: (gcloud.container.clusters.list) PERMISSION_DENIED: Kubernetes Engine API has not been used")
+
+			res := env.run("", "prod")
+
+			if res.err != nil {
+				t.Fatalf("switch failed: %v", res.err)
+			}
+			if env.gcloud.Active() != "prod" {
+				t.Errorf("active = %q, want prod", env.gcloud.Active())
+			}
+			if !strings.Contains(res.stderr, "Warning:") {
+				t.Errorf("stderr = %q, want the failure reported as a warning", res.stderr)
+			}
+		})
+	}
+}
+
+func TestGKEOffByDefault(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.AddCluster("prod-project", gcloud.Cluster{Name: "main", Location: "europe-west1"})
+
+	env.mustRun("prod")
+
+	if calls := containerCalls(env); len(calls) != 0 {
+		t.Errorf("container calls = %q, want none without gke settings", calls)
+	}
+}
+
+func TestGKESkippedWithNoHooks(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeSettings("gke:\n  suggest: true\n")
+
+	env.mustRun("prod", "--no-hooks")
+
+	if calls := containerCalls(env); len(calls) != 0 {
+		t.Errorf("container calls = %q, want none with --no-hooks", calls)
+	}
+}
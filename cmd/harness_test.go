@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/gcloudtest"
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/internal/prompt"
+	"github.com/Okabe-Junya/gcloudctx/internal/update"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/session"
+	"github.com/fatih/color"
+)
+
+// testEnv runs gcloudctx commands in-process against a fake gcloud, with its
+// own home, cache, gcloud configuration and working directories
+type testEnv struct {
+	t      *testing.T
+	gcloud *gcloudtest.FakeRunner
+	// home is $HOME, holding the settings and history files
+	home string
+	// configDir is gcloud's configuration directory ($CLOUDSDK_CONFIG), the default one under home
+	configDir string
+	// workDir is the working directory of every command
+	workDir string
+	// terminal is whether stdin counts as a terminal for confirmation
+	// prompts; it does unless a test says otherwise
+	terminal bool
+	// tty stands in for the controlling terminal prompts fall back to when
+	// stdin is not a terminal; nil means there is none
+	tty *fakeTTY
+}
+
+// fakeTTY is a controlling terminal answering with its input and recording
+// the questions asked on it
+type fakeTTY struct {
+	*strings.Reader
+	questions strings.Builder
+}
+
+func (f *fakeTTY) Write(p []byte) (int, error) { return f.questions.Write(p) }
+func (f *fakeTTY) Close() error                { return nil }
+
+// result is what a command printed and returned
+type result struct {
+	stdout string
+	stderr string
+	err    error
+}
+
+// newTestEnv creates an environment with the configurations dev (active,
+// project dev-project) and prod (project prod-project)
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	env := newEmptyTestEnv(t)
+	env.gcloud.Add("dev", map[string]string{"core/project": "dev-project", "core/account": "dev@example.com"})
+	env.gcloud.Add("prod", map[string]string{"core/project": "prod-project", "core/account": "ops@example.com"})
+	return env
+}
+
+// newEmptyTestEnv creates an environment where gcloud has no configurations,
+// as on a machine where 'gcloud init' never ran
+func newEmptyTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	root := t.TempDir()
+	env := &testEnv{
+		t:         t,
+		home:      filepath.Join(root, "home"),
+		configDir: filepath.Join(root, "home", ".config", "gcloud"),
+		workDir:   filepath.Join(root, "work"),
+		terminal:  true,
+	}
+	bin := filepath.Join(root, "bin")
+	for _, dir := range []string{env.home, env.workDir, bin} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+
+	// Commands check that gcloud is installed; the stub fails if anything runs it
+	stub := filepath.Join(bin, "gcloud")
+	if runtime.GOOS == "windows" {
+		stub += ".cmd"
+	}
+	if err := os.WriteFile(stub, []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatalf("failed to write gcloud stub: %v", err)
+	}
+
+	t.Setenv("HOME", env.home)
+	t.Setenv("USERPROFILE", env.home)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(root, "cache"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+	t.Setenv("PATH", bin)
+	t.Setenv(gcloud.EnvConfigDir, env.configDir)
+	t.Setenv(gcloud.EnvGcloudPath, "")
+	t.Setenv(gcloud.EnvActiveConfigName, "")
+	t.Setenv(gcloud.EnvReadOnly, "")
+	t.Setenv(gcloud.EnvOffline, "")
+	t.Setenv(session.EnvSession, "")
+	t.Setenv(output.EnvDisableSpinner, "1")
+	t.Setenv("NO_COLOR", "1")
+	// Tests never look up releases on GitHub; doctorReleaseServer serves one
+	t.Setenv(update.EnvDisable, "1")
+	t.Chdir(env.workDir)
+
+	env.gcloud = gcloudtest.Install(t, env.configDir)
+
+	noColor, isTerminal, openTerminal := color.NoColor, prompt.IsTerminal, prompt.OpenTerminal
+	color.NoColor = true
+	prompt.IsTerminal = func() bool { return env.terminal }
+	prompt.OpenTerminal = func() (io.ReadWriteCloser, error) {
+		if env.tty == nil {
+			return nil, errors.New("no controlling terminal")
+		}
+		return env.tty, nil
+	}
+	t.Cleanup(func() {
+		color.NoColor = noColor
+		prompt.IsTerminal, prompt.OpenTerminal = isTerminal, openTerminal
+		gcloud.SetConfigRoot("")
+	})
+	return env
+}
+
+// run runs gcloudctx with args on a new command tree, as the binary does,
+// feeding it stdin, and captures its output
+func (e *testEnv) run(stdin string, args ...string) result {
+	e.t.Helper()
+
+	stdout, stderr := e.tempFile("stdout", ""), e.tempFile("stderr", "")
+	in := e.tempFile("stdin", stdin)
+	defer func() {
+		for _, f := range []*os.File{stdout, stderr, in} {
+			_ = f.Close()
+		}
+	}()
+
+	oldStdout, oldStderr, oldStdin := os.Stdout, os.Stderr, os.Stdin
+	os.Stdout, os.Stderr, os.Stdin = stdout, stderr, in
+	defer func() {
+		os.Stdout, os.Stderr, os.Stdin = oldStdout, oldStderr, oldStdin
+		output.SetMessageOutput(oldStdout)
+	}()
+
+	root := NewRootCommand()
+	root.SetArgs(normalizeHistoryJumpArgs(root, args))
+	err := root.Execute()
+
+	return result{stdout: readAll(e.t, stdout), stderr: readAll(e.t, stderr), err: err}
+}
+
+// mustRun runs gcloudctx with args and fails the test when the command fails
+func (e *testEnv) mustRun(args ...string) result {
+	e.t.Helper()
+	res := e.run("", args...)
+	if res.err != nil {
+		e.t.Fatalf("gcloudctx %s: %v\nstdout: %s\nstderr: %s", strings.Join(args, " "), res.err, res.stdout, res.stderr)
+	}
+	return res
+}
+
+// writeFile writes a file relative to the working directory
+func (e *testEnv) writeFile(name, contents string) string {
+	e.t.Helper()
+	path := filepath.Join(e.workDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		e.t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		e.t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+// writeSettings writes the settings file in the home directory
+func (e *testEnv) writeSettings(contents string) {
+	e.t.Helper()
+	if err := os.WriteFile(filepath.Join(e.home, ".gcloudctx.yaml"), []byte(contents), 0o600); err != nil {
+		e.t.Fatalf("failed to write settings: %v", err)
+	}
+}
+
+// tempFile creates a file holding contents, positioned at its start
+func (e *testEnv) tempFile(name, contents string) *os.File {
+	e.t.Helper()
+	f, err := os.CreateTemp(e.t.TempDir(), name)
+	if err != nil {
+		e.t.Fatalf("failed to create %s: %v", name, err)
+	}
+	if _, err := f.WriteString(contents); err != nil {
+		e.t.Fatalf("failed to write %s: %v", name, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		e.t.Fatalf("failed to rewind %s: %v", name, err)
+	}
+	return f
+}
+
+func readAll(t *testing.T, f *os.File) string {
+	t.Helper()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("failed to rewind %s: %v", f.Name(), err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", f.Name(), err)
+	}
+	return string(data)
+}
+
+// interactiveGcloud replaces the gcloud stub with one that succeeds, printing
+// a line on stdout and one on stderr as gcloud's login does; the returned
+// function returns the arguments of every run, one run per line
+func (e *testEnv) interactiveGcloud() func() string {
+	e.t.Helper()
+	if runtime.GOOS == "windows" {
+		e.t.Skip("the gcloud stub is a shell script")
+	}
+	root := filepath.Dir(e.home)
+	calls := filepath.Join(root, "interactive-calls")
+	script := "#!/bin/sh\n" +
+		"echo \"$*\" >> '" + calls + "'\n" +
+		"echo 'Credentials saved to file'\n" +
+		"echo 'Go to the following link in your browser' >&2\n"
+	if err := os.WriteFile(filepath.Join(root, "bin", "gcloud"), []byte(script), 0o755); err != nil {
+		e.t.Fatalf("failed to write gcloud stub: %v", err)
+	}
+	return func() string {
+		data, err := os.ReadFile(calls)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			e.t.Fatalf("failed to read gcloud calls: %v", err)
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// historyOptions holds the flags of the history command
+type historyOptions struct {
+	*options
+	format string
+}
+
+func newHistoryCmd(parent *options) *cobra.Command {
+	o := &historyOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the previous configurations '-' and '-N' switch to",
+		Long: `Show the previous configurations, most recent first, with the position
+'gcloudctx -N' switches to.
+
+SOURCE tells what switched away from a configuration: gcloudctx, or "external"
+for a switch made without it, such as 'gcloud config configurations activate'
+in another terminal. gcloudctx notices those the next time it runs.
+
+Examples:
+  gcloudctx history            # Table of previous configurations
+  gcloudctx history -o json    # JSON array for tooling`,
+		Args: cobra.NoArgs,
+		RunE: o.runHistory,
+	}
+	cmd.Flags().StringVarP(&o.format, "output", "o", "", "Output format (json, yaml)")
+	return cmd
+}
+
+func (o *historyOptions) runHistory(cmd *cobra.Command, args []string) error {
+	format, err := output.ValidateOutputFormat(o.format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	entries, err := history.GetEntries()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if output.IsMachineFormat(format) {
+		return output.PrintHistory(entries, format)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No previous configurations recorded yet")
+		return nil
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	bold := color.New(color.Bold).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	rows := [][]string{{bold("#"), bold("CONFIGURATION"), bold("SOURCE")}}
+	for i, entry := range entries {
+		source := string(entry.Source)
+		if entry.Source == history.SourceExternal {
+			source = yellow(source)
+		}
+		rows = append(rows, []string{"-" + strconv.Itoa(i+1), entry.Name, source})
+	}
+	for _, line := range output.AlignColumns(rows, 2) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// reconcileActiveConfiguration records a switch made without gcloudctx since
+// it last ran, so '-' goes back to where the user was
+func reconcileActiveConfiguration() {
+	if !history.Enabled() {
+		return
+	}
+	configDir, active, ok := globalActiveConfiguration()
+	if !ok {
+		return
+	}
+	lastActivePath, err := history.GetLastActiveFilePath()
+	if err != nil {
+		return
+	}
+	historyPath, err := history.GetHistoryFilePath()
+	if err != nil {
+		return
+	}
+	if _, err := history.ReconcileActiveAt(lastActivePath, historyPath, configDir, active); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to check for external switches: %w", err))
+	}
+}
+
+// rememberActiveConfiguration records the active configuration after gcloudctx
+// changed gcloud's configuration, so its own switches are not taken for external ones
+func rememberActiveConfiguration() {
+	configDir, active, ok := globalActiveConfiguration()
+	if !ok {
+		return
+	}
+	path, err := history.GetLastActiveFilePath()
+	if err != nil {
+		return
+	}
+	if err := history.RememberActiveAt(path, configDir, active); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, err)
+	}
+}
+
+// globalActiveConfiguration returns gcloud's configuration directory and the
+// configuration its active_config file names; ok is false before anything
+// was activated or when the file cannot be read
+func globalActiveConfiguration() (configDir, active string, ok bool) {
+	configDir, err := gcloud.ConfigDir()
+	if err != nil {
+		return "", "", false
+	}
+	active, err = gcloud.GlobalActiveConfigName()
+	if err != nil || active == "" {
+		return "", "", false
+	}
+	return configDir, active, true
+}
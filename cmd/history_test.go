@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+)
+
+// historyEntries returns the history as 'history -o json' prints it
+func historyEntries(t *testing.T, env *testEnv) []history.Entry {
+	t.Helper()
+	res := env.mustRun("history", "-o", "json")
+	var entries []history.Entry
+	if err := json.Unmarshal([]byte(res.stdout), &entries); err != nil {
+		t.Fatalf("history -o json = %q: %v", res.stdout, err)
+	}
+	return entries
+}
+
+func TestHistoryRecordsExternalSwitch(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.mustRun("-c")
+	// As 'gcloud config configurations activate prod' in another terminal
+	env.gcloud.Activate("prod")
+
+	entries := historyEntries(t, env)
+	if len(entries) != 1 || entries[0].Name != "dev" || entries[0].Source != history.SourceExternal {
+		t.Fatalf("history = %+v, want dev switched away from externally", entries)
+	}
+
+	res := env.mustRun("history")
+	if !strings.Contains(res.stdout, "SOURCE") || !strings.Contains(res.stdout, "external") {
+		t.Errorf("history = %q, want the source column", res.stdout)
+	}
+
+	env.mustRun("-")
+	if env.gcloud.Active() != "dev" {
+		t.Errorf("active = %q after '-', want dev", env.gcloud.Active())
+	}
+	entries = historyEntries(t, env)
+	if len(entries) != 2 || entries[0].Name != "prod" || entries[0].Source != history.SourceGcloudctx {
+		t.Errorf("history = %+v, want prod switched away from by gcloudctx on top", entries)
+	}
+}
+
+func TestHistoryOwnSwitchIsNotExternal(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.mustRun("prod")
+	env.mustRun("-c")
+
+	entries := historyEntries(t, env)
+	if len(entries) != 1 || entries[0].Name != "dev" || entries[0].Source != history.SourceGcloudctx {
+		t.Errorf("history = %+v, want only the gcloudctx switch", entries)
+	}
+}
+
+func TestHistoryEmpty(t *testing.T) {
+	env := newTestEnv(t)
+
+	if res := env.mustRun("history"); !strings.Contains(res.stdout, "No previous configurations") {
+		t.Errorf("history = %q, want the empty message", res.stdout)
+	}
+	if entries := historyEntries(t, env); len(entries) != 0 {
+		t.Errorf("history = %+v, want none", entries)
+	}
+}
+
+// assertNoHistoryFiles fails when the history or usage file was written
+func assertNoHistoryFiles(t *testing.T, env *testEnv) {
+	t.Helper()
+	for _, path := range []string{history.HistoryFilePathIn(env.home), history.UsageFilePathIn(env.home)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was written (stat: %v)", path, err)
+		}
+	}
+}
+
+func TestNoHistoryFlag(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeFile(".gcloudctx", "prod\n")
+
+	env.mustRun("prod", "--no-history")
+	env.mustRun("dev", "--no-history")
+	env.mustRun("auto", "--no-history")
+	env.mustRun("use", "dev", "--switch", "--no-history")
+
+	if env.gcloud.Active() != "dev" {
+		t.Errorf("active = %q, want the switches made", env.gcloud.Active())
+	}
+	assertNoHistoryFiles(t, env)
+
+	// Without the flag the history is kept as usual
+	env.mustRun("prod")
+	if entries := historyEntries(t, env); len(entries) != 1 || entries[0].Name != "dev" {
+		t.Errorf("history = %+v, want dev", entries)
+	}
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeSettings("history:\n  enabled: false\n")
+
+	env.mustRun("prod")
+	// As 'gcloud config configurations activate dev' in another terminal
+	env.gcloud.Activate("dev")
+	env.mustRun("prod")
+	assertNoHistoryFiles(t, env)
+
+	for _, args := range [][]string{{"-"}, {"history"}} {
+		res := env.run("", args...)
+		if res.err == nil || !strings.Contains(res.stdout, "history is disabled") {
+			t.Errorf("gcloudctx %s = %v, stdout %q; want history disabled", strings.Join(args, " "), res.err, res.stdout)
+		}
+	}
+	if env.gcloud.Active() != "prod" {
+		t.Errorf("active = %q, want prod", env.gcloud.Active())
+	}
+}
@@ -2,60 +2,311 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/internal/prompt"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
 	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/notes"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
-var (
-	importActivateFlag  bool
-	importOverwriteFlag bool
-	importNameFlag      string
-)
+// importOptions holds the flags of the import command
+type importOptions struct {
+	*options
+	activate     bool
+	overwrite    bool
+	name         string
+	yes          bool
+	sanitize     bool
+	format       string
+	progress     bool
+	syncADC      bool
+	includeNotes bool
+}
 
-var importCmd = &cobra.Command{
-	Use:   "import <file>",
-	Short: "Import a gcloud configuration from a file",
-	Long: `Import a gcloud configuration from a YAML or JSON file.
+func newImportCmd(parent *options) *cobra.Command {
+	o := &importOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "import <file>...",
+		Short: "Import a gcloud configuration from a file",
+		Long: `Import a gcloud configuration from a YAML or JSON file.
 
 This creates a new configuration with the properties specified in the file.
 The file format is automatically detected from the extension or content.
 
+Property values may reference the importer's environment instead of being
+embedded in the file (this is what 'gcloudctx export --redact-pattern' writes):
+
+  project:
+    valueFrom:
+      env: BILLING_PROJECT
+
+The import fails with an error naming the variable if it is not set.
+
+The optional impersonate_service_account field sets the configuration's
+auth/impersonate_service_account property. It is also remembered with the
+sync_adc field, so 'gcloudctx <name> --sync-adc' (or import --sync-adc)
+impersonates the right service account without further flags.
+
+The note field, written by 'gcloudctx export --include-notes', becomes the
+configuration's note (see 'gcloudctx note') only with --include-notes.
+
 Examples:
   gcloudctx import config.yaml                # Import from YAML file
   gcloudctx import config.json                # Import from JSON file
   gcloudctx import config.yaml --activate     # Import and activate
+  gcloudctx import config.yaml --activate --sync-adc  # Import, activate and sync ADC
   gcloudctx import config.yaml --name myconf  # Import with a different name
-  gcloudctx import config.yaml --overwrite    # Overwrite if exists`,
-	Args: cobra.ExactArgs(1),
-	RunE: runImport,
+  gcloudctx import config.yaml --sanitize-name  # Fix an invalid name such as 'Prod.EU'
+  gcloudctx import config.yaml --include-notes  # Keep the note written by export --include-notes
+  gcloudctx import config.yaml --overwrite    # Overwrite if exists (asks first)
+  gcloudctx import config.yaml --overwrite --yes  # Overwrite without asking
+
+With --overwrite, the existing configuration is changed in place: properties
+the file leaves out are unset and the file's are set. gcloud's files are
+snapshotted first, so a failed import puts the existing configuration back
+exactly as it was.`,
+		Args:              cobra.MinimumNArgs(1),
+		RunE:              o.runImport,
+		ValidArgsFunction: completeImportFiles,
+	}
+	cmd.Flags().BoolVar(&o.activate, "activate", false, "Activate the imported configuration")
+	cmd.Flags().BoolVar(&o.overwrite, "overwrite", false, "Overwrite if configuration already exists")
+	cmd.Flags().StringVar(&o.name, "name", "", "Use a different name for the imported configuration")
+	cmd.Flags().BoolVar(&o.sanitize, "sanitize-name", false, "Turn an invalid name into a valid one (e.g. 'Prod.EU' becomes 'prod-eu')")
+	cmd.Flags().BoolVarP(&o.yes, "yes", "y", false, "Overwrite without confirmation")
+	cmd.Flags().BoolVarP(&o.yes, "force", "f", false, "Overwrite without confirmation (alias for --yes)")
+	cmd.Flags().StringVarP(&o.format, "output", "o", "", "Output format for the import result (json, yaml)")
+	cmd.Flags().BoolVar(&o.syncADC, "sync-adc", false, "Sync Application Default Credentials after activating (requires --activate)")
+	cmd.Flags().BoolVar(&o.noBrowser, "no-browser", false, noBrowserUsage)
+	cmd.Flags().BoolVar(&o.includeNotes, "include-notes", false, "Set the note of each configuration from its file")
+	cmd.Flags().BoolVar(&o.progress, "progress", false, "Report progress on stderr (JSON events with -o json)")
+	_ = cmd.RegisterFlagCompletionFunc("name", cobra.NoFileCompletions)
+	return cmd
 }
 
-func init() {
-	importCmd.Flags().BoolVar(&importActivateFlag, "activate", false, "Activate the imported configuration")
-	importCmd.Flags().BoolVar(&importOverwriteFlag, "overwrite", false, "Overwrite if configuration already exists")
-	importCmd.Flags().StringVar(&importNameFlag, "name", "", "Use a different name for the imported configuration")
-	rootCmd.AddCommand(importCmd)
+// importFileExtensions are the extensions of the files import reads
+var importFileExtensions = []string{"yaml", "yml", "json"}
+
+// completeImportFiles completes only the YAML and JSON files import reads
+func completeImportFiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return importFileExtensions, cobra.ShellCompDirectiveFilterFileExt
 }
 
-func runImport(cmd *cobra.Command, args []string) error {
-	filePath := args[0]
+func (o *importOptions) runImport(cmd *cobra.Command, args []string) error {
+	format, err := output.ValidateOutputFormat(o.format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	machineOutput := output.IsMachineFormat(format)
+
+	if len(args) > 1 && (o.name != "" || o.activate) {
+		err := fmt.Errorf("--name and --activate can only be used with a single file")
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if o.syncADC && !o.activate {
+		err := fmt.Errorf("--sync-adc requires --activate")
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	// Parse and validate every file before any configuration is changed
+	jobs, err := o.loadImportJobs(args)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	// Confirm overwrites once for the whole batch if not forced
+	if !o.yes {
+		var overwrites []string
+		for _, job := range jobs {
+			if job.err == nil && job.exists {
+				overwrites = append(overwrites, job.name)
+			}
+		}
+		if len(overwrites) > 0 {
+			confirmed, err := confirmImportOverwrite(overwrites, machineOutput)
+			if err != nil {
+				output.PrintError(err.Error(), !o.noColor)
+				return err
+			}
+			if !confirmed {
+				// With machine output stdout only ever holds the import result
+				if machineOutput {
+					fmt.Fprintln(os.Stderr, "Import canceled")
+				} else {
+					fmt.Println("Import canceled")
+				}
+				return nil
+			}
+		}
+	}
+
+	targets := make([]string, len(jobs))
+	for i, job := range jobs {
+		targets[i] = job.target()
+	}
+
+	result := &output.ImportResult{}
+	reporter := output.NewProgressReporter(format, o.progress)
+	failures := output.RunBatch(reporter, "import", targets, func(i int) error {
+		job := jobs[i]
+		// With --progress the reporter shows its own spinner
+		stop := func() {}
+		if !o.progress {
+			stop = output.StartSpinner(fmt.Sprintf("Importing %s...", job.target()))
+		}
+		err := importJobConfiguration(job)
+		stop()
+		if err != nil {
+			if !machineOutput {
+				output.PrintError(err.Error(), !o.noColor)
+			}
+			return err
+		}
+		if o.includeNotes && job.resolved.Note != "" {
+			if err := notes.Set(job.name, job.resolved.Note, time.Now()); err != nil {
+				// Non-fatal error, just warn
+				statedir.Warn(os.Stderr, fmt.Errorf("failed to save configuration note: %w", err))
+			}
+		}
+
+		result.Imported = append(result.Imported, output.ImportedConfiguration{Name: job.name, File: job.path})
+		if !machineOutput {
+			output.PrintSuccess(fmt.Sprintf("imported configuration %q from %s", job.name, job.path), !o.noColor)
+		}
+		return nil
+	})
+	result.Failed = failures
+
+	if machineOutput {
+		if err := output.PrintImportResult(result, format); err != nil {
+			return err
+		}
+	}
+
+	if len(failures) > 0 {
+		if len(jobs) == 1 {
+			return errors.New(failures[0].Error)
+		}
+		return fmt.Errorf("%d of %d imports failed", len(failures), len(jobs))
+	}
+
+	// Activate if requested
+	if o.activate {
+		configName := jobs[0].name
+		if err := gcloud.ActivateConfiguration(configName); err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		recordUsage(configName)
+		if !machineOutput {
+			output.PrintSuccess(fmt.Sprintf("activated configuration %q", configName), !o.noColor)
+		}
+	}
+
+	if o.syncADC {
+		resolved := jobs[0].resolved
+		messages := io.Writer(os.Stdout)
+		if machineOutput {
+			// Keep stdout parseable; progress goes to stderr
+			messages = os.Stderr
+		}
+		fmt.Fprintln(messages, "Syncing Application Default Credentials...")
+		if err := gcloud.SyncADC(resolved.ImpersonateServiceAccount, o.adcNoBrowser(messages)); err != nil {
+			output.PrintError(fmt.Sprintf("failed to sync ADC: %v", err), !o.noColor)
+			return err
+		}
+		if !machineOutput {
+			output.PrintSuccess("ADC synced successfully", !o.noColor)
+		}
+	}
 
+	return nil
+}
+
+// importJob is a single file of an import, parsed before any configuration is changed
+type importJob struct {
+	path     string
+	name     string
+	resolved *configfile.Resolved
+	exists   bool
+	// err is set when the file cannot be imported; the job then fails without touching gcloud
+	err error
+}
+
+// target returns the name reported for the job in progress events and results
+func (j *importJob) target() string {
+	if j.name != "" {
+		return j.name
+	}
+	return j.path
+}
+
+// loadImportJobs parses the import files and checks their names against the existing configurations
+func (o *importOptions) loadImportJobs(paths []string) ([]*importJob, error) {
+	stop := output.StartSpinner(querySpinnerMessage)
+	configs, err := gcloud.ListConfigurations()
+	stop()
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]bool{}
+	for _, config := range configs {
+		existing[config.Name] = true
+	}
+
+	jobs := make([]*importJob, len(paths))
+	seen := map[string]bool{}
+	for i, path := range paths {
+		job := &importJob{path: path}
+		jobs[i] = job
+
+		job.name, job.resolved, job.err = o.parseImportFile(path)
+		if job.err != nil {
+			continue
+		}
+
+		switch {
+		case seen[job.name]:
+			job.err = fmt.Errorf("configuration %q is imported more than once", job.name)
+		case existing[job.name] && !o.overwrite:
+			job.err = fmt.Errorf("configuration %q already exists (use --overwrite to replace)", job.name)
+		default:
+			job.exists = existing[job.name]
+		}
+		seen[job.name] = true
+	}
+
+	return jobs, nil
+}
+
+// parseImportFile reads an import file and returns the configuration name and resolved values
+func (o *importOptions) parseImportFile(filePath string) (string, *configfile.Resolved, error) {
 	// Read file
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		output.PrintError(fmt.Sprintf("failed to read file: %v", err), !noColorFlag)
-		return err
+		return "", nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
 	// Parse configuration
-	var importConfig ExportConfig
+	var importConfig configfile.Config
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	switch ext {
@@ -71,96 +322,117 @@ func runImport(cmd *cobra.Command, args []string) error {
 	}
 
 	if err != nil {
-		output.PrintError(fmt.Sprintf("failed to parse file: %v", err), !noColorFlag)
-		return err
+		return "", nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+
+	// Resolve valueFrom references from the environment
+	resolved, err := configfile.Resolve(&importConfig, os.LookupEnv)
+	if err != nil {
+		return "", nil, err
 	}
 
 	// Determine configuration name
 	configName := importConfig.Name
-	if importNameFlag != "" {
-		configName = importNameFlag
+	if o.name != "" {
+		configName = o.name
 	}
 
 	if configName == "" {
-		output.PrintError("configuration name is required (use --name or include 'name' in the file)", !noColorFlag)
-		return fmt.Errorf("missing configuration name")
+		return "", nil, fmt.Errorf("configuration name is required (use --name or include 'name' in the file)")
 	}
 
-	// Validate configuration name
-	if err := gcloud.ValidateConfigurationName(configName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
-		return err
+	// Validate configuration name, suggesting a sanitized one when it is invalid
+	configName, err = resolveConfigurationName(configName, o.sanitize, "--sanitize-name")
+	if err != nil {
+		return "", nil, err
 	}
 
-	// Check if configuration already exists
-	if gcloud.ConfigurationExists(configName) {
-		if !importOverwriteFlag {
-			output.PrintError(fmt.Sprintf("configuration %q already exists (use --overwrite to replace)", configName), !noColorFlag)
-			return fmt.Errorf("configuration already exists")
-		}
-		// Delete existing configuration for overwrite
-		if err := gcloud.DeleteConfiguration(configName); err != nil {
-			// If it's the active config, we can't delete it
-			output.PrintError(fmt.Sprintf("failed to delete existing configuration: %v", err), !noColorFlag)
-			return err
-		}
+	return configName, resolved, nil
+}
+
+// confirmImportOverwrite asks whether existing configurations may be overwritten
+// The question goes to stderr with machine output so stdout stays parseable
+func confirmImportOverwrite(names []string, machineOutput bool) (bool, error) {
+	w := os.Stdout
+	if machineOutput {
+		w = os.Stderr
 	}
 
-	// Create the configuration
-	if err := gcloud.CreateConfiguration(configName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
-		return err
+	msg := fmt.Sprintf("Configurations %s already exist. Overwrite them?", strings.Join(names, ", "))
+	if len(names) == 1 {
+		msg = fmt.Sprintf("Configuration %q already exists. Overwrite it?", names[0])
 	}
+	return prompt.Ask(w, msg, true, false)
+}
 
-	// Set properties
-	if err := setImportedProperties(configName, &importConfig); err != nil {
-		// Clean up on failure - ignore error as we're already in error state
-		if cleanupErr := gcloud.DeleteConfiguration(configName); cleanupErr != nil {
-			// Log cleanup error but continue with original error
-			fmt.Fprintf(os.Stderr, "Warning: failed to cleanup configuration: %v\n", cleanupErr)
-		}
-		output.PrintError(err.Error(), !noColorFlag)
+// importJobConfiguration creates or overwrites the configuration of an import job and records its ADC hint
+func importJobConfiguration(job *importJob) error {
+	if job.err != nil {
+		return job.err
+	}
+
+	if err := applyImportJob(job); err != nil {
 		return err
 	}
+	saveImportHint(job)
+	return nil
+}
 
-	output.PrintSuccess(fmt.Sprintf("imported configuration %q from %s", configName, filePath), !noColorFlag)
+// saveImportHint remembers how ADC is synced for the configuration of an
+// import job; overwriting replaces any earlier hint
+func saveImportHint(job *importJob) {
+	hint := adc.Hint{SyncADC: job.resolved.SyncADC, ImpersonateServiceAccount: job.resolved.ImpersonateServiceAccount}
+	if err := adc.SaveHint(job.name, hint); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to save ADC hint: %w", err))
+	}
+}
 
-	// Activate if requested
-	if importActivateFlag {
-		if err := gcloud.ActivateConfiguration(configName); err != nil {
-			output.PrintError(err.Error(), !noColorFlag)
-			return err
-		}
-		output.PrintSuccess(fmt.Sprintf("activated configuration %q", configName), !noColorFlag)
+// applyImportJob creates the configuration of an import job, or overwrites an existing one in place
+func applyImportJob(job *importJob) error {
+	settings := importPropertySettings(job.resolved)
+	if !job.exists {
+		return gcloud.ImportConfiguration(job.name, settings)
 	}
 
+	if err := gcloud.OverwriteConfiguration(job.name, settings); err != nil {
+		return err
+	}
+	clearOverwrittenProject(job.name)
 	return nil
 }
 
-func setImportedProperties(configName string, config *ExportConfig) error {
-	if config.Account != "" {
-		if err := gcloud.RunGcloudCommandQuiet("config", "set", "account", config.Account, "--configuration", configName); err != nil {
-			return fmt.Errorf("failed to set account: %w", err)
-		}
-	}
-
-	if config.Project != "" {
-		if err := gcloud.RunGcloudCommandQuiet("config", "set", "project", config.Project, "--configuration", configName); err != nil {
-			return fmt.Errorf("failed to set project: %w", err)
-		}
+// clearOverwrittenProject forgets the previous project of an overwritten
+// configuration, which belonged to the properties it replaced
+func clearOverwrittenProject(name string) {
+	if err := history.ClearPreviousProject(name); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to clear project history: %w", err))
 	}
+}
 
-	if config.Region != "" {
-		if err := gcloud.RunGcloudCommandQuiet("config", "set", "compute/region", config.Region, "--configuration", configName); err != nil {
-			return fmt.Errorf("failed to set region: %w", err)
+// importPropertySettings lists the properties to apply for an imported configuration
+func importPropertySettings(config *configfile.Resolved) []gcloud.PropertySetting {
+	var settings []gcloud.PropertySetting
+	for _, setting := range []gcloud.PropertySetting{
+		{Property: "core/account", Value: config.Account},
+		{Property: "core/project", Value: config.Project},
+		{Property: "compute/region", Value: config.Region},
+		{Property: "compute/zone", Value: config.Zone},
+		{Property: "auth/impersonate_service_account", Value: config.ImpersonateServiceAccount},
+	} {
+		if setting.Value != "" {
+			settings = append(settings, setting)
 		}
 	}
-
-	if config.Zone != "" {
-		if err := gcloud.RunGcloudCommandQuiet("config", "set", "compute/zone", config.Zone, "--configuration", configName); err != nil {
-			return fmt.Errorf("failed to set zone: %w", err)
+	// Usage reporting is only touched when the file says either way, spelled as
+	// gcloud reports booleans so the import verifies
+	if config.DisableUsageReporting != nil {
+		value := "False"
+		if *config.DisableUsageReporting {
+			value = "True"
 		}
+		settings = append(settings, gcloud.PropertySetting{Property: "core/disable_usage_reporting", Value: value})
 	}
-
-	return nil
+	return settings
 }
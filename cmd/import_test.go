@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	env := newTestEnv(t)
+	path := env.writeFile("staging.yaml", "name: staging\nproject: staging-project\nregion: europe-west1\n")
+
+	res := env.mustRun("import", path)
+
+	properties, ok := env.gcloud.Properties("staging")
+	if !ok || properties["core/project"] != "staging-project" || properties["compute/region"] != "europe-west1" {
+		t.Errorf("staging properties = %v (exists %v), want the file's", properties, ok)
+	}
+	if !strings.Contains(res.stdout, `imported configuration "staging"`) {
+		t.Errorf("stdout = %q, want the import reported", res.stdout)
+	}
+}
+
+func TestImportNameAndActivate(t *testing.T) {
+	env := newTestEnv(t)
+	path := env.writeFile("staging.json", `{"name": "staging", "project": "staging-project"}`)
+
+	env.mustRun("import", path, "--name", "qa", "--activate")
+
+	if env.gcloud.Active() != "qa" {
+		t.Errorf("active = %q, want qa", env.gcloud.Active())
+	}
+	if slices.Contains(env.gcloud.Names(), "staging") {
+		t.Error("the file's name was used instead of --name")
+	}
+}
+
+func TestImportOverwrite(t *testing.T) {
+	env := newTestEnv(t)
+	path := env.writeFile("prod.yaml", "name: prod\nproject: new-prod-project\n")
+
+	env.mustRun("import", path, "--overwrite", "--yes")
+
+	properties, _ := env.gcloud.Properties("prod")
+	if properties["core/project"] != "new-prod-project" || properties["core/account"] != "" {
+		t.Errorf("prod properties = %v, want only the file's", properties)
+	}
+	if names := env.gcloud.Names(); !slices.Equal(names, []string{"dev", "prod"}) {
+		t.Errorf("configurations = %v, want no temporary configuration left", names)
+	}
+}
+
+func TestImportExistingCanceled(t *testing.T) {
+	env := newTestEnv(t)
+	path := env.writeFile("prod.yaml", "name: prod\nproject: new-prod-project\n")
+
+	res := env.run("n\n", "import", path, "--overwrite")
+
+	if res.err != nil || !strings.Contains(res.stdout, "Import canceled") {
+		t.Errorf("declined overwrite = %v, stdout %q; want it canceled", res.err, res.stdout)
+	}
+	if properties, _ := env.gcloud.Properties("prod"); properties["core/project"] != "prod-project" {
+		t.Errorf("prod properties = %v, want them untouched", properties)
+	}
+}
+
+func TestImportExistingCanceledJSON(t *testing.T) {
+	env := newTestEnv(t)
+	path := env.writeFile("prod.yaml", "name: prod\nproject: new-prod-project\n")
+
+	res := env.run("n\n", "import", path, "--overwrite", "-o", "json")
+
+	if res.err != nil || !strings.Contains(res.stderr, "Import canceled") {
+		t.Errorf("declined overwrite = %v, stderr %q; want it canceled", res.err, res.stderr)
+	}
+	if res.stdout != "" {
+		t.Errorf("stdout = %q, want nothing that is not JSON", res.stdout)
+	}
+}
+
+func TestImportMissingVariable(t *testing.T) {
+	env := newTestEnv(t)
+	t.Setenv("STAGING_PROJECT", "")
+	path := env.writeFile("staging.yaml", "name: staging\nproject:\n  valueFrom:\n    env: STAGING_PROJECT\n")
+
+	res := env.run("", "import", path)
+
+	if res.err == nil || !strings.Contains(res.stdout, "STAGING_PROJECT") {
+		t.Errorf("importing with an unset variable = %v, stdout %q; want the variable named", res.err, res.stdout)
+	}
+	if slices.Contains(env.gcloud.Names(), "staging") {
+		t.Error("staging was created")
+	}
+}
+
+func TestImportCompletion(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("__complete", "import", "")
+	if res.stdout != "yaml\nyml\njson\n:8\n" {
+		t.Errorf("import completion = %q, want YAML and JSON files only", res.stdout)
+	}
+
+	res = env.mustRun("__complete", "import", "config.yaml", "--name", "")
+	if res.stdout != ":4\n" {
+		t.Errorf("--name completion = %q, want nothing", res.stdout)
+	}
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/cache"
+	"github.com/Okabe-Junya/gcloudctx/pkg/interactive"
+	"github.com/Okabe-Junya/gcloudctx/pkg/local"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// jumpOptions holds the flags of the jump command
+type jumpOptions struct {
+	*options
+	list bool
+}
+
+func newJumpCmd(parent *options) *cobra.Command {
+	o := &jumpOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "jump [query]",
+		Short: "Pick a directory with a .gcloudctx file and print its path",
+		Long: `Pick one of the directories known to contain a .gcloudctx file and print its path.
+
+Directories are remembered whenever 'gcloudctx auto' or 'gcloudctx use' touches
+their .gcloudctx file, so the list grows as you work. Directories whose file or
+configuration no longer exists are forgotten when the list is read.
+
+Without a query, fzf shows each directory with its configuration and the chosen
+path is printed. With a query, the most recently seen directory whose name
+(or, failing that, whose path) contains it is printed without asking.
+
+The command only prints the path; a shell function changes into it, and your
+cd hook running 'gcloudctx auto' then switches the configuration.
+
+Examples:
+  gcloudctx jump              # Pick a directory with fzf
+  gcloudctx jump api          # The most recent directory matching "api"
+  gcloudctx jump --list       # List remembered directories
+
+  # Add to your shell:
+  # Bash/Zsh:
+  #   gj() { local dir; dir="$(gcloudctx jump "$@")" && [ -n "$dir" ] && cd "$dir"; }
+  # Fish:
+  #   function gj; set -l dir (gcloudctx jump $argv); and test -n "$dir"; and cd $dir; end`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: o.runJump,
+	}
+	cmd.Flags().BoolVarP(&o.list, "list", "l", false, "List remembered directories instead of picking one")
+	return cmd
+}
+
+func (o *jumpOptions) runJump(cmd *cobra.Command, args []string) error {
+	// Stdout carries only the chosen path, for the shell function to cd into
+	output.SetMessageOutput(os.Stderr)
+
+	entries := loadJumpEntries()
+	if o.list {
+		o.printJumpEntries(entries)
+		return nil
+	}
+
+	if len(args) == 1 {
+		entry, ok := matchJumpEntry(entries, args[0])
+		if !ok {
+			err := fmt.Errorf("no remembered directory matches %q", args[0])
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		fmt.Println(entry.Dir)
+		return nil
+	}
+
+	if len(entries) == 0 {
+		err := errors.New("no directories with a .gcloudctx file remembered yet; they are added when 'gcloudctx auto' or 'gcloudctx use' touches one")
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if !interactive.IsFzfInstalled() {
+		err := errors.New("fzf is not installed; pass part of a directory name or use --list")
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	dir, err := interactive.SelectDirectoryInteractive(entries)
+	if err != nil {
+		// Canceling prints nothing, so the shell function stays put
+		if errors.Is(err, interactive.ErrSelectionCanceled) {
+			return nil
+		}
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	fmt.Println(dir)
+	return nil
+}
+
+// printJumpEntries prints the remembered directories as a table
+func (o *jumpOptions) printJumpEntries(entries []interactive.DirectoryEntry) {
+	if len(entries) == 0 {
+		fmt.Println("No directories remembered; they are added when 'gcloudctx auto' or 'gcloudctx use' touches a .gcloudctx file")
+		return
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	bold := color.New(color.Bold).SprintFunc()
+	gray := color.New(color.FgHiBlack).SprintFunc()
+
+	orEmpty := func(value string) string {
+		if value == "" {
+			return gray("-")
+		}
+		return value
+	}
+
+	rows := [][]string{{bold("DIRECTORY"), bold("CONFIGURATION"), bold("PROJECT")}}
+	for _, entry := range entries {
+		rows = append(rows, []string{entry.Display, orEmpty(entry.Configuration), orEmpty(entry.Project)})
+	}
+	for _, line := range output.AlignColumns(rows, 2) {
+		fmt.Println(line)
+	}
+}
+
+// matchJumpEntry returns the first (most recently seen) entry whose directory
+// name contains query, or else whose path does, ignoring case
+func matchJumpEntry(entries []interactive.DirectoryEntry, query string) (interactive.DirectoryEntry, bool) {
+	query = strings.ToLower(query)
+	for _, entry := range entries {
+		if strings.Contains(strings.ToLower(filepath.Base(entry.Dir)), query) {
+			return entry, true
+		}
+	}
+	for _, entry := range entries {
+		if strings.Contains(strings.ToLower(entry.Dir), query) {
+			return entry, true
+		}
+	}
+	return interactive.DirectoryEntry{}, false
+}
+
+// jumpDirsCacheKey records the directories with a .gcloudctx file and when they were last seen
+const jumpDirsCacheKey = "jump-dirs"
+
+// jumpDirsTTL is how long the remembered directories are kept without any use
+const jumpDirsTTL = 365 * 24 * time.Hour
+
+// jumpDirsRefreshInterval is how stale a directory's last-seen time may get
+// before it is written again, so a cd hook staying in one tree rarely writes
+const jumpDirsRefreshInterval = time.Hour
+
+// rememberJumpDirs records dirs as containing a .gcloudctx file
+func rememberJumpDirs(dirs ...string) {
+	seen := map[string]time.Time{}
+	_, _ = cache.Load(jumpDirsCacheKey, jumpDirsTTL, &seen)
+
+	now := time.Now()
+	changed := false
+	for _, dir := range dirs {
+		if last, ok := seen[dir]; ok && now.Sub(last) < jumpDirsRefreshInterval {
+			continue
+		}
+		seen[dir] = now
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	if err := cache.Save(jumpDirsCacheKey, seen); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to remember directory: %w", err))
+	}
+}
+
+// forgetJumpDir removes dir from the remembered directories
+func forgetJumpDir(dir string) {
+	seen := map[string]time.Time{}
+	if found, err := cache.Load(jumpDirsCacheKey, jumpDirsTTL, &seen); err != nil || !found {
+		return
+	}
+	if _, ok := seen[dir]; !ok {
+		return
+	}
+	delete(seen, dir)
+	if err := cache.Save(jumpDirsCacheKey, seen); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to forget directory: %w", err))
+	}
+}
+
+// loadJumpEntries returns the remembered directories, most recently seen first
+// Directories whose .gcloudctx file or configuration is gone are pruned
+func loadJumpEntries() []interactive.DirectoryEntry {
+	seen := map[string]time.Time{}
+	if found, err := cache.Load(jumpDirsCacheKey, jumpDirsTTL, &seen); err != nil || !found {
+		return nil
+	}
+
+	home, _ := os.UserHomeDir()
+	var entries []interactive.DirectoryEntry
+	pruned := false
+	for dir := range seen {
+		match, ok := local.ReadMatch(dir)
+		if !ok || (match.Config != "" && !jumpConfigurationExists(match.Config)) {
+			delete(seen, dir)
+			pruned = true
+			continue
+		}
+		entries = append(entries, interactive.DirectoryEntry{
+			Dir:           dir,
+			Display:       displayHomePath(dir, home),
+			Configuration: match.Config,
+			Project:       match.Project,
+		})
+	}
+
+	if pruned {
+		if err := cache.Save(jumpDirsCacheKey, seen); err != nil {
+			// Non-fatal error, just warn
+			statedir.Warn(os.Stderr, fmt.Errorf("failed to prune directories: %w", err))
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b interactive.DirectoryEntry) int {
+		if c := seen[b.Dir].Compare(seen[a.Dir]); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Dir, b.Dir)
+	})
+	return entries
+}
+
+// jumpConfigurationExists reports whether name exists, keeping the directory
+// when that cannot be determined
+func jumpConfigurationExists(name string) bool {
+	exists, err := configurationExists(name)
+	return err != nil || exists
+}
+
+// displayHomePath shows path relative to the home directory as "~/..."
+func displayHomePath(path, home string) string {
+	if home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rel, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rel
+	}
+	return path
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/local"
+)
+
+// mkdirAt creates a directory under the working directory and returns its path
+func mkdirAt(t *testing.T, env *testEnv, name string) string {
+	t.Helper()
+	dir := filepath.Join(env.workDir, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestJumpRemembersUseAndAuto(t *testing.T) {
+	env := newTestEnv(t)
+	api := mkdirAt(t, env, "api")
+	web := mkdirAt(t, env, "web")
+	env.writeFile(filepath.Join("web", local.ConfigFileName), "configuration: prod\nproject: web-project\n")
+
+	t.Chdir(api)
+	env.mustRun("use", "--local", "dev")
+	t.Chdir(web)
+	env.mustRun("auto")
+
+	res := env.mustRun("jump", "--list")
+	for _, want := range []string{api, web, "dev", "prod", "web-project"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("jump --list = %q, want %q listed", res.stdout, want)
+		}
+	}
+
+	if res := env.mustRun("jump", "API"); res.stdout != api+"\n" {
+		t.Errorf("jump API = %q, want %q", res.stdout, api)
+	}
+	if res := env.run("", "jump", "nothing"); res.err == nil || res.stdout != "" {
+		t.Errorf("jump nothing = %q, %v; want an error and no path", res.stdout, res.err)
+	}
+}
+
+func TestJumpPrunesStaleDirectories(t *testing.T) {
+	env := newTestEnv(t)
+	if err := gcloud.CreateConfiguration("staging"); err != nil {
+		t.Fatal(err)
+	}
+	gone := mkdirAt(t, env, "gone")
+	stale := mkdirAt(t, env, "stale")
+	kept := mkdirAt(t, env, "kept")
+	for dir, config := range map[string]string{gone: "dev", stale: "staging", kept: "prod"} {
+		t.Chdir(dir)
+		env.mustRun("use", "--local", config)
+	}
+
+	if err := os.Remove(filepath.Join(gone, local.ConfigFileName)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gcloud.DeleteConfiguration("staging"); err != nil {
+		t.Fatal(err)
+	}
+
+	res := env.mustRun("jump", "--list")
+	if !strings.Contains(res.stdout, kept) || strings.Contains(res.stdout, gone) || strings.Contains(res.stdout, stale) {
+		t.Errorf("jump --list = %q, want only %s", res.stdout, kept)
+	}
+
+	// The pruned directories stay gone even when their file comes back
+	env.writeFile(filepath.Join("gone", local.ConfigFileName), "dev\n")
+	if res := env.mustRun("jump", "--list"); strings.Contains(res.stdout, gone) {
+		t.Errorf("jump --list = %q, want %s pruned from the cache", res.stdout, gone)
+	}
+}
+
+func TestJumpUnsetForgetsDirectory(t *testing.T) {
+	env := newTestEnv(t)
+	dir := mkdirAt(t, env, "api")
+	t.Chdir(dir)
+	env.mustRun("use", "--local", "dev")
+	env.mustRun("use", "--unset")
+
+	if res := env.mustRun("jump", "--list"); strings.Contains(res.stdout, dir) {
+		t.Errorf("jump --list = %q, want %s forgotten", res.stdout, dir)
+	}
+}
+
+func TestJumpWithoutFzf(t *testing.T) {
+	env := newTestEnv(t)
+	dir := mkdirAt(t, env, "api")
+	t.Chdir(dir)
+	env.mustRun("use", "--local", "dev")
+
+	res := env.run("", "jump")
+	if res.err == nil || !strings.Contains(res.stderr, "fzf is not installed") || res.stdout != "" {
+		t.Errorf("jump without fzf = %q, %q, %v; want the fzf error on stderr", res.stdout, res.stderr, res.err)
+	}
+}
+
+func TestJumpNothingRemembered(t *testing.T) {
+	env := newTestEnv(t)
+	if res := env.mustRun("jump", "--list"); !strings.Contains(res.stdout, "No directories remembered") {
+		t.Errorf("jump --list = %q, want the empty notice", res.stdout)
+	}
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/activity"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// defaultLogLimit is how many entries 'gcloudctx log' shows by default
+const defaultLogLimit = 20
+
+// logOptions holds the flags of the log command
+type logOptions struct {
+	*options
+	format string
+	since  string
+	limit  int
+}
+
+func newLogCmd(parent *options) *cobra.Command {
+	o := &logOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "Show the commands that changed gcloud's configuration",
+		Long: `Show the activity log: every gcloudctx command that changed, or tried to
+change, gcloud's configuration, with who ran it, its arguments, the active
+configuration before and after, and whether it succeeded.
+
+The log is kept in ~/.gcloudctx_activity.jsonl, one JSON object per line, and
+rotated to ~/.gcloudctx_activity.jsonl.1 when it reaches 2 MB. Values of flags
+named like tokens, keys or secrets are not logged. Writing the log never fails
+a command.
+
+--since takes a duration such as 30m, 24h or 7d, or a date such as 2025-01-31.
+
+Examples:
+  gcloudctx log                  # The most recent entries
+  gcloudctx log --since 24h      # Everything from the last day
+  gcloudctx log --since 7d -n 0  # Everything from the last week
+  gcloudctx log -o json          # Entries as stored, for tooling`,
+		Args: cobra.NoArgs,
+		RunE: o.runLog,
+	}
+	cmd.Flags().StringVarP(&o.format, "output", "o", "", "Output format (json, yaml)")
+	cmd.Flags().StringVar(&o.since, "since", "", "Only show entries since a duration ago (30m, 24h, 7d) or a date (2025-01-31)")
+	cmd.Flags().IntVarP(&o.limit, "limit", "n", defaultLogLimit, "Show at most this many of the most recent entries (0 for all)")
+	return cmd
+}
+
+func (o *logOptions) runLog(cmd *cobra.Command, args []string) error {
+	format, err := output.ValidateOutputFormat(o.format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	var since time.Time
+	if o.since != "" {
+		since, err = parseSince(o.since, time.Now())
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+	}
+
+	path, err := activity.GetLogFilePath()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	entries, err := activity.Read(path, since)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if o.limit > 0 && len(entries) > o.limit {
+		entries = entries[len(entries)-o.limit:]
+	}
+
+	if output.IsMachineFormat(format) {
+		return output.PrintActivity(entries, format)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No activity logged")
+		return nil
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	bold := color.New(color.Bold).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	rows := [][]string{{bold("TIME"), bold("USER"), bold("COMMAND"), bold("ACTIVE"), bold("RESULT")}}
+	for _, entry := range entries {
+		result := "ok"
+		if !entry.Success {
+			result = red("failed: " + entry.Error)
+		}
+		rows = append(rows, []string{
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			entry.User,
+			strings.Join(append([]string{entry.Command}, entry.Args...), " "),
+			describeActiveChange(entry.Previous, entry.Active),
+			result,
+		})
+	}
+	for _, line := range output.AlignColumns(rows, 2) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// describeActiveChange shows the active configuration, or how it changed
+func describeActiveChange(previous, active string) string {
+	if previous == active {
+		return active
+	}
+	return previous + " -> " + active
+}
+
+// parseSince parses --since as a duration before now, allowing days ("7d"),
+// or as a date or RFC 3339 time
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q (use a duration such as 24h or 7d, or a date such as 2025-01-31)", value)
+}
+
+// trackChanges makes every command in the tree under cmd that tries to change
+// gcloud's configuration append to the activity log and remember the active
+// configuration, so the next run does not take its switch for an external one
+func trackChanges(cmd *cobra.Command) {
+	if run := cmd.RunE; run != nil {
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			attempts := gcloud.WriteAttempts()
+			previous, _ := gcloud.ActiveConfigName()
+			err := run(cmd, args)
+			if gcloud.WriteAttempts() != attempts {
+				recordActivity(cmd, args, previous, err)
+				rememberActiveConfiguration()
+			}
+			return err
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		trackChanges(sub)
+	}
+}
+
+// recordActivity appends a command to the activity log; failures only warn
+func recordActivity(cmd *cobra.Command, args []string, previous string, runErr error) {
+	path, err := activity.GetLogFilePath()
+	if err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to write activity log: %w", err))
+		return
+	}
+
+	active, _ := gcloud.ActiveConfigName()
+	entry := activity.Entry{
+		Time:     time.Now().UTC(),
+		User:     activityUser(),
+		Command:  cmd.CommandPath(),
+		Args:     activityArgs(cmd, args),
+		Previous: previous,
+		Active:   active,
+		Success:  runErr == nil,
+	}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	}
+	if err := activity.Append(path, entry); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to write activity log: %w", err))
+	}
+}
+
+// activityArgs returns the arguments and the flags set on the command line, sanitized
+func activityArgs(cmd *cobra.Command, args []string) []string {
+	logged := make([]string, 0, len(args))
+	for _, arg := range args {
+		logged = append(logged, activity.SanitizeArg(arg))
+	}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Value.Type() == "bool" && f.Value.String() == "true" {
+			logged = append(logged, "--"+f.Name)
+			return
+		}
+		logged = append(logged, activity.SanitizeFlag(f.Name, f.Value.String()))
+	})
+	return logged
+}
+
+// activityUser names the user running gcloudctx, and who became them with sudo
+func activityUser() string {
+	name := ""
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && sudoUser != name {
+		return fmt.Sprintf("%s (sudo from %s)", name, sudoUser)
+	}
+	return name
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/activity"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// loggedActivity returns the entries of the activity log as 'log -o json' prints them
+func loggedActivity(t *testing.T, env *testEnv, args ...string) []activity.Entry {
+	t.Helper()
+	res := env.mustRun(append([]string{"log", "-o", "json"}, args...)...)
+	var entries []activity.Entry
+	if err := json.Unmarshal([]byte(res.stdout), &entries); err != nil {
+		t.Fatalf("log -o json = %q: %v", res.stdout, err)
+	}
+	return entries
+}
+
+func TestLogRecordsMutations(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.mustRun("-l")
+	env.mustRun("prod", "--yes")
+	env.mustRun("describe")
+	env.gcloud.Fail("config configurations activate", "ERROR: (gcloud.config.configurations.activate) boom")
+	env.run("", "dev")
+
+	entries := loggedActivity(t, env)
+	if len(entries) != 2 {
+		t.Fatalf("logged %+v, want the two switches only", entries)
+	}
+
+	switched := entries[0]
+	if switched.Command != "gcloudctx" || strings.Join(switched.Args, " ") != "prod --yes" ||
+		switched.Previous != "dev" || switched.Active != "prod" || !switched.Success || switched.User == "" {
+		t.Errorf("switch entry = %+v", switched)
+	}
+	failed := entries[1]
+	if failed.Success || !strings.Contains(failed.Error, "boom") || failed.Previous != "prod" || failed.Active != "prod" {
+		t.Errorf("failed switch entry = %+v", failed)
+	}
+
+	res := env.mustRun("log")
+	for _, want := range []string{"gcloudctx prod --yes", "dev -> prod", "failed:"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("log = %q, want %q", res.stdout, want)
+		}
+	}
+}
+
+func TestLogReadOnlyRefusal(t *testing.T) {
+	env := newTestEnv(t)
+	t.Setenv(gcloud.EnvReadOnly, "1")
+
+	env.run("", "prod")
+	entries := loggedActivity(t, env)
+	if len(entries) != 1 || entries[0].Success || !strings.Contains(entries[0].Error, "read-only") {
+		t.Errorf("logged %+v, want the refused switch", entries)
+	}
+}
+
+func TestLogSinceAndLimit(t *testing.T) {
+	env := newTestEnv(t)
+	path := activity.LogFilePathIn(env.home)
+	now := time.Now().UTC()
+	for _, entry := range []activity.Entry{
+		{Time: now.Add(-10 * 24 * time.Hour), Command: "gcloudctx", Args: []string{"old"}, Success: true},
+		{Time: now.Add(-2 * time.Hour), Command: "gcloudctx", Args: []string{"recent"}, Success: true},
+		{Time: now.Add(-time.Minute), Command: "gcloudctx", Args: []string{"latest"}, Success: true},
+	} {
+		if err := activity.Append(path, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if entries := loggedActivity(t, env, "--since", "7d"); len(entries) != 2 || entries[0].Args[0] != "recent" {
+		t.Errorf("log --since 7d = %+v, want the two recent entries", entries)
+	}
+	if entries := loggedActivity(t, env, "--since", "1h"); len(entries) != 1 || entries[0].Args[0] != "latest" {
+		t.Errorf("log --since 1h = %+v, want the latest entry", entries)
+	}
+	if entries := loggedActivity(t, env, "-n", "1"); len(entries) != 1 || entries[0].Args[0] != "latest" {
+		t.Errorf("log -n 1 = %+v, want the latest entry", entries)
+	}
+	if res := env.run("", "log", "--since", "yesterday"); res.err == nil {
+		t.Error("log --since yesterday succeeded, want an error")
+	}
+}
+
+func TestLogWriteFailureDoesNotFailCommand(t *testing.T) {
+	env := newTestEnv(t)
+	if err := os.Mkdir(filepath.Join(env.home, ".gcloudctx_activity.jsonl"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	res := env.mustRun("prod")
+	if env.gcloud.Active() != "prod" || !strings.Contains(res.stderr, "failed to write activity log") {
+		t.Errorf("switch = %q, stderr %q; want the switch made and a warning", res.stdout, res.stderr)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"30m":                  now.Add(-30 * time.Minute),
+		"24h":                  now.Add(-24 * time.Hour),
+		"7d":                   now.AddDate(0, 0, -7),
+		"2025-03-01T00:00:00Z": time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for value, want := range tests {
+		if got, err := parseSince(value, now); err != nil || !got.Equal(want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "-1h", "xd", "soon"} {
+		if _, err := parseSince(value, now); err == nil {
+			t.Errorf("parseSince(%q) succeeded, want an error", value)
+		}
+	}
+}
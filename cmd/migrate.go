@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/migrate"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// migrateScanOptions holds the flags of the migrate-scan command
+type migrateScanOptions struct {
+	*options
+	rewrite bool
+	dryRun  bool
+}
+
+func newMigrateScanCmd(parent *options) *cobra.Command {
+	o := &migrateScanOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "migrate-scan [path...]",
+		Short: "Find raw gcloud configuration commands and suggest gcloudctx equivalents",
+		Long: `Scan shell rc files and scripts for raw 'gcloud config configurations'
+and 'gcloud config set' invocations, and report the equivalent gcloudctx command.
+
+When no paths are given, common shell rc files in your home directory are scanned
+(~/.bashrc, ~/.zshrc, ~/.profile, ~/.config/fish/config.fish, ...).
+
+With --rewrite, invocations that can be translated with certainty are replaced
+in place. Rewriting is a dry run by default; pass --dry-run=false to modify files.
+A timestamped .bak copy is written next to every modified file first; earlier
+backups are never overwritten.
+Invocations spanning multiple lines or using unsupported flags are only reported.
+
+Examples:
+  gcloudctx migrate-scan                              # Scan shell rc files
+  gcloudctx migrate-scan scripts/deploy.sh            # Scan a specific script
+  gcloudctx migrate-scan scripts/*.sh --rewrite       # Preview rewrites
+  gcloudctx migrate-scan ~/.zshrc --rewrite --dry-run=false  # Apply rewrites`,
+		RunE: o.runMigrateScan,
+	}
+	cmd.Flags().BoolVar(&o.rewrite, "rewrite", false, "Rewrite translatable invocations in place")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", true, "Only show what --rewrite would change")
+	return cmd
+}
+
+func (o *migrateScanOptions) runMigrateScan(cmd *cobra.Command, args []string) error {
+	paths := args
+	if len(paths) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			output.PrintError(fmt.Sprintf("failed to get home directory: %v", err), !o.noColor)
+			return err
+		}
+		paths = migrate.DefaultRCFiles(home)
+		if len(paths) == 0 {
+			fmt.Println("No shell rc files found")
+			return nil
+		}
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	green := color.New(color.FgGreen).SprintFunc()
+	gray := color.New(color.FgHiBlack).SprintFunc()
+
+	total := 0
+	rewritable := 0
+	for _, path := range paths {
+		findings, err := migrate.ScanFile(path)
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+
+		for _, f := range findings {
+			total++
+			fmt.Printf("%s:%d: %s\n", f.Path, f.Line, f.Original)
+			if f.Replacement != "" {
+				fmt.Printf("  %s %s\n", green("->"), f.Replacement)
+			}
+			if f.Note != "" {
+				fmt.Printf("  %s\n", gray(f.Note))
+			}
+			if f.Rewritable {
+				rewritable++
+			}
+		}
+
+		if o.rewrite && !o.dryRun {
+			rewritten, backupPath, err := migrate.RewriteFile(path)
+			if err != nil {
+				output.PrintError(err.Error(), !o.noColor)
+				return err
+			}
+			if rewritten > 0 {
+				output.PrintSuccess(fmt.Sprintf("rewrote %d invocation(s) in %s (backup: %s)", rewritten, path, backupPath), !o.noColor)
+			}
+		}
+	}
+
+	if total == 0 {
+		fmt.Println("No raw gcloud configuration commands found")
+		return nil
+	}
+
+	fmt.Printf("\nFound %d invocation(s), %d can be rewritten automatically\n", total, rewritable)
+	if o.rewrite && o.dryRun && rewritable > 0 {
+		fmt.Println("Dry run: pass --dry-run=false to apply the rewrites")
+	}
+
+	return nil
+}
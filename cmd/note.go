@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/notes"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
+	"github.com/spf13/cobra"
+)
+
+func newNoteCmd(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "note",
+		Short: "Annotate configurations with a note",
+		Long: `Keep a free-text note about a configuration, such as the client it belongs
+to, its billing account or when its access expires.
+
+The note is shown in full by 'gcloudctx describe', '-c --info' and the fzf
+preview, and its first line in the NOTES column of 'gcloudctx -l -o wide'.
+Notes are kept in ~/.gcloudctx_metadata, keyed by configuration name; they
+follow renamed configurations, and listing configurations forgets the notes
+of deleted ones. 'gcloudctx export --include-notes' writes the note into the
+export file and 'gcloudctx import --include-notes' keeps it.
+
+Examples:
+  gcloudctx note set prod "client X, billing acct 42, expires Q3"
+  gcloudctx note rm prod`,
+	}
+	cmd.AddCommand(newNoteSetCmd(o), newNoteRmCmd(o))
+	return cmd
+}
+
+func newNoteSetCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:               "set <configuration-name> <text>",
+		Short:             "Set the note of a configuration, replacing any earlier one",
+		Args:              cobra.ExactArgs(2),
+		RunE:              o.runNoteSet,
+		ValidArgsFunction: completeConfigNames,
+	}
+}
+
+func newNoteRmCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:               "rm <configuration-name>",
+		Short:             "Remove the note of a configuration",
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.runNoteRm,
+		ValidArgsFunction: completeConfigNames,
+	}
+}
+
+func (o *options) runNoteSet(cmd *cobra.Command, args []string) error {
+	configName, text := args[0], notes.Normalize(args[1])
+	if text == "" {
+		err := fmt.Errorf("the note is empty (use 'gcloudctx note rm %s' to remove it)", configName)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	exists, err := gcloud.ConfigurationExists(configName)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if !exists {
+		output.PrintError(fmt.Sprintf("configuration %q does not exist", configName), !o.noColor)
+		return fmt.Errorf("configuration not found")
+	}
+
+	if err := notes.Set(configName, text, time.Now()); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	output.PrintSuccess(fmt.Sprintf("set the note of configuration %q", configName), !o.noColor)
+	return nil
+}
+
+func (o *options) runNoteRm(cmd *cobra.Command, args []string) error {
+	configName := args[0]
+
+	removed, err := notes.Remove(configName)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if !removed {
+		output.PrintSuccess(fmt.Sprintf("configuration %q has no note", configName), !o.noColor)
+		return nil
+	}
+	output.PrintSuccess(fmt.Sprintf("removed the note of configuration %q", configName), !o.noColor)
+	return nil
+}
+
+// usesDefaultConfigDir reports whether gcloud's configurations are read from
+// the platform default directory rather than a tree chosen with --config-root
+// or $CLOUDSDK_CONFIG
+func usesDefaultConfigDir() bool {
+	if gcloud.ConfigRoot() != "" {
+		return false
+	}
+	dir := os.Getenv(gcloud.EnvConfigDir)
+	if dir == "" {
+		return true
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false
+	}
+	return filepath.Clean(dir) == filepath.Clean(gcloud.DefaultConfigDir(home))
+}
+
+// loadNotes provides the notes of configurations to lists, details and previews
+func loadNotes() {
+	texts, err := notes.Load()
+	if err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, err)
+		return
+	}
+	output.SetNotes(texts)
+}
+
+// pruneNotes forgets the notes of configurations that no longer exist
+// configs must be every configuration. Notes are kept by name for gcloud's
+// default configuration directory, so nothing is pruned when configs belong to
+// another tree (--config-root or $CLOUDSDK_CONFIG), nor when there are none at
+// all, which more likely means gcloud is not set up than that all were deleted
+func pruneNotes(configs []gcloud.Configuration) {
+	if len(configs) == 0 || !usesDefaultConfigDir() {
+		return
+	}
+	names := make([]string, len(configs))
+	for i, config := range configs {
+		names[i] = config.Name
+	}
+	if _, err := notes.Prune(names); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to prune configuration notes: %w", err))
+	}
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/notes"
+)
+
+const prodNote = "client X, billing acct 42, expires Q3"
+
+func TestNoteShownInListDescribeAndPreview(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.mustRun("note", "set", "prod", prodNote)
+
+	wide := env.mustRun("-l", "-o", "wide")
+	if !strings.Contains(wide.stdout, "NOTES") || !strings.Contains(wide.stdout, "client X, billing acct 42, ...") {
+		t.Errorf("-l -o wide stdout = %q, want the truncated note", wide.stdout)
+	}
+	describe := env.mustRun("describe", "prod")
+	if !strings.Contains(describe.stdout, "Note:\n  "+prodNote+"\n") {
+		t.Errorf("describe stdout = %q, want the full note", describe.stdout)
+	}
+	preview := env.mustRun("__preview", "prod\t  prod (ops@example.com) [prod-project]")
+	if !strings.Contains(preview.stdout, "Note:") || !strings.Contains(preview.stdout, "client X, billing acct 42") {
+		t.Errorf("preview stdout = %q, want the note", preview.stdout)
+	}
+
+	env.mustRun("note", "rm", "prod")
+	if describe := env.mustRun("describe", "prod"); strings.Contains(describe.stdout, "Note:") {
+		t.Errorf("describe stdout after note rm = %q, want no note", describe.stdout)
+	}
+}
+
+func TestNoteSetErrors(t *testing.T) {
+	env := newTestEnv(t)
+
+	if res := env.run("", "note", "set", "missing", "text"); res.err == nil || !strings.Contains(res.stdout, `configuration "missing" does not exist`) {
+		t.Errorf("note set of a missing configuration: err = %v, stdout = %q", res.err, res.stdout)
+	}
+	if res := env.run("", "note", "set", "prod", "  "); res.err == nil || !strings.Contains(res.stdout, "note rm prod") {
+		t.Errorf("note set of an empty note: err = %v, stdout = %q", res.err, res.stdout)
+	}
+}
+
+func TestNotesPrunedOnList(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("note", "set", "prod", prodNote)
+	if err := notes.Set("deleted", "left behind", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	env.mustRun("-l")
+
+	texts, err := notes.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := texts["deleted"]; ok || texts["prod"] != prodNote {
+		t.Errorf("notes after listing = %q, want only prod's", texts)
+	}
+}
+
+func TestNotesKeptForOtherConfigDir(t *testing.T) {
+	env := newTestEnv(t)
+	if err := notes.Set("elsewhere", "kept", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Configurations of another tree say nothing about the default one's
+	t.Setenv(gcloud.EnvConfigDir, filepath.Join(t.TempDir(), "gcloud"))
+	env.mustRun("-l")
+
+	texts, err := notes.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texts["elsewhere"] != "kept" {
+		t.Errorf("notes after listing another tree = %q, want elsewhere's kept", texts)
+	}
+}
+
+func TestNotesKeptWithoutConfigurations(t *testing.T) {
+	env := newEmptyTestEnv(t)
+	if err := notes.Set("prod", prodNote, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	env.mustRun("-l")
+
+	texts, err := notes.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texts["prod"] != prodNote {
+		t.Errorf("notes after listing no configurations = %q, want prod's kept", texts)
+	}
+}
+
+func TestNoteFollowsRename(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("note", "set", "prod", prodNote)
+
+	env.mustRun("rename", "prod", "production")
+
+	texts, err := notes.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texts["production"] != prodNote {
+		t.Errorf("notes after rename = %q, want the note under production", texts)
+	}
+}
+
+func TestExportImportIncludeNotes(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("note", "set", "prod", prodNote)
+	path := filepath.Join(env.workDir, "prod.yaml")
+
+	plain := env.mustRun("export", "prod")
+	if strings.Contains(plain.stdout, "note:") {
+		t.Errorf("export without --include-notes = %q, want no note", plain.stdout)
+	}
+	env.mustRun("export", "prod", "--include-notes", "--output", path)
+
+	env.mustRun("import", path, "--name", "shared")
+	if texts, _ := notes.Load(); texts["shared"] != "" {
+		t.Errorf("import without --include-notes set note %q", texts["shared"])
+	}
+	env.mustRun("import", path, "--name", "team", "--include-notes")
+	if texts, _ := notes.Load(); texts["team"] != prodNote {
+		t.Errorf("imported note = %q, want %q", texts["team"], prodNote)
+	}
+}
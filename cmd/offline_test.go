@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/cache"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// networkCalls returns the gcloud commands env ran that need the network
+func networkCalls(env *testEnv) []string {
+	var calls []string
+	for _, call := range env.gcloud.Calls() {
+		if gcloud.NeedsNetwork(strings.Fields(call)) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func TestOfflineRunsNoNetworkCommands(t *testing.T) {
+	commands := [][]string{
+		{"-l"},
+		{"-c", "--info"},
+		{"prod"},
+		{"dev"},
+		{"status"},
+		{"doctor"},
+	}
+	for _, how := range []string{"flag", "env"} {
+		t.Run(how, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.writeSettings("gke:\n  suggest: true\n  configurations:\n    dev:\n      auto_credentials: main@europe-west1\n")
+			env.gcloud.AddCluster("prod-project", gcloud.Cluster{Name: "main", Location: "europe-west1"})
+			if how == "env" {
+				t.Setenv(gcloud.EnvOffline, "1")
+			}
+
+			for _, args := range commands {
+				if how == "flag" {
+					args = append(args, "--offline")
+				}
+				env.run("", args...)
+			}
+
+			if calls := networkCalls(env); len(calls) != 0 {
+				t.Errorf("network commands run offline: %q", calls)
+			}
+		})
+	}
+}
+
+func TestOfflineListReadsFiles(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("-l", "--offline")
+
+	if !strings.Contains(res.stdout, "dev") || !strings.Contains(res.stdout, "prod") {
+		t.Errorf("stdout = %q, want both configurations", res.stdout)
+	}
+	for _, call := range env.gcloud.Calls() {
+		if strings.HasPrefix(call, "config configurations list") {
+			t.Errorf("gcloud listed the configurations offline: %q", call)
+		}
+	}
+}
+
+func TestOfflineNotes(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeSettings("gke:\n  suggest: true\n")
+
+	doctor := env.run("", "doctor", "--offline")
+	lines := strings.Split(doctor.stdout, "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[1], "offline mode") {
+		t.Errorf("doctor stdout = %q, want offline mode reported first", doctor.stdout)
+	}
+	if !strings.Contains(doctor.stdout, "(skipped: offline)") {
+		t.Errorf("doctor stdout = %q, want the access token check skipped", doctor.stdout)
+	}
+
+	status := env.mustRun("status", "--offline")
+	if !strings.Contains(status.stdout, "(skipped: offline)") {
+		t.Errorf("status stdout = %q, want the access token check skipped", status.stdout)
+	}
+
+	switched := env.mustRun("prod", "--offline")
+	if !strings.Contains(switched.stdout, "GKE cluster suggestions (skipped: offline)") {
+		t.Errorf("stdout = %q, want the GKE suggestions skipped", switched.stdout)
+	}
+}
+
+func TestLoadProjectsOffline(t *testing.T) {
+	newTestEnv(t)
+	t.Setenv(gcloud.EnvOffline, "1")
+	t.Setenv(envProjectCacheTTL, "0s")
+
+	if _, err := loadProjects("dev@example.com", false); !errors.Is(err, gcloud.ErrOffline) {
+		t.Fatalf("loadProjects() without a cache error = %v, want ErrOffline", err)
+	}
+
+	// A stale list is used rather than none
+	cached := []gcloud.Project{{ProjectID: "dev-project"}}
+	if err := cache.Save(projectCacheKey("dev@example.com"), cached); err != nil {
+		t.Fatal(err)
+	}
+	projects, err := loadProjects("dev@example.com", true)
+	if err != nil {
+		t.Fatalf("loadProjects() error = %v", err)
+	}
+	if len(projects) != 1 || projects[0].ProjectID != "dev-project" {
+		t.Errorf("loadProjects() = %+v, want the cached list", projects)
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/paths"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// pathsOptions holds the flags of the paths command
+type pathsOptions struct {
+	*options
+	format string
+	only   string
+}
+
+func newPathsCmd(parent *options) *cobra.Command {
+	o := &pathsOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "paths",
+		Short: "Show every file and directory gcloudctx reads or writes",
+		Long: `Show every file and directory gcloudctx reads or writes, where each path
+came from, and whether it exists, is missing, or cannot be written.
+
+Paths are resolved by the same code gcloudctx uses to read and write them, so
+the listing reflects $CLOUDSDK_CONFIG, $HOME and the user cache directory.
+
+Use --only to print a single path for use in scripts. Nothing but the path
+is printed to stdout, so errors go to stderr only.
+
+Examples:
+  gcloudctx paths                    # Table of all paths
+  gcloudctx paths -o json            # JSON array for tooling
+  cat "$(gcloudctx paths --only history)"`,
+		Args: cobra.NoArgs,
+		RunE: o.runPaths,
+	}
+	cmd.Flags().StringVarP(&o.format, "output", "o", "", "Output format (json, yaml)")
+	cmd.Flags().StringVar(&o.only, "only", "", "Print only the path with this key")
+	_ = cmd.RegisterFlagCompletionFunc("only", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		entries, err := paths.Resolve()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return paths.Keys(entries), cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+func (o *pathsOptions) runPaths(cmd *cobra.Command, args []string) error {
+	entries, err := paths.Resolve()
+
+	// --only output is substituted into commands, so errors are only returned
+	if o.only != "" {
+		if err != nil {
+			return err
+		}
+		entry, err := paths.Lookup(entries, o.only)
+		if err != nil {
+			return fmt.Errorf("%w (valid keys: %s)", err, strings.Join(paths.Keys(entries), ", "))
+		}
+		fmt.Println(entry.Path)
+		return nil
+	}
+
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	format, err := output.ValidateOutputFormat(o.format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if output.IsMachineFormat(format) {
+		return output.PrintPaths(entries, format)
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	rows := [][]string{{"KEY", "PATH", "SOURCE", "STATUS"}}
+	for _, entry := range entries {
+		rows = append(rows, []string{entry.Key, entry.Path, entry.Source, colorPathStatus(entry.Status)})
+	}
+	for _, line := range output.AlignColumns(rows, 2) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// colorPathStatus highlights statuses that need attention
+func colorPathStatus(status paths.Status) string {
+	switch status {
+	case paths.StatusExists:
+		return color.GreenString(string(status))
+	case paths.StatusUnwritable:
+		return color.RedString(string(status))
+	default:
+		return color.HiBlackString(string(status))
+	}
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/spf13/cobra"
+)
+
+// Exit statuses of pick when it cannot switch
+const (
+	pickExitAmbiguous = 2
+	pickExitNoMatch   = 3
+)
+
+// pickOptions holds the flags of the pick command
+type pickOptions struct {
+	*options
+	match string
+	index int
+}
+
+func newPickCmd(parent *options) *cobra.Command {
+	o := &pickOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "pick (--match PATTERN | --index N)",
+		Short: "Switch to a configuration picked by pattern or position, without prompting",
+		Long: `Switch to a configuration picked without fzf or a prompt, for scripts and
+tmux popups.
+
+--match takes a glob: * matches any run of characters, ? any one character
+and [...] one of a class. When exactly one configuration matches, gcloudctx
+switches to it. When several match, their names are printed one per line and
+the command exits with status 2; when none does, it exits with status 3.
+
+--index takes the position of a configuration in 'gcloudctx -l', counting from
+1. Configurations are listed sorted by name, so positions only change when
+configurations are added, removed or renamed. A position past the end exits
+with status 3.
+
+Examples:
+  gcloudctx pick --match 'prod*'
+  gcloudctx pick --match '*-eu' -o json
+  gcloudctx pick --index 2`,
+		Args: cobra.NoArgs,
+		RunE: o.runPick,
+	}
+	cmd.Flags().StringVar(&o.match, "match", "", "Switch to the only configuration whose name matches this glob")
+	cmd.Flags().IntVar(&o.index, "index", 0, "Switch to the configuration at this position of 'gcloudctx -l', counting from 1")
+	cmd.Flags().StringVarP(&o.outputFormat, "output", "o", "", "Output format for the switch result (json, yaml)")
+	cmd.Flags().BoolVar(&o.noHooks, "no-hooks", false, "Do not run post-switch hooks or the GKE integration")
+	cmd.Flags().BoolVar(&o.noHistory, "no-history", false, "Do not record the switch in the history or usage statistics")
+	cmd.Flags().BoolVar(&o.forceSwitch, "force", false, "Switch even when a pre-switch guard refuses")
+	cmd.Flags().BoolVarP(&o.yes, "yes", "y", false, "Switch to a protected configuration without confirmation")
+	cmd.MarkFlagsMutuallyExclusive("match", "index")
+	cmd.MarkFlagsOneRequired("match", "index")
+	_ = cmd.RegisterFlagCompletionFunc("match", completeConfigNames)
+	return cmd
+}
+
+func (o *pickOptions) runPick(cmd *cobra.Command, args []string) error {
+	if cmd.Flags().Changed("index") && o.index < 1 {
+		err := fmt.Errorf("--index must be 1 or more, got %d", o.index)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	name, err := o.pickConfiguration(configs)
+	if err != nil {
+		return err
+	}
+	return o.switchConfiguration(name)
+}
+
+// pickConfiguration returns the name of the configuration --match or --index
+// picks from configs; when there is none, or several, it returns an error
+// carrying pick's exit status
+func (o *pickOptions) pickConfiguration(configs []gcloud.Configuration) (string, error) {
+	if o.match == "" {
+		if o.index > len(configs) {
+			err := fmt.Errorf("there is no configuration at position %d (%d configurations)", o.index, len(configs))
+			output.PrintError(err.Error(), !o.noColor)
+			return "", &exitError{err: err, code: pickExitNoMatch}
+		}
+		return configs[o.index-1].Name, nil
+	}
+
+	matches, err := gcloud.MatchConfigurations(configs, o.match)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		err := fmt.Errorf("no configuration matches %q", o.match)
+		output.PrintError(err.Error(), !o.noColor)
+		return "", &exitError{err: err, code: pickExitNoMatch}
+	case 1:
+		return matches[0].Name, nil
+	}
+
+	// Stdout carries only the names, for scripts to offer a choice
+	output.SetMessageOutput(os.Stderr)
+	for _, config := range matches {
+		fmt.Println(config.Name)
+	}
+	err = fmt.Errorf("%d configurations match %q; use a narrower pattern", len(matches), o.match)
+	output.PrintError(err.Error(), !o.noColor)
+	return "", &exitError{err: err, code: pickExitAmbiguous}
+}
+
+// exitError makes gcloudctx exit with a status other than 1
+type exitError struct {
+	err  error
+	code int
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// exitCode returns the status gcloudctx exits with after a command fails with err
+func exitCode(err error) int {
+	var exit *exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+	return 1
+}
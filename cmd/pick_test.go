@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPickMatch(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("prod-eu", map[string]string{"core/project": "prod-eu-project"})
+
+	res := env.mustRun("pick", "--match", "*-eu")
+
+	if active := env.gcloud.Active(); active != "prod-eu" {
+		t.Errorf("active = %q, want prod-eu", active)
+	}
+	if !strings.Contains(res.stdout, "prod-eu") {
+		t.Errorf("stdout = %q, want the switch reported", res.stdout)
+	}
+}
+
+func TestPickAmbiguous(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("prod-eu", map[string]string{"core/project": "prod-eu-project"})
+
+	res := env.run("", "pick", "--match", "prod*")
+
+	if got := exitCode(res.err); got != pickExitAmbiguous {
+		t.Errorf("exit code = %d (%v), want %d", got, res.err, pickExitAmbiguous)
+	}
+	if res.stdout != "prod\nprod-eu\n" {
+		t.Errorf("stdout = %q, want only the matching names", res.stdout)
+	}
+	if !strings.Contains(res.stderr, `2 configurations match "prod*"`) {
+		t.Errorf("stderr = %q, want the error", res.stderr)
+	}
+	if active := env.gcloud.Active(); active != "dev" {
+		t.Errorf("active = %q, want dev unchanged", active)
+	}
+}
+
+func TestPickNoMatch(t *testing.T) {
+	env := newTestEnv(t)
+
+	for _, args := range [][]string{{"--match", "staging*"}, {"--index", "3"}} {
+		res := env.run("", append([]string{"pick"}, args...)...)
+		if got := exitCode(res.err); got != pickExitNoMatch {
+			t.Errorf("pick %v: exit code = %d (%v), want %d", args, got, res.err, pickExitNoMatch)
+		}
+	}
+	for _, args := range [][]string{{}, {"--index", "0"}, {"--match", "prod*", "--index", "1"}, {"--match", "prod["}} {
+		res := env.run("", append([]string{"pick"}, args...)...)
+		if res.err == nil || exitCode(res.err) != 1 {
+			t.Errorf("pick %v: error = %v, want a usage error", args, res.err)
+		}
+	}
+	if active := env.gcloud.Active(); active != "dev" {
+		t.Errorf("active = %q, want dev unchanged", active)
+	}
+}
+
+func TestPickIndex(t *testing.T) {
+	env := newTestEnv(t)
+	// Added last, but listed first
+	env.gcloud.Add("acme", map[string]string{"core/project": "acme-project"})
+
+	list := env.mustRun("-l", "-o", "name")
+	if list.stdout != "acme\ndev\nprod\n" {
+		t.Fatalf("gcloudctx -l -o name = %q, want the configurations sorted by name", list.stdout)
+	}
+
+	env.mustRun("pick", "--index", "3")
+	if active := env.gcloud.Active(); active != "prod" {
+		t.Errorf("after --index 3, active = %q, want prod", active)
+	}
+	env.mustRun("pick", "--index", "1")
+	if active := env.gcloud.Active(); active != "acme" {
+		t.Errorf("after --index 1, active = %q, want acme", active)
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("prod-eu", map[string]string{"core/project": "prod-eu-project"})
+
+	if res := env.mustRun("-l", "--filter", "prod*", "-o", "name"); res.stdout != "prod\nprod-eu\n" {
+		t.Errorf("-l --filter prod* = %q, want the matching names", res.stdout)
+	}
+	if res := env.mustRun("-l", "--filter", "staging"); !strings.Contains(res.stdout, `No configurations match "staging"`) {
+		t.Errorf("-l --filter staging = %q, want no matches reported", res.stdout)
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/interactive"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newPickerDeleteCmd returns an internal command used by the fzf delete key binding
+func newPickerDeleteCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:    interactive.DeleteCommand + " <fzf-line>",
+		Short:  "Internal command for fzf delete binding (do not use directly)",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		RunE:   o.runPickerDelete,
+	}
+}
+
+// newPickerListCmd returns an internal command used to reload the fzf list
+func newPickerListCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:    interactive.ListCommand,
+		Short:  "Internal command for fzf list reload (do not use directly)",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE:   o.runPickerList,
+	}
+}
+
+func (o *options) runPickerDelete(cmd *cobra.Command, args []string) error {
+	// Wait for the user before returning to fzf so messages stay visible
+	defer waitForEnter()
+
+	configName, err := interactive.ParseConfigurationName(args[0])
+	if err != nil {
+		output.PrintError(fmt.Sprintf("could not parse configuration name from %q", args[0]), !o.noColor)
+		return nil
+	}
+
+	activeConfig, err := gcloud.GetActiveConfiguration()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return nil
+	}
+
+	if activeConfig.Name == configName {
+		output.PrintError(fmt.Sprintf("cannot delete the active configuration %q from the picker", configName), !o.noColor)
+		return nil
+	}
+
+	// Reuse the delete command so confirmation is handled the same way;
+	// errors are already printed and must not break fzf
+	_ = (&deleteOptions{options: o}).runDelete(cmd, []string{configName})
+	return nil
+}
+
+func (o *options) runPickerList(cmd *cobra.Command, args []string) error {
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		return nil // Keep the current list rather than breaking fzf
+	}
+
+	currentName := ""
+	for _, config := range configs {
+		if config.IsActive {
+			currentName = config.Name
+		}
+	}
+
+	// fzf reads the list through a pipe; keep the colors it renders with --ansi
+	if _, noColorEnv := os.LookupEnv("NO_COLOR"); !o.noColor && !noColorEnv {
+		color.NoColor = false
+	}
+	output.SetPreviousConfiguration(previousConfigName(configs))
+	fmt.Print(interactive.FormatConfigurationLines(configs, currentName))
+	return nil
+}
+
+// waitForEnter blocks until the user presses Enter
+func waitForEnter() {
+	fmt.Print("\nPress Enter to return to the picker...")
+	_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+}
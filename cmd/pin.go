@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/session"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// pinTerminalOptions holds the flags of the pin-terminal command
+type pinTerminalOptions struct {
+	*options
+	unset bool
+}
+
+func newPinTerminalCmd(parent *options) *cobra.Command {
+	o := &pinTerminalOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "pin-terminal [configuration-name]",
+		Short: "Pin this terminal to a configuration so 'gcloudctx auto' leaves it alone",
+		Long: `Pin the current terminal to a configuration.
+
+While a terminal is pinned, 'gcloudctx auto' does nothing in it, so changing into
+directories with a .gcloudctx file no longer switches configurations there. Other
+terminals keep following .gcloudctx files.
+
+Terminals are identified by the GCLOUDCTX_SESSION environment variable; add this
+to your shell rc file:
+
+  export GCLOUDCTX_SESSION=$$          # Bash / Zsh
+  set -gx GCLOUDCTX_SESSION $fish_pid  # Fish
+  $env:GCLOUDCTX_SESSION = $PID        # PowerShell
+
+Pins expire after 24 hours in which 'gcloudctx auto' did not run in the terminal.
+
+Examples:
+  gcloudctx pin-terminal prod     # Switch to 'prod' and pin this terminal to it
+  gcloudctx pin-terminal          # Pin this terminal to the active configuration
+  gcloudctx pin-terminal --unset  # Release the pin`,
+		Args:              cobra.MaximumNArgs(1),
+		RunE:              o.runPinTerminal,
+		ValidArgsFunction: completeConfigNames,
+	}
+	cmd.Flags().BoolVar(&o.unset, "unset", false, "Release this terminal's pin")
+	return cmd
+}
+
+func (o *pinTerminalOptions) runPinTerminal(cmd *cobra.Command, args []string) error {
+	sessionID, err := session.ID()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	store, err := session.DefaultPinStore()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if o.unset {
+		if err := store.Unset(sessionID); err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		output.PrintSuccess("released terminal pin", !o.noColor)
+		return nil
+	}
+
+	var configName string
+	if len(args) == 1 {
+		configName = args[0]
+		if err := o.switchConfiguration(configName); err != nil {
+			return err
+		}
+	} else {
+		activeConfig, err := gcloud.GetActiveConfiguration()
+		if err != nil {
+			err = withBootstrapHint(err)
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		configName = activeConfig.Name
+	}
+
+	if err := store.Set(sessionID, configName); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	output.PrintSuccess(fmt.Sprintf("pinned this terminal to configuration %q", configName), !o.noColor)
+	return nil
+}
+
+// terminalPin returns the pin of the current terminal, or nil when it is not pinned
+// Errors are ignored so a broken pins file never breaks auto-switching or the prompt
+func terminalPin() *session.Pin {
+	sessionID, err := session.ID()
+	if err != nil {
+		return nil
+	}
+
+	store, err := session.DefaultPinStore()
+	if err != nil {
+		return nil
+	}
+
+	pin, err := store.Get(sessionID)
+	if err != nil {
+		return nil
+	}
+	return pin
+}
+
+// touchTerminalPin keeps the pin of the current terminal from expiring while
+// auto-switching honors it
+func touchTerminalPin() {
+	sessionID, err := session.ID()
+	if err != nil {
+		return
+	}
+
+	store, err := session.DefaultPinStore()
+	if err != nil {
+		return
+	}
+
+	if err := store.Touch(sessionID); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to refresh terminal pin: %w", err))
+	}
+}
+
+// printPinnedNotice tells the user (dimmed, on stderr) that auto-switching was skipped
+func (o *options) printPinnedNotice(pin *session.Pin) {
+	if o.noColor {
+		color.NoColor = true
+	}
+	gray := color.New(color.FgHiBlack).SprintFunc()
+	fmt.Fprintln(os.Stderr, gray(fmt.Sprintf("terminal pinned to %q; not switching (gcloudctx pin-terminal --unset to release)", pin.Config)))
+}
@@ -2,33 +2,48 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
 	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
 	"github.com/Okabe-Junya/gcloudctx/pkg/interactive"
 	"github.com/spf13/cobra"
 )
 
-// previewCmd is an internal command used by fzf for preview functionality
-var previewCmd = &cobra.Command{
-	Use:    interactive.PreviewCommand + " <configuration-name>",
-	Short:  "Internal command for fzf preview (do not use directly)",
-	Hidden: true, // Hide from help output
-	Args:   cobra.ExactArgs(1),
-	RunE:   runPreview,
-}
-
-func init() {
-	rootCmd.AddCommand(previewCmd)
+// newPreviewCmd returns an internal command used by fzf for preview functionality
+// fzf pipes the selection line in; passing it as an argument is still accepted
+func newPreviewCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:    interactive.PreviewCommand + " [selection-line]",
+		Short:  "Internal command for fzf preview (do not use directly)",
+		Hidden: true, // Hide from help output
+		Args:   cobra.MaximumNArgs(1),
+		RunE:   o.runPreview,
+	}
 }
 
-func runPreview(cmd *cobra.Command, args []string) error {
-	input := args[0]
+func (o *options) runPreview(cmd *cobra.Command, args []string) error {
+	var input string
+	if len(args) > 0 {
+		input = args[0]
+	} else {
+		// Reading the line from stdin avoids the shell quoting of {}, which some
+		// fzf and shell combinations on Windows mangle
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Printf("Configuration: (unreadable)\n\n(Could not read the selection: %v)\n", err)
+			return nil
+		}
+		input = string(data)
+	}
 
 	// Parse the configuration name from the fzf selection line
 	// Format: "* config-name (account) [project]" or "  config-name (account) [project]"
 	configName, err := interactive.ParseConfigurationName(input)
 	if err != nil {
-		fmt.Printf("Configuration: %s\n\n(Could not parse configuration name)\n", input)
+		fmt.Printf("Configuration: %s\n\n(Could not parse configuration name)\n", strings.TrimSpace(input))
 		return nil
 	}
 
@@ -40,33 +55,9 @@ func runPreview(cmd *cobra.Command, args []string) error {
 	}
 
 	// Display configuration details
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("  Configuration: %s\n", config.Name)
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
-
-	if config.IsActive {
-		fmt.Printf("  Status:  ✓ Active\n")
-	} else {
-		fmt.Printf("  Status:  Inactive\n")
-	}
-
-	if config.Properties.Core.Account != "" {
-		fmt.Printf("  Account: %s\n", config.Properties.Core.Account)
-	}
-
-	if config.Properties.Core.Project != "" {
-		fmt.Printf("  Project: %s\n", config.Properties.Core.Project)
-	}
-
-	if config.Properties.Compute.Region != "" {
-		fmt.Printf("  Region:  %s\n", config.Properties.Compute.Region)
-	}
-
-	if config.Properties.Compute.Zone != "" {
-		fmt.Printf("  Zone:    %s\n", config.Properties.Compute.Zone)
-	}
-
-	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	loadLastUsed()
+	loadNotes()
+	output.PrintPreview(config, interactive.PreviewWidth())
 
 	return nil
 }
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	tests := []struct {
+		name  string
+		stdin string
+		args  []string
+	}{
+		{name: "line as argument", args: []string{"prod\t  prod (ops@example.com) [prod-project]"}},
+		{name: "line on stdin", stdin: "prod\t  prod (ops@example.com) [prod-project]\n"},
+		{name: "display line on stdin", stdin: "  prod (ops@example.com) [prod-project]\n"},
+		{name: "parentheses in the account as argument", args: []string{"* prod (ci (bot)@example.com) [prod-project]"}},
+		{name: "parentheses in the account on stdin", stdin: "* prod (ci (bot)@example.com) [prod-project]\n"},
+		{name: "brackets in the account on stdin", stdin: "  prod ([ops]@example.com) [prod-project]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+
+			res := env.run(tt.stdin, append([]string{"__preview"}, tt.args...)...)
+
+			if res.err != nil {
+				t.Fatalf("__preview: %v", res.err)
+			}
+			if strings.Contains(res.stdout, "Could not parse") || !strings.Contains(res.stdout, "prod-project") {
+				t.Errorf("stdout = %q, want the details of prod", res.stdout)
+			}
+		})
+	}
+}
+
+func TestPreviewUnparsable(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("\n", "__preview")
+
+	if res.err != nil {
+		t.Fatalf("__preview: %v", res.err)
+	}
+	if !strings.Contains(res.stdout, "Could not parse configuration name") {
+		t.Errorf("stdout = %q, want the line reported as unparsable", res.stdout)
+	}
+}
+
+func TestPreviewWindowWidth(t *testing.T) {
+	env := newTestEnv(t)
+	t.Setenv("FZF_PREVIEW_COLUMNS", "30")
+
+	res := env.mustRun("__preview", "prod")
+
+	if want := strings.Repeat("━", 30) + "\n"; !strings.HasPrefix(res.stdout, want) {
+		t.Errorf("stdout = %q, want rules as wide as the preview window", res.stdout)
+	}
+}
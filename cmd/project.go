@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/cache"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/interactive"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// envProjectCacheTTL overrides how long the project list is cached (e.g. "30m")
+	envProjectCacheTTL = "GCLOUDCTX_PROJECT_CACHE_TTL"
+
+	// defaultProjectCacheTTL is how long the project list is cached per account
+	defaultProjectCacheTTL = 10 * time.Minute
+)
+
+// projectOptions holds the flags of the project command
+type projectOptions struct {
+	*options
+	interactive bool
+	refresh     bool
+}
+
+func newProjectCmd(parent *options) *cobra.Command {
+	o := &projectOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "project [project-id]",
+		Short: "Show or set the project of the active configuration",
+		Long: `Show or set the project (core/project) of the active configuration.
+
+Without arguments, an interactive fzf picker listing your projects is shown
+(if fzf is installed); otherwise the current project is printed.
+
+The project list is cached per account for 10 minutes because
+'gcloud projects list' is slow. Use --refresh to reload it, or set
+GCLOUDCTX_PROJECT_CACHE_TTL (e.g. "1h") to change the cache lifetime.
+
+Examples:
+  gcloudctx project                 # Pick a project interactively
+  gcloudctx project my-project-id   # Set the project directly
+  gcloudctx project -               # Switch back to the previous project
+  gcloudctx project -i --refresh    # Pick from a freshly loaded list`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: o.runProject,
+	}
+	cmd.Flags().BoolVarP(&o.interactive, "interactive", "i", false, "Interactive project selection with fzf")
+	cmd.Flags().BoolVar(&o.refresh, "refresh", false, "Reload the project list instead of using the cache")
+	return cmd
+}
+
+// newProjectPreviewCmd returns an internal command used by fzf for the project picker preview
+func newProjectPreviewCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:    interactive.ProjectPreviewCommand + " <fzf-line>",
+		Short:  "Internal command for fzf project preview (do not use directly)",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		RunE:   o.runProjectPreview,
+	}
+}
+
+func (o *projectOptions) runProject(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		// Handle '-' to switch to the previous project of the active configuration
+		if args[0] == "-" {
+			return o.switchToPreviousProject()
+		}
+		return o.setProject(args[0])
+	}
+
+	if o.interactive {
+		return o.interactiveProjectSelection()
+	}
+
+	if os.Getenv(interactive.EnvIgnoreFzf) != "1" && interactive.IsFzfInstalled() {
+		return o.interactiveProjectSelection()
+	}
+
+	return o.showCurrentProject()
+}
+
+func (o *options) showCurrentProject() error {
+	core, err := gcloud.GetActiveCoreProperties()
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if core.Project == "" {
+		fmt.Println("No project set")
+		return nil
+	}
+
+	fmt.Println(core.Project)
+	return nil
+}
+
+func (o *options) switchToPreviousProject() error {
+	activeConfig, err := gcloud.GetActiveConfiguration()
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	previousProject, err := history.GetPreviousProject(activeConfig.Name)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	return o.setProject(previousProject)
+}
+
+func (o *options) setProject(projectID string) error {
+	// Remember the current project so 'gcloudctx project -' can flip back
+	activeConfig, err := gcloud.GetActiveConfiguration()
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	currentProject := activeConfig.Properties.Core.Project
+	if currentProject == projectID {
+		output.PrintSuccess(fmt.Sprintf("already on project %q", projectID), !o.noColor)
+		return nil
+	}
+
+	if currentProject != "" {
+		if err := history.SavePreviousProject(activeConfig.Name, currentProject); err != nil {
+			// Non-fatal error, just warn
+			statedir.Warn(os.Stderr, fmt.Errorf("failed to save project history: %w", err))
+		}
+	}
+
+	stop := output.StartSpinner("Setting project...")
+	err = gcloud.SetProject(projectID)
+	stop()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	output.PrintSuccess(fmt.Sprintf("set project to %q", projectID), !o.noColor)
+	return nil
+}
+
+func (o *projectOptions) interactiveProjectSelection() error {
+	if !interactive.IsFzfInstalled() {
+		output.PrintError("fzf is not installed. Please install fzf for interactive mode.", !o.noColor)
+		return interactive.ErrFzfNotInstalled
+	}
+
+	activeConfig, err := gcloud.GetActiveConfiguration()
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	projects, err := loadProjects(activeConfig.Properties.Core.Account, o.refresh)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	selected, err := interactive.SelectProjectInteractive(projects, activeConfig.Properties.Core.Project)
+	if err != nil {
+		// Canceling leaves the project untouched
+		if errors.Is(err, interactive.ErrSelectionCanceled) {
+			return nil
+		}
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	return o.setProject(selected)
+}
+
+// loadProjects returns the projects for an account, using the cache when it is
+// fresh, or offline when there is any
+func loadProjects(account string, refresh bool) ([]gcloud.Project, error) {
+	key := projectCacheKey(account)
+
+	var projects []gcloud.Project
+	if !refresh {
+		if found, err := cache.Load(key, projectCacheTTL(), &projects); err == nil && found {
+			return projects, nil
+		}
+	}
+
+	if gcloud.Offline() {
+		// Any cached list beats none, however old
+		if found, err := cache.Load(key, math.MaxInt64, &projects); err == nil && found {
+			fmt.Fprintln(os.Stderr, "Project list refresh (skipped: offline); using the cached list")
+			return projects, nil
+		}
+		return nil, fmt.Errorf("%w: no cached project list for %s (list the projects once online)", gcloud.ErrOffline, account)
+	}
+
+	stop := output.StartSpinner("Loading projects...")
+	projects, err := gcloud.ListProjects()
+	stop()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cache.Save(key, projects); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to cache project list: %w", err))
+	}
+
+	return projects, nil
+}
+
+// projectCacheKey returns the cache key of the project list for an account
+func projectCacheKey(account string) string {
+	return "projects-" + account
+}
+
+// projectCacheTTL returns the project list cache lifetime
+func projectCacheTTL() time.Duration {
+	if value := os.Getenv(envProjectCacheTTL); value != "" {
+		if ttl, err := time.ParseDuration(value); err == nil {
+			return ttl
+		}
+	}
+	return defaultProjectCacheTTL
+}
+
+func (o *options) runProjectPreview(cmd *cobra.Command, args []string) error {
+	projectID, err := interactive.ParseProjectID(args[0])
+	if err != nil {
+		fmt.Printf("Project: %s\n\n(Could not parse project ID)\n", args[0])
+		return nil
+	}
+
+	// The picker was just populated from the cache, so read details from it
+	// instead of making a slow network call for every highlighted line
+	activeConfig, err := gcloud.GetActiveConfiguration()
+	if err != nil {
+		fmt.Printf("Project: %s\n\n(Details unavailable)\n", projectID)
+		return nil // Don't return error to avoid breaking fzf
+	}
+
+	var projects []gcloud.Project
+	found, err := cache.Load(projectCacheKey(activeConfig.Properties.Core.Account), projectCacheTTL(), &projects)
+	if err != nil || !found {
+		fmt.Printf("Project: %s\n\n(Details unavailable)\n", projectID)
+		return nil
+	}
+
+	project, ok := gcloud.FindProjectByID(projects, projectID)
+	if !ok {
+		fmt.Printf("Project: %s\n\n(Details unavailable)\n", projectID)
+		return nil
+	}
+
+	output.PrintProjectPreview(project, interactive.PreviewWidth())
+	return nil
+}
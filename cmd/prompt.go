@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
+	"github.com/Okabe-Junya/gcloudctx/pkg/promptgen"
+	"github.com/spf13/cobra"
+)
+
+// pinGlyph marks a pinned terminal in the prompt segment
+const pinGlyph = "📌"
+
+// promptOptions holds the flags of the prompt command
+type promptOptions struct {
+	*options
+	format   string
+	starship bool
+	p10k     bool
+	color    string
+}
+
+func newPromptCmd(parent *options) *cobra.Command {
+	o := &promptOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "Print the active configuration for use in a shell prompt",
+		Long: `Print the active configuration name for use in a shell prompt.
+
+This reads gcloud's state files directly instead of running gcloud, so it is
+fast enough to run on every prompt. gcloud is only asked when active_config
+names a configuration without a file, such as one deleted by hand. A pin glyph is shown when the terminal is
+pinned with 'gcloudctx pin-terminal', and the configuration's emoji from the
+"display" setting in ~/.gcloudctx.yaml before its name. Nothing is printed on errors.
+
+--format replaces {name}, {project}, {emoji} and {pin} (the pin glyph when the
+terminal is pinned) in a template of your own. Values are printed without
+colors, which every shell marks up differently.
+
+--starship and --p10k print a ready-made starship module or powerlevel10k
+segment that runs this command, with --format and --color (one of the display
+colors, default blue) filled in, to append to your prompt configuration.
+
+Examples:
+  # Bash
+  PS1='[$(gcloudctx prompt)] \w $ '
+  # Zsh
+  setopt PROMPT_SUBST; PROMPT='[$(gcloudctx prompt)] %~ %# '
+  # Emoji and project
+  PS1='[$(gcloudctx prompt --format "{emoji}{name}:{project}")] \w $ '
+  # Starship and powerlevel10k; append the output to ~/.config/starship.toml or ~/.p10k.zsh
+  gcloudctx prompt --starship --color cyan
+  gcloudctx prompt --p10k --format "{emoji}{name}"`,
+		Args: cobra.NoArgs,
+		RunE: o.runPrompt,
+	}
+	cmd.Flags().StringVar(&o.format, "format", "", "Template with {name}, {project}, {emoji} and {pin} placeholders")
+	cmd.Flags().BoolVar(&o.starship, "starship", false, "Print a starship custom module running this command")
+	cmd.Flags().BoolVar(&o.p10k, "p10k", false, "Print a powerlevel10k segment function running this command")
+	cmd.Flags().StringVar(&o.color, "color", "", "Color of the --starship or --p10k segment (default blue)")
+	cmd.MarkFlagsMutuallyExclusive("starship", "p10k")
+	_ = cmd.RegisterFlagCompletionFunc("color", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return linefmt.ColorNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+func (o *promptOptions) runPrompt(cmd *cobra.Command, args []string) error {
+	// Stdout ends up in the prompt, so messages never go there
+	output.SetMessageOutput(os.Stderr)
+
+	// A mistyped template is reported, unlike errors reading the configuration
+	if err := validatePromptFormat(o.format); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if o.starship || o.p10k {
+		return o.printPromptSegment()
+	}
+	if o.color != "" {
+		err := errors.New("--color only applies to --starship and --p10k")
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	config, err := gcloud.GetActiveConfigurationFast()
+	if err != nil {
+		// Never break the user's prompt
+		return nil
+	}
+
+	pinned := terminalPin() != nil
+	if o.format != "" {
+		fmt.Println(expandPromptFormat(o.format, config, pinned))
+		return nil
+	}
+	fmt.Println(formatPromptSegment(config.Name, pinned))
+	return nil
+}
+
+// printPromptSegment prints the starship module or powerlevel10k segment
+func (o *promptOptions) printPromptSegment() error {
+	generate := promptgen.P10k
+	if o.starship {
+		generate = promptgen.Starship
+	}
+	segment, err := generate(promptgen.Options{Format: o.format, Color: o.color})
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	fmt.Print(segment)
+	return nil
+}
+
+// formatPromptSegment renders the prompt segment for a configuration name
+func formatPromptSegment(name string, pinned bool) string {
+	segment := name
+	if emoji := output.DisplayEmoji(name); emoji != "" {
+		segment = emoji + " " + segment
+	}
+	if pinned {
+		return pinGlyph + " " + segment
+	}
+	return segment
+}
+
+// promptPlaceholderPattern matches a placeholder of the prompt format
+var promptPlaceholderPattern = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// promptPlaceholders are the placeholders --format accepts
+var promptPlaceholders = []string{"{name}", "{project}", "{emoji}", "{pin}"}
+
+// validatePromptFormat rejects placeholders --format does not know
+func validatePromptFormat(format string) error {
+	for _, placeholder := range promptPlaceholderPattern.FindAllString(format, -1) {
+		if !slices.Contains(promptPlaceholders, placeholder) {
+			return fmt.Errorf("unknown placeholder %s in --format (use %s)", placeholder, strings.Join(promptPlaceholders, ", "))
+		}
+	}
+	return nil
+}
+
+// expandPromptFormat fills in the placeholders of format for config
+func expandPromptFormat(format string, config *gcloud.Configuration, pinned bool) string {
+	pin := ""
+	if pinned {
+		pin = pinGlyph
+	}
+	return strings.NewReplacer(
+		"{name}", config.Name,
+		"{project}", config.Properties.Core.Project,
+		"{emoji}", output.DisplayEmoji(config.Name),
+		"{pin}", pin,
+	).Replace(format)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptDisplayEmoji(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeSettings("display:\n  dev: {color: green, emoji: \"🧪\"}\n")
+
+	if res := env.mustRun("prompt"); res.stdout != "🧪 dev\n" {
+		t.Errorf("prompt = %q, want the emoji before the name", res.stdout)
+	}
+
+	res := env.mustRun("prompt", "--format", "{emoji}{name}:{project}{pin}")
+	if res.stdout != "🧪dev:dev-project\n" {
+		t.Errorf("prompt --format = %q", res.stdout)
+	}
+}
+
+func TestPromptUnknownPlaceholder(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "prompt", "--format", "{nmae}")
+	if res.err == nil || res.stdout != "" || !strings.Contains(res.stderr, "unknown placeholder {nmae}") {
+		t.Errorf("prompt with an unknown placeholder = %q, %q, %v; want an error", res.stdout, res.stderr, res.err)
+	}
+}
+
+func TestSwitchMessageDisplayStyle(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeSettings("display:\n  prod: {color: red, emoji: \"🔥\"}\n")
+
+	res := env.mustRun("prod")
+	if !strings.Contains(res.stdout, `switched to configuration 🔥 "prod"`) {
+		t.Errorf("stdout = %q, want the emoji in the success message", res.stdout)
+	}
+	if res := env.mustRun("--list"); !strings.Contains(res.stdout, "* 🔥 prod") {
+		t.Errorf("list = %q, want the emoji before the active name", res.stdout)
+	}
+}
+
+func TestPromptFrameworkSegments(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("prompt", "--starship", "--format", "{emoji}{name}", "--color", "cyan")
+	if !strings.Contains(res.stdout, "[custom.gcloudctx]") || !strings.Contains(res.stdout, `command = "gcloudctx prompt --format '{emoji}{name}'"`) ||
+		!strings.Contains(res.stdout, `style = "bold cyan"`) {
+		t.Errorf("prompt --starship = %q", res.stdout)
+	}
+
+	res = env.mustRun("prompt", "--p10k")
+	if !strings.Contains(res.stdout, "function prompt_gcloudctx()") || !strings.Contains(res.stdout, `segment="$(gcloudctx prompt 2>/dev/null)"`) ||
+		!strings.Contains(res.stdout, "p10k segment -f 4 ") {
+		t.Errorf("prompt --p10k = %q", res.stdout)
+	}
+
+	for _, args := range [][]string{
+		{"prompt", "--starship", "--p10k"},
+		{"prompt", "--p10k", "--color", "octarine"},
+		{"prompt", "--starship", "--format", "{nmae}"},
+		{"prompt", "--color", "cyan"},
+	} {
+		if res := env.run("", args...); res.err == nil || res.stdout != "" {
+			t.Errorf("%v = %q, %v; want an error", args, res.stdout, res.err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/settings"
+	"github.com/spf13/cobra"
+)
+
+func newProtectCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "protect <configuration-name>",
+		Short: "Ask for confirmation before switching to a configuration",
+		Long: `Mark a configuration as protected.
+
+Switching to a protected configuration asks "Switch to PROTECTED configuration
+'NAME'? (y/N)" first; pass --yes to skip the question in scripts. Without a
+terminal to ask on, the switch fails unless --yes is given. 'gcloudctx auto'
+never switches to protected configurations unless auto.allow_protected is set.
+Protected configurations are shown in red in lists and the picker. Deleting
+one, with 'gcloudctx delete' or 'gcloudctx apply --prune', requires typing its
+name even with --force.
+
+The set is kept under "protected" in ~/.gcloudctx.yaml.
+
+Examples:
+  gcloudctx protect prod     # Confirm before switching to 'prod'
+  gcloudctx unprotect prod   # Switch to 'prod' without confirmation again`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.runProtect,
+		ValidArgsFunction: completeConfigNames,
+	}
+}
+
+func newUnprotectCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:               "unprotect <configuration-name>",
+		Short:             "Stop asking for confirmation before switching to a configuration",
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.runUnprotect,
+		ValidArgsFunction: o.completeProtectedNames,
+	}
+}
+
+func (o *options) runProtect(cmd *cobra.Command, args []string) error {
+	configName := args[0]
+
+	exists, err := gcloud.ConfigurationExists(configName)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if !exists {
+		output.PrintError(fmt.Sprintf("configuration %q does not exist", configName), !o.noColor)
+		return fmt.Errorf("configuration not found")
+	}
+
+	changed, err := settings.Protect(configName)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if !changed {
+		output.PrintSuccess(fmt.Sprintf("configuration %q is already protected", configName), !o.noColor)
+		return nil
+	}
+	output.PrintSuccess(fmt.Sprintf("protected configuration %q", configName), !o.noColor)
+	return nil
+}
+
+func (o *options) runUnprotect(cmd *cobra.Command, args []string) error {
+	configName := args[0]
+
+	changed, err := settings.Unprotect(configName)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if !changed {
+		output.PrintSuccess(fmt.Sprintf("configuration %q is not protected", configName), !o.noColor)
+		return nil
+	}
+	output.PrintSuccess(fmt.Sprintf("unprotected configuration %q", configName), !o.noColor)
+	return nil
+}
+
+// completeProtectedNames completes the names of protected configurations
+func (o *options) completeProtectedNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return o.protectedNames, cobra.ShellCompDirectiveNoFileComp
+}
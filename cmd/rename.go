@@ -2,29 +2,87 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/internal/prompt"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/bulkrename"
 	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/inflight"
+	"github.com/Okabe-Junya/gcloudctx/pkg/notes"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
 	"github.com/spf13/cobra"
 )
 
-var renameCmd = &cobra.Command{
-	Use:   "rename <old-name> <new-name>",
-	Short: "Rename a gcloud configuration",
-	Long: `Rename a gcloud configuration.
+// renameOptions holds the flags of the rename command
+type renameOptions struct {
+	*options
+	prefix  string
+	suffix  string
+	pattern string
+	dryRun  bool
+	yes     bool
+}
+
+func newRenameCmd(parent *options) *cobra.Command {
+	o := &renameOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "rename <old-name> <new-name>",
+		Short: "Rename a gcloud configuration",
+		Long: `Rename a gcloud configuration.
 
 This creates a new configuration with the new name, copies all properties
-from the old configuration, and deletes the old one.
+from the old configuration, and deletes the old one. If any step fails, both
+configurations and the active one are put back as they were.
+
+Renaming "default" is allowed, but gcloud may recreate an empty "default"
+configuration later, for example when no other configuration is active.
+
+--prefix, --suffix and --pattern rename every configuration they match
+instead. --prefix OLD:NEW and --suffix OLD:NEW replace a prefix or suffix, or
+add NEW to every name when OLD is empty. --pattern takes a sed-like
+substitution, s/REGEXP/REPLACEMENT/ with an optional g flag, where
+REPLACEMENT may use \1 to \9 and &. Every new name is
+checked before anything is renamed: it must be valid, unused, and not the new
+name of another configuration. The mapping is shown, one "OLD -> NEW" line per
+configuration sorted by old name, and confirmed before the renames start; a
+rename that fails does not stop the others.
 
 Examples:
-  gcloudctx rename old-config new-config`,
-	Args:              cobra.ExactArgs(2),
-	RunE:              runRename,
-	ValidArgsFunction: completeConfigNamesForRename,
+  gcloudctx rename old-config new-config
+  gcloudctx rename --prefix acme-:newco- --dry-run  # Review the mapping
+  gcloudctx rename --prefix acme-:newco-
+  gcloudctx rename --suffix -old: --yes
+  gcloudctx rename --pattern 's/^(.*)-eu$/eu-\1/'`,
+		Args:              o.validateRenameArgs,
+		RunE:              o.runRename,
+		ValidArgsFunction: completeConfigNamesForRename,
+	}
+	cmd.Flags().StringVar(&o.prefix, "prefix", "", "Replace the prefix OLD with NEW in every configuration name (OLD:NEW)")
+	cmd.Flags().StringVar(&o.suffix, "suffix", "", "Replace the suffix OLD with NEW in every configuration name (OLD:NEW)")
+	cmd.Flags().StringVar(&o.pattern, "pattern", "", "Rename configurations with a sed-like substitution (s/REGEXP/REPLACEMENT/)")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Only show how --prefix, --suffix or --pattern would rename configurations")
+	cmd.Flags().BoolVarP(&o.yes, "yes", "y", false, "Rename without confirmation")
+	cmd.MarkFlagsMutuallyExclusive("prefix", "suffix", "pattern")
+	return cmd
+}
+
+// bulk reports whether configurations are renamed by a rule instead of by name
+func (o *renameOptions) bulk() bool {
+	return o.prefix != "" || o.suffix != "" || o.pattern != ""
 }
 
-func init() {
-	rootCmd.AddCommand(renameCmd)
+// validateRenameArgs takes two names, or none with a rule
+func (o *renameOptions) validateRenameArgs(cmd *cobra.Command, args []string) error {
+	if o.bulk() {
+		return cobra.NoArgs(cmd, args)
+	}
+	if o.dryRun {
+		return fmt.Errorf("--dry-run needs --prefix, --suffix or --pattern")
+	}
+	return cobra.ExactArgs(2)(cmd, args)
 }
 
 // completeConfigNamesForRename provides completion for rename command
@@ -34,35 +92,182 @@ func completeConfigNamesForRename(cmd *cobra.Command, args []string, toComplete
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
-	configs, err := gcloud.ListConfigurations()
+	configs, err := completionConfigurations()
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
 	var names []string
-	for _, config := range configs {
-		names = append(names, config.Name)
+	for i := range configs {
+		names = append(names, output.CompletionEntry(&configs[i]))
 	}
 
-	return names, cobra.ShellCompDirectiveNoFileComp
+	return pendingOperations().FilterCompletions(names), cobra.ShellCompDirectiveNoFileComp
 }
 
-func runRename(cmd *cobra.Command, args []string) error {
+func (o *renameOptions) runRename(cmd *cobra.Command, args []string) error {
+	if o.bulk() {
+		return o.runBulkRename()
+	}
+
 	oldName := args[0]
 	newName := args[1]
 
 	// Validate new configuration name before making gcloud calls
 	if err := gcloud.ValidateConfigurationName(newName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
+	if gcloud.IsReservedName(oldName) {
+		fmt.Fprintf(os.Stderr, "Warning: %q is reserved by gcloud, which may recreate it automatically after the rename\n", oldName)
+	}
+
+	// Record the rename while it runs so concurrent completion skips the old name
+	plan, store := beginInflightPlan(inflight.Plan{
+		Operation: "rename",
+		Renames:   []inflight.Rename{{From: oldName, To: newName}},
+	})
+	defer endInflightPlan(plan, store)
+
 	// Rename the configuration (gcloud install check is done inside RunGcloudCommand)
 	if err := gcloud.RenameConfiguration(oldName, newName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
-	output.PrintSuccess(fmt.Sprintf("renamed configuration %q to %q", oldName, newName), !noColorFlag)
+	moveConfigurationState(oldName, newName)
+	output.PrintSuccess(fmt.Sprintf("renamed configuration %q to %q", oldName, newName), !o.noColor)
 	return nil
 }
+
+// renameRule parses whichever of --prefix, --suffix and --pattern is set
+func (o *renameOptions) renameRule() (*bulkrename.Rule, error) {
+	switch {
+	case o.prefix != "":
+		return bulkrename.ParsePrefix(o.prefix)
+	case o.suffix != "":
+		return bulkrename.ParseSuffix(o.suffix)
+	default:
+		return bulkrename.ParsePattern(o.pattern)
+	}
+}
+
+// runBulkRename renames every configuration the rule matches
+func (o *renameOptions) runBulkRename() error {
+	rule, err := o.renameRule()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	names := make([]string, len(configs))
+	for i := range configs {
+		names[i] = configs[i].Name
+	}
+
+	renames := bulkrename.Plan(names, rule)
+	if len(renames) == 0 {
+		fmt.Println("No configurations to rename")
+		return nil
+	}
+
+	// The mapping alone goes to stdout so a dry run can be saved for review
+	for _, rename := range renames {
+		fmt.Printf("%s -> %s\n", rename.From, rename.To)
+	}
+	if invalid := bulkrename.Invalid(renames); len(invalid) > 0 {
+		for _, rename := range invalid {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", rename.From, rename.Err)
+		}
+		err := fmt.Errorf("%d of %d configurations cannot be renamed; nothing was renamed", len(invalid), len(renames))
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	for _, rename := range renames {
+		if gcloud.IsReservedName(rename.From) {
+			fmt.Fprintf(os.Stderr, "Warning: %q is reserved by gcloud, which may recreate it automatically after the rename\n", rename.From)
+		}
+	}
+
+	if o.dryRun {
+		fmt.Fprintln(os.Stderr, "Dry run: nothing was renamed")
+		return nil
+	}
+
+	confirmed, err := prompt.Ask(os.Stdout, fmt.Sprintf("Rename these %d configuration(s)?", len(renames)), true, o.yes)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Rename canceled")
+		return nil
+	}
+
+	// Record the renames while they run so concurrent completion skips the old names
+	pending := inflight.Plan{Operation: "rename"}
+	for _, rename := range renames {
+		pending.Renames = append(pending.Renames, inflight.Rename{From: rename.From, To: rename.To})
+	}
+	plan, store := beginInflightPlan(pending)
+	defer endInflightPlan(plan, store)
+
+	failed := 0
+	for _, rename := range renames {
+		err := gcloud.RenameConfiguration(rename.From, rename.To)
+		renewInflightPlan(plan, store)
+		if err != nil {
+			failed++
+			output.PrintError(fmt.Sprintf("failed to rename configuration %q: %v", rename.From, err), !o.noColor)
+			continue
+		}
+		moveConfigurationState(rename.From, rename.To)
+		output.PrintSuccess(fmt.Sprintf("renamed configuration %q to %q", rename.From, rename.To), !o.noColor)
+	}
+
+	if failed > 0 {
+		err := fmt.Errorf("%d of %d configurations failed to rename", failed, len(renames))
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	output.PrintSuccess(fmt.Sprintf("renamed %d configuration(s)", len(renames)), !o.noColor)
+	return nil
+}
+
+// moveConfigurationState moves what gcloudctx keeps about a renamed configuration to its new name
+func moveConfigurationState(oldName, newName string) {
+	// Drop the old name's project history
+	if err := history.ClearPreviousProject(oldName); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to clear project history: %w", err))
+	}
+
+	// Keep the last-used time with the configuration
+	if err := history.RenameUsage(oldName, newName); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to move usage history: %w", err))
+	}
+
+	// Keep the note with the configuration
+	if err := notes.Rename(oldName, newName); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to move configuration note: %w", err))
+	}
+
+	// Keep the ADC hint with the configuration
+	if err := adc.RenameHint(oldName, newName); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to move ADC hint: %w", err))
+	}
+	if store, err := adc.DefaultSnapshotStore(); err == nil {
+		if err := store.Rename(oldName, newName); err != nil {
+			// Non-fatal error, just warn
+			statedir.Warn(os.Stderr, fmt.Errorf("failed to move ADC snapshot: %w", err))
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/inflight"
+)
+
+func TestRename(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("rename", "prod", "production")
+
+	if names := env.gcloud.Names(); !slices.Equal(names, []string{"dev", "production"}) {
+		t.Errorf("configurations = %v, want dev and production", names)
+	}
+	if properties, _ := env.gcloud.Properties("production"); properties["core/project"] != "prod-project" {
+		t.Errorf("production properties = %v, want those of prod", properties)
+	}
+	if env.gcloud.Active() != "dev" {
+		t.Errorf("active = %q after rename, want dev unchanged", env.gcloud.Active())
+	}
+	if !strings.Contains(res.stdout, `renamed configuration "prod" to "production"`) {
+		t.Errorf("stdout = %q, want the rename reported", res.stdout)
+	}
+}
+
+func TestRenameActive(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.mustRun("rename", "dev", "development")
+
+	if env.gcloud.Active() != "development" {
+		t.Errorf("active = %q, want the renamed configuration", env.gcloud.Active())
+	}
+	if slices.Contains(env.gcloud.Names(), "dev") {
+		t.Error("dev still exists")
+	}
+}
+
+func TestRenameToExisting(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "rename", "prod", "dev")
+
+	if res.err == nil || !strings.Contains(res.stdout, `configuration "dev" already exists`) {
+		t.Errorf("renaming onto an existing configuration = %v, stdout %q; want an error", res.err, res.stdout)
+	}
+	if names := env.gcloud.Names(); !slices.Equal(names, []string{"dev", "prod"}) {
+		t.Errorf("configurations = %v, want them unchanged", names)
+	}
+}
+
+func TestRenameInvalidName(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "rename", "prod", "Prod.EU")
+
+	if res.err == nil {
+		t.Fatal("renaming to an invalid name succeeded")
+	}
+	if calls := env.gcloud.Calls(); len(calls) != 0 {
+		t.Errorf("an invalid name ran gcloud: %q", calls)
+	}
+}
+
+func TestRenamePrefix(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("acme-staging", map[string]string{"core/project": "staging-project"})
+	env.gcloud.Add("acme-prod", map[string]string{"core/project": "acme-project"})
+
+	res := env.run("y\n", "rename", "--prefix", "acme-:newco-")
+
+	if res.err != nil {
+		t.Fatalf("rename --prefix: %v\nstdout: %s", res.err, res.stdout)
+	}
+	if names := env.gcloud.Names(); !slices.Equal(names, []string{"dev", "newco-prod", "newco-staging", "prod"}) {
+		t.Errorf("configurations = %v, want the acme- ones renamed", names)
+	}
+	if properties, _ := env.gcloud.Properties("newco-prod"); properties["core/project"] != "acme-project" {
+		t.Errorf("newco-prod properties = %v, want those of acme-prod", properties)
+	}
+	for _, want := range []string{"acme-prod -> newco-prod\nacme-staging -> newco-staging\n", "renamed 2 configuration(s)"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("stdout = %q, want %q", res.stdout, want)
+		}
+	}
+}
+
+func TestRenamePatternDryRun(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("rename", "--pattern", `s/^(.*)$/\1-old/`, "--dry-run")
+
+	if res.stdout != "dev -> dev-old\nprod -> prod-old\n" {
+		t.Errorf("stdout = %q, want only the mapping", res.stdout)
+	}
+	if calls := env.gcloud.Calls(); len(calls) != 1 || !strings.HasPrefix(calls[0], "config configurations list") {
+		t.Errorf("dry run ran gcloud: %q", calls)
+	}
+}
+
+func TestRenameBulkValidatesUpFront(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("prod-eu", nil)
+
+	res := env.run("", "rename", "--suffix", "-eu:", "--yes")
+
+	if res.err == nil || !strings.Contains(res.stderr, `configuration "prod" already exists`) {
+		t.Errorf("rename onto an existing name = %v, stderr %q; want it refused", res.err, res.stderr)
+	}
+	if names := env.gcloud.Names(); !slices.Equal(names, []string{"dev", "prod", "prod-eu"}) {
+		t.Errorf("configurations = %v, want them unchanged", names)
+	}
+}
+
+func TestRenameBulkContinuesPastFailures(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Fail("config configurations create dev-old", "ERROR: (gcloud.config.configurations.create) boom")
+
+	res := env.run("", "rename", "--suffix", ":-old", "--yes")
+
+	if res.err == nil || !strings.Contains(res.stdout, "1 of 2 configurations failed to rename") {
+		t.Errorf("rename = %v, stdout %q; want the failure summarized", res.err, res.stdout)
+	}
+	if names := env.gcloud.Names(); !slices.Equal(names, []string{"dev", "prod-old"}) {
+		t.Errorf("configurations = %v, want prod renamed and dev kept", names)
+	}
+}
+
+func TestRenameBulkArgs(t *testing.T) {
+	env := newTestEnv(t)
+
+	for _, args := range [][]string{
+		{"rename", "--prefix", "a:b", "prod", "x"},
+		{"rename", "--prefix", "a:b", "--suffix", "c:d"},
+		{"rename", "prod", "x", "--dry-run"},
+		{"rename", "--pattern", "s/a/b"},
+	} {
+		if res := env.run("", args...); res.err == nil {
+			t.Errorf("gcloudctx %s succeeded, want an error", strings.Join(args, " "))
+		}
+	}
+}
+
+func TestRenewInflightPlanExtendsLease(t *testing.T) {
+	newTestEnv(t)
+	store, err := inflight.DefaultStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := store.Begin(inflight.Plan{Operation: "rename", Renames: []inflight.Rename{{From: "prod", To: "production"}}}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renewInflightPlan(plan, store)
+
+	// Each step of a batch buys another full lease
+	if remaining := time.Until(plan.LeaseExpiresAt); remaining < inflight.DefaultLease-time.Minute {
+		t.Errorf("lease after renewal ends in %v, want about %v", remaining, inflight.DefaultLease)
+	}
+	plans, err := store.Active()
+	if err != nil || len(plans) != 1 || !plans[0].LeaseExpiresAt.Equal(plan.LeaseExpiresAt) {
+		t.Errorf("active plans = %+v, %v; want the renewed plan stored", plans, err)
+	}
+}
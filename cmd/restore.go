@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/internal/prompt"
+	"github.com/Okabe-Junya/gcloudctx/pkg/backup"
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// restoreOptions holds the flags of the restore command
+type restoreOptions struct {
+	*options
+	dryRun bool
+	yes    bool
+}
+
+func newRestoreCmd(parent *options) *cobra.Command {
+	o := &restoreOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Restore configurations and gcloudctx state from a backup",
+		Long: `Recreate everything in an archive written by 'gcloudctx backup'.
+
+Each configuration's properties file is restored exactly as it was archived,
+replacing the file of an existing configuration, so every property comes back,
+including those the export format has no field for. Configurations with
+invalid names are skipped. gcloudctx state files and the ADC snapshot (if
+archived) replace the current ones and are written readable only by you.
+Finally the configuration that was active at backup time is activated, unless
+it failed to restore.
+
+Examples:
+  gcloudctx restore gcloudctx-backup.tar.gz --dry-run  # Show what would change
+  gcloudctx restore gcloudctx-backup.tar.gz            # Restore after confirmation
+  gcloudctx restore gcloudctx-backup.tar.gz --yes      # Restore without asking`,
+		Args: cobra.ExactArgs(1),
+		RunE: o.runRestore,
+	}
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Only show what would change")
+	cmd.Flags().BoolVarP(&o.yes, "yes", "y", false, "Restore without confirmation")
+	return cmd
+}
+
+func (o *restoreOptions) runRestore(cmd *cobra.Command, args []string) error {
+	archivePath := args[0]
+
+	// The ADC file is restored directly, without a gcloud command to refuse
+	if err := gcloud.CheckWritable("restoring a backup"); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("failed to open backup: %v", err), !o.noColor)
+		return err
+	}
+	archive, err := backup.Read(f)
+	f.Close()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	existing := map[string]bool{}
+	for _, config := range configs {
+		existing[config.Name] = true
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		output.PrintError(fmt.Sprintf("failed to get home directory: %v", err), !o.noColor)
+		return err
+	}
+	adcPath, err := adcFilePath()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	changes := backup.PlanConfigurations(archive, func(name string) bool { return existing[name] })
+	o.printRestorePlan(archive, changes, home, adcPath)
+
+	if o.dryRun {
+		fmt.Println("\nDry run: nothing was changed")
+		return nil
+	}
+
+	confirmed, err := prompt.Ask(os.Stdout, "\nRestore these items?", true, o.yes)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Restore canceled")
+		return nil
+	}
+
+	// Configurations first, so state referring to them (history, hints) lands afterwards
+	restored := map[string]bool{}
+	failed := 0
+	for _, change := range changes {
+		if change.Action == backup.ActionSkip {
+			failed++
+			continue
+		}
+
+		if err := restoreConfiguration(change, archive.Properties[change.Config.Name]); err != nil {
+			failed++
+			output.PrintError(fmt.Sprintf("failed to restore configuration %q: %v", change.Config.Name, err), !o.noColor)
+			continue
+		}
+		restored[change.Config.Name] = true
+		output.PrintSuccess(fmt.Sprintf("restored configuration %q", change.Config.Name), !o.noColor)
+	}
+
+	for _, name := range archivedStateNames(archive) {
+		if err := backup.WritePrivateFile(filepath.Join(home, name), archive.State[name]); err != nil {
+			failed++
+			output.PrintError(err.Error(), !o.noColor)
+		}
+	}
+
+	if archive.ADC != nil {
+		if err := os.MkdirAll(filepath.Dir(adcPath), 0o700); err != nil {
+			failed++
+			output.PrintError(fmt.Sprintf("failed to create %s: %v", filepath.Dir(adcPath), err), !o.noColor)
+		} else if err := backup.WritePrivateFile(adcPath, archive.ADC); err != nil {
+			failed++
+			output.PrintError(err.Error(), !o.noColor)
+		}
+	}
+
+	// Only re-activate the archived configuration if it is really there now
+	if active := archive.Manifest.Active; active != "" {
+		if restored[active] {
+			if err := gcloud.ActivateConfiguration(active); err != nil {
+				failed++
+				output.PrintError(err.Error(), !o.noColor)
+			} else {
+				output.PrintSuccess(fmt.Sprintf("activated configuration %q", active), !o.noColor)
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: not activating %q because it was not restored\n", active)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d item(s) failed to restore", failed)
+	}
+	return nil
+}
+
+// restoreConfiguration creates or overwrites one configuration from an archive
+// The archived properties file replaces the configuration's file byte for
+// byte; version 1 archives only hold the export format, which is imported
+func restoreConfiguration(change backup.ConfigurationChange, properties []byte) error {
+	// Backups hold literals, but hand-edited archives may use valueFrom references
+	resolved, err := configfile.Resolve(&change.Config, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	job := &importJob{
+		path:     change.Config.Name,
+		name:     change.Config.Name,
+		resolved: resolved,
+		exists:   change.Action == backup.ActionOverwrite,
+	}
+	if properties == nil {
+		return importJobConfiguration(job)
+	}
+
+	if err := gcloud.WriteConfigurationFile(job.name, properties); err != nil {
+		return err
+	}
+	if job.exists {
+		clearOverwrittenProject(job.name)
+	}
+	saveImportHint(job)
+	return nil
+}
+
+// printRestorePlan lists what a restore would change
+func (o *options) printRestorePlan(archive *backup.Archive, changes []backup.ConfigurationChange, home, adcPath string) {
+	if o.noColor {
+		color.NoColor = true
+	}
+	gray := color.New(color.FgHiBlack).SprintFunc()
+
+	fmt.Printf("Backup from %s\n\n", archive.Manifest.CreatedAt.Local().Format("2006-01-02 15:04"))
+	for _, change := range changes {
+		line := fmt.Sprintf("%-10s configuration %s", change.Action, change.Config.Name)
+		if change.Reason != "" {
+			line += " " + gray("("+change.Reason+")")
+		}
+		fmt.Println(line)
+	}
+
+	for _, name := range archivedStateNames(archive) {
+		fmt.Printf("%-10s %s\n", "write", filepath.Join(home, name))
+	}
+	if archive.ADC != nil {
+		fmt.Printf("%-10s %s %s\n", "write", adcPath, gray("(Application Default Credentials)"))
+	}
+	if archive.Manifest.Active != "" {
+		fmt.Printf("%-10s configuration %s %s\n", "activate", archive.Manifest.Active, gray("(if restored)"))
+	}
+}
+
+// archivedStateNames returns the state file names listed in the manifest, in order
+func archivedStateNames(archive *backup.Archive) []string {
+	var names []string
+	for _, name := range archive.Manifest.State {
+		if _, ok := archive.State[name]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -4,14 +4,31 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/internal/prompt"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/cache"
 	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
 	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/hooks"
+	"github.com/Okabe-Junya/gcloudctx/pkg/inflight"
 	"github.com/Okabe-Junya/gcloudctx/pkg/interactive"
+	"github.com/Okabe-Junya/gcloudctx/pkg/local"
+	"github.com/Okabe-Junya/gcloudctx/pkg/procscan"
+	"github.com/Okabe-Junya/gcloudctx/pkg/settings"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statelock"
 	"github.com/spf13/cobra"
 )
 
@@ -22,217 +39,982 @@ var (
 	Commit = "none"
 	// Date is the build date, set during build via ldflags
 	Date = "unknown"
-
-	// Flags
-	listFlag         bool
-	currentFlag      bool
-	interactiveFlag  bool
-	syncADCFlag      bool
-	impersonateFlag  string
-	showInfoFlag     bool
-	noColorFlag      bool
-	outputFormatFlag string
 )
 
-var rootCmd = &cobra.Command{
-	Use:   "gcloudctx [configuration-name]",
-	Short: "Fast way to switch between gcloud configurations",
-	Long: `gcloudctx is a tool to quickly switch between gcloud configurations,
+// options holds the flags shared by the commands of one command tree, and the
+// settings loaded when it runs
+type options struct {
+	list         bool
+	current      bool
+	interactive  bool
+	syncADC      bool
+	impersonate  string
+	noBrowser    bool
+	filter       string
+	showInfo     bool
+	noColor      bool
+	outputFormat string
+	showDiff     bool
+	showHeader   bool
+	columns      string
+	showAll      bool
+	configRoot   string
+	noHooks      bool
+	noHistory    bool
+	forceSwitch  bool
+	yes          bool
+	verbose      bool
+	retries      int
+	offline      bool
+	widget       bool
+
+	// noBrowserSet is whether --no-browser was given, rather than detected
+	noBrowserSet bool
+
+	// disableNumberedPicker is the disable_numbered_picker setting
+	disableNumberedPicker bool
+	// hookSettings is the hooks section of the settings file
+	hookSettings hooks.Config
+	// protectedNames are the configurations that ask for confirmation before switching
+	protectedNames []string
+	// autoAllowProtected is the auto.allow_protected setting
+	autoAllowProtected bool
+	// gkeSettings is the gke section of the settings file
+	gkeSettings settings.GKESettings
+	// checkRunningGcloud is the switch.check_running_gcloud setting
+	checkRunningGcloud bool
+	// workspaces is the workspaces section of the settings file
+	workspaces map[string]settings.Workspace
+}
+
+// NewRootCommand returns the gcloudctx command with all its subcommands
+// Every call builds a new tree with its own flags, so a program embedding
+// gcloudctx can execute several commands without one's flags leaking into the next
+// A history jump such as "-2" must follow "--" when the args are set directly,
+// otherwise it is parsed as a shorthand flag
+func NewRootCommand() *cobra.Command {
+	o := &options{}
+	rootCmd := &cobra.Command{
+		Use:   "gcloudctx [configuration-name]",
+		Short: "Fast way to switch between gcloud configurations",
+		Long: `gcloudctx is a tool to quickly switch between gcloud configurations,
 inspired by kubectx/kubens.
 
 Examples:
-  gcloudctx                    # Show current configuration
+  gcloudctx                    # Pick a configuration (fzf, or numbered without it)
   gcloudctx my-config          # Switch to 'my-config'
   gcloudctx -                  # Switch to previous configuration
+  gcloudctx -2                 # Switch to the configuration before the previous one
+  gcloudctx .                  # Switch to the configuration of the nearest .gcloudctx file
   gcloudctx -l                 # List all configurations
+  gcloudctx -l --filter 'prod*'  # List the configurations whose name starts with prod
+  gcloudctx -l -o 'value(name,project)'  # Tab-separated fields, one configuration per line
+  gcloudctx -c -o value=project  # Print the project of the current configuration
+  gcloudctx -c --info --show-all  # Details including usage reporting
   gcloudctx -i                 # Interactive selection with fzf
-  gcloudctx my-config --sync-adc  # Switch and sync ADC`,
-	Version:               buildVersionString(),
-	RunE:                  runRoot,
-	Args:                  cobra.MaximumNArgs(1),
-	ValidArgsFunction:     completeConfigNames,
-	DisableFlagsInUseLine: false,
-}
-
-func init() {
-	rootCmd.Flags().BoolVarP(&listFlag, "list", "l", false, "List all configurations")
-	rootCmd.Flags().BoolVarP(&currentFlag, "current", "c", false, "Show current configuration")
-	rootCmd.Flags().BoolVarP(&interactiveFlag, "interactive", "i", false, "Interactive mode with fzf")
-	rootCmd.Flags().BoolVar(&syncADCFlag, "sync-adc", false, "Sync Application Default Credentials after switching")
-	rootCmd.Flags().StringVar(&impersonateFlag, "impersonate-service-account", "", "Service account to impersonate for ADC")
-	rootCmd.Flags().BoolVar(&showInfoFlag, "info", false, "Show detailed configuration information")
-	rootCmd.Flags().BoolVar(&noColorFlag, "no-color", false, "Disable colored output")
-	rootCmd.Flags().StringVarP(&outputFormatFlag, "output", "o", "", "Output format (json, yaml, wide, name)")
-}
-
-func runRoot(cmd *cobra.Command, args []string) error {
+  gcloudctx my-config --sync-adc  # Switch and sync ADC
+  gcloudctx my-config --show-diff # Switch and show changed properties`,
+		Version:               currentVersionInfo().String(),
+		PersistentPreRunE:     o.applyPersistentFlags,
+		RunE:                  o.runRoot,
+		Args:                  cobra.MaximumNArgs(1),
+		ValidArgsFunction:     completeConfigNames,
+		DisableFlagsInUseLine: false,
+	}
+
+	rootCmd.Flags().BoolVarP(&o.list, "list", "l", false, "List all configurations")
+	rootCmd.Flags().BoolVarP(&o.current, "current", "c", false, "Show current configuration")
+	rootCmd.Flags().StringVar(&o.filter, "filter", "", "List only the configurations whose name matches this glob (with -l)")
+	rootCmd.Flags().BoolVarP(&o.interactive, "interactive", "i", false, "Interactive mode with fzf")
+	rootCmd.Flags().BoolVar(&o.syncADC, "sync-adc", false, "Sync Application Default Credentials after switching")
+	rootCmd.Flags().StringVar(&o.impersonate, "impersonate-service-account", "", "Service account to impersonate for ADC")
+	rootCmd.Flags().BoolVar(&o.noBrowser, "no-browser", false, noBrowserUsage)
+	rootCmd.Flags().BoolVar(&o.showInfo, "info", false, "Show detailed configuration information")
+	rootCmd.Flags().BoolVar(&o.showAll, "show-all", false, "Include rarely needed properties such as usage reporting in --info and -o wide")
+	rootCmd.Flags().BoolVar(&o.noColor, "no-color", false, "Disable colored output")
+	rootCmd.Flags().StringVarP(&o.outputFormat, "output", "o", "", "Output format (json, yaml, wide, name, csv, tsv, value(FIELD,...))")
+	rootCmd.Flags().StringVar(&o.columns, "columns", "", "Comma-separated columns for -o wide, csv or tsv ("+strings.Join(output.ColumnNames(), ", ")+")")
+	rootCmd.Flags().BoolVar(&o.showDiff, "show-diff", false, "Show the properties that differ after switching")
+	rootCmd.Flags().BoolVar(&o.showHeader, "show-header", false, "Print the list as a table with a header row")
+	rootCmd.Flags().BoolVar(&o.noHooks, "no-hooks", false, "Do not run post-switch hooks or the GKE integration")
+	rootCmd.Flags().BoolVar(&o.noHistory, "no-history", false, "Do not record the switch in the history or usage statistics")
+	rootCmd.Flags().BoolVar(&o.forceSwitch, "force", false, "Switch even when a pre-switch guard refuses or gcloud commands are running")
+	rootCmd.Flags().BoolVarP(&o.yes, "yes", "y", false, "Switch to a protected configuration without confirmation")
+	rootCmd.Flags().BoolVar(&o.widget, "widget", false, "Pick with fzf for a shell key binding, printing only the result of the switch")
+	_ = rootCmd.Flags().MarkHidden("widget")
+	rootCmd.MarkFlagsMutuallyExclusive("widget", "list")
+	rootCmd.MarkFlagsMutuallyExclusive("widget", "current")
+	rootCmd.MarkFlagsMutuallyExclusive("widget", "output")
+	rootCmd.PersistentFlags().StringVar(&o.configRoot, "config-root", "", "Use this gcloud configuration directory instead of $CLOUDSDK_CONFIG or the default")
+	_ = rootCmd.MarkPersistentFlagDirname("config-root")
+	rootCmd.PersistentFlags().BoolVar(&o.verbose, "verbose", false, "Include gcloud's full output and path when a gcloud command fails")
+	rootCmd.PersistentFlags().IntVar(&o.retries, "retries", gcloud.DefaultRetries, "Run read-only gcloud commands again this many times after a network or service failure")
+	rootCmd.PersistentFlags().BoolVar(&o.offline, "offline", false, "Read gcloud's files directly and refuse anything that needs the network (as $"+gcloud.EnvOffline+"=1 does)")
+
+	_ = rootCmd.RegisterFlagCompletionFunc("columns", completeColumns)
+
+	rootCmd.AddCommand(
+		newAccountsCmd(o),
+		newADCCmd(o),
+		newApplyCmd(o),
+		newAutoCmd(o),
+		newBackupCmd(o),
+		newCheckCmd(o),
+		newCloneCmd(o),
+		newCompleteDataCmd(o),
+		newCompletionCmd(o),
+		newCopyPropertyCmd(o),
+		newCreateCmd(o),
+		newDeleteCmd(o),
+		newDescribeCmd(o),
+		newDiffFileCmd(o),
+		newDoctorCmd(o),
+		newEditCmd(o),
+		newEnvCmd(o),
+		newExportCmd(o),
+		newHistoryCmd(o),
+		newImportCmd(o),
+		newJumpCmd(o),
+		newLogCmd(o),
+		newMigrateScanCmd(o),
+		newNoteCmd(o),
+		newPathsCmd(o),
+		newPickCmd(o),
+		newPickerDeleteCmd(o),
+		newPickerListCmd(o),
+		newPinTerminalCmd(o),
+		newPreviewCmd(o),
+		newProjectCmd(o),
+		newProjectPreviewCmd(o),
+		newPromptCmd(o),
+		newProtectCmd(o),
+		newUnprotectCmd(o),
+		newRenameCmd(o),
+		newRestoreCmd(o),
+		newStatsCmd(o),
+		newStatusCmd(o),
+		newTemplatesCmd(o),
+		newUninstallStateCmd(o),
+		newUseCmd(o),
+		newVersionCmd(o),
+		newWatchCmd(o),
+		newWhichCmd(o),
+		newWidgetCmd(o),
+		newWorkspaceCmd(o),
+	)
+	trackChanges(rootCmd)
+	return rootCmd
+}
+
+// applyPersistentFlags applies the settings file and the flags every command accepts
+func (o *options) applyPersistentFlags(cmd *cobra.Command, args []string) error {
+	o.applySettings()
+	// An earlier command in the same process may have sent banners to stderr
+	output.SetMessageOutput(os.Stdout)
+	gcloud.SetVerboseErrors(o.verbose)
+	statedir.SetVerbose(o.verbose)
+	gcloud.SetRetries(o.retries)
+	gcloud.SetOffline(o.offline)
+	o.noBrowserSet = cmd.Flags().Changed("no-browser")
+	if err := o.applyConfigRoot(cmd, args); err != nil {
+		return err
+	}
+	reconcileActiveConfiguration()
+	return nil
+}
+
+// applyConfigRoot points every command at the directory given with --config-root
+// Both the files gcloudctx reads and the gcloud commands it runs use that tree
+func (o *options) applyConfigRoot(cmd *cobra.Command, args []string) error {
+	if o.configRoot == "" {
+		return nil
+	}
+
+	dir, err := filepath.Abs(o.configRoot)
+	if err != nil {
+		return fmt.Errorf("invalid --config-root: %w", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		err := fmt.Errorf("--config-root %s is not a directory", o.configRoot)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	gcloud.SetConfigRoot(dir)
+	// gcloudctx processes started by fzf (preview, key bindings) do not get the
+	// flag and follow the same tree through the environment instead
+	if err := os.Setenv(gcloud.EnvConfigDir, dir); err != nil {
+		return fmt.Errorf("failed to set %s: %w", gcloud.EnvConfigDir, err)
+	}
+	return nil
+}
+
+// completeColumns completes the next name of a comma-separated --columns value
+func completeColumns(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix = toComplete[:i+1]
+	}
+
+	var completions []string
+	for _, name := range output.ColumnNames() {
+		completions = append(completions, prefix+name)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// applySettings loads the user's settings file and applies it, together with
+// the terminal pin, to the output package
+func (o *options) applySettings() {
+	userSettings, err := settings.Load()
+	if err != nil {
+		// Non-fatal error, just warn and continue with defaults
+		statedir.Warn(os.Stderr, err)
+	}
+	o.disableNumberedPicker = userSettings.DisableNumberedPicker
+	interactive.SetBindingsDisabled(userSettings.Fzf.DisableBindings)
+	interactive.SetTmuxPopupSize(userSettings.Fzf.TmuxPopup)
+	o.hookSettings = userSettings.Hooks
+	o.protectedNames = userSettings.Protected
+	o.autoAllowProtected = userSettings.Auto.AllowProtected
+	o.gkeSettings = userSettings.GKE
+	o.checkRunningGcloud = userSettings.Switch.CheckRunningGcloud
+	o.workspaces = userSettings.Workspaces
+	history.SetEnabled(userSettings.History.IsEnabled())
+	output.SetProtectedConfigurations(userSettings.Protected)
+	output.SetDisplayStyles(displayStyles(userSettings.Display))
+	gcloud.SetReadOnly(userSettings.ReadOnly)
+	output.SetAccessible(userSettings.Accessible)
+	output.SetLineFormats(userSettings.ListTemplate(), userSettings.PickerTemplate(), userSettings.ActiveMarker)
+	output.SetListOptions(output.ListOptions{
+		ShowHeader:        userSettings.ShowHeader || o.showHeader,
+		HighlightActive:   userSettings.HighlightActive,
+		DimMissingProject: userSettings.DimMissingProject,
+	})
+
+	pinned := ""
+	if pin := terminalPin(); pin != nil {
+		pinned = pin.Config
+	}
+	output.SetPinnedConfiguration(pinned)
+}
+
+// displayStyles converts the display settings for the output package
+func displayStyles(display map[string]settings.Display) map[string]output.DisplayStyle {
+	styles := make(map[string]output.DisplayStyle, len(display))
+	for name, d := range display {
+		styles[name] = output.DisplayStyle{Color: d.Color, Emoji: d.Emoji}
+	}
+	return styles
+}
+
+func (o *options) runRoot(cmd *cobra.Command, args []string) error {
 	// Check if gcloud is installed
 	if err := gcloud.CheckGcloudInstalled(); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
+	// Handle the picker of the shell widget ('gcloudctx widget')
+	if o.widget {
+		return o.widgetSelection(args)
+	}
+
 	// Handle list flag
-	if listFlag {
-		return listConfigurations()
+	if o.list {
+		return o.listConfigurations()
 	}
 
 	// Handle current flag
-	if currentFlag {
-		return showCurrentConfiguration()
+	if o.current {
+		return o.showCurrentConfiguration()
 	}
 
 	// Handle interactive flag
-	if interactiveFlag {
-		return interactiveSelection()
+	if o.interactive {
+		return o.interactiveSelection()
 	}
 
-	// If no arguments, try interactive mode (if fzf is available), otherwise show current configuration
+	// If no arguments, pick interactively (with fzf, or the numbered picker on a
+	// terminal), otherwise show current configuration
 	if len(args) == 0 {
-		// Check if we should skip fzf (via environment variable or explicit flag)
-		if os.Getenv(interactive.EnvIgnoreFzf) != "1" && interactive.IsFzfInstalled() {
-			return interactiveSelection()
+		action := interactive.ChooseBareAction(interactive.BareOptions{
+			IgnoreFzf:             os.Getenv(interactive.EnvIgnoreFzf) == "1",
+			DisableNumberedPicker: o.disableNumberedPicker,
+			FzfInstalled:          interactive.IsFzfInstalled,
+			IsTerminal:            interactive.IsTerminal,
+		})
+		switch action {
+		case interactive.PickWithFzf:
+			return o.interactiveSelection()
+		case interactive.PickNumbered:
+			return o.numberedSelection()
+		default:
+			return o.showCurrentConfiguration()
 		}
-		return showCurrentConfiguration()
 	}
 
 	// Switch to specified configuration
 	targetConfig := args[0]
 
-	// Handle '-' to switch to previous configuration
+	// Handle '-' and '-N' to switch to a previous configuration
 	if targetConfig == "-" {
-		return switchToPrevious()
+		return o.switchToPrevious(1)
+	}
+	if targetConfig == localConfigToken {
+		return o.switchToLocalConfiguration()
+	}
+	if n, ok := parseHistoryJump(targetConfig); ok {
+		return o.switchToPrevious(n)
 	}
 
 	// Switch to the target configuration
-	return switchConfiguration(targetConfig)
+	return o.switchConfiguration(targetConfig)
 }
 
-func listConfigurations() error {
+func (o *options) listConfigurations() error {
+	stop := output.StartSpinner(querySpinnerMessage)
 	configs, err := gcloud.ListConfigurations()
+	stop()
 	if err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
+	pruneNotes(configs)
 	if len(configs) == 0 {
-		fmt.Println("No configurations found")
+		fmt.Printf("No configurations found; %s\n", bootstrapHint)
 		return nil
 	}
 
 	// Validate and use output format
-	format, err := output.ValidateOutputFormat(outputFormatFlag)
+	format, err := output.ValidateOutputFormat(o.outputFormat)
 	if err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
-	return output.PrintConfigurationsWithFormat(configs, format, !noColorFlag)
+	if o.filter != "" {
+		if configs, err = gcloud.MatchConfigurations(configs, o.filter); err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		if len(configs) == 0 && !output.IsMachineFormat(format) {
+			fmt.Printf("No configurations match %q\n", o.filter)
+			return nil
+		}
+	}
+
+	columns, err := o.listColumns(format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	output.SetPreviousConfiguration(previousConfigName(configs))
+
+	return output.PrintConfigurationsWithFormat(configs, format, columns, !o.noColor)
 }
 
-func showCurrentConfiguration() error {
-	config, err := gcloud.GetActiveConfiguration()
+// listColumns parses --columns, or the fields of -o value(...), for a list
+// printed in format, loading the last-used times and notes the columns may show
+func (o *options) listColumns(format output.Format) ([]output.Column, error) {
+	if o.columns != "" && !output.SupportsColumns(format) {
+		return nil, fmt.Errorf("--columns requires -o wide, csv or tsv")
+	}
+	if format == output.FormatValue {
+		columns, _, err := output.ParseValueFormat(o.outputFormat)
+		if err != nil {
+			return nil, err
+		}
+		loadLastUsed()
+		loadNotes()
+		return columns, nil
+	}
+	columns, err := output.ParseColumns(o.columns)
 	if err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		return nil, err
+	}
+	if o.columns == "" && o.showAll {
+		columns = output.AllColumns()
+	}
+	if output.SupportsColumns(format) {
+		loadLastUsed()
+		loadNotes()
+	}
+	return columns, nil
+}
+
+// showCurrentConfiguration prints the active configuration in the -o format
+func (o *options) showCurrentConfiguration() error {
+	format, err := output.ValidateOutputFormat(o.outputFormat)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	columns, err := o.listColumns(format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
-	if showInfoFlag {
-		output.PrintConfigurationDetails(config, !noColorFlag)
-	} else {
-		output.PrintCurrentConfiguration(config, !noColorFlag)
+	config, err := gcloud.GetActiveConfigurationFast()
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
 	}
 
-	return nil
+	if format == output.FormatDefault && o.showInfo {
+		loadLastUsed()
+		loadNotes()
+		output.SetShowAll(o.showAll)
+		output.PrintConfigurationDetails(config, !o.noColor)
+		return nil
+	}
+	return output.PrintCurrentConfigurationWithFormat(config, format, columns, !o.noColor)
 }
 
-func interactiveSelection() error {
+func (o *options) interactiveSelection() error {
 	if !interactive.IsFzfInstalled() {
-		output.PrintError("fzf is not installed. Please install fzf for interactive mode.", !noColorFlag)
+		output.PrintError("fzf is not installed. Please install fzf for interactive mode.", !o.noColor)
 		return interactive.ErrFzfNotInstalled
 	}
 
 	configs, err := gcloud.ListConfigurations()
 	if err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
+	if len(configs) == 0 {
+		if o.widget {
+			output.PrintError(interactive.ErrNoConfigurations.Error(), !o.noColor)
+			return interactive.ErrNoConfigurations
+		}
+		return o.offerBootstrap()
+	}
 
-	currentConfig, err := gcloud.GetActiveConfiguration()
+	currentConfig, err := gcloud.GetActiveConfigurationFast()
 	if err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
+	output.SetPreviousConfiguration(previousConfigName(configs))
 	selected, err := interactive.SelectConfigurationInteractive(configs, currentConfig.Name)
 	if err != nil {
 		if errors.Is(err, interactive.ErrSelectionCanceled) {
 			return nil
 		}
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
-	return switchConfiguration(selected)
+	return o.switchConfiguration(selected)
+}
+
+// widgetSelection is interactiveSelection for the shell widget, which shows
+// what gcloudctx prints under the prompt: nothing when the picker is canceled,
+// and only the result line when it switches
+func (o *options) widgetSelection(args []string) error {
+	if len(args) > 0 {
+		err := errors.New("--widget does not take a configuration name")
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	return o.interactiveSelection()
 }
 
-func switchToPrevious() error {
-	previousName, err := history.GetPreviousConfig()
+// numberedSelection lets the user pick a configuration by number when fzf is not installed
+func (o *options) numberedSelection() error {
+	configs, err := gcloud.ListConfigurations()
 	if err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
+	if len(configs) == 0 {
+		return o.offerBootstrap()
+	}
 
-	return switchConfiguration(previousName)
+	currentConfig, err := gcloud.GetActiveConfiguration()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	output.SetPreviousConfiguration(previousConfigName(configs))
+	selected, err := interactive.SelectConfigurationNumbered(os.Stdin, os.Stderr, configs, currentConfig.Name)
+	if err != nil {
+		if errors.Is(err, interactive.ErrSelectionCanceled) {
+			return nil
+		}
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	return o.switchConfiguration(selected)
 }
 
-func switchConfiguration(targetName string) error {
-	// Get current configuration before switching
-	currentConfig, err := gcloud.GetActiveConfiguration()
+// switchToPrevious switches to the n-th previous configuration that still exists
+// Entries for configurations deleted or renamed since they were recorded are reported and removed
+func (o *options) switchToPrevious(n int) error {
+	entries, err := history.GetHistory()
+	// An unavailable history is an empty one
+	if err != nil && !errors.Is(err, history.ErrHistoryDisabled) && statedir.Unavailable(err) {
+		statedir.Warn(os.Stderr, err)
+		entries, err = nil, nil
+	}
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	currentName := ""
+	for _, config := range configs {
+		if config.IsActive {
+			currentName = config.Name
+		}
+	}
+	exists := func(name string) bool {
+		for _, config := range configs {
+			if config.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	previousName, stale, err := history.ResolvePrevious(entries, n, currentName, exists)
+	for _, name := range stale {
+		fmt.Fprintf(os.Stderr, "Warning: previous configuration %q no longer exists\n", name)
+	}
+	if len(stale) > 0 {
+		if err := history.RemoveFromHistory(stale...); err != nil {
+			// Non-fatal error, just warn
+			statedir.Warn(os.Stderr, fmt.Errorf("failed to update history: %w", err))
+		}
+	}
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	return o.switchConfiguration(previousName)
+}
+
+// localConfigToken names the configuration of the nearest .gcloudctx file
+// Configuration names start with a letter, so it never shadows one
+const localConfigToken = "."
+
+// switchToLocalConfiguration switches to the configuration named by the nearest .gcloudctx file
+func (o *options) switchToLocalConfiguration() error {
+	configName, dir, err := local.FindLocalConfig()
 	if err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
-	// Check if target configuration exists
-	if !gcloud.ConfigurationExists(targetName) {
-		output.PrintError(fmt.Sprintf("configuration %q not found", targetName), !noColorFlag)
+	exists, err := gcloud.ConfigurationExists(configName)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if !exists {
+		output.PrintError(fmt.Sprintf("configuration %q (from %s/.gcloudctx) does not exist", configName, dir), !o.noColor)
 		return fmt.Errorf("configuration not found")
 	}
 
-	// Check if already on target configuration
+	return o.switchConfiguration(configName)
+}
+
+// parseHistoryJump parses a "-N" history jump argument (N >= 1)
+func parseHistoryJump(arg string) (int, bool) {
+	digits, ok := strings.CutPrefix(arg, "-")
+	if !ok || digits == "" {
+		return 0, false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// normalizeHistoryJumpArgs moves a "-N" history jump behind "--" so it is parsed
+// as a positional argument instead of a shorthand flag
+// Arguments for subcommands are returned unchanged
+func normalizeHistoryJumpArgs(root *cobra.Command, args []string) []string {
+	jump := -1
+	for i, arg := range args {
+		if arg == "--" || arg == cobra.ShellCompRequestCmd || arg == cobra.ShellCompNoDescRequestCmd {
+			return args
+		}
+		if _, ok := parseHistoryJump(arg); ok && jump < 0 {
+			jump = i
+			continue
+		}
+		for _, sub := range root.Commands() {
+			if sub.Name() == arg || sub.HasAlias(arg) {
+				return args
+			}
+		}
+	}
+
+	if jump < 0 {
+		return args
+	}
+
+	normalized := make([]string, 0, len(args)+1)
+	normalized = append(normalized, args[:jump]...)
+	normalized = append(normalized, args[jump+1:]...)
+	return append(normalized, "--", args[jump])
+}
+
+// switchOutputFormat validates -o for commands that switch configurations
+// With a machine format, banners are sent to stderr so that stdout only
+// carries the switch result
+func (o *options) switchOutputFormat() (output.Format, bool, error) {
+	format, err := output.ValidateOutputFormat(o.outputFormat)
+	if err != nil {
+		return "", false, err
+	}
+	machineOutput := output.IsMachineFormat(format)
+	if machineOutput {
+		output.SetMessageOutput(os.Stderr)
+	}
+	return format, machineOutput, nil
+}
+
+// querySpinnerMessage is shown while waiting for gcloud to list configurations
+const querySpinnerMessage = "Querying gcloud..."
+
+// lockState takes the state lock, waiting up to statelock.DefaultWait for a
+// switch running in another terminal
+// Without a usable state directory there is no state to protect, so the
+// switch goes ahead unlocked and the returned lock is nil
+func lockState() (*statelock.Lock, error) {
+	path, err := statelock.GetLockFilePath()
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), statelock.DefaultWait)
+		defer cancel()
+		var lock *statelock.Lock
+		if lock, err = statelock.Acquire(ctx, path); err == nil {
+			return lock, nil
+		}
+	}
+	if statedir.Unavailable(err) {
+		statedir.Warn(os.Stderr, err)
+		return nil, nil
+	}
+	return nil, err
+}
+
+// recordSwitch records a switch in the history and usage statistics, unless
+// --no-history is set
+func (o *options) recordSwitch(from, to string) {
+	if o.noHistory {
+		return
+	}
+	if err := history.RecordSwitch(from, to); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to save history: %w", err))
+	}
+	recordUsage(to)
+}
+
+// recordUsage remembers that configName was switched to now
+func recordUsage(configName string) {
+	if err := history.RecordUsage(configName, time.Now()); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to record usage: %w", err))
+	}
+}
+
+// loadLastUsed passes when each configuration was last used to the output package
+func loadLastUsed() {
+	usage, err := history.LastUsed()
+	if err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, err)
+		return
+	}
+	output.SetLastUsed(usage)
+}
+
+func (o *options) switchConfiguration(targetName string) error {
+	// Validate the output format up front; json/yaml print a switch result on stdout
+	format, machineOutput, err := o.switchOutputFormat()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	// Human-readable progress goes to stderr when stdout carries the result,
+	// and nowhere for the shell widget, which shows a single line
+	var messages io.Writer = os.Stdout
+	if machineOutput {
+		messages = os.Stderr
+	}
+	if o.widget {
+		messages = io.Discard
+	}
+
+	// Refuse names an in-flight batch operation is about to delete or rename
+	if err := pendingOperations().CheckName(targetName); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	// Ask before taking the lock so a pending prompt does not block other terminals
+	if confirmed, err := o.confirmProtectedSwitch(targetName); err != nil || !confirmed {
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+		}
+		return err
+	}
+
+	if confirmed, err := o.confirmSwitchWhileGcloudRuns(targetName); err != nil || !confirmed {
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+		}
+		return err
+	}
+
+	// Serialize with switches in other terminals; everything from reading the
+	// active configuration to writing history happens under the lock
+	lock, err := lockState()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	defer lock.Unlock()
+
+	// Resolve the current and target configurations from a single list call
+	stop := output.StartSpinner(querySpinnerMessage)
+	currentConfig, targetConfig, err := gcloud.ResolveSwitch(targetName)
+	stop()
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	// Already on the target: nothing to do. History is left untouched on purpose;
+	// recording the active name would only make a following '-' a no-op as well
 	if currentConfig.Name == targetName {
-		output.PrintSuccess(fmt.Sprintf("already on configuration %q", targetName), !noColorFlag)
+		output.PrintSuccess(fmt.Sprintf("already on configuration %q", targetName), !o.noColor)
+		if machineOutput {
+			return output.PrintSwitchResult(&output.SwitchResult{Previous: targetName, Current: targetName}, format)
+		}
 		return nil
 	}
 
-	// Save current configuration to history
-	if err := history.SavePreviousConfig(currentConfig.Name); err != nil {
-		// Non-fatal error, just warn
-		fmt.Fprintf(os.Stderr, "Warning: failed to save history: %v\n", err)
+	if targetConfig == nil {
+		output.PrintError(fmt.Sprintf("configuration %q not found", targetName), !o.noColor)
+		return fmt.Errorf("configuration not found")
+	}
+
+	// Guards run before anything changes, so a refused switch records nothing
+	if err := o.runPreSwitchGuards(currentConfig.Name, targetConfig, messages); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
 	}
 
 	// Activate the target configuration
-	if err := gcloud.ActivateConfiguration(targetName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+	stop = output.StartSpinner(fmt.Sprintf("Switching to %s...", targetName))
+	err = gcloud.ActivateConfiguration(targetName)
+	stop()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
-	output.PrintSuccess(fmt.Sprintf("switched to configuration %q", targetName), !noColorFlag)
+	// Hooks run before history is written so a rolled back switch leaves no trace
+	if err := o.runPostSwitchHooks(currentConfig.Name, targetConfig, messages); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	// Save the previous configuration to history
+	o.recordSwitch(currentConfig.Name, targetName)
+	// Release early; syncing ADC below may wait on a browser for minutes
+	_ = lock.Unlock()
+
+	diffs := gcloud.DiffConfigurations(currentConfig, targetConfig)
+
+	output.PrintSuccess("switched to configuration "+output.StyledName(targetName), !o.noColor)
+	if !machineOutput && !o.widget {
+		// The diff is part of the machine-readable result
+		output.PrintDiff(diffs, o.showDiff, !o.noColor)
+	}
 
 	// Sync ADC if requested
-	if syncADCFlag {
-		fmt.Println("Syncing Application Default Credentials...")
-		if err := gcloud.SyncADC(impersonateFlag); err != nil {
-			output.PrintError(fmt.Sprintf("failed to sync ADC: %v", err), !noColorFlag)
+	if o.syncADC {
+		fmt.Fprintln(messages, "Syncing Application Default Credentials...")
+		if err := gcloud.SyncADC(o.adcImpersonation(targetName, machineOutput), o.adcNoBrowser(messages)); err != nil {
+			output.PrintError(fmt.Sprintf("failed to sync ADC: %v", err), !o.noColor)
 			return err
 		}
-		output.PrintSuccess("ADC synced successfully", !noColorFlag)
+		output.PrintSuccess("ADC synced successfully", !o.noColor)
+	} else if hint, err := adc.GetHint(targetName); err == nil && hint.SyncADC {
+		fmt.Fprintf(messages, "Tip: configuration %q was imported with sync_adc; run with --sync-adc to sync ADC\n", targetName)
+	}
+
+	o.runGKEIntegration(targetConfig, messages)
+
+	if machineOutput {
+		return output.PrintSwitchResult(&output.SwitchResult{
+			Previous:  currentConfig.Name,
+			Current:   targetName,
+			Changed:   true,
+			ADCSynced: o.syncADC,
+			Diff:      diffs,
+		}, format)
 	}
 
 	return nil
 }
 
+// isProtectedConfiguration reports whether the named configuration is protected
+func (o *options) isProtectedConfiguration(name string) bool {
+	return slices.Contains(o.protectedNames, name)
+}
+
+// confirmProtectedSwitch asks on the terminal whether to switch to a protected
+// configuration; --yes, unprotected targets and the active configuration need
+// no confirmation
+// When stdin is piped the controlling terminal is asked; without one, switching
+// to a protected configuration fails
+func (o *options) confirmProtectedSwitch(targetName string) (bool, error) {
+	if o.yes || !o.isProtectedConfiguration(targetName) {
+		return true, nil
+	}
+	if active, err := gcloud.ActiveConfigName(); err == nil && active == targetName {
+		return true, nil
+	}
+
+	confirmed, err := prompt.AskTerminal(os.Stderr, fmt.Sprintf("Switch to PROTECTED configuration '%s'?", targetName), true)
+	if errors.Is(err, prompt.ErrNoTerminal) {
+		return false, fmt.Errorf("configuration %q is protected; pass --yes to switch to it without confirmation", targetName)
+	}
+	if err != nil {
+		return false, err
+	}
+	if !confirmed {
+		fmt.Fprintln(os.Stderr, "Switch canceled")
+	}
+	return confirmed, nil
+}
+
+// processLister lists the processes switch.check_running_gcloud looks through
+// Tests replace it to stand in for running gcloud commands
+var processLister = procscan.System()
+
+// confirmSwitchWhileGcloudRuns asks on the terminal whether to switch while
+// gcloud, gsutil or bq processes are running, when switch.check_running_gcloud
+// is set; they may read the configuration again and act on the wrong one
+// --force switches anyway after listing them; when stdin is piped the
+// controlling terminal is asked, and without one the switch fails
+func (o *options) confirmSwitchWhileGcloudRuns(targetName string) (bool, error) {
+	if !o.checkRunningGcloud {
+		return true, nil
+	}
+	if active, err := gcloud.ActiveConfigName(); err == nil && active == targetName {
+		return true, nil
+	}
+
+	processes, err := procscan.Find(processLister)
+	if err != nil {
+		// Non-fatal error, just warn
+		fmt.Fprintf(os.Stderr, "Warning: could not check for running gcloud commands: %v\n", err)
+		return true, nil
+	}
+	if len(processes) == 0 {
+		return true, nil
+	}
+
+	fmt.Fprintln(os.Stderr, "Warning: these commands may read the configuration while it changes:")
+	for _, process := range processes {
+		fmt.Fprintf(os.Stderr, "  %d  %s\n", process.PID, process.Command)
+	}
+	if o.forceSwitch {
+		fmt.Fprintln(os.Stderr, "Switching anyway (--force)")
+		return true, nil
+	}
+	confirmed, err := prompt.AskTerminal(os.Stderr, fmt.Sprintf("Switch to %q anyway?", targetName), true)
+	if errors.Is(err, prompt.ErrNoTerminal) {
+		return false, errors.New("gcloud commands are running; pass --force to switch anyway")
+	}
+	if err != nil {
+		return false, err
+	}
+	if !confirmed {
+		fmt.Fprintln(os.Stderr, "Switch canceled")
+	}
+	return confirmed, nil
+}
+
+// runPreSwitchGuards runs the pre-switch guards for a switch from previous to target
+// A veto is returned as an error, or only reported with --force or guards_warn_only
+func (o *options) runPreSwitchGuards(previous string, target *gcloud.Configuration, messages io.Writer) error {
+	commands := o.hookSettings.PreSwitch(target.Name)
+	if len(commands) == 0 {
+		return nil
+	}
+
+	event := hooks.Event{Old: previous, New: target.Name, Project: target.Properties.Core.Project}
+	err := hooks.Guard(commands, event, o.hookSettings.Timeout(), messages)
+	if err == nil {
+		return nil
+	}
+	if o.forceSwitch || o.hookSettings.GuardsWarnOnly {
+		// Non-fatal error, just warn
+		fmt.Fprintf(os.Stderr, "Warning: %v (switching anyway)\n", err)
+		return nil
+	}
+	return fmt.Errorf("%w (use --force to switch anyway)", err)
+}
+
+// runPostSwitchHooks runs the post-switch hooks for a switch from previous to target
+// Failures are only reported, unless fail_on_hook_error is set: then previous is
+// activated again and the error returned
+func (o *options) runPostSwitchHooks(previous string, target *gcloud.Configuration, messages io.Writer) error {
+	if o.noHooks {
+		return nil
+	}
+	commands := o.hookSettings.PostSwitch(target.Name)
+	if len(commands) == 0 {
+		return nil
+	}
+
+	event := hooks.Event{Old: previous, New: target.Name, Project: target.Properties.Core.Project}
+	err := hooks.RunAll(commands, event, messages, os.Stderr)
+	if err == nil {
+		return nil
+	}
+	if !o.hookSettings.FailOnHookError {
+		// Non-fatal error, just warn
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return nil
+	}
+
+	if rollbackErr := gcloud.ActivateConfiguration(previous); rollbackErr != nil {
+		return fmt.Errorf("%w (switching back to %q also failed: %v)", err, previous, rollbackErr)
+	}
+	return fmt.Errorf("%w; switched back to %q", err, previous)
+}
+
 // completeConfigNames provides completion for configuration names
 func completeConfigNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	if len(args) > 0 {
@@ -240,34 +1022,205 @@ func completeConfigNames(cmd *cobra.Command, args []string, toComplete string) (
 	}
 
 	// Try to get configuration names
-	configs, err := gcloud.ListConfigurations()
+	configs, err := completionConfigurations()
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
 	var names []string
+	for i := range configs {
+		names = append(names, output.CompletionEntry(&configs[i]))
+	}
+	completions := pendingOperations().FilterCompletions(names)
+
+	// Shorthands come first so they are easy to spot in the menu
+	var shorthands []string
+	if previous := previousConfigName(configs); previous != "" {
+		shorthands = append(shorthands, "-\tprevious: "+previous)
+	}
+	if configName, _, err := local.FindLocalConfig(); err == nil {
+		shorthands = append(shorthands, localConfigToken+"\tlocal: "+configName)
+	}
+
+	return append(shorthands, completions...), cobra.ShellCompDirectiveNoFileComp
+}
+
+// previousConfigName returns the configuration 'gcloudctx -' would switch to,
+// or "" when there is none; unlike switchToPrevious it never edits the history
+func previousConfigName(configs []gcloud.Configuration) string {
+	entries, err := history.GetHistory()
+	if err != nil {
+		return ""
+	}
+
+	current := ""
+	names := map[string]bool{}
 	for _, config := range configs {
-		names = append(names, config.Name)
+		names[config.Name] = true
+		if config.IsActive {
+			current = config.Name
+		}
+	}
+
+	previous, _, err := history.ResolvePrevious(entries, 1, current, func(name string) bool { return names[name] })
+	if err != nil {
+		return ""
+	}
+	return previous
+}
+
+// completionCacheKey caches the configuration list used by shell completion
+const completionCacheKey = "completion-configurations"
+
+// completionCacheTTL bounds how long a cached list is trusted; within it, the
+// list is still refreshed as soon as a configuration file changes
+const completionCacheTTL = time.Hour
+
+// completionCache is a configuration list and the stamp of the files it was read from
+type completionCache struct {
+	Stamp   string                 `json:"stamp"`
+	Configs []gcloud.Configuration `json:"configs"`
+}
+
+// completionConfigurations returns the configurations for shell completion
+// Running gcloud takes long enough to be noticeable on every TAB, so the list is
+// cached until gcloud's configuration files change; the active configuration is
+// always read fresh since switching does not touch those files
+func completionConfigurations() ([]gcloud.Configuration, error) {
+	stamp, stampErr := gcloud.ConfigurationsStamp()
+
+	var cached completionCache
+	if stampErr == nil {
+		if found, err := cache.Load(completionCacheKey, completionCacheTTL, &cached); err == nil && found && cached.Stamp == stamp {
+			if active, err := gcloud.ActiveConfigName(); err == nil {
+				for i := range cached.Configs {
+					cached.Configs[i].IsActive = cached.Configs[i].Name == active
+				}
+				return cached.Configs, nil
+			}
+		}
+	}
+
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		return nil, err
+	}
+	if stampErr == nil {
+		// Best effort; completion works without the cache
+		_ = cache.Save(completionCacheKey, completionCache{Stamp: stamp, Configs: configs})
+	}
+	return configs, nil
+}
+
+// pendingOperations returns the name changes of in-flight batch operations
+// Errors are ignored so a broken state directory never breaks completion or switching
+func pendingOperations() inflight.Pending {
+	store, err := inflight.DefaultStore()
+	if err != nil {
+		return inflight.Summarize(nil)
+	}
+
+	plans, err := store.Active()
+	if err != nil {
+		return inflight.Summarize(nil)
 	}
 
-	return names, cobra.ShellCompDirectiveNoFileComp
+	return inflight.Summarize(plans)
+}
+
+// beginInflightPlan records a mutating operation so concurrent invocations can account for it
+// Failing to record the plan is non-fatal; the returned values are then nil
+func beginInflightPlan(plan inflight.Plan) (*inflight.Plan, *inflight.Store) {
+	store, err := inflight.DefaultStore()
+	if err != nil {
+		return nil, nil
+	}
+
+	started, err := store.Begin(plan, inflight.DefaultLease)
+	if err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to record in-flight operation: %w", err))
+		return nil, nil
+	}
+
+	return started, store
+}
+
+// renewInflightPlan extends the lease of a plan recorded by beginInflightPlan
+// Batches call it after each step, so a long batch keeps its plan active
+func renewInflightPlan(plan *inflight.Plan, store *inflight.Store) {
+	if plan == nil || store == nil {
+		return
+	}
+	if err := store.Renew(plan, inflight.DefaultLease); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to renew in-flight operation: %w", err))
+	}
+}
+
+// endInflightPlan removes a plan recorded by beginInflightPlan
+func endInflightPlan(plan *inflight.Plan, store *inflight.Store) {
+	if plan == nil || store == nil {
+		return
+	}
+	if err := store.End(plan); err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, err)
+	}
 }
 
 // Execute runs the root command
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	root := NewRootCommand()
+	root.SetArgs(normalizeHistoryJumpArgs(root, os.Args[1:]))
+	if err := root.Execute(); err != nil {
+		os.Exit(exitCode(err))
+	}
+	if versionRequested(root) {
+		noColor, _ := root.Flags().GetBool("no-color")
+		offline, _ := root.PersistentFlags().GetBool("offline")
+		printUpdateNotice(!noColor, offline || gcloud.Offline())
 	}
 }
 
-// buildVersionString returns a formatted version string including commit and date
-func buildVersionString() string {
-	result := Version
-	if Commit != "none" {
-		result += " (commit: " + Commit[:min(7, len(Commit))] + ")"
+// noBrowserUsage describes the --no-browser flag of the commands syncing ADC
+const noBrowserUsage = "Print a URL to log in from another machine instead of launching a browser (the default over SSH or without a display)"
+
+// adcNoBrowser reports whether syncing ADC should have gcloud print a URL
+// instead of launching a browser. --no-browser decides when given; otherwise
+// a browser is not launched where none can be opened, noting why on w.
+// Everything is printed before gcloud runs, so its URL is not interleaved with our output.
+func (o *options) adcNoBrowser(w io.Writer) bool {
+	if o.noBrowserSet {
+		return o.noBrowser
+	}
+	reason, headless := gcloud.Headless()
+	if headless {
+		fmt.Fprintf(w, "Note: %s, so gcloud will print a URL to open in a browser elsewhere (--no-browser=false launches one here)\n", reason)
+	}
+	return headless
+}
+
+// adcImpersonation returns the service account to impersonate when syncing ADC for a configuration
+// An explicit --impersonate-service-account wins over the hint recorded when the configuration was imported
+func (o *options) adcImpersonation(configName string, machineOutput bool) string {
+	if o.impersonate != "" {
+		return o.impersonate
 	}
-	if Date != "unknown" {
-		result += " built at " + Date
+
+	hint, err := adc.GetHint(configName)
+	if err != nil {
+		// Non-fatal error, just warn
+		statedir.Warn(os.Stderr, fmt.Errorf("failed to read ADC hint: %w", err))
+		return ""
+	}
+	if hint.ImpersonateServiceAccount != "" {
+		message := fmt.Sprintf("Impersonating %s (recorded when %q was imported)", hint.ImpersonateServiceAccount, configName)
+		if machineOutput {
+			fmt.Fprintln(os.Stderr, message)
+		} else {
+			fmt.Println(message)
+		}
 	}
-	return result
+	return hint.ImpersonateServiceAccount
 }
@@ -0,0 +1,548 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/procscan"
+)
+
+func TestSwitch(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("prod")
+
+	if env.gcloud.Active() != "prod" {
+		t.Errorf("active = %q, want prod", env.gcloud.Active())
+	}
+	if !strings.Contains(res.stdout, `switched to configuration "prod"`) {
+		t.Errorf("stdout = %q, want the switch reported", res.stdout)
+	}
+	if previous, err := history.GetPreviousConfig(); err != nil || previous != "dev" {
+		t.Errorf("previous configuration = %q, %v; want dev", previous, err)
+	}
+}
+
+func TestSwitchBack(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("prod")
+
+	env.mustRun("-")
+
+	if env.gcloud.Active() != "dev" {
+		t.Errorf("active = %q after switching back, want dev", env.gcloud.Active())
+	}
+}
+
+func TestSwitchToActive(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.mustRun("dev")
+
+	if calls := env.gcloud.Calls(); slices.ContainsFunc(calls, func(c string) bool { return strings.Contains(c, "activate") }) {
+		t.Errorf("switching to the active configuration ran %q", calls)
+	}
+}
+
+func TestSwitchMissingConfiguration(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "staging")
+
+	if res.err == nil {
+		t.Fatal("switching to a missing configuration succeeded")
+	}
+	if !strings.Contains(res.stdout, "staging") {
+		t.Errorf("stdout = %q, want the missing configuration named", res.stdout)
+	}
+	if env.gcloud.Active() != "dev" {
+		t.Errorf("active = %q, want dev unchanged", env.gcloud.Active())
+	}
+}
+
+func TestList(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("-l", "-o", "name")
+
+	if res.stdout != "dev\nprod\n" {
+		t.Errorf("stdout = %q, want the configuration names", res.stdout)
+	}
+}
+
+func TestListJSON(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("-l", "-o", "json")
+
+	var configs []struct {
+		Name     string `json:"name"`
+		IsActive bool   `json:"is_active"`
+		Project  string `json:"project"`
+	}
+	if err := json.Unmarshal([]byte(res.stdout), &configs); err != nil {
+		t.Fatalf("stdout is not a configuration list: %v\n%s", err, res.stdout)
+	}
+	if len(configs) != 2 || configs[0].Name != "dev" || !configs[0].IsActive || configs[1].Project != "prod-project" {
+		t.Errorf("configurations = %+v", configs)
+	}
+}
+
+func TestListDefault(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("-l")
+
+	for _, want := range []string{"dev", "prod", "dev-project", "prod-project"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("stdout = %q, want it to mention %q", res.stdout, want)
+		}
+	}
+}
+
+func TestListMarksPrevious(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("prod")
+
+	res := env.mustRun("-l")
+	if !strings.Contains(res.stdout, "^ dev") || !strings.Contains(res.stdout, "* prod") {
+		t.Errorf("stdout = %q, want dev marked previous and prod active", res.stdout)
+	}
+
+	res = env.mustRun("-l", "-o", "json")
+	var configs []struct {
+		Name       string `json:"name"`
+		IsPrevious bool   `json:"is_previous"`
+	}
+	if err := json.Unmarshal([]byte(res.stdout), &configs); err != nil {
+		t.Fatalf("stdout is not a configuration list: %v\n%s", err, res.stdout)
+	}
+	if len(configs) != 2 || !configs[0].IsPrevious || configs[1].IsPrevious {
+		t.Errorf("configurations = %+v, want only dev previous", configs)
+	}
+}
+
+func TestListGcloudFailure(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Fail("config configurations list", "ERROR: (gcloud.config.configurations.list) There was a problem refreshing your current auth tokens: Reauthentication failed.")
+
+	res := env.run("", "-l")
+
+	if res.err == nil {
+		t.Fatal("listing succeeded although gcloud failed")
+	}
+	if !strings.Contains(res.stdout, "gcloud auth login") {
+		t.Errorf("stdout = %q, want the reauthentication hint", res.stdout)
+	}
+}
+
+func TestFlagsDoNotLeak(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("-l", "-o", "name")
+
+	res := env.mustRun("-c")
+
+	if strings.Contains(res.stdout, "prod") {
+		t.Errorf("-c after -l -o name printed the list: %q", res.stdout)
+	}
+}
+
+func TestMachineOutputDoesNotLeak(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("prod", "-o", "json")
+
+	res := env.mustRun("dev")
+
+	if !strings.Contains(res.stdout, `switched to configuration "dev"`) || strings.Contains(res.stdout, "{") {
+		t.Errorf("stdout = %q, want only the message after a run with -o json", res.stdout)
+	}
+}
+
+func TestNewRootCommandHasOwnFlags(t *testing.T) {
+	first, second := NewRootCommand(), NewRootCommand()
+
+	if err := first.ParseFlags([]string{"-l", "-o", "json"}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	create, _, err := first.Find([]string{"create"})
+	if err != nil {
+		t.Fatalf("Find(create) error = %v", err)
+	}
+	if err := create.ParseFlags([]string{"--activate"}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+
+	for _, name := range []string{"list", "output"} {
+		if flag := second.Flags().Lookup(name); flag.Changed || flag.Value.String() != flag.DefValue {
+			t.Errorf("--%s of the second root = %q, want the default", name, flag.Value)
+		}
+	}
+	create, _, _ = second.Find([]string{"create"})
+	if flag := create.Flags().Lookup("activate"); flag.Value.String() != "false" {
+		t.Errorf("create --activate of the second root = %q, want false", flag.Value)
+	}
+}
+
+// currentBudget is how long 'gcloudctx -c' may take, since it is the most
+// frequent command and runs in prompts and scripts
+const currentBudget = 10 * time.Millisecond
+
+func TestCurrentRunsNoGcloud(t *testing.T) {
+	env := newTestEnv(t)
+
+	const runs = 20
+	timings := make([]time.Duration, runs)
+	for i := range timings {
+		start := time.Now()
+		res := env.mustRun("-c")
+		timings[i] = time.Since(start)
+		if !strings.Contains(res.stdout, "dev") {
+			t.Fatalf("stdout = %q, want the active configuration", res.stdout)
+		}
+	}
+
+	if calls := env.gcloud.Calls(); len(calls) != 0 {
+		t.Errorf("-c ran gcloud %d times: %q", len(calls), calls)
+	}
+
+	slices.Sort(timings)
+	median := timings[runs/2]
+	t.Logf("-c over %d runs: median %s, slowest %s (budget %s)", runs, median, timings[runs-1], currentBudget)
+	if median > currentBudget {
+		t.Errorf("-c took %s, want under %s", median, currentBudget)
+	}
+}
+
+func TestCurrentDanglingActiveConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("prod")
+	// active_config left pointing at a configuration deleted behind gcloud's back
+	if err := os.WriteFile(filepath.Join(env.configDir, gcloud.ActiveConfigFileName), []byte("deleted"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	res := env.mustRun("-c")
+
+	if !strings.Contains(res.stdout, "prod") {
+		t.Errorf("stdout = %q, want the configuration gcloud reports", res.stdout)
+	}
+	if calls := env.gcloud.Calls(); !slices.Contains(calls, "config configurations list --format=json") {
+		t.Errorf("gcloud calls = %q, want the list as a fallback", calls)
+	}
+}
+
+func TestCurrentJSON(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("-c", "-o", "json")
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(res.stdout), &got); err != nil {
+		t.Fatalf("-c -o json printed %q, want a single object: %v", res.stdout, err)
+	}
+	if got["name"] != "dev" || got["project"] != "dev-project" || got["account"] != "dev@example.com" || got["is_active"] != true {
+		t.Errorf("-c -o json = %v, want the active configuration dev", got)
+	}
+}
+
+func TestCurrentFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{format: "yaml", want: []string{"name: dev\n", "project: dev-project\n", "is_active: true\n"}},
+		{format: "name", want: []string{"dev\n"}},
+		{format: "wide", want: []string{"NAME", "dev-project"}},
+		{format: "value=project", want: []string{"dev-project\n"}},
+		{format: "value=account", want: []string{"dev@example.com\n"}},
+		{format: "value=region", want: []string{"\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			env := newTestEnv(t)
+
+			res := env.mustRun("-c", "-o", tt.format)
+
+			for _, want := range tt.want {
+				if !strings.Contains(res.stdout, want) {
+					t.Errorf("-c -o %s printed %q, want %q in it", tt.format, res.stdout, want)
+				}
+			}
+			if strings.Contains(res.stdout, "prod") {
+				t.Errorf("-c -o %s printed %q, want only the active configuration", tt.format, res.stdout)
+			}
+		})
+	}
+
+	t.Run("name and value print nothing else", func(t *testing.T) {
+		env := newTestEnv(t)
+		if res := env.mustRun("-c", "-o", "name"); res.stdout != "dev\n" {
+			t.Errorf("-c -o name printed %q, want the name alone", res.stdout)
+		}
+		if res := env.mustRun("-c", "-o", "value=project"); res.stdout != "dev-project\n" {
+			t.Errorf("-c -o value=project printed %q, want the project alone", res.stdout)
+		}
+	})
+}
+
+func TestCurrentUnknownValueField(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "-c", "-o", "value=billing")
+
+	if res.err == nil || !strings.Contains(res.err.Error(), `unknown field "billing"`) || !strings.Contains(res.err.Error(), "project") {
+		t.Errorf("-c -o value=billing = %v, want an error listing the valid fields", res.err)
+	}
+}
+
+func TestListValueFormat(t *testing.T) {
+	env := newTestEnv(t)
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "value(name)", want: "dev\nprod\n"},
+		{format: "value(name,project,region)", want: "dev\tdev-project\t\nprod\tprod-project\t\n"},
+		{format: "VALUE(Name, Account)", want: "dev\tdev@example.com\nprod\tops@example.com\n"},
+	}
+	for _, tt := range tests {
+		if res := env.mustRun("-l", "-o", tt.format); res.stdout != tt.want {
+			t.Errorf("-l -o %s printed %q, want %q", tt.format, res.stdout, tt.want)
+		}
+	}
+
+	if res := env.mustRun("-c", "-o", "value(name,project)"); res.stdout != "dev\tdev-project\n" {
+		t.Errorf("-c -o value(name,project) printed %q, want the active configuration only", res.stdout)
+	}
+
+	res := env.run("", "-l", "-o", "value(name,billing)")
+	if res.err == nil || !strings.Contains(res.err.Error(), `unknown field "billing"`) || !strings.Contains(res.err.Error(), "usage_reporting") {
+		t.Errorf("-l -o value(name,billing) = %v, want an error listing the valid fields", res.err)
+	}
+}
+
+func TestShowAllUsageReporting(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("ci", map[string]string{"core/project": "ci-project", "core/disable_usage_reporting": "True"})
+	env.mustRun("ci")
+
+	if res := env.mustRun("-l", "-o", "wide"); strings.Contains(res.stdout, "USAGE REPORTING") {
+		t.Errorf("-o wide = %q, want usage reporting left out by default", res.stdout)
+	}
+	if res := env.mustRun("-l", "-o", "wide", "--show-all"); !strings.Contains(res.stdout, "USAGE REPORTING") || !strings.Contains(res.stdout, "disabled") {
+		t.Errorf("-o wide --show-all = %q, want the usage reporting column", res.stdout)
+	}
+
+	if res := env.mustRun("-c", "--info"); strings.Contains(res.stdout, "Usage reporting") {
+		t.Errorf("--info = %q, want usage reporting left out by default", res.stdout)
+	}
+	if res := env.mustRun("-c", "--info", "--show-all"); !strings.Contains(res.stdout, "Usage reporting: disabled") {
+		t.Errorf("--info --show-all = %q, want usage reporting shown", res.stdout)
+	}
+}
+
+// fakeProcesses makes the switch see processes as running
+func fakeProcesses(t *testing.T, processes ...procscan.Process) {
+	t.Helper()
+	lister := processLister
+	processLister = procscan.ListerFunc(func() ([]procscan.Process, error) {
+		return processes, nil
+	})
+	t.Cleanup(func() { processLister = lister })
+}
+
+func TestSwitchWhileGcloudRuns(t *testing.T) {
+	deploy := procscan.Process{PID: 4242, Command: "gcloud run deploy api"}
+
+	tests := []struct {
+		name       string
+		settings   string
+		processes  []procscan.Process
+		terminal   bool
+		stdin      string
+		args       []string
+		wantActive string
+		wantErr    bool
+		wantStderr string
+	}{
+		{name: "check off", processes: []procscan.Process{deploy}, terminal: true, args: []string{"prod"}, wantActive: "prod"},
+		{name: "nothing running", settings: "switch:\n  check_running_gcloud: true\n", terminal: true, args: []string{"prod"}, wantActive: "prod"},
+		{name: "confirmed", settings: "switch:\n  check_running_gcloud: true\n", processes: []procscan.Process{deploy}, terminal: true, stdin: "y\n", args: []string{"prod"}, wantActive: "prod", wantStderr: "4242  gcloud run deploy api"},
+		{name: "declined", settings: "switch:\n  check_running_gcloud: true\n", processes: []procscan.Process{deploy}, terminal: true, stdin: "n\n", args: []string{"prod"}, wantActive: "dev", wantStderr: "Switch canceled"},
+		{name: "no terminal", settings: "switch:\n  check_running_gcloud: true\n", processes: []procscan.Process{deploy}, args: []string{"prod"}, wantActive: "dev", wantErr: true, wantStderr: "4242"},
+		{name: "forced", settings: "switch:\n  check_running_gcloud: true\n", processes: []procscan.Process{deploy}, args: []string{"prod", "--force"}, wantActive: "prod", wantStderr: "Switching anyway"},
+		{name: "other processes", settings: "switch:\n  check_running_gcloud: true\n", processes: []procscan.Process{{PID: 1, Command: "terraform apply"}}, args: []string{"prod"}, wantActive: "prod"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.terminal = tt.terminal
+			if tt.settings != "" {
+				env.writeSettings(tt.settings)
+			}
+			fakeProcesses(t, tt.processes...)
+
+			res := env.run(tt.stdin, tt.args...)
+			if (res.err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", res.err, tt.wantErr)
+			}
+			if env.gcloud.Active() != tt.wantActive {
+				t.Errorf("active = %q, want %q", env.gcloud.Active(), tt.wantActive)
+			}
+			if !strings.Contains(res.stderr, tt.wantStderr) {
+				t.Errorf("stderr = %q, want %q", res.stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestExistingUnusualName(t *testing.T) {
+	const weird = "My.Weird_Config"
+
+	t.Run("switch and back", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.gcloud.Add(weird, map[string]string{"core/project": "weird-project"})
+
+		env.mustRun(weird)
+		if env.gcloud.Active() != weird {
+			t.Fatalf("active = %q, want %s", env.gcloud.Active(), weird)
+		}
+		if res := env.mustRun("-c", "-o", "value(name,project)"); res.stdout != weird+"\tweird-project\n" {
+			t.Errorf("-c = %q, want the unusual configuration", res.stdout)
+		}
+		env.mustRun("-")
+		env.mustRun("-")
+		if env.gcloud.Active() != weird {
+			t.Errorf("active = %q after toggling back, want %s", env.gcloud.Active(), weird)
+		}
+	})
+
+	t.Run("describe", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.gcloud.Add(weird, map[string]string{"core/project": "weird-project"})
+
+		if res := env.mustRun("describe", weird); !strings.Contains(res.stdout, "weird-project") {
+			t.Errorf("describe = %q, want its project", res.stdout)
+		}
+	})
+
+	t.Run("use", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.gcloud.Add(weird, nil)
+
+		env.mustRun("use", weird)
+		env.mustRun(".")
+		if env.gcloud.Active() != weird {
+			t.Errorf("active = %q after 'gcloudctx .', want %s", env.gcloud.Active(), weird)
+		}
+	})
+
+	t.Run("clone from and rename away", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.gcloud.Add(weird, map[string]string{"core/project": "weird-project"})
+
+		env.mustRun("clone", weird, "weird-copy")
+		if props, ok := env.gcloud.Properties("weird-copy"); !ok || props["core/project"] != "weird-project" {
+			t.Errorf("clone = %v (exists %v), want the source's project", props, ok)
+		}
+		env.mustRun("rename", weird, "weird")
+		if _, ok := env.gcloud.Properties("weird"); !ok {
+			t.Errorf("configurations = %v, want %s renamed to weird", env.gcloud.Names(), weird)
+		}
+	})
+
+	t.Run("new names are still validated", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		for _, args := range [][]string{
+			{"create", weird},
+			{"clone", "prod", weird},
+			{"rename", "prod", weird},
+		} {
+			if res := env.run("", args...); res.err == nil {
+				t.Errorf("%v succeeded, want the new name refused", args)
+			}
+		}
+		env.writeFile("team.yaml", "- name: "+weird+"\n")
+		if res := env.run("", "apply", "team.yaml"); res.err == nil || !strings.Contains(res.stdout, weird+" failed") {
+			t.Errorf("apply = %q, %v; want creating %s refused", res.stdout, res.err, weird)
+		}
+	})
+}
+
+func TestUnavailableHome(t *testing.T) {
+	homes := map[string]func(t *testing.T, env *testEnv) string{
+		"nonexistent": func(t *testing.T, env *testEnv) string { return filepath.Join(env.home, "missing") },
+		"unset":       func(t *testing.T, env *testEnv) string { return "" },
+		"a file":      func(t *testing.T, env *testEnv) string { return env.writeFile("home", "") },
+		"read-only": func(t *testing.T, env *testEnv) string {
+			if os.Geteuid() == 0 {
+				t.Skip("root can write to read-only directories")
+			}
+			if err := os.Chmod(env.home, 0o555); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = os.Chmod(env.home, 0o755) })
+			return env.home
+		},
+	}
+	for name, home := range homes {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			t.Setenv("HOME", home(t, env))
+			t.Setenv("XDG_CACHE_HOME", "")
+			t.Setenv("XDG_CONFIG_HOME", "")
+
+			res := env.mustRun("prod")
+			if env.gcloud.Active() != "prod" || res.stderr != "" {
+				t.Errorf("switch: active %q, stderr %q; want prod without warnings", env.gcloud.Active(), res.stderr)
+			}
+			if res := env.mustRun("-l"); !strings.Contains(res.stdout, "prod") || res.stderr != "" {
+				t.Errorf("list = %q, stderr %q; want the configurations without warnings", res.stdout, res.stderr)
+			}
+
+			res = env.mustRun("dev", "--verbose")
+			if env.gcloud.Active() != "dev" || strings.Count(res.stderr, "Note: ") != 1 {
+				t.Errorf("switch --verbose: active %q, stderr %q; want dev and one note", env.gcloud.Active(), res.stderr)
+			}
+		})
+	}
+}
+
+func TestSwitchToProtectedConfiguration(t *testing.T) {
+	t.Run("piped stdin asks the controlling terminal", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.mustRun("protect", "prod")
+		env.terminal = false
+		env.tty = &fakeTTY{Reader: strings.NewReader("y\n")}
+
+		res := env.run("n\n", "prod")
+		if res.err != nil || env.gcloud.Active() != "prod" {
+			t.Errorf("switch = %v, active %q; want prod confirmed on the terminal", res.err, env.gcloud.Active())
+		}
+		if !strings.Contains(env.tty.questions.String(), "Switch to PROTECTED configuration 'prod'?") {
+			t.Errorf("terminal = %q, want the question asked there", env.tty.questions.String())
+		}
+	})
+
+	t.Run("no terminal", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.mustRun("protect", "prod")
+		env.terminal = false
+
+		res := env.run("y\n", "prod")
+		if res.err == nil || env.gcloud.Active() != "dev" {
+			t.Errorf("switch = %v, active %q; want it refused", res.err, env.gcloud.Active())
+		}
+	})
+}
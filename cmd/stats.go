@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// statsOptions holds the flags of the stats command
+type statsOptions struct {
+	*options
+	format string
+	reset  bool
+}
+
+func newStatsCmd(parent *options) *cobra.Command {
+	o := &statsOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show how often each configuration was switched to",
+		Long: `Show how many times gcloudctx switched to each configuration and when it
+was last used, most switched first.
+
+Only switches made through gcloudctx are counted: switching with 'gcloudctx',
+'gcloudctx auto', 'use --switch', and the --activate flags of create, clone and
+import.
+
+Examples:
+  gcloudctx stats            # Ranked table
+  gcloudctx stats -o json    # JSON array for tooling
+  gcloudctx stats --reset    # Start counting from zero`,
+		Args: cobra.NoArgs,
+		RunE: o.runStats,
+	}
+	cmd.Flags().StringVarP(&o.format, "output", "o", "", "Output format (json, yaml)")
+	cmd.Flags().BoolVar(&o.reset, "reset", false, "Clear all switch counts and last-used times")
+	return cmd
+}
+
+func (o *statsOptions) runStats(cmd *cobra.Command, args []string) error {
+	if o.reset {
+		if err := history.ClearUsage(); err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		output.PrintSuccess("cleared usage statistics", !o.noColor)
+		return nil
+	}
+
+	format, err := output.ValidateOutputFormat(o.format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	usage, err := history.LoadUsage()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	ranked := history.RankBySwitches(usage)
+
+	if output.IsMachineFormat(format) {
+		stats := make([]output.UsageStat, len(ranked))
+		for i, entry := range ranked {
+			stats[i] = output.UsageStat{Name: entry.Name, Switches: entry.Usage.Switches, LastUsed: entry.Usage.LastUsed}
+		}
+		return output.PrintUsageStats(stats, format)
+	}
+
+	if len(ranked) == 0 {
+		fmt.Println("No switches recorded yet")
+		return nil
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	bold := color.New(color.Bold).SprintFunc()
+
+	now := time.Now()
+	rows := [][]string{{bold("NAME"), bold("SWITCHES"), bold("LAST USED")}}
+	for _, entry := range ranked {
+		rows = append(rows, []string{
+			entry.Name,
+			strconv.Itoa(entry.Usage.Switches),
+			output.FormatRelativeTime(entry.Usage.LastUsed, now),
+		})
+	}
+	for _, line := range output.AlignColumns(rows, 2) {
+		fmt.Println(line)
+	}
+	return nil
+}
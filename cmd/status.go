@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/local"
+	"github.com/spf13/cobra"
+)
+
+// statusOptions holds the flags of the status command
+type statusOptions struct {
+	*options
+	refresh bool
+}
+
+func newStatusCmd(parent *options) *cobra.Command {
+	o := &statusOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the active configuration, its project, ADC and the local .gcloudctx file",
+		Long: `Show the context gcloud commands run in: the active configuration with its
+account and project, the Application Default Credentials, and the .gcloudctx
+file that applies to the current directory.
+
+The ADC type shows whether client libraries use a user, a service account key,
+an impersonated service account (with the chain of accounts it goes through)
+or workload identity federation. No token or key is printed.
+
+Apart from the access token row, which asks gcloud when the active account's
+token expires, this reads gcloud's files instead of running gcloud. Use
+'gcloudctx watch' to keep it on screen while it changes.
+
+Examples:
+  gcloudctx status            # The active context
+  gcloudctx status --refresh  # Get a new access token first`,
+		Args: cobra.NoArgs,
+		RunE: o.runStatus,
+	}
+	cmd.Flags().BoolVar(&o.refresh, "refresh", false, "Refresh the access token before showing when it expires")
+	return cmd
+}
+
+// adcEnvVar points Google client libraries at a credentials file instead of gcloud's ADC
+const adcEnvVar = "GOOGLE_APPLICATION_CREDENTIALS"
+
+// contextStatus is what status and watch show
+type contextStatus struct {
+	Config string
+	// Previous is the configuration 'gcloudctx -' switches to, empty when there is none
+	Previous string
+	Account  string
+	Project  string
+	// ADC describes the Application Default Credentials client libraries use
+	ADC string
+	// ADCType describes the kind of credentials in the ADC file, empty when there is none
+	ADCType string
+	// AccessToken says when the active account's access token expires, empty
+	// when it was not checked
+	AccessToken string
+	// Local is the .gcloudctx file in effect, empty when there is none
+	Local string
+	// LocalConfig is the configuration Local names, empty when it is unreadable
+	LocalConfig string
+	// LocalErr is why Local cannot be used
+	LocalErr error
+	// LocalProjectFile is the .gcloudctx file setting the project, empty when there is none
+	LocalProjectFile string
+	// LocalProject is the project LocalProjectFile sets
+	LocalProject string
+}
+
+// collectStatus gathers the status of the active context from gcloud's and
+// gcloudctx's files
+func collectStatus() (*contextStatus, error) {
+	config, err := gcloud.GetActiveConfigurationFast()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &contextStatus{
+		Config:   config.Name,
+		Previous: previousConfigNameFast(config.Name),
+		Account:  config.Properties.Core.Account,
+		Project:  config.Properties.Core.Project,
+		ADC:      describeADC(config.Name),
+		ADCType:  describeADCType(),
+	}
+
+	matches, err := local.FindLocalConfigs()
+	if err != nil {
+		return status, nil
+	}
+	if effective, _ := local.ClassifyShadowing(matches); effective.Dir != "" {
+		status.Local, status.LocalConfig, status.LocalErr = effective.Path(), effective.Config, effective.Err
+	}
+	if projectFile, ok := local.EffectiveProject(matches); ok {
+		status.LocalProjectFile, status.LocalProject = projectFile.Path(), projectFile.Project
+	}
+	return status, nil
+}
+
+// previousConfigNameFast is previousConfigName checking that configurations
+// exist from gcloud's files instead of listing them
+func previousConfigNameFast(current string) string {
+	entries, err := history.GetHistory()
+	if err != nil {
+		return ""
+	}
+	exists := func(name string) bool {
+		found, err := gcloud.ConfigurationFileExists(name)
+		return err == nil && found
+	}
+	previous, _, err := history.ResolvePrevious(entries, 1, current, exists)
+	if err != nil {
+		return ""
+	}
+	return previous
+}
+
+// describeADC says where the Application Default Credentials come from and
+// whether the configuration has a snapshot of them
+func describeADC(configName string) string {
+	if path := os.Getenv(adcEnvVar); path != "" {
+		return fmt.Sprintf("%s ($%s)", path, adcEnvVar)
+	}
+
+	description := "not set up"
+	if dir, err := gcloud.ConfigDir(); err == nil {
+		if _, err := os.Stat(filepath.Join(dir, gcloud.ADCFileName)); err == nil {
+			description = gcloud.ADCFileName
+		}
+	}
+
+	store, err := adc.DefaultSnapshotStore()
+	if err != nil {
+		return description
+	}
+	snapshot, err := store.Get(configName)
+	if err != nil {
+		return description
+	}
+	if snapshot.Metadata.Dead() {
+		return description + " (snapshot failed verification)"
+	}
+	return description + fmt.Sprintf(" (snapshot saved %s)", snapshot.Metadata.SavedAt.Local().Format("2006-01-02"))
+}
+
+// activeADCFile returns the credentials file client libraries read, if any
+func activeADCFile() (string, bool) {
+	if path := os.Getenv(adcEnvVar); path != "" {
+		return path, true
+	}
+	path, err := adcFilePath()
+	if err != nil {
+		return "", false
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// describeADCType says what kind of credentials the ADC file holds, without any secret
+func describeADCType() string {
+	path, ok := activeADCFile()
+	if !ok {
+		return ""
+	}
+	info, err := gcloud.ReadADC(path)
+	if err != nil {
+		return fmt.Sprintf("unreadable (%s)", firstLine(err.Error()))
+	}
+	return info.String()
+}
+
+// renderStatus prints the status block
+func renderStatus(w io.Writer, status *contextStatus) {
+	orUnset := func(value string) string {
+		if value == "" {
+			return "(unset)"
+		}
+		return value
+	}
+	orNone := func(value string) string {
+		if value == "" {
+			return "none"
+		}
+		return value
+	}
+
+	localFile := "none"
+	switch {
+	case status.LocalErr != nil:
+		localFile = firstLine(status.LocalErr.Error())
+	case status.Local == "":
+	case status.LocalConfig == status.Config:
+		localFile = fmt.Sprintf("%s (%s, active)", status.Local, status.LocalConfig)
+	default:
+		localFile = fmt.Sprintf("%s (%s, not active; run 'gcloudctx .')", status.Local, status.LocalConfig)
+	}
+
+	rows := [][]string{
+		{"Configuration:", status.Config},
+		{"Previous:", orNone(status.Previous)},
+		{"Account:", orUnset(status.Account)},
+		{"Project:", orUnset(status.Project)},
+		{"ADC:", status.ADC},
+	}
+	if status.ADCType != "" {
+		rows = append(rows, []string{"ADC type:", status.ADCType})
+	}
+	if status.AccessToken != "" {
+		rows = append(rows, []string{"Access token:", status.AccessToken})
+	}
+	rows = append(rows, []string{"Local file:", localFile})
+	switch {
+	case status.LocalProjectFile == "":
+	case status.LocalProject == status.Project:
+		rows = append(rows, []string{"Local project:", fmt.Sprintf("%s (%s, active)", status.LocalProjectFile, status.LocalProject)})
+	default:
+		rows = append(rows, []string{"Local project:", fmt.Sprintf("%s (%s, not active; run 'gcloudctx auto')", status.LocalProjectFile, status.LocalProject)})
+	}
+	for _, line := range output.AlignColumns(rows, 1) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+func (o *statusOptions) runStatus(cmd *cobra.Command, args []string) error {
+	status, err := collectStatus()
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	// Asking gcloud about the token is too slow for watch, so only status does
+	if status.Account != "" {
+		token := checkAccessToken(o.refresh, time.Now())
+		status.AccessToken = token.result
+		if token.status != doctorOK && token.advice != "" {
+			status.AccessToken += fmt.Sprintf(" (%s)", token.advice)
+		}
+	}
+
+	renderStatus(os.Stdout, status)
+	return nil
+}
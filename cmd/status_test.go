@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+func TestStatus(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeFile(".gcloudctx", "prod\n")
+
+	res := env.mustRun("status")
+
+	for _, want := range []string{"dev", "dev-project", "dev@example.com", "not set up", "(prod, not active; run 'gcloudctx .')"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("stdout = %q, want it to mention %q", res.stdout, want)
+		}
+	}
+	// Only the access token needs gcloud
+	if calls := env.gcloud.Calls(); len(calls) != 1 || !strings.HasPrefix(calls[0], "config config-helper") {
+		t.Errorf("status ran gcloud: %q", calls)
+	}
+}
+
+func TestStatusAccessToken(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.gcloud.SetTokenExpiry(time.Now().Add(30 * time.Minute))
+	if res := env.mustRun("status"); !strings.Contains(res.stdout, "Access token:  expires in 30m") {
+		t.Errorf("stdout = %q, want the token expiry", res.stdout)
+	}
+	if strings.Contains(env.mustRun("status").stdout, "ya29.") {
+		t.Error("status printed the access token")
+	}
+
+	env.gcloud.SetTokenExpiry(time.Now().Add(5 * time.Minute))
+	if res := env.mustRun("status"); !strings.Contains(res.stdout, "expires in 5m (run 'gcloudctx status --refresh')") {
+		t.Errorf("stdout = %q, want a near expiry warning", res.stdout)
+	}
+	if res := env.mustRun("status", "--refresh"); !strings.Contains(res.stdout, "expires in 1h\n") {
+		t.Errorf("status --refresh = %q, want a new token", res.stdout)
+	}
+
+	if _, err := env.gcloud.Run("config", "unset", "account"); err != nil {
+		t.Fatal(err)
+	}
+	if res := env.mustRun("status"); strings.Contains(res.stdout, "Access token:") {
+		t.Errorf("stdout = %q, want no token row without an account", res.stdout)
+	}
+}
+
+func TestStatusADCType(t *testing.T) {
+	env := newTestEnv(t)
+	if res := env.mustRun("status"); strings.Contains(res.stdout, "ADC type:") {
+		t.Errorf("stdout = %q, want no ADC type without ADC", res.stdout)
+	}
+
+	adcFile := filepath.Join(env.configDir, gcloud.ADCFileName)
+	if err := os.WriteFile(adcFile, []byte(`{
+  "type": "impersonated_service_account",
+  "service_account_impersonation_url": "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/deploy@p.iam.gserviceaccount.com:generateAccessToken",
+  "delegates": ["projects/-/serviceAccounts/hop@p.iam.gserviceaccount.com"],
+  "source_credentials": {"type": "authorized_user", "client_id": "id", "client_secret": "s3cret", "refresh_token": "r3fresh"}
+}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	res := env.mustRun("status")
+	if !strings.Contains(res.stdout, "ADC type:      impersonated_service_account (authorized_user → hop@p.iam.gserviceaccount.com → deploy@p.iam.gserviceaccount.com)") {
+		t.Errorf("stdout = %q, want the impersonation chain", res.stdout)
+	}
+	for _, secret := range []string{"s3cret", "r3fresh"} {
+		if strings.Contains(res.stdout, secret) {
+			t.Errorf("stdout = %q, printed %q", res.stdout, secret)
+		}
+	}
+
+	res = env.mustRun("doctor")
+	for _, want := range []string{"access token", "expires in 1h", "impersonated_service_account"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("doctor = %q, want %q", res.stdout, want)
+		}
+	}
+}
+
+func TestStatusPrevious(t *testing.T) {
+	env := newTestEnv(t)
+	if res := env.mustRun("status"); !strings.Contains(res.stdout, "Previous:      none") {
+		t.Errorf("stdout = %q, want no previous configuration", res.stdout)
+	}
+
+	env.mustRun("prod")
+
+	if res := env.mustRun("status"); !strings.Contains(res.stdout, "Previous:      dev") {
+		t.Errorf("stdout = %q, want dev as the previous configuration", res.stdout)
+	}
+}
+
+func TestWatchNeedsTerminal(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "watch")
+
+	if res.err == nil {
+		t.Fatal("watch succeeded without a terminal")
+	}
+	if !strings.Contains(res.stdout, "gcloudctx status") {
+		t.Errorf("stdout = %q, want it to point at status", res.stdout)
+	}
+}
+
+func TestWatchLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{})
+	frames := 0
+	draw := func(w io.Writer) {
+		frames++
+		if _, err := io.WriteString(w, "frame\n"); err != nil {
+			t.Fatal(err)
+		}
+		switch frames {
+		case 1:
+			// A change redraws at once, well before the interval
+			go func() { changes <- struct{}{} }()
+		case 2:
+			cancel()
+		}
+	}
+
+	var out strings.Builder
+	if err := watchLoop(ctx, &out, time.Hour, changes, draw); err != nil {
+		t.Fatalf("watchLoop() error = %v", err)
+	}
+
+	if frames != 2 {
+		t.Errorf("drew %d frames, want 2", frames)
+	}
+	if got := out.String(); !strings.HasPrefix(got, hideCursor+clearScreen) || !strings.HasSuffix(got, showCursor) || strings.Count(got, clearScreen) != 2 {
+		t.Errorf("output = %q, want two cleared frames with the cursor restored", got)
+	}
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
+	"github.com/Okabe-Junya/gcloudctx/pkg/interactive"
+	"github.com/Okabe-Junya/gcloudctx/pkg/templates"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func newTemplatesCmd(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Manage configuration templates",
+		Long: `Templates are configuration presets in ~/.config/gcloudctx/templates/<name>.yaml.
+They use the export file format, and values may contain Go template
+placeholders:
+
+  {{.User}}          your login name
+  {{.Name}}          the name of the configuration being created
+  {{.Param "env"}}   a value given with --param env=VALUE (asked for when missing)
+
+Example template (team-default.yaml):
+
+  account: "{{.User}}@example.com"
+  project: "team-{{.Param "env"}}"
+  region: europe-west1
+  zone: europe-west1-b
+
+Create a configuration from it with:
+
+  gcloudctx create my-config --template team-default --param env=staging`,
+	}
+	cmd.AddCommand(newTemplatesListCmd(o))
+	return cmd
+}
+
+func newTemplatesListCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List available configuration templates and their parameters",
+		Args:  cobra.NoArgs,
+		RunE:  o.runTemplatesList,
+	}
+}
+
+// completeTemplateNames provides completion for --template
+func completeTemplateNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dir, err := templates.GetTemplatesDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	list, err := templates.List(dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, len(list))
+	for i, tmpl := range list {
+		names[i] = tmpl.Name
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func (o *options) runTemplatesList(cmd *cobra.Command, args []string) error {
+	dir, err := templates.GetTemplatesDir()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	list, err := templates.List(dir)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Printf("No templates (add <name>.yaml files to %s)\n", dir)
+		return nil
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	gray := color.New(color.FgHiBlack).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	user, _ := templates.CurrentUser()
+	rows := [][]string{{"TEMPLATE", "PARAMETERS", "PATH"}}
+	for _, tmpl := range list {
+		params := gray("-")
+		if names, err := tmpl.Params(templates.Data{User: user}); err != nil {
+			params = red("invalid")
+		} else if len(names) > 0 {
+			params = strings.Join(names, ", ")
+		}
+		rows = append(rows, []string{tmpl.Name, params, tmpl.Path})
+	}
+
+	for _, line := range output.AlignColumns(rows, 2) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// parseTemplateParams turns key=value arguments of --param into a map
+func parseTemplateParams(args []string) (map[string]string, error) {
+	params := map[string]string{}
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid --param %q (use KEY=VALUE)", arg)
+		}
+		params[key] = value
+	}
+	return params, nil
+}
+
+// renderTemplate renders the named template for a new configuration
+// Parameters missing from params are asked for in a terminal and are an error otherwise
+func renderTemplate(templateName, configName string, params map[string]string) (*configfile.Resolved, error) {
+	dir, err := templates.GetTemplatesDir()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := templates.Load(dir, templateName)
+	if err != nil {
+		return nil, err
+	}
+	user, err := templates.CurrentUser()
+	if err != nil {
+		return nil, err
+	}
+	data := templates.Data{Name: configName, User: user, Params: params}
+
+	names, err := tmpl.Params(data)
+	if err != nil {
+		return nil, err
+	}
+	for key := range params {
+		if !slices.Contains(names, key) {
+			return nil, fmt.Errorf("template %q has no parameter %q", templateName, key)
+		}
+	}
+
+	var missing []string
+	for _, name := range names {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		if !interactive.IsTerminal() {
+			return nil, fmt.Errorf("template %q needs --param for %s", templateName, strings.Join(missing, ", "))
+		}
+		if err := promptTemplateParams(missing, params); err != nil {
+			return nil, err
+		}
+	}
+
+	config, err := tmpl.Render(data)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := configfile.Resolve(config, os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	if err := templates.Validate(resolved); err != nil {
+		return nil, fmt.Errorf("template %q: %w", templateName, err)
+	}
+	resolved.Name = configName
+	return resolved, nil
+}
+
+// promptTemplateParams asks for each missing parameter on stderr and stores the answers in params
+func promptTemplateParams(missing []string, params map[string]string) error {
+	reader := bufio.NewReader(os.Stdin)
+	for _, name := range missing {
+		fmt.Fprintf(os.Stderr, "Value for %s: ", name)
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(os.Stderr)
+			return fmt.Errorf("no value given for parameter %q", name)
+		}
+		params[name] = strings.TrimSpace(answer)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/internal/prompt"
+	"github.com/Okabe-Junya/gcloudctx/pkg/activity"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/cache"
+	"github.com/Okabe-Junya/gcloudctx/pkg/cleanup"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/inflight"
+	"github.com/Okabe-Junya/gcloudctx/pkg/notes"
+	"github.com/Okabe-Junya/gcloudctx/pkg/session"
+	"github.com/Okabe-Junya/gcloudctx/pkg/settings"
+	"github.com/Okabe-Junya/gcloudctx/pkg/shellrc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statelock"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// uninstallConfirmation is the word that must be typed to remove state
+const uninstallConfirmation = "uninstall"
+
+// uninstallStateOptions holds the flags of the uninstall-state command
+type uninstallStateOptions struct {
+	*options
+	dryRun bool
+}
+
+func newUninstallStateCmd(parent *options) *cobra.Command {
+	o := &uninstallStateOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "uninstall-state",
+		Short: "List or remove all state owned by gcloudctx",
+		Long: `List everything gcloudctx has written outside of gcloud itself, and optionally remove it:
+
+  - history, settings, ADC snapshots, and in-flight operation state in your home directory
+  - the cache directory
+  - gcloudctx-managed blocks in shell rc files (the rest of the file is kept,
+    and a timestamped .bak copy is written first)
+  - other rc file lines mentioning gcloudctx, such as hooks and widgets pasted
+    from the README; these are only listed, for you to remove by hand
+  - completion scripts installed in your home directory at the locations
+    suggested by 'gcloudctx completion' (system and Homebrew completions
+    belong to the package manager and are left alone)
+
+gcloud's own configuration directory (~/.config/gcloud or $CLOUDSDK_CONFIG) is never touched.
+
+Listing is the default; pass --dry-run=false to remove the listed items after
+typing "uninstall" to confirm.
+
+Examples:
+  gcloudctx uninstall-state                 # List gcloudctx-owned state
+  gcloudctx uninstall-state --dry-run=false # Remove it after confirmation`,
+		Args: cobra.NoArgs,
+		RunE: o.runUninstallState,
+	}
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", true, "Only list what would be removed")
+	return cmd
+}
+
+func (o *uninstallStateOptions) runUninstallState(cmd *cobra.Command, args []string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		output.PrintError(fmt.Sprintf("failed to get home directory: %v", err), !o.noColor)
+		return err
+	}
+
+	artifacts, err := discoverOwnedState(home)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if len(artifacts) == 0 {
+		fmt.Println("No gcloudctx state found")
+		return nil
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	gray := color.New(color.FgHiBlack).SprintFunc()
+
+	// Lines the user pasted into rc files are listed, but never edited
+	var removable, manual []cleanup.Artifact
+	for _, artifact := range artifacts {
+		if artifact.Manual() {
+			manual = append(manual, artifact)
+		} else {
+			removable = append(removable, artifact)
+		}
+	}
+
+	for _, artifact := range removable {
+		fmt.Printf("%-11s %s %s\n", artifact.Kind, artifact.Path, gray("("+artifact.Description+")"))
+	}
+	if len(manual) > 0 {
+		if len(removable) > 0 {
+			fmt.Println()
+		}
+		fmt.Println("Remove by hand (gcloudctx does not edit lines it did not write):")
+		for _, artifact := range manual {
+			fmt.Printf("%-11s %s %s\n", artifact.Kind, artifact.Path, gray("("+artifact.Description+")"))
+		}
+	}
+	if len(removable) == 0 {
+		return nil
+	}
+
+	if o.dryRun {
+		fmt.Printf("\nDry run: pass --dry-run=false to remove these %d item(s)\n", len(removable))
+		return nil
+	}
+
+	confirmed, err := prompt.AskTyped(os.Stdout, fmt.Sprintf("\nThis removes the %d item(s) listed above.", len(removable)), uninstallConfirmation)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Uninstall canceled")
+		return nil
+	}
+
+	protectedDir := cleanup.GcloudConfigDir(home)
+	failed := 0
+	for _, artifact := range removable {
+		if err := cleanup.Remove(artifact, protectedDir); err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to remove %d item(s)", failed)
+	}
+
+	output.PrintSuccess(fmt.Sprintf("removed %d item(s)", len(removable)), !o.noColor)
+	return nil
+}
+
+// ownedStateFile is a file or directory in the home directory written by gcloudctx
+type ownedStateFile struct {
+	description string
+	path        func() (string, error)
+	// portable marks state that is meaningful on another machine and goes into backups
+	portable bool
+}
+
+// ownedStateFiles lists the state gcloudctx keeps in the home directory
+var ownedStateFiles = []ownedStateFile{
+	{"configuration history", history.GetHistoryFilePath, true},
+	{"last seen active configuration", history.GetLastActiveFilePath, false},
+	{"project history", history.GetProjectHistoryFilePath, true},
+	{"usage history", history.GetUsageFilePath, true},
+	{"configuration notes", notes.GetMetadataFilePath, true},
+	{"activity log", activity.GetLogFilePath, false},
+	{"settings file", settings.GetSettingsFilePath, true},
+	{"in-flight operations", inflight.GetStateDir, false},
+	{"state lock", statelock.GetLockFilePath, false},
+	{"terminal pins", session.GetPinsFilePath, true},
+	{"ADC hints", adc.GetHintsFilePath, true},
+	// Snapshots hold credentials; backups include ADC only with --include-adc
+	{"ADC snapshots", adc.GetSnapshotsDir, false},
+}
+
+// discoverOwnedState collects every artifact gcloudctx owns
+func discoverOwnedState(home string) ([]cleanup.Artifact, error) {
+	statePaths := map[string]string{}
+	for _, entry := range ownedStateFiles {
+		path, err := entry.path()
+		if err != nil {
+			return nil, err
+		}
+		statePaths[path] = entry.description
+	}
+
+	artifacts := cleanup.DiscoverState(statePaths)
+
+	if cacheDir, err := cache.GetCacheDir(); err == nil {
+		artifacts = append(artifacts, cleanup.DiscoverCache(cacheDir)...)
+	}
+
+	hooks, err := cleanup.DiscoverShellHooks(shellrc.DefaultRCFiles(home))
+	if err != nil {
+		return nil, err
+	}
+	artifacts = append(artifacts, hooks...)
+
+	return append(artifacts, cleanup.DiscoverCompletions(home)...), nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUninstallStateListsPastedLines(t *testing.T) {
+	env := newTestEnv(t)
+	zshrc := filepath.Join(env.home, ".zshrc")
+	contents := "alias k=kubectl\neval \"$(gcloudctx widget zsh)\"\n"
+	if err := os.WriteFile(zshrc, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	res := env.mustRun("uninstall-state")
+
+	if !strings.Contains(res.stdout, "Remove by hand") || !strings.Contains(res.stdout, zshrc) || !strings.Contains(res.stdout, "line(s) 2") {
+		t.Errorf("stdout = %q, want line 2 of .zshrc listed for manual removal", res.stdout)
+	}
+	if data, _ := os.ReadFile(zshrc); string(data) != contents {
+		t.Errorf(".zshrc = %q, want it untouched", data)
+	}
+}
+
+func TestUninstallStateTypedConfirmation(t *testing.T) {
+	env := newTestEnv(t)
+	settingsPath := filepath.Join(env.home, ".gcloudctx.yaml")
+	env.writeSettings("protected:\n  - prod\n")
+	env.terminal = false
+	env.tty = &fakeTTY{Reader: strings.NewReader("no\n")}
+
+	// The piped word is not the answer when a terminal can be asked
+	res := env.run("uninstall\n", "uninstall-state", "--dry-run=false")
+	if res.err != nil || !strings.Contains(res.stdout, "Uninstall canceled") {
+		t.Errorf("uninstall-state = %v, stdout %q; want it canceled on the terminal", res.err, res.stdout)
+	}
+	if !strings.Contains(env.tty.questions.String(), `Type "uninstall" to confirm: `) {
+		t.Errorf("terminal = %q, want the question asked there", env.tty.questions.String())
+	}
+	if _, err := os.Stat(settingsPath); err != nil {
+		t.Fatalf("settings file after a canceled uninstall: %v", err)
+	}
+
+	env.tty = nil
+	res = env.run("uninstall\n", "uninstall-state", "--dry-run=false")
+	if res.err != nil {
+		t.Fatalf("uninstall-state with the word piped: %v", res.err)
+	}
+	if _, err := os.Stat(settingsPath); !os.IsNotExist(err) {
+		t.Errorf("settings file after uninstall: %v, want it removed", err)
+	}
+}
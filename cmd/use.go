@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/Okabe-Junya/gcloudctx/internal/output"
 	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
@@ -9,87 +12,150 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var (
-	useLocalFlag  bool
-	useUnsetFlag  bool
-	useSwitchFlag bool
-)
+// useOptions holds the flags of the use command
+type useOptions struct {
+	*options
+	local       bool
+	unset       bool
+	switchAfter bool
+}
 
-var useCmd = &cobra.Command{
-	Use:   "use [configuration-name]",
-	Short: "Set the configuration for the current directory",
-	Long: `Set a gcloud configuration to be used in the current directory.
+func newUseCmd(parent *options) *cobra.Command {
+	o := &useOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "use [configuration-name]",
+		Short: "Set the configuration for the current directory",
+		Long: `Set a gcloud configuration to be used in the current directory.
 
 This creates a .gcloudctx file in the current directory that specifies
 which configuration should be used. When you run 'gcloudctx use --switch'
 or 'gcloudctx auto', it will automatically switch to this configuration.
 
+A warning is printed when the new file hides a .gcloudctx file in a parent
+directory that names a different configuration, or is hidden itself by one in
+a subdirectory.
+
 Examples:
   gcloudctx use my-project          # Set config for current directory
   gcloudctx use my-project --switch # Set and immediately switch
+  gcloudctx use my-project --switch -o json  # Print the switch result as JSON
   gcloudctx use --unset             # Remove the .gcloudctx file
   gcloudctx use                     # Show current directory's config`,
-	Args:              cobra.MaximumNArgs(1),
-	RunE:              runUse,
-	ValidArgsFunction: completeConfigNames,
-}
-
-func init() {
-	useCmd.Flags().BoolVar(&useLocalFlag, "local", true, "Write to the current directory (default)")
-	useCmd.Flags().BoolVar(&useUnsetFlag, "unset", false, "Remove the .gcloudctx file from the current directory")
-	useCmd.Flags().BoolVar(&useSwitchFlag, "switch", false, "Switch to the configuration after setting it")
-	rootCmd.AddCommand(useCmd)
+		Args:              cobra.MaximumNArgs(1),
+		RunE:              o.runUse,
+		ValidArgsFunction: completeConfigNames,
+	}
+	cmd.Flags().BoolVar(&o.local, "local", true, "Write to the current directory (default)")
+	cmd.Flags().BoolVar(&o.unset, "unset", false, "Remove the .gcloudctx file from the current directory")
+	cmd.Flags().BoolVar(&o.switchAfter, "switch", false, "Switch to the configuration after setting it")
+	cmd.Flags().StringVarP(&o.outputFormat, "output", "o", "", "Output format for the switch result (json, yaml; requires --switch)")
+	cmd.Flags().BoolVar(&o.noHooks, "no-hooks", false, "Do not run post-switch hooks (with --switch)")
+	cmd.Flags().BoolVar(&o.noHistory, "no-history", false, "Do not record the switch in the history or usage statistics (with --switch)")
+	cmd.Flags().BoolVar(&o.forceSwitch, "force", false, "Switch even when a pre-switch guard refuses (with --switch)")
+	cmd.Flags().BoolVarP(&o.yes, "yes", "y", false, "Switch to a protected configuration without confirmation (with --switch)")
+	return cmd
 }
 
-func runUse(cmd *cobra.Command, args []string) error {
+func (o *useOptions) runUse(cmd *cobra.Command, args []string) error {
 	// Handle unset flag
-	if useUnsetFlag {
-		return unsetLocalConfig()
+	if o.unset {
+		return o.unsetLocalConfig()
 	}
 
 	// If no arguments, show current local config
 	if len(args) == 0 {
-		return showLocalConfig()
+		return o.showLocalConfig()
 	}
 
 	configName := args[0]
 
-	// Validate configuration name
-	if err := gcloud.ValidateConfigurationName(configName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+	if o.outputFormat != "" {
+		if !o.switchAfter {
+			err := fmt.Errorf("--output requires --switch")
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+		// Send messages to stderr before any are printed
+		if _, _, err := o.switchOutputFormat(); err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+	}
+
+	// Validate configuration name; it only has to name an existing configuration
+	if err := gcloud.ValidateExistingConfigurationName(configName); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
 	// Check if configuration exists
-	if !gcloud.ConfigurationExists(configName) {
-		output.PrintError(fmt.Sprintf("configuration %q does not exist", configName), !noColorFlag)
+	exists, err := gcloud.ConfigurationExists(configName)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if !exists {
+		output.PrintError(fmt.Sprintf("configuration %q does not exist", configName), !o.noColor)
 		return fmt.Errorf("configuration not found")
 	}
 
 	// Write local config
 	if err := local.WriteLocalConfigCurrent(configName); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
 	path, err := local.GetLocalConfigPath()
 	if err != nil {
 		path = local.ConfigFileName
+	} else {
+		rememberJumpDirs(filepath.Dir(path))
 	}
-	output.PrintSuccess(fmt.Sprintf("set local configuration to %q (saved to %s)", configName, path), !noColorFlag)
+	output.PrintSuccess(fmt.Sprintf("set local configuration to %q (saved to %s)", configName, path), !o.noColor)
+	warnShadowing(configName)
 
 	// Switch if requested
-	if useSwitchFlag {
-		return switchConfiguration(configName)
+	if o.switchAfter {
+		return o.switchConfiguration(configName)
 	}
 
 	return nil
 }
 
-func showLocalConfig() error {
+// warnShadowing warns when the .gcloudctx file just written for configName
+// hides files farther up the tree, or is itself hidden in subdirectories
+func warnShadowing(configName string) {
+	if matches, err := local.FindLocalConfigs(); err == nil {
+		_, shadowed := local.ClassifyShadowing(matches)
+		for _, s := range local.Disagreeing(shadowed) {
+			fmt.Fprintf(os.Stderr, "Warning: this file shadows %s for this directory\n", describeShadowed(s))
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	nested, err := local.FindNestedConfigs(cwd, local.MaxNestedSearchDirs)
+	for _, match := range nested {
+		// Files that only set a project leave the configuration alone
+		if match.Err != nil || (match.Config != "" && match.Config != configName) {
+			fmt.Fprintf(os.Stderr, "Warning: %s takes precedence over this file in %s and below\n", describeShadowed(local.Shadowed{Match: match}), match.Dir)
+		}
+	}
+	if errors.Is(err, local.ErrNestedSearchTruncated) {
+		fmt.Fprintf(os.Stderr, "Warning: stopped looking for nested %s files after %d directories\n", local.ConfigFileName, local.MaxNestedSearchDirs)
+	}
+}
+
+func (o *options) showLocalConfig() error {
 	configName, dir, err := local.FindLocalConfig()
+	if errors.Is(err, local.ErrNoLocalConfig) {
+		output.PrintError("no local configuration found in current directory or parent directories", !o.noColor)
+		return err
+	}
 	if err != nil {
-		output.PrintError("no local configuration found in current directory or parent directories", !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
@@ -98,17 +164,21 @@ func showLocalConfig() error {
 	return nil
 }
 
-func unsetLocalConfig() error {
+func (o *options) unsetLocalConfig() error {
 	if !local.ConfigExists() {
-		output.PrintError("no .gcloudctx file in current directory", !noColorFlag)
+		output.PrintError("no .gcloudctx file in current directory", !o.noColor)
 		return fmt.Errorf("no local config")
 	}
 
 	if err := local.RemoveLocalConfigCurrent(); err != nil {
-		output.PrintError(err.Error(), !noColorFlag)
+		output.PrintError(err.Error(), !o.noColor)
 		return err
 	}
 
-	output.PrintSuccess("removed .gcloudctx file from current directory", !noColorFlag)
+	if cwd, err := os.Getwd(); err == nil {
+		forgetJumpDir(cwd)
+	}
+
+	output.PrintSuccess("removed .gcloudctx file from current directory", !o.noColor)
 	return nil
 }
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/local"
+)
+
+func TestUse(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("use", "prod")
+
+	data, err := os.ReadFile(filepath.Join(env.workDir, local.ConfigFileName))
+	if err != nil || strings.TrimSpace(string(data)) != "prod" {
+		t.Errorf(".gcloudctx = %q, %v; want prod", data, err)
+	}
+	if !strings.Contains(res.stdout, `set local configuration to "prod"`) {
+		t.Errorf("stdout = %q, want the file reported", res.stdout)
+	}
+	if env.gcloud.Active() != "dev" {
+		t.Errorf("active = %q, want dev without --switch", env.gcloud.Active())
+	}
+}
+
+func TestUseSwitch(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.mustRun("use", "prod", "--switch")
+
+	if env.gcloud.Active() != "prod" {
+		t.Errorf("active = %q, want prod", env.gcloud.Active())
+	}
+}
+
+func TestUseShowAndUnset(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("use", "prod")
+
+	if res := env.mustRun("use"); !strings.Contains(res.stdout, "prod") {
+		t.Errorf("use without arguments printed %q, want prod", res.stdout)
+	}
+
+	env.mustRun("use", "--unset")
+	if _, err := os.Stat(filepath.Join(env.workDir, local.ConfigFileName)); !os.IsNotExist(err) {
+		t.Errorf(".gcloudctx still exists after --unset: %v", err)
+	}
+}
+
+func TestUseMissingConfiguration(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "use", "staging")
+
+	if res.err == nil || !strings.Contains(res.stdout, `configuration "staging" does not exist`) {
+		t.Errorf("use of a missing configuration = %v, stdout %q; want an error", res.err, res.stdout)
+	}
+	if _, err := os.Stat(filepath.Join(env.workDir, local.ConfigFileName)); !os.IsNotExist(err) {
+		t.Error(".gcloudctx was written for a missing configuration")
+	}
+}
+
+func TestUseListFailure(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Fail("config configurations list", "ERROR: (gcloud.config.configurations.list) Unable to read the configurations directory.")
+
+	res := env.run("", "use", "prod")
+
+	if res.err == nil {
+		t.Fatal("use succeeded although gcloud failed")
+	}
+	if strings.Contains(res.stdout, "does not exist") || !strings.Contains(res.stdout, "Unable to read the configurations directory") {
+		t.Errorf("stdout = %q, want gcloud's failure instead of a missing configuration", res.stdout)
+	}
+	if _, err := os.Stat(filepath.Join(env.workDir, local.ConfigFileName)); !os.IsNotExist(err) {
+		t.Error(".gcloudctx was written although the configuration could not be checked")
+	}
+}
+
+func TestUseShadowWarning(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeFile(local.ConfigFileName, "dev\n")
+	nested := filepath.Join(env.workDir, "service")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(nested)
+
+	res := env.mustRun("use", "prod")
+
+	if !strings.Contains(res.stderr, "shadows") {
+		t.Errorf("stderr = %q, want the shadowed file reported", res.stderr)
+	}
+}
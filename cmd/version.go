@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/internal/update"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/settings"
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+// versionOptions holds the flags of the version command
+type versionOptions struct {
+	*options
+	format        string
+	includeGcloud bool
+}
+
+func newVersionCmd(parent *options) *cobra.Command {
+	o := &versionOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Show the gcloudctx version and build information",
+		Long: `Show the gcloudctx version, the commit and date it was built from, the Go
+version and the platform. Unlike --version, -o json and -o yaml print them
+as a document for scripts.
+
+With --include-gcloud the Google Cloud SDK version is added, which runs
+'gcloud version'.
+
+Examples:
+  gcloudctx version
+  gcloudctx version -o json
+  gcloudctx version --include-gcloud`,
+		Args: cobra.NoArgs,
+		RunE: o.runVersion,
+	}
+	cmd.Flags().StringVarP(&o.format, "output", "o", "", "Output format (json, yaml)")
+	cmd.Flags().BoolVar(&o.includeGcloud, "include-gcloud", false, "Include the Google Cloud SDK version")
+	return cmd
+}
+
+// currentVersionInfo describes the running build; ldflags set the version,
+// commit and date of releases
+func currentVersionInfo() output.VersionInfo {
+	return output.VersionInfo{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+}
+
+func (o *versionOptions) runVersion(cmd *cobra.Command, args []string) error {
+	format, err := output.ValidateOutputFormat(o.format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	info := currentVersionInfo()
+	if o.includeGcloud {
+		info.GcloudVersion, err = gcloud.SDKVersion()
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+	}
+
+	if output.IsMachineFormat(format) {
+		return output.PrintVersionInfo(info, format)
+	}
+
+	rows := [][]string{
+		{"Version:", info.Version},
+		{"Commit:", info.Commit},
+		{"Built:", info.Date},
+		{"Go:", info.GoVersion},
+		{"Platform:", info.OS + "/" + info.Arch},
+	}
+	if info.GcloudVersion != "" {
+		rows = append(rows, []string{"gcloud:", info.GcloudVersion})
+	}
+	for _, line := range output.AlignColumns(rows, 1) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// versionRequested reports whether the root command just printed its version
+// cobra handles --version before any hook runs, so Execute asks afterwards
+func versionRequested(root *cobra.Command) bool {
+	flag := root.Flags().Lookup("version")
+	return flag != nil && flag.Changed
+}
+
+// updateChecker looks up the latest release; tests point it at a local server
+var updateChecker = &update.Checker{}
+
+// updateCheckDisabled reports whether the user turned the update check off
+// Settings are read here because --version skips initialization
+func updateCheckDisabled() bool {
+	if os.Getenv(update.EnvDisable) == "1" {
+		return true
+	}
+	userSettings, err := settings.Load()
+	return err == nil && userSettings.DisableUpdateCheck
+}
+
+// printUpdateNotice tells the user on stderr when a newer release is available
+// The check is skipped when disabled, offline or when stderr is not a terminal,
+// and any failure is ignored: it must never change the exit code or the version output
+func printUpdateNotice(useColor, offline bool) {
+	if !isatty.IsTerminal(os.Stderr.Fd()) && !isatty.IsCygwinTerminal(os.Stderr.Fd()) {
+		return
+	}
+	if updateCheckDisabled() {
+		return
+	}
+	if offline {
+		fmt.Fprintln(os.Stderr, "Update check (skipped: offline)")
+		return
+	}
+
+	release, err := updateChecker.LatestCached(context.Background())
+	if err != nil || !update.IsNewer(Version, release.Version) {
+		return
+	}
+
+	if !useColor {
+		color.NoColor = true
+	}
+	yellow := color.New(color.FgYellow).SprintFunc()
+	fmt.Fprintln(os.Stderr, yellow(fmt.Sprintf("A newer version %s is available (you have %s)", release.Version, Version)))
+	fmt.Fprintln(os.Stderr, yellow("To upgrade: "+update.UpgradeHint(installMethod(), release)))
+}
+
+// installMethod guesses how the running binary was installed
+func installMethod() update.InstallMethod {
+	executable, err := os.Executable()
+	if err != nil {
+		return update.InstallManual
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	goBinDirs := []string{os.Getenv("GOBIN")}
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		goBinDirs = append(goBinDirs, filepath.Join(gopath, "bin"))
+	}
+	return update.DetectInstallMethod(executable, goBinDirs)
+}
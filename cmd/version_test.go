@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestVersionJSON(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("version", "-o", "json")
+
+	var fields map[string]string
+	if err := json.Unmarshal([]byte(res.stdout), &fields); err != nil {
+		t.Fatalf("stdout is not a version document: %v\n%s", err, res.stdout)
+	}
+	// Without ldflags the build reports the defaults
+	want := map[string]string{
+		"version":    "dev",
+		"commit":     "none",
+		"date":       "unknown",
+		"go_version": runtime.Version(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+	}
+	for key, value := range want {
+		if got, ok := fields[key]; !ok || got != value {
+			t.Errorf("%s = %q (present: %v), want %q", key, got, ok, value)
+		}
+	}
+	if _, ok := fields["gcloud_version"]; ok {
+		t.Errorf("gcloud_version is present without --include-gcloud: %s", res.stdout)
+	}
+	if calls := env.gcloud.Calls(); len(calls) != 0 {
+		t.Errorf("version ran gcloud: %q", calls)
+	}
+}
+
+func TestVersionIncludeGcloud(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("version", "--include-gcloud", "-o", "yaml")
+
+	var fields map[string]string
+	if err := yaml.Unmarshal([]byte(res.stdout), &fields); err != nil {
+		t.Fatalf("stdout is not a version document: %v\n%s", err, res.stdout)
+	}
+	if fields["gcloud_version"] != "999.0.0" || fields["version"] != "dev" {
+		t.Errorf("version document = %v, want the SDK version of the fake gcloud", fields)
+	}
+}
+
+func TestVersionText(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("version")
+	for _, want := range []string{"Version:  dev", "Go:       " + runtime.Version(), runtime.GOOS + "/" + runtime.GOARCH} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("stdout = %q, want it to contain %q", res.stdout, want)
+		}
+	}
+
+	// The flag shares the rendering with the subcommand
+	if res := env.mustRun("--version"); !strings.Contains(res.stdout, "version dev") {
+		t.Errorf("--version printed %q, want the version", res.stdout)
+	}
+}
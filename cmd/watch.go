@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/interactive"
+	"github.com/Okabe-Junya/gcloudctx/pkg/local"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+// Terminal control sequences used to redraw the watch screen in place
+const (
+	clearScreen = "\033[H\033[2J"
+	hideCursor  = "\033[?25l"
+	showCursor  = "\033[?25h"
+)
+
+// errWatchNeedsTerminal is returned when watch would print frames into a pipe or file
+var errWatchNeedsTerminal = errors.New("watch needs a terminal; use 'gcloudctx status' in scripts")
+
+type watchOptions struct {
+	*options
+	interval time.Duration
+}
+
+func newWatchCmd(parent *options) *cobra.Command {
+	o := &watchOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Keep the status of the active context on screen while it changes",
+		Long: `Show 'gcloudctx status' and redraw it in place until Ctrl+C.
+
+The screen is redrawn as soon as the active configuration, a configuration
+file, ADC or a .gcloudctx file of the current directory changes, and every
+--interval otherwise. Where file notifications are unavailable, --interval is
+how often changes are picked up.
+
+Examples:
+  gcloudctx watch
+  gcloudctx watch --interval 5s`,
+		Args: cobra.NoArgs,
+		RunE: o.runWatch,
+	}
+	cmd.Flags().DurationVar(&o.interval, "interval", 2*time.Second, "How often to redraw the status")
+	return cmd
+}
+
+func (o *watchOptions) runWatch(cmd *cobra.Command, args []string) error {
+	if o.interval <= 0 {
+		err := fmt.Errorf("--interval must be positive, got %s", o.interval)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if !interactive.IsTerminal() {
+		output.PrintError(errWatchNeedsTerminal.Error(), !o.noColor)
+		return errWatchNeedsTerminal
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	changes, closeWatcher := watchStatusFiles()
+	defer closeWatcher()
+
+	return watchLoop(ctx, os.Stdout, o.interval, changes, func(w io.Writer) {
+		fmt.Fprintf(w, "Every %s: gcloudctx status (Ctrl+C to quit)  %s\n\n", o.interval, time.Now().Format("15:04:05"))
+		status, err := collectStatus()
+		if err != nil {
+			fmt.Fprintf(w, "Error: %v\n", withBootstrapHint(err))
+			return
+		}
+		renderStatus(w, status)
+	})
+}
+
+// watchLoop draws a frame with draw, then again on every tick of interval and
+// every change, until ctx is done. The cursor is hidden meanwhile and restored
+// on return, leaving the last frame on screen.
+func watchLoop(ctx context.Context, w io.Writer, interval time.Duration, changes <-chan struct{}, draw func(io.Writer)) error {
+	fmt.Fprint(w, hideCursor)
+	defer fmt.Fprint(w, showCursor)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Each frame is written at once so the screen does not flicker
+		var frame bytes.Buffer
+		frame.WriteString(clearScreen)
+		draw(&frame)
+		if _, err := w.Write(frame.Bytes()); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-changes:
+		}
+	}
+}
+
+// watchStatusFiles notifies of changes to the files the status is read from:
+// active_config, the configuration files, ADC and the .gcloudctx files of the
+// current directory and its parents. A change to several files at once is
+// delivered as one notification. Without file notifications the channel never
+// delivers, leaving the redraws to the interval.
+func watchStatusFiles() (changes <-chan struct{}, closeWatcher func()) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, func() {}
+	}
+
+	// dirs maps each watched directory to the file names in it that matter, nil for all
+	dirs := map[string][]string{}
+	if configDir, err := gcloud.ConfigDir(); err == nil {
+		dirs[configDir] = []string{gcloud.ActiveConfigFileName, gcloud.ADCFileName}
+		dirs[filepath.Join(configDir, "configurations")] = nil
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		for dir := cwd; ; dir = filepath.Dir(dir) {
+			dirs[dir] = append(dirs[dir], local.ConfigFileName)
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	for dir := range dirs {
+		// A directory that does not exist yet is not watched; the interval covers it
+		if err := watcher.Add(dir); err != nil {
+			delete(dirs, dir)
+		}
+	}
+
+	notify := make(chan struct{}, 1)
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				names, watched := dirs[filepath.Dir(event.Name)]
+				if !watched || (names != nil && !slices.Contains(names, filepath.Base(event.Name))) {
+					continue
+				}
+				select {
+				case notify <- struct{}{}:
+				default:
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return notify, func() {
+		if err := watcher.Close(); err != nil {
+			// Non-fatal error, just warn
+			fmt.Fprintf(os.Stderr, "Warning: failed to stop watching files: %v\n", err)
+		}
+	}
+}
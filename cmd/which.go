@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/spf13/cobra"
+)
+
+// whichOptions holds the flags of the which command
+type whichOptions struct {
+	*options
+	format  string
+	project string
+	account string
+}
+
+func newWhichCmd(parent *options) *cobra.Command {
+	o := &whichOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "which",
+		Short: "Find the configurations using a project or account",
+		Long: `Find the configurations whose project or account matches.
+
+Values match exactly, ignoring case; when no configuration matches exactly,
+those starting with the value are shown instead. Given both --project and
+--account, a configuration must match both. Nothing matching is an error, so
+the command exits with status 1.
+
+Examples:
+  gcloudctx which --project my-project-id
+  gcloudctx which --account me@example.com
+  gcloudctx which --project my-proj          # Prefix match
+  gcloudctx which --project my-project-id -o name
+
+  # Switch to the configuration using a project
+  # gcloudctx "$(gcloudctx which --project my-project-id -o name)"`,
+		Args: cobra.NoArgs,
+		RunE: o.runWhich,
+	}
+	cmd.Flags().StringVar(&o.project, "project", "", "Project ID to look for")
+	cmd.Flags().StringVar(&o.account, "account", "", "Account to look for")
+	cmd.Flags().StringVarP(&o.format, "output", "o", "", "Output format (json, yaml, wide, name)")
+	return cmd
+}
+
+func (o *whichOptions) runWhich(cmd *cobra.Command, args []string) error {
+	format, err := output.ValidateOutputFormat(o.format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if format != output.FormatDefault {
+		// Stdout carries only the matches, e.g. for $(gcloudctx which -o name)
+		output.SetMessageOutput(os.Stderr)
+	}
+
+	if o.project == "" && o.account == "" {
+		err := errors.New("pass --project or --account to look for")
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	stop := output.StartSpinner(querySpinnerMessage)
+	configs, err := gcloud.ListConfigurations()
+	stop()
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	matches := matchConfigurations(configs, o.project, o.account)
+	if len(matches) == 0 {
+		err := fmt.Errorf("no configuration uses %s", o.describeQuery())
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	loadLastUsed()
+	return output.PrintConfigurationsWithFormat(matches, format, output.DefaultColumns(), !o.noColor)
+}
+
+// describeQuery describes what the which command looks for
+func (o *whichOptions) describeQuery() string {
+	var parts []string
+	if o.project != "" {
+		parts = append(parts, fmt.Sprintf("project %q", o.project))
+	}
+	if o.account != "" {
+		parts = append(parts, fmt.Sprintf("account %q", o.account))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// matchConfigurations returns the configurations whose project and account
+// match exactly, or else those starting with the given values, ignoring case
+// An empty project or account matches any configuration.
+func matchConfigurations(configs []gcloud.Configuration, project, account string) []gcloud.Configuration {
+	matches := func(value, want string, prefix bool) bool {
+		if want == "" {
+			return true
+		}
+		if prefix {
+			return strings.HasPrefix(strings.ToLower(value), strings.ToLower(want))
+		}
+		return strings.EqualFold(value, want)
+	}
+
+	for _, prefix := range []bool{false, true} {
+		var found []gcloud.Configuration
+		for _, config := range configs {
+			core := config.Properties.Core
+			if matches(core.Project, project, prefix) && matches(core.Account, account, prefix) {
+				found = append(found, config)
+			}
+		}
+		if len(found) > 0 {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWhich(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("staging", map[string]string{"core/project": "prod-project-staging", "core/account": "ops@example.com"})
+
+	if res := env.mustRun("which", "--project", "prod-project", "-o", "name"); res.stdout != "prod\n" {
+		t.Errorf("which --project prod-project = %q, want only the exact match", res.stdout)
+	}
+	if res := env.mustRun("which", "--project", "PROD-PROJECT-S", "-o", "name"); res.stdout != "staging\n" {
+		t.Errorf("which --project PROD-PROJECT-S = %q, want the prefix match", res.stdout)
+	}
+	if res := env.mustRun("which", "--account", "ops@example.com", "-o", "name"); res.stdout != "prod\nstaging\n" {
+		t.Errorf("which --account = %q, want both configurations", res.stdout)
+	}
+	if res := env.mustRun("which", "--account", "ops@", "--project", "prod-project-s", "-o", "name"); res.stdout != "staging\n" {
+		t.Errorf("which --account --project = %q, want configurations matching both", res.stdout)
+	}
+	if res := env.mustRun("which", "--project", "dev-project"); !strings.Contains(res.stdout, "dev") {
+		t.Errorf("which --project dev-project = %q, want dev listed", res.stdout)
+	}
+}
+
+func TestWhichNoMatch(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.run("", "which", "--project", "missing", "-o", "name")
+	if res.err == nil || res.stdout != "" || !strings.Contains(res.stderr, `project "missing"`) {
+		t.Errorf("which --project missing = %q, %q, %v; want an error on stderr only", res.stdout, res.stderr, res.err)
+	}
+	if res := env.run("", "which"); res.err == nil {
+		t.Error("which without flags succeeded, want an error")
+	}
+}
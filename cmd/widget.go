@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/widgetgen"
+	"github.com/spf13/cobra"
+)
+
+// widgetOptions holds the flags of the widget command
+type widgetOptions struct {
+	*options
+	key string
+}
+
+func newWidgetCmd(parent *options) *cobra.Command {
+	o := &widgetOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "widget zsh|bash",
+		Short: "Print a key binding that opens the picker without leaving the command line",
+		Long: `Print a zsh widget or bash key binding that opens the configuration picker
+when you press a key, ctrl-g unless --key says otherwise.
+
+The picker is drawn with fzf below the prompt, so whatever you were typing
+stays on the command line. Canceling prints nothing; switching prints a single
+line under the prompt, which is then redrawn. fzf is required.
+
+--key takes ctrl-X or alt-X for a letter X.
+
+Examples:
+  gcloudctx widget zsh               # Print the zsh widget bound to ctrl-g
+  gcloudctx widget bash --key alt-g  # Print the bash binding for alt-g
+  # Load it from ~/.zshrc or ~/.bashrc
+  eval "$(gcloudctx widget zsh)"`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{string(widgetgen.Zsh), string(widgetgen.Bash)},
+		RunE:      o.runWidget,
+	}
+	cmd.Flags().StringVar(&o.key, "key", widgetgen.DefaultKey, "Key opening the picker (ctrl-X or alt-X)")
+	return cmd
+}
+
+func (o *widgetOptions) runWidget(cmd *cobra.Command, args []string) error {
+	script, err := widgetgen.Generate(widgetgen.Shell(args[0]), widgetgen.Options{Key: o.key})
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/interactive"
+)
+
+// installFakeFzf puts an fzf on PATH that selects the line of the named
+// configuration, or is canceled like with ESC when pick is empty
+func installFakeFzf(t *testing.T, pick string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake fzf is a shell script")
+	}
+	t.Setenv("TMUX", "")
+	t.Setenv(interactive.EnvFzfTmux, "0")
+
+	choose := "exit 130"
+	if pick != "" {
+		// PATH holds only the stubs, so no grep
+		choose = "while IFS= read -r line; do case \"$line\" in \"" + pick + "\t\"*) echo \"$line\";; esac; done"
+	}
+	script := "#!/bin/sh\nif [ \"$1\" = --version ]; then echo '0.50.0 (brew)'; exit 0; fi\n" + choose + "\n"
+	bin := strings.Split(os.Getenv("PATH"), string(os.PathListSeparator))[0]
+	if err := os.WriteFile(filepath.Join(bin, "fzf"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWidgetSwitch(t *testing.T) {
+	env := newTestEnv(t)
+	installFakeFzf(t, "prod")
+
+	res := env.mustRun("--widget", "--show-diff")
+	if env.gcloud.Active() != "prod" {
+		t.Errorf("active = %q, want prod", env.gcloud.Active())
+	}
+	if res.stdout != "Success: switched to configuration \"prod\"\n" || res.stderr != "" {
+		t.Errorf("--widget printed %q, %q; want only the switch line", res.stdout, res.stderr)
+	}
+}
+
+func TestWidgetCancel(t *testing.T) {
+	env := newTestEnv(t)
+	installFakeFzf(t, "")
+
+	res := env.mustRun("--widget")
+	if env.gcloud.Active() != "dev" || res.stdout != "" || res.stderr != "" {
+		t.Errorf("canceled --widget = %q, %q, active %q; want nothing printed or changed", res.stdout, res.stderr, env.gcloud.Active())
+	}
+}
+
+func TestWidgetRejectsArgs(t *testing.T) {
+	env := newTestEnv(t)
+
+	if res := env.run("", "--widget", "prod"); res.err == nil {
+		t.Error("--widget prod succeeded, want an error")
+	}
+	if res := env.run("", "--widget", "-l"); res.err == nil {
+		t.Error("--widget -l succeeded, want an error")
+	}
+}
+
+func TestWidgetCommand(t *testing.T) {
+	env := newTestEnv(t)
+
+	res := env.mustRun("widget", "zsh")
+	if !strings.Contains(res.stdout, "gcloudctx --widget") || !strings.Contains(res.stdout, "bindkey '^G'") {
+		t.Errorf("widget zsh = %q, want a ctrl-g binding running --widget", res.stdout)
+	}
+	res = env.mustRun("widget", "bash", "--key", "alt-g")
+	if !strings.Contains(res.stdout, `bind -x '"\eg"`) {
+		t.Errorf("widget bash --key alt-g = %q, want an alt-g binding", res.stdout)
+	}
+	if res := env.run("", "widget", "zsh", "--key", "f5"); res.err == nil {
+		t.Error("widget --key f5 succeeded, want an error")
+	}
+	if res := env.run("", "widget", "fish"); res.err == nil {
+		t.Error("widget fish succeeded, want an error")
+	}
+}
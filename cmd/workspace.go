@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/settings"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statedir"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// workspaceListOptions holds the flags of the workspace list command
+type workspaceListOptions struct {
+	*options
+	format string
+}
+
+func newWorkspaceCmd(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workspace",
+		Short: "Save and switch to configurations with a project and region",
+		Long: `A workspace is a configuration together with a project and region to apply
+on top of it, for clients that share a configuration but not a project.
+
+'workspace save' records the active configuration with its current project and
+compute/region. 'workspace use' switches to the configuration as a normal
+switch does (so 'gcloudctx -' goes back) and then sets the project and region,
+remembering the previous project for 'gcloudctx project -'.
+
+Workspaces are kept under "workspaces" in ~/.gcloudctx.yaml.
+
+Examples:
+  gcloudctx workspace save acme      # Save the active configuration, project and region
+  gcloudctx workspace use acme       # Switch to it again later
+  gcloudctx workspace list           # List workspaces, flagging deleted configurations
+  gcloudctx workspace delete acme    # Forget a workspace`,
+	}
+	cmd.AddCommand(
+		newWorkspaceDeleteCmd(o),
+		newWorkspaceListCmd(o),
+		newWorkspaceSaveCmd(o),
+		newWorkspaceUseCmd(o),
+	)
+	return cmd
+}
+
+func newWorkspaceSaveCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "save <name>",
+		Short: "Save the active configuration, project and region as a workspace",
+		Args:  cobra.ExactArgs(1),
+		RunE:  o.runWorkspaceSave,
+	}
+}
+
+func newWorkspaceUseCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:               "use <name>",
+		Short:             "Switch to a workspace's configuration and apply its project and region",
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.runWorkspaceUse,
+		ValidArgsFunction: completeWorkspaceNames,
+	}
+}
+
+func newWorkspaceListCmd(parent *options) *cobra.Command {
+	o := &workspaceListOptions{options: parent}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List saved workspaces",
+		Args:  cobra.NoArgs,
+		RunE:  o.runWorkspaceList,
+	}
+	cmd.Flags().StringVarP(&o.format, "output", "o", "", "Output format (json, yaml)")
+	return cmd
+}
+
+func newWorkspaceDeleteCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete <name>",
+		Short:             "Delete a saved workspace",
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.runWorkspaceDelete,
+		ValidArgsFunction: completeWorkspaceNames,
+	}
+}
+
+func (o *options) runWorkspaceSave(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := settings.ValidateWorkspaceName(name); err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	activeConfig, err := gcloud.GetActiveConfiguration()
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	workspace := settings.Workspace{
+		Configuration: activeConfig.Name,
+		Project:       activeConfig.Properties.Core.Project,
+		Region:        activeConfig.Properties.Compute.Region,
+	}
+	replaced, err := settings.SaveWorkspace(name, workspace)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	verb := "saved"
+	if replaced {
+		verb = "updated"
+	}
+	output.PrintSuccess(fmt.Sprintf("%s workspace %q (%s)", verb, name, describeWorkspace(workspace)), !o.noColor)
+	return nil
+}
+
+func (o *options) runWorkspaceUse(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	workspace, ok := o.workspaces[name]
+	if !ok {
+		err := fmt.Errorf("workspace %q not found", name)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	exists, err := gcloud.ConfigurationExists(workspace.Configuration)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if !exists {
+		err := fmt.Errorf("workspace %q uses configuration %q, which no longer exists (save the workspace again or delete it)", name, workspace.Configuration)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	if err := o.switchConfiguration(workspace.Configuration); err != nil {
+		return err
+	}
+
+	// A declined protected switch leaves another configuration active, whose
+	// project and region must stay as they are
+	activeConfig, err := gcloud.GetActiveConfiguration()
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if activeConfig.Name != workspace.Configuration {
+		return nil
+	}
+
+	var changes []gcloud.PropertySetting
+	currentProject := activeConfig.Properties.Core.Project
+	if workspace.Project != "" && workspace.Project != currentProject {
+		changes = append(changes, gcloud.PropertySetting{Property: "core/project", Value: workspace.Project})
+		if currentProject != "" {
+			// Remember the current project so 'gcloudctx project -' can flip back
+			if err := history.SavePreviousProject(activeConfig.Name, currentProject); err != nil {
+				// Non-fatal error, just warn
+				statedir.Warn(os.Stderr, fmt.Errorf("failed to save project history: %w", err))
+			}
+		}
+	}
+	if workspace.Region != "" && workspace.Region != activeConfig.Properties.Compute.Region {
+		changes = append(changes, gcloud.PropertySetting{Property: "compute/region", Value: workspace.Region})
+	}
+
+	if len(changes) > 0 {
+		stop := output.StartSpinner("Applying workspace...")
+		err = gcloud.SetProperties(activeConfig.Name, changes)
+		stop()
+		if err != nil {
+			output.PrintError(err.Error(), !o.noColor)
+			return err
+		}
+	}
+
+	output.PrintSuccess(fmt.Sprintf("using workspace %q (%s)", name, describeWorkspace(workspace)), !o.noColor)
+	return nil
+}
+
+func (o *workspaceListOptions) runWorkspaceList(cmd *cobra.Command, args []string) error {
+	format, err := output.ValidateOutputFormat(o.format)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	stop := output.StartSpinner(querySpinnerMessage)
+	configs, err := gcloud.ListConfigurations()
+	stop()
+	if err != nil {
+		err = withBootstrapHint(err)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	configNames := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		configNames[config.Name] = true
+	}
+
+	names := slices.Sorted(maps.Keys(o.workspaces))
+	if output.IsMachineFormat(format) {
+		results := make([]output.WorkspaceOutput, len(names))
+		for i, name := range names {
+			workspace := o.workspaces[name]
+			results[i] = output.WorkspaceOutput{
+				Name:                name,
+				Configuration:       workspace.Configuration,
+				Project:             workspace.Project,
+				Region:              workspace.Region,
+				ConfigurationExists: configNames[workspace.Configuration],
+			}
+		}
+		return output.PrintWorkspaces(results, format)
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No workspaces; save one with 'gcloudctx workspace save <name>'")
+		return nil
+	}
+
+	if o.noColor {
+		color.NoColor = true
+	}
+	bold := color.New(color.Bold).SprintFunc()
+	gray := color.New(color.FgHiBlack).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	orEmpty := func(value string) string {
+		if value == "" {
+			return gray("-")
+		}
+		return value
+	}
+
+	rows := [][]string{{bold("WORKSPACE"), bold("CONFIGURATION"), bold("PROJECT"), bold("REGION")}}
+	for _, name := range names {
+		workspace := o.workspaces[name]
+		configuration := workspace.Configuration
+		if !configNames[configuration] {
+			configuration = red(configuration + " (deleted)")
+		}
+		rows = append(rows, []string{name, configuration, orEmpty(workspace.Project), orEmpty(workspace.Region)})
+	}
+	for _, line := range output.AlignColumns(rows, 2) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func (o *options) runWorkspaceDelete(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	changed, err := settings.DeleteWorkspace(name)
+	if err != nil {
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+	if !changed {
+		err := fmt.Errorf("workspace %q not found", name)
+		output.PrintError(err.Error(), !o.noColor)
+		return err
+	}
+
+	output.PrintSuccess(fmt.Sprintf("deleted workspace %q", name), !o.noColor)
+	return nil
+}
+
+// describeWorkspace summarizes a workspace as "configuration X, project Y, region Z"
+func describeWorkspace(workspace settings.Workspace) string {
+	description := fmt.Sprintf("configuration %q", workspace.Configuration)
+	if workspace.Project != "" {
+		description += fmt.Sprintf(", project %q", workspace.Project)
+	}
+	if workspace.Region != "" {
+		description += fmt.Sprintf(", region %q", workspace.Region)
+	}
+	return description
+}
+
+// completeWorkspaceNames completes the names of saved workspaces
+func completeWorkspaceNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	userSettings, err := settings.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return slices.Sorted(maps.Keys(userSettings.Workspaces)), cobra.ShellCompDirectiveNoFileComp
+}
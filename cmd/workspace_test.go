@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+)
+
+func TestWorkspaceSaveAndUse(t *testing.T) {
+	env := newTestEnv(t)
+	env.gcloud.Add("dev", map[string]string{"core/project": "acme-project", "core/account": "dev@example.com", "compute/region": "europe-west1"})
+	env.gcloud.Activate("dev")
+
+	res := env.mustRun("workspace", "save", "acme")
+	if !strings.Contains(res.stdout, `saved workspace "acme" (configuration "dev", project "acme-project", region "europe-west1")`) {
+		t.Errorf("stdout = %q, want the workspace described", res.stdout)
+	}
+
+	// Drift away from the workspace, then come back to it
+	env.mustRun("project", "other-project")
+	env.mustRun("prod")
+
+	res = env.mustRun("workspace", "use", "acme")
+	if active := env.gcloud.Active(); active != "dev" {
+		t.Fatalf("active = %q, want dev", active)
+	}
+	properties, _ := env.gcloud.Properties("dev")
+	if properties["core/project"] != "acme-project" || properties["compute/region"] != "europe-west1" {
+		t.Errorf("dev properties = %v, want the workspace's project and region", properties)
+	}
+	if !strings.Contains(res.stdout, `using workspace "acme"`) {
+		t.Errorf("stdout = %q, want the workspace reported", res.stdout)
+	}
+
+	// The switch was recorded, and so was the project it replaced
+	env.mustRun("project", "-")
+	if properties, _ := env.gcloud.Properties("dev"); properties["core/project"] != "other-project" {
+		t.Errorf("project after 'project -' = %q, want other-project", properties["core/project"])
+	}
+	env.mustRun("-")
+	if active := env.gcloud.Active(); active != "prod" {
+		t.Errorf("active after '-' = %q, want prod", active)
+	}
+}
+
+func TestWorkspaceUseMissing(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeSettings("workspaces:\n  gone:\n    configuration: staging\n    project: staging-project\n")
+
+	res := env.run("", "workspace", "use", "gone")
+	if res.err == nil || !strings.Contains(res.err.Error(), `configuration "staging", which no longer exists`) {
+		t.Errorf("err = %v, want the deleted configuration reported", res.err)
+	}
+	res = env.run("", "workspace", "use", "unknown")
+	if res.err == nil || !strings.Contains(res.err.Error(), `workspace "unknown" not found`) {
+		t.Errorf("err = %v, want the workspace reported missing", res.err)
+	}
+
+	if active := env.gcloud.Active(); active != "dev" {
+		t.Errorf("active = %q, want dev left active", active)
+	}
+	if properties, _ := env.gcloud.Properties("dev"); properties["core/project"] != "dev-project" {
+		t.Errorf("dev project = %q, want it untouched", properties["core/project"])
+	}
+}
+
+func TestWorkspaceList(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeSettings("workspaces:\n  acme:\n    configuration: dev\n    project: acme-project\n  gone:\n    configuration: staging\n")
+
+	res := env.mustRun("workspace", "list")
+	lines := strings.Split(strings.TrimSpace(res.stdout), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("stdout = %q, want a header and two workspaces", res.stdout)
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "acme dev acme-project -" {
+		t.Errorf("acme line = %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "staging (deleted)") {
+		t.Errorf("gone line = %q, want the deleted configuration flagged", lines[2])
+	}
+
+	res = env.mustRun("workspace", "list", "-o", "json")
+	var workspaces []output.WorkspaceOutput
+	if err := json.Unmarshal([]byte(res.stdout), &workspaces); err != nil {
+		t.Fatalf("invalid JSON %q: %v", res.stdout, err)
+	}
+	if len(workspaces) != 2 || !workspaces[0].ConfigurationExists || workspaces[1].ConfigurationExists {
+		t.Errorf("workspaces = %+v, want acme existing and gone flagged", workspaces)
+	}
+}
+
+func TestWorkspaceDelete(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustRun("workspace", "save", "acme")
+
+	env.mustRun("workspace", "delete", "acme")
+	if res := env.mustRun("workspace", "list"); !strings.Contains(res.stdout, "No workspaces") {
+		t.Errorf("stdout = %q, want no workspaces left", res.stdout)
+	}
+	if res := env.run("", "workspace", "delete", "acme"); res.err == nil {
+		t.Error("deleting a missing workspace succeeded")
+	}
+}
@@ -0,0 +1,86 @@
+// Package apitest records the exported API of a package in a golden file, so
+// tests catch breaking changes to surfaces that are documented as stable.
+//
+// A package lists its stable functions, types, and values in an API, and its
+// test calls Check. Run the tests with -update to accept an intended change.
+package apitest
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/golden"
+)
+
+// API lists the exported symbols covered by the stability guarantee
+type API struct {
+	Funcs  map[string]any
+	Types  map[string]reflect.Type
+	Values map[string]any
+}
+
+// Surface describes the API, one sorted line per symbol
+func (a API) Surface() []string {
+	var lines []string
+	for name, fn := range a.Funcs {
+		lines = append(lines, fmt.Sprintf("func %s %s", name, reflect.TypeOf(fn)))
+	}
+	for name, value := range a.Values {
+		lines = append(lines, fmt.Sprintf("value %s %s = %v", name, reflect.TypeOf(value), value))
+	}
+	for name, typ := range a.Types {
+		lines = append(lines, fmt.Sprintf("type %s %s", name, typ.Kind()))
+
+		switch typ.Kind() {
+		case reflect.Struct:
+			for i := range typ.NumField() {
+				if field := typ.Field(i); field.IsExported() {
+					lines = append(lines, fmt.Sprintf("field %s.%s %s", name, field.Name, field.Type))
+				}
+			}
+		case reflect.Interface:
+			for i := range typ.NumMethod() {
+				method := typ.Method(i)
+				lines = append(lines, fmt.Sprintf("method %s.%s %s", name, method.Name, method.Type))
+			}
+		case reflect.Func:
+			lines = append(lines, fmt.Sprintf("signature %s %s", name, funcSignature(typ)))
+		}
+
+		if typ.Kind() != reflect.Interface {
+			ptr := reflect.PointerTo(typ)
+			for i := range ptr.NumMethod() {
+				method := ptr.Method(i)
+				lines = append(lines, fmt.Sprintf("method (*%s).%s %s", name, method.Name, method.Type))
+			}
+		}
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+// Check compares the API with testdata/api.golden, rewriting it under -update
+func Check(t *testing.T, a API) {
+	t.Helper()
+	got := strings.Join(a.Surface(), "\n") + "\n"
+	if !golden.Assert(t, filepath.Join("testdata", "api.golden"), got) {
+		t.Log("exported API changed; if this is intended and compatible, run go test -update")
+	}
+}
+
+// funcSignature spells out a named function type's underlying signature
+func funcSignature(typ reflect.Type) reflect.Type {
+	in := make([]reflect.Type, typ.NumIn())
+	for i := range in {
+		in[i] = typ.In(i)
+	}
+	out := make([]reflect.Type, typ.NumOut())
+	for i := range out {
+		out[i] = typ.Out(i)
+	}
+	return reflect.FuncOf(in, out, typ.IsVariadic())
+}
@@ -0,0 +1,523 @@
+// Package gcloudtest provides a fake gcloud for tests of code that runs gcloud
+// through gcloud.SetRunner.
+//
+// The fake answers the configuration commands gcloudctx uses from in-memory
+// state and mirrors that state to a gcloud configuration directory (one
+// config_<name> file per configuration plus active_config), so code reading
+// gcloud's files directly sees the same configurations as code running gcloud.
+// Configuration files written behind its back are read back before each
+// command, as gcloud reads them.
+package gcloudtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// FakeRunner is a gcloud.Runner simulating gcloud's configuration commands
+type FakeRunner struct {
+	mu  sync.Mutex
+	dir string
+	// configs maps configuration names to their "section/key" properties
+	configs  map[string]map[string]string
+	active   string
+	projects []gcloud.Project
+	// accounts are the accounts 'gcloud auth list' holds credentials for
+	accounts []string
+	// clusters maps projects to their GKE clusters
+	clusters map[string][]gcloud.Cluster
+	failures map[string]string
+	calls    []string
+	// reportedDir is the configuration directory 'gcloud info' reports, dir when empty
+	reportedDir string
+	// tokenExpiry is when the access token 'config config-helper' reports
+	// expires; a new token lasts an hour when it is zero or refreshed
+	tokenExpiry time.Time
+}
+
+// NewFakeRunner returns a fake without configurations that mirrors its state
+// to the gcloud configuration directory dir
+func NewFakeRunner(dir string) *FakeRunner {
+	return &FakeRunner{
+		dir:      dir,
+		configs:  map[string]map[string]string{},
+		clusters: map[string][]gcloud.Cluster{},
+		failures: map[string]string{},
+	}
+}
+
+// Install creates a FakeRunner for dir and runs gcloud commands through it
+// until the test ends
+func Install(tb testing.TB, dir string) *FakeRunner {
+	tb.Helper()
+	fake := NewFakeRunner(dir)
+	if err := fake.sync(); err != nil {
+		tb.Fatalf("failed to write %s: %v", dir, err)
+	}
+	tb.Cleanup(gcloud.SetRunner(fake))
+	return fake
+}
+
+// Add creates a configuration with properties keyed by "section/key"
+// (e.g. "core/project"); the first configuration added becomes active
+func (f *FakeRunner) Add(name string, properties map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.configs[name] = maps.Clone(properties)
+	if f.configs[name] == nil {
+		f.configs[name] = map[string]string{}
+	}
+	if f.active == "" {
+		f.active = name
+	}
+	f.mustSync()
+}
+
+// Activate makes name the active configuration without recording a call
+func (f *FakeRunner) Activate(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.active = name
+	f.mustSync()
+}
+
+// AddProject makes 'gcloud projects list' return the project
+func (f *FakeRunner) AddProject(project gcloud.Project) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.projects = append(f.projects, project)
+}
+
+// AddAccount makes 'gcloud auth list' report a credential for account
+func (f *FakeRunner) AddAccount(account string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.accounts = append(f.accounts, account)
+}
+
+// AddCluster makes 'gcloud container clusters list' return the cluster for
+// project and 'get-credentials' succeed for it
+func (f *FakeRunner) AddCluster(project string, cluster gcloud.Cluster) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clusters[project] = append(f.clusters[project], cluster)
+}
+
+// Fail makes every command starting with the words of command fail with
+// output, as gcloud prints it (e.g. "ERROR: (gcloud.config.set) ...")
+func (f *FakeRunner) Fail(command, output string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[command] = output
+}
+
+// SetTokenExpiry makes 'gcloud config config-helper' report an access token
+// expiring at expiry, until it is refreshed with --force-auth-refresh
+func (f *FakeRunner) SetTokenExpiry(expiry time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokenExpiry = expiry
+}
+
+// ReportConfigDir makes 'gcloud info' report dir as the configuration directory,
+// as an SDK install using another directory than the one gcloudctx reads does
+func (f *FakeRunner) ReportConfigDir(dir string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reportedDir = dir
+}
+
+// Active returns the name of the active configuration
+func (f *FakeRunner) Active() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.active
+}
+
+// Names returns the names of the configurations in gcloud's (alphabetical) order
+func (f *FakeRunner) Names() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Sorted(maps.Keys(f.configs))
+}
+
+// Properties returns the properties of the named configuration and whether it exists
+func (f *FakeRunner) Properties(name string) (map[string]string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	properties, ok := f.configs[name]
+	return maps.Clone(properties), ok
+}
+
+// Calls returns the commands run so far, each as its space-separated arguments
+func (f *FakeRunner) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// Run implements gcloud.Runner
+func (f *FakeRunner) Run(args ...string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	command := strings.Join(args, " ")
+	f.calls = append(f.calls, command)
+	for prefix, output := range f.failures {
+		if command == prefix || strings.HasPrefix(command, prefix+" ") {
+			return "", gcloud.ClassifyError(args, output, errors.New("exit status 1"))
+		}
+	}
+
+	if err := f.load(); err != nil {
+		return "", err
+	}
+	output, failure := f.run(parseArgs(args))
+	if failure != "" {
+		return "", gcloud.ClassifyError(args, failure, errors.New("exit status 1"))
+	}
+	if err := f.sync(); err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+// RunQuiet implements gcloud.Runner
+func (f *FakeRunner) RunQuiet(args ...string) error {
+	_, err := f.Run(args...)
+	return err
+}
+
+// invocation is a gcloud command line split into positional words and flags
+type invocation struct {
+	words []string
+	flags map[string]string
+}
+
+// valueFlags are the flags given as "--name value" in the commands the fake answers
+var valueFlags = map[string]bool{"configuration": true, "location": true, "project": true}
+
+// parseArgs splits args into words and "--name value" or "--name=value" flags
+func parseArgs(args []string) invocation {
+	inv := invocation{flags: map[string]string{}}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") {
+			inv.words = append(inv.words, arg)
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !hasValue && valueFlags[name] && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		inv.flags[name] = value
+	}
+	return inv
+}
+
+// run executes a command against the state and returns its output, or the
+// output gcloud prints when it fails
+func (f *FakeRunner) run(inv invocation) (output, failure string) {
+	words := inv.words
+	command := strings.Join(words[:min(len(words), 3)], " ")
+	name := ""
+	if len(words) > 3 {
+		name = words[3]
+	}
+
+	switch {
+	case command == "config configurations list":
+		return f.listJSON(), ""
+
+	case command == "config configurations describe":
+		if _, ok := f.configs[name]; !ok {
+			return "", fmt.Sprintf("ERROR: (gcloud.config.configurations.describe) The configuration [%s] does not exist.", name)
+		}
+		data, _ := json.Marshal(f.entry(name))
+		return string(data), ""
+
+	case command == "config configurations create":
+		if _, ok := f.configs[name]; ok {
+			return "", fmt.Sprintf("ERROR: (gcloud.config.configurations.create) Cannot create configuration [%s], it already exists.", name)
+		}
+		f.configs[name] = map[string]string{}
+		// Like gcloud, a new configuration is activated unless --no-activate is given
+		if _, ok := inv.flags["no-activate"]; !ok {
+			f.active = name
+		}
+		return "", ""
+
+	case command == "config configurations activate":
+		if _, ok := f.configs[name]; !ok {
+			return "", fmt.Sprintf("ERROR: (gcloud.config.configurations.activate) Cannot activate configuration [%s], it does not exist.", name)
+		}
+		f.active = name
+		return "", ""
+
+	case command == "config configurations delete":
+		for _, name := range words[3:] {
+			if _, ok := f.configs[name]; !ok {
+				return "", fmt.Sprintf("ERROR: (gcloud.config.configurations.delete) Cannot delete configuration [%s], it does not exist.", name)
+			}
+			if name == f.active {
+				return "", fmt.Sprintf("ERROR: (gcloud.config.configurations.delete) Deleting named configuration failed because configuration [%s] is set as active.  Use `gcloud config configurations activate` to change the active configuration.", name)
+			}
+		}
+		for _, name := range words[3:] {
+			delete(f.configs, name)
+		}
+		return "", ""
+
+	case len(words) >= 3 && words[0] == "config" && (words[1] == "set" || words[1] == "unset" || words[1] == "get-value"):
+		return f.property(words, inv.flags["configuration"])
+
+	case command == "projects list":
+		data, _ := json.Marshal(f.projects)
+		if f.projects == nil {
+			data = []byte("[]")
+		}
+		return string(data), ""
+
+	case command == "auth list":
+		// Like gcloud, the account of the active configuration is ACTIVE
+		accounts := []gcloud.CredentialedAccount{}
+		for _, account := range f.accounts {
+			status := ""
+			if f.active != "" && f.configs[f.active]["core/account"] == account {
+				status = "ACTIVE"
+			}
+			accounts = append(accounts, gcloud.CredentialedAccount{Account: account, Status: status})
+		}
+		data, _ := json.Marshal(accounts)
+		return string(data), ""
+
+	case command == "container clusters list":
+		data, _ := json.Marshal(f.clusters[inv.flags["project"]])
+		if f.clusters[inv.flags["project"]] == nil {
+			data = []byte("[]")
+		}
+		return string(data), ""
+
+	case command == "container clusters get-credentials":
+		cluster := gcloud.Cluster{Name: name, Location: inv.flags["location"]}
+		if !slices.Contains(f.clusters[inv.flags["project"]], cluster) {
+			return "", fmt.Sprintf("ERROR: (gcloud.container.clusters.get-credentials) ResponseError: code=404, message=Not found: projects/%s/locations/%s/clusters/%s.", inv.flags["project"], cluster.Location, name)
+		}
+		return "", ""
+
+	case len(words) == 2 && words[0] == "config" && words[1] == "config-helper":
+		if f.active == "" || f.configs[f.active]["core/account"] == "" {
+			return "", "ERROR: (gcloud.config.config-helper) You do not currently have an active account selected."
+		}
+		if _, ok := inv.flags["force-auth-refresh"]; ok || f.tokenExpiry.IsZero() {
+			f.tokenExpiry = time.Now().Add(time.Hour)
+		}
+		data, _ := json.Marshal(map[string]any{
+			"configuration": map[string]any{"active_configuration": f.active},
+			"credential":    map[string]string{"access_token": "ya29.fake", "token_expiry": f.tokenExpiry.UTC().Format(time.RFC3339)},
+		})
+		return string(data), ""
+
+	case len(words) > 0 && words[0] == "info":
+		dir := f.reportedDir
+		if dir == "" {
+			dir = f.dir
+		}
+		data, _ := json.Marshal(map[string]any{"config": map[string]any{"paths": map[string]string{"global_config_dir": dir}}})
+		return string(data), ""
+
+	case len(words) > 0 && words[0] == "version":
+		return `{"Google Cloud SDK": "999.0.0"}`, ""
+	}
+
+	return "", fmt.Sprintf("ERROR: (gcloud) Invalid choice: '%s'.", strings.Join(words, " "))
+}
+
+// property runs 'config set', 'config unset' and 'config get-value' on the
+// named configuration, or the active one when configName is empty
+func (f *FakeRunner) property(words []string, configName string) (output, failure string) {
+	if configName == "" {
+		configName = f.active
+	}
+	properties, ok := f.configs[configName]
+	if !ok {
+		return "", fmt.Sprintf("ERROR: (gcloud.config.%s) The configuration [%s] does not exist.", words[1], configName)
+	}
+
+	// gcloud treats properties without a section as core properties
+	property := words[2]
+	if !strings.Contains(property, "/") {
+		property = "core/" + property
+	}
+
+	switch words[1] {
+	case "set":
+		if len(words) < 4 {
+			return "", "ERROR: (gcloud.config.set) argument VALUE: Must be specified."
+		}
+		properties[property] = words[3]
+	case "unset":
+		delete(properties, property)
+	case "get-value":
+		return properties[property] + "\n", ""
+	}
+	return "", ""
+}
+
+// configEntry is a configuration as 'gcloud config configurations list --format=json' prints it
+type configEntry struct {
+	Name       string                       `json:"name"`
+	IsActive   bool                         `json:"is_active"`
+	Properties map[string]map[string]string `json:"properties"`
+}
+
+func (f *FakeRunner) entry(name string) configEntry {
+	sections := map[string]map[string]string{}
+	for key, value := range f.configs[name] {
+		section, property, _ := strings.Cut(key, "/")
+		if sections[section] == nil {
+			sections[section] = map[string]string{}
+		}
+		sections[section][property] = value
+	}
+	return configEntry{Name: name, IsActive: name == f.active, Properties: sections}
+}
+
+func (f *FakeRunner) listJSON() string {
+	entries := []configEntry{}
+	for _, name := range slices.Sorted(maps.Keys(f.configs)) {
+		entries = append(entries, f.entry(name))
+	}
+	data, _ := json.Marshal(entries)
+	return string(data)
+}
+
+// sync writes the state to the configuration directory the way gcloud keeps it
+func (f *FakeRunner) sync() error {
+	configurations := filepath.Join(f.dir, "configurations")
+	if err := os.MkdirAll(configurations, 0o755); err != nil {
+		return err
+	}
+
+	existing, err := filepath.Glob(filepath.Join(configurations, "config_*"))
+	if err != nil {
+		return err
+	}
+	for _, path := range existing {
+		if _, ok := f.configs[strings.TrimPrefix(filepath.Base(path), "config_")]; !ok {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	for name := range f.configs {
+		if err := writeIfChanged(filepath.Join(configurations, "config_"+name), f.ini(name)); err != nil {
+			return err
+		}
+	}
+
+	if f.active == "" {
+		return nil
+	}
+	return writeIfChanged(filepath.Join(f.dir, gcloud.ActiveConfigFileName), f.active)
+}
+
+// load reads back the configuration files changed since the fake last wrote
+// them, such as a restored snapshot or a file replaced directly, and forgets
+// configurations whose file was removed
+// Files the fake cannot parse are left alone
+func (f *FakeRunner) load() error {
+	paths, err := filepath.Glob(filepath.Join(f.dir, "configurations", "config_*"))
+	if err != nil {
+		return err
+	}
+
+	found := map[string]bool{}
+	for _, path := range paths {
+		name := strings.TrimPrefix(filepath.Base(path), "config_")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		found[name] = true
+		if _, ok := f.configs[name]; ok && string(data) == f.ini(name) {
+			continue
+		}
+		if properties, ok := parseINI(string(data)); ok {
+			f.configs[name] = properties
+		}
+	}
+
+	for name := range f.configs {
+		if !found[name] {
+			delete(f.configs, name)
+		}
+	}
+	return nil
+}
+
+// parseINI reads a properties file written like ini does, reporting whether it parsed
+func parseINI(data string) (map[string]string, bool) {
+	properties := map[string]string{}
+	section := ""
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok || section == "" {
+				return nil, false
+			}
+			properties[section+"/"+strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return properties, true
+}
+
+// writeIfChanged writes contents to path unless it already holds them, so
+// commands that change nothing leave modification times alone, as gcloud does
+func writeIfChanged(path, contents string) error {
+	if current, err := os.ReadFile(path); err == nil && string(current) == contents {
+		return nil
+	}
+	return os.WriteFile(path, []byte(contents), 0o600)
+}
+
+// mustSync is sync for methods called by tests, which cannot return errors
+func (f *FakeRunner) mustSync() {
+	if err := f.sync(); err != nil {
+		panic(fmt.Sprintf("gcloudtest: failed to write %s: %v", f.dir, err))
+	}
+}
+
+// ini renders a configuration as gcloud's properties file
+func (f *FakeRunner) ini(name string) string {
+	var b strings.Builder
+	sections := f.entry(name).Properties
+	for _, section := range slices.Sorted(maps.Keys(sections)) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n", section)
+		for _, key := range slices.Sorted(maps.Keys(sections[section])) {
+			fmt.Fprintf(&b, "%s = %s\n", key, sections[section][key])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package gcloudtest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// install points the gcloud package at a fresh configuration directory served by a fake
+func install(t *testing.T) (*FakeRunner, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(gcloud.EnvConfigDir, dir)
+	t.Setenv(gcloud.EnvActiveConfigName, "")
+	return Install(t, dir), dir
+}
+
+func TestFakeRunnerConfigurations(t *testing.T) {
+	fake, _ := install(t)
+	fake.Add("dev", map[string]string{"core/project": "dev-project"})
+	fake.Add("prod", map[string]string{"core/project": "prod-project", "compute/region": "europe-west1"})
+
+	configs, err := gcloud.ListConfigurations()
+	if err != nil {
+		t.Fatalf("ListConfigurations() error = %v", err)
+	}
+	if len(configs) != 2 || configs[0].Name != "dev" || !configs[0].IsActive || configs[1].Name != "prod" {
+		t.Fatalf("ListConfigurations() = %+v, want dev (active) and prod", configs)
+	}
+	if configs[1].Properties.Compute.Region != "europe-west1" {
+		t.Errorf("prod region = %q, want europe-west1", configs[1].Properties.Compute.Region)
+	}
+
+	if err := gcloud.ActivateConfiguration("prod"); err != nil {
+		t.Fatalf("ActivateConfiguration() error = %v", err)
+	}
+	if name, err := gcloud.ActiveConfigName(); err != nil || name != "prod" {
+		t.Errorf("ActiveConfigName() = %q, %v; want prod from active_config", name, err)
+	}
+
+	if err := gcloud.SetProperties("dev", []gcloud.PropertySetting{{Property: "compute/zone", Value: "europe-west1-b"}}); err != nil {
+		t.Fatalf("SetProperties() error = %v", err)
+	}
+	if properties, _ := fake.Properties("dev"); properties["compute/zone"] != "europe-west1-b" {
+		t.Errorf("dev properties = %v, want compute/zone set", properties)
+	}
+
+	want := []string{
+		"config configurations list --format=json",
+		"config configurations activate prod",
+		"config set compute/zone europe-west1-b --configuration dev",
+	}
+	if calls := fake.Calls(); !slices.Equal(calls, want) {
+		t.Errorf("Calls() = %q, want %q", calls, want)
+	}
+}
+
+func TestFakeRunnerMirrorsFiles(t *testing.T) {
+	fake, dir := install(t)
+	fake.Add("dev", map[string]string{"core/project": "dev-project"})
+
+	if err := gcloud.CreateConfiguration("staging"); err != nil {
+		t.Fatalf("CreateConfiguration() error = %v", err)
+	}
+	if exists, err := gcloud.ConfigurationFileExists("staging"); err != nil || !exists {
+		t.Errorf("ConfigurationFileExists(staging) = %v, %v; want true", exists, err)
+	}
+	if fake.Active() != "dev" {
+		t.Errorf("active = %q after create --no-activate, want dev", fake.Active())
+	}
+	// gcloud activates new configurations unless told otherwise
+	if err := gcloud.RunGcloudCommandQuiet("config", "configurations", "create", "qa"); err != nil {
+		t.Fatalf("creating qa: %v", err)
+	}
+	if fake.Active() != "qa" {
+		t.Errorf("active = %q after create, want qa", fake.Active())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "configurations", "config_dev"))
+	if err != nil || string(data) != "[core]\nproject = dev-project\n" {
+		t.Errorf("config_dev = %q, %v", data, err)
+	}
+
+	fake.Activate("dev")
+	if err := gcloud.DeleteConfiguration("qa"); err != nil {
+		t.Fatalf("DeleteConfiguration() error = %v", err)
+	}
+	if err := gcloud.DeleteConfiguration("staging"); err != nil {
+		t.Fatalf("DeleteConfiguration() error = %v", err)
+	}
+	if exists, _ := gcloud.ConfigurationFileExists("staging"); exists {
+		t.Error("config_staging still exists after delete")
+	}
+	if names := fake.Names(); !slices.Equal(names, []string{"dev"}) {
+		t.Errorf("Names() = %v, want [dev]", names)
+	}
+}
+
+func TestFakeRunnerErrors(t *testing.T) {
+	fake, _ := install(t)
+	fake.Add("dev", nil)
+
+	if err := gcloud.ActivateConfiguration("missing"); !errors.Is(err, gcloud.ErrConfigurationNotFound) {
+		t.Errorf("activating a missing configuration = %v, want ErrConfigurationNotFound", err)
+	}
+
+	fake.Fail("projects list", "ERROR: (gcloud.projects.list) There was a problem refreshing your current auth tokens: Reauthentication failed.")
+	if _, err := gcloud.ListProjects(); !errors.Is(err, gcloud.ErrReauthRequired) {
+		t.Errorf("ListProjects() = %v, want ErrReauthRequired", err)
+	}
+}
@@ -0,0 +1,165 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// ColumnName is the name of the column holding the configuration name
+const ColumnName = "name"
+
+// Column is a column of the wide, csv and tsv outputs
+type Column struct {
+	// Name is the column's name as given to --columns and used in csv/tsv headers
+	Name  string
+	title string
+	value func(config *gcloud.Configuration) string
+	// machineValue replaces value in csv and tsv output when set
+	machineValue func(config *gcloud.Configuration) string
+	// unset describes an empty value in accessible output; "not set" by default
+	unset string
+	// minWidth pads short columns so wide tables line up across invocations
+	minWidth int
+	// floor is the narrowest width the terminal-width shrink may reach
+	floor int
+	// optional columns are left out of the defaults and shown with --show-all
+	// or when named in --columns
+	optional bool
+}
+
+// allColumns are the known columns in their default order
+// The name floor keeps long generated names recognizable
+var allColumns = []Column{
+	{Name: ColumnName, title: "NAME", minWidth: 20, floor: 20, value: func(c *gcloud.Configuration) string { return c.Name }},
+	{Name: "account", title: "ACCOUNT", minWidth: 30, floor: 12, value: func(c *gcloud.Configuration) string { return c.Properties.Core.Account }},
+	{Name: "project", title: "PROJECT", minWidth: 25, floor: 12, value: func(c *gcloud.Configuration) string { return c.Properties.Core.Project }},
+	{Name: "region", title: "REGION", minWidth: 15, floor: 8, value: func(c *gcloud.Configuration) string { return c.Properties.Compute.Region }},
+	{Name: "zone", title: "ZONE", minWidth: 15, floor: 8, value: func(c *gcloud.Configuration) string { return c.Properties.Compute.Zone }},
+	{Name: "usage_reporting", title: "USAGE REPORTING", optional: true, value: usageReportingValue, machineValue: usageReportingDisabled},
+	{Name: "notes", title: "NOTES", unset: "none", minWidth: 10, floor: 10, value: noteValue, machineValue: noteText},
+	{Name: "last_used", title: "LAST USED", unset: "never", value: lastUsedValue, machineValue: lastUsedTimestamp},
+}
+
+// usageReportingValue describes core/disable_usage_reporting, empty when it is not set
+func usageReportingValue(c *gcloud.Configuration) string {
+	disabled, set := c.Properties.UsageReportingDisabled()
+	switch {
+	case !set:
+		return ""
+	case disabled:
+		return "disabled"
+	default:
+		return "enabled"
+	}
+}
+
+// usageReportingDisabled returns core/disable_usage_reporting as "true" or "false", empty when it is not set
+func usageReportingDisabled(c *gcloud.Configuration) string {
+	disabled, set := c.Properties.UsageReportingDisabled()
+	if !set {
+		return ""
+	}
+	return strconv.FormatBool(disabled)
+}
+
+// lastUsedValue returns how long ago a configuration was last used, empty when never
+func lastUsedValue(c *gcloud.Configuration) string {
+	at, ok := lastUsed[c.Name]
+	if !ok {
+		return ""
+	}
+	return FormatRelativeTime(at, now())
+}
+
+// lastUsedTimestamp returns when a configuration was last used in RFC 3339, empty when never
+func lastUsedTimestamp(c *gcloud.Configuration) string {
+	at, ok := lastUsed[c.Name]
+	if !ok {
+		return ""
+	}
+	return at.UTC().Format(time.RFC3339)
+}
+
+// delimitedValue returns the column's value for csv and tsv output
+func (c Column) delimitedValue(config *gcloud.Configuration) string {
+	if c.machineValue != nil {
+		return c.machineValue(config)
+	}
+	return c.value(config)
+}
+
+// unsetText describes an empty value in accessible output
+func (c Column) unsetText() string {
+	if c.unset != "" {
+		return c.unset
+	}
+	return "not set"
+}
+
+// DefaultColumns returns the known columns that are not optional, in the default order
+func DefaultColumns() []Column {
+	var columns []Column
+	for _, column := range allColumns {
+		if !column.optional {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// AllColumns returns every known column, including the optional ones, in the default order
+func AllColumns() []Column {
+	return append([]Column(nil), allColumns...)
+}
+
+// ColumnNames returns the names of the known columns
+func ColumnNames() []string {
+	names := make([]string, len(allColumns))
+	for i, column := range allColumns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// ParseColumns parses a comma-separated list of column names, keeping the
+// requested order. An empty spec selects the default columns.
+func ParseColumns(spec string) ([]Column, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultColumns(), nil
+	}
+
+	var columns []Column
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		column, ok := lookupColumn(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q (valid columns: %s)", part, strings.Join(ColumnNames(), ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("column %q is given more than once", name)
+		}
+		seen[name] = true
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// lookupColumn returns the known column with the given name
+func lookupColumn(name string) (Column, bool) {
+	for _, column := range allColumns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// SupportsColumns reports whether a format honors column selection
+func SupportsColumns(format Format) bool {
+	return format == FormatWide || format == FormatCSV || format == FormatTSV
+}
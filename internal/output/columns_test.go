@@ -0,0 +1,171 @@
+package output
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/golden"
+	"github.com/fatih/color"
+)
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []string
+		wantErr string
+	}{
+		{spec: "", want: []string{"name", "account", "project", "region", "zone", "notes", "last_used"}},
+		{spec: "name,project", want: []string{"name", "project"}},
+		{spec: "name,usage_reporting", want: []string{"name", "usage_reporting"}},
+		{spec: "project, NAME ,region", want: []string{"project", "name", "region"}},
+		{spec: "name,owner", wantErr: `unknown column "owner" (valid columns: name, account, project, region, zone, usage_reporting, notes, last_used)`},
+		{spec: "name,,project", wantErr: `unknown column ""`},
+		{spec: "name,project,name", wantErr: `column "name" is given more than once`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			columns, err := ParseColumns(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseColumns(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseColumns(%q) error = %v", tt.spec, err)
+			}
+
+			got := make([]string, len(columns))
+			for i, column := range columns {
+				got[i] = column.Name
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ParseColumns(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseValueFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    []string
+		ok      bool
+		wantErr bool
+	}{
+		{format: "value=project", want: []string{"project"}, ok: true},
+		{format: "VALUE=Account", want: []string{"account"}, ok: true},
+		{format: "value=last_used", want: []string{"last_used"}, ok: true},
+		{format: "value(name)", want: []string{"name"}, ok: true},
+		{format: "value(name, project,zone)", want: []string{"name", "project", "zone"}, ok: true},
+		{format: "value=billing", ok: true, wantErr: true},
+		{format: "value(name,billing)", ok: true, wantErr: true},
+		{format: "value(name", ok: true, wantErr: true},
+		{format: "value()", ok: true, wantErr: true},
+		{format: "value=", ok: true, wantErr: true},
+		{format: "json", ok: false},
+		{format: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		columns, ok, err := ParseValueFormat(tt.format)
+		if ok != tt.ok || (err != nil) != tt.wantErr {
+			t.Errorf("ParseValueFormat(%q) = %v, %v; want ok %v, error %v", tt.format, ok, err, tt.ok, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		var names []string
+		for _, column := range columns {
+			names = append(names, column.Name)
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("ParseValueFormat(%q) = columns %v, want %v", tt.format, names, tt.want)
+		}
+	}
+}
+
+func TestWriteConfigurationValues(t *testing.T) {
+	columns, _, err := ParseValueFormat("value(name,project,region)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	writeConfigurationValues(&buf, goldenConfigs()[:2], columns)
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if fields := strings.Split(line, "\t"); len(fields) != 3 {
+			t.Errorf("line %q has %d fields, want 3", line, len(fields))
+		}
+	}
+}
+
+func TestSelectedColumnsGolden(t *testing.T) {
+	color.NoColor = true
+	configs := goldenConfigs()
+	columns, err := ParseColumns("project,name,region")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		render func(w *bytes.Buffer) error
+	}{
+		{"wide", func(w *bytes.Buffer) error { standardViews{}.wide(w, configs, columns, 0); return nil }},
+		{"wide-default", func(w *bytes.Buffer) error { standardViews{}.wide(w, configs, DefaultColumns(), 0); return nil }},
+		{"accessible-wide", func(w *bytes.Buffer) error { accessibleViews{}.wide(w, configs, columns, 0); return nil }},
+		{"csv", func(w *bytes.Buffer) error { return writeConfigurationsDelimited(w, configs, columns, ',') }},
+		{"tsv", func(w *bytes.Buffer) error { return writeConfigurationsDelimited(w, configs, columns, '\t') }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.render(&buf); err != nil {
+				t.Fatal(err)
+			}
+			golden.Assert(t, filepath.Join("testdata", "columns", tt.name+".golden"), buf.String())
+		})
+	}
+}
+
+func TestLastUsedColumnGolden(t *testing.T) {
+	color.NoColor = true
+	configs := goldenConfigs()
+	columns, err := ParseColumns("name,last_used")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	t.Cleanup(func() { lastUsed, now = nil, time.Now })
+	SetLastUsed(map[string]time.Time{"prod": clock.Add(-3 * time.Hour)})
+	now = func() time.Time { return clock }
+
+	tests := []struct {
+		name   string
+		render func(w *bytes.Buffer) error
+	}{
+		{"last-used-wide", func(w *bytes.Buffer) error { standardViews{}.wide(w, configs, columns, 0); return nil }},
+		{"last-used-accessible-wide", func(w *bytes.Buffer) error { accessibleViews{}.wide(w, configs, columns, 0); return nil }},
+		{"last-used-csv", func(w *bytes.Buffer) error { return writeConfigurationsDelimited(w, configs, columns, ',') }},
+		{"last-used-details", func(w *bytes.Buffer) error { standardViews{}.details(w, &configs[0]); return nil }},
+		{"last-used-preview", func(w *bytes.Buffer) error { standardViews{}.preview(w, &configs[1], DefaultPreviewWidth); return nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.render(&buf); err != nil {
+				t.Fatal(err)
+			}
+			golden.Assert(t, filepath.Join("testdata", "columns", tt.name+".golden"), buf.String())
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// maxCompletionDescriptionLength caps completion descriptions so they do not wrap in zsh
+const maxCompletionDescriptionLength = 50
+
+// CompletionEntry returns the shell completion entry for a configuration: its
+// exact name, a tab, and an "active, project, account" description that zsh and
+// fish show next to the candidate (bash ignores it)
+// Missing parts are left out, and long descriptions are capped with an ellipsis
+func CompletionEntry(config *gcloud.Configuration) string {
+	var parts []string
+	if config.IsActive {
+		parts = append(parts, "active")
+	}
+	if config.Properties.Core.Project != "" {
+		parts = append(parts, config.Properties.Core.Project)
+	}
+	if config.Properties.Core.Account != "" {
+		parts = append(parts, config.Properties.Core.Account)
+	}
+	if len(parts) == 0 {
+		return config.Name
+	}
+
+	description := strings.NewReplacer("\t", " ", "\n", " ").Replace(strings.Join(parts, ", "))
+	return config.Name + "\t" + TruncateString(description, maxCompletionDescriptionLength)
+}
+
+// completionDataSeparators would break a completion data line; no value gcloud
+// accepts contains them, but they are replaced by spaces all the same
+var completionDataSeparators = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+// WriteCompletionData writes one line per configuration for tools reusing the
+// completion data: "name\tproject\taccount\tactive", with active "true" or
+// "false" and unset values empty
+// The fields and their order are stable across versions; new fields are only
+// ever appended. Values are never colored, truncated or quoted, and contain no
+// tabs or line breaks
+func WriteCompletionData(w io.Writer, configs []gcloud.Configuration) error {
+	for i := range configs {
+		config := &configs[i]
+		fields := []string{
+			config.Name,
+			config.Properties.Core.Project,
+			config.Properties.Core.Account,
+			strconv.FormatBool(config.IsActive),
+		}
+		for j, field := range fields {
+			fields[j] = completionDataSeparators.Replace(field)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(fields, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+func TestCompletionEntry(t *testing.T) {
+	core := func(project, account string) gcloud.Properties {
+		return gcloud.Properties{Core: gcloud.CoreProperties{Project: project, Account: account}}
+	}
+
+	tests := []struct {
+		name   string
+		config gcloud.Configuration
+		want   string
+	}{
+		{
+			name:   "active with project and account",
+			config: gcloud.Configuration{Name: "prod", IsActive: true, Properties: core("prod-project", "me@example.com")},
+			want:   "prod\tactive, prod-project, me@example.com",
+		},
+		{
+			name:   "inactive",
+			config: gcloud.Configuration{Name: "dev", Properties: core("dev-project", "me@example.com")},
+			want:   "dev\tdev-project, me@example.com",
+		},
+		{
+			name:   "account only",
+			config: gcloud.Configuration{Name: "ci", Properties: core("", "ci@example.iam.gserviceaccount.com")},
+			want:   "ci\tci@example.iam.gserviceaccount.com",
+		},
+		{
+			name:   "bare",
+			config: gcloud.Configuration{Name: "empty"},
+			want:   "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompletionEntry(&tt.config); got != tt.want {
+				t.Errorf("CompletionEntry() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompletionEntryTruncatesDescription(t *testing.T) {
+	config := gcloud.Configuration{
+		Name:     "team",
+		IsActive: true,
+		Properties: gcloud.Properties{Core: gcloud.CoreProperties{
+			Project: "プロジェクト-本番環境-asia-northeast1",
+			Account: "deployment-automation@team-platform.iam.gserviceaccount.com",
+		}},
+	}
+
+	name, description, ok := strings.Cut(CompletionEntry(&config), "\t")
+	if !ok || name != "team" {
+		t.Fatalf("CompletionEntry() = %q, want a tab-separated description after the name", CompletionEntry(&config))
+	}
+	// A wide character that does not fit may leave one column unused
+	if w := displayWidth(description); w > maxCompletionDescriptionLength || w < maxCompletionDescriptionLength-1 {
+		t.Errorf("description takes %d columns, want %d", w, maxCompletionDescriptionLength)
+	}
+	if !utf8.ValidString(description) || !strings.HasSuffix(description, "...") {
+		t.Errorf("description = %q, want valid UTF-8 ending in an ellipsis", description)
+	}
+}
+
+func TestWriteCompletionData(t *testing.T) {
+	configs := []gcloud.Configuration{
+		{Name: "dev", IsActive: true, Properties: gcloud.Properties{Core: gcloud.CoreProperties{Project: "dev-project", Account: "dev@example.com"}}},
+		{Name: "empty"},
+		{Name: "a-very-long-configuration-name-that-is-never-truncated", Properties: gcloud.Properties{Core: gcloud.CoreProperties{Account: "only-account@example.com"}}},
+		{Name: "odd", Properties: gcloud.Properties{Core: gcloud.CoreProperties{Project: "tab\there", Account: "line\nbreak"}}},
+	}
+
+	var out strings.Builder
+	if err := WriteCompletionData(&out, configs); err != nil {
+		t.Fatalf("WriteCompletionData() error = %v", err)
+	}
+
+	want := "dev\tdev-project\tdev@example.com\ttrue\n" +
+		"empty\t\t\tfalse\n" +
+		"a-very-long-configuration-name-that-is-never-truncated\t\tonly-account@example.com\tfalse\n" +
+		"odd\ttab here\tline break\tfalse\n"
+	if out.String() != want {
+		t.Errorf("WriteCompletionData() =\n%q\nwant\n%q", out.String(), want)
+	}
+}
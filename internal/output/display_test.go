@@ -0,0 +1,76 @@
+package output
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/golden"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/fatih/color"
+)
+
+func TestDisplayStylesGolden(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	defer SetListOptions(ListOptions{})
+	defer SetDisplayStyles(nil)
+	defer SetProtectedConfigurations(nil)
+	color.NoColor = false
+	SetLineFormats(nil, nil, "")
+	SetProtectedConfigurations([]string{"prod"})
+	SetDisplayStyles(map[string]DisplayStyle{
+		"prod": {Color: "red", Emoji: "🔥"},
+		"dev":  {Color: "green"},
+	})
+
+	configs := append(goldenConfigs(), gcloud.Configuration{Name: "scratch"})
+	tests := []struct {
+		name   string
+		render func(w *bytes.Buffer)
+	}{
+		{"list", func(w *bytes.Buffer) { standardViews{}.list(w, configs) }},
+		{"header", func(w *bytes.Buffer) {
+			SetListOptions(ListOptions{ShowHeader: true})
+			defer SetListOptions(ListOptions{})
+			standardViews{}.list(w, configs)
+		}},
+		{"wide", func(w *bytes.Buffer) { standardViews{}.wide(w, configs, DefaultColumns()[:3], 0) }},
+		{"current", func(w *bytes.Buffer) { standardViews{}.current(w, &configs[0]) }},
+		{"picker", func(w *bytes.Buffer) {
+			for i := range configs {
+				w.WriteString(FormatPickerLine(&configs[i], configs[i].Name, configs[i].IsActive) + "\n")
+			}
+		}},
+		{"success", func(w *bytes.Buffer) {
+			standardViews{}.banner(w, bannerSuccess, "switched to configuration "+StyledName("prod"))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.render(&buf)
+			golden.Assert(t, filepath.Join("testdata", "display", tt.name+".golden"), buf.String())
+		})
+	}
+}
+
+func TestStyledNameWithoutStyle(t *testing.T) {
+	defer SetDisplayStyles(nil)
+	SetDisplayStyles(map[string]DisplayStyle{"prod": {Emoji: "🔥"}})
+
+	if got := StyledName("dev"); got != `"dev"` {
+		t.Errorf("StyledName(dev) = %q, want the quoted name alone", got)
+	}
+	if got := StyledName("prod"); got != `🔥 "prod"` {
+		t.Errorf("StyledName(prod) = %q, want the emoji before the name", got)
+	}
+}
+
+func TestAlignColumnsWideCharacters(t *testing.T) {
+	lines := AlignColumns([][]string{{"🔥 prod", "x"}, {"dev", "y"}}, 2)
+	if strings.Index(lines[0], "x") != len("🔥 prod  ") || lines[1] != "dev      y" {
+		t.Errorf("AlignColumns() = %q, want the emoji counted as two columns", lines)
+	}
+}
@@ -3,11 +3,20 @@
 package output
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/Okabe-Junya/gcloudctx/pkg/activity"
 	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
+	"github.com/Okabe-Junya/gcloudctx/pkg/manifest"
+	"github.com/Okabe-Junya/gcloudctx/pkg/paths"
 	"github.com/fatih/color"
 	"gopkg.in/yaml.v3"
 )
@@ -22,6 +31,11 @@ const (
 	FormatYAML    Format = "yaml"
 	FormatWide    Format = "wide"
 	FormatName    Format = "name"
+	FormatCSV     Format = "csv"
+	FormatTSV     Format = "tsv"
+	// FormatValue prints the fields selected by "-o value(FIELD,...)",
+	// tab-separated, one line per configuration
+	FormatValue Format = "value"
 )
 
 // PrintConfigurations prints all configurations in a formatted way
@@ -29,34 +43,7 @@ func PrintConfigurations(configs []gcloud.Configuration, useColor bool) {
 	if !useColor {
 		color.NoColor = true
 	}
-
-	cyan := color.New(color.FgCyan).SprintFunc()
-	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
-	gray := color.New(color.FgHiBlack).SprintFunc()
-
-	for _, config := range configs {
-		marker := " "
-		nameColor := cyan
-		if config.IsActive {
-			marker = "*"
-			nameColor = yellow
-		}
-
-		account := config.Properties.Core.Account
-		project := config.Properties.Core.Project
-
-		// Format: * name (account) [project]
-		line := fmt.Sprintf("%s %s", marker, nameColor(config.Name))
-
-		if account != "" {
-			line += fmt.Sprintf(" %s", gray(fmt.Sprintf("(%s)", account)))
-		}
-		if project != "" {
-			line += fmt.Sprintf(" %s", gray(fmt.Sprintf("[%s]", project)))
-		}
-
-		fmt.Println(line)
-	}
+	currentViews().list(os.Stdout, configs)
 }
 
 // PrintCurrentConfiguration prints the current configuration name
@@ -64,9 +51,7 @@ func PrintCurrentConfiguration(config *gcloud.Configuration, useColor bool) {
 	if !useColor {
 		color.NoColor = true
 	}
-
-	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
-	fmt.Println(yellow(config.Name))
+	currentViews().current(os.Stdout, config)
 }
 
 // PrintConfigurationDetails prints detailed information about a configuration
@@ -74,33 +59,85 @@ func PrintConfigurationDetails(config *gcloud.Configuration, useColor bool) {
 	if !useColor {
 		color.NoColor = true
 	}
+	currentViews().details(os.Stdout, config)
+}
 
-	cyan := color.New(color.FgCyan).SprintFunc()
-	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
-
-	fmt.Printf("%s: %s\n", cyan("Configuration"), yellow(config.Name))
-
-	if config.IsActive {
-		fmt.Printf("%s: %s\n", cyan("Status"), yellow("active"))
-	} else {
-		fmt.Printf("%s: inactive\n", cyan("Status"))
+// PrintConfigurationSections prints every property the typed fields do not
+// model, grouped by section, to follow PrintConfigurationDetails
+func PrintConfigurationSections(config *gcloud.Configuration, useColor bool) {
+	if !useColor {
+		color.NoColor = true
 	}
+	currentViews().sections(os.Stdout, unmodeledProperties(config))
+}
 
-	if account := config.Properties.Core.Account; account != "" {
-		fmt.Printf("%s: %s\n", cyan("Account"), account)
+// PrintConfigurationDocument prints a configuration as a JSON or YAML document;
+// with all set, every property is included under "properties"
+func PrintConfigurationDocument(config *gcloud.Configuration, format Format, all bool) error {
+	document := newConfigOutput(config)
+	if all {
+		document.Properties = groupProperties(gcloud.FlattenProperties(config))
 	}
+	return printDocument(document, format)
+}
+
+// modeledProperties are the properties the details view already shows
+var modeledProperties = []string{"core/account", "core/project", "compute/region", "compute/zone"}
 
-	if project := config.Properties.Core.Project; project != "" {
-		fmt.Printf("%s: %s\n", cyan("Project"), project)
+// unmodeledProperties returns the properties of config other than modeledProperties, by section
+func unmodeledProperties(config *gcloud.Configuration) map[string]map[string]string {
+	flat := gcloud.FlattenProperties(config)
+	for _, property := range modeledProperties {
+		delete(flat, property)
 	}
+	return groupProperties(flat)
+}
 
-	if region := config.Properties.Compute.Region; region != "" {
-		fmt.Printf("%s: %s\n", cyan("Region"), region)
+// groupProperties groups "section/name" properties by section
+func groupProperties(flat map[string]string) map[string]map[string]string {
+	sections := map[string]map[string]string{}
+	for property, value := range flat {
+		section, name, _ := strings.Cut(property, "/")
+		if sections[section] == nil {
+			sections[section] = map[string]string{}
+		}
+		sections[section][name] = value
 	}
+	return sections
+}
+
+// DefaultPreviewWidth is the width of the preview's rules when the preview
+// window's width is not known
+const DefaultPreviewWidth = 40
+
+// PrintPreview prints the configuration details shown in the fzf preview window,
+// width columns wide (DefaultPreviewWidth when 0)
+func PrintPreview(config *gcloud.Configuration, width int) {
+	currentViews().preview(os.Stdout, config, width)
+}
+
+// PrintProjectPreview prints the project details shown in the project picker
+// preview window, width columns wide (DefaultPreviewWidth when 0)
+func PrintProjectPreview(project *gcloud.Project, width int) {
+	currentViews().projectPreview(os.Stdout, project, width)
+}
 
-	if zone := config.Properties.Compute.Zone; zone != "" {
-		fmt.Printf("%s: %s\n", cyan("Zone"), zone)
+// PrintDiff prints the property differences between two configurations,
+// either as a collapsed one-line summary or as a full table when expanded
+func PrintDiff(diffs []gcloud.PropertyDiff, expanded, useColor bool) {
+	if !useColor {
+		color.NoColor = true
 	}
+	currentViews().diff(os.Stdout, diffs, expanded)
+}
+
+// messageOutput receives the banners printed by PrintError and PrintSuccess
+var messageOutput io.Writer = os.Stdout
+
+// SetMessageOutput redirects error and success banners, e.g. to stderr while
+// stdout carries a machine-readable result
+func SetMessageOutput(w io.Writer) {
+	messageOutput = w
 }
 
 // PrintError prints an error message
@@ -108,9 +145,7 @@ func PrintError(message string, useColor bool) {
 	if !useColor {
 		color.NoColor = true
 	}
-
-	red := color.New(color.FgRed, color.Bold).SprintFunc()
-	fmt.Printf("%s %s\n", red("Error:"), message)
+	currentViews().banner(messageOutput, bannerError, message)
 }
 
 // PrintSuccess prints a success message
@@ -118,9 +153,7 @@ func PrintSuccess(message string, useColor bool) {
 	if !useColor {
 		color.NoColor = true
 	}
-
-	green := color.New(color.FgGreen, color.Bold).SprintFunc()
-	fmt.Printf("%s %s\n", green("Success:"), message)
+	currentViews().banner(messageOutput, bannerSuccess, message)
 }
 
 // FormatConfigurationName formats a configuration name with marker if active
@@ -132,15 +165,46 @@ func FormatConfigurationName(name string, isActive bool) string {
 	return fmt.Sprintf("%s %s", marker, name)
 }
 
-// TruncateString truncates a string to a maximum length
+// TruncateString truncates a string to take at most maxLen terminal columns,
+// ending it with "..." when it is cut; wide characters count as two columns
+// and are never split
 func TruncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	if displayWidth(s) <= maxLen {
 		return s
 	}
-	if maxLen <= 3 {
-		return s[:maxLen]
+	ellipsis := "..."
+	if maxLen <= len(ellipsis) {
+		ellipsis = ""
 	}
-	return s[:maxLen-3] + "..."
+
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		w := displayWidth(string(r))
+		if width+w > maxLen-len(ellipsis) {
+			break
+		}
+		b.WriteRune(r)
+		width += w
+	}
+	return b.String() + ellipsis
+}
+
+// TruncateMiddle shortens s to at most maxLen characters by replacing its middle
+// with an ellipsis, keeping the start and the (usually more distinctive) end visible
+func TruncateMiddle(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	if maxLen <= 1 {
+		return string(runes[:max(maxLen, 0)])
+	}
+
+	keep := maxLen - 1 // one character for the ellipsis
+	head := keep / 2
+	tail := keep - head
+	return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
 }
 
 // AlignColumns aligns text in columns
@@ -156,10 +220,7 @@ func AlignColumns(rows [][]string, padding int) []string {
 	for _, row := range rows {
 		for i, cell := range row {
 			// Remove ANSI color codes for width calculation
-			cleanCell := removeANSICodes(cell)
-			if len(cleanCell) > maxWidths[i] {
-				maxWidths[i] = len(cleanCell)
-			}
+			maxWidths[i] = max(maxWidths[i], displayWidth(StripANSI(cell)))
 		}
 	}
 
@@ -168,8 +229,7 @@ func AlignColumns(rows [][]string, padding int) []string {
 	for i, row := range rows {
 		var parts []string
 		for j, cell := range row {
-			cleanCell := removeANSICodes(cell)
-			spaces := maxWidths[j] - len(cleanCell) + padding
+			spaces := maxWidths[j] - displayWidth(StripANSI(cell)) + padding
 			if j < colCount-1 {
 				parts = append(parts, cell+strings.Repeat(" ", spaces))
 			} else {
@@ -182,26 +242,34 @@ func AlignColumns(rows [][]string, padding int) []string {
 	return result
 }
 
-// removeANSICodes removes ANSI color codes from a string
-func removeANSICodes(s string) string {
-	// Simple ANSI code removal (may not handle all cases)
-	inEscape := false
-	var result strings.Builder
+// displayWidth returns how many terminal columns s takes
+func displayWidth(s string) int {
+	return linefmt.DisplayWidth(s)
+}
 
-	for _, r := range s {
-		if r == '\x1b' {
-			inEscape = true
+// StripANSI removes ANSI escape sequences from a string: colors and other
+// control sequences (ESC [ ... final byte) as well as two-character escapes
+func StripANSI(s string) string {
+	var result strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\x1b' {
+			result.WriteByte(s[i])
 			continue
 		}
-		if inEscape {
-			if r == 'm' {
-				inEscape = false
-			}
+		if i+1 >= len(s) {
+			break
+		}
+		if s[i+1] != '[' {
+			// Two-character escape such as ESC 7
+			i++
 			continue
 		}
-		result.WriteRune(r)
+		// Control sequence: parameter and intermediate bytes up to a final byte in @-~
+		i += 2
+		for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+			i++
+		}
 	}
-
 	return result.String()
 }
 
@@ -209,42 +277,65 @@ func removeANSICodes(s string) string {
 type ConfigOutput struct {
 	Name     string `json:"name" yaml:"name"`
 	IsActive bool   `json:"is_active" yaml:"is_active"`
-	Account  string `json:"account,omitempty" yaml:"account,omitempty"`
-	Project  string `json:"project,omitempty" yaml:"project,omitempty"`
-	Region   string `json:"region,omitempty" yaml:"region,omitempty"`
-	Zone     string `json:"zone,omitempty" yaml:"zone,omitempty"`
+	// IsPrevious reports whether 'gcloudctx -' switches to the configuration
+	IsPrevious bool   `json:"is_previous" yaml:"is_previous"`
+	Account    string `json:"account,omitempty" yaml:"account,omitempty"`
+	Project    string `json:"project,omitempty" yaml:"project,omitempty"`
+	Region     string `json:"region,omitempty" yaml:"region,omitempty"`
+	Zone       string `json:"zone,omitempty" yaml:"zone,omitempty"`
+	// Properties holds every property by section, only for 'describe --all'
+	Properties map[string]map[string]string `json:"properties,omitempty" yaml:"properties,omitempty"`
 }
 
 // PrintConfigurationsWithFormat prints configurations in the specified format
-func PrintConfigurationsWithFormat(configs []gcloud.Configuration, format Format, useColor bool) error {
+// Human-readable formats follow accessible mode; machine formats (json, yaml,
+// name, csv, tsv) never do.
+// columns selects the columns of the wide, csv and tsv formats.
+func PrintConfigurationsWithFormat(configs []gcloud.Configuration, format Format, columns []Column, useColor bool) error {
 	switch format {
 	case FormatJSON:
 		return printConfigurationsJSON(configs)
 	case FormatYAML:
 		return printConfigurationsYAML(configs)
 	case FormatWide:
-		printConfigurationsWide(configs, useColor)
+		if !useColor {
+			color.NoColor = true
+		}
+		currentViews().wide(os.Stdout, configs, columns, TerminalWidth())
 		return nil
 	case FormatName:
 		printConfigurationsName(configs)
 		return nil
+	case FormatCSV:
+		return writeConfigurationsDelimited(os.Stdout, configs, columns, ',')
+	case FormatTSV:
+		return writeConfigurationsDelimited(os.Stdout, configs, columns, '\t')
+	case FormatValue:
+		writeConfigurationValues(os.Stdout, configs, columns)
+		return nil
 	default:
 		PrintConfigurations(configs, useColor)
 		return nil
 	}
 }
 
+// newConfigOutput returns the json/yaml form of config
+func newConfigOutput(config *gcloud.Configuration) ConfigOutput {
+	return ConfigOutput{
+		Name:       config.Name,
+		IsActive:   config.IsActive,
+		IsPrevious: !config.IsActive && isPrevious(config.Name),
+		Account:    config.Properties.Core.Account,
+		Project:    config.Properties.Core.Project,
+		Region:     config.Properties.Compute.Region,
+		Zone:       config.Properties.Compute.Zone,
+	}
+}
+
 func printConfigurationsJSON(configs []gcloud.Configuration) error {
 	output := make([]ConfigOutput, len(configs))
-	for i, c := range configs {
-		output[i] = ConfigOutput{
-			Name:     c.Name,
-			IsActive: c.IsActive,
-			Account:  c.Properties.Core.Account,
-			Project:  c.Properties.Core.Project,
-			Region:   c.Properties.Compute.Region,
-			Zone:     c.Properties.Compute.Zone,
-		}
+	for i := range configs {
+		output[i] = newConfigOutput(&configs[i])
 	}
 	data, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
@@ -256,15 +347,8 @@ func printConfigurationsJSON(configs []gcloud.Configuration) error {
 
 func printConfigurationsYAML(configs []gcloud.Configuration) error {
 	output := make([]ConfigOutput, len(configs))
-	for i, c := range configs {
-		output[i] = ConfigOutput{
-			Name:     c.Name,
-			IsActive: c.IsActive,
-			Account:  c.Properties.Core.Account,
-			Project:  c.Properties.Core.Project,
-			Region:   c.Properties.Compute.Region,
-			Zone:     c.Properties.Compute.Zone,
-		}
+	for i := range configs {
+		output[i] = newConfigOutput(&configs[i])
 	}
 	data, err := yaml.Marshal(output)
 	if err != nil {
@@ -274,68 +358,106 @@ func printConfigurationsYAML(configs []gcloud.Configuration) error {
 	return nil
 }
 
-func printConfigurationsWide(configs []gcloud.Configuration, useColor bool) {
-	if !useColor {
-		color.NoColor = true
+func printConfigurationsName(configs []gcloud.Configuration) {
+	for _, config := range configs {
+		fmt.Println(config.Name)
 	}
+}
 
-	cyan := color.New(color.FgCyan).SprintFunc()
-	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
-	gray := color.New(color.FgHiBlack).SprintFunc()
-	bold := color.New(color.Bold).SprintFunc()
+// writeConfigurationsDelimited writes a header row of column names followed by
+// one row per configuration, separated by comma (csv) or tab (tsv); unset values are empty
+func writeConfigurationsDelimited(w io.Writer, configs []gcloud.Configuration, columns []Column, comma rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
 
-	// Print header
-	fmt.Printf("%s  %-20s  %-30s  %-25s  %-15s  %s\n",
-		bold(" "),
-		bold("NAME"),
-		bold("ACCOUNT"),
-		bold("PROJECT"),
-		bold("REGION"),
-		bold("ZONE"))
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
 
-	for _, config := range configs {
-		marker := " "
-		nameColor := cyan
-		if config.IsActive {
-			marker = "*"
-			nameColor = yellow
+	for i := range configs {
+		row := make([]string, len(columns))
+		for j, column := range columns {
+			row[j] = column.delimitedValue(&configs[i])
 		}
-
-		account := config.Properties.Core.Account
-		if account == "" {
-			account = gray("-")
+		if err := writer.Write(row); err != nil {
+			return err
 		}
-		project := config.Properties.Core.Project
-		if project == "" {
-			project = gray("-")
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// PrintCurrentConfigurationWithFormat prints the active configuration in the specified format
+// json and yaml print a single object rather than a list of one; the other
+// machine formats and wide print a one-row list
+func PrintCurrentConfigurationWithFormat(config *gcloud.Configuration, format Format, columns []Column, useColor bool) error {
+	switch format {
+	case FormatJSON, FormatYAML:
+		return printDocument(newConfigOutput(config), format)
+	case FormatDefault:
+		PrintCurrentConfiguration(config, useColor)
+		return nil
+	default:
+		return PrintConfigurationsWithFormat([]gcloud.Configuration{*config}, format, columns, useColor)
+	}
+}
+
+// writeConfigurationValues writes the column values of each configuration on
+// a line of their own, tab-separated and without a header; unset values are empty
+func writeConfigurationValues(w io.Writer, configs []gcloud.Configuration, columns []Column) {
+	for i := range configs {
+		values := make([]string, len(columns))
+		for j, column := range columns {
+			values[j] = column.delimitedValue(&configs[i])
 		}
-		region := config.Properties.Compute.Region
-		if region == "" {
-			region = gray("-")
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+}
+
+// valueFormatPrefix starts the "-o value=FIELD" format, which prints one field
+const valueFormatPrefix = "value="
+
+// ParseValueFormat parses an "-o value(FIELD,...)" format, as in gcloud's
+// --format, or its single-field spelling "-o value=FIELD", where each FIELD is
+// a column name; ok is false for any other format
+func ParseValueFormat(format string) (columns []Column, ok bool, err error) {
+	lower := strings.ToLower(strings.TrimSpace(format))
+	fields, found := strings.CutPrefix(lower, valueFormatPrefix)
+	if !found {
+		inner, isCall := strings.CutPrefix(lower, "value(")
+		if !isCall {
+			return nil, false, nil
 		}
-		zone := config.Properties.Compute.Zone
-		if zone == "" {
-			zone = gray("-")
+		if fields, found = strings.CutSuffix(inner, ")"); !found {
+			return nil, true, fmt.Errorf("unterminated -o %s (use value(FIELD,...))", format)
 		}
-
-		fmt.Printf("%s  %-20s  %-30s  %-25s  %-15s  %s\n",
-			marker,
-			nameColor(TruncateString(config.Name, 20)),
-			TruncateString(account, 30),
-			TruncateString(project, 25),
-			TruncateString(region, 15),
-			zone)
 	}
-}
 
-func printConfigurationsName(configs []gcloud.Configuration) {
-	for _, config := range configs {
-		fmt.Println(config.Name)
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		column, known := lookupColumn(field)
+		if !known {
+			return nil, true, fmt.Errorf("unknown field %q in -o %s (valid fields: %s)", field, format, strings.Join(ColumnNames(), ", "))
+		}
+		columns = append(columns, column)
 	}
+	return columns, true, nil
 }
 
 // ValidateOutputFormat validates the output format string
 func ValidateOutputFormat(format string) (Format, error) {
+	if _, ok, err := ParseValueFormat(format); ok {
+		if err != nil {
+			return "", err
+		}
+		return FormatValue, nil
+	}
+
 	switch strings.ToLower(format) {
 	case "", "default":
 		return FormatDefault, nil
@@ -347,7 +469,196 @@ func ValidateOutputFormat(format string) (Format, error) {
 		return FormatWide, nil
 	case "name":
 		return FormatName, nil
+	case "csv":
+		return FormatCSV, nil
+	case "tsv":
+		return FormatTSV, nil
+	default:
+		return "", fmt.Errorf("unsupported output format: %s (supported: json, yaml, wide, name, csv, tsv, value(FIELD,...))", format)
+	}
+}
+
+// SwitchResult represents the outcome of a configuration switch for JSON/YAML output
+// It is shared by every command that switches (root, auto, use --switch)
+type SwitchResult struct {
+	Previous string `json:"previous" yaml:"previous"`
+	Current  string `json:"current" yaml:"current"`
+	// Changed is false when the target was already active
+	Changed   bool                  `json:"changed" yaml:"changed"`
+	ADCSynced bool                  `json:"adc_synced" yaml:"adc_synced"`
+	Diff      []gcloud.PropertyDiff `json:"diff" yaml:"diff"`
+}
+
+// IsMachineFormat reports whether a format is meant for programs rather than people
+func IsMachineFormat(format Format) bool {
+	return format == FormatJSON || format == FormatYAML
+}
+
+// PrintSwitchResult prints a switch result in a machine format (json or yaml)
+func PrintSwitchResult(result *SwitchResult, format Format) error {
+	if result.Diff == nil {
+		result.Diff = []gcloud.PropertyDiff{}
+	}
+
+	return printDocument(result, format)
+}
+
+// ImportResult represents the outcome of an import for JSON/YAML output
+type ImportResult struct {
+	Imported []ImportedConfiguration `json:"imported" yaml:"imported"`
+	Failed   []BatchFailure          `json:"failed" yaml:"failed"`
+}
+
+// ImportedConfiguration is a configuration created by an import
+type ImportedConfiguration struct {
+	Name string `json:"name" yaml:"name"`
+	File string `json:"file" yaml:"file"`
+}
+
+// PrintImportResult prints an import result in a machine format (json or yaml)
+func PrintImportResult(result *ImportResult, format Format) error {
+	if result.Imported == nil {
+		result.Imported = []ImportedConfiguration{}
+	}
+	if result.Failed == nil {
+		result.Failed = []BatchFailure{}
+	}
+	return printDocument(result, format)
+}
+
+// PrintPaths prints resolved paths in a machine format (json or yaml)
+func PrintPaths(entries []paths.Entry, format Format) error {
+	if entries == nil {
+		entries = []paths.Entry{}
+	}
+	return printDocument(entries, format)
+}
+
+// PrintDrift prints manifest drift records in a machine format (json or yaml)
+func PrintDrift(drifts []manifest.Drift, format Format) error {
+	if drifts == nil {
+		drifts = []manifest.Drift{}
+	}
+	return printDocument(drifts, format)
+}
+
+// UsageStat is how often and how recently a configuration was switched to, for JSON/YAML output
+type UsageStat struct {
+	Name     string    `json:"name" yaml:"name"`
+	Switches int       `json:"switches" yaml:"switches"`
+	LastUsed time.Time `json:"last_used" yaml:"last_used"`
+}
+
+// PrintUsageStats prints usage statistics in a machine format (json or yaml)
+func PrintUsageStats(stats []UsageStat, format Format) error {
+	if stats == nil {
+		stats = []UsageStat{}
+	}
+	return printDocument(stats, format)
+}
+
+// PrintHistory prints the previous configurations in a machine format (json or yaml)
+func PrintHistory(entries []history.Entry, format Format) error {
+	if entries == nil {
+		entries = []history.Entry{}
+	}
+	return printDocument(entries, format)
+}
+
+// PrintActivity prints activity log entries in a machine format (json or yaml)
+func PrintActivity(entries []activity.Entry, format Format) error {
+	if entries == nil {
+		entries = []activity.Entry{}
+	}
+	return printDocument(entries, format)
+}
+
+// AccountOutput is a credentialed or configured account, for JSON/YAML output
+type AccountOutput struct {
+	Account string `json:"account" yaml:"account"`
+	// Active reports whether the active configuration uses the account
+	Active bool `json:"active" yaml:"active"`
+	// Credentialed reports whether gcloud holds a credential for the account
+	Credentialed   bool     `json:"credentialed" yaml:"credentialed"`
+	Configurations []string `json:"configurations" yaml:"configurations"`
+}
+
+// PrintAccounts prints accounts in a machine format (json or yaml)
+func PrintAccounts(accounts []AccountOutput, format Format) error {
+	if accounts == nil {
+		accounts = []AccountOutput{}
+	}
+	for i := range accounts {
+		if accounts[i].Configurations == nil {
+			accounts[i].Configurations = []string{}
+		}
+	}
+	return printDocument(accounts, format)
+}
+
+// WorkspaceOutput is a saved workspace, for JSON/YAML output
+type WorkspaceOutput struct {
+	Name          string `json:"name" yaml:"name"`
+	Configuration string `json:"configuration" yaml:"configuration"`
+	Project       string `json:"project,omitempty" yaml:"project,omitempty"`
+	Region        string `json:"region,omitempty" yaml:"region,omitempty"`
+	// ConfigurationExists is false once the configuration has been deleted
+	ConfigurationExists bool `json:"configuration_exists" yaml:"configuration_exists"`
+}
+
+// PrintWorkspaces prints workspaces in a machine format (json or yaml)
+func PrintWorkspaces(workspaces []WorkspaceOutput, format Format) error {
+	if workspaces == nil {
+		workspaces = []WorkspaceOutput{}
+	}
+	return printDocument(workspaces, format)
+}
+
+// VersionInfo describes the gcloudctx build, for 'gcloudctx version' and --version
+type VersionInfo struct {
+	Version   string `json:"version" yaml:"version"`
+	Commit    string `json:"commit" yaml:"commit"`
+	Date      string `json:"date" yaml:"date"`
+	GoVersion string `json:"go_version" yaml:"go_version"`
+	OS        string `json:"os" yaml:"os"`
+	Arch      string `json:"arch" yaml:"arch"`
+	// GcloudVersion is the Google Cloud SDK version, empty unless requested
+	GcloudVersion string `json:"gcloud_version,omitempty" yaml:"gcloud_version,omitempty"`
+}
+
+// String returns the one-line version shown by --version: the version, the
+// short commit and the build date, each left out when the build did not set it
+func (v VersionInfo) String() string {
+	result := v.Version
+	if v.Commit != "none" {
+		result += " (commit: " + v.Commit[:min(7, len(v.Commit))] + ")"
+	}
+	if v.Date != "unknown" {
+		result += " built at " + v.Date
+	}
+	return result
+}
+
+// PrintVersionInfo prints version information in a machine format (json or yaml)
+func PrintVersionInfo(info VersionInfo, format Format) error {
+	return printDocument(info, format)
+}
+
+// printDocument prints a result document as YAML, or as indented JSON otherwise
+func printDocument(v any, format Format) error {
+	switch format {
+	case FormatYAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
 	default:
-		return "", fmt.Errorf("unsupported output format: %s (supported: json, yaml, wide, name)", format)
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
 	}
+	return nil
 }
@@ -37,6 +37,11 @@ func TestTruncateString(t *testing.T) {
 		{"exact", 5, "exact"},
 		{"toolong", 5, "to..."},
 		{"abc", 2, "ab"},
+		{"プロジェクト-本番環境", 8, "プロ..."},
+		{"プロジェクト", 12, "プロジェクト"},
+		{"プロジェクト", 3, "プ"},
+		{"🚀🚀🚀 launch", 7, "🚀🚀..."},
+		{"ümlaut", 6, "ümlaut"},
 	}
 
 	for _, tt := range tests {
@@ -45,11 +50,42 @@ func TestTruncateString(t *testing.T) {
 			if result != tt.expected {
 				t.Errorf("Expected %q, got %q", tt.expected, result)
 			}
+			if width := displayWidth(result); width > tt.maxLen {
+				t.Errorf("TruncateString(%q, %d) takes %d columns", tt.input, tt.maxLen, width)
+			}
+		})
+	}
+}
+
+func TestTruncateMiddle(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxLen   int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"exact", 5, "exact"},
+		{"abcdefghij", 7, "abc…hij"},
+		{"abcdefghij", 6, "ab…hij"},
+		{"abcdef", 1, "a"},
+		{"abcdef", 0, ""},
+		{"team-platform-sandbox-environment-us-central1-generated-a1b2c3d", 20, "team-plat…ed-a1b2c3d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := TruncateMiddle(tt.input, tt.maxLen)
+			if result != tt.expected {
+				t.Errorf("TruncateMiddle(%q, %d) = %q, want %q", tt.input, tt.maxLen, result, tt.expected)
+			}
+			if got := len([]rune(result)); got > max(tt.maxLen, 0) {
+				t.Errorf("TruncateMiddle(%q, %d) has %d characters", tt.input, tt.maxLen, got)
+			}
 		})
 	}
 }
 
-func TestRemoveANSICodes(t *testing.T) {
+func TestStripANSI(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
@@ -58,11 +94,16 @@ func TestRemoveANSICodes(t *testing.T) {
 		{"no codes", "plain text", "plain text"},
 		{"with color", "\x1b[31mred text\x1b[0m", "red text"},
 		{"multiple codes", "\x1b[1m\x1b[31mbold red\x1b[0m", "bold red"},
+		{"erase line", "\x1b[Kname\x1b[m", "name"},
+		{"256 colors", "\x1b[38;5;208mname\x1b[0m", "name"},
+		{"two-character escape", "\x1b7name\x1b8", "name"},
+		{"truncated sequence", "name\x1b[3", "name"},
+		{"multi-byte text", "\x1b[33m→ dev\x1b[0m", "→ dev"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := removeANSICodes(tt.input)
+			result := StripANSI(tt.input)
 			if result != tt.expected {
 				t.Errorf("Expected %q, got %q", tt.expected, result)
 			}
@@ -126,3 +167,23 @@ func TestPrintCurrentConfigurationDoesNotPanic(t *testing.T) {
 
 	PrintCurrentConfiguration(config, false)
 }
+
+func TestVersionInfoString(t *testing.T) {
+	tests := []struct {
+		name string
+		info VersionInfo
+		want string
+	}{
+		{"ldflags unset", VersionInfo{Version: "dev", Commit: "none", Date: "unknown"}, "dev"},
+		{"release", VersionInfo{Version: "v1.2.3", Commit: "0123456789abcdef", Date: "2026-01-02T03:04:05Z"}, "v1.2.3 (commit: 0123456) built at 2026-01-02T03:04:05Z"},
+		{"short commit", VersionInfo{Version: "v1.2.3", Commit: "abc", Date: "unknown"}, "v1.2.3 (commit: abc)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
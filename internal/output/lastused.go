@@ -0,0 +1,51 @@
+package output
+
+import (
+	"fmt"
+	"time"
+)
+
+// lastUsed maps configuration names to when gcloudctx last switched to them;
+// nil means usage is unknown and the last-used line is left out of views
+var lastUsed map[string]time.Time
+
+// now is the clock relative times are measured against
+var now = time.Now
+
+// SetLastUsed provides when each configuration was last used
+func SetLastUsed(usage map[string]time.Time) {
+	if usage == nil {
+		usage = map[string]time.Time{}
+	}
+	lastUsed = usage
+}
+
+// lastUsedText returns how long ago a configuration was last used, or "-"
+func lastUsedText(name string) string {
+	return FormatRelativeTime(lastUsed[name], now())
+}
+
+// FormatRelativeTime describes t relative to now in the largest whole unit:
+// "just now", "5m ago", "3h ago", "2d ago", "3w ago" or "2y ago".
+// The zero time yields "-"; times in the future count as "just now".
+func FormatRelativeTime(t, now time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	elapsed := now.Sub(t)
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm ago", int(elapsed/time.Minute))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(elapsed/time.Hour))
+	case elapsed < 7*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(elapsed/(24*time.Hour)))
+	case elapsed < 365*24*time.Hour:
+		return fmt.Sprintf("%dw ago", int(elapsed/(7*24*time.Hour)))
+	default:
+		return fmt.Sprintf("%dy ago", int(elapsed/(365*24*time.Hour)))
+	}
+}
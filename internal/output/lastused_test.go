@@ -0,0 +1,33 @@
+package output
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatRelativeTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{"never", time.Time{}, "-"},
+		{"just now", now.Add(-20 * time.Second), "just now"},
+		{"future", now.Add(time.Hour), "just now"},
+		{"minutes", now.Add(-5 * time.Minute), "5m ago"},
+		{"hours", now.Add(-3*time.Hour - 59*time.Minute), "3h ago"},
+		{"days", now.Add(-2 * 24 * time.Hour), "2d ago"},
+		{"weeks", now.Add(-3 * 7 * 24 * time.Hour), "3w ago"},
+		{"years", now.Add(-2 * 365 * 24 * time.Hour), "2y ago"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatRelativeTime(tt.t, now); got != tt.want {
+				t.Errorf("FormatRelativeTime() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
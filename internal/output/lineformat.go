@@ -0,0 +1,177 @@
+package output
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
+	"github.com/fatih/color"
+)
+
+// Built-in line templates, used when the settings do not override them
+var (
+	defaultListTemplate   = linefmt.MustParse("list_format", linefmt.DefaultListFormat)
+	defaultPickerTemplate = linefmt.MustParse("picker_format", linefmt.DefaultPickerFormat)
+)
+
+// Line formats are set via SetLineFormats from the user's settings
+var (
+	listTemplate   = defaultListTemplate
+	pickerTemplate = defaultPickerTemplate
+	activeMarker   = linefmt.DefaultActiveMarker
+)
+
+// pinnedConfiguration is the configuration the current terminal is pinned to
+var pinnedConfiguration string
+
+// previousConfiguration is the configuration 'gcloudctx -' switches to
+var previousConfiguration string
+
+// protectedConfigurations are the configurations that ask for confirmation before switching
+var protectedConfigurations []string
+
+// SetLineFormats sets the list and picker line templates and the active marker
+// A nil template or empty marker keeps the built-in default
+func SetLineFormats(list, picker *linefmt.Template, marker string) {
+	listTemplate = defaultListTemplate
+	if list != nil {
+		listTemplate = list
+	}
+	pickerTemplate = defaultPickerTemplate
+	if picker != nil {
+		pickerTemplate = picker
+	}
+	activeMarker = linefmt.DefaultActiveMarker
+	if marker != "" {
+		activeMarker = marker
+	}
+}
+
+// SetPinnedConfiguration records the configuration the current terminal is pinned to
+func SetPinnedConfiguration(name string) {
+	pinnedConfiguration = name
+}
+
+// SetPreviousConfiguration records the configuration 'gcloudctx -' switches to,
+// which lists mark with linefmt.PreviousMarker; "" when there is none
+func SetPreviousConfiguration(name string) {
+	previousConfiguration = name
+}
+
+// isPrevious reports whether the named configuration is the previous one
+func isPrevious(name string) bool {
+	return previousConfiguration != "" && name == previousConfiguration
+}
+
+// stateMarker returns the marker column of a configuration
+func stateMarker(name string, active bool) string {
+	return linefmt.StateMarker(activeMarker, active, isPrevious(name))
+}
+
+// SetProtectedConfigurations records the protected configurations, which lists show in red
+func SetProtectedConfigurations(names []string) {
+	protectedConfigurations = names
+}
+
+// isProtected reports whether the named configuration is protected
+func isProtected(name string) bool {
+	return slices.Contains(protectedConfigurations, name)
+}
+
+// DisplayStyle overrides how a configuration is shown
+type DisplayStyle struct {
+	// Color is one of linefmt.ColorNames, or empty for the default colors
+	Color string
+	// Emoji is shown before the name, or empty for none
+	Emoji string
+}
+
+// displayStyles are the per-configuration display overrides, keyed by name
+var displayStyles map[string]DisplayStyle
+
+// SetDisplayStyles records the per-configuration display overrides
+func SetDisplayStyles(styles map[string]DisplayStyle) {
+	displayStyles = styles
+}
+
+// DisplayEmoji returns the emoji set for the named configuration, empty when none is set
+func DisplayEmoji(name string) string {
+	return displayStyles[name].Emoji
+}
+
+// nameColor returns the function coloring a configuration's name: its display
+// color (bold when active), else red when protected, yellow when active and cyan
+func nameColor(name string, active bool) func(a ...any) string {
+	if style := displayStyles[name]; style.Color != "" {
+		spec := style.Color
+		if active {
+			spec += " bold"
+		}
+		return func(a ...any) string { return linefmt.Paint(spec, fmt.Sprint(a...)) }
+	}
+	switch {
+	case isProtected(name):
+		return color.New(color.FgRed).SprintFunc()
+	case active:
+		return color.New(color.FgYellow, color.Bold).SprintFunc()
+	default:
+		return color.New(color.FgCyan).SprintFunc()
+	}
+}
+
+// emojiPrefix returns the display emoji of the named configuration followed by
+// a space, or "" when none is set
+func emojiPrefix(name string) string {
+	if emoji := DisplayEmoji(name); emoji != "" {
+		return emoji + " "
+	}
+	return ""
+}
+
+// StyledName returns the quoted configuration name for messages, in its display
+// color and after its emoji when they are set
+func StyledName(name string) string {
+	quoted := fmt.Sprintf("%q", name)
+	if style := displayStyles[name]; style.Color != "" {
+		quoted = linefmt.Paint(style.Color+" bold", quoted)
+	}
+	return emojiPrefix(name) + quoted
+}
+
+// lineFields collects the template fields of a configuration shown as displayName
+func lineFields(config *gcloud.Configuration, displayName string, active bool) linefmt.Fields {
+	return linefmt.Fields{
+		Name:       displayName,
+		Account:    config.Properties.Core.Account,
+		Project:    config.Properties.Core.Project,
+		Region:     config.Properties.Compute.Region,
+		Zone:       config.Properties.Compute.Zone,
+		IsActive:   active,
+		IsPrevious: !active && isPrevious(config.Name),
+		Pinned:     pinnedConfiguration != "" && config.Name == pinnedConfiguration,
+		Protected:  isProtected(config.Name),
+		Color:      displayStyles[config.Name].Color,
+		Emoji:      displayStyles[config.Name].Emoji,
+		Marker:     stateMarker(config.Name, active),
+	}
+}
+
+// renderLine renders fields with t, falling back to the built-in template when
+// a configuration trips an error the sample data did not
+func renderLine(t, fallback *linefmt.Template, fields linefmt.Fields) string {
+	if line, err := t.Render(fields); err == nil {
+		return line
+	}
+	line, _ := fallback.Render(fields)
+	return line
+}
+
+// FormatPickerLine renders the visible part of an fzf picker line
+// Tabs and line breaks are replaced so the line stays one fzf entry whose hidden
+// name field is still the first tab-separated field
+func FormatPickerLine(config *gcloud.Configuration, displayName string, isCurrent bool) string {
+	line := renderLine(pickerTemplate, defaultPickerTemplate, lineFields(config, displayName, isCurrent))
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(line)
+}
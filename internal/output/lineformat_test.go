@@ -0,0 +1,164 @@
+package output
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/golden"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
+	"github.com/fatih/color"
+)
+
+func TestStandardListDefaultUnchanged(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+	SetLineFormats(nil, nil, "")
+	configs := goldenConfigs()
+
+	var buf bytes.Buffer
+	standardViews{}.list(&buf, configs)
+
+	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	gray := color.New(color.FgHiBlack).SprintFunc()
+	want := "* " + yellow("prod") + " " + gray("(admin@example.com)") + " " + gray("[prod-project]") + "\n" +
+		"  " + cyan("dev") + " " + gray("[dev-project]") + "\n"
+	if buf.String() != want {
+		t.Errorf("default list = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestStandardListProtected(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	defer SetProtectedConfigurations(nil)
+	color.NoColor = false
+	SetLineFormats(nil, nil, "")
+	SetProtectedConfigurations([]string{"prod", "dev"})
+
+	var buf bytes.Buffer
+	standardViews{}.list(&buf, goldenConfigs())
+
+	red := color.New(color.FgRed).SprintFunc()
+	for _, name := range []string{"prod", "dev"} {
+		if !strings.Contains(buf.String(), red(name)) {
+			t.Errorf("list = %q, want %s in red", buf.String(), name)
+		}
+	}
+
+	picker := FormatPickerLine(&goldenConfigs()[1], "dev", false)
+	if picker != "  "+red("dev")+" [dev-project]" {
+		t.Errorf("FormatPickerLine() = %q, want the name in red", picker)
+	}
+}
+
+func TestListMarksPrevious(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	defer SetLineFormats(nil, nil, "")
+	defer SetPreviousConfiguration("")
+	color.NoColor = true
+	SetPreviousConfiguration("dev")
+
+	tests := []struct {
+		marker string
+		want   string
+	}{
+		{"", "^ dev [dev-project]"},
+		{"→→", "^  dev [dev-project]"},
+	}
+	for _, tt := range tests {
+		SetLineFormats(nil, nil, tt.marker)
+
+		var buf bytes.Buffer
+		standardViews{}.list(&buf, goldenConfigs())
+		if lines := strings.Split(buf.String(), "\n"); lines[1] != tt.want {
+			t.Errorf("with marker %q the previous line = %q, want %q", tt.marker, lines[1], tt.want)
+		}
+	}
+
+	// The active configuration keeps its marker even when history names it
+	SetLineFormats(nil, nil, "")
+	SetPreviousConfiguration("prod")
+	if line := FormatPickerLine(&goldenConfigs()[0], "prod", true); !strings.HasPrefix(line, linefmt.DefaultActiveMarker+" ") {
+		t.Errorf("FormatPickerLine() = %q, want the active marker", line)
+	}
+
+	var buf bytes.Buffer
+	SetPreviousConfiguration("dev")
+	accessibleViews{}.list(&buf, goldenConfigs())
+	if !strings.Contains(buf.String(), "previous configuration: dev") {
+		t.Errorf("accessible list = %q, want dev announced as previous", buf.String())
+	}
+}
+
+func TestStandardListFormatGolden(t *testing.T) {
+	color.NoColor = true
+	defer SetLineFormats(nil, nil, "")
+	defer SetPinnedConfiguration("")
+
+	tests := []struct {
+		name   string
+		format string
+		marker string
+		pinned string
+	}{
+		{"default", "", "", ""},
+		{"project-first", `{{.Marker}} {{with .Project}}[{{.}}] {{end}}{{.Name}}`, "", ""},
+		{"marker", "", "→", ""},
+		{"pinned", `{{.Marker}} {{.Name}}{{if .Pinned}} (pinned){{end}}`, "", "dev"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var list *linefmt.Template
+			if tt.format != "" {
+				var err error
+				if list, err = linefmt.Parse("list_format", tt.format); err != nil {
+					t.Fatalf("Parse() error = %v", err)
+				}
+			}
+			SetLineFormats(list, nil, tt.marker)
+			SetPinnedConfiguration(tt.pinned)
+
+			var buf bytes.Buffer
+			standardViews{}.list(&buf, goldenConfigs())
+			golden.Assert(t, filepath.Join("testdata", "list", tt.name+".golden"), buf.String())
+		})
+	}
+}
+
+func TestStandardListOptionsGolden(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	defer SetListOptions(ListOptions{})
+	SetLineFormats(nil, nil, "")
+
+	tests := []struct {
+		name  string
+		opts  ListOptions
+		color bool
+	}{
+		{"header", ListOptions{ShowHeader: true}, false},
+		{"header-color", ListOptions{ShowHeader: true}, true},
+		{"highlight-active", ListOptions{HighlightActive: true}, true},
+		{"dim-missing-project", ListOptions{DimMissingProject: true}, true},
+		{"header-highlight-dim", ListOptions{ShowHeader: true, HighlightActive: true, DimMissingProject: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			color.NoColor = !tt.color
+			SetListOptions(tt.opts)
+
+			configs := append(goldenConfigs(), gcloud.Configuration{
+				Name:       "scratch",
+				Properties: gcloud.Properties{Core: gcloud.CoreProperties{Account: "me@example.com"}},
+			})
+
+			var buf bytes.Buffer
+			standardViews{}.list(&buf, configs)
+			golden.Assert(t, filepath.Join("testdata", "list", tt.name+".golden"), buf.String())
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
+	"github.com/fatih/color"
+)
+
+// ListOptions controls the presentation of the default list view
+type ListOptions struct {
+	// ShowHeader prints the list as an aligned table with a header row
+	// instead of one list_format line per configuration
+	ShowHeader bool
+	// HighlightActive highlights the whole line of the active configuration
+	HighlightActive bool
+	// DimMissingProject dims configurations without a project
+	DimMissingProject bool
+}
+
+// listOptions is set via SetListOptions from the user's settings and flags
+var listOptions ListOptions
+
+// SetListOptions sets the presentation options of the default list view
+func SetListOptions(opts ListOptions) {
+	listOptions = opts
+}
+
+// listHeader is the header row of the list table
+var listHeader = []string{"NAME", "ACCOUNT", "PROJECT"}
+
+// writeList renders the default list view according to listOptions
+func writeList(w io.Writer, configs []gcloud.Configuration) {
+	var lines []string
+	if listOptions.ShowHeader {
+		lines = listTable(configs)
+		bold := color.New(color.Bold).SprintFunc()
+		fmt.Fprintln(w, bold(lines[0]))
+		lines = lines[1:]
+	} else {
+		for i := range configs {
+			fields := lineFields(&configs[i], configs[i].Name, configs[i].IsActive)
+			lines = append(lines, renderLine(listTemplate, defaultListTemplate, fields))
+		}
+	}
+
+	for i, line := range lines {
+		fmt.Fprintln(w, emphasizeRow(line, &configs[i]))
+	}
+}
+
+// listTable lays the configurations out as a header row plus aligned rows
+func listTable(configs []gcloud.Configuration) []string {
+	gray := color.New(color.FgHiBlack).SprintFunc()
+
+	orDash := func(value string) string {
+		if value == "" {
+			return gray("-")
+		}
+		return value
+	}
+
+	rows := [][]string{listHeader}
+	markers := []string{linefmt.Marker(activeMarker, false)}
+	for _, config := range configs {
+		rows = append(rows, []string{
+			emojiPrefix(config.Name) + nameColor(config.Name, config.IsActive)(config.Name),
+			orDash(config.Properties.Core.Account),
+			orDash(config.Properties.Core.Project),
+		})
+		markers = append(markers, stateMarker(config.Name, config.IsActive))
+	}
+
+	lines := AlignColumns(rows, 2)
+	for i := range lines {
+		lines[i] = markers[i] + " " + lines[i]
+	}
+	return lines
+}
+
+// emphasizeRow applies whole-line highlighting and dimming to a rendered row
+// The row's own colors are dropped so the emphasis covers the line evenly
+func emphasizeRow(line string, config *gcloud.Configuration) string {
+	switch {
+	case listOptions.HighlightActive && config.IsActive:
+		return color.New(color.FgYellow, color.Bold, color.ReverseVideo).Sprint(StripANSI(line))
+	case listOptions.DimMissingProject && config.Properties.Core.Project == "":
+		return color.New(color.FgHiBlack).Sprint(StripANSI(line))
+	default:
+		return line
+	}
+}
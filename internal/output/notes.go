@@ -0,0 +1,55 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// noteColumnWidth is how much of a note the NOTES column of wide lists shows
+const noteColumnWidth = 30
+
+// notes maps configuration names to their notes; configurations without a
+// note are absent
+var notes map[string]string
+
+// SetNotes provides the notes of configurations for lists, details and previews
+func SetNotes(texts map[string]string) {
+	notes = texts
+}
+
+// noteValue returns the first line of a configuration's note, truncated for the NOTES column
+func noteValue(c *gcloud.Configuration) string {
+	first, rest, _ := strings.Cut(notes[c.Name], "\n")
+	if rest != "" {
+		first += " ..."
+	}
+	return TruncateString(first, noteColumnWidth)
+}
+
+// noteText returns a configuration's full note, with lines joined for csv and tsv
+func noteText(c *gcloud.Configuration) string {
+	return strings.ReplaceAll(notes[c.Name], "\n", " / ")
+}
+
+// wrapText breaks each line of text at spaces so lines fit in width columns
+// Words longer than width are left whole
+func wrapText(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case displayWidth(line)+1+displayWidth(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,66 @@
+package output
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/fatih/color"
+)
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  []string
+	}{
+		{"client X, billing acct 42, expires Q3", 20, []string{"client X, billing", "acct 42, expires Q3"}},
+		{"short", 20, []string{"short"}},
+		{"first\n\nthird line", 20, []string{"first", "", "third line"}},
+		{"averyveryverylongword fits", 10, []string{"averyveryverylongword", "fits"}},
+	}
+
+	for _, tt := range tests {
+		if got := wrapText(tt.text, tt.width); !slices.Equal(got, tt.want) {
+			t.Errorf("wrapText(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestNoteViews(t *testing.T) {
+	color.NoColor = true
+	defer SetNotes(nil)
+	SetNotes(map[string]string{"prod": "client X, billing acct 42, expires Q3\nask #ops before changes"})
+	prod := &gcloud.Configuration{Name: "prod"}
+	dev := &gcloud.Configuration{Name: "dev"}
+
+	if got, want := noteValue(prod), "client X, billing acct 42, ..."; got != want {
+		t.Errorf("noteValue(prod) = %q, want %q", got, want)
+	}
+	if got := noteValue(dev); got != "" {
+		t.Errorf("noteValue(dev) = %q, want empty", got)
+	}
+	if got, want := noteText(prod), "client X, billing acct 42, expires Q3 / ask #ops before changes"; got != want {
+		t.Errorf("noteText(prod) = %q, want %q", got, want)
+	}
+
+	var details bytes.Buffer
+	standardViews{}.details(&details, prod)
+	if !strings.Contains(details.String(), "Note:\n  client X, billing acct 42, expires Q3\n  ask #ops before changes\n") {
+		t.Errorf("details = %q, want the full note", details.String())
+	}
+
+	var preview bytes.Buffer
+	standardViews{}.preview(&preview, prod, 24)
+	if !strings.Contains(preview.String(), "  Note:\n    client X, billing\n    acct 42, expires Q3\n    ask #ops before\n    changes\n") {
+		t.Errorf("preview = %q, want the note wrapped to the window", preview.String())
+	}
+
+	var without bytes.Buffer
+	standardViews{}.preview(&without, dev, 24)
+	if strings.Contains(without.String(), "Note") {
+		t.Errorf("preview of dev = %q, want no note", without.String())
+	}
+}
@@ -0,0 +1,178 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ProgressEventVersion is the version of the progress event schema
+// Bump it whenever a field is removed or changes meaning; adding fields is compatible
+const ProgressEventVersion = 1
+
+// Progress event stages, in the order they are emitted:
+// one "start", then "begin" followed by "done" or "failed" per target, then one "summary"
+const (
+	StageStart   = "start"
+	StageBegin   = "begin"
+	StageDone    = "done"
+	StageFailed  = "failed"
+	StageSummary = "summary"
+)
+
+// ProgressEvent is a single progress update of a long operation
+// With -o json --progress, events are written to stderr as newline-delimited JSON
+type ProgressEvent struct {
+	Version   int    `json:"version"`
+	Operation string `json:"operation"`
+	Stage     string `json:"stage"`
+	Target    string `json:"target,omitempty"`
+	Percent   int    `json:"percent"`
+	Message   string `json:"message,omitempty"`
+	// Succeeded and Failed are only set on the summary event
+	Succeeded *int `json:"succeeded,omitempty"`
+	Failed    *int `json:"failed,omitempty"`
+}
+
+// ProgressReporter receives progress events of a long operation
+type ProgressReporter interface {
+	Report(event ProgressEvent)
+	// Close stops any ongoing display; it is called once after the summary event
+	Close()
+}
+
+// NewProgressReporter selects the progress sink for an output format:
+// JSON events on stderr for -o json with progress enabled, a TTY spinner on
+// stderr for human output with progress enabled, and nothing otherwise
+func NewProgressReporter(format Format, progress bool) ProgressReporter {
+	switch {
+	case !progress:
+		return nopReporter{}
+	case format == FormatJSON:
+		return NewJSONProgressReporter(os.Stderr)
+	default:
+		return &spinnerReporter{}
+	}
+}
+
+// nopReporter discards progress events
+type nopReporter struct{}
+
+func (nopReporter) Report(ProgressEvent) {}
+func (nopReporter) Close()               {}
+
+// jsonReporter writes progress events as newline-delimited JSON
+type jsonReporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewJSONProgressReporter returns a reporter writing newline-delimited JSON events to w
+func NewJSONProgressReporter(w io.Writer) ProgressReporter {
+	return &jsonReporter{encoder: json.NewEncoder(w)}
+}
+
+func (r *jsonReporter) Report(event ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.Version = ProgressEventVersion
+	// Progress is best-effort; a closed stderr must not fail the operation
+	_ = r.encoder.Encode(event)
+}
+
+func (r *jsonReporter) Close() {}
+
+// spinnerReporter shows the current target next to a TTY spinner
+type spinnerReporter struct {
+	stop func()
+}
+
+func (r *spinnerReporter) Report(event ProgressEvent) {
+	switch event.Stage {
+	case StageBegin:
+		r.Close()
+		r.stop = StartSpinner(fmt.Sprintf("%s (%d%%)", event.Message, event.Percent))
+	case StageDone, StageFailed, StageSummary:
+		r.Close()
+	}
+}
+
+func (r *spinnerReporter) Close() {
+	if r.stop != nil {
+		r.stop()
+		r.stop = nil
+	}
+}
+
+// BatchFailure records a target of a batch operation that failed
+type BatchFailure struct {
+	Target string `json:"target" yaml:"target"`
+	Error  string `json:"error" yaml:"error"`
+}
+
+// RunBatch runs step for every target in order, reporting progress events
+// Failures do not stop the batch; they are reported and returned
+func RunBatch(reporter ProgressReporter, operation string, targets []string, step func(i int) error) []BatchFailure {
+	defer reporter.Close()
+
+	total := len(targets)
+	reporter.Report(ProgressEvent{
+		Operation: operation,
+		Stage:     StageStart,
+		Message:   fmt.Sprintf("%s %d configuration(s)", operation, total),
+	})
+
+	var failures []BatchFailure
+	for i, target := range targets {
+		reporter.Report(ProgressEvent{
+			Operation: operation,
+			Stage:     StageBegin,
+			Target:    target,
+			Percent:   percent(i, total),
+			Message:   fmt.Sprintf("%s %s", operation, target),
+		})
+
+		if err := step(i); err != nil {
+			failures = append(failures, BatchFailure{Target: target, Error: err.Error()})
+			reporter.Report(ProgressEvent{
+				Operation: operation,
+				Stage:     StageFailed,
+				Target:    target,
+				Percent:   percent(i+1, total),
+				Message:   err.Error(),
+			})
+			continue
+		}
+
+		reporter.Report(ProgressEvent{
+			Operation: operation,
+			Stage:     StageDone,
+			Target:    target,
+			Percent:   percent(i+1, total),
+		})
+	}
+
+	succeeded := total - len(failures)
+	failed := len(failures)
+	reporter.Report(ProgressEvent{
+		Operation: operation,
+		Stage:     StageSummary,
+		Percent:   100,
+		Message:   fmt.Sprintf("%d succeeded, %d failed", succeeded, failed),
+		Succeeded: &succeeded,
+		Failed:    &failed,
+	})
+
+	return failures
+}
+
+// percent returns done out of total as a whole percentage
+func percent(done, total int) int {
+	if total == 0 {
+		return 100
+	}
+	return done * 100 / total
+}
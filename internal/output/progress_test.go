@@ -0,0 +1,100 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestRunBatchJSONEvents(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewJSONProgressReporter(&buf)
+
+	failures := RunBatch(reporter, "import", []string{"dev", "stg", "prod"}, func(i int) error {
+		if i == 1 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if len(failures) != 1 || failures[0].Target != "stg" || failures[0].Error != "boom" {
+		t.Fatalf("RunBatch() failures = %+v, want one failure for stg", failures)
+	}
+
+	var events []ProgressEvent
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var event ProgressEvent
+		if err := decoder.Decode(&event); err != nil {
+			t.Fatalf("failed to decode event: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	want := []struct {
+		stage   string
+		target  string
+		percent int
+	}{
+		{StageStart, "", 0},
+		{StageBegin, "dev", 0},
+		{StageDone, "dev", 33},
+		{StageBegin, "stg", 33},
+		{StageFailed, "stg", 66},
+		{StageBegin, "prod", 66},
+		{StageDone, "prod", 100},
+		{StageSummary, "", 100},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		got := events[i]
+		if got.Version != ProgressEventVersion {
+			t.Errorf("event %d version = %d, want %d", i, got.Version, ProgressEventVersion)
+		}
+		if got.Operation != "import" || got.Stage != w.stage || got.Target != w.target || got.Percent != w.percent {
+			t.Errorf("event %d = %+v, want stage %q target %q percent %d", i, got, w.stage, w.target, w.percent)
+		}
+	}
+
+	summary := events[len(events)-1]
+	if summary.Succeeded == nil || *summary.Succeeded != 2 || summary.Failed == nil || *summary.Failed != 1 {
+		t.Errorf("summary counts = %v/%v, want 2 succeeded and 1 failed", summary.Succeeded, summary.Failed)
+	}
+	if events[0].Succeeded != nil || events[0].Failed != nil {
+		t.Errorf("start event carries summary counts: %+v", events[0])
+	}
+}
+
+func TestNewProgressReporter(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		progress bool
+		want     string
+	}{
+		{"disabled", FormatJSON, false, "nop"},
+		{"json", FormatJSON, true, "json"},
+		{"human", FormatDefault, true, "spinner"},
+		{"yaml", FormatYAML, true, "spinner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			switch NewProgressReporter(tt.format, tt.progress).(type) {
+			case nopReporter:
+				got = "nop"
+			case *jsonReporter:
+				got = "json"
+			case *spinnerReporter:
+				got = "spinner"
+			}
+			if got != tt.want {
+				t.Errorf("NewProgressReporter(%q, %v) = %s, want %s", tt.format, tt.progress, got, tt.want)
+			}
+		})
+	}
+}
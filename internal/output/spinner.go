@@ -0,0 +1,104 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+// EnvDisableSpinner turns the loading spinner off when set to "1"
+const EnvDisableSpinner = "GCLOUDCTX_DISABLE_SPINNER"
+
+// spinnerFrames are the animation frames of the loading spinner
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is the delay between spinner frames
+const spinnerInterval = 100 * time.Millisecond
+
+// spinnerDelay is how long an operation runs before the spinner appears, so
+// fast operations do not flash it
+const spinnerDelay = 300 * time.Millisecond
+
+// clock provides the timers of the spinner; tests substitute a fake
+type clock interface {
+	After(d time.Duration) <-chan time.Time
+	// Tick returns a channel delivering a tick every d and a function stopping it
+	Tick(d time.Duration) (<-chan time.Time, func())
+}
+
+// realClock is the clock of the time package
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Tick(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+	return ticker.C, ticker.Stop
+}
+
+// spinnerEnabled reports whether the spinner may be shown: stderr must be a
+// terminal, EnvDisableSpinner not set and accessible output off, since screen
+// readers would announce every frame
+func spinnerEnabled(getenv func(string) string, stderrIsTerminal bool) bool {
+	return stderrIsTerminal && getenv(EnvDisableSpinner) != "1" && !accessible
+}
+
+// StartSpinner shows an animated spinner with a message on stderr until the
+// returned stop function is called. The spinner only appears once the
+// operation has run for a moment, and never when stderr is not a terminal,
+// GCLOUDCTX_DISABLE_SPINNER=1 or output is accessible, so piped output stays
+// clean. Stop erases it before returning, so output printed afterwards starts
+// on a clean line.
+func StartSpinner(message string) (stop func()) {
+	isTerminal := isatty.IsTerminal(os.Stderr.Fd()) || isatty.IsCygwinTerminal(os.Stderr.Fd())
+	if !spinnerEnabled(os.Getenv, isTerminal) {
+		return func() {}
+	}
+	return startSpinner(os.Stderr, message, spinnerDelay, realClock{})
+}
+
+// startSpinner draws the spinner on w once delay has passed on c
+func startSpinner(w io.Writer, message string, delay time.Duration, c clock) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		// Stopping within the delay leaves the terminal untouched
+		select {
+		case <-done:
+			return
+		case <-c.After(delay):
+		}
+
+		tick, stopTick := c.Tick(spinnerInterval)
+		defer stopTick()
+
+		for i := 0; ; i++ {
+			fmt.Fprintf(w, "\r%s %s", spinnerFrames[i%len(spinnerFrames)], message)
+			select {
+			case <-done:
+				// Clear the spinner line
+				fmt.Fprintf(w, "\r\033[K")
+				return
+			case <-tick:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
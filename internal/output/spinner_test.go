@@ -0,0 +1,115 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock fires timers only when the test says so
+type fakeClock struct {
+	after chan time.Time
+	tick  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{after: make(chan time.Time), tick: make(chan time.Time)}
+}
+
+func (c *fakeClock) After(time.Duration) <-chan time.Time { return c.after }
+
+func (c *fakeClock) Tick(time.Duration) (<-chan time.Time, func()) { return c.tick, func() {} }
+
+// syncBuffer is a bytes.Buffer safe for the spinner goroutine and the test
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSpinnerStoppedWithinDelay(t *testing.T) {
+	var out syncBuffer
+	stop := startSpinner(&out, "querying gcloud...", spinnerDelay, newFakeClock())
+	stop()
+
+	if out.String() != "" {
+		t.Errorf("spinner stopped within the delay wrote %q, want nothing", out.String())
+	}
+}
+
+func TestSpinnerAppearsAfterDelay(t *testing.T) {
+	var out syncBuffer
+	clock := newFakeClock()
+	stop := startSpinner(&out, "querying gcloud...", spinnerDelay, clock)
+
+	clock.after <- time.Time{}
+	clock.tick <- time.Time{}
+	stop()
+
+	want := "\r" + spinnerFrames[0] + " querying gcloud..." +
+		"\r" + spinnerFrames[1] + " querying gcloud..." +
+		"\r\033[K"
+	if out.String() != want {
+		t.Errorf("spinner wrote %q, want %q", out.String(), want)
+	}
+	if !strings.HasSuffix(out.String(), "\r\033[K") {
+		t.Error("spinner did not erase its line")
+	}
+}
+
+func TestSpinnerStopIsIdempotent(t *testing.T) {
+	var out syncBuffer
+	clock := newFakeClock()
+	stop := startSpinner(&out, "x", spinnerDelay, clock)
+	clock.after <- time.Time{}
+	stop()
+	stop()
+
+	if got := strings.Count(out.String(), "\r\033[K"); got != 1 {
+		t.Errorf("line erased %d times, want 1", got)
+	}
+}
+
+func TestSpinnerEnabled(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        string
+		terminal   bool
+		accessible bool
+		want       bool
+	}{
+		{"terminal", "", true, false, true},
+		{"not a terminal", "", false, false, false},
+		{"disabled", "1", true, false, false},
+		{"accessible", "", true, true, false},
+	}
+
+	defer SetAccessible(false)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetAccessible(tt.accessible)
+			getenv := func(key string) string {
+				if key == EnvDisableSpinner {
+					return tt.env
+				}
+				return ""
+			}
+			if got := spinnerEnabled(getenv, tt.terminal); got != tt.want {
+				t.Errorf("spinnerEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
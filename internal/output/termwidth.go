@@ -0,0 +1,48 @@
+package output
+
+import (
+	"os"
+	"strconv"
+)
+
+// TerminalWidth returns the width of the terminal attached to stdout, or 0 if unknown
+// The COLUMNS environment variable takes precedence when it is a positive number
+func TerminalWidth() int {
+	if value := os.Getenv("COLUMNS"); value != "" {
+		if width, err := strconv.Atoi(value); err == nil && width > 0 {
+			return width
+		}
+	}
+	return terminalWidth(os.Stdout.Fd())
+}
+
+// fitColumns shrinks column widths until their sum fits in available, never going below floors
+// The widest shrinkable column loses a character first, so long columns give way before short ones;
+// a non-positive available width leaves the widths untouched
+func fitColumns(widths, floors []int, available int) []int {
+	fitted := append([]int(nil), widths...)
+	if available <= 0 {
+		return fitted
+	}
+
+	total := 0
+	for _, width := range fitted {
+		total += width
+	}
+
+	for total > available {
+		widest := -1
+		for i, width := range fitted {
+			if width > floors[i] && (widest < 0 || width > fitted[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break // every column is at its floor
+		}
+		fitted[widest]--
+		total--
+	}
+
+	return fitted
+}
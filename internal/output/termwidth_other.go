@@ -0,0 +1,8 @@
+//go:build !unix
+
+package output
+
+// terminalWidth is not supported on this platform; the table is printed unshrunk
+func terminalWidth(fd uintptr) int {
+	return 0
+}
@@ -0,0 +1,100 @@
+package output
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/fatih/color"
+)
+
+func TestFitColumns(t *testing.T) {
+	tests := []struct {
+		name      string
+		widths    []int
+		floors    []int
+		available int
+		expected  []int
+	}{
+		{"fits already", []int{10, 10}, []int{5, 5}, 30, []int{10, 10}},
+		{"unknown width", []int{60, 10}, []int{20, 5}, 0, []int{60, 10}},
+		{"widest shrinks first", []int{60, 10}, []int{20, 5}, 50, []int{40, 10}},
+		{"shrinks evenly once equal", []int{30, 30}, []int{10, 10}, 40, []int{20, 20}},
+		{"stops at floors", []int{60, 30}, []int{20, 12}, 10, []int{20, 12}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fitColumns(tt.widths, tt.floors, tt.available)
+			if !slices.Equal(result, tt.expected) {
+				t.Errorf("fitColumns() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTerminalWidthFromColumns(t *testing.T) {
+	t.Setenv("COLUMNS", "123")
+	if got := TerminalWidth(); got != 123 {
+		t.Errorf("TerminalWidth() = %d, want 123", got)
+	}
+}
+
+func TestStandardWideLongNames(t *testing.T) {
+	color.NoColor = true
+	longName := "team-platform-sandbox-environment-us-central1-generated-a1b2c3d"
+	configs := []gcloud.Configuration{
+		{
+			Name:     longName,
+			IsActive: true,
+			Properties: gcloud.Properties{
+				Core:    gcloud.CoreProperties{Account: "admin@example.com", Project: "sandbox-project"},
+				Compute: gcloud.ComputeProperties{Region: "us-central1", Zone: "us-central1-a"},
+			},
+		},
+		{Name: "dev"},
+	}
+
+	t.Run("unknown terminal width keeps full names", func(t *testing.T) {
+		var buf bytes.Buffer
+		standardViews{}.wide(&buf, configs, DefaultColumns(), 0)
+		if !strings.Contains(buf.String(), longName) {
+			t.Errorf("expected full name in output:\n%s", buf.String())
+		}
+	})
+
+	t.Run("narrow terminal shrinks to the name floor", func(t *testing.T) {
+		const termWidth = 100
+
+		var buf bytes.Buffer
+		standardViews{}.wide(&buf, configs, DefaultColumns(), termWidth)
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		for _, line := range lines {
+			if got := len([]rune(line)); got > termWidth {
+				t.Errorf("line exceeds terminal width (%d > %d): %q", got, termWidth, line)
+			}
+		}
+
+		fields := strings.Fields(lines[1])
+		name := fields[1]
+		if got := len([]rune(name)); got < allColumns[0].floor {
+			t.Errorf("name %q shrank below the floor of %d", name, allColumns[0].floor)
+		}
+		if !strings.HasSuffix(name, "a1b2c3d") {
+			t.Errorf("name %q lost its distinctive suffix", name)
+		}
+	})
+
+	t.Run("very narrow terminal stops at floors", func(t *testing.T) {
+		var buf bytes.Buffer
+		standardViews{}.wide(&buf, configs, DefaultColumns(), 40)
+
+		name := strings.Fields(strings.Split(buf.String(), "\n")[1])[1]
+		if got := len([]rune(name)); got != allColumns[0].floor {
+			t.Errorf("name %q has %d characters, want the floor of %d", name, got, allColumns[0].floor)
+		}
+	})
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package output
+
+import "golang.org/x/sys/unix"
+
+// terminalWidth queries the window size of the terminal behind fd
+func terminalWidth(fd uintptr) int {
+	ws, err := unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0
+	}
+	return int(ws.Col)
+}
@@ -0,0 +1,569 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
+	"github.com/fatih/color"
+)
+
+// EnvAccessible enables accessible output when set to "1"
+const EnvAccessible = "GCLOUDCTX_ACCESSIBLE"
+
+// bannerKind identifies the type of a status banner
+type bannerKind int
+
+const (
+	bannerError bannerKind = iota
+	bannerSuccess
+)
+
+// viewSet renders the human-readable views of gcloudctx.
+// Machine formats (json, yaml, name) never go through a view set.
+type viewSet interface {
+	list(w io.Writer, configs []gcloud.Configuration)
+	wide(w io.Writer, configs []gcloud.Configuration, columns []Column, termWidth int)
+	current(w io.Writer, config *gcloud.Configuration)
+	details(w io.Writer, config *gcloud.Configuration)
+	sections(w io.Writer, sections map[string]map[string]string)
+	preview(w io.Writer, config *gcloud.Configuration, width int)
+	projectPreview(w io.Writer, project *gcloud.Project, width int)
+	diff(w io.Writer, diffs []gcloud.PropertyDiff, expanded bool)
+	banner(w io.Writer, kind bannerKind, message string)
+}
+
+// accessible is set via SetAccessible from the user's settings
+var accessible bool
+
+// SetAccessible enables or disables the accessible view set
+func SetAccessible(enabled bool) {
+	accessible = enabled
+}
+
+// showAll is set via SetShowAll from the --show-all flag
+var showAll bool
+
+// SetShowAll makes the details view include properties that are usually left out
+func SetShowAll(enabled bool) {
+	showAll = enabled
+}
+
+// IsAccessible reports whether accessible output is enabled
+func IsAccessible() bool {
+	return accessible || os.Getenv(EnvAccessible) == "1"
+}
+
+// currentViews is the template selector: every Print* function renders through
+// the view set it returns, so presentation is chosen in exactly one place
+func currentViews() viewSet {
+	if IsAccessible() {
+		return accessibleViews{}
+	}
+	return standardViews{}
+}
+
+// standardViews renders the default compact, decorated output
+type standardViews struct{}
+
+func (standardViews) list(w io.Writer, configs []gcloud.Configuration) {
+	writeList(w, configs)
+}
+
+// wideSeparator is the gap between wide table columns
+const wideSeparator = "  "
+
+func (standardViews) wide(w io.Writer, configs []gcloud.Configuration, columns []Column, termWidth int) {
+	gray := color.New(color.FgHiBlack).SprintFunc()
+	bold := color.New(color.Bold).SprintFunc()
+
+	rows := make([][]string, len(configs))
+	for i := range configs {
+		rows[i] = make([]string, len(columns))
+		for j, column := range columns {
+			rows[i][j] = column.value(&configs[i])
+			if column.Name == ColumnName {
+				rows[i][j] = emojiPrefix(configs[i].Name) + rows[i][j]
+			}
+		}
+	}
+	widths := wideColumnWidths(columns, rows, termWidth)
+
+	// Print header
+	fmt.Fprint(w, bold(linefmt.Marker(activeMarker, false)))
+	for i, column := range columns {
+		fmt.Fprint(w, wideSeparator+padCell(bold(column.title), len(column.title), widths[i], i == len(columns)-1))
+	}
+	fmt.Fprintln(w)
+
+	for i, config := range configs {
+		paintName := nameColor(config.Name, config.IsActive)
+		prefix := emojiPrefix(config.Name)
+
+		fmt.Fprint(w, stateMarker(config.Name, config.IsActive))
+		for j, value := range rows[i] {
+			last := j == len(rows[i])-1
+			isName := columns[j].Name == ColumnName
+
+			if value == "" {
+				fmt.Fprint(w, wideSeparator+padCell(gray("-"), 1, widths[j], last))
+				continue
+			}
+
+			var cell string
+			switch {
+			case isName:
+				// Keep the distinctive end of long names visible; the emoji stays whole
+				cell = prefix + paintName(TruncateMiddle(strings.TrimPrefix(value, prefix), widths[j]-displayWidth(prefix)))
+			case last:
+				cell = value
+			default:
+				cell = TruncateString(value, widths[j])
+			}
+			cellLen := displayWidth(StripANSI(cell))
+			fmt.Fprint(w, wideSeparator+padCell(cell, cellLen, widths[j], last))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// wideColumnWidths sizes the wide table columns to their content, then shrinks them
+// to the terminal width (when known) without going below the columns' floors
+func wideColumnWidths(columns []Column, rows [][]string, termWidth int) []int {
+	widths := make([]int, len(columns))
+	floors := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = max(column.minWidth, len(column.title))
+		floors[i] = column.floor
+	}
+	// The last column is not padded, so it needs no minimum width
+	widths[len(widths)-1] = len(columns[len(columns)-1].title)
+
+	for _, row := range rows {
+		for i, value := range row {
+			widths[i] = max(widths[i], displayWidth(value))
+		}
+	}
+
+	// The last column is never truncated, so its floor is its full width
+	floors[len(floors)-1] = widths[len(widths)-1]
+
+	available := 0
+	if termWidth > 0 {
+		// Marker column plus one separator before every column
+		markerWidth := len([]rune(activeMarker))
+		available = max(termWidth-markerWidth-len(wideSeparator)*len(widths), 1)
+	}
+	return fitColumns(widths, floors, available)
+}
+
+// padCell pads a (possibly colored) cell of visible length cellLen to width
+// The last column is not padded to avoid trailing whitespace
+func padCell(cell string, cellLen, width int, last bool) string {
+	if last || cellLen >= width {
+		return cell
+	}
+	return cell + strings.Repeat(" ", width-cellLen)
+}
+
+func (standardViews) current(w io.Writer, config *gcloud.Configuration) {
+	paint := color.New(color.FgYellow, color.Bold).SprintFunc()
+	if displayStyles[config.Name].Color != "" {
+		paint = nameColor(config.Name, true)
+	}
+	fmt.Fprintln(w, paint(config.Name))
+}
+
+func (standardViews) details(w io.Writer, config *gcloud.Configuration) {
+	cyan := color.New(color.FgCyan).SprintFunc()
+	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
+
+	fmt.Fprintf(w, "%s: %s\n", cyan("Configuration"), yellow(config.Name))
+
+	if config.IsActive {
+		fmt.Fprintf(w, "%s: %s\n", cyan("Status"), yellow("active"))
+	} else {
+		fmt.Fprintf(w, "%s: inactive\n", cyan("Status"))
+	}
+
+	if account := config.Properties.Core.Account; account != "" {
+		fmt.Fprintf(w, "%s: %s\n", cyan("Account"), account)
+	}
+
+	if project := config.Properties.Core.Project; project != "" {
+		fmt.Fprintf(w, "%s: %s\n", cyan("Project"), project)
+	}
+
+	if region := config.Properties.Compute.Region; region != "" {
+		fmt.Fprintf(w, "%s: %s\n", cyan("Region"), region)
+	}
+
+	if zone := config.Properties.Compute.Zone; zone != "" {
+		fmt.Fprintf(w, "%s: %s\n", cyan("Zone"), zone)
+	}
+
+	if value := usageReportingValue(config); showAll && value != "" {
+		fmt.Fprintf(w, "%s: %s\n", cyan("Usage reporting"), value)
+	}
+
+	if lastUsed != nil {
+		fmt.Fprintf(w, "%s: %s\n", cyan("Last used"), lastUsedText(config.Name))
+	}
+
+	if note := notes[config.Name]; note != "" {
+		fmt.Fprintf(w, "%s:\n", cyan("Note"))
+		for _, line := range strings.Split(note, "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
+
+func (standardViews) sections(w io.Writer, sections map[string]map[string]string) {
+	cyan := color.New(color.FgCyan).SprintFunc()
+	bold := color.New(color.Bold).SprintFunc()
+
+	for _, section := range sortedSectionNames(sections) {
+		fmt.Fprintf(w, "\n%s\n", bold("["+section+"]"))
+		for _, name := range sortedKeys(sections[section]) {
+			fmt.Fprintf(w, "%s: %s\n", cyan(name), sections[section][name])
+		}
+	}
+}
+
+// previewRule is a horizontal rule across a preview window width columns wide
+func previewRule(width int) string {
+	if width <= 0 {
+		width = DefaultPreviewWidth
+	}
+	return strings.Repeat("━", width)
+}
+
+func (standardViews) preview(w io.Writer, config *gcloud.Configuration, width int) {
+	fmt.Fprintln(w, previewRule(width))
+	fmt.Fprintf(w, "  Configuration: %s\n", config.Name)
+	fmt.Fprintf(w, "%s\n\n", previewRule(width))
+
+	if config.IsActive {
+		fmt.Fprintf(w, "  Status:  ✓ Active\n")
+	} else {
+		fmt.Fprintf(w, "  Status:  Inactive\n")
+	}
+
+	if config.Properties.Core.Account != "" {
+		fmt.Fprintf(w, "  Account: %s\n", config.Properties.Core.Account)
+	}
+
+	if config.Properties.Core.Project != "" {
+		fmt.Fprintf(w, "  Project: %s\n", config.Properties.Core.Project)
+	}
+
+	if config.Properties.Compute.Region != "" {
+		fmt.Fprintf(w, "  Region:  %s\n", config.Properties.Compute.Region)
+	}
+
+	if config.Properties.Compute.Zone != "" {
+		fmt.Fprintf(w, "  Zone:    %s\n", config.Properties.Compute.Zone)
+	}
+
+	if lastUsed != nil {
+		fmt.Fprintf(w, "  Last used: %s\n", lastUsedText(config.Name))
+	}
+
+	if note := notes[config.Name]; note != "" {
+		if width <= 0 {
+			width = DefaultPreviewWidth
+		}
+		fmt.Fprintf(w, "\n  Note:\n")
+		for _, line := range wrapText(note, width-4) {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%s\n", previewRule(width))
+}
+
+func (standardViews) projectPreview(w io.Writer, project *gcloud.Project, width int) {
+	fmt.Fprintln(w, previewRule(width))
+	fmt.Fprintf(w, "  Project: %s\n", project.ProjectID)
+	fmt.Fprintf(w, "%s\n\n", previewRule(width))
+
+	if project.Name != "" {
+		fmt.Fprintf(w, "  Name:    %s\n", project.Name)
+	}
+	if project.ProjectNumber != "" {
+		fmt.Fprintf(w, "  Number:  %s\n", project.ProjectNumber)
+	}
+	if project.LifecycleState != "" {
+		fmt.Fprintf(w, "  State:   %s\n", project.LifecycleState)
+	}
+	if project.Parent != nil {
+		fmt.Fprintf(w, "  Parent:  %s %s\n", project.Parent.Type, project.Parent.ID)
+	}
+
+	if len(project.Labels) > 0 {
+		fmt.Fprintf(w, "\n  Labels:\n")
+		for _, key := range sortedKeys(project.Labels) {
+			fmt.Fprintf(w, "    %s=%s\n", key, project.Labels[key])
+		}
+	}
+
+	fmt.Fprintf(w, "\n%s\n", previewRule(width))
+}
+
+func (standardViews) diff(w io.Writer, diffs []gcloud.PropertyDiff, expanded bool) {
+	gray := color.New(color.FgHiBlack).SprintFunc()
+
+	if !expanded {
+		if len(diffs) > 0 {
+			fmt.Fprintln(w, gray(fmt.Sprintf("%s (use --show-diff for details)", diffCountText(len(diffs)))))
+		}
+		return
+	}
+
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, gray("No properties differ"))
+		return
+	}
+
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	bold := color.New(color.Bold).SprintFunc()
+
+	rows := [][]string{{bold("PROPERTY"), bold("FROM"), bold("TO")}}
+	for _, d := range diffs {
+		from := red(d.From)
+		if d.From == "" {
+			from = gray("-")
+		}
+		to := green(d.To)
+		if d.To == "" {
+			to = gray("-")
+		}
+		rows = append(rows, []string{d.Property, from, to})
+	}
+
+	for _, line := range AlignColumns(rows, 2) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+func (standardViews) banner(w io.Writer, kind bannerKind, message string) {
+	switch kind {
+	case bannerError:
+		red := color.New(color.FgRed, color.Bold).SprintFunc()
+		fmt.Fprintf(w, "%s %s\n", red("Error:"), message)
+	case bannerSuccess:
+		green := color.New(color.FgGreen, color.Bold).SprintFunc()
+		fmt.Fprintf(w, "%s %s\n", green("Success:"), message)
+	}
+}
+
+// accessibleViews renders labeled prose, one field per line, without markers,
+// brackets or box drawing so screen readers can read the output naturally
+type accessibleViews struct{}
+
+// writeField writes a single "label: value" line
+func writeField(w io.Writer, label, value string) {
+	cyan := color.New(color.FgCyan).SprintFunc()
+	fmt.Fprintf(w, "%s: %s\n", cyan(label), value)
+}
+
+// writeConfigurationName writes the labeled configuration name, announcing the
+// active and the previous one
+func writeConfigurationName(w io.Writer, config *gcloud.Configuration) {
+	switch {
+	case config.IsActive:
+		yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
+		writeField(w, "active configuration", yellow(config.Name))
+	case isPrevious(config.Name):
+		writeField(w, "previous configuration", config.Name)
+	default:
+		writeField(w, "configuration", config.Name)
+	}
+}
+
+// writeProperties writes the configuration properties; unset ones are spelled out when showUnset is true
+func writeProperties(w io.Writer, config *gcloud.Configuration, showUnset bool) {
+	fields := []struct {
+		label string
+		value string
+	}{
+		{"account", config.Properties.Core.Account},
+		{"project", config.Properties.Core.Project},
+		{"region", config.Properties.Compute.Region},
+		{"zone", config.Properties.Compute.Zone},
+	}
+
+	for _, field := range fields {
+		switch {
+		case field.value != "":
+			writeField(w, field.label, field.value)
+		case showUnset:
+			writeField(w, field.label, "not set")
+		}
+	}
+}
+
+func (accessibleViews) list(w io.Writer, configs []gcloud.Configuration) {
+	for i := range configs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		writeConfigurationName(w, &configs[i])
+		if account := configs[i].Properties.Core.Account; account != "" {
+			writeField(w, "account", account)
+		}
+		if project := configs[i].Properties.Core.Project; project != "" {
+			writeField(w, "project", project)
+		}
+		if isProtected(configs[i].Name) {
+			writeField(w, "protected", "yes")
+		}
+	}
+}
+
+func (accessibleViews) wide(w io.Writer, configs []gcloud.Configuration, columns []Column, _ int) {
+	for i := range configs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		writeConfigurationName(w, &configs[i])
+		for _, column := range columns {
+			if column.Name == ColumnName {
+				continue
+			}
+			value := column.value(&configs[i])
+			if value == "" {
+				value = column.unsetText()
+			}
+			writeField(w, strings.ReplaceAll(column.Name, "_", " "), value)
+		}
+	}
+}
+
+func (accessibleViews) current(w io.Writer, config *gcloud.Configuration) {
+	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
+	writeField(w, "active configuration", yellow(config.Name))
+}
+
+func (accessibleViews) details(w io.Writer, config *gcloud.Configuration) {
+	writeField(w, "configuration", config.Name)
+	if config.IsActive {
+		writeField(w, "status", "active")
+	} else {
+		writeField(w, "status", "inactive")
+	}
+	writeProperties(w, config, false)
+	if value := usageReportingValue(config); showAll && value != "" {
+		writeField(w, "usage reporting", value)
+	}
+	if lastUsed != nil {
+		if at, ok := lastUsed[config.Name]; ok {
+			writeField(w, "last used", FormatRelativeTime(at, now()))
+		} else {
+			writeField(w, "last used", "never")
+		}
+	}
+	if note := notes[config.Name]; note != "" {
+		writeField(w, "note", strings.ReplaceAll(note, "\n", " / "))
+	}
+}
+
+func (accessibleViews) sections(w io.Writer, sections map[string]map[string]string) {
+	for _, section := range sortedSectionNames(sections) {
+		for _, name := range sortedKeys(sections[section]) {
+			writeField(w, section+"/"+name, sections[section][name])
+		}
+	}
+}
+
+func (v accessibleViews) preview(w io.Writer, config *gcloud.Configuration, width int) {
+	v.details(w, config)
+}
+
+func (accessibleViews) projectPreview(w io.Writer, project *gcloud.Project, width int) {
+	writeField(w, "project", project.ProjectID)
+	if project.Name != "" {
+		writeField(w, "name", project.Name)
+	}
+	if project.ProjectNumber != "" {
+		writeField(w, "number", project.ProjectNumber)
+	}
+	if project.LifecycleState != "" {
+		writeField(w, "state", project.LifecycleState)
+	}
+	if project.Parent != nil {
+		writeField(w, "parent", project.Parent.Type+" "+project.Parent.ID)
+	}
+	for _, key := range sortedKeys(project.Labels) {
+		writeField(w, "label "+key, project.Labels[key])
+	}
+}
+
+func (accessibleViews) diff(w io.Writer, diffs []gcloud.PropertyDiff, expanded bool) {
+	if !expanded {
+		if len(diffs) > 0 {
+			writeField(w, "differences", diffCountText(len(diffs))+", use --show-diff for details")
+		}
+		return
+	}
+
+	if len(diffs) == 0 {
+		writeField(w, "differences", "none")
+		return
+	}
+
+	for _, d := range diffs {
+		switch {
+		case d.From == "":
+			writeField(w, "property "+d.Property, "set to "+d.To)
+		case d.To == "":
+			writeField(w, "property "+d.Property, "unset, was "+d.From)
+		default:
+			writeField(w, "property "+d.Property, "changed from "+d.From+" to "+d.To)
+		}
+	}
+}
+
+func (accessibleViews) banner(w io.Writer, kind bannerKind, message string) {
+	switch kind {
+	case bannerError:
+		red := color.New(color.FgRed, color.Bold).SprintFunc()
+		fmt.Fprintf(w, "%s %s\n", red("error:"), message)
+	case bannerSuccess:
+		green := color.New(color.FgGreen, color.Bold).SprintFunc()
+		fmt.Fprintf(w, "%s %s\n", green("done:"), message)
+	}
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedSectionNames returns the section names of grouped properties in sorted order
+func sortedSectionNames(sections map[string]map[string]string) []string {
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diffCountText describes the number of differing properties
+func diffCountText(n int) string {
+	if n == 1 {
+		return "1 property differs"
+	}
+	return fmt.Sprintf("%d properties differ", n)
+}
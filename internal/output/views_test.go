@@ -0,0 +1,174 @@
+package output
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/golden"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/fatih/color"
+)
+
+func goldenConfigs() []gcloud.Configuration {
+	return []gcloud.Configuration{
+		{
+			Name:     "prod",
+			IsActive: true,
+			Properties: gcloud.Properties{
+				Core:    gcloud.CoreProperties{Account: "admin@example.com", Project: "prod-project"},
+				Compute: gcloud.ComputeProperties{Region: "us-central1", Zone: "us-central1-a"},
+			},
+		},
+		{
+			Name: "dev",
+			Properties: gcloud.Properties{
+				Core: gcloud.CoreProperties{Project: "dev-project"},
+			},
+		},
+	}
+}
+
+func goldenProject() *gcloud.Project {
+	return &gcloud.Project{
+		ProjectID:      "prod-project",
+		Name:           "Production",
+		ProjectNumber:  "123456789012",
+		LifecycleState: "ACTIVE",
+		Labels:         map[string]string{"team": "platform", "env": "prod"},
+		Parent:         &gcloud.ProjectParent{Type: "folder", ID: "987654321"},
+	}
+}
+
+func TestAccessibleViewsGolden(t *testing.T) {
+	color.NoColor = true
+	configs := goldenConfigs()
+	views := accessibleViews{}
+
+	tests := []struct {
+		name   string
+		render func(w *bytes.Buffer)
+	}{
+		{"list", func(w *bytes.Buffer) { views.list(w, configs) }},
+		{"wide", func(w *bytes.Buffer) { views.wide(w, configs, DefaultColumns(), 0) }},
+		{"current", func(w *bytes.Buffer) { views.current(w, &configs[0]) }},
+		{"details", func(w *bytes.Buffer) { views.details(w, &configs[1]) }},
+		{"preview", func(w *bytes.Buffer) { views.preview(w, &configs[0], DefaultPreviewWidth) }},
+		{"project-preview", func(w *bytes.Buffer) { views.projectPreview(w, goldenProject(), DefaultPreviewWidth) }},
+		{"error", func(w *bytes.Buffer) { views.banner(w, bannerError, "configuration \"x\" not found") }},
+		{"success", func(w *bytes.Buffer) { views.banner(w, bannerSuccess, "switched to configuration \"prod\"") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.render(&buf)
+			golden.Assert(t, filepath.Join("testdata", "accessible", tt.name+".golden"), buf.String())
+		})
+	}
+}
+
+func TestDiffViewsGolden(t *testing.T) {
+	color.NoColor = true
+	diffs := []gcloud.PropertyDiff{
+		{Property: "compute/region", From: "us-central1", To: ""},
+		{Property: "core/account", From: "admin@example.com", To: "dev@example.com"},
+		{Property: "core/project", From: "", To: "dev-project"},
+	}
+
+	tests := []struct {
+		name     string
+		views    viewSet
+		diffs    []gcloud.PropertyDiff
+		expanded bool
+	}{
+		{"standard-collapsed", standardViews{}, diffs, false},
+		{"standard-expanded", standardViews{}, diffs, true},
+		{"standard-empty", standardViews{}, nil, true},
+		{"accessible-collapsed", accessibleViews{}, diffs, false},
+		{"accessible-expanded", accessibleViews{}, diffs, true},
+		{"accessible-empty", accessibleViews{}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.views.diff(&buf, tt.diffs, tt.expanded)
+			golden.Assert(t, filepath.Join("testdata", "diff", tt.name+".golden"), buf.String())
+		})
+	}
+}
+
+func TestDiffCollapsedEmpty(t *testing.T) {
+	color.NoColor = true
+
+	for _, views := range []viewSet{standardViews{}, accessibleViews{}} {
+		var buf bytes.Buffer
+		views.diff(&buf, nil, false)
+		if buf.Len() != 0 {
+			t.Errorf("%T: collapsed diff without differences = %q, want empty", views, buf.String())
+		}
+	}
+}
+
+func TestCurrentViewsSelector(t *testing.T) {
+	defer SetAccessible(false)
+
+	t.Setenv(EnvAccessible, "")
+	SetAccessible(false)
+	if _, ok := currentViews().(standardViews); !ok {
+		t.Error("Expected standard views by default")
+	}
+
+	SetAccessible(true)
+	if _, ok := currentViews().(accessibleViews); !ok {
+		t.Error("Expected accessible views when enabled via settings")
+	}
+
+	SetAccessible(false)
+	t.Setenv(EnvAccessible, "1")
+	if _, ok := currentViews().(accessibleViews); !ok {
+		t.Errorf("Expected accessible views when %s=1", EnvAccessible)
+	}
+}
+
+func TestStandardPreviewUnchanged(t *testing.T) {
+	color.NoColor = true
+	configs := goldenConfigs()
+
+	var buf bytes.Buffer
+	standardViews{}.preview(&buf, &configs[1], DefaultPreviewWidth)
+
+	want := "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n" +
+		"  Configuration: dev\n" +
+		"━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n" +
+		"  Status:  Inactive\n" +
+		"  Project: dev-project\n" +
+		"\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	if buf.String() != want {
+		t.Errorf("standard preview = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestPreviewFitsWindow(t *testing.T) {
+	color.NoColor = true
+	configs := goldenConfigs()
+
+	var buf bytes.Buffer
+	standardViews{}.preview(&buf, &configs[1], 24)
+	standardViews{}.projectPreview(&buf, goldenProject(), 24)
+
+	rules := 0
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "━") {
+			rules++
+			if line != strings.Repeat("━", 24) {
+				t.Errorf("rule %q is not 24 columns wide", line)
+			}
+		}
+	}
+	if rules != 6 {
+		t.Errorf("found %d rules, want 6 in\n%s", rules, buf.String())
+	}
+}
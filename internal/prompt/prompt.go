@@ -0,0 +1,146 @@
+// Package prompt asks the yes/no and typed questions gcloudctx commands
+// confirm destructive or risky operations with.
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// IsTerminal reports whether stdin is a terminal someone can answer on
+// Tests replace it to answer prompts from a file
+var IsTerminal = func() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// OpenTerminal opens the controlling terminal to ask on when stdin is piped
+// Tests replace it to stand in for a terminal or for its absence
+var OpenTerminal = openTerminal
+
+// Confirm asks msg on w and reads the answer from r
+// "y", "yes", "n" and "no" are accepted in any case, an empty answer takes the
+// default (no when defaultNo is set) and anything else asks again
+// End of input counts as no
+func Confirm(w io.Writer, r io.Reader, msg string, defaultNo bool) (bool, error) {
+	choices := "(Y/n)"
+	if defaultNo {
+		choices = "(y/N)"
+	}
+	fmt.Fprintf(w, "%s %s: ", msg, choices)
+
+	for {
+		answer, err := readLine(r)
+		if err != nil {
+			fmt.Fprintln(w)
+			if errors.Is(err, io.EOF) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to read confirmation: %w", err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "":
+			return !defaultNo, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintf(w, "Please answer y or n %s: ", choices)
+	}
+}
+
+// ConfirmTyped asks msg on w and for expected to be typed back, and reads the
+// answer from r
+// Only the exact text confirms, surrounding spaces aside; end of input counts
+// as a refusal. Like Confirm, input after the answer stays in r
+func ConfirmTyped(w io.Writer, r io.Reader, msg, expected string) (bool, error) {
+	fmt.Fprintf(w, "%s\nType %q to confirm: ", msg, expected)
+
+	answer, err := readLine(r)
+	if err != nil {
+		fmt.Fprintln(w)
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	return strings.TrimSpace(answer) == expected, nil
+}
+
+// ErrNoTerminal is returned by AskTerminal when there is no terminal to ask on
+var ErrNoTerminal = errors.New("no terminal to ask on")
+
+// Ask confirms msg with an answer from the user, as gcloud and git do
+// force confirms without asking. The question is asked on w when stdin is a
+// terminal; when stdin is piped it is asked on the controlling terminal if
+// there is one, and otherwise on w with the answer read from the pipe
+func Ask(w io.Writer, msg string, defaultNo, force bool) (bool, error) {
+	if force {
+		return true, nil
+	}
+	return ask(w, false, func(w io.Writer, r io.Reader) (bool, error) {
+		return Confirm(w, r, msg, defaultNo)
+	})
+}
+
+// AskTyped is Ask for confirmations where expected must be typed back, as
+// ConfirmTyped asks them; there is no force for those
+func AskTyped(w io.Writer, msg, expected string) (bool, error) {
+	return ask(w, false, func(w io.Writer, r io.Reader) (bool, error) {
+		return ConfirmTyped(w, r, msg, expected)
+	})
+}
+
+// AskTerminal is Ask for questions a piped answer must not settle: without
+// stdin or the controlling terminal to ask on it fails with ErrNoTerminal
+func AskTerminal(w io.Writer, msg string, defaultNo bool) (bool, error) {
+	return ask(w, true, func(w io.Writer, r io.Reader) (bool, error) {
+		return Confirm(w, r, msg, defaultNo)
+	})
+}
+
+// ask runs question on w and stdin when stdin is a terminal, and on the
+// controlling terminal when it is not; without one it reads the answer from
+// the pipe, or fails with ErrNoTerminal when terminalOnly is set
+func ask(w io.Writer, terminalOnly bool, question func(w io.Writer, r io.Reader) (bool, error)) (bool, error) {
+	if !IsTerminal() {
+		tty, err := OpenTerminal()
+		if err == nil {
+			defer tty.Close()
+			return question(tty, tty)
+		}
+		if terminalOnly {
+			return false, ErrNoTerminal
+		}
+	}
+	return question(w, os.Stdin)
+}
+
+// readLine reads a line from r without the line break
+// It reads a byte at a time so input after the line stays in r for the next
+// prompt; a last line without a line break still counts
+func readLine(r io.Reader) (string, error) {
+	var line strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return line.String(), nil
+			}
+			line.WriteByte(buf[0])
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) && line.Len() > 0 {
+				return line.String(), nil
+			}
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		defaultNo bool
+		want      bool
+	}{
+		{name: "y", input: "y\n", defaultNo: true, want: true},
+		{name: "yes in capitals", input: " YES \n", defaultNo: true, want: true},
+		{name: "n", input: "n\n", want: false},
+		{name: "No", input: "No\n", want: false},
+		{name: "empty answer with default no", input: "\n", defaultNo: true, want: false},
+		{name: "empty answer with default yes", input: "\n", want: true},
+		{name: "end of input with default yes", input: "", want: false},
+		{name: "end of input with default no", input: "", defaultNo: true, want: false},
+		{name: "last line without line break", input: "yes", defaultNo: true, want: true},
+		{name: "invalid answer asks again", input: "maybe\ny\n", defaultNo: true, want: true},
+		{name: "invalid answer then end of input", input: "prod\n", want: false},
+		{name: "windows line break", input: "y\r\n", defaultNo: true, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := Confirm(&out, strings.NewReader(tt.input), "Delete it?", tt.defaultNo)
+			if err != nil {
+				t.Fatalf("Confirm failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Confirm(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmPrompt(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := Confirm(&out, strings.NewReader("what\nn\n"), "Delete it?", true); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Delete it? (y/N): Please answer y or n (y/N): "; out.String() != want {
+		t.Errorf("prompt = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	if _, err := Confirm(&out, strings.NewReader("\n"), "Continue?", false); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Continue? (Y/n): "; out.String() != want {
+		t.Errorf("prompt = %q, want %q", out.String(), want)
+	}
+}
+
+func TestConfirmLeavesLaterAnswers(t *testing.T) {
+	in := strings.NewReader("y\nn\n")
+	var out bytes.Buffer
+
+	first, err := Confirm(&out, in, "First?", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Confirm(&out, in, "Second?", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first || second {
+		t.Errorf("answers = %v, %v; want true, false", first, second)
+	}
+}
+
+// failingReader fails every read with err
+type failingReader struct{ err error }
+
+func (r failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestConfirmReadError(t *testing.T) {
+	readErr := errors.New("device gone")
+	var out bytes.Buffer
+	got, err := Confirm(&out, failingReader{readErr}, "Delete it?", true)
+	if got || !errors.Is(err, readErr) {
+		t.Errorf("Confirm = %v, %v; want false and the read error", got, err)
+	}
+	if _, err := Confirm(&out, failingReader{io.EOF}, "Delete it?", true); err != nil {
+		t.Errorf("Confirm at end of input failed: %v", err)
+	}
+}
+
+// fakeTTY is a terminal answering with its input and recording the questions
+type fakeTTY struct {
+	*strings.Reader
+	questions bytes.Buffer
+	closed    bool
+}
+
+func (f *fakeTTY) Write(p []byte) (int, error) { return f.questions.Write(p) }
+func (f *fakeTTY) Close() error                { f.closed = true; return nil }
+
+// fakeStdin points os.Stdin at a file holding input for the rest of the test
+func fakeStdin(t *testing.T, input string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		f.Close()
+	})
+}
+
+// fakeTerminals replaces IsTerminal and OpenTerminal for the rest of the test;
+// a nil tty means there is no controlling terminal
+func fakeTerminals(t *testing.T, stdinIsTerminal bool, tty *fakeTTY) {
+	t.Helper()
+	isTerminal, openTerminal := IsTerminal, OpenTerminal
+	t.Cleanup(func() { IsTerminal, OpenTerminal = isTerminal, openTerminal })
+	IsTerminal = func() bool { return stdinIsTerminal }
+	OpenTerminal = func() (io.ReadWriteCloser, error) {
+		if tty == nil {
+			return nil, errors.New("no terminal")
+		}
+		return tty, nil
+	}
+}
+
+func TestAskForce(t *testing.T) {
+	fakeTerminals(t, false, nil)
+	fakeStdin(t, "n\n")
+
+	var out bytes.Buffer
+	got, err := Ask(&out, "Delete it?", true, true)
+	if err != nil || !got {
+		t.Errorf("Ask with force = %v, %v; want it confirmed", got, err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("output = %q, want nothing asked with force", out.String())
+	}
+}
+
+func TestAskTerminalStdin(t *testing.T) {
+	tty := &fakeTTY{Reader: strings.NewReader("n\n")}
+	fakeTerminals(t, true, tty)
+	fakeStdin(t, "y\n")
+
+	var out bytes.Buffer
+	got, err := Ask(&out, "Delete it?", true, false)
+	if err != nil || !got {
+		t.Errorf("Ask = %v, %v; want the answer from stdin", got, err)
+	}
+	if out.String() != "Delete it? (y/N): " || tty.questions.Len() != 0 {
+		t.Errorf("asked %q on w and %q on the terminal, want only w", out.String(), tty.questions.String())
+	}
+}
+
+func TestAskPipedStdinUsesTerminal(t *testing.T) {
+	tty := &fakeTTY{Reader: strings.NewReader("y\n")}
+	fakeTerminals(t, false, tty)
+	fakeStdin(t, "n\n")
+
+	var out bytes.Buffer
+	got, err := Ask(&out, "Delete it?", true, false)
+	if err != nil || !got {
+		t.Errorf("Ask = %v, %v; want the answer from the terminal", got, err)
+	}
+	if out.Len() != 0 || tty.questions.String() != "Delete it? (y/N): " {
+		t.Errorf("asked %q on w and %q on the terminal, want only the terminal", out.String(), tty.questions.String())
+	}
+	if !tty.closed {
+		t.Error("the terminal was left open")
+	}
+}
+
+func TestAskPipedStdinWithoutTerminal(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "piped yes", input: "y\n", want: true},
+		{name: "piped no", input: "n\n", want: false},
+		{name: "nothing piped", input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeTerminals(t, false, nil)
+			fakeStdin(t, tt.input)
+
+			var out bytes.Buffer
+			got, err := Ask(&out, "Delete it?", true, false)
+			if err != nil || got != tt.want {
+				t.Errorf("Ask with %q piped = %v, %v; want %v", tt.input, got, err, tt.want)
+			}
+			if !strings.HasPrefix(out.String(), "Delete it? (y/N): ") {
+				t.Errorf("output = %q, want the question", out.String())
+			}
+		})
+	}
+}
+
+func TestConfirmTyped(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "exact", input: "prod\n", want: true},
+		{name: "surrounding spaces", input: "  prod \r\n", want: true},
+		{name: "yes is not enough", input: "y\n", want: false},
+		{name: "different case", input: "Prod\n", want: false},
+		{name: "last line without line break", input: "prod", want: true},
+		{name: "end of input", input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := ConfirmTyped(&out, strings.NewReader(tt.input), "Delete it?", "prod")
+			if err != nil {
+				t.Fatalf("ConfirmTyped failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConfirmTyped(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+			if !strings.HasPrefix(out.String(), "Delete it?\nType \"prod\" to confirm: ") {
+				t.Errorf("prompt = %q", out.String())
+			}
+		})
+	}
+}
+
+func TestConfirmTypedLeavesLaterAnswers(t *testing.T) {
+	in := strings.NewReader("vault\nvault\n")
+	var out bytes.Buffer
+
+	for _, name := range []string{"vault", "vault"} {
+		got, err := ConfirmTyped(&out, in, "Delete it?", name)
+		if err != nil || !got {
+			t.Fatalf("ConfirmTyped(%s) = %v, %v; want the piped answer", name, got, err)
+		}
+	}
+	if in.Len() != 0 {
+		t.Errorf("%d byte(s) left unread", in.Len())
+	}
+}
+
+func TestAskTypedPipedStdinUsesTerminal(t *testing.T) {
+	tty := &fakeTTY{Reader: strings.NewReader("prod\n")}
+	fakeTerminals(t, false, tty)
+	fakeStdin(t, "no\n")
+
+	var out bytes.Buffer
+	got, err := AskTyped(&out, "Delete it?", "prod")
+	if err != nil || !got {
+		t.Errorf("AskTyped = %v, %v; want the answer from the terminal", got, err)
+	}
+	if out.Len() != 0 || !strings.Contains(tty.questions.String(), `Type "prod" to confirm: `) {
+		t.Errorf("asked %q on w and %q on the terminal, want only the terminal", out.String(), tty.questions.String())
+	}
+}
+
+func TestAskTypedPipedStdinWithoutTerminal(t *testing.T) {
+	fakeTerminals(t, false, nil)
+	fakeStdin(t, "prod\n")
+
+	var out bytes.Buffer
+	got, err := AskTyped(&out, "Delete it?", "prod")
+	if err != nil || !got {
+		t.Errorf("AskTyped = %v, %v; want the piped answer", got, err)
+	}
+}
+
+func TestAskTerminal(t *testing.T) {
+	t.Run("piped stdin uses terminal", func(t *testing.T) {
+		tty := &fakeTTY{Reader: strings.NewReader("y\n")}
+		fakeTerminals(t, false, tty)
+		fakeStdin(t, "n\n")
+
+		var out bytes.Buffer
+		got, err := AskTerminal(&out, "Switch?", true)
+		if err != nil || !got {
+			t.Errorf("AskTerminal = %v, %v; want the answer from the terminal", got, err)
+		}
+		if !tty.closed {
+			t.Error("the terminal was left open")
+		}
+	})
+
+	t.Run("no terminal", func(t *testing.T) {
+		fakeTerminals(t, false, nil)
+		fakeStdin(t, "y\n")
+
+		var out bytes.Buffer
+		got, err := AskTerminal(&out, "Switch?", true)
+		if got || !errors.Is(err, ErrNoTerminal) {
+			t.Errorf("AskTerminal = %v, %v; want ErrNoTerminal", got, err)
+		}
+		if out.Len() != 0 {
+			t.Errorf("output = %q, want nothing asked", out.String())
+		}
+	})
+}
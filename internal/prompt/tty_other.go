@@ -0,0 +1,13 @@
+//go:build !unix && !windows
+
+package prompt
+
+import (
+	"errors"
+	"io"
+)
+
+// openTerminal is not supported on this platform; answers come from stdin
+func openTerminal() (io.ReadWriteCloser, error) {
+	return nil, errors.New("no controlling terminal on this platform")
+}
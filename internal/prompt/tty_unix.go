@@ -0,0 +1,13 @@
+//go:build unix
+
+package prompt
+
+import (
+	"io"
+	"os"
+)
+
+// openTerminal opens the controlling terminal of the process
+func openTerminal() (io.ReadWriteCloser, error) {
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
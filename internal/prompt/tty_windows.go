@@ -0,0 +1,36 @@
+//go:build windows
+
+package prompt
+
+import (
+	"io"
+	"os"
+)
+
+// console is the Windows console, whose input and output are separate files
+type console struct {
+	in  *os.File
+	out *os.File
+}
+
+func (c console) Read(p []byte) (int, error)  { return c.in.Read(p) }
+func (c console) Write(p []byte) (int, error) { return c.out.Write(p) }
+
+func (c console) Close() error {
+	c.out.Close()
+	return c.in.Close()
+}
+
+// openTerminal opens the console of the process
+func openTerminal() (io.ReadWriteCloser, error) {
+	in, err := os.Open("CONIN$")
+	if err != nil {
+		return nil, err
+	}
+	out, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	if err != nil {
+		in.Close()
+		return nil, err
+	}
+	return console{in: in, out: out}, nil
+}
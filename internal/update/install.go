@@ -0,0 +1,54 @@
+package update
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// InstallMethod is how the running gcloudctx binary was installed
+type InstallMethod string
+
+// Install methods with their own upgrade instructions
+const (
+	InstallHomebrew InstallMethod = "homebrew"
+	InstallGo       InstallMethod = "go"
+	InstallManual   InstallMethod = "manual"
+)
+
+// modulePath is the path 'go install' takes
+const modulePath = "github.com/Okabe-Junya/gcloudctx"
+
+// DetectInstallMethod guesses the install method from the path of the running
+// executable (with symlinks resolved) and the Go bin directories ($GOBIN, $GOPATH/bin)
+func DetectInstallMethod(executable string, goBinDirs []string) InstallMethod {
+	slashed := filepath.ToSlash(executable)
+	for _, marker := range []string{"/Cellar/", "/homebrew/", "/linuxbrew/"} {
+		if strings.Contains(slashed, marker) {
+			return InstallHomebrew
+		}
+	}
+
+	dir := filepath.Dir(executable)
+	for _, binDir := range goBinDirs {
+		if binDir != "" && filepath.Clean(binDir) == dir {
+			return InstallGo
+		}
+	}
+
+	return InstallManual
+}
+
+// UpgradeHint tells the user how to install release with the given method
+func UpgradeHint(method InstallMethod, release *Release) string {
+	switch method {
+	case InstallHomebrew:
+		return "brew upgrade gcloudctx"
+	case InstallGo:
+		return "go install " + modulePath + "@" + release.Version
+	default:
+		if release.URL != "" {
+			return "download it from " + release.URL
+		}
+		return "download it from https://" + modulePath + "/releases/latest"
+	}
+}
@@ -0,0 +1,49 @@
+package update
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectInstallMethod(t *testing.T) {
+	goBin := filepath.Join("/home", "me", "go", "bin")
+
+	tests := []struct {
+		name       string
+		executable string
+		want       InstallMethod
+	}{
+		{name: "homebrew on Apple silicon", executable: "/opt/homebrew/Cellar/gcloudctx/1.2.3/bin/gcloudctx", want: InstallHomebrew},
+		{name: "homebrew on Intel", executable: "/usr/local/Cellar/gcloudctx/1.2.3/bin/gcloudctx", want: InstallHomebrew},
+		{name: "linuxbrew", executable: "/home/linuxbrew/.linuxbrew/bin/gcloudctx", want: InstallHomebrew},
+		{name: "go install", executable: filepath.Join(goBin, "gcloudctx"), want: InstallGo},
+		{name: "manual", executable: "/usr/local/bin/gcloudctx", want: InstallManual},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectInstallMethod(tt.executable, []string{"", goBin}); got != tt.want {
+				t.Errorf("DetectInstallMethod(%q) = %q, want %q", tt.executable, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeHint(t *testing.T) {
+	release := &Release{Version: "v1.4.0", URL: "https://github.com/Okabe-Junya/gcloudctx/releases/tag/v1.4.0"}
+
+	tests := []struct {
+		method InstallMethod
+		want   string
+	}{
+		{InstallHomebrew, "brew upgrade gcloudctx"},
+		{InstallGo, "go install github.com/Okabe-Junya/gcloudctx@v1.4.0"},
+		{InstallManual, "download it from https://github.com/Okabe-Junya/gcloudctx/releases/tag/v1.4.0"},
+	}
+
+	for _, tt := range tests {
+		if got := UpgradeHint(tt.method, release); got != tt.want {
+			t.Errorf("UpgradeHint(%q) = %q, want %q", tt.method, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package update checks whether a newer gcloudctx release is available.
+// The latest release is looked up on GitHub with a short timeout and the answer
+// is cached for a day, so the check never slows gcloudctx down noticeably.
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/cache"
+)
+
+const (
+	// EnvDisable turns the update check off when set to "1"
+	EnvDisable = "GCLOUDCTX_DISABLE_UPDATE_CHECK"
+
+	// DefaultAPIURL is the GitHub API endpoint describing the latest release
+	DefaultAPIURL = "https://api.github.com/repos/Okabe-Junya/gcloudctx/releases/latest"
+
+	// DefaultTimeout bounds the whole request, including reading the response
+	DefaultTimeout = 2 * time.Second
+
+	// CheckInterval is how long a looked-up release is reused before asking GitHub again
+	CheckInterval = 24 * time.Hour
+
+	// cacheKey is the cache entry holding the last looked-up release
+	cacheKey = "update-check"
+
+	// maxResponseSize bounds the release document that is read
+	maxResponseSize = 1 << 20
+)
+
+// Release is a published gcloudctx release
+type Release struct {
+	// Version is the release tag, e.g. "v1.4.0"
+	Version string `json:"tag_name"`
+	// URL is the release page
+	URL string `json:"html_url"`
+}
+
+// Checker looks up the latest release
+type Checker struct {
+	// APIURL is the endpoint returning the latest release (default: DefaultAPIURL)
+	APIURL string
+	// Client sends the request (default: http.DefaultClient)
+	Client *http.Client
+	// Timeout bounds the lookup (default: DefaultTimeout)
+	Timeout time.Duration
+}
+
+// Latest returns the latest release
+func (c *Checker) Latest(ctx context.Context) (*Release, error) {
+	apiURL := c.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to check for updates: %s", resp.Status)
+	}
+
+	var release Release
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	if _, ok := parseVersion(release.Version); !ok {
+		return nil, fmt.Errorf("failed to parse release: unexpected version %q", release.Version)
+	}
+
+	return &release, nil
+}
+
+// LatestCached is Latest, reusing the release looked up within CheckInterval
+// Failed lookups are not cached, so the next call tries again
+func (c *Checker) LatestCached(ctx context.Context) (*Release, error) {
+	var cached Release
+	if found, err := cache.Load(cacheKey, CheckInterval, &cached); err == nil && found {
+		return &cached, nil
+	}
+
+	release, err := c.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Best effort; without the cache the next call simply asks again
+	_ = cache.Save(cacheKey, release)
+	return release, nil
+}
+
+// IsNewer reports whether latest is a later version than current
+// Versions are compared as "vMAJOR.MINOR.PATCH"; a current version that does
+// not parse, such as "dev" for local builds, is never reported as outdated
+func IsNewer(current, latest string) bool {
+	cur, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	lat, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+
+	for i := range cur {
+		if lat[i] != cur[i] {
+			return lat[i] > cur[i]
+		}
+	}
+	return false
+}
+
+// IsRelease reports whether version is a release version such as "v1.2.3",
+// rather than a local build such as "dev"
+func IsRelease(version string) bool {
+	_, ok := parseVersion(version)
+	return ok
+}
+
+// parseVersion parses "v1.2.3" or "1.2.3", ignoring pre-release and build suffixes
+func parseVersion(s string) ([3]int, bool) {
+	var v [3]int
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != len(v) {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
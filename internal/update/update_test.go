@@ -0,0 +1,159 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// releaseServer serves body with status and counts the requests it received
+func releaseServer(t *testing.T, status int, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestLatest(t *testing.T) {
+	server, _ := releaseServer(t, http.StatusOK, `{"tag_name": "v1.4.0", "html_url": "https://example.com/v1.4.0"}`)
+	checker := &Checker{APIURL: server.URL}
+
+	release, err := checker.Latest(context.Background())
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if release.Version != "v1.4.0" || release.URL != "https://example.com/v1.4.0" {
+		t.Errorf("Latest() = %+v", release)
+	}
+}
+
+func TestLatestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "rate limited", status: http.StatusForbidden, body: `{"message": "API rate limit exceeded"}`},
+		{name: "not json", status: http.StatusOK, body: `<html>`},
+		{name: "no version", status: http.StatusOK, body: `{"tag_name": "nightly"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := releaseServer(t, tt.status, tt.body)
+			if release, err := (&Checker{APIURL: server.URL}).Latest(context.Background()); err == nil {
+				t.Errorf("Latest() = %+v, want an error", release)
+			}
+		})
+	}
+}
+
+func TestLatestTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-unblock:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(unblock) })
+
+	start := time.Now()
+	_, err := (&Checker{APIURL: server.URL, Timeout: 50 * time.Millisecond}).Latest(context.Background())
+	if err == nil {
+		t.Fatal("Latest() against a hanging server succeeded, want an error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Latest() took %v, want it bounded by the timeout", elapsed)
+	}
+}
+
+func TestLatestCached(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	server, requests := releaseServer(t, http.StatusOK, `{"tag_name": "v1.4.0"}`)
+	checker := &Checker{APIURL: server.URL}
+
+	for range 3 {
+		release, err := checker.LatestCached(context.Background())
+		if err != nil {
+			t.Fatalf("LatestCached failed: %v", err)
+		}
+		if release.Version != "v1.4.0" {
+			t.Errorf("LatestCached() = %+v", release)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server received %d requests, want 1", got)
+	}
+}
+
+func TestLatestCachedDoesNotCacheFailures(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	server, requests := releaseServer(t, http.StatusInternalServerError, "")
+	checker := &Checker{APIURL: server.URL}
+
+	for range 2 {
+		if _, err := checker.LatestCached(context.Background()); err == nil {
+			t.Fatal("LatestCached() succeeded, want an error")
+		}
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server received %d requests, want 2", got)
+	}
+}
+
+func TestIsRelease(t *testing.T) {
+	for version, want := range map[string]bool{
+		"v1.2.3":      true,
+		"1.2.3":       true,
+		"v1.2.3-rc.1": true,
+		"dev":         false,
+		"v1.2":        false,
+	} {
+		if got := IsRelease(version); got != want {
+			t.Errorf("IsRelease(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"v1.2.3", "v1.2.4", true},
+		{"1.2.3", "v1.10.0", true},
+		{"v1.2.3", "v2.0.0", true},
+		{"v1.2.3", "v1.2.3", false},
+		{"v1.3.0", "v1.2.9", false},
+		{"v1.2.3-rc.1", "v1.2.3", false},
+		{"1.2.4-next", "v1.2.3", false},
+		{"dev", "v9.9.9", false},
+		{"v1.2.3", "latest", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.current+"->"+tt.latest, func(t *testing.T) {
+			if got := IsNewer(tt.current, tt.latest); got != tt.want {
+				t.Errorf("IsNewer(%q, %q) = %v, want %v", tt.current, tt.latest, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Package activity keeps an append-only log of the gcloudctx commands that
+// changed, or tried to change, gcloud's configuration.
+// Each entry is a JSON line recording who ran what, which configuration was
+// active before and after, and whether the command succeeded. The log is
+// rotated by size, keeping one older generation, so it never grows without bound.
+package activity
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	logFileName = ".gcloudctx_activity.jsonl"
+
+	// rotatedSuffix is appended to the name of the previous generation of the log
+	rotatedSuffix = ".1"
+
+	// MaxLogSize is the size beyond which the log is rotated
+	MaxLogSize = 2 << 20
+)
+
+// redacted replaces the values of flags that may hold secrets
+const redacted = "REDACTED"
+
+// sensitiveFlagWords mark flags and "name=value" arguments whose values are not logged
+var sensitiveFlagWords = []string{"token", "secret", "password", "key", "credential"}
+
+// Entry is one logged command
+type Entry struct {
+	Time    time.Time `json:"time" yaml:"time"`
+	User    string    `json:"user,omitempty" yaml:"user,omitempty"`
+	Command string    `json:"command" yaml:"command"`
+	Args    []string  `json:"args,omitempty" yaml:"args,omitempty"`
+	// Previous and Active are the active configuration before and after the command
+	Previous string `json:"previous,omitempty" yaml:"previous,omitempty"`
+	Active   string `json:"active,omitempty" yaml:"active,omitempty"`
+	Success  bool   `json:"success" yaml:"success"`
+	Error    string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// GetLogFilePath returns the path to the activity log
+func GetLogFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return LogFilePathIn(homeDir), nil
+}
+
+// LogFilePathIn returns the path of the activity log kept in a state directory
+func LogFilePathIn(dir string) string {
+	return filepath.Join(dir, logFileName)
+}
+
+// Append adds entry to the log at path, rotating it first when the entry
+// would take it past MaxLogSize
+func Append(path string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity: %w", err)
+	}
+	data = append(data, '\n')
+
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(data)) > MaxLogSize {
+		if err := os.Rename(path, path+rotatedSuffix); err != nil {
+			return fmt.Errorf("failed to rotate activity log: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open activity log: %w", err)
+	}
+	// A single write keeps concurrent appends from interleaving
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write activity log: %w", err)
+	}
+	return f.Close()
+}
+
+// Read returns the entries of the log at path logged at or after since, oldest
+// first, including the rotated generation
+// Lines that do not parse, such as one cut short by a full disk, are skipped
+func Read(path string, since time.Time) ([]Entry, error) {
+	var entries []Entry
+	for _, file := range []string{path + rotatedSuffix, path} {
+		read, err := readFile(file, since)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, read...)
+	}
+	return entries, nil
+}
+
+// readFile reads the entries of one generation of the log
+func readFile(path string, since time.Time) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activity log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLogSize)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read activity log: %w", err)
+	}
+	return entries, nil
+}
+
+// SanitizeFlag returns "--name=value" for a flag, with the value replaced when
+// the flag's name suggests it holds a secret
+func SanitizeFlag(name, value string) string {
+	if isSensitive(name) {
+		value = redacted
+	}
+	return "--" + name + "=" + value
+}
+
+// SanitizeArg returns a positional argument, with the value of a "name=value"
+// argument replaced when the name suggests it holds a secret
+func SanitizeArg(arg string) string {
+	if name, _, ok := strings.Cut(arg, "="); ok && isSensitive(name) {
+		return name + "=" + redacted
+	}
+	return arg
+}
+
+// isSensitive reports whether a flag or property name suggests a secret value
+func isSensitive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, word := range sensitiveFlagWords {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package activity
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendRead(t *testing.T) {
+	path := LogFilePathIn(t.TempDir())
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []Entry{
+		{Time: start, User: "alice", Command: "gcloudctx", Args: []string{"prod"}, Previous: "dev", Active: "prod", Success: true},
+		{Time: start.Add(time.Hour), Command: "gcloudctx delete", Args: []string{"old"}, Previous: "prod", Active: "prod", Error: "read-only mode"},
+	}
+	for _, entry := range entries {
+		if err := Append(path, entry); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	got, err := Read(path, time.Time{})
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, entries) {
+		t.Errorf("Read() = %+v, want %+v", got, entries)
+	}
+
+	got, err = Read(path, start.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Command != "gcloudctx delete" {
+		t.Errorf("Read(since) = %+v, want only the later entry", got)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("log mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestReadMissing(t *testing.T) {
+	entries, err := Read(LogFilePathIn(t.TempDir()), time.Time{})
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Read() = %v, %v; want no entries", entries, err)
+	}
+}
+
+func TestReadSkipsMalformedLines(t *testing.T) {
+	path := LogFilePathIn(t.TempDir())
+	if err := os.WriteFile(path, []byte("{\"time\":\"2025-01-01T00:00:00Z\",\"command\":\"gcloudctx\",\"success\":true}\n{\"time\":\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Read(path, time.Time{})
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Read() = %v, %v; want the complete entry only", entries, err)
+	}
+}
+
+func TestAppendRotates(t *testing.T) {
+	path := LogFilePathIn(t.TempDir())
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", MaxLogSize-10)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := Entry{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Command: "gcloudctx", Success: true}
+	if err := Append(path, entry); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > 1024 {
+		t.Errorf("log size = %d after rotation, want only the new entry", info.Size())
+	}
+	if _, err := os.Stat(path + rotatedSuffix); err != nil {
+		t.Errorf("rotated log missing: %v", err)
+	}
+
+	// The rotated generation is still read, and a second rotation replaces it
+	entries, err := Read(path, time.Time{})
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Read() = %v, %v; want the new entry", entries, err)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{SanitizeFlag("project", "my-project"), "--project=my-project"},
+		{SanitizeFlag("access-token", "ya29.abc"), "--access-token=REDACTED"},
+		{SanitizeFlag("api-key", "k"), "--api-key=REDACTED"},
+		{SanitizeArg("prod"), "prod"},
+		{SanitizeArg("core/project=my-project"), "core/project=my-project"},
+		{SanitizeArg("auth/client_secret=s3cret"), "auth/client_secret=REDACTED"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("sanitized = %q, want %q", tt.got, tt.want)
+		}
+	}
+}
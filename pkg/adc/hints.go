@@ -0,0 +1,126 @@
+// Package adc keeps per-configuration hints for syncing Application Default Credentials,
+// and snapshots of the credentials themselves.
+// Hints are recorded when a configuration is imported from a file that says its
+// ADC needs syncing or impersonation, so 'gcloudctx <name> --sync-adc' can use
+// the right service account without the user having to remember it.
+package adc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const hintsFileName = ".gcloudctx_adc_hints"
+
+// Hint describes how ADC should be synced for a configuration
+type Hint struct {
+	SyncADC                   bool   `json:"sync_adc,omitempty"`
+	ImpersonateServiceAccount string `json:"impersonate_service_account,omitempty"`
+}
+
+// IsZero reports whether the hint carries no information
+func (h Hint) IsZero() bool {
+	return !h.SyncADC && h.ImpersonateServiceAccount == ""
+}
+
+// GetHintsFilePath returns the path to the ADC hints file
+func GetHintsFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, hintsFileName), nil
+}
+
+// loadHints reads the configuration-to-hint map from the hints file
+func loadHints() (map[string]Hint, error) {
+	path, err := GetHintsFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	hints := map[string]Hint{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return hints, nil
+		}
+		return nil, fmt.Errorf("failed to read ADC hints: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &hints); err != nil {
+		return nil, fmt.Errorf("failed to parse ADC hints: %w", err)
+	}
+
+	return hints, nil
+}
+
+// saveHints writes the configuration-to-hint map to the hints file
+func saveHints(hints map[string]Hint) error {
+	path, err := GetHintsFilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(hints, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode ADC hints: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save ADC hints: %w", err)
+	}
+
+	return nil
+}
+
+// SaveHint records the ADC hint of a configuration; a zero hint removes it
+func SaveHint(configName string, hint Hint) error {
+	hints, err := loadHints()
+	if err != nil {
+		return err
+	}
+
+	if hint.IsZero() {
+		if _, ok := hints[configName]; !ok {
+			return nil
+		}
+		delete(hints, configName)
+	} else {
+		hints[configName] = hint
+	}
+	return saveHints(hints)
+}
+
+// GetHint returns the ADC hint of a configuration, or a zero hint if there is none
+func GetHint(configName string) (Hint, error) {
+	hints, err := loadHints()
+	if err != nil {
+		return Hint{}, err
+	}
+	return hints[configName], nil
+}
+
+// ClearHint removes the ADC hint of a configuration
+func ClearHint(configName string) error {
+	return SaveHint(configName, Hint{})
+}
+
+// RenameHint moves the ADC hint of a configuration to its new name
+func RenameHint(oldName, newName string) error {
+	hints, err := loadHints()
+	if err != nil {
+		return err
+	}
+
+	hint, ok := hints[oldName]
+	if !ok {
+		return nil
+	}
+
+	delete(hints, oldName)
+	hints[newName] = hint
+	return saveHints(hints)
+}
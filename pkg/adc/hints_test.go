@@ -0,0 +1,92 @@
+package adc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetHintsFilePath(t *testing.T) {
+	path, err := GetHintsFilePath()
+	if err != nil {
+		t.Fatalf("GetHintsFilePath failed: %v", err)
+	}
+	if !filepath.IsAbs(path) {
+		t.Error("Expected absolute path")
+	}
+}
+
+func TestSaveAndGetHint(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	hint := Hint{SyncADC: true, ImpersonateServiceAccount: "deployer@proj.iam.gserviceaccount.com"}
+	if err := SaveHint("prod", hint); err != nil {
+		t.Fatalf("SaveHint failed: %v", err)
+	}
+
+	got, err := GetHint("prod")
+	if err != nil {
+		t.Fatalf("GetHint failed: %v", err)
+	}
+	if got != hint {
+		t.Errorf("GetHint() = %+v, want %+v", got, hint)
+	}
+
+	missing, err := GetHint("dev")
+	if err != nil {
+		t.Fatalf("GetHint failed: %v", err)
+	}
+	if !missing.IsZero() {
+		t.Errorf("GetHint() for unknown configuration = %+v, want zero", missing)
+	}
+}
+
+func TestSaveZeroHintRemoves(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := SaveHint("prod", Hint{SyncADC: true}); err != nil {
+		t.Fatalf("SaveHint failed: %v", err)
+	}
+	if err := ClearHint("prod"); err != nil {
+		t.Fatalf("ClearHint failed: %v", err)
+	}
+
+	got, err := GetHint("prod")
+	if err != nil {
+		t.Fatalf("GetHint failed: %v", err)
+	}
+	if !got.IsZero() {
+		t.Errorf("GetHint() after clear = %+v, want zero", got)
+	}
+}
+
+func TestClearHintWithoutFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := ClearHint("prod"); err != nil {
+		t.Fatalf("ClearHint failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, hintsFileName)); !os.IsNotExist(err) {
+		t.Error("ClearHint should not create the hints file")
+	}
+}
+
+func TestRenameHint(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	hint := Hint{ImpersonateServiceAccount: "sa@proj.iam.gserviceaccount.com"}
+	if err := SaveHint("old", hint); err != nil {
+		t.Fatalf("SaveHint failed: %v", err)
+	}
+	if err := RenameHint("old", "new"); err != nil {
+		t.Fatalf("RenameHint failed: %v", err)
+	}
+
+	if got, _ := GetHint("new"); got != hint {
+		t.Errorf("GetHint(new) = %+v, want %+v", got, hint)
+	}
+	if got, _ := GetHint("old"); !got.IsZero() {
+		t.Errorf("GetHint(old) = %+v, want zero", got)
+	}
+}
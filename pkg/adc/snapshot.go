@@ -0,0 +1,346 @@
+package adc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+const (
+	snapshotsDirName     = ".gcloudctx_adc_snapshots"
+	snapshotMetadataName = "metadata.json"
+)
+
+// ErrNoSnapshot is returned when a configuration has no ADC snapshot
+var ErrNoSnapshot = errors.New("no ADC snapshot")
+
+// Metadata records when a snapshot was taken and last checked
+type Metadata struct {
+	SavedAt time.Time `json:"saved_at"`
+	// LastVerified is the last time a token could be obtained from the snapshot
+	LastVerified time.Time `json:"last_verified,omitzero"`
+	// LastChecked is the last verification attempt, successful or not
+	LastChecked time.Time `json:"last_checked,omitzero"`
+	// LastError is the error of the last failed verification
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Dead reports whether the last verification failed
+func (m Metadata) Dead() bool {
+	return m.LastError != ""
+}
+
+// Snapshot is a saved copy of application_default_credentials.json for a configuration
+type Snapshot struct {
+	Config   string
+	Metadata Metadata
+}
+
+// SnapshotStore keeps ADC snapshots, one directory per configuration
+type SnapshotStore struct {
+	dir string
+}
+
+// NewSnapshotStore returns a store keeping snapshots under dir
+func NewSnapshotStore(dir string) *SnapshotStore {
+	return &SnapshotStore{dir: dir}
+}
+
+// GetSnapshotsDir returns the directory holding ADC snapshots
+func GetSnapshotsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, snapshotsDirName), nil
+}
+
+// DefaultSnapshotStore returns the store in the user's home directory
+func DefaultSnapshotStore() (*SnapshotStore, error) {
+	dir, err := GetSnapshotsDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewSnapshotStore(dir), nil
+}
+
+// configDir returns the snapshot directory of a configuration
+func (s *SnapshotStore) configDir(config string) (string, error) {
+	if err := gcloud.ValidateExistingConfigurationName(config); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, config), nil
+}
+
+// CredentialsPath returns the path of the saved credentials of a configuration
+func (s *SnapshotStore) CredentialsPath(config string) (string, error) {
+	dir, err := s.configDir(config)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, gcloud.ADCFileName), nil
+}
+
+// Save stores credentials as the snapshot of config, replacing any earlier one
+func (s *SnapshotStore) Save(config string, credentials []byte, now time.Time) error {
+	dir, err := s.configDir(config)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := writePrivateFile(filepath.Join(dir, gcloud.ADCFileName), credentials); err != nil {
+		return fmt.Errorf("failed to save ADC snapshot: %w", err)
+	}
+	return s.writeMetadata(config, Metadata{SavedAt: now})
+}
+
+// Get returns the snapshot of config, or ErrNoSnapshot
+func (s *SnapshotStore) Get(config string) (*Snapshot, error) {
+	path, err := s.CredentialsPath(config)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w for configuration %q", ErrNoSnapshot, config)
+		}
+		return nil, fmt.Errorf("failed to read ADC snapshot: %w", err)
+	}
+
+	metadata, err := s.readMetadata(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Config: config, Metadata: metadata}, nil
+}
+
+// List returns every snapshot sorted by configuration name
+func (s *SnapshotStore) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list ADC snapshots: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() || gcloud.ValidateExistingConfigurationName(entry.Name()) != nil {
+			continue
+		}
+		snapshot, err := s.Get(entry.Name())
+		if errors.Is(err, ErrNoSnapshot) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Config < snapshots[j].Config })
+	return snapshots, nil
+}
+
+// Delete removes the snapshot of config; deleting a missing snapshot is not an error
+func (s *SnapshotStore) Delete(config string) error {
+	dir, err := s.configDir(config)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete ADC snapshot: %w", err)
+	}
+	return nil
+}
+
+// Rename moves the snapshot of oldName to newName
+func (s *SnapshotStore) Rename(oldName, newName string) error {
+	oldDir, err := s.configDir(oldName)
+	if err != nil {
+		return err
+	}
+	newDir, err := s.configDir(newName)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(oldDir); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := os.RemoveAll(newDir); err != nil {
+		return fmt.Errorf("failed to rename ADC snapshot: %w", err)
+	}
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return fmt.Errorf("failed to rename ADC snapshot: %w", err)
+	}
+	return nil
+}
+
+// readMetadata reads the metadata of a snapshot; missing metadata is not an error
+func (s *SnapshotStore) readMetadata(config string) (Metadata, error) {
+	var metadata Metadata
+	data, err := os.ReadFile(filepath.Join(s.dir, config, snapshotMetadataName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return metadata, nil
+		}
+		return metadata, fmt.Errorf("failed to read ADC snapshot metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return metadata, fmt.Errorf("failed to parse ADC snapshot metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// writeMetadata writes the metadata of a snapshot
+func (s *SnapshotStore) writeMetadata(config string, metadata Metadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode ADC snapshot metadata: %w", err)
+	}
+	if err := writePrivateFile(filepath.Join(s.dir, config, snapshotMetadataName), data); err != nil {
+		return fmt.Errorf("failed to save ADC snapshot metadata: %w", err)
+	}
+	return nil
+}
+
+// writePrivateFile writes data readable only by the owner, tightening existing files
+func writePrivateFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0o600)
+}
+
+// Executor runs gcloud with its configuration directory set to configDir
+type Executor interface {
+	Run(configDir string, args ...string) (string, error)
+}
+
+// ExecutorFunc adapts a function to the Executor interface
+type ExecutorFunc func(configDir string, args ...string) (string, error)
+
+// Run calls f(configDir, args...)
+func (f ExecutorFunc) Run(configDir string, args ...string) (string, error) {
+	return f(configDir, args...)
+}
+
+// sandboxedEnvVars are removed from the environment of sandboxed gcloud runs,
+// so nothing outside the sandbox directory decides which credentials are used
+var sandboxedEnvVars = []string{gcloud.EnvConfigDir, gcloud.EnvActiveConfigName, "GOOGLE_APPLICATION_CREDENTIALS"}
+
+// GcloudExecutor runs the gcloud binary found in PATH
+// In offline mode, commands that need the network are refused (see gcloud.CheckNetwork)
+var GcloudExecutor Executor = ExecutorFunc(func(configDir string, args ...string) (string, error) {
+	if err := gcloud.CheckNetwork(args); err != nil {
+		return "", err
+	}
+	gcloudPath, err := gcloud.GcloudPath()
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command(gcloudPath, args...)
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if !slices.Contains(sandboxedEnvVars, name) {
+			cmd.Env = append(cmd.Env, kv)
+		}
+	}
+	cmd.Env = append(cmd.Env, gcloud.EnvConfigDir+"="+configDir)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+})
+
+// VerifyResult is the outcome of verifying one snapshot
+type VerifyResult struct {
+	Config string
+	// Err is set when no token could be obtained, i.e. the snapshot is dead
+	Err error
+}
+
+// Verify checks that a token can still be obtained from the snapshot of config.
+// The snapshot is copied into a temporary gcloud configuration directory and
+// 'gcloud auth application-default print-access-token' runs against that copy,
+// so the live ADC file (in liveConfigDir) is never read or written. The
+// snapshot's metadata records the outcome.
+func (s *SnapshotStore) Verify(config, liveConfigDir string, executor Executor, now time.Time) (VerifyResult, error) {
+	source, err := s.CredentialsPath(config)
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	credentials, err := os.ReadFile(source)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return VerifyResult{}, fmt.Errorf("%w for configuration %q", ErrNoSnapshot, config)
+		}
+		return VerifyResult{}, fmt.Errorf("failed to read ADC snapshot: %w", err)
+	}
+	metadata, err := s.readMetadata(config)
+	if err != nil {
+		return VerifyResult{}, err
+	}
+
+	sandbox, err := os.MkdirTemp("", "gcloudctx-adc-")
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("failed to create ADC sandbox: %w", err)
+	}
+	defer os.RemoveAll(sandbox)
+
+	if sameDir(sandbox, liveConfigDir) {
+		return VerifyResult{}, fmt.Errorf("refusing to verify ADC snapshot in the live gcloud configuration directory %s", liveConfigDir)
+	}
+	if err := writePrivateFile(filepath.Join(sandbox, gcloud.ADCFileName), credentials); err != nil {
+		return VerifyResult{}, fmt.Errorf("failed to prepare ADC sandbox: %w", err)
+	}
+
+	result := VerifyResult{Config: config}
+	if _, err := executor.Run(sandbox, "auth", "application-default", "print-access-token"); err != nil {
+		// Offline, nothing was learned about the snapshot, so it is not marked dead
+		if errors.Is(err, gcloud.ErrOffline) {
+			return VerifyResult{}, err
+		}
+		result.Err = err
+	}
+
+	metadata.LastChecked = now
+	if result.Err != nil {
+		metadata.LastError = result.Err.Error()
+	} else {
+		metadata.LastVerified = now
+		metadata.LastError = ""
+	}
+	if err := s.writeMetadata(config, metadata); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// sameDir reports whether a and b name the same directory
+func sameDir(a, b string) bool {
+	if b == "" {
+		return false
+	}
+	aInfo, errA := os.Stat(a)
+	bInfo, errB := os.Stat(b)
+	if errA == nil && errB == nil {
+		return os.SameFile(aInfo, bInfo)
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
@@ -0,0 +1,165 @@
+package adc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+var snapshotTime = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestStore returns a store in a temp directory holding a snapshot for each config
+func newTestStore(t *testing.T, configs ...string) *SnapshotStore {
+	t.Helper()
+	store := NewSnapshotStore(filepath.Join(t.TempDir(), "snapshots"))
+	for _, config := range configs {
+		if err := store.Save(config, []byte(`{"type":"authorized_user","client_id":"`+config+`"}`), snapshotTime); err != nil {
+			t.Fatalf("Save(%s) error = %v", config, err)
+		}
+	}
+	return store
+}
+
+func TestSnapshotStoreRoundTrip(t *testing.T) {
+	store := newTestStore(t, "prod", "dev")
+
+	snapshots, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Config != "dev" || snapshots[1].Config != "prod" {
+		t.Fatalf("List() = %+v, want dev and prod", snapshots)
+	}
+	if !snapshots[0].Metadata.SavedAt.Equal(snapshotTime) {
+		t.Errorf("SavedAt = %v, want %v", snapshots[0].Metadata.SavedAt, snapshotTime)
+	}
+
+	path, err := store.CredentialsPath("prod")
+	if err != nil {
+		t.Fatalf("CredentialsPath() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("snapshot missing: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("snapshot permissions = %o, want 600", perm)
+	}
+
+	if err := store.Rename("prod", "production"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if _, err := store.Get("prod"); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("Get(prod) after rename error = %v, want ErrNoSnapshot", err)
+	}
+	if _, err := store.Get("production"); err != nil {
+		t.Errorf("Get(production) error = %v", err)
+	}
+
+	if err := store.Delete("dev"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if snapshots, _ := store.List(); len(snapshots) != 1 || snapshots[0].Config != "production" {
+		t.Errorf("List() after delete = %+v, want only production", snapshots)
+	}
+}
+
+func TestSnapshotStoreRejectsInvalidNames(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.Save("../escape", []byte("{}"), snapshotTime); err == nil {
+		t.Error("Save(../escape) succeeded, want error")
+	}
+}
+
+func TestVerifyRunsInSandbox(t *testing.T) {
+	store := newTestStore(t, "prod")
+	liveDir := t.TempDir()
+	liveADC := filepath.Join(liveDir, gcloud.ADCFileName)
+	if err := os.WriteFile(liveADC, []byte("live"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	snapshotPath, _ := store.CredentialsPath("prod")
+	snapshot, _ := os.ReadFile(snapshotPath)
+
+	var sandbox string
+	executor := ExecutorFunc(func(configDir string, args ...string) (string, error) {
+		sandbox = configDir
+		if configDir == liveDir {
+			t.Fatal("executor ran against the live configuration directory")
+		}
+		got, err := os.ReadFile(filepath.Join(configDir, gcloud.ADCFileName))
+		if err != nil || string(got) != string(snapshot) {
+			t.Errorf("sandbox credentials = %q, %v; want the snapshot", got, err)
+		}
+		// A refresh may rewrite the credentials; it must stay inside the sandbox
+		if err := os.WriteFile(filepath.Join(configDir, gcloud.ADCFileName), []byte("refreshed"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return "ya29.token", nil
+	})
+
+	verifiedAt := snapshotTime.Add(24 * time.Hour)
+	result, err := store.Verify("prod", liveDir, executor, verifiedAt)
+	if err != nil || result.Err != nil {
+		t.Fatalf("Verify() = %+v, %v; want success", result, err)
+	}
+
+	if got, _ := os.ReadFile(liveADC); string(got) != "live" {
+		t.Errorf("live ADC = %q, want it untouched", got)
+	}
+	if got, _ := os.ReadFile(snapshotPath); string(got) != string(snapshot) {
+		t.Errorf("snapshot = %q, want it untouched", got)
+	}
+	if _, err := os.Stat(sandbox); !os.IsNotExist(err) {
+		t.Errorf("sandbox %s was not removed", sandbox)
+	}
+
+	got, _ := store.Get("prod")
+	if !got.Metadata.LastVerified.Equal(verifiedAt) || got.Metadata.Dead() {
+		t.Errorf("metadata = %+v, want verified at %v", got.Metadata, verifiedAt)
+	}
+}
+
+func TestVerifyRecordsDeadSnapshot(t *testing.T) {
+	store := newTestStore(t, "prod")
+	firstCheck := snapshotTime.Add(time.Hour)
+	ok := ExecutorFunc(func(string, ...string) (string, error) { return "token", nil })
+	if _, err := store.Verify("prod", t.TempDir(), ok, firstCheck); err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := ExecutorFunc(func(string, ...string) (string, error) {
+		return "", errors.New("invalid_grant: Token has been expired or revoked")
+	})
+	secondCheck := firstCheck.Add(time.Hour)
+	result, err := store.Verify("prod", t.TempDir(), revoked, secondCheck)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Err == nil {
+		t.Fatal("Verify() result has no error for a revoked token")
+	}
+
+	got, _ := store.Get("prod")
+	if !got.Metadata.Dead() || !got.Metadata.LastChecked.Equal(secondCheck) {
+		t.Errorf("metadata = %+v, want dead as of %v", got.Metadata, secondCheck)
+	}
+	if !got.Metadata.LastVerified.Equal(firstCheck) {
+		t.Errorf("LastVerified = %v, want the earlier success %v", got.Metadata.LastVerified, firstCheck)
+	}
+}
+
+func TestVerifyMissingSnapshot(t *testing.T) {
+	store := newTestStore(t)
+	executor := ExecutorFunc(func(string, ...string) (string, error) {
+		t.Fatal("executor must not run without a snapshot")
+		return "", nil
+	})
+	if _, err := store.Verify("prod", t.TempDir(), executor, snapshotTime); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("Verify() error = %v, want ErrNoSnapshot", err)
+	}
+}
@@ -0,0 +1,296 @@
+// Package backup reads and writes gcloudctx backup archives.
+// An archive is a gzip-compressed tar file holding a manifest, one export-format
+// file and gcloud's own properties file per configuration, gcloudctx's own state
+// files, and optionally a snapshot of Application Default Credentials. Reading
+// is defensive: entry names are checked against the layout and entry sizes are
+// capped, so a corrupt or hostile archive cannot write outside the expected
+// files or exhaust memory.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatVersion is the archive format written by this version
+// Archives with a newer version are rejected rather than partially restored
+// Version 1 archives hold no properties files
+const FormatVersion = 2
+
+const (
+	manifestName      = "manifest.json"
+	configurationsDir = "configurations/"
+	propertiesDir     = "properties/"
+	stateDir          = "state/"
+	adcEntryName      = "adc/application_default_credentials.json"
+
+	// maxEntrySize bounds each archive entry
+	maxEntrySize = 16 << 20
+)
+
+// ErrInvalidArchive is returned for archives that do not follow the backup layout
+var ErrInvalidArchive = errors.New("invalid backup archive")
+
+// StateFiles are the gcloudctx state files in the home directory that an
+// archive may hold; any other state entry is rejected
+var StateFiles = []string{
+	".gcloudctx.yaml",
+	".gcloudctx_adc_hints",
+	".gcloudctx_metadata",
+	".gcloudctx_pins",
+	".gcloudctx_previous",
+	".gcloudctx_previous_projects",
+	".gcloudctx_usage",
+}
+
+// Manifest describes the contents of an archive
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Active is the configuration that was active when the backup was taken
+	Active         string   `json:"active,omitempty"`
+	Configurations []string `json:"configurations"`
+	State          []string `json:"state,omitempty"`
+	ADC            bool     `json:"adc,omitempty"`
+}
+
+// Archive is the decoded content of a backup
+type Archive struct {
+	Manifest       Manifest
+	Configurations []configfile.Config
+	// Properties maps configuration names to gcloud's properties file of the
+	// configuration (config_<name>), restored byte for byte so properties the
+	// export format has no field for survive
+	Properties map[string][]byte
+	// State maps state file names (e.g. ".gcloudctx_pins") to their contents
+	State map[string][]byte
+	// ADC holds the Application Default Credentials snapshot, if any
+	ADC []byte
+}
+
+// Write encodes the archive as a gzip-compressed tar file
+// The manifest's configuration and state lists are derived from the archive content
+func Write(w io.Writer, a *Archive) error {
+	manifest := a.Manifest
+	manifest.Version = FormatVersion
+	manifest.Configurations = make([]string, len(a.Configurations))
+	for i, config := range a.Configurations {
+		manifest.Configurations[i] = config.Name
+	}
+	for name := range a.Properties {
+		if !slices.Contains(manifest.Configurations, name) {
+			return fmt.Errorf("properties of configuration %q, which is not archived", name)
+		}
+	}
+	manifest.State = nil
+	for name := range a.State {
+		if !validStateName(name) {
+			return fmt.Errorf("invalid state file name %q", name)
+		}
+		manifest.State = append(manifest.State, name)
+	}
+	slices.Sort(manifest.State)
+	manifest.ADC = a.ADC != nil
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := writeEntry(tw, manifestName, manifestData, manifest.CreatedAt); err != nil {
+		return err
+	}
+
+	for _, config := range a.Configurations {
+		data, err := yaml.Marshal(config)
+		if err != nil {
+			return fmt.Errorf("failed to encode configuration %q: %w", config.Name, err)
+		}
+		if err := writeEntry(tw, configurationsDir+config.Name+".yaml", data, manifest.CreatedAt); err != nil {
+			return err
+		}
+		if properties, ok := a.Properties[config.Name]; ok {
+			if err := writeEntry(tw, propertiesDir+config.Name, properties, manifest.CreatedAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range manifest.State {
+		if err := writeEntry(tw, stateDir+name, a.State[name], manifest.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	if a.ADC != nil {
+		if err := writeEntry(tw, adcEntryName, a.ADC, manifest.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+// writeEntry adds a private regular file to the tar stream
+func writeEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0o600,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Read decodes a gzip-compressed tar archive written by Write
+func Read(r io.Reader) (*Archive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gz.Close()
+
+	archive := &Archive{Properties: map[string][]byte{}, State: map[string][]byte{}}
+	configs := map[string]configfile.Config{}
+	var manifest *Manifest
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("%w: unexpected entry %q", ErrInvalidArchive, header.Name)
+		}
+
+		data, err := readEntry(tr, header)
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(header.Name)
+		switch {
+		case name == manifestName:
+			manifest = &Manifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return nil, fmt.Errorf("%w: failed to parse manifest: %v", ErrInvalidArchive, err)
+			}
+		case strings.HasPrefix(name, configurationsDir) && path.Dir(name)+"/" == configurationsDir:
+			var config configfile.Config
+			if err := yaml.Unmarshal(data, &config); err != nil {
+				return nil, fmt.Errorf("%w: failed to parse %s: %v", ErrInvalidArchive, name, err)
+			}
+			if path.Base(name) != config.Name+".yaml" {
+				return nil, fmt.Errorf("%w: %s holds configuration %q", ErrInvalidArchive, name, config.Name)
+			}
+			configs[config.Name] = config
+		case strings.HasPrefix(name, propertiesDir) && path.Dir(name)+"/" == propertiesDir:
+			archive.Properties[path.Base(name)] = data
+		case strings.HasPrefix(name, stateDir) && validStateName(strings.TrimPrefix(name, stateDir)):
+			archive.State[strings.TrimPrefix(name, stateDir)] = data
+		case name == adcEntryName:
+			archive.ADC = data
+		default:
+			return nil, fmt.Errorf("%w: unexpected entry %q", ErrInvalidArchive, header.Name)
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, manifestName)
+	}
+	if manifest.Version < 1 || manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("%w: unsupported format version %d (this gcloudctx reads up to %d)", ErrInvalidArchive, manifest.Version, FormatVersion)
+	}
+	archive.Manifest = *manifest
+
+	for _, name := range manifest.Configurations {
+		config, ok := configs[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: configuration %q is listed but missing", ErrInvalidArchive, name)
+		}
+		archive.Configurations = append(archive.Configurations, config)
+		delete(configs, name)
+	}
+	if len(configs) > 0 {
+		return nil, fmt.Errorf("%w: configuration files not listed in the manifest", ErrInvalidArchive)
+	}
+	for name := range archive.Properties {
+		if !slices.Contains(manifest.Configurations, name) {
+			return nil, fmt.Errorf("%w: properties of configuration %q, which is not listed in the manifest", ErrInvalidArchive, name)
+		}
+	}
+
+	return archive, nil
+}
+
+// readEntry reads an entry, refusing entries larger than maxEntrySize
+func readEntry(tr *tar.Reader, header *tar.Header) ([]byte, error) {
+	if header.Size > maxEntrySize {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrInvalidArchive, header.Name, maxEntrySize)
+	}
+	data, err := io.ReadAll(io.LimitReader(tr, maxEntrySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read %s: %v", ErrInvalidArchive, header.Name, err)
+	}
+	if len(data) > maxEntrySize {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrInvalidArchive, header.Name, maxEntrySize)
+	}
+	return data, nil
+}
+
+// validStateName reports whether name is one of the StateFiles
+func validStateName(name string) bool {
+	return slices.Contains(StateFiles, name)
+}
+
+// WritePrivateFile writes data to path readable only by the owner
+// Unlike os.WriteFile, it also tightens the permissions of an existing file
+func WritePrivateFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to restrict permissions of %s: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
+)
+
+func sampleArchive() *Archive {
+	return &Archive{
+		Manifest: Manifest{
+			CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			Active:    "prod",
+		},
+		Configurations: []configfile.Config{
+			{Name: "prod", Project: configfile.Literal("prod-project"), SyncADC: true},
+			{Name: "dev", Account: configfile.Literal("me@example.com")},
+		},
+		Properties: map[string][]byte{
+			"prod": []byte("[core]\nproject = prod-project\n\n[proxy]\naddress = proxy.internal\n"),
+		},
+		State: map[string][]byte{
+			".gcloudctx_previous": []byte("dev\nstaging"),
+			".gcloudctx.yaml":     []byte("accessible: true\n"),
+		},
+		ADC: []byte(`{"type": "authorized_user"}`),
+	}
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, sampleArchive()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if got.Manifest.Version != FormatVersion || got.Manifest.Active != "prod" || !got.Manifest.ADC {
+		t.Errorf("manifest = %+v", got.Manifest)
+	}
+	if len(got.Configurations) != 2 || got.Configurations[0].Name != "prod" || got.Configurations[1].Name != "dev" {
+		t.Fatalf("configurations = %+v", got.Configurations)
+	}
+	if got.Configurations[0].Project.Literal != "prod-project" || !got.Configurations[0].SyncADC {
+		t.Errorf("prod = %+v", got.Configurations[0])
+	}
+	if string(got.Properties["prod"]) != "[core]\nproject = prod-project\n\n[proxy]\naddress = proxy.internal\n" || len(got.Properties) != 1 {
+		t.Errorf("properties = %q", got.Properties)
+	}
+	if string(got.State[".gcloudctx_previous"]) != "dev\nstaging" || len(got.State) != 2 {
+		t.Errorf("state = %q", got.State)
+	}
+	if string(got.ADC) != `{"type": "authorized_user"}` {
+		t.Errorf("ADC = %q", got.ADC)
+	}
+}
+
+func TestWriteWithoutADC(t *testing.T) {
+	archive := sampleArchive()
+	archive.ADC = nil
+
+	var buf bytes.Buffer
+	if err := Write(&buf, archive); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got.Manifest.ADC || got.ADC != nil {
+		t.Errorf("archive without ADC read back with ADC: %+v", got.Manifest)
+	}
+}
+
+// rawArchive builds a gzip-compressed tar file from name/content pairs
+func rawArchive(t *testing.T, entries ...string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for i := 0; i < len(entries); i += 2 {
+		data := []byte(entries[i+1])
+		if err := tw.WriteHeader(&tar.Header{Name: entries[i], Mode: 0o600, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return &buf
+}
+
+func TestReadRejectsInvalidArchives(t *testing.T) {
+	manifest := `{"version": 1, "configurations": []}`
+
+	tests := []struct {
+		name    string
+		archive *bytes.Buffer
+	}{
+		{"not gzip", bytes.NewBufferString("plain text")},
+		{"missing manifest", rawArchive(t, "state/.gcloudctx_pins", "{}")},
+		{"newer version", rawArchive(t, manifestName, `{"version": 99}`)},
+		{"path traversal", rawArchive(t, manifestName, manifest, "state/../../.bashrc", "rm -rf ~")},
+		{"foreign state file", rawArchive(t, manifestName, manifest, "state/.bashrc", "x")},
+		{"unowned gcloudctx file", rawArchive(t, manifestName, manifest, "state/.gcloudctx", "context: prod\n")},
+		{"unportable state file", rawArchive(t, manifestName, manifest, "state/.gcloudctx_activity.jsonl", "{}")},
+		{"unlisted properties", rawArchive(t, manifestName, manifest, "properties/a", "[core]\n")},
+		{"nested properties", rawArchive(t, manifestName, `{"version": 2, "configurations": ["a"]}`, "configurations/a.yaml", "name: a\n", "properties/x/a", "[core]\n")},
+		{"unknown entry", rawArchive(t, manifestName, manifest, "etc/passwd", "x")},
+		{"name mismatch", rawArchive(t, manifestName, `{"version": 1, "configurations": ["a"]}`, "configurations/a.yaml", "name: b\n")},
+		{"missing configuration", rawArchive(t, manifestName, `{"version": 1, "configurations": ["a"]}`)},
+		{"unlisted configuration", rawArchive(t, manifestName, manifest, "configurations/a.yaml", "name: a\n")},
+		{"oversized entry", rawArchive(t, manifestName, manifest, "state/.gcloudctx_pins", strings.Repeat("x", maxEntrySize+1))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(tt.archive); !errors.Is(err, ErrInvalidArchive) {
+				t.Errorf("Read() error = %v, want ErrInvalidArchive", err)
+			}
+		})
+	}
+}
+
+func TestReadVersion1(t *testing.T) {
+	archive := rawArchive(t,
+		manifestName, `{"version": 1, "configurations": ["a"], "state": [".gcloudctx_previous"]}`,
+		"configurations/a.yaml", "name: a\nproject: a-project\n",
+		"state/.gcloudctx_previous", "b",
+	)
+
+	got, err := Read(archive)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(got.Configurations) != 1 || len(got.Properties) != 0 || string(got.State[".gcloudctx_previous"]) != "b" {
+		t.Errorf("archive = %+v, want the configuration without properties files", got)
+	}
+}
+
+func TestWriteRejectsInvalidStateName(t *testing.T) {
+	archive := sampleArchive()
+	archive.State["../evil"] = []byte("x")
+
+	if err := Write(&bytes.Buffer{}, archive); err == nil {
+		t.Error("Write() should reject state names outside the gcloudctx files")
+	}
+}
+
+func TestPlanConfigurations(t *testing.T) {
+	archive := &Archive{Configurations: []configfile.Config{
+		{Name: "prod"},
+		{Name: "new"},
+		{Name: "Bad.Name"},
+		{Name: "My.Weird_Config"},
+		{Name: "../escape"},
+	}}
+
+	// Existing configurations gcloud created under unusual names are restored
+	changes := PlanConfigurations(archive, func(name string) bool { return name == "prod" || name == "My.Weird_Config" })
+
+	want := []Action{ActionOverwrite, ActionCreate, ActionSkip, ActionOverwrite, ActionSkip}
+	for i, change := range changes {
+		if change.Action != want[i] {
+			t.Errorf("%s: action = %s, want %s", change.Config.Name, change.Action, want[i])
+		}
+	}
+	if changes[2].Reason == "" {
+		t.Error("skipped configuration should carry a reason")
+	}
+}
+
+func TestWritePrivateFileTightensPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permissions")
+	}
+
+	path := filepath.Join(t.TempDir(), "application_default_credentials.json")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WritePrivateFile(path, []byte("new")); err != nil {
+		t.Fatalf("WritePrivateFile failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("permissions = %o, want 600", perm)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("content = %q, want new", data)
+	}
+}
@@ -0,0 +1,48 @@
+package backup
+
+import (
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// Action is what a restore does with one configuration of an archive
+type Action string
+
+// Restore actions
+const (
+	ActionCreate    Action = "create"
+	ActionOverwrite Action = "overwrite"
+	ActionSkip      Action = "skip"
+)
+
+// ConfigurationChange is the planned restore of one configuration
+type ConfigurationChange struct {
+	Config configfile.Config
+	Action Action
+	// Reason explains a skipped configuration
+	Reason string
+}
+
+// PlanConfigurations decides how each configuration of an archive is restored:
+// existing ones are overwritten, the rest are created, and configurations
+// with names that cannot be restored are skipped. Existing names only need to
+// be safe; new ones must be valid to create.
+func PlanConfigurations(a *Archive, exists func(name string) bool) []ConfigurationChange {
+	changes := make([]ConfigurationChange, len(a.Configurations))
+	for i, config := range a.Configurations {
+		change := ConfigurationChange{Config: config}
+		if err := gcloud.ValidateExistingConfigurationName(config.Name); err != nil {
+			change.Action = ActionSkip
+			change.Reason = err.Error()
+		} else if exists(config.Name) {
+			change.Action = ActionOverwrite
+		} else if err := gcloud.ValidateConfigurationName(config.Name); err != nil {
+			change.Action = ActionSkip
+			change.Reason = err.Error()
+		} else {
+			change.Action = ActionCreate
+		}
+		changes[i] = change
+	}
+	return changes
+}
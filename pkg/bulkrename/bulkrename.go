@@ -0,0 +1,246 @@
+// Package bulkrename plans renaming many configurations at once, such as every
+// configuration starting with "acme-" to start with "newco-" instead.
+// A Rule maps old names to new ones and Plan validates every new name up front,
+// so a rename is only started once the whole mapping is known to be possible.
+package bulkrename
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// Rule maps configuration names to new names
+type Rule struct {
+	re          *regexp.Regexp
+	replacement string
+	// global replaces every match instead of the first
+	global bool
+}
+
+// ParsePrefix parses "OLD:NEW", replacing the prefix OLD with NEW
+func ParsePrefix(spec string) (*Rule, error) {
+	from, to, err := parseMapping(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prefix %q: %w", spec, err)
+	}
+	return &Rule{re: regexp.MustCompile("^" + regexp.QuoteMeta(from)), replacement: literal(to)}, nil
+}
+
+// ParseSuffix parses "OLD:NEW", replacing the suffix OLD with NEW
+func ParseSuffix(spec string) (*Rule, error) {
+	from, to, err := parseMapping(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid suffix %q: %w", spec, err)
+	}
+	return &Rule{re: regexp.MustCompile(regexp.QuoteMeta(from) + "$"), replacement: literal(to)}, nil
+}
+
+// parseMapping splits "OLD:NEW"; OLD may be empty to add NEW to every name,
+// and NEW to remove OLD
+func parseMapping(spec string) (from, to string, err error) {
+	from, to, ok := strings.Cut(spec, ":")
+	if !ok {
+		return "", "", errors.New("want OLD:NEW")
+	}
+	if from == "" && to == "" {
+		return "", "", errors.New("OLD and NEW must not both be empty")
+	}
+	if strings.Contains(to, ":") {
+		return "", "", errors.New("want a single ':' between OLD and NEW")
+	}
+	return from, to, nil
+}
+
+// literal escapes a replacement so it is inserted as is
+func literal(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// ParsePattern parses a sed-like substitution "s/REGEXP/REPLACEMENT/FLAGS"
+// Any punctuation character can stand in for "/", and is escaped with a
+// backslash where it occurs in REGEXP or REPLACEMENT. REGEXP uses Go's regular
+// expression syntax; REPLACEMENT may refer to groups as \1 to \9 and to the
+// whole match as &. The only flag is g, replacing every match instead of the first.
+func ParsePattern(expr string) (*Rule, error) {
+	rule, err := parsePattern(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", expr, err)
+	}
+	return rule, nil
+}
+
+func parsePattern(expr string) (*Rule, error) {
+	if len(expr) < 2 || expr[0] != 's' {
+		return nil, errors.New("want s/REGEXP/REPLACEMENT/")
+	}
+	delim := expr[1]
+	if delim == '\\' || delim > 0x7f || !isPunct(delim) {
+		return nil, fmt.Errorf("%q cannot separate the parts of a substitution", delim)
+	}
+
+	parts, err := splitUnescaped(expr[2:], delim)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) != 3 {
+		return nil, errors.New("want s/REGEXP/REPLACEMENT/")
+	}
+
+	rule := &Rule{}
+	switch parts[2] {
+	case "":
+	case "g":
+		rule.global = true
+	default:
+		return nil, fmt.Errorf("unknown flags %q (only g is supported)", parts[2])
+	}
+	if parts[0] == "" {
+		return nil, errors.New("REGEXP must not be empty")
+	}
+	if rule.re, err = regexp.Compile(parts[0]); err != nil {
+		return nil, err
+	}
+	if rule.replacement, err = expandReplacement(parts[1], rule.re.NumSubexp()); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// isPunct reports whether c is ASCII punctuation
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[]^_`{|}~", c) >= 0
+}
+
+// splitUnescaped splits s at each delim not preceded by a backslash, dropping
+// the backslash of an escaped delim
+func splitUnescaped(s string, delim byte) ([]string, error) {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == delim:
+			part.WriteByte(delim)
+			i++
+		case s[i] == '\\' && i+1 < len(s):
+			part.WriteString(s[i : i+2])
+			i++
+		case s[i] == '\\':
+			return nil, errors.New("trailing backslash")
+		case s[i] == delim:
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(s[i])
+		}
+	}
+	return append(parts, part.String()), nil
+}
+
+// expandReplacement turns a sed replacement into a regexp template
+func expandReplacement(repl string, groups int) (string, error) {
+	var out strings.Builder
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		switch {
+		case c == '\\' && i+1 < len(repl) && repl[i+1] >= '1' && repl[i+1] <= '9':
+			group := int(repl[i+1] - '0')
+			if group > groups {
+				return "", fmt.Errorf("\\%d refers to a group REGEXP does not have", group)
+			}
+			fmt.Fprintf(&out, "${%d}", group)
+			i++
+		case c == '\\' && i+1 < len(repl):
+			out.WriteString(literal(repl[i+1 : i+2]))
+			i++
+		case c == '&':
+			out.WriteString("${0}")
+		default:
+			out.WriteString(literal(string(c)))
+		}
+	}
+	return out.String(), nil
+}
+
+// Apply returns the new name for name, and whether the rule changes it
+func (r *Rule) Apply(name string) (string, bool) {
+	var renamed string
+	if r.global {
+		renamed = r.re.ReplaceAllString(name, r.replacement)
+	} else if loc := r.re.FindStringSubmatchIndex(name); loc != nil {
+		renamed = name[:loc[0]] + string(r.re.ExpandString(nil, r.replacement, name, loc)) + name[loc[1]:]
+	} else {
+		renamed = name
+	}
+	return renamed, renamed != name
+}
+
+// Rename is the planned rename of one configuration
+type Rename struct {
+	From string
+	To   string
+	// Err is why the configuration cannot be renamed to To
+	Err error
+}
+
+// Plan maps the configurations in names that rule changes to their new
+// names, sorted by old name. Each new name must be valid, must not be the name
+// of an existing configuration (even one renamed away, since the order of the
+// renames would matter) and must not be the new name of another configuration.
+func Plan(names []string, rule *Rule) []Rename {
+	existing := map[string]bool{}
+	for _, name := range names {
+		existing[name] = true
+	}
+
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	var renames []Rename
+	targets := map[string][]int{}
+	for _, name := range sorted {
+		to, changed := rule.Apply(name)
+		if !changed {
+			continue
+		}
+		rename := Rename{From: name, To: to}
+		if err := gcloud.ValidateConfigurationName(to); err != nil {
+			rename.Err = err
+		} else if existing[to] {
+			rename.Err = fmt.Errorf("configuration %q already exists", to)
+		}
+		targets[to] = append(targets[to], len(renames))
+		renames = append(renames, rename)
+	}
+
+	for to, indexes := range targets {
+		if len(indexes) < 2 {
+			continue
+		}
+		var froms []string
+		for _, i := range indexes {
+			froms = append(froms, renames[i].From)
+		}
+		for _, i := range indexes {
+			if renames[i].Err == nil {
+				renames[i].Err = fmt.Errorf("%s would all be renamed to %q", strings.Join(froms, ", "), to)
+			}
+		}
+	}
+	return renames
+}
+
+// Invalid returns the renames that cannot be made
+func Invalid(renames []Rename) []Rename {
+	var invalid []Rename
+	for _, rename := range renames {
+		if rename.Err != nil {
+			invalid = append(invalid, rename)
+		}
+	}
+	return invalid
+}
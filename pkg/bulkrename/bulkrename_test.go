@@ -0,0 +1,100 @@
+package bulkrename
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule  string
+		parse func(string) (*Rule, error)
+		name  string
+		want  string
+	}{
+		{"acme-:newco-", ParsePrefix, "acme-prod", "newco-prod"},
+		{"acme-:newco-", ParsePrefix, "team-acme-prod", "team-acme-prod"},
+		{"acme-:", ParsePrefix, "acme-prod", "prod"},
+		{":team-", ParsePrefix, "prod", "team-prod"},
+		{"-old:-new", ParseSuffix, "prod-old", "prod-new"},
+		{"-old:-new", ParseSuffix, "prod-old-eu", "prod-old-eu"},
+		{"s/^acme-/newco-/", ParsePattern, "acme-prod", "newco-prod"},
+		{"s/-/_/", ParsePattern, "a-b-c", "a_b-c"},
+		{"s/-/_/g", ParsePattern, "a-b-c", "a_b_c"},
+		{`s/^(\w+)-(\w+)$/\2-\1/`, ParsePattern, "prod-eu", "eu-prod"},
+		{"s/prod/&-old/", ParsePattern, "prod", "prod-old"},
+		{`s/prod/x\&y/`, ParsePattern, "prod", "x&y"},
+		{"s/prod/$1/", ParsePattern, "prod", "$1"},
+		{`s#a\#b#c#`, ParsePattern, "xa#b", "xc"},
+		{`s/a\/b/c/`, ParsePattern, "a/b", "c"},
+	}
+	for _, tt := range tests {
+		rule, err := tt.parse(tt.rule)
+		if err != nil {
+			t.Errorf("parsing %q: %v", tt.rule, err)
+			continue
+		}
+		got, changed := rule.Apply(tt.name)
+		if got != tt.want || changed != (tt.want != tt.name) {
+			t.Errorf("%q applied to %q = %q, %v; want %q", tt.rule, tt.name, got, changed, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"acme-", ":", "a:b:c"} {
+		if _, err := ParsePrefix(spec); err == nil {
+			t.Errorf("ParsePrefix(%q) succeeded, want an error", spec)
+		}
+	}
+	for _, expr := range []string{"", "s", "y/a/b/", "s/a/b", "s/a/b/c/d", "sa/b/c/", "s//b/", "s/(/b/", `s/a/\1/`, "s/a/b/i", `s/a/b\`} {
+		if _, err := ParsePattern(expr); err == nil {
+			t.Errorf("ParsePattern(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	rule, err := ParsePrefix("acme-:newco-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"acme-staging", "dev", "acme-prod", "newco-prod-eu", "acme-prod-eu"}
+
+	renames := Plan(names, rule)
+
+	want := []string{"acme-prod -> newco-prod", "acme-prod-eu -> newco-prod-eu", "acme-staging -> newco-staging"}
+	if len(renames) != len(want) {
+		t.Fatalf("Plan() = %+v, want %d renames", renames, len(want))
+	}
+	for i, rename := range renames {
+		if got := rename.From + " -> " + rename.To; got != want[i] {
+			t.Errorf("rename %d = %q, want %q", i, got, want[i])
+		}
+	}
+	invalid := Invalid(renames)
+	if len(invalid) != 1 || invalid[0].From != "acme-prod-eu" || !strings.Contains(invalid[0].Err.Error(), "already exists") {
+		t.Errorf("Invalid() = %+v, want the collision with newco-prod-eu", invalid)
+	}
+}
+
+func TestPlanRejectsInvalidAndDuplicateNames(t *testing.T) {
+	rule, err := ParsePattern("s/-(eu|us)$//")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renames := Plan([]string{"prod-eu", "prod-us", "9-eu"}, rule)
+
+	if len(renames) != 3 {
+		t.Fatalf("Plan() = %+v, want 3 renames", renames)
+	}
+	if renames[0].From != "9-eu" || renames[0].Err == nil {
+		t.Errorf("rename of 9-eu = %+v, want an invalid name", renames[0])
+	}
+	for _, rename := range renames[1:] {
+		if rename.Err == nil || !strings.Contains(rename.Err.Error(), `prod-eu, prod-us would all be renamed to "prod"`) {
+			t.Errorf("rename of %s: %v, want the duplicate reported", rename.From, rename.Err)
+		}
+	}
+}
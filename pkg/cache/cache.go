@@ -0,0 +1,109 @@
+// Package cache stores slow-to-fetch data, such as project lists, on disk.
+// Entries are JSON files in the user's cache directory and expire after a TTL.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+const cacheDirName = "gcloudctx"
+
+// unsafeKeyChars matches characters that are not allowed in cache file names
+var unsafeKeyChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// GetCacheDir returns the directory where cache entries are stored
+func GetCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	return filepath.Join(dir, cacheDirName), nil
+}
+
+// Load reads the entry for key into v if it exists and is younger than ttl.
+// It returns false when the entry is missing or expired.
+func Load(key string, ttl time.Duration, v any) (bool, error) {
+	dir, err := GetCacheDir()
+	if err != nil {
+		return false, err
+	}
+	return loadFromDir(dir, key, ttl, v)
+}
+
+// Save writes v as the entry for key
+func Save(key string, v any) error {
+	dir, err := GetCacheDir()
+	if err != nil {
+		return err
+	}
+	return saveToDir(dir, key, v)
+}
+
+// Clear removes the entry for key
+func Clear(key string) error {
+	dir, err := GetCacheDir()
+	if err != nil {
+		return err
+	}
+	path := entryPath(dir, key)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear cache entry %s: %w", path, err)
+	}
+	return nil
+}
+
+// entryPath returns the file path for a cache key
+func entryPath(dir, key string) string {
+	return filepath.Join(dir, unsafeKeyChars.ReplaceAllString(key, "_")+".json")
+}
+
+func loadFromDir(dir, key string, ttl time.Duration, v any) (bool, error) {
+	path := entryPath(dir, key)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat cache entry %s: %w", path, err)
+	}
+
+	if time.Since(info.ModTime()) > ttl {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read cache entry %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		// A corrupt entry is treated as a miss so it gets refreshed
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func saveToDir(dir, key string, v any) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	path := entryPath(dir, key)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write cache entry %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	want := []string{"a", "b"}
+	if err := saveToDir(dir, "projects-user@example.com", want); err != nil {
+		t.Fatalf("saveToDir failed: %v", err)
+	}
+
+	var got []string
+	found, err := loadFromDir(dir, "projects-user@example.com", time.Hour, &got)
+	if err != nil {
+		t.Fatalf("loadFromDir failed: %v", err)
+	}
+	if !found {
+		t.Fatal("Expected cache hit")
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("loadFromDir() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	var got []string
+	found, err := loadFromDir(t.TempDir(), "missing", time.Hour, &got)
+	if err != nil {
+		t.Fatalf("loadFromDir failed: %v", err)
+	}
+	if found {
+		t.Error("Expected cache miss for missing entry")
+	}
+}
+
+func TestLoadExpired(t *testing.T) {
+	dir := t.TempDir()
+	if err := saveToDir(dir, "key", "value"); err != nil {
+		t.Fatalf("saveToDir failed: %v", err)
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(entryPath(dir, "key"), old, old); err != nil {
+		t.Fatalf("failed to age cache entry: %v", err)
+	}
+
+	var got string
+	found, err := loadFromDir(dir, "key", time.Hour, &got)
+	if err != nil {
+		t.Fatalf("loadFromDir failed: %v", err)
+	}
+	if found {
+		t.Error("Expected cache miss for expired entry")
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(entryPath(dir, "key"), []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("failed to write corrupt entry: %v", err)
+	}
+
+	var got map[string]string
+	found, err := loadFromDir(dir, "key", time.Hour, &got)
+	if err != nil {
+		t.Fatalf("loadFromDir failed: %v", err)
+	}
+	if found {
+		t.Error("Expected cache miss for corrupt entry")
+	}
+}
+
+func TestEntryPathSanitizesKey(t *testing.T) {
+	path := entryPath("/cache", "projects-user@example.com/../x")
+	if filepath.Dir(path) != "/cache" {
+		t.Errorf("entryPath() escaped the cache directory: %s", path)
+	}
+	if filepath.Base(path) != "projects-user_example.com_.._x.json" {
+		t.Errorf("entryPath() = %s", path)
+	}
+}
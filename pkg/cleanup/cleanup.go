@@ -0,0 +1,214 @@
+// Package cleanup discovers and removes the state gcloudctx leaves on disk:
+// history and settings files, the in-flight state directory, caches, shell
+// hook blocks in rc files, and installed completion scripts.
+// gcloud's own configuration directory is never touched.
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/shellrc"
+)
+
+// Kind classifies a discovered artifact
+type Kind string
+
+// Artifact kinds
+const (
+	KindState      Kind = "state"
+	KindCache      Kind = "cache"
+	KindShellHook  Kind = "shell-hook"
+	KindShellLine  Kind = "shell-line"
+	KindCompletion Kind = "completion"
+)
+
+// ErrProtectedPath is returned when removing a path inside gcloud's configuration directory
+var ErrProtectedPath = errors.New("refusing to touch gcloud's configuration directory")
+
+// ErrManualRemoval is returned when removing an artifact only the user can remove
+var ErrManualRemoval = errors.New("remove by hand")
+
+// Artifact is a file, directory, or rc-file block owned by gcloudctx
+type Artifact struct {
+	Kind        Kind
+	Path        string
+	Description string
+	IsDir       bool
+	// Blocks is the number of managed blocks in an rc file (shell hooks only)
+	Blocks int
+}
+
+// Manual reports whether the artifact is only listed for the user to remove:
+// rc file lines outside managed blocks were written by the user, not gcloudctx
+func (a *Artifact) Manual() bool {
+	return a.Kind == KindShellLine
+}
+
+// DiscoverState returns the state files and directories in paths that exist
+// paths maps each path to a description, e.g. the history file to "configuration history"
+func DiscoverState(paths map[string]string) []Artifact {
+	var artifacts []Artifact
+	for _, path := range sortedPaths(paths) {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, Artifact{
+			Kind:        KindState,
+			Path:        path,
+			Description: paths[path],
+			IsDir:       info.IsDir(),
+		})
+	}
+	return artifacts
+}
+
+// DiscoverCache returns the cache directory when it exists
+func DiscoverCache(cacheDir string) []Artifact {
+	info, err := os.Stat(cacheDir)
+	if err != nil || !info.IsDir() {
+		return nil
+	}
+	return []Artifact{{Kind: KindCache, Path: cacheDir, Description: "cache directory", IsDir: true}}
+}
+
+// DiscoverShellHooks returns the rc files containing gcloudctx-managed blocks,
+// and the rc files with other lines mentioning gcloudctx (such as the hooks and
+// widgets pasted from the README) as manual artifacts
+func DiscoverShellHooks(rcFiles []string) ([]Artifact, error) {
+	var artifacts []Artifact
+	for _, path := range rcFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		if blocks := shellrc.FindBlocks(string(data)); len(blocks) > 0 {
+			names := make([]string, len(blocks))
+			for i, block := range blocks {
+				names[i] = block.Name
+			}
+			artifacts = append(artifacts, Artifact{
+				Kind:        KindShellHook,
+				Path:        path,
+				Description: "shell hook block(s): " + strings.Join(names, ", "),
+				Blocks:      len(blocks),
+			})
+		}
+
+		if lines := shellrc.FindReferences(string(data)); len(lines) > 0 {
+			numbers := make([]string, len(lines))
+			for i, line := range lines {
+				numbers[i] = strconv.Itoa(line)
+			}
+			artifacts = append(artifacts, Artifact{
+				Kind:        KindShellLine,
+				Path:        path,
+				Description: "mentions gcloudctx on line(s) " + strings.Join(numbers, ", ") + "; remove by hand",
+			})
+		}
+	}
+	return artifacts, nil
+}
+
+// DiscoverCompletions returns the completion scripts installed in home at the
+// locations suggested by 'gcloudctx completion --help' (and common per-user
+// alternatives). Only regular files that really live under home are returned:
+// symlinks, and files reached through a symlinked directory, usually belong to
+// a package manager or a dotfiles checkout and are left alone.
+func DiscoverCompletions(home string) []Artifact {
+	realHome, err := filepath.EvalSymlinks(home)
+	if err != nil {
+		return nil
+	}
+
+	var artifacts []Artifact
+	for _, path := range CompletionPaths(home) {
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil || !isWithin(resolved, realHome) {
+			continue
+		}
+		artifacts = append(artifacts, Artifact{Kind: KindCompletion, Path: path, Description: "completion script"})
+	}
+	return artifacts
+}
+
+// CompletionPaths returns the per-user install locations of gcloudctx
+// completion scripts. System and Homebrew locations (/etc/bash_completion.d,
+// site-functions) are not included: package managers install and remove those.
+func CompletionPaths(home string) []string {
+	return []string{
+		filepath.Join(home, ".config", "fish", "completions", "gcloudctx.fish"),
+		filepath.Join(home, ".local", "share", "bash-completion", "completions", "gcloudctx"),
+		filepath.Join(home, ".zfunc", "_gcloudctx"),
+		filepath.Join(home, ".zsh", "completions", "_gcloudctx"),
+	}
+}
+
+// GcloudConfigDir returns gcloud's configuration directory ($CLOUDSDK_CONFIG or ~/.config/gcloud)
+func GcloudConfigDir(home string) string {
+	return gcloud.ConfigDirFor(home)
+}
+
+// Remove deletes an artifact; shell hook artifacts only lose their managed blocks
+// (a timestamped .bak copy of the rc file is kept). Paths inside protectedDir
+// and manual artifacts are refused.
+func Remove(artifact Artifact, protectedDir string) error {
+	if isWithin(artifact.Path, protectedDir) {
+		return fmt.Errorf("%w: %s", ErrProtectedPath, artifact.Path)
+	}
+	if artifact.Manual() {
+		return fmt.Errorf("%w: %s (%s)", ErrManualRemoval, artifact.Path, artifact.Description)
+	}
+
+	switch {
+	case artifact.Kind == KindShellHook:
+		_, _, err := shellrc.RemoveBlocksFromFile(artifact.Path)
+		return err
+	case artifact.IsDir:
+		if err := os.RemoveAll(artifact.Path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", artifact.Path, err)
+		}
+	default:
+		if err := os.Remove(artifact.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", artifact.Path, err)
+		}
+	}
+	return nil
+}
+
+// isWithin reports whether path is dir or lies inside it
+func isWithin(path, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// sortedPaths returns the keys of paths in lexical order
+func sortedPaths(paths map[string]string) []string {
+	keys := make([]string, 0, len(paths))
+	for path := range paths {
+		keys = append(keys, path)
+	}
+	slices.Sort(keys)
+	return keys
+}
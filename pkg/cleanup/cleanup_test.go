@@ -0,0 +1,240 @@
+package cleanup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestDiscoverState(t *testing.T) {
+	home := t.TempDir()
+	history := filepath.Join(home, ".gcloudctx_previous")
+	inflightDir := filepath.Join(home, ".gcloudctx_inflight")
+	writeFile(t, history, "prod")
+	if err := os.Mkdir(inflightDir, 0o700); err != nil {
+		t.Fatalf("failed to create state dir: %v", err)
+	}
+
+	artifacts := DiscoverState(map[string]string{
+		history:                                "configuration history",
+		inflightDir:                            "in-flight operations",
+		filepath.Join(home, ".gcloudctx.yaml"): "settings file",
+	})
+
+	if len(artifacts) != 2 {
+		t.Fatalf("DiscoverState() returned %d artifacts, want 2: %+v", len(artifacts), artifacts)
+	}
+	// Artifacts are sorted by path
+	if artifacts[0].Path != inflightDir || !artifacts[0].IsDir {
+		t.Errorf("artifact 0 = %+v, want state directory", artifacts[0])
+	}
+	if artifacts[1].Path != history || artifacts[1].IsDir {
+		t.Errorf("artifact 1 = %+v, want history file", artifacts[1])
+	}
+}
+
+func TestDiscoverCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "gcloudctx")
+	if artifacts := DiscoverCache(dir); len(artifacts) != 0 {
+		t.Errorf("expected no artifacts for missing cache, got %+v", artifacts)
+	}
+
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatalf("failed to create cache dir: %v", err)
+	}
+	artifacts := DiscoverCache(dir)
+	if len(artifacts) != 1 || artifacts[0].Kind != KindCache || !artifacts[0].IsDir {
+		t.Errorf("DiscoverCache() = %+v, want the cache directory", artifacts)
+	}
+}
+
+func TestDiscoverShellHooks(t *testing.T) {
+	home := t.TempDir()
+	withHook := filepath.Join(home, ".zshrc")
+	withoutHook := filepath.Join(home, ".bashrc")
+	writeFile(t, withHook, "alias k=kubectl\n# >>> gcloudctx hook >>>\neval \"$(gcloudctx hook zsh)\"\n# <<< gcloudctx hook <<<\n")
+	writeFile(t, withoutHook, "alias k=kubectl\n")
+
+	artifacts, err := DiscoverShellHooks([]string{withHook, withoutHook, filepath.Join(home, ".missing")})
+	if err != nil {
+		t.Fatalf("DiscoverShellHooks failed: %v", err)
+	}
+	if len(artifacts) != 1 {
+		t.Fatalf("DiscoverShellHooks() returned %d artifacts, want 1", len(artifacts))
+	}
+	if artifacts[0].Path != withHook || artifacts[0].Blocks != 1 || artifacts[0].Kind != KindShellHook {
+		t.Errorf("artifact = %+v, want one hook block in .zshrc", artifacts[0])
+	}
+}
+
+func TestDiscoverShellHooksReportsPastedLines(t *testing.T) {
+	home := t.TempDir()
+	zshrc := filepath.Join(home, ".zshrc")
+	writeFile(t, zshrc, "alias k=kubectl\neval \"$(gcloudctx widget zsh)\"\n")
+
+	artifacts, err := DiscoverShellHooks([]string{zshrc})
+	if err != nil {
+		t.Fatalf("DiscoverShellHooks failed: %v", err)
+	}
+	if len(artifacts) != 1 || artifacts[0].Kind != KindShellLine || !artifacts[0].Manual() || !strings.Contains(artifacts[0].Description, "line(s) 2") {
+		t.Fatalf("DiscoverShellHooks() = %+v, want line 2 reported for manual removal", artifacts)
+	}
+
+	// The user's lines are never edited
+	if err := Remove(artifacts[0], ""); !errors.Is(err, ErrManualRemoval) {
+		t.Errorf("Remove() error = %v, want ErrManualRemoval", err)
+	}
+	if data, _ := os.ReadFile(zshrc); !strings.Contains(string(data), "gcloudctx widget zsh") {
+		t.Errorf(".zshrc = %q, want it untouched", data)
+	}
+}
+
+func TestDiscoverCompletions(t *testing.T) {
+	home := t.TempDir()
+	fish := filepath.Join(home, ".config", "fish", "completions", "gcloudctx.fish")
+	writeFile(t, fish, "complete -c gcloudctx")
+
+	artifacts := DiscoverCompletions(home)
+	found := false
+	for _, a := range artifacts {
+		if a.Path == fish && a.Kind == KindCompletion {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("DiscoverCompletions() = %+v, want fish completion", artifacts)
+	}
+}
+
+func TestDiscoverCompletionsSkipsPackageManagedFiles(t *testing.T) {
+	home := t.TempDir()
+	prefix := t.TempDir()
+
+	// A completion symlinked into home from a package manager prefix
+	brewZsh := filepath.Join(prefix, "share", "zsh", "site-functions", "_gcloudctx")
+	writeFile(t, brewZsh, "#compdef gcloudctx")
+	zsh := filepath.Join(home, ".zfunc", "_gcloudctx")
+	if err := os.MkdirAll(filepath.Dir(zsh), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(brewZsh, zsh); err != nil {
+		t.Fatal(err)
+	}
+
+	// A completions directory that is itself a link out of home
+	brewFish := filepath.Join(prefix, "share", "fish", "vendor_completions.d")
+	writeFile(t, filepath.Join(brewFish, "gcloudctx.fish"), "complete -c gcloudctx")
+	if err := os.MkdirAll(filepath.Join(home, ".config", "fish"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(brewFish, filepath.Join(home, ".config", "fish", "completions")); err != nil {
+		t.Fatal(err)
+	}
+
+	if artifacts := DiscoverCompletions(home); len(artifacts) != 0 {
+		t.Errorf("DiscoverCompletions() = %+v, want none", artifacts)
+	}
+}
+
+func TestCompletionPathsStayInHome(t *testing.T) {
+	home := t.TempDir()
+	for _, path := range CompletionPaths(home) {
+		if !isWithin(path, home) {
+			t.Errorf("CompletionPaths() includes %s outside the home directory", path)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	home := t.TempDir()
+
+	file := filepath.Join(home, ".gcloudctx_previous")
+	writeFile(t, file, "prod")
+	if err := Remove(Artifact{Kind: KindState, Path: file}, ""); err != nil {
+		t.Fatalf("Remove(file) failed: %v", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error("expected file to be removed")
+	}
+
+	dir := filepath.Join(home, "cache", "gcloudctx")
+	writeFile(t, filepath.Join(dir, "projects.json"), "[]")
+	if err := Remove(Artifact{Kind: KindCache, Path: dir, IsDir: true}, ""); err != nil {
+		t.Fatalf("Remove(dir) failed: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("expected directory to be removed")
+	}
+
+	rc := filepath.Join(home, ".zshrc")
+	writeFile(t, rc, "alias k=kubectl\n# >>> gcloudctx hook >>>\nhook\n# <<< gcloudctx hook <<<\n")
+	if err := Remove(Artifact{Kind: KindShellHook, Path: rc}, ""); err != nil {
+		t.Fatalf("Remove(hook) failed: %v", err)
+	}
+	data, err := os.ReadFile(rc)
+	if err != nil {
+		t.Fatalf("failed to read rc file: %v", err)
+	}
+	if string(data) != "alias k=kubectl\n" {
+		t.Errorf("rc file after removal = %q, want only user content", data)
+	}
+}
+
+func TestRemoveRefusesGcloudConfigDir(t *testing.T) {
+	home := t.TempDir()
+	gcloudDir := GcloudConfigDir(home)
+	target := filepath.Join(gcloudDir, "configurations", "config_default")
+	writeFile(t, target, "[core]\n")
+
+	for _, path := range []string{gcloudDir, target} {
+		err := Remove(Artifact{Kind: KindState, Path: path, IsDir: path == gcloudDir}, gcloudDir)
+		if !errors.Is(err, ErrProtectedPath) {
+			t.Errorf("Remove(%s) error = %v, want ErrProtectedPath", path, err)
+		}
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("gcloud configuration was touched: %v", err)
+	}
+}
+
+func TestGcloudConfigDir(t *testing.T) {
+	t.Setenv("CLOUDSDK_CONFIG", "")
+	if got, want := GcloudConfigDir("/home/user"), filepath.Join("/home/user", ".config", "gcloud"); got != want {
+		t.Errorf("GcloudConfigDir() = %q, want %q", got, want)
+	}
+
+	t.Setenv("CLOUDSDK_CONFIG", "/custom/gcloud")
+	if got := GcloudConfigDir("/home/user"); got != "/custom/gcloud" {
+		t.Errorf("GcloudConfigDir() = %q, want $CLOUDSDK_CONFIG", got)
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	tests := []struct {
+		path, dir string
+		want      bool
+	}{
+		{"/home/u/.config/gcloud", "/home/u/.config/gcloud", true},
+		{"/home/u/.config/gcloud/x", "/home/u/.config/gcloud", true},
+		{"/home/u/.config/gcloudctx", "/home/u/.config/gcloud", false},
+		{"/home/u/.gcloudctx_previous", "/home/u/.config/gcloud", false},
+		{"/home/u/x", "", false},
+	}
+	for _, tt := range tests {
+		if got := isWithin(tt.path, tt.dir); got != tt.want {
+			t.Errorf("isWithin(%q, %q) = %v, want %v", tt.path, tt.dir, got, tt.want)
+		}
+	}
+}
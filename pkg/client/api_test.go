@@ -0,0 +1,93 @@
+package client
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/apitest"
+)
+
+// exportedFuncs lists every exported function; removing one breaks compilation here,
+// changing a signature changes the golden file
+var exportedFuncs = map[string]any{
+	"Default":            Default,
+	"NewClient":          NewClient,
+	"WithAllowProtected": WithAllowProtected,
+	"WithClock":          WithClock,
+	"WithConfigDir":      WithConfigDir,
+	"WithExecutor":       WithExecutor,
+	"WithLogger":         WithLogger,
+	"WithStateDir":       WithStateDir,
+	"WithTimeout":        WithTimeout,
+}
+
+// exportedTypes lists every exported type
+var exportedTypes = map[string]reflect.Type{
+	"Client":       reflect.TypeFor[Client](),
+	"Executor":     reflect.TypeFor[Executor](),
+	"ExecutorFunc": reflect.TypeFor[ExecutorFunc](),
+	"Option":       reflect.TypeFor[Option](),
+	"SwitchResult": reflect.TypeFor[SwitchResult](),
+}
+
+// exportedValues lists every exported constant and variable
+var exportedValues = map[string]any{
+	"DefaultTimeout": DefaultTimeout,
+	"ErrNotFound":    ErrNotFound,
+	"ErrProtected":   ErrProtected,
+}
+
+func TestAPIStability(t *testing.T) {
+	apitest.Check(t, apitest.API{Funcs: exportedFuncs, Types: exportedTypes, Values: exportedValues})
+}
+
+// TestAPITablesComplete makes sure every exported top-level identifier is listed above,
+// so new symbols cannot bypass the stability check
+func TestAPITablesComplete(t *testing.T) {
+	fset := token.NewFileSet()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", file, err)
+		}
+
+		for _, decl := range parsed.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil && d.Name.IsExported() {
+					if _, ok := exportedFuncs[d.Name.Name]; !ok {
+						t.Errorf("exported func %s is missing from exportedFuncs", d.Name.Name)
+					}
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						if _, ok := exportedTypes[s.Name.Name]; s.Name.IsExported() && !ok {
+							t.Errorf("exported type %s is missing from exportedTypes", s.Name.Name)
+						}
+					case *ast.ValueSpec:
+						for _, name := range s.Names {
+							if _, ok := exportedValues[name.Name]; name.IsExported() && !ok {
+								t.Errorf("exported value %s is missing from exportedValues", name.Name)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/settings"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statelock"
+)
+
+// DefaultTimeout bounds each gcloud command unless WithTimeout says otherwise
+const DefaultTimeout = 30 * time.Second
+
+// listCacheTTL is how long a configuration list is reused by concurrent callers
+// Mutations through the client drop the cache immediately
+const listCacheTTL = 2 * time.Second
+
+// ErrNotFound is returned when a named configuration does not exist
+// It is gcloud.ErrConfigurationNotFound, so errors from either package match it
+var ErrNotFound = gcloud.ErrConfigurationNotFound
+
+// ErrProtected is returned when switching to a protected configuration
+// without WithAllowProtected
+var ErrProtected = errors.New("configuration is protected")
+
+// SwitchResult describes the outcome of Client.Switch
+type SwitchResult struct {
+	// Previous is the configuration that was active before the switch
+	Previous string
+	// Current is the configuration that is active now
+	Current string
+	// Changed is false when Current was already active
+	Changed bool
+	// Diff lists the properties that differ between Previous and Current
+	Diff []gcloud.PropertyDiff
+}
+
+// Client lists and switches gcloud configurations
+type Client struct {
+	executor       Executor
+	configDir      string
+	stateDir       string
+	timeout        time.Duration
+	logger         *slog.Logger
+	now            func() time.Time
+	allowProtected bool
+
+	// mu guards the configuration list cache shared by concurrent calls
+	mu       sync.Mutex
+	cache    []gcloud.Configuration
+	cachedAt time.Time
+}
+
+// NewClient returns a client with the given options
+// Without options it runs the gcloud binary in PATH, keeps no history, and
+// bounds each command by DefaultTimeout
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		timeout: DefaultTimeout,
+		logger:  slog.New(slog.DiscardHandler),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.executor == nil {
+		c.executor = &gcloudExecutor{configDir: c.configDir}
+	}
+	return c
+}
+
+// Default returns a client configured like the gcloudctx CLI: gcloud's
+// configuration directory honors $CLOUDSDK_CONFIG, and switch history is kept
+// in the home directory so 'gcloudctx -' sees switches made through the client
+// Options are applied after the defaults and may override them
+func Default(opts ...Option) (*Client, error) {
+	configDir, err := gcloud.ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	defaults := []Option{WithConfigDir(configDir), WithStateDir(home)}
+	return NewClient(append(defaults, opts...)...), nil
+}
+
+// List returns all configurations, sorted by name
+func (c *Client) List(ctx context.Context) ([]gcloud.Configuration, error) {
+	c.mu.Lock()
+	if c.cache != nil && c.now().Sub(c.cachedAt) < listCacheTTL {
+		configs := slices.Clone(c.cache)
+		c.mu.Unlock()
+		return configs, nil
+	}
+	c.mu.Unlock()
+
+	configs, err := c.gcloud(ctx).List()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.cache = slices.Clone(configs)
+	c.cachedAt = c.now()
+	c.mu.Unlock()
+
+	return configs, nil
+}
+
+// Active returns the active configuration, or an error wrapping
+// gcloud.ErrNoActiveConfiguration
+func (c *Client) Active(ctx context.Context) (*gcloud.Configuration, error) {
+	configs, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range configs {
+		if configs[i].IsActive {
+			return &configs[i], nil
+		}
+	}
+	return nil, gcloud.ErrNoActiveConfiguration
+}
+
+// Get returns the named configuration, or an error wrapping ErrNotFound
+func (c *Client) Get(ctx context.Context, name string) (*gcloud.Configuration, error) {
+	configs, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range configs {
+		if configs[i].Name == name {
+			return &configs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+}
+
+// Switch activates the named configuration and records the previous one in
+// the switch history (when a state directory is set)
+// Switching to the active configuration changes nothing and is not an error.
+// With a state directory, switches are serialized with other clients and the
+// CLI through the state lock, waiting at most until ctx is done, and the
+// settings file there applies as it does to the CLI: switching to a protected
+// configuration fails with ErrProtected unless WithAllowProtected is given,
+// read_only makes Switch fail with gcloud.ErrReadOnly (as GCLOUDCTX_READONLY
+// does), and history.enabled: false keeps the switch out of the history.
+func (c *Client) Switch(ctx context.Context, name string) (*SwitchResult, error) {
+	if err := gcloud.ValidateExistingConfigurationName(name); err != nil {
+		return nil, err
+	}
+
+	var userSettings *settings.Settings
+	if c.stateDir != "" {
+		var err error
+		if userSettings, err = settings.LoadFrom(c.stateDir); err != nil {
+			return nil, err
+		}
+		if err := c.checkSettings(name, userSettings); err != nil {
+			return nil, err
+		}
+
+		lock, err := statelock.Acquire(ctx, statelock.LockFilePathIn(c.stateDir))
+		if err != nil {
+			return nil, err
+		}
+		defer lock.Unlock()
+	}
+
+	// Decide on fresh state read under the lock; a cached list may predate
+	// another process's switch
+	c.invalidate()
+	active, err := c.Active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	target, err := c.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if active.Name == target.Name {
+		return &SwitchResult{Previous: active.Name, Current: target.Name, Diff: []gcloud.PropertyDiff{}}, nil
+	}
+
+	err = c.gcloud(ctx).Activate(name)
+	c.invalidate()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only a switch that happened is recorded, like the CLI does
+	if c.stateDir != "" {
+		c.recordSwitch(active.Name, target.Name, userSettings)
+	}
+
+	return &SwitchResult{
+		Previous: active.Name,
+		Current:  target.Name,
+		Changed:  true,
+		Diff:     gcloud.DiffConfigurations(active, target),
+	}, nil
+}
+
+// checkSettings refuses a switch the settings of the state directory forbid:
+// any switch with read_only, and one to a protected configuration unless
+// WithAllowProtected was given
+func (c *Client) checkSettings(name string, userSettings *settings.Settings) error {
+	if userSettings.ReadOnly {
+		return fmt.Errorf("%w: switching to %q is not allowed (read_only is set in %s)", gcloud.ErrReadOnly, name, settings.SettingsFilePathIn(c.stateDir))
+	}
+	if !c.allowProtected && userSettings.IsProtected(name) {
+		return fmt.Errorf("%w: %q", ErrProtected, name)
+	}
+	return nil
+}
+
+// recordSwitch records a switch in the state directory: the history and usage
+// (unless history is disabled there), and the active configuration, so the
+// CLI does not take the switch for one made without gcloudctx
+func (c *Client) recordSwitch(from, to string, userSettings *settings.Settings) {
+	if userSettings.History.IsEnabled() {
+		if err := history.RecordSwitchAt(history.HistoryFilePathIn(c.stateDir), from, to); err != nil {
+			c.logger.Warn("failed to record switch history", "error", err)
+		}
+		if err := history.RecordUsageAt(history.UsageFilePathIn(c.stateDir), to, c.now()); err != nil {
+			c.logger.Warn("failed to record usage", "error", err)
+		}
+	}
+
+	configDir := c.configDir
+	if configDir == "" {
+		dir, err := gcloud.ConfigDir()
+		if err != nil {
+			c.logger.Warn("failed to remember the active configuration", "error", err)
+			return
+		}
+		configDir = dir
+	}
+	if err := history.RememberActiveAt(history.LastActiveFilePathIn(c.stateDir), configDir, to); err != nil {
+		c.logger.Warn("failed to remember the active configuration", "error", err)
+	}
+}
+
+// gcloud returns a gcloud.Client running its commands through the executor
+// under ctx, so they pass the same read-only and offline checks as the CLI's
+func (c *Client) gcloud(ctx context.Context) *gcloud.Client {
+	return gcloud.NewClient(gcloud.WithRunner(executorRunner{ctx: ctx, client: c}), gcloud.WithConfigRoot(c.configDir))
+}
+
+// executorRunner is the gcloud.Runner of a Client for one call
+type executorRunner struct {
+	ctx    context.Context
+	client *Client
+}
+
+func (r executorRunner) Run(args ...string) (string, error) {
+	return r.client.run(r.ctx, args...)
+}
+
+func (r executorRunner) RunQuiet(args ...string) error {
+	_, err := r.client.run(r.ctx, args...)
+	return err
+}
+
+// run executes a gcloud command through the executor, applying the timeout
+func (c *Client) run(ctx context.Context, args ...string) (string, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := c.now()
+	output, err := c.executor.Run(ctx, args...)
+	c.logger.Debug("ran gcloud", "args", args, "duration", c.now().Sub(start), "error", err)
+	return output, err
+}
+
+// invalidate drops the cached configuration list
+func (c *Client) invalidate() {
+	c.mu.Lock()
+	c.cache = nil
+	c.mu.Unlock()
+}
@@ -0,0 +1,383 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/settings"
+)
+
+// fakeExecutor serves a configuration list and records activations
+type fakeExecutor struct {
+	mu     sync.Mutex
+	active string
+	names  []string
+	calls  []string
+	// activateDelay slows activations down, widening the window for races
+	activateDelay time.Duration
+}
+
+func newFakeExecutor(active string, names ...string) *fakeExecutor {
+	return &fakeExecutor{active: active, names: names}
+}
+
+func (f *fakeExecutor) Run(ctx context.Context, args ...string) (string, error) {
+	if len(args) > 2 && args[2] == "activate" && f.activateDelay > 0 {
+		time.Sleep(f.activateDelay)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.Join(args, " ")
+	f.calls = append(f.calls, key)
+
+	switch {
+	case key == "config configurations list --format=json":
+		var entries []string
+		for _, name := range f.names {
+			entries = append(entries, fmt.Sprintf(`{"name": %q, "is_active": %t, "properties": {"core": {"project": "%s-project"}}}`, name, name == f.active, name))
+		}
+		return "[" + strings.Join(entries, ",") + "]", nil
+	case strings.HasPrefix(key, "config configurations activate "):
+		f.active = args[len(args)-1]
+		return "", nil
+	default:
+		return "", fmt.Errorf("unexpected gcloud command: %s", key)
+	}
+}
+
+func (f *fakeExecutor) listCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, call := range f.calls {
+		if strings.HasPrefix(call, "config configurations list") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSwitchRecordsHistory(t *testing.T) {
+	stateDir := t.TempDir()
+	fake := newFakeExecutor("prod", "prod", "dev")
+	c := NewClient(WithExecutor(fake), WithStateDir(stateDir))
+
+	result, err := c.Switch(context.Background(), "dev")
+	if err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+	if !result.Changed || result.Previous != "prod" || result.Current != "dev" {
+		t.Errorf("Switch() = %+v", result)
+	}
+	if len(result.Diff) != 1 || result.Diff[0].Property != "core/project" {
+		t.Errorf("Switch() diff = %+v, want core/project", result.Diff)
+	}
+
+	data, err := os.ReadFile(history.HistoryFilePathIn(stateDir))
+	if err != nil {
+		t.Fatalf("history not written: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != "prod" {
+		t.Errorf("history = %q, want prod", data)
+	}
+
+	active, err := c.Active(context.Background())
+	if err != nil || active.Name != "dev" {
+		t.Errorf("Active() after switch = %v, %v, want dev", active, err)
+	}
+}
+
+func TestSwitchAlreadyActive(t *testing.T) {
+	stateDir := t.TempDir()
+	fake := newFakeExecutor("prod", "prod", "dev")
+	c := NewClient(WithExecutor(fake), WithStateDir(stateDir))
+
+	result, err := c.Switch(context.Background(), "prod")
+	if err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+	if result.Changed {
+		t.Errorf("Switch() to the active configuration reported a change: %+v", result)
+	}
+	if _, err := os.Stat(history.HistoryFilePathIn(stateDir)); !os.IsNotExist(err) {
+		t.Error("history should not be written when nothing changed")
+	}
+}
+
+func TestSwitchNotFound(t *testing.T) {
+	c := NewClient(WithExecutor(newFakeExecutor("prod", "prod")))
+
+	if _, err := c.Switch(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Switch() error = %v, want ErrNotFound", err)
+	}
+	if _, err := c.Switch(context.Background(), "bad name"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Switch() with an invalid name should fail validation, got %v", err)
+	}
+}
+
+func TestSwitchReadOnly(t *testing.T) {
+	t.Setenv(gcloud.EnvReadOnly, "1")
+	stateDir := t.TempDir()
+	fake := newFakeExecutor("prod", "prod", "dev")
+	c := NewClient(WithExecutor(fake), WithStateDir(stateDir))
+
+	if _, err := c.Switch(context.Background(), "dev"); !errors.Is(err, gcloud.ErrReadOnly) {
+		t.Errorf("Switch() in read-only mode error = %v, want gcloud.ErrReadOnly", err)
+	}
+	assertNotSwitched(t, fake, stateDir)
+}
+
+func TestSwitchReadOnlySetting(t *testing.T) {
+	stateDir := t.TempDir()
+	writeSettings(t, stateDir, "read_only: true\n")
+	fake := newFakeExecutor("prod", "prod", "dev")
+	c := NewClient(WithExecutor(fake), WithStateDir(stateDir))
+
+	if _, err := c.Switch(context.Background(), "dev"); !errors.Is(err, gcloud.ErrReadOnly) {
+		t.Errorf("Switch() with read_only set error = %v, want gcloud.ErrReadOnly", err)
+	}
+	assertNotSwitched(t, fake, stateDir)
+}
+
+func TestSwitchHistoryDisabled(t *testing.T) {
+	stateDir := t.TempDir()
+	writeSettings(t, stateDir, "history:\n  enabled: false\n")
+	c := NewClient(WithExecutor(newFakeExecutor("prod", "prod", "dev")), WithStateDir(stateDir), WithConfigDir(t.TempDir()))
+
+	if result, err := c.Switch(context.Background(), "dev"); err != nil || !result.Changed {
+		t.Fatalf("Switch() = %+v, %v; want a switch", result, err)
+	}
+	for _, path := range []string{history.HistoryFilePathIn(stateDir), history.UsageFilePathIn(stateDir)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was written with history disabled", path)
+		}
+	}
+}
+
+func TestSwitchRemembersActive(t *testing.T) {
+	stateDir, configDir := t.TempDir(), t.TempDir()
+	lastActive, historyPath := history.LastActiveFilePathIn(stateDir), history.HistoryFilePathIn(stateDir)
+	// The CLI has seen prod active before
+	if err := history.RememberActiveAt(lastActive, configDir, "prod"); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(WithExecutor(newFakeExecutor("prod", "prod", "dev")), WithStateDir(stateDir), WithConfigDir(configDir))
+
+	if _, err := c.Switch(context.Background(), "dev"); err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+
+	// The next CLI run does not take the switch for an external one
+	if from, err := history.ReconcileActiveAt(lastActive, historyPath, configDir, "dev"); err != nil || from != "" {
+		t.Errorf("ReconcileActiveAt() after Switch = %q, %v; want no external switch", from, err)
+	}
+	data, err := os.ReadFile(historyPath)
+	if err != nil || strings.TrimSpace(string(data)) != "prod" {
+		t.Errorf("history = %q, %v; want the switch recorded once", data, err)
+	}
+}
+
+// writeSettings writes the settings file of a state directory
+func writeSettings(t *testing.T, stateDir, contents string) {
+	t.Helper()
+	if err := os.WriteFile(settings.SettingsFilePathIn(stateDir), []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertNotSwitched checks that no activation ran and nothing was recorded
+func assertNotSwitched(t *testing.T, fake *fakeExecutor, stateDir string) {
+	t.Helper()
+	for _, call := range fake.calls {
+		if strings.Contains(call, "activate") {
+			t.Errorf("gcloud ran %q for a refused switch", call)
+		}
+	}
+	for _, path := range []string{history.HistoryFilePathIn(stateDir), history.UsageFilePathIn(stateDir), history.LastActiveFilePathIn(stateDir)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was written for a refused switch", path)
+		}
+	}
+}
+
+func TestSwitchProtected(t *testing.T) {
+	stateDir := t.TempDir()
+	writeSettings(t, stateDir, "protected: [dev]\n")
+	fake := newFakeExecutor("prod", "prod", "dev")
+
+	c := NewClient(WithExecutor(fake), WithStateDir(stateDir))
+	if _, err := c.Switch(context.Background(), "dev"); !errors.Is(err, ErrProtected) {
+		t.Errorf("Switch() to a protected configuration error = %v, want ErrProtected", err)
+	}
+
+	c = NewClient(WithExecutor(fake), WithStateDir(stateDir), WithAllowProtected(true))
+	if result, err := c.Switch(context.Background(), "dev"); err != nil || !result.Changed {
+		t.Errorf("Switch() with WithAllowProtected = %+v, %v; want a switch", result, err)
+	}
+}
+
+func TestNotFoundMatchesGcloud(t *testing.T) {
+	c := NewClient(WithExecutor(newFakeExecutor("prod", "prod")))
+
+	if _, err := c.Get(context.Background(), "missing"); !errors.Is(err, gcloud.ErrConfigurationNotFound) {
+		t.Errorf("Get() error = %v, want gcloud.ErrConfigurationNotFound", err)
+	}
+}
+
+func TestListCache(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := newFakeExecutor("prod", "prod", "dev")
+	c := NewClient(WithExecutor(fake), WithClock(func() time.Time { return now }))
+
+	for range 3 {
+		if _, err := c.List(context.Background()); err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+	}
+	if got := fake.listCalls(); got != 1 {
+		t.Errorf("gcloud list ran %d times within the cache TTL, want 1", got)
+	}
+
+	now = now.Add(listCacheTTL)
+	if _, err := c.List(context.Background()); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if got := fake.listCalls(); got != 2 {
+		t.Errorf("gcloud list ran %d times after the cache expired, want 2", got)
+	}
+}
+
+func TestListReturnsCopies(t *testing.T) {
+	c := NewClient(WithExecutor(newFakeExecutor("prod", "prod")))
+
+	first, _ := c.List(context.Background())
+	first[0].Name = "mutated"
+
+	second, err := c.List(context.Background())
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if second[0].Name != "prod" {
+		t.Errorf("mutating a result changed the cache: %q", second[0].Name)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	blocking := ExecutorFunc(func(ctx context.Context, args ...string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	c := NewClient(WithExecutor(blocking), WithTimeout(10*time.Millisecond))
+
+	if _, err := c.List(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("List() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	fake := newFakeExecutor("prod", "prod", "dev", "staging")
+	c := NewClient(WithExecutor(fake))
+
+	var wg sync.WaitGroup
+	targets := []string{"prod", "dev", "staging"}
+	for i := range 30 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%3 == 0 {
+				if _, err := c.Switch(context.Background(), targets[i%len(targets)]); err != nil {
+					t.Errorf("Switch failed: %v", err)
+				}
+				return
+			}
+			if _, err := c.List(context.Background()); err != nil {
+				t.Errorf("List failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConcurrentSwitchesKeepHistoryConsistent(t *testing.T) {
+	stateDir := t.TempDir()
+	fake := newFakeExecutor("prod", "prod", "dev", "staging")
+	fake.activateDelay = 2 * time.Millisecond
+
+	// Two clients stand in for two terminals sharing gcloud and the state directory
+	clients := []*Client{
+		NewClient(WithExecutor(fake), WithStateDir(stateDir)),
+		NewClient(WithExecutor(fake), WithStateDir(stateDir)),
+	}
+	targets := []string{"dev", "staging"}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results []*SwitchResult
+	)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := clients[i%2].Switch(context.Background(), targets[i%2])
+			if err != nil {
+				t.Errorf("Switch failed: %v", err)
+				return
+			}
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// Serialized switches form a chain: each one starts where the previous ended
+	var changed []*SwitchResult
+	for _, result := range results {
+		if result.Changed {
+			changed = append(changed, result)
+		}
+	}
+	current := "prod"
+	for range changed {
+		next := -1
+		for i, result := range changed {
+			if result != nil && result.Previous == current {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			t.Fatalf("no switch starts from %q; switches interleaved", current)
+		}
+		current = changed[next].Current
+		changed[next] = nil
+	}
+
+	fake.mu.Lock()
+	active := fake.active
+	fake.mu.Unlock()
+	if active != current {
+		t.Errorf("active configuration = %q, want %q (the end of the switch chain)", active, current)
+	}
+
+	// The most recent history entry is the configuration active before the last switch
+	entries, err := os.ReadFile(history.HistoryFilePathIn(stateDir))
+	if err != nil {
+		t.Fatalf("history not written: %v", err)
+	}
+	previous := strings.Split(string(entries), "\n")[0]
+	if previous == active {
+		t.Errorf("history starts with the active configuration %q", previous)
+	}
+}
@@ -0,0 +1,24 @@
+// Package client is the library API of gcloudctx, for tools that want to list
+// and switch gcloud configurations without shelling out to the gcloudctx CLI.
+//
+// A Client is created with NewClient and functional options, or with Default,
+// which is configured like the CLI ($CLOUDSDK_CONFIG is honored, switch
+// history is shared with 'gcloudctx -', and the read_only, history.enabled and
+// protected settings of ~/.gcloudctx.yaml apply). All methods of Client are
+// safe for concurrent use.
+//
+// A Client is a thin layer over gcloud.Client, adding contexts, timeouts,
+// logging and switch history. Its gcloud commands go through the same checks as
+// the CLI's, so read-only and offline mode apply, and its errors match the
+// gcloud.Err* values with errors.Is. For operations other than listing and
+// switching, use gcloud.Client directly.
+//
+// # Compatibility
+//
+// The exported surface of this package is stable within a major version of
+// the module: exported identifiers are not removed, renamed, or changed in
+// signature, and new knobs are added as new Option functions rather than new
+// parameters. The surface is recorded in testdata/api.golden and checked by the
+// tests, so a breaking change cannot land by accident. Types from pkg/gcloud
+// used in signatures (Configuration, PropertyDiff) carry the same guarantee.
+package client
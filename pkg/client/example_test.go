@@ -0,0 +1,53 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/client"
+)
+
+func ExampleClient() {
+	// A fake executor standing in for the gcloud binary
+	active := "prod"
+	fake := client.ExecutorFunc(func(ctx context.Context, args ...string) (string, error) {
+		switch strings.Join(args[:3], " ") {
+		case "config configurations list":
+			return fmt.Sprintf(`[
+				{"name": "prod", "is_active": %t, "properties": {"core": {"project": "prod-project"}}},
+				{"name": "dev", "is_active": %t, "properties": {"core": {"project": "dev-project"}}}
+			]`, active == "prod", active == "dev"), nil
+		case "config configurations activate":
+			active = args[3]
+			return "", nil
+		}
+		return "", fmt.Errorf("unexpected command: %v", args)
+	})
+
+	c := client.NewClient(client.WithExecutor(fake))
+	ctx := context.Background()
+
+	configs, err := c.List(ctx)
+	if err != nil {
+		panic(err)
+	}
+	for _, config := range configs {
+		fmt.Println(config.Name, config.IsActive)
+	}
+
+	result, err := c.Switch(ctx, "dev")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("switched from %s to %s\n", result.Previous, result.Current)
+	for _, diff := range result.Diff {
+		fmt.Printf("%s: %s -> %s\n", diff.Property, diff.From, diff.To)
+	}
+
+	// Output:
+	// dev false
+	// prod true
+	// switched from prod to dev
+	// core/project: prod-project -> dev-project
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// Executor runs gcloud commands for a Client
+// Commands reach it only after the checks every gcloudctx command passes:
+// read-only mode refuses those that change a configuration, and offline mode
+// those that need the network
+type Executor interface {
+	// Run executes gcloud with args and returns its trimmed output
+	// It must stop when ctx is done and be safe for concurrent use
+	Run(ctx context.Context, args ...string) (string, error)
+}
+
+// ExecutorFunc adapts a function to the Executor interface
+type ExecutorFunc func(ctx context.Context, args ...string) (string, error)
+
+// Run calls f(ctx, args...)
+func (f ExecutorFunc) Run(ctx context.Context, args ...string) (string, error) {
+	return f(ctx, args...)
+}
+
+// gcloudExecutor runs the gcloud binary found in PATH
+type gcloudExecutor struct {
+	configDir string
+}
+
+func (e *gcloudExecutor) Run(ctx context.Context, args ...string) (string, error) {
+	gcloudPath, err := gcloud.GcloudPath()
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, gcloudPath, args...)
+	if e.configDir != "" {
+		cmd.Env = append(os.Environ(), gcloud.EnvConfigDir+"="+e.configDir)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", fmt.Errorf("gcloud %s: %w", strings.Join(args, " "), ctxErr)
+		}
+		return "", gcloud.ClassifyError(args, string(output), err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
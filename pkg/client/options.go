@@ -0,0 +1,64 @@
+package client
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithExecutor runs gcloud commands with e instead of the gcloud binary in PATH
+func WithExecutor(e Executor) Option {
+	return func(c *Client) {
+		c.executor = e
+	}
+}
+
+// WithConfigDir points gcloud at a configuration directory, like $CLOUDSDK_CONFIG
+// It only affects the default executor
+func WithConfigDir(dir string) Option {
+	return func(c *Client) {
+		c.configDir = dir
+	}
+}
+
+// WithStateDir records switch history in dir; an empty dir disables history
+// Default uses the home directory, sharing history with the CLI
+func WithStateDir(dir string) Option {
+	return func(c *Client) {
+		c.stateDir = dir
+	}
+}
+
+// WithTimeout bounds every gcloud command; zero disables the timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithLogger logs each gcloud command at debug level; nil discards logs
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		if logger == nil {
+			logger = slog.New(slog.DiscardHandler)
+		}
+		c.logger = logger
+	}
+}
+
+// WithClock replaces the clock used for cache expiry and command durations
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+// WithAllowProtected lets Switch activate configurations protected in the
+// settings of the state directory, like the CLI's --yes
+func WithAllowProtected(allow bool) Option {
+	return func(c *Client) {
+		c.allowProtected = allow
+	}
+}
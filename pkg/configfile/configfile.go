@@ -0,0 +1,216 @@
+// Package configfile defines the portable file format used by export and import.
+// Property values are either literals or references such as
+// "valueFrom: {env: VAR_NAME}" that are resolved on the importing machine,
+// so shared files do not need to embed sensitive values.
+package configfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of environment variable names generated for redacted values
+const EnvPrefix = "GCLOUDCTX_"
+
+// Config represents the exported configuration format
+type Config struct {
+	Name    string `json:"name" yaml:"name"`
+	Account Value  `json:"account,omitzero" yaml:"account,omitempty"`
+	Project Value  `json:"project,omitzero" yaml:"project,omitempty"`
+	Region  Value  `json:"region,omitzero" yaml:"region,omitempty"`
+	Zone    Value  `json:"zone,omitzero" yaml:"zone,omitempty"`
+
+	// ImpersonateServiceAccount and SyncADC are hints for syncing Application
+	// Default Credentials; files written before they existed simply omit them
+	ImpersonateServiceAccount Value `json:"impersonate_service_account,omitzero" yaml:"impersonate_service_account,omitempty"`
+	SyncADC                   bool  `json:"sync_adc,omitempty" yaml:"sync_adc,omitempty"`
+
+	// DisableUsageReporting is core/disable_usage_reporting; nil leaves it unset
+	DisableUsageReporting *bool `json:"disable_usage_reporting,omitempty" yaml:"disable_usage_reporting,omitempty"`
+
+	// Note is gcloudctx's note about the configuration, written and read only
+	// with --include-notes
+	Note string `json:"note,omitempty" yaml:"note,omitempty"`
+}
+
+// Resolved holds the plain property values of a Config after resolving references
+type Resolved struct {
+	Name    string
+	Account string
+	Project string
+	Region  string
+	Zone    string
+
+	ImpersonateServiceAccount string
+	SyncADC                   bool
+	DisableUsageReporting     *bool
+
+	Note string
+}
+
+// Value is a property value: either a literal string or a reference to be
+// resolved when the file is imported
+type Value struct {
+	Literal string
+	From    *ValueFrom
+}
+
+// ValueFrom describes where a referenced value comes from
+type ValueFrom struct {
+	Env string `json:"env" yaml:"env"`
+}
+
+// valueRef is the serialized form of a reference value
+type valueRef struct {
+	ValueFrom *ValueFrom `json:"valueFrom" yaml:"valueFrom"`
+}
+
+// Literal returns a Value holding a literal string
+func Literal(s string) Value {
+	return Value{Literal: s}
+}
+
+// FromEnv returns a Value referencing an environment variable
+func FromEnv(name string) Value {
+	return Value{From: &ValueFrom{Env: name}}
+}
+
+// IsZero reports whether the value is unset
+func (v Value) IsZero() bool {
+	return v.Literal == "" && v.From == nil
+}
+
+// Resolve returns the value, looking up references with lookup
+// (typically os.LookupEnv)
+func (v Value) Resolve(lookup func(string) (string, bool)) (string, error) {
+	if v.From == nil {
+		return v.Literal, nil
+	}
+	if v.From.Env == "" {
+		return "", fmt.Errorf("valueFrom must specify an environment variable name")
+	}
+	value, ok := lookup(v.From.Env)
+	if !ok || value == "" {
+		return "", fmt.Errorf("environment variable %s is not set (required by valueFrom)", v.From.Env)
+	}
+	return value, nil
+}
+
+// MarshalJSON writes literals as plain strings and references as objects
+func (v Value) MarshalJSON() ([]byte, error) {
+	if v.From != nil {
+		return json.Marshal(valueRef{ValueFrom: v.From})
+	}
+	return json.Marshal(v.Literal)
+}
+
+// UnmarshalJSON accepts either a plain string or a valueFrom object
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var literal string
+	if err := json.Unmarshal(data, &literal); err == nil {
+		*v = Literal(literal)
+		return nil
+	}
+
+	var ref valueRef
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return fmt.Errorf("value must be a string or a valueFrom object: %w", err)
+	}
+	if ref.ValueFrom == nil {
+		return fmt.Errorf("value must be a string or a valueFrom object")
+	}
+	*v = Value{From: ref.ValueFrom}
+	return nil
+}
+
+// MarshalYAML writes literals as plain strings and references as mappings
+func (v Value) MarshalYAML() (any, error) {
+	if v.From != nil {
+		return valueRef{ValueFrom: v.From}, nil
+	}
+	return v.Literal, nil
+}
+
+// UnmarshalYAML accepts either a scalar or a valueFrom mapping
+func (v *Value) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*v = Literal(node.Value)
+		return nil
+	}
+
+	var ref valueRef
+	if err := node.Decode(&ref); err != nil {
+		return fmt.Errorf("value must be a string or a valueFrom mapping: %w", err)
+	}
+	if ref.ValueFrom == nil {
+		return fmt.Errorf("line %d: value must be a string or a valueFrom mapping", node.Line)
+	}
+	*v = Value{From: ref.ValueFrom}
+	return nil
+}
+
+// field pairs a property name with its value in a Config
+type field struct {
+	name  string
+	value *Value
+}
+
+// fields returns the redactable property fields of the config
+func (c *Config) fields() []field {
+	return []field{
+		{"account", &c.Account},
+		{"project", &c.Project},
+		{"region", &c.Region},
+		{"zone", &c.Zone},
+		{"impersonate_service_account", &c.ImpersonateServiceAccount},
+	}
+}
+
+// EnvVarName returns the environment variable name used for a redacted property
+func EnvVarName(property string) string {
+	return EnvPrefix + strings.ToUpper(property)
+}
+
+// Redact replaces every literal value matching pattern with a reference to the
+// environment variable named by EnvVarName, and returns the redacted property names
+func Redact(c *Config, pattern *regexp.Regexp) []string {
+	var redacted []string
+	for _, f := range c.fields() {
+		if f.value.From != nil || f.value.Literal == "" {
+			continue
+		}
+		if pattern.MatchString(f.value.Literal) {
+			*f.value = FromEnv(EnvVarName(f.name))
+			redacted = append(redacted, f.name)
+		}
+	}
+	return redacted
+}
+
+// Resolve resolves all references of the config using lookup
+// (typically os.LookupEnv)
+func Resolve(c *Config, lookup func(string) (string, bool)) (*Resolved, error) {
+	resolved := &Resolved{Name: c.Name, SyncADC: c.SyncADC, DisableUsageReporting: c.DisableUsageReporting, Note: c.Note}
+	targets := map[string]*string{
+		"account": &resolved.Account,
+		"project": &resolved.Project,
+		"region":  &resolved.Region,
+		"zone":    &resolved.Zone,
+
+		"impersonate_service_account": &resolved.ImpersonateServiceAccount,
+	}
+
+	for _, f := range c.fields() {
+		value, err := f.value.Resolve(lookup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", f.name, err)
+		}
+		*targets[f.name] = value
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,202 @@
+package configfile
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func lookupFrom(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestRedact(t *testing.T) {
+	config := &Config{
+		Name:    "automation",
+		Account: Literal("ci@example.iam.gserviceaccount.com"),
+		Project: Literal("billing-secret-123"),
+		Region:  Literal("us-central1"),
+	}
+
+	redacted := Redact(config, regexp.MustCompile(`secret|registry\.internal`))
+
+	if len(redacted) != 1 || redacted[0] != "project" {
+		t.Fatalf("Redact() = %v, want [project]", redacted)
+	}
+	if config.Project.From == nil || config.Project.From.Env != "GCLOUDCTX_PROJECT" {
+		t.Errorf("project should reference GCLOUDCTX_PROJECT, got %+v", config.Project)
+	}
+	if config.Account.Literal != "ci@example.iam.gserviceaccount.com" {
+		t.Errorf("account should be unchanged, got %+v", config.Account)
+	}
+	if !config.Zone.IsZero() {
+		t.Errorf("empty zone should stay empty, got %+v", config.Zone)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	config := &Config{
+		Name:    "automation",
+		Account: Literal("ci@example.com"),
+		Project: FromEnv("BILLING_PROJECT"),
+	}
+
+	resolved, err := Resolve(config, lookupFrom(map[string]string{"BILLING_PROJECT": "billing-123"}))
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if resolved.Project != "billing-123" || resolved.Account != "ci@example.com" || resolved.Name != "automation" {
+		t.Errorf("Resolve() = %+v", resolved)
+	}
+}
+
+func TestResolveMissingEnv(t *testing.T) {
+	config := &Config{Name: "automation", Project: FromEnv("BILLING_PROJECT")}
+
+	_, err := Resolve(config, lookupFrom(nil))
+	if err == nil {
+		t.Fatal("Resolve() expected error for unset environment variable")
+	}
+	if !strings.Contains(err.Error(), "BILLING_PROJECT") {
+		t.Errorf("error should name the variable, got %q", err.Error())
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	input := `name: automation
+account: ci@example.com
+project:
+  valueFrom:
+    env: BILLING_PROJECT
+`
+	var config Config
+	if err := yaml.Unmarshal([]byte(input), &config); err != nil {
+		t.Fatalf("yaml.Unmarshal failed: %v", err)
+	}
+	if config.Account.Literal != "ci@example.com" {
+		t.Errorf("account = %+v", config.Account)
+	}
+	if config.Project.From == nil || config.Project.From.Env != "BILLING_PROJECT" {
+		t.Errorf("project = %+v", config.Project)
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		t.Fatalf("yaml.Marshal failed: %v", err)
+	}
+	want := `name: automation
+account: ci@example.com
+project:
+    valueFrom:
+        env: BILLING_PROJECT
+`
+	if string(data) != want {
+		t.Errorf("yaml round trip mismatch\ngot:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	input := `{"name":"automation","account":"ci@example.com","project":{"valueFrom":{"env":"BILLING_PROJECT"}}}`
+
+	var config Config
+	if err := json.Unmarshal([]byte(input), &config); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if config.Project.From == nil || config.Project.From.Env != "BILLING_PROJECT" {
+		t.Errorf("project = %+v", config.Project)
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if string(data) != input {
+		t.Errorf("json round trip = %s, want %s", data, input)
+	}
+}
+
+func TestUnmarshalInvalidValue(t *testing.T) {
+	if err := yaml.Unmarshal([]byte("name: x\nproject:\n  other: y\n"), &Config{}); err == nil {
+		t.Error("yaml.Unmarshal expected error for mapping without valueFrom")
+	}
+	if err := json.Unmarshal([]byte(`{"name":"x","project":42}`), &Config{}); err == nil {
+		t.Error("json.Unmarshal expected error for non-string value")
+	}
+}
+
+func TestADCHintsRoundTrip(t *testing.T) {
+	input := `name: automation
+project: billing-123
+impersonate_service_account: deployer@billing-123.iam.gserviceaccount.com
+sync_adc: true
+`
+	var config Config
+	if err := yaml.Unmarshal([]byte(input), &config); err != nil {
+		t.Fatalf("yaml.Unmarshal failed: %v", err)
+	}
+	if !config.SyncADC || config.ImpersonateServiceAccount.Literal != "deployer@billing-123.iam.gserviceaccount.com" {
+		t.Errorf("ADC hints = %v, %+v", config.SyncADC, config.ImpersonateServiceAccount)
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		t.Fatalf("yaml.Marshal failed: %v", err)
+	}
+	if string(data) != input {
+		t.Errorf("yaml round trip mismatch\ngot:\n%s\nwant:\n%s", data, input)
+	}
+
+	jsonData, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	want := `{"name":"automation","project":"billing-123","impersonate_service_account":"deployer@billing-123.iam.gserviceaccount.com","sync_adc":true}`
+	if string(jsonData) != want {
+		t.Errorf("json = %s, want %s", jsonData, want)
+	}
+
+	resolved, err := Resolve(&config, lookupFrom(nil))
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if !resolved.SyncADC || resolved.ImpersonateServiceAccount != "deployer@billing-123.iam.gserviceaccount.com" {
+		t.Errorf("Resolve() = %+v", resolved)
+	}
+}
+
+func TestUnmarshalCompatibility(t *testing.T) {
+	// Files from older versions lack the ADC hints; files from newer versions may carry unknown fields
+	inputs := map[string]string{
+		"yaml without hints": "name: old\nproject: p\n",
+		"yaml unknown field": "name: old\nproject: p\nfuture_field: {nested: true}\n",
+		"json without hints": `{"name":"old","project":"p"}`,
+		"json unknown field": `{"name":"old","project":"p","future_field":[1,2]}`,
+	}
+
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			var config Config
+			var err error
+			if strings.HasPrefix(name, "json") {
+				err = json.Unmarshal([]byte(input), &config)
+			} else {
+				err = yaml.Unmarshal([]byte(input), &config)
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if config.Name != "old" || config.Project.Literal != "p" {
+				t.Errorf("config = %+v", config)
+			}
+			if config.SyncADC || !config.ImpersonateServiceAccount.IsZero() {
+				t.Errorf("ADC hints should default to unset, got %v, %+v", config.SyncADC, config.ImpersonateServiceAccount)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package configfile
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultEnvVarPrefix is the prefix gcloud reads property overrides from
+// (e.g. CLOUDSDK_CORE_PROJECT overrides core/project)
+const DefaultEnvVarPrefix = "CLOUDSDK_"
+
+// EnvStyle selects how environment variable lines are written
+type EnvStyle int
+
+const (
+	// EnvStyleShell writes "export NAME=value" lines quoted for POSIX shells,
+	// suitable for eval "$(gcloudctx export prod --format env)"
+	EnvStyleShell EnvStyle = iota
+	// EnvStyleDotenv writes "NAME=value" lines for .env files
+	EnvStyleDotenv
+)
+
+// envSections maps exported properties to their gcloud section
+// Fields without a section (the ADC hints) are not gcloud properties and are not exported
+var envSections = map[string]string{
+	"account": "core",
+	"project": "core",
+	"region":  "compute",
+	"zone":    "compute",
+}
+
+var (
+	// envPrefixPattern matches prefixes that keep variable names valid identifiers
+	envPrefixPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// envSafeValuePattern matches values that need no quoting in either style
+	envSafeValuePattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+)
+
+// ValidateEnvPrefix checks that prefix yields valid environment variable names
+// An empty prefix is allowed and produces names such as CORE_PROJECT
+func ValidateEnvPrefix(prefix string) error {
+	if prefix != "" && !envPrefixPattern.MatchString(prefix) {
+		return fmt.Errorf("invalid prefix %q: must start with a letter or underscore and contain only letters, digits and underscores", prefix)
+	}
+	return nil
+}
+
+// EnvVarLines returns one environment variable assignment per set property,
+// named prefix + SECTION_PROPERTY (e.g. CLOUDSDK_COMPUTE_REGION)
+// Empty properties are omitted; references are written as variable expansions
+func EnvVarLines(c *Config, prefix string, style EnvStyle) ([]string, error) {
+	if err := ValidateEnvPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, f := range c.fields() {
+		section, ok := envSections[f.name]
+		if !ok || f.value.IsZero() {
+			continue
+		}
+
+		name := prefix + strings.ToUpper(section+"_"+f.name)
+		value, err := envValue(*f.value, style)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", f.name, err)
+		}
+
+		if style == EnvStyleShell {
+			lines = append(lines, fmt.Sprintf("export %s=%s", name, value))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s=%s", name, value))
+		}
+	}
+	return lines, nil
+}
+
+// envValue formats a value for the right-hand side of an assignment
+func envValue(v Value, style EnvStyle) (string, error) {
+	if v.From != nil {
+		if !envPrefixPattern.MatchString(v.From.Env) {
+			return "", fmt.Errorf("invalid environment variable name %q in valueFrom", v.From.Env)
+		}
+		if style == EnvStyleShell {
+			return `"${` + v.From.Env + `}"`, nil
+		}
+		return "${" + v.From.Env + "}", nil
+	}
+
+	if envSafeValuePattern.MatchString(v.Literal) {
+		return v.Literal, nil
+	}
+	if style == EnvStyleShell {
+		return shellQuote(v.Literal), nil
+	}
+	return dotenvQuote(v.Literal), nil
+}
+
+// shellQuote wraps s in single quotes, which disable every expansion in POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// dotenvQuote wraps s in double quotes, escaping what dotenv loaders interpret
+func dotenvQuote(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`)
+	return `"` + replacer.Replace(s) + `"`
+}
@@ -0,0 +1,148 @@
+package configfile
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvVarLines(t *testing.T) {
+	config := &Config{
+		Name:    "prod",
+		Account: Literal("me@corp.com"),
+		Project: Literal("my-proj"),
+		Zone:    Literal("us-central1-a"),
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		style  EnvStyle
+		want   []string
+	}{
+		{
+			name:   "shell",
+			prefix: DefaultEnvVarPrefix,
+			style:  EnvStyleShell,
+			want: []string{
+				"export CLOUDSDK_CORE_ACCOUNT=me@corp.com",
+				"export CLOUDSDK_CORE_PROJECT=my-proj",
+				"export CLOUDSDK_COMPUTE_ZONE=us-central1-a",
+			},
+		},
+		{
+			name:   "dotenv with prefix",
+			prefix: "TF_VAR_",
+			style:  EnvStyleDotenv,
+			want: []string{
+				"TF_VAR_CORE_ACCOUNT=me@corp.com",
+				"TF_VAR_CORE_PROJECT=my-proj",
+				"TF_VAR_COMPUTE_ZONE=us-central1-a",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EnvVarLines(config, tt.prefix, tt.style)
+			if err != nil {
+				t.Fatalf("EnvVarLines failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EnvVarLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvVarLinesQuoting(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  Value
+		shell  string
+		dotenv string
+	}{
+		{"space", Literal("my proj"), `'my proj'`, `"my proj"`},
+		{"single quote", Literal("it's"), `'it'\''s'`, `"it's"`},
+		{"double quote", Literal(`say "hi"`), `'say "hi"'`, `"say \"hi\""`},
+		{"dollar", Literal("$HOME"), `'$HOME'`, `"\$HOME"`},
+		{"backtick", Literal("`id`"), "'`id`'", "\"`id`\""},
+		{"backslash", Literal(`a\b`), `'a\b'`, `"a\\b"`},
+		{"newline", Literal("a\nb"), "'a\nb'", `"a\nb"`},
+		{"semicolon", Literal("a;rm -rf"), `'a;rm -rf'`, `"a;rm -rf"`},
+		{"reference", FromEnv("BILLING_PROJECT"), `"${BILLING_PROJECT}"`, `${BILLING_PROJECT}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Name: "prod", Project: tt.value}
+
+			shell, err := EnvVarLines(config, DefaultEnvVarPrefix, EnvStyleShell)
+			if err != nil {
+				t.Fatalf("EnvVarLines failed: %v", err)
+			}
+			if want := "export CLOUDSDK_CORE_PROJECT=" + tt.shell; len(shell) != 1 || shell[0] != want {
+				t.Errorf("shell = %q, want %q", shell, want)
+			}
+
+			dotenv, err := EnvVarLines(config, DefaultEnvVarPrefix, EnvStyleDotenv)
+			if err != nil {
+				t.Fatalf("EnvVarLines failed: %v", err)
+			}
+			if want := "CLOUDSDK_CORE_PROJECT=" + tt.dotenv; len(dotenv) != 1 || dotenv[0] != want {
+				t.Errorf("dotenv = %q, want %q", dotenv, want)
+			}
+		})
+	}
+}
+
+func TestEnvVarLinesShellRoundTrip(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	values := []string{"my proj", "it's", `say "hi"`, "$HOME", "`id`", `a\b`, "a\nb", "a;echo pwned"}
+	for _, value := range values {
+		config := &Config{Name: "prod", Project: Literal(value)}
+		lines, err := EnvVarLines(config, DefaultEnvVarPrefix, EnvStyleShell)
+		if err != nil {
+			t.Fatalf("EnvVarLines failed: %v", err)
+		}
+
+		script := strings.Join(lines, "\n") + "\nprintf '%s' \"$CLOUDSDK_CORE_PROJECT\""
+		out, err := exec.Command(sh, "-c", script).Output()
+		if err != nil {
+			t.Fatalf("sh failed for %q: %v", value, err)
+		}
+		if string(out) != value {
+			t.Errorf("round trip of %q = %q", value, out)
+		}
+	}
+}
+
+func TestEnvVarLinesOmitsEmpty(t *testing.T) {
+	// ADC hints are not gcloud properties and are never exported
+	config := &Config{Name: "empty", ImpersonateServiceAccount: Literal("sa@p.iam.gserviceaccount.com"), SyncADC: true}
+	lines, err := EnvVarLines(config, DefaultEnvVarPrefix, EnvStyleShell)
+	if err != nil {
+		t.Fatalf("EnvVarLines failed: %v", err)
+	}
+	if len(lines) != 0 {
+		t.Errorf("EnvVarLines() = %q, want none", lines)
+	}
+}
+
+func TestValidateEnvPrefix(t *testing.T) {
+	for _, prefix := range []string{"", "CLOUDSDK_", "TF_VAR_", "_x"} {
+		if err := ValidateEnvPrefix(prefix); err != nil {
+			t.Errorf("ValidateEnvPrefix(%q) = %v, want nil", prefix, err)
+		}
+	}
+	for _, prefix := range []string{"1X_", "MY-PREFIX", "A B", "$(id)"} {
+		if err := ValidateEnvPrefix(prefix); err == nil {
+			t.Errorf("ValidateEnvPrefix(%q) = nil, want error", prefix)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Package editor opens files in the user's text editor.
+// The editor is taken from $VISUAL, then $EDITOR, as most command-line tools
+// do; either may carry arguments, such as "code --wait".
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Environment variables naming the editor, in order of preference
+const (
+	EnvVisual = "VISUAL"
+	EnvEditor = "EDITOR"
+)
+
+// fallbackEditor returns the editor used when neither variable is set
+func fallbackEditor(goos string) string {
+	if goos == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// Command returns the editor command and its arguments
+func Command() []string {
+	return command(runtime.GOOS, os.Getenv)
+}
+
+func command(goos string, getenv func(string) string) []string {
+	for _, name := range []string{EnvVisual, EnvEditor} {
+		if fields := strings.Fields(getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{fallbackEditor(goos)}
+}
+
+// Open edits path in the user's editor and waits for it to exit
+func Open(path string) error {
+	args := append(Command(), path)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", args[0], err)
+	}
+	return nil
+}
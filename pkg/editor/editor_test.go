@@ -0,0 +1,74 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want []string
+	}{
+		{name: "visual wins", goos: "linux", env: map[string]string{"VISUAL": "nvim", "EDITOR": "nano"}, want: []string{"nvim"}},
+		{name: "editor with args", goos: "linux", env: map[string]string{"EDITOR": "code --wait"}, want: []string{"code", "--wait"}},
+		{name: "blank visual", goos: "linux", env: map[string]string{"VISUAL": "  ", "EDITOR": "nano"}, want: []string{"nano"}},
+		{name: "fallback", goos: "darwin", want: []string{"vi"}},
+		{name: "windows fallback", goos: "windows", want: []string{"notepad"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			if got := command(tt.goos, getenv); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("command() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake editor is a shell script")
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "fake-editor")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$1\" >> \"$2\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config_prod")
+	if err := os.WriteFile(path, []byte("[core]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The extra argument checks that arguments in $VISUAL are passed through
+	t.Setenv(EnvVisual, script+" project=edited")
+	if err := Open(path); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[core]\nproject=edited\n"; string(data) != want {
+		t.Errorf("file after editing = %q, want %q", data, want)
+	}
+}
+
+func TestOpenFailingEditor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake editor is a shell script")
+	}
+
+	t.Setenv(EnvVisual, "false")
+	if err := Open(filepath.Join(t.TempDir(), "config_prod")); err == nil {
+		t.Error("Open succeeded, want the editor's exit status as an error")
+	}
+}
@@ -0,0 +1,47 @@
+package gcloud
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/apitest"
+)
+
+// stableAPI lists the symbols covered by the compatibility section of the package
+// doc; the rest of the package serves the CLI and is deliberately left out
+var stableAPI = apitest.API{
+	Funcs: map[string]any{
+		"NewClient":      NewClient,
+		"WithCacheTTL":   WithCacheTTL,
+		"WithConfigRoot": WithConfigRoot,
+		"WithRunner":     WithRunner,
+	},
+	Types: map[string]reflect.Type{
+		"Client":            reflect.TypeFor[Client](),
+		"ClientOption":      reflect.TypeFor[ClientOption](),
+		"CommandError":      reflect.TypeFor[CommandError](),
+		"ComputeProperties": reflect.TypeFor[ComputeProperties](),
+		"Configuration":     reflect.TypeFor[Configuration](),
+		"CoreProperties":    reflect.TypeFor[CoreProperties](),
+		"Properties":        reflect.TypeFor[Properties](),
+		"PropertyDiff":      reflect.TypeFor[PropertyDiff](),
+		"PropertySetting":   reflect.TypeFor[PropertySetting](),
+		"Runner":            reflect.TypeFor[Runner](),
+	},
+	Values: map[string]any{
+		"ErrActiveConfiguration":   ErrActiveConfiguration,
+		"ErrConfigurationExists":   ErrConfigurationExists,
+		"ErrConfigurationNotFound": ErrConfigurationNotFound,
+		"ErrInvalidPropertyValue":  ErrInvalidPropertyValue,
+		"ErrNetworkUnreachable":    ErrNetworkUnreachable,
+		"ErrNoActiveConfiguration": ErrNoActiveConfiguration,
+		"ErrNoConfigurations":      ErrNoConfigurations,
+		"ErrPermissionDenied":      ErrPermissionDenied,
+		"ErrReauthRequired":        ErrReauthRequired,
+		"ErrServiceUnavailable":    ErrServiceUnavailable,
+	},
+}
+
+func TestAPIStability(t *testing.T) {
+	apitest.Check(t, stableAPI)
+}
@@ -0,0 +1,85 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CredentialedAccount is an account gcloud holds credentials for, as returned
+// by gcloud auth list
+type CredentialedAccount struct {
+	Account string `json:"account"`
+	// Status is "ACTIVE" for the account of the active configuration
+	Status string `json:"status,omitempty"`
+}
+
+// ListCredentialedAccounts returns the accounts gcloud holds credentials for
+func ListCredentialedAccounts() ([]CredentialedAccount, error) {
+	output, err := RunGcloudCommand("auth", "list", "--format=json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentialed accounts: %w", err)
+	}
+
+	return parseCredentialedAccounts(output)
+}
+
+// parseCredentialedAccounts parses the JSON output of gcloud auth list
+func parseCredentialedAccounts(output string) ([]CredentialedAccount, error) {
+	var accounts []CredentialedAccount
+	if err := json.Unmarshal([]byte(output), &accounts); err != nil {
+		return nil, fmt.Errorf("failed to parse credentialed accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// AccountUsage is an account with the configurations that use it
+type AccountUsage struct {
+	Account string
+	// Credentialed reports whether gcloud holds a credential for the account
+	Credentialed bool
+	// Configurations are the names of the configurations whose core/account
+	// is the account, sorted
+	Configurations []string
+}
+
+// Orphaned reports whether nothing uses the credential, or the configurations
+// using the account have no credential to run with
+func (u AccountUsage) Orphaned() bool {
+	return !u.Credentialed || len(u.Configurations) == 0
+}
+
+// AccountUsages cross-references credentialed accounts with the accounts
+// configurations use, sorted by account
+// Accounts configurations use without a credential are included, uncredentialed
+func AccountUsages(accounts []CredentialedAccount, configs []Configuration) []AccountUsage {
+	byAccount := map[string]*AccountUsage{}
+	usage := func(account string) *AccountUsage {
+		key := strings.ToLower(account)
+		if byAccount[key] == nil {
+			byAccount[key] = &AccountUsage{Account: account}
+		}
+		return byAccount[key]
+	}
+
+	for _, account := range accounts {
+		usage(account.Account).Credentialed = true
+	}
+	for _, config := range configs {
+		if account := config.Properties.Core.Account; account != "" {
+			u := usage(account)
+			u.Configurations = append(u.Configurations, config.Name)
+		}
+	}
+
+	usages := make([]AccountUsage, 0, len(byAccount))
+	for _, u := range byAccount {
+		slices.Sort(u.Configurations)
+		usages = append(usages, *u)
+	}
+	slices.SortFunc(usages, func(a, b AccountUsage) int {
+		return strings.Compare(strings.ToLower(a.Account), strings.ToLower(b.Account))
+	})
+	return usages
+}
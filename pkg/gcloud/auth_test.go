@@ -0,0 +1,66 @@
+package gcloud
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCredentialedAccounts(t *testing.T) {
+	output := `[
+  {"account": "dev@example.com", "status": "ACTIVE"},
+  {"account": "ci@project.iam.gserviceaccount.com", "status": ""}
+]`
+
+	got, err := parseCredentialedAccounts(output)
+	if err != nil {
+		t.Fatalf("parseCredentialedAccounts() error = %v", err)
+	}
+	want := []CredentialedAccount{
+		{Account: "dev@example.com", Status: "ACTIVE"},
+		{Account: "ci@project.iam.gserviceaccount.com"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCredentialedAccounts() = %+v, want %+v", got, want)
+	}
+
+	if _, err := parseCredentialedAccounts("No credentialed accounts."); err == nil {
+		t.Error("parseCredentialedAccounts() accepted non-JSON output")
+	}
+}
+
+func TestAccountUsages(t *testing.T) {
+	accounts := []CredentialedAccount{
+		{Account: "ops@example.com"},
+		{Account: "old@example.com"},
+		{Account: "Dev@example.com", Status: "ACTIVE"},
+	}
+	configs := []Configuration{
+		{Name: "staging", Properties: Properties{Core: CoreProperties{Account: "dev@example.com"}}},
+		{Name: "dev", IsActive: true, Properties: Properties{Core: CoreProperties{Account: "dev@example.com"}}},
+		{Name: "prod", Properties: Properties{Core: CoreProperties{Account: "ops@example.com"}}},
+		{Name: "ci", Properties: Properties{Core: CoreProperties{Account: "ci@example.com"}}},
+		{Name: "empty"},
+	}
+
+	got := AccountUsages(accounts, configs)
+
+	want := []AccountUsage{
+		{Account: "ci@example.com", Configurations: []string{"ci"}},
+		{Account: "Dev@example.com", Credentialed: true, Configurations: []string{"dev", "staging"}},
+		{Account: "old@example.com", Credentialed: true},
+		{Account: "ops@example.com", Credentialed: true, Configurations: []string{"prod"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AccountUsages() = %+v, want %+v", got, want)
+	}
+
+	var orphans []string
+	for _, u := range got {
+		if u.Orphaned() {
+			orphans = append(orphans, u.Account)
+		}
+	}
+	if want := []string{"ci@example.com", "old@example.com"}; !reflect.DeepEqual(orphans, want) {
+		t.Errorf("orphaned accounts = %q, want %q", orphans, want)
+	}
+}
@@ -0,0 +1,23 @@
+package gcloud
+
+import (
+	"os"
+	"runtime"
+)
+
+// Headless reports whether gcloud cannot open a browser to log in on this
+// machine, and why: the session is over SSH, or Linux has no display
+func Headless() (string, bool) {
+	return headless(runtime.GOOS, os.Getenv)
+}
+
+// headless is Headless for the given GOOS and environment
+func headless(goos string, getenv func(string) string) (string, bool) {
+	if getenv("SSH_CONNECTION") != "" || getenv("SSH_TTY") != "" {
+		return "this is an SSH session", true
+	}
+	if goos == "linux" && getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == "" {
+		return "no display is available", true
+	}
+	return "", false
+}
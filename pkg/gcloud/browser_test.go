@@ -0,0 +1,28 @@
+package gcloud
+
+import "testing"
+
+func TestHeadless(t *testing.T) {
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want bool
+	}{
+		{"linux desktop", "linux", map[string]string{"DISPLAY": ":0"}, false},
+		{"linux wayland", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, false},
+		{"linux without display", "linux", nil, true},
+		{"ssh with forwarded display", "linux", map[string]string{"DISPLAY": "localhost:10.0", "SSH_CONNECTION": "10.0.0.1 50000 10.0.0.2 22"}, true},
+		{"ssh tty", "darwin", map[string]string{"SSH_TTY": "/dev/ttys001"}, true},
+		{"macos", "darwin", nil, false},
+		{"windows", "windows", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, got := headless(tt.goos, func(key string) string { return tt.env[key] })
+			if got != tt.want || (reason != "") != tt.want {
+				t.Errorf("headless() = %q, %v, want %v", reason, got, tt.want)
+			}
+		})
+	}
+}
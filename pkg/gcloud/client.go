@@ -0,0 +1,440 @@
+package gcloud
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Client manages the configurations of one gcloud configuration directory
+// Its methods never print; failures are returned as errors that can be
+// matched with errors.Is (ErrConfigurationNotFound, ErrConfigurationExists,
+// ErrActiveConfiguration, ErrNoActiveConfiguration, ErrReadOnly and the kinds
+// of gcloud failures listed in errors.go). A Client is safe for concurrent use.
+type Client struct {
+	// runner is nil for the package-level functions, which follow SetRunner
+	runner     Runner
+	configRoot string
+	cacheTTL   time.Duration
+
+	// mu guards the configuration list cache
+	mu       sync.Mutex
+	cache    []Configuration
+	cachedAt time.Time
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithConfigRoot points the client at a gcloud configuration directory instead
+// of $CLOUDSDK_CONFIG or the default; it only affects the default runner
+func WithConfigRoot(dir string) ClientOption {
+	return func(c *Client) {
+		c.configRoot = dir
+	}
+}
+
+// WithRunner runs gcloud commands with r instead of the gcloud binary in PATH
+func WithRunner(r Runner) ClientOption {
+	return func(c *Client) {
+		c.runner = r
+	}
+}
+
+// WithCacheTTL reuses a configuration list for up to ttl; changes made through
+// the client drop it at once. Zero, the default, lists afresh on every call
+func WithCacheTTL(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.cacheTTL = ttl
+	}
+}
+
+// NewClient returns a client with the given options
+// Without options it runs the gcloud binary in PATH on gcloud's usual
+// configuration directory and caches nothing
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.runner == nil {
+		c.runner = execRunner{configRoot: c.configRoot}
+	}
+	return c
+}
+
+// defaultClient backs the package-level functions, running commands through
+// the runner set with SetRunner on the directory set with SetConfigRoot
+var defaultClient = &Client{}
+
+// List returns all configurations, sorted by name
+// In offline mode they are read from gcloud's files instead of running gcloud
+func (c *Client) List() ([]Configuration, error) {
+	if Offline() {
+		dir, err := c.configDir()
+		if err != nil {
+			return nil, err
+		}
+		return readConfigurations(dir)
+	}
+
+	if c.cacheTTL > 0 {
+		c.mu.Lock()
+		if c.cache != nil && time.Since(c.cachedAt) < c.cacheTTL {
+			configs := slices.Clone(c.cache)
+			c.mu.Unlock()
+			return configs, nil
+		}
+		c.mu.Unlock()
+	}
+
+	output, err := c.run("config", "configurations", "list", "--format=json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configurations: %w", err)
+	}
+	configs, err := ParseConfigurations(output)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cacheTTL > 0 {
+		c.mu.Lock()
+		c.cache = slices.Clone(configs)
+		c.cachedAt = time.Now()
+		c.mu.Unlock()
+	}
+	return configs, nil
+}
+
+// Get returns the named configuration
+func (c *Client) Get(name string) (*Configuration, error) {
+	configs, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	if config, found := findConfigurationByName(configs, name); found {
+		return config, nil
+	}
+	return nil, kindErrorf(ErrConfigurationNotFound, "configuration %q not found", name)
+}
+
+// Exists reports whether the named configuration exists; a failure to list
+// the configurations is returned rather than reported as not existing
+func (c *Client) Exists(name string) (bool, error) {
+	configs, err := c.List()
+	if err != nil {
+		return false, fmt.Errorf("failed to check whether configuration %q exists: %w", name, err)
+	}
+	return configurationExistsInList(configs, name), nil
+}
+
+// Active returns the active configuration
+func (c *Client) Active() (*Configuration, error) {
+	configs, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	return getActiveConfigurationFromList(configs)
+}
+
+// Activate makes the named configuration the active one
+func (c *Client) Activate(name string) error {
+	err := c.runQuiet("config", "configurations", "activate", name)
+	c.invalidate()
+	if err != nil {
+		return fmt.Errorf("failed to activate configuration %q: %w", name, err)
+	}
+	return nil
+}
+
+// Create creates an empty configuration without activating it
+func (c *Client) Create(name string) error {
+	if exists, err := c.Exists(name); err != nil {
+		return err
+	} else if exists {
+		return kindErrorf(ErrConfigurationExists, "configuration %q already exists", name)
+	}
+
+	err := c.runQuiet("config", "configurations", "create", name, "--no-activate")
+	c.invalidate()
+	if err != nil {
+		return fmt.Errorf("failed to create configuration %q: %w", name, err)
+	}
+	return nil
+}
+
+// Delete deletes a configuration; the active configuration cannot be deleted
+func (c *Client) Delete(name string) error {
+	if exists, err := c.Exists(name); err != nil {
+		return err
+	} else if !exists {
+		return kindErrorf(ErrConfigurationNotFound, "configuration %q does not exist", name)
+	}
+
+	activeConfig, err := c.Active()
+	if err != nil {
+		return err
+	}
+	if activeConfig.Name == name {
+		return kindErrorf(ErrActiveConfiguration, "cannot delete active configuration %q", name)
+	}
+
+	err = c.runQuiet("config", "configurations", "delete", name, "--quiet")
+	c.invalidate()
+	if err != nil {
+		return fmt.Errorf("failed to delete configuration %q: %w", name, err)
+	}
+	return nil
+}
+
+// Clone creates targetName with the account, project, region and zone of sourceName
+func (c *Client) Clone(sourceName, targetName string) error {
+	if exists, err := c.Exists(sourceName); err != nil {
+		return err
+	} else if !exists {
+		return kindErrorf(ErrConfigurationNotFound, "source configuration %q does not exist", sourceName)
+	}
+	if exists, err := c.Exists(targetName); err != nil {
+		return err
+	} else if exists {
+		return kindErrorf(ErrConfigurationExists, "target configuration %q already exists", targetName)
+	}
+
+	sourceConfig, err := c.Get(sourceName)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Create(targetName); err != nil {
+		return err
+	}
+
+	if err := c.copyConfigProperties(sourceConfig, targetName); err != nil {
+		// Clean up on failure
+		if cleanupErr := c.cleanupConfiguration(targetName); cleanupErr != nil {
+			return fmt.Errorf("failed to copy properties: %w (cleanup also failed: %v)", err, cleanupErr)
+		}
+		return fmt.Errorf("failed to copy properties: %w", err)
+	}
+
+	return nil
+}
+
+// Rename renames a configuration, keeping it active if it was
+func (c *Client) Rename(oldName, newName string) error {
+	if exists, err := c.Exists(oldName); err != nil {
+		return err
+	} else if !exists {
+		return kindErrorf(ErrConfigurationNotFound, "configuration %q does not exist", oldName)
+	}
+	if exists, err := c.Exists(newName); err != nil {
+		return err
+	} else if exists {
+		return kindErrorf(ErrConfigurationExists, "configuration %q already exists", newName)
+	}
+
+	// Get the old configuration details before starting
+	oldConfig, err := c.Get(oldName)
+	if err != nil {
+		return err
+	}
+
+	// gcloud doesn't have a rename command, so we need to create a new one and
+	// copy the properties; a failure at any step puts both configurations and
+	// the active one back as they were
+	return c.withSnapshot([]string{oldName, newName}, func() error {
+		if err := c.Create(newName); err != nil {
+			return err
+		}
+
+		if err := c.copyConfigProperties(oldConfig, newName); err != nil {
+			return fmt.Errorf("failed to copy properties: %w", err)
+		}
+
+		// If old config was active, switch to new one
+		if oldConfig.IsActive {
+			if err := c.Activate(newName); err != nil {
+				return fmt.Errorf("failed to activate configuration: %w", err)
+			}
+		}
+
+		if err := c.Delete(oldName); err != nil {
+			return fmt.Errorf("failed to delete old configuration %q: %w", oldName, err)
+		}
+		return nil
+	})
+}
+
+// Export returns every property set on the named configuration, sorted by
+// property, in the form Import accepts
+func (c *Client) Export(name string) ([]PropertySetting, error) {
+	config, err := c.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := FlattenProperties(config)
+	settings := make([]PropertySetting, 0, len(properties))
+	for _, property := range slices.Sorted(maps.Keys(properties)) {
+		settings = append(settings, PropertySetting{Property: property, Value: properties[property]})
+	}
+	return settings, nil
+}
+
+// Import creates a configuration with the given properties
+// The configuration is created, populated, and verified in place; it is removed again on failure
+func (c *Client) Import(name string, settings []PropertySetting) error {
+	if err := c.Create(name); err != nil {
+		return err
+	}
+
+	if err := c.populateConfiguration(name, settings); err != nil {
+		if cleanupErr := c.cleanupConfiguration(name); cleanupErr != nil {
+			return fmt.Errorf("%w (cleanup also failed: %v)", err, cleanupErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Overwrite replaces the properties of an existing configuration with the given ones
+// Properties the settings leave out are unset; if any step fails, the
+// configuration is restored exactly as it was (see WithSnapshot)
+func (c *Client) Overwrite(name string, settings []PropertySetting) error {
+	existing, err := c.Get(name)
+	if err != nil {
+		return err
+	}
+
+	keep := map[string]bool{}
+	for _, setting := range settings {
+		keep[setting.Property] = true
+	}
+	var unset []string
+	for _, property := range slices.Sorted(maps.Keys(FlattenProperties(existing))) {
+		if !keep[property] {
+			unset = append(unset, property)
+		}
+	}
+
+	return c.WithSnapshot(name, func() error {
+		if err := c.UnsetProperties(name, unset); err != nil {
+			return err
+		}
+		return c.populateConfiguration(name, settings)
+	})
+}
+
+// SetProperties applies property settings to a configuration in order
+func (c *Client) SetProperties(configName string, settings []PropertySetting) error {
+	defer c.invalidate()
+	for _, setting := range settings {
+		if err := c.runQuiet("config", "set", setting.Property, setting.Value, "--configuration", configName); err != nil {
+			return fmt.Errorf("failed to set %s: %w", setting.Property, err)
+		}
+	}
+	return nil
+}
+
+// UnsetProperties removes properties from a configuration in order
+func (c *Client) UnsetProperties(configName string, properties []string) error {
+	defer c.invalidate()
+	for _, property := range properties {
+		if err := c.runQuiet("config", "unset", property, "--configuration", configName); err != nil {
+			return fmt.Errorf("failed to unset %s: %w", property, err)
+		}
+	}
+	return nil
+}
+
+// VerifyProperties checks that every setting is present on the named configuration
+func (c *Client) VerifyProperties(configName string, settings []PropertySetting) error {
+	config, err := c.Get(configName)
+	if err != nil {
+		return err
+	}
+
+	applied := FlattenProperties(config)
+	for _, setting := range settings {
+		if got := applied[setting.Property]; got != setting.Value {
+			return fmt.Errorf("property %s of %q is %q after import, want %q", setting.Property, configName, got, setting.Value)
+		}
+	}
+	return nil
+}
+
+// populateConfiguration sets and verifies the properties of a configuration
+func (c *Client) populateConfiguration(name string, settings []PropertySetting) error {
+	if err := c.SetProperties(name, settings); err != nil {
+		return err
+	}
+	return c.VerifyProperties(name, settings)
+}
+
+// copyConfigProperties copies the account, project, region and zone of source to targetName
+func (c *Client) copyConfigProperties(source *Configuration, targetName string) error {
+	var settings []PropertySetting
+	for _, setting := range []PropertySetting{
+		{Property: "account", Value: source.Properties.Core.Account},
+		{Property: "project", Value: source.Properties.Core.Project},
+		{Property: "compute/region", Value: source.Properties.Compute.Region},
+		{Property: "compute/zone", Value: source.Properties.Compute.Zone},
+	} {
+		if setting.Value != "" {
+			settings = append(settings, setting)
+		}
+	}
+	return c.SetProperties(targetName, settings)
+}
+
+// cleanupConfiguration attempts to delete a configuration and returns any error encountered
+func (c *Client) cleanupConfiguration(name string) error {
+	if err := c.Delete(name); err != nil {
+		return fmt.Errorf("failed to cleanup configuration %q: %w", name, err)
+	}
+	return nil
+}
+
+// run executes a gcloud command through the client's runner
+// In read-only mode, commands that change gcloud's configuration are refused;
+// read-only commands failing transiently are retried (see SetRetries)
+func (c *Client) run(args ...string) (string, error) {
+	if err := checkCommand(args); err != nil {
+		return "", err
+	}
+
+	var output string
+	err := withRetries(args, func() (err error) {
+		output, err = c.commandRunner().Run(args...)
+		return err
+	})
+	return output, err
+}
+
+// runQuiet is run for commands whose output is only needed on failure
+func (c *Client) runQuiet(args ...string) error {
+	if err := checkCommand(args); err != nil {
+		return err
+	}
+	return withRetries(args, func() error {
+		return c.commandRunner().RunQuiet(args...)
+	})
+}
+
+// commandRunner returns the runner of the client, or the package runner for defaultClient
+func (c *Client) commandRunner() Runner {
+	if c.runner != nil {
+		return c.runner
+	}
+	return runner()
+}
+
+// invalidate drops the cached configuration list
+func (c *Client) invalidate() {
+	c.mu.Lock()
+	c.cache = nil
+	c.mu.Unlock()
+}
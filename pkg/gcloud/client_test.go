@@ -0,0 +1,213 @@
+package gcloud
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client on a fakeGcloud holding dev (active) and prod
+// The package runner fails the test, so nothing may bypass the client's runner
+func newTestClient(t *testing.T, opts ...ClientOption) (*Client, *fakeGcloud) {
+	t.Helper()
+	fake := &fakeGcloud{configs: map[string]map[string]string{}}
+	fake.add("dev", map[string]string{"core/project": "dev-project"})
+	fake.add("prod", map[string]string{"core/project": "prod-project", "core/account": "ops@example.com", "compute/region": "europe-west1"})
+	fake.active = "dev"
+
+	t.Cleanup(SetRunner(&fakeRunner{}))
+	return NewClient(append([]ClientOption{WithRunner(fake)}, opts...)...), fake
+}
+
+func TestClientConfigurations(t *testing.T) {
+	c, fake := newTestClient(t)
+
+	if err := c.Clone("prod", "prod-copy"); err != nil {
+		t.Fatalf("Clone() error = %v", err)
+	}
+	if err := c.Rename("prod-copy", "staging"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	settings, err := c.Export("staging")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	want := []PropertySetting{
+		{Property: "compute/region", Value: "europe-west1"},
+		{Property: "core/account", Value: "ops@example.com"},
+		{Property: "core/project", Value: "prod-project"},
+	}
+	if !slices.Equal(settings, want) {
+		t.Errorf("Export() = %v, want %v", settings, want)
+	}
+
+	if err := c.Import("qa", settings); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if err := c.Activate("qa"); err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+	if err := c.Delete("staging"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	active, err := c.Active()
+	if err != nil || active.Name != "qa" || active.Properties.Compute.Region != "europe-west1" {
+		t.Errorf("Active() = %+v, %v; want qa with the imported properties", active, err)
+	}
+	if !slices.Equal(fake.order, []string{"dev", "prod", "qa"}) {
+		t.Errorf("configurations = %v, want dev, prod and qa", fake.order)
+	}
+	// New configurations are never activated as a side effect
+	if slices.ContainsFunc(fake.calls, func(call string) bool {
+		return strings.Contains(call, "configurations create") && !strings.HasSuffix(call, "--no-activate")
+	}) {
+		t.Errorf("a configuration was created without --no-activate: %q", fake.calls)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"create existing", c.Create("prod"), ErrConfigurationExists},
+		{"delete missing", c.Delete("missing"), ErrConfigurationNotFound},
+		{"delete active", c.Delete("dev"), ErrActiveConfiguration},
+		{"clone missing", c.Clone("missing", "copy"), ErrConfigurationNotFound},
+		{"rename onto existing", c.Rename("dev", "prod"), ErrConfigurationExists},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, tt.err, tt.want)
+		}
+	}
+
+	if _, err := c.Get("missing"); !errors.Is(err, ErrConfigurationNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrConfigurationNotFound", err)
+	}
+	if err := c.Create("prod"); err.Error() != `configuration "prod" already exists` {
+		t.Errorf("Create(prod) error = %q, want the configuration named", err)
+	}
+}
+
+func TestClientListFailure(t *testing.T) {
+	c := NewClient(WithRunner(&fakeRunner{}))
+
+	if exists, err := c.Exists("prod"); err == nil || exists {
+		t.Errorf("Exists() = %v, %v; want the list failure", exists, err)
+	}
+	for name, err := range map[string]error{
+		"Create": c.Create("prod"),
+		"Delete": c.Delete("prod"),
+		"Clone":  c.Clone("prod", "copy"),
+		"Rename": c.Rename("prod", "staging"),
+	} {
+		if err == nil || errors.Is(err, ErrConfigurationNotFound) || errors.Is(err, ErrConfigurationExists) {
+			t.Errorf("%s() error = %v, want the list failure rather than a verdict", name, err)
+		} else if !strings.Contains(err.Error(), "failed to list configurations") {
+			t.Errorf("%s() error = %q, want the list failure", name, err)
+		}
+	}
+}
+
+func TestClientNoActiveConfiguration(t *testing.T) {
+	c, fake := newTestClient(t)
+	fake.active = ""
+
+	if _, err := c.Active(); !errors.Is(err, ErrNoActiveConfiguration) {
+		t.Errorf("Active() error = %v, want ErrNoActiveConfiguration", err)
+	}
+}
+
+func TestClientNoConfigurations(t *testing.T) {
+	c := NewClient(WithRunner(&fakeGcloud{configs: map[string]map[string]string{}}))
+
+	_, err := c.Active()
+	if !errors.Is(err, ErrNoConfigurations) || !errors.Is(err, ErrNoActiveConfiguration) {
+		t.Errorf("Active() error = %v, want ErrNoConfigurations matching ErrNoActiveConfiguration", err)
+	}
+}
+
+func TestClientCacheTTL(t *testing.T) {
+	c, fake := newTestClient(t, WithCacheTTL(time.Minute))
+	countLists := func() int {
+		return len(slices.DeleteFunc(slices.Clone(fake.calls), func(call string) bool {
+			return !strings.HasPrefix(call, "config configurations list")
+		}))
+	}
+
+	for range 3 {
+		if _, err := c.List(); err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+	}
+	if n := countLists(); n != 1 {
+		t.Errorf("3 List() calls listed %d times, want once", n)
+	}
+
+	if err := c.Activate("prod"); err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+	if active, err := c.Active(); err != nil || active.Name != "prod" {
+		t.Errorf("Active() after Activate = %+v, %v; want prod", active, err)
+	}
+	if n := countLists(); n != 2 {
+		t.Errorf("listed %d times, want the cache dropped after Activate", n)
+	}
+}
+
+func TestClientPrintsNothing(t *testing.T) {
+	c, fake := newTestClient(t)
+	fake.failSet = "compute/zone"
+
+	stdout, stderr := captureFile(t), captureFile(t)
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	_, _ = c.List()
+	_ = c.Activate("prod")
+	_ = c.Import("qa", []PropertySetting{{Property: "compute/zone", Value: "nowhere"}})
+	_ = c.Delete("missing")
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+
+	for _, f := range []*os.File{stdout, stderr} {
+		info, err := f.Stat()
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", f.Name(), err)
+		}
+		if info.Size() != 0 {
+			t.Errorf("%s got %d bytes, want nothing printed", f.Name(), info.Size())
+		}
+	}
+}
+
+func TestWithConfigRoot(t *testing.T) {
+	dir := t.TempDir()
+
+	c := NewClient(WithConfigRoot(dir))
+
+	r, ok := c.runner.(execRunner)
+	if !ok || r.configRoot != dir {
+		t.Fatalf("runner = %#v, want the exec runner on %s", c.runner, dir)
+	}
+	if env := commandEnv(r.configRoot); !slices.Contains(env, EnvConfigDir+"="+dir) {
+		t.Errorf("gcloud environment does not set %s to %s", EnvConfigDir, dir)
+	}
+}
+
+// captureFile returns an empty file standing in for stdout or stderr
+func captureFile(t *testing.T) *os.File {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "output")
+	if err != nil {
+		t.Fatalf("failed to create output file: %v", err)
+	}
+	t.Cleanup(func() { _ = f.Close() })
+	return f
+}
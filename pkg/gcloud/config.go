@@ -6,286 +6,248 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
 )
 
 // ListConfigurations returns all available gcloud configurations
 func ListConfigurations() ([]Configuration, error) {
-	output, err := RunGcloudCommand("config", "configurations", "list", "--format=json")
-	if err != nil {
-		return nil, fmt.Errorf("failed to list configurations: %w", err)
-	}
+	return defaultClient.List()
+}
 
+// ParseConfigurations decodes the output of
+// 'gcloud config configurations list --format=json'
+// The configurations are sorted by name
+func ParseConfigurations(output string) ([]Configuration, error) {
 	var configs []Configuration
 	if err := json.Unmarshal([]byte(output), &configs); err != nil {
 		return nil, fmt.Errorf("failed to parse configurations: %w", err)
 	}
 
+	// Sorted by name, so positions in listings stay the same between runs
+	slices.SortStableFunc(configs, func(a, b Configuration) int {
+		return strings.Compare(a.Name, b.Name)
+	})
 	return configs, nil
 }
 
 // GetActiveConfiguration returns the currently active configuration
 func GetActiveConfiguration() (*Configuration, error) {
-	configs, err := ListConfigurations()
-	if err != nil {
-		return nil, err
-	}
-
-	for _, config := range configs {
-		if config.IsActive {
-			return &config, nil
-		}
-	}
-
-	return nil, fmt.Errorf("no active configuration found")
+	return defaultClient.Active()
 }
 
-// ActivateConfiguration activates a specific configuration
-func ActivateConfiguration(name string) error {
-	if err := RunGcloudCommandQuiet("config", "configurations", "activate", name); err != nil {
-		return fmt.Errorf("failed to activate configuration %q: %w", name, err)
+// GetActiveConfigurationFast returns the active configuration from gcloud's files
+// without running gcloud, which makes it cheap enough for 'gcloudctx -c' and prompts
+// It honors $CLOUDSDK_ACTIVE_CONFIG_NAME like ActiveConfigName. When the active
+// configuration has no readable file, as when active_config names a deleted
+// configuration, it falls back to GetActiveConfiguration.
+func GetActiveConfigurationFast() (*Configuration, error) {
+	if config, ok := readActiveConfiguration(); ok {
+		return config, nil
 	}
-	return nil
+	return GetActiveConfiguration()
 }
 
-// ConfigurationExists checks if a configuration exists
-func ConfigurationExists(name string) bool {
-	configs, err := ListConfigurations()
-	if err != nil {
-		return false
+// readActiveConfiguration reads the active configuration from gcloud's files
+// ok is false when they do not answer for it
+func readActiveConfiguration() (config *Configuration, ok bool) {
+	name, err := ActiveConfigName()
+	if err != nil || ValidateExistingConfigurationName(name) != nil {
+		return nil, false
 	}
-
-	for _, config := range configs {
-		if config.Name == name {
-			return true
-		}
+	path, err := ConfigFilePath(name)
+	if err != nil {
+		return nil, false
 	}
-
-	return false
-}
-
-// SyncADC synchronizes Application Default Credentials with the current configuration
-func SyncADC(impersonateServiceAccount string) error {
-	args := []string{"auth", "application-default", "login"}
-
-	if impersonateServiceAccount != "" {
-		args = append(args, "--impersonate-service-account", impersonateServiceAccount)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
 	}
-
-	// Run the command interactively (user needs to authenticate in browser)
-	cmd := exec.Command("gcloud", args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to sync ADC: %w", err)
+	properties, err := parseConfigFile(data)
+	if err != nil {
+		return nil, false
 	}
-
-	return nil
+	return &Configuration{Name: name, IsActive: true, Properties: properties}, true
 }
 
-// GetConfigurationInfo returns detailed information about a configuration
-func GetConfigurationInfo(name string) (*Configuration, error) {
+// ResolveSwitch returns the active configuration and the configuration named target
+// from a single list call; target is nil when no configuration has that name
+func ResolveSwitch(target string) (active, found *Configuration, err error) {
 	configs, err := ListConfigurations()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	for _, config := range configs {
-		if config.Name == name {
-			return &config, nil
+	for i := range configs {
+		if configs[i].IsActive {
+			active = &configs[i]
+		}
+		if configs[i].Name == target {
+			found = &configs[i]
 		}
 	}
 
-	return nil, fmt.Errorf("configuration %q not found", name)
-}
-
-// GetCurrentProject returns the current project from active configuration
-func GetCurrentProject() (string, error) {
-	output, err := RunGcloudCommand("config", "get-value", "project")
-	if err != nil {
-		return "", err
+	if len(configs) == 0 {
+		return nil, nil, errNoConfigurations()
 	}
-	return strings.TrimSpace(output), nil
-}
-
-// GetCurrentAccount returns the current account from active configuration
-func GetCurrentAccount() (string, error) {
-	output, err := RunGcloudCommand("config", "get-value", "account")
-	if err != nil {
-		return "", err
+	if active == nil {
+		return nil, nil, ErrNoActiveConfiguration
 	}
-	return strings.TrimSpace(output), nil
+
+	return active, found, nil
 }
 
-// CreateConfiguration creates a new gcloud configuration
-func CreateConfiguration(name string) error {
-	if ConfigurationExists(name) {
-		return fmt.Errorf("configuration %q already exists", name)
-	}
+// ActivateConfiguration activates a specific configuration
+func ActivateConfiguration(name string) error {
+	return defaultClient.Activate(name)
+}
 
-	if err := RunGcloudCommandQuiet("config", "configurations", "create", name); err != nil {
-		return fmt.Errorf("failed to create configuration %q: %w", name, err)
-	}
-	return nil
+// ConfigurationExists checks if a configuration exists; it fails when the
+// configurations cannot be listed
+func ConfigurationExists(name string) (bool, error) {
+	return defaultClient.Exists(name)
 }
 
-// DeleteConfiguration deletes a gcloud configuration
-func DeleteConfiguration(name string) error {
-	if !ConfigurationExists(name) {
-		return fmt.Errorf("configuration %q does not exist", name)
-	}
+// SyncADC synchronizes Application Default Credentials with the current configuration
+// With noBrowser, gcloud prints a URL to open on another machine instead of launching a browser
+func SyncADC(impersonateServiceAccount string, noBrowser bool) error {
+	args := []string{"auth", "application-default", "login"}
 
-	// Get active configuration to prevent deletion
-	activeConfig, err := GetActiveConfiguration()
-	if err != nil {
-		return err
+	if impersonateServiceAccount != "" {
+		args = append(args, "--impersonate-service-account", impersonateServiceAccount)
 	}
-
-	if activeConfig.Name == name {
-		return fmt.Errorf("cannot delete active configuration %q", name)
+	if noBrowser {
+		args = append(args, "--no-launch-browser")
 	}
-
-	if err := RunGcloudCommandQuiet("config", "configurations", "delete", name, "--quiet"); err != nil {
-		return fmt.Errorf("failed to delete configuration %q: %w", name, err)
+	if err := runInteractive(args); err != nil {
+		return fmt.Errorf("failed to sync ADC: %w", err)
 	}
 	return nil
 }
 
-// CloneConfiguration creates a new configuration by copying properties from an existing one
-func CloneConfiguration(sourceName, targetName string) error {
-	if !ConfigurationExists(sourceName) {
-		return fmt.Errorf("source configuration %q does not exist", sourceName)
-	}
-
-	if ConfigurationExists(targetName) {
-		return fmt.Errorf("target configuration %q already exists", targetName)
+// Login runs 'gcloud auth login' to add an account to the active configuration
+func Login() error {
+	if err := runInteractive([]string{"auth", "login"}); err != nil {
+		return fmt.Errorf("failed to log in: %w", err)
 	}
+	return nil
+}
 
-	// Get the source configuration details
-	sourceConfig, err := GetConfigurationInfo(sourceName)
-	if err != nil {
+// runInteractive runs gcloud attached to the terminal, since the user needs to
+// authenticate in a browser and may be asked questions. What gcloud prints is
+// for the user, so it goes to stderr and stdout keeps only gcloudctx's result.
+func runInteractive(args []string) error {
+	if err := checkCommand(args); err != nil {
 		return err
 	}
 
-	// Create the new configuration
-	if err := CreateConfiguration(targetName); err != nil {
+	gcloudPath, err := GcloudPath()
+	if err != nil {
 		return err
 	}
 
-	// Copy properties to new configuration
-	if err := copyConfigProperties(sourceConfig, targetName); err != nil {
-		// Clean up on failure
-		if cleanupErr := cleanupConfiguration(targetName); cleanupErr != nil {
-			return fmt.Errorf("failed to copy properties: %w (cleanup also failed: %v)", err, cleanupErr)
-		}
-		return fmt.Errorf("failed to copy properties: %w", err)
-	}
+	cmd := exec.Command(gcloudPath, args...)
+	cmd.Env = commandEnv("")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
 
-	return nil
+// GetConfigurationInfo returns detailed information about a configuration
+func GetConfigurationInfo(name string) (*Configuration, error) {
+	return defaultClient.Get(name)
 }
 
-// RenameConfiguration renames a gcloud configuration
-func RenameConfiguration(oldName, newName string) error {
-	if !ConfigurationExists(oldName) {
-		return fmt.Errorf("configuration %q does not exist", oldName)
-	}
+// unsetValue is what 'gcloud config get-value' prints for a property that is not set
+const unsetValue = "(unset)"
 
-	if ConfigurationExists(newName) {
-		return fmt.Errorf("configuration %q already exists", newName)
-	}
+// coreEnvOverrides maps core properties to the variables gcloud lets override them
+var coreEnvOverrides = map[string]string{
+	"account": "CLOUDSDK_CORE_ACCOUNT",
+	"project": "CLOUDSDK_CORE_PROJECT",
+}
 
-	// Get the old configuration details before starting
-	oldConfig, err := GetConfigurationInfo(oldName)
+// GetActiveCoreProperties returns the account and project of the active configuration
+// with a single list call, applying $CLOUDSDK_CORE_ACCOUNT and $CLOUDSDK_CORE_PROJECT
+// the way gcloud does. Unset properties are "", never "(unset)".
+func GetActiveCoreProperties() (CoreProperties, error) {
+	config, err := GetActiveConfiguration()
 	if err != nil {
-		return err
+		return CoreProperties{}, err
 	}
 
-	// gcloud doesn't have a rename command, so we need to create a new one
-	// and copy the properties
-	if err := CreateConfiguration(newName); err != nil {
-		return err
-	}
-
-	// Copy properties to new configuration
-	if err := copyConfigProperties(oldConfig, newName); err != nil {
-		// Clean up on failure
-		if cleanupErr := cleanupConfiguration(newName); cleanupErr != nil {
-			return fmt.Errorf("failed to copy properties: %w (cleanup also failed: %v)", err, cleanupErr)
-		}
-		return fmt.Errorf("failed to copy properties: %w", err)
-	}
+	core := config.Properties.Core
+	core.Account = coreValue("account", core.Account)
+	core.Project = coreValue("project", core.Project)
+	return core, nil
+}
 
-	// If old config was active, switch to new one
-	if oldConfig.IsActive {
-		if err := ActivateConfiguration(newName); err != nil {
-			// Clean up on failure
-			if cleanupErr := cleanupConfiguration(newName); cleanupErr != nil {
-				return fmt.Errorf("failed to activate configuration: %w (cleanup also failed: %v)", err, cleanupErr)
-			}
-			return fmt.Errorf("failed to activate configuration: %w", err)
-		}
+// coreValue normalizes a core property, preferring its environment override
+func coreValue(property, value string) string {
+	if override, ok := os.LookupEnv(coreEnvOverrides[property]); ok {
+		value = override
 	}
-
-	// Delete old configuration
-	if err := DeleteConfiguration(oldName); err != nil {
-		return fmt.Errorf("failed to delete old configuration %q: %w", oldName, err)
+	value = strings.TrimSpace(value)
+	if value == unsetValue {
+		return ""
 	}
-
-	return nil
+	return value
 }
 
-// copyConfigProperties copies properties from one configuration to another
-func copyConfigProperties(source *Configuration, targetName string) error {
-	// Copy account property
-	if source.Properties.Core.Account != "" {
-		if err := RunGcloudCommandQuiet("config", "set", "account", source.Properties.Core.Account, "--configuration", targetName); err != nil {
-			return fmt.Errorf("failed to copy account property: %w", err)
-		}
-	}
+// GetCurrentProject returns the current project from active configuration
+//
+// Deprecated: use GetActiveCoreProperties, which also returns the account
+func GetCurrentProject() (string, error) {
+	core, err := GetActiveCoreProperties()
+	return core.Project, err
+}
 
-	// Copy project property
-	if source.Properties.Core.Project != "" {
-		if err := RunGcloudCommandQuiet("config", "set", "project", source.Properties.Core.Project, "--configuration", targetName); err != nil {
-			return fmt.Errorf("failed to copy project property: %w", err)
-		}
-	}
+// GetCurrentAccount returns the current account from active configuration
+//
+// Deprecated: use GetActiveCoreProperties, which also returns the project
+func GetCurrentAccount() (string, error) {
+	core, err := GetActiveCoreProperties()
+	return core.Account, err
+}
 
-	// Copy region property
-	if source.Properties.Compute.Region != "" {
-		if err := RunGcloudCommandQuiet("config", "set", "compute/region", source.Properties.Compute.Region, "--configuration", targetName); err != nil {
-			return fmt.Errorf("failed to copy region property: %w", err)
-		}
-	}
+// CreateConfiguration creates a new gcloud configuration
+func CreateConfiguration(name string) error {
+	return defaultClient.Create(name)
+}
 
-	// Copy zone property
-	if source.Properties.Compute.Zone != "" {
-		if err := RunGcloudCommandQuiet("config", "set", "compute/zone", source.Properties.Compute.Zone, "--configuration", targetName); err != nil {
-			return fmt.Errorf("failed to copy zone property: %w", err)
-		}
-	}
+// DeleteConfiguration deletes a gcloud configuration
+func DeleteConfiguration(name string) error {
+	return defaultClient.Delete(name)
+}
 
-	return nil
+// CloneConfiguration creates a new configuration by copying properties from an existing one
+func CloneConfiguration(sourceName, targetName string) error {
+	return defaultClient.Clone(sourceName, targetName)
 }
 
-// cleanupConfiguration attempts to delete a configuration and returns any error encountered
-func cleanupConfiguration(name string) error {
-	if err := DeleteConfiguration(name); err != nil {
-		return fmt.Errorf("failed to cleanup configuration %q: %w", name, err)
-	}
-	return nil
+// RenameConfiguration renames a gcloud configuration
+func RenameConfiguration(oldName, newName string) error {
+	return defaultClient.Rename(oldName, newName)
 }
 
 // getActiveConfigurationFromList finds the active configuration from a list
 // This is a pure function for easier testing
 func getActiveConfigurationFromList(configs []Configuration) (*Configuration, error) {
+	if len(configs) == 0 {
+		return nil, errNoConfigurations()
+	}
 	for i := range configs {
 		if configs[i].IsActive {
 			return &configs[i], nil
 		}
 	}
-	return nil, fmt.Errorf("no active configuration found")
+	return nil, ErrNoActiveConfiguration
+}
+
+// errNoConfigurations reports that gcloud has no configurations yet
+func errNoConfigurations() error {
+	return kindErrorf(ErrNoConfigurations, "no active configuration found: gcloud has no configurations yet")
 }
 
 // findConfigurationByName finds a configuration by name from a list
@@ -305,6 +267,24 @@ func configurationExistsInList(configs []Configuration, name string) bool {
 	return found
 }
 
+// ReservedConfigurationName is the configuration gcloud creates on first use
+// and falls back to while no other configuration is active
+const ReservedConfigurationName = "default"
+
+// IsReservedName reports whether name is a configuration gcloud treats specially
+func IsReservedName(name string) bool {
+	return name == ReservedConfigurationName
+}
+
+// ValidateDeletion checks that a configuration may be deleted
+// The reserved configuration can only be deleted when force is set
+func ValidateDeletion(name string, force bool) error {
+	if IsReservedName(name) && !force {
+		return fmt.Errorf("configuration %q is reserved by gcloud; use --force to delete it anyway", name)
+	}
+	return nil
+}
+
 // configNameRegex validates configuration names
 // Must start with a letter, contain only alphanumeric, hyphens, and underscores
 var configNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
@@ -312,7 +292,9 @@ var configNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
 // MaxConfigNameLength is the maximum allowed length for a configuration name
 const MaxConfigNameLength = 63
 
-// ValidateConfigurationName validates a configuration name
+// ValidateConfigurationName validates the name of a configuration about to be created
+// Names of configurations that already exist are checked with
+// ValidateExistingConfigurationName instead
 func ValidateConfigurationName(name string) error {
 	if name == "" {
 		return fmt.Errorf("configuration name cannot be empty")
@@ -328,3 +310,67 @@ func ValidateConfigurationName(name string) error {
 
 	return nil
 }
+
+// existingConfigNameRegex matches the names configurations may already have:
+// gcloud keeps ones that configNameRegex refuses, such as names with dots or a
+// leading digit created by old SDKs or by hand
+var existingConfigNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// ValidateExistingConfigurationName validates the name of a configuration that
+// is expected to exist already, such as a switch target or the source of a clone
+// It only rejects names that cannot be a configuration file of gcloud's, or that
+// gcloud would take for a flag; whether the configuration exists is up to the caller
+func ValidateExistingConfigurationName(name string) error {
+	if name == "" {
+		return fmt.Errorf("configuration name cannot be empty")
+	}
+
+	if !existingConfigNameRegex.MatchString(name) {
+		return fmt.Errorf("configuration name %q must start with a letter or digit and contain only alphanumeric characters, dots, hyphens, and underscores", name)
+	}
+
+	return nil
+}
+
+// sanitizedNamePrefix is prepended to sanitized names that would not start with a letter
+const sanitizedNamePrefix = "config-"
+
+// SanitizeConfigurationName derives a valid configuration name from an arbitrary string
+// It lowercases the input, replaces runs of invalid characters with a single hyphen,
+// prefixes names that do not start with a letter, and trims the result to MaxConfigNameLength.
+// An error is returned when the input has no usable characters at all.
+func SanitizeConfigurationName(name string) (string, error) {
+	var builder strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			if pendingHyphen && builder.Len() > 0 {
+				builder.WriteByte('-')
+			}
+			pendingHyphen = false
+			builder.WriteRune(r)
+			continue
+		}
+		// Hyphens and every invalid rune collapse into a single separator
+		pendingHyphen = true
+	}
+
+	sanitized := builder.String()
+	if strings.Trim(sanitized, "_") == "" {
+		return "", fmt.Errorf("cannot derive a configuration name from %q", name)
+	}
+
+	if sanitized[0] < 'a' || sanitized[0] > 'z' {
+		sanitized = sanitizedNamePrefix + sanitized
+	}
+
+	if len(sanitized) > MaxConfigNameLength {
+		sanitized = strings.TrimRight(sanitized[:MaxConfigNameLength], "-_")
+	}
+
+	if err := ValidateConfigurationName(sanitized); err != nil {
+		return "", fmt.Errorf("cannot derive a configuration name from %q: %w", name, err)
+	}
+
+	return sanitized, nil
+}
@@ -1,6 +1,8 @@
 package gcloud
 
 import (
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -148,6 +150,47 @@ func TestConfigurationExistsInList(t *testing.T) {
 	}
 }
 
+func TestGetActiveCoreProperties(t *testing.T) {
+	tests := []struct {
+		name string
+		core string
+		env  map[string]string
+		want CoreProperties
+	}{
+		{"set", `{"account": "me@example.com", "project": "prod-project"}`, nil, CoreProperties{Account: "me@example.com", Project: "prod-project"}},
+		{"unset", `{"account": "(unset)", "project": "(unset)"}`, nil, CoreProperties{}},
+		{"empty", `{"account": "", "project": " "}`, nil, CoreProperties{}},
+		{"missing", `{}`, nil, CoreProperties{}},
+		{"environment override", `{"project": "prod-project"}`, map[string]string{"CLOUDSDK_CORE_PROJECT": "other-project"}, CoreProperties{Project: "other-project"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"CLOUDSDK_CORE_ACCOUNT", "CLOUDSDK_CORE_PROJECT"} {
+				t.Setenv(name, "")
+				if err := os.Unsetenv(name); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			fake := installFakeRunner(t, `[{"name": "prod", "is_active": true, "properties": {"core": `+tt.core+`}}]`)
+
+			got, err := GetActiveCoreProperties()
+			if err != nil {
+				t.Fatalf("GetActiveCoreProperties() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetActiveCoreProperties() = %+v, want %+v", got, tt.want)
+			}
+			if len(fake.calls) != 1 {
+				t.Errorf("gcloud ran %d times, want once: %q", len(fake.calls), fake.calls)
+			}
+		})
+	}
+}
+
 func TestValidateConfigurationName(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -177,6 +220,72 @@ func TestValidateConfigurationName(t *testing.T) {
 	}
 }
 
+func TestValidateExistingConfigurationName(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{"myconfig", false},
+		{"My.Weird_Config", false},
+		{"123config", false},
+		{"this-is-a-very-long-configuration-name-that-exceeds-the-maximum-allowed-length-for-gcloud-configurations", false},
+		{"", true},
+		{"-config", true},
+		{".hidden", true},
+		{"..", true},
+		{"../config", true},
+		{`dir\config`, true},
+		{"my config", true},
+		{"prod; rm -rf ~", true},
+		{"line\nbreak", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateExistingConfigurationName(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateExistingConfigurationName(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestIsReservedName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"default", true},
+		{"Default", false},
+		{"default-2", false},
+		{"prod", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsReservedName(tt.name); got != tt.want {
+			t.Errorf("IsReservedName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateDeletion(t *testing.T) {
+	tests := []struct {
+		name    string
+		force   bool
+		wantErr bool
+	}{
+		{"prod", false, false},
+		{"prod", true, false},
+		{"default", false, true},
+		{"default", true, false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateDeletion(tt.name, tt.force)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateDeletion(%q, %v) error = %v, wantErr %v", tt.name, tt.force, err, tt.wantErr)
+		}
+	}
+}
+
 // containsString checks if s contains substr
 func containsString(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
@@ -191,3 +300,65 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestSanitizeConfigurationName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"dot and uppercase", "Prod.EU", "prod-eu", false},
+		{"already valid", "my-config_1", "my-config_1", false},
+		{"spaces and symbols collapse", "  team  /  prod!!eu  ", "team-prod-eu", false},
+		{"leading digits", "2024-release", "config-2024-release", false},
+		{"leading underscore", "_internal", "config-_internal", false},
+		{"unicode", "prod-ümlaut-日本", "prod-mlaut", false},
+		{"only invalid characters", "...", "", true},
+		{"only unicode", "日本語", "", true},
+		{"empty", "", "", true},
+		{"only underscores", "___", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SanitizeConfigurationName(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SanitizeConfigurationName(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SanitizeConfigurationName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if !tt.wantErr {
+				if err := ValidateConfigurationName(got); err != nil {
+					t.Errorf("sanitized name %q is invalid: %v", got, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeConfigurationNameLongInputs(t *testing.T) {
+	inputs := []string{
+		strings.Repeat("a", 200),
+		strings.Repeat("ab.", 70),
+		"9" + strings.Repeat("x", 199),
+		strings.Repeat("a", 62) + "-" + strings.Repeat("b", 137),
+	}
+
+	for _, input := range inputs {
+		got, err := SanitizeConfigurationName(input)
+		if err != nil {
+			t.Fatalf("SanitizeConfigurationName(%d chars) failed: %v", len(input), err)
+		}
+		if len(got) > MaxConfigNameLength {
+			t.Errorf("sanitized name has %d characters, want at most %d", len(got), MaxConfigNameLength)
+		}
+		if err := ValidateConfigurationName(got); err != nil {
+			t.Errorf("sanitized name %q is invalid: %v", got, err)
+		}
+		if strings.HasSuffix(got, "-") {
+			t.Errorf("sanitized name %q ends with a hyphen", got)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package gcloud
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// configFilePrefix prefixes the file of each configuration in the configurations directory
+const configFilePrefix = "config_"
+
+// ConfigFilePath returns the file gcloud keeps the named configuration's properties in
+func ConfigFilePath(name string) (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configurationsDirName, configFilePrefix+name), nil
+}
+
+// ConfigurationFileExists reports whether the named configuration has a file in
+// gcloud's configurations directory, without running gcloud
+// Names that cannot be a configuration file never exist, so they cannot point elsewhere
+func ConfigurationFileExists(name string) (bool, error) {
+	if ValidateExistingConfigurationName(name) != nil {
+		return false, nil
+	}
+
+	path, err := ConfigFilePath(name)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read configuration %q: %w", name, err)
+	}
+	return info.Mode().IsRegular(), nil
+}
+
+// knownSections are the property sections gcloud recognizes
+var knownSections = []string{
+	"accessibility", "ai", "ai_platform", "api_client_overrides", "api_endpoint_overrides",
+	"app", "artifacts", "auth", "batch", "billing", "builds", "code", "component_manager",
+	"composer", "compute", "container", "container_attached", "container_aws",
+	"container_azure", "context_aware", "core", "dataflow", "datafusion", "datapipelines",
+	"dataplex", "dataproc", "datastream", "deploy", "deployment_manager", "emulator",
+	"eventarc", "filestore", "functions", "gcloudignore", "gkebackup", "gkemulticloud",
+	"healthcare", "inframanager", "interactive", "lifesciences", "looker", "media_asset",
+	"memcache", "metastore", "ml_engine", "mps", "notebooks", "privateca", "proxy",
+	"pubsub", "recaptcha", "redis", "resource_policy", "run", "runapps", "scc",
+	"secrets", "spanner", "ssh", "storage", "survey", "test", "transcoder", "transport",
+	"workflows", "workstations",
+}
+
+// knownKeys lists the keys of the sections whose keys are checked; keys of
+// other recognized sections are accepted as they are
+var knownKeys = map[string][]string{
+	"core": {
+		"account", "api_host", "check_gce_metadata", "console_log_format", "custom_ca_certs_file",
+		"default_regional_backend_service", "disable_color", "disable_file_logging",
+		"disable_prompts", "disable_usage_reporting", "enable_feature_flags", "http_timeout",
+		"interactive_ux_style", "log_http", "log_http_redact_token", "log_http_show_request_body",
+		"max_log_days", "parse_error_details", "pass_credentials_to_gsutil",
+		"print_completion_tracebacks", "print_handled_tracebacks", "print_unhandled_tracebacks",
+		"project", "request_reason", "resource_completion_style", "show_structured_logs",
+		"trace_token", "universe_domain", "user_output_enabled", "verbosity",
+	},
+	"compute": {
+		"image_family_scope", "region", "use_new_list_usable_subnets_api", "zone",
+	},
+}
+
+// ConfigFileError is a line of a configuration file that gcloud cannot parse
+type ConfigFileError struct {
+	Line    int
+	Message string
+}
+
+func (e *ConfigFileError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ValidateConfigFile checks a configuration file the way gcloud's INI parser reads it
+// A line that breaks parsing is returned as a *ConfigFileError; unknown sections
+// and keys still parse, so they are returned as warnings instead
+func ValidateConfigFile(data []byte) (warnings []string, err error) {
+	sections := map[string]map[string]bool{}
+	section := ""
+	lastKey := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+
+		switch {
+		case line == "":
+			lastKey = false
+			continue
+		case strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case raw[0] == ' ' || raw[0] == '\t':
+			// A continuation of the previous value
+			if !lastKey {
+				return warnings, &ConfigFileError{Line: lineNumber, Message: "indented line does not continue a value"}
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || len(line) < 3 {
+				return warnings, &ConfigFileError{Line: lineNumber, Message: fmt.Sprintf("malformed section header %q", line)}
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, duplicate := sections[section]; duplicate {
+				return warnings, &ConfigFileError{Line: lineNumber, Message: fmt.Sprintf("section [%s] appears more than once", section)}
+			}
+			sections[section] = map[string]bool{}
+			lastKey = false
+			if !slices.Contains(knownSections, section) {
+				warnings = append(warnings, fmt.Sprintf("line %d: unknown section [%s]", lineNumber, section))
+			}
+			continue
+		}
+
+		i := strings.IndexAny(line, "=:")
+		if i <= 0 {
+			return warnings, &ConfigFileError{Line: lineNumber, Message: fmt.Sprintf("expected \"key = value\", got %q", line)}
+		}
+		if section == "" {
+			return warnings, &ConfigFileError{Line: lineNumber, Message: "property outside of a [section]"}
+		}
+
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		if sections[section][key] {
+			return warnings, &ConfigFileError{Line: lineNumber, Message: fmt.Sprintf("%s/%s is set more than once", section, key)}
+		}
+		sections[section][key] = true
+		lastKey = true
+
+		if keys, checked := knownKeys[section]; checked && !slices.Contains(keys, key) {
+			warnings = append(warnings, fmt.Sprintf("line %d: unknown property %s/%s", lineNumber, section, key))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return warnings, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	return warnings, nil
+}
+
+// parseConfigFile reads the properties of a configuration file the way gcloud's
+// INI parser does: keys are lowercased, values trimmed, and indented lines
+// continue the previous value
+func parseConfigFile(data []byte) (Properties, error) {
+	if _, err := ValidateConfigFile(data); err != nil {
+		return Properties{}, err
+	}
+
+	sections := map[string]map[string]any{}
+	section, key := "", ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+
+		switch {
+		case line == "":
+			key = ""
+		case strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case raw[0] == ' ' || raw[0] == '\t':
+			sections[section][key] = sections[section][key].(string) + "\n" + line
+		case strings.HasPrefix(line, "["):
+			section, key = strings.TrimSpace(line[1:len(line)-1]), ""
+			sections[section] = map[string]any{}
+		default:
+			i := strings.IndexAny(line, "=:")
+			key = strings.ToLower(strings.TrimSpace(line[:i]))
+			sections[section][key] = strings.TrimSpace(line[i+1:])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Properties{}, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	value := func(section, key string) string {
+		s, _ := sections[section][key].(string)
+		return s
+	}
+	properties := Properties{
+		Core: CoreProperties{
+			Account: value("core", "account"),
+			Project: value("core", "project"),
+		},
+		Compute: ComputeProperties{
+			Region: value("compute", "region"),
+			Zone:   value("compute", "zone"),
+		},
+		Sections: sections,
+	}
+	if disabled, err := strconv.ParseBool(value("core", "disable_usage_reporting")); err == nil {
+		properties.Core.DisableUsageReport = disabled
+	}
+	return properties, nil
+}
+
+// readConfigurations reads every configuration from the configuration
+// directory dir without running gcloud, sorted by name like ParseConfigurations
+// Files whose name cannot be a configuration, or that gcloud cannot parse, are skipped
+func readConfigurations(dir string) ([]Configuration, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, configurationsDirName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read configurations: %w", err)
+	}
+
+	active := os.Getenv(EnvActiveConfigName)
+	if active == "" {
+		data, err := os.ReadFile(filepath.Join(dir, ActiveConfigFileName))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read active configuration: %w", err)
+		}
+		active = strings.TrimSpace(string(data))
+	}
+	if active == "" {
+		active = ReservedConfigurationName
+	}
+
+	var configs []Configuration
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), configFilePrefix)
+		if !ok || entry.IsDir() || ValidateExistingConfigurationName(name) != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, configurationsDirName, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read configuration %q: %w", name, err)
+		}
+		properties, err := parseConfigFile(data)
+		if err != nil {
+			continue
+		}
+		configs = append(configs, Configuration{Name: name, IsActive: name == active, Properties: properties})
+	}
+
+	// os.ReadDir returns the entries sorted by file name, hence by configuration name
+	return configs, nil
+}
+
+// ReadConfigurationFile returns the contents of the named configuration's
+// properties file, or nil when the configuration has no file
+func ReadConfigurationFile(name string) ([]byte, error) {
+	return defaultClient.readConfigurationFile(name)
+}
+
+// WriteConfigurationFile replaces the named configuration's properties file
+// with data, creating the configuration when it does not exist
+func WriteConfigurationFile(name string, data []byte) error {
+	return defaultClient.writeConfigurationFile(name, data)
+}
+
+// readConfigurationFile is ReadConfigurationFile for the client's configuration directory
+func (c *Client) readConfigurationFile(name string) ([]byte, error) {
+	path, err := c.configFilePath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration %q: %w", name, err)
+	}
+	return data, nil
+}
+
+// writeConfigurationFile is WriteConfigurationFile for the client's configuration directory
+// The data must parse as gcloud reads it; the file is replaced atomically and
+// keeps the permissions of the file it replaces
+func (c *Client) writeConfigurationFile(name string, data []byte) error {
+	// The file is written directly, without a gcloud command to refuse
+	if err := CheckWritable(fmt.Sprintf("writing configuration %q", name)); err != nil {
+		return err
+	}
+	if _, err := ValidateConfigFile(data); err != nil {
+		return fmt.Errorf("configuration %q does not parse: %w", name, err)
+	}
+
+	path, err := c.configFilePath(name)
+	if err != nil {
+		return err
+	}
+	current, err := readFileState(path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration %q: %w", name, err)
+	}
+	mode := os.FileMode(0o600)
+	if current.exists {
+		mode = current.mode
+	}
+
+	defer c.invalidate()
+	if err := writeConfigFile(path, data, mode); err != nil {
+		return fmt.Errorf("failed to write configuration %q: %w", name, err)
+	}
+	return nil
+}
+
+// configFilePath returns the properties file of the named configuration in the
+// client's configuration directory
+// The name becomes part of a path, so it must not leave the directory
+func (c *Client) configFilePath(name string) (string, error) {
+	if err := ValidateExistingConfigurationName(name); err != nil {
+		return "", fmt.Errorf("invalid configuration name %q: %w", name, err)
+	}
+	dir, err := c.configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configurationsDirName, configFilePrefix+name), nil
+}
@@ -0,0 +1,203 @@
+package gcloud
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidateConfigFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantWarnings []string
+		wantErrLine  int
+	}{
+		{
+			name: "valid",
+			data: "# managed by hand\n[core]\naccount = me@example.com\nproject: my-project\n\n[compute]\nregion = us-central1\nzone = us-central1-a\n",
+		},
+		{
+			name: "continuation line",
+			data: "[core]\ncustom_ca_certs_file = /etc/ssl/\n  corp.pem\n",
+		},
+		{
+			name:         "unknown section and key",
+			data:         "[core]\nprojet = typo\n[snacks]\nflavor = salty\n[run]\nregion = us-east1\n",
+			wantWarnings: []string{"line 2: unknown property core/projet", "line 3: unknown section [snacks]"},
+		},
+		{name: "property before section", data: "project = p\n[core]\n", wantErrLine: 1},
+		{name: "malformed header", data: "[core\nproject = p\n", wantErrLine: 1},
+		{name: "not a key value line", data: "[core]\njust some words\n", wantErrLine: 2},
+		{name: "duplicate section", data: "[core]\nproject = p\n[core]\naccount = a\n", wantErrLine: 3},
+		{name: "duplicate key", data: "[compute]\nzone = a\nZONE = b\n", wantErrLine: 3},
+		{name: "stray indentation", data: "[core]\n\n  project = p\n", wantErrLine: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := ValidateConfigFile([]byte(tt.data))
+			if tt.wantErrLine != 0 {
+				var fileErr *ConfigFileError
+				if !errors.As(err, &fileErr) || fileErr.Line != tt.wantErrLine {
+					t.Fatalf("ValidateConfigFile() error = %v, want an error on line %d", err, tt.wantErrLine)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateConfigFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("ValidateConfigFile() warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestConfigFilePath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, dir)
+
+	path, err := ConfigFilePath("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "configurations", "config_prod"); path != want {
+		t.Errorf("ConfigFilePath() = %q, want %q", path, want)
+	}
+}
+
+func TestConfigurationFileExists(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, dir)
+	writeConfigurations(t, dir, "prod")
+	if err := os.WriteFile(filepath.Join(dir, "secret"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"prod", true},
+		{"staging", false},
+		{"../secret", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		got, err := ConfigurationFileExists(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("ConfigurationFileExists(%q) = %v, %v; want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestWriteConfigurationFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, dir)
+	t.Setenv(EnvReadOnly, "")
+
+	data := []byte("[core]\nproject = prod-project\n\n[proxy]\naddress = proxy.internal\n\n[snacks]\nflavor = salty\n")
+	if err := WriteConfigurationFile("prod", data); err != nil {
+		t.Fatalf("WriteConfigurationFile() error = %v", err)
+	}
+	got, err := ReadConfigurationFile("prod")
+	if err != nil || string(got) != string(data) {
+		t.Errorf("ReadConfigurationFile() = %q, %v; want the file byte for byte", got, err)
+	}
+
+	if got, err := ReadConfigurationFile("staging"); err != nil || got != nil {
+		t.Errorf("ReadConfigurationFile(missing) = %q, %v; want nil", got, err)
+	}
+	if err := WriteConfigurationFile("../escape", data); err == nil {
+		t.Error("WriteConfigurationFile() should refuse names leaving the directory")
+	}
+	if err := WriteConfigurationFile("broken", []byte("project = no section\n")); err == nil {
+		t.Error("WriteConfigurationFile() should refuse files gcloud cannot parse")
+	}
+
+	t.Setenv(EnvReadOnly, "1")
+	if err := WriteConfigurationFile("prod", []byte("[core]\n")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("WriteConfigurationFile() in read-only mode error = %v, want ErrReadOnly", err)
+	}
+}
+
+// writeConfigurations creates an empty file for each named configuration in dir
+func writeConfigurations(tb testing.TB, dir string, names ...string) {
+	tb.Helper()
+	configurations := filepath.Join(dir, "configurations")
+	if err := os.MkdirAll(configurations, 0o755); err != nil {
+		tb.Fatal(err)
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(configurations, "config_"+name), []byte("[core]\n"), 0o600); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// The native reader is what keeps prompts and cd hooks from running gcloud
+func BenchmarkActiveConfigName(b *testing.B) {
+	dir := b.TempDir()
+	b.Setenv(EnvConfigDir, dir)
+	b.Setenv(EnvActiveConfigName, "")
+	if err := os.WriteFile(filepath.Join(dir, ActiveConfigFileName), []byte("prod"), 0o600); err != nil {
+		b.Fatal(err)
+	}
+
+	for b.Loop() {
+		if _, err := ActiveConfigName(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConfigurationFileExists(b *testing.B) {
+	dir := b.TempDir()
+	b.Setenv(EnvConfigDir, dir)
+	writeConfigurations(b, dir, "dev", "staging", "prod")
+
+	for b.Loop() {
+		if exists, err := ConfigurationFileExists("prod"); err != nil || !exists {
+			b.Fatalf("ConfigurationFileExists() = %v, %v", exists, err)
+		}
+	}
+}
+
+func TestParseConfigFile(t *testing.T) {
+	data := []byte(`# comment
+[core]
+Account = me@example.com
+project: my-project
+disable_usage_reporting = True
+
+[compute]
+region = asia-northeast1
+zone = asia-northeast1-a
+
+[container]
+cluster = my-cluster
+  continued
+`)
+
+	got, err := parseConfigFile(data)
+	if err != nil {
+		t.Fatalf("parseConfigFile() error = %v", err)
+	}
+	want := CoreProperties{Account: "me@example.com", Project: "my-project", DisableUsageReport: true}
+	if got.Core != want {
+		t.Errorf("core = %+v, want %+v", got.Core, want)
+	}
+	if got.Compute != (ComputeProperties{Region: "asia-northeast1", Zone: "asia-northeast1-a"}) {
+		t.Errorf("compute = %+v", got.Compute)
+	}
+	if cluster := got.Sections["container"]["cluster"]; cluster != "my-cluster\ncontinued" {
+		t.Errorf("container/cluster = %q, want the continued value", cluster)
+	}
+
+	if _, err := parseConfigFile([]byte("project = outside\n")); err == nil {
+		t.Error("parseConfigFile() accepted a property outside of a section")
+	}
+}
@@ -0,0 +1,178 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ADCType is the kind of Application Default Credentials, the "type" field of the file
+type ADCType string
+
+// ADC types gcloud and the Google client libraries write
+const (
+	ADCAuthorizedUser             ADCType = "authorized_user"
+	ADCServiceAccount             ADCType = "service_account"
+	ADCImpersonatedServiceAccount ADCType = "impersonated_service_account"
+	ADCExternalAccount            ADCType = "external_account"
+)
+
+// ADCInfo is what an Application Default Credentials file says about the
+// identity it holds; keys, tokens and secrets are never read into it
+type ADCInfo struct {
+	Type ADCType
+	// ServiceAccount is the account of service_account credentials
+	ServiceAccount string
+	// SourceType is the type of the credentials that impersonate, for
+	// impersonated_service_account
+	SourceType ADCType
+	// Audience names the workload identity pool of external_account credentials
+	Audience string
+	// ImpersonationChain lists the service accounts impersonated in order: the
+	// delegates, then the target; it is empty without impersonation
+	ImpersonationChain []string
+}
+
+// adcFile holds the fields of an ADC file ParseADC reads
+type adcFile struct {
+	Type              string   `json:"type"`
+	ClientEmail       string   `json:"client_email"`
+	ImpersonationURL  string   `json:"service_account_impersonation_url"`
+	Delegates         []string `json:"delegates"`
+	Audience          string   `json:"audience"`
+	SourceCredentials *struct {
+		Type string `json:"type"`
+	} `json:"source_credentials"`
+}
+
+// impersonationURLPattern extracts the target service account of a
+// service_account_impersonation_url
+var impersonationURLPattern = regexp.MustCompile(`/serviceAccounts/([^/:]+):generate(Access|Id)Token$`)
+
+// delegatePrefix starts the resource names of impersonation delegates
+const delegatePrefix = "projects/-/serviceAccounts/"
+
+// ReadADC parses the ADC file at path
+func ReadADC(path string) (*ADCInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ADC: %w", err)
+	}
+	return ParseADC(data)
+}
+
+// ParseADC parses the contents of an ADC file of one of the known types
+func ParseADC(data []byte) (*ADCInfo, error) {
+	var file adcFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse ADC: %w", err)
+	}
+
+	info := &ADCInfo{Type: ADCType(file.Type)}
+	switch info.Type {
+	case ADCAuthorizedUser:
+	case ADCServiceAccount:
+		info.ServiceAccount = file.ClientEmail
+	case ADCImpersonatedServiceAccount:
+		if file.SourceCredentials == nil {
+			return nil, errors.New("failed to parse ADC: impersonated credentials without source credentials")
+		}
+		info.SourceType = ADCType(file.SourceCredentials.Type)
+		if file.ImpersonationURL == "" {
+			return nil, errors.New("failed to parse ADC: impersonated credentials without an impersonation URL")
+		}
+	case ADCExternalAccount:
+		info.Audience = file.Audience
+	case "":
+		return nil, errors.New("failed to parse ADC: no credential type")
+	default:
+		return nil, fmt.Errorf("failed to parse ADC: unknown credential type %q", file.Type)
+	}
+
+	if file.ImpersonationURL != "" {
+		match := impersonationURLPattern.FindStringSubmatch(file.ImpersonationURL)
+		if match == nil {
+			return nil, fmt.Errorf("failed to parse ADC: unrecognized impersonation URL %q", file.ImpersonationURL)
+		}
+		for _, delegate := range file.Delegates {
+			info.ImpersonationChain = append(info.ImpersonationChain, strings.TrimPrefix(delegate, delegatePrefix))
+		}
+		info.ImpersonationChain = append(info.ImpersonationChain, match[1])
+	}
+	return info, nil
+}
+
+// Impersonated reports whether the credentials act as a service account they impersonate
+func (i *ADCInfo) Impersonated() bool {
+	return len(i.ImpersonationChain) > 0
+}
+
+// String describes the credentials, such as
+// "impersonated_service_account (authorized_user → deploy@p.iam.gserviceaccount.com)"
+func (i *ADCInfo) String() string {
+	var identity []string
+	switch i.Type {
+	case ADCServiceAccount:
+		identity = append(identity, i.ServiceAccount)
+	case ADCImpersonatedServiceAccount:
+		identity = append(identity, string(i.SourceType))
+	case ADCExternalAccount:
+		identity = append(identity, i.Audience)
+	}
+	identity = append(identity, i.ImpersonationChain...)
+
+	var parts []string
+	for _, part := range identity {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return string(i.Type)
+	}
+	return fmt.Sprintf("%s (%s)", i.Type, strings.Join(parts, " → "))
+}
+
+// TokenNearExpiry is how long before it expires an access token counts as near expiry
+const TokenNearExpiry = 10 * time.Minute
+
+// AccessTokenExpiry returns when the access token of the active account
+// expires, refreshing the token first when refresh is set
+// gcloud refreshes an expired token by itself; errors matching
+// ErrReauthRequired mean it could not, and the account must log in again.
+// The token itself is never returned.
+func AccessTokenExpiry(refresh bool) (time.Time, error) {
+	args := []string{"config", "config-helper", "--format=json"}
+	if refresh {
+		args = append(args, "--force-auth-refresh")
+	}
+	output, err := RunGcloudCommand(args...)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to check the access token: %w", err)
+	}
+	return parseTokenExpiry(output)
+}
+
+// parseTokenExpiry reads the token expiry from the output of 'gcloud config config-helper'
+func parseTokenExpiry(output string) (time.Time, error) {
+	var helper struct {
+		Credential struct {
+			TokenExpiry string `json:"token_expiry"`
+		} `json:"credential"`
+	}
+	if err := json.Unmarshal([]byte(output), &helper); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse the access token expiry: %w", err)
+	}
+	if helper.Credential.TokenExpiry == "" {
+		return time.Time{}, errors.New("gcloud reported no access token expiry")
+	}
+	expiry, err := time.Parse(time.RFC3339, helper.Credential.TokenExpiry)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse the access token expiry: %w", err)
+	}
+	return expiry, nil
+}
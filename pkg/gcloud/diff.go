@@ -0,0 +1,99 @@
+package gcloud
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// PropertyDiff describes a property whose value differs between two configurations
+// An empty From or To means the property is unset on that side
+type PropertyDiff struct {
+	Property string `json:"property" yaml:"property"`
+	From     string `json:"from" yaml:"from"`
+	To       string `json:"to" yaml:"to"`
+}
+
+// FlattenProperties returns all properties of a configuration keyed by "section/name"
+// Raw sections are used when available so untyped sections are included
+func FlattenProperties(config *Configuration) map[string]string {
+	flat := map[string]string{}
+
+	if config.Properties.Sections != nil {
+		for section, values := range config.Properties.Sections {
+			for name, value := range values {
+				flat[section+"/"+name] = formatPropertyValue(value)
+			}
+		}
+		return flat
+	}
+
+	// Fall back to typed fields for configurations built without raw sections
+	core := config.Properties.Core
+	compute := config.Properties.Compute
+	setIfNotEmpty(flat, "core/account", core.Account)
+	setIfNotEmpty(flat, "core/project", core.Project)
+	if core.DisableUsageReport {
+		flat["core/disable_usage_reporting"] = "True"
+	}
+	setIfNotEmpty(flat, "compute/region", compute.Region)
+	setIfNotEmpty(flat, "compute/zone", compute.Zone)
+
+	return flat
+}
+
+func setIfNotEmpty(flat map[string]string, key, value string) {
+	if value != "" {
+		flat[key] = value
+	}
+}
+
+// formatPropertyValue renders a raw property value as gcloud displays it
+func formatPropertyValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// DiffConfigurations compares the full property sets of two configurations
+// The result is sorted by property name
+func DiffConfigurations(from, to *Configuration) []PropertyDiff {
+	return diffProperties(FlattenProperties(from), FlattenProperties(to))
+}
+
+// diffProperties compares two flattened property sets
+func diffProperties(from, to map[string]string) []PropertyDiff {
+	keys := map[string]bool{}
+	for key := range from {
+		keys[key] = true
+	}
+	for key := range to {
+		keys[key] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var diffs []PropertyDiff
+	for _, key := range sorted {
+		if from[key] != to[key] {
+			diffs = append(diffs, PropertyDiff{Property: key, From: from[key], To: to[key]})
+		}
+	}
+	return diffs
+}
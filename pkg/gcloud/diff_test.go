@@ -0,0 +1,109 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPropertiesUnmarshalKeepsRawSections(t *testing.T) {
+	data := `{
+		"name": "prod",
+		"is_active": true,
+		"properties": {
+			"core": {"account": "admin@example.com", "project": "prod-project"},
+			"compute": {"region": "us-central1"},
+			"billing": {"quota_project": "billing-project"},
+			"container": {"cluster": "main"}
+		}
+	}`
+
+	var config Configuration
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+
+	if config.Properties.Core.Account != "admin@example.com" || config.Properties.Compute.Region != "us-central1" {
+		t.Errorf("typed fields not decoded: %+v", config.Properties)
+	}
+	if config.Properties.Sections["billing"]["quota_project"] != "billing-project" {
+		t.Errorf("raw billing section not kept: %+v", config.Properties.Sections)
+	}
+	if config.Properties.Sections["container"]["cluster"] != "main" {
+		t.Errorf("raw container section not kept: %+v", config.Properties.Sections)
+	}
+}
+
+func TestUsageReportingDisabled(t *testing.T) {
+	tests := []struct {
+		core         string
+		wantDisabled bool
+		wantSet      bool
+	}{
+		{core: `{"disable_usage_reporting": "True"}`, wantDisabled: true, wantSet: true},
+		{core: `{"disable_usage_reporting": "False"}`, wantSet: true},
+		{core: `{"disable_usage_reporting": true}`, wantDisabled: true, wantSet: true},
+		{core: `{"account": "me@example.com"}`},
+	}
+
+	for _, tt := range tests {
+		var properties Properties
+		if err := json.Unmarshal([]byte(`{"core": `+tt.core+`}`), &properties); err != nil {
+			t.Fatalf("json.Unmarshal(%s) failed: %v", tt.core, err)
+		}
+		if properties.Core.DisableUsageReport != tt.wantDisabled {
+			t.Errorf("%s: DisableUsageReport = %v, want %v", tt.core, properties.Core.DisableUsageReport, tt.wantDisabled)
+		}
+		disabled, set := properties.UsageReportingDisabled()
+		if disabled != tt.wantDisabled || set != tt.wantSet {
+			t.Errorf("%s: UsageReportingDisabled() = %v, %v; want %v, %v", tt.core, disabled, set, tt.wantDisabled, tt.wantSet)
+		}
+	}
+}
+
+func TestFlattenPropertiesTypedFallback(t *testing.T) {
+	config := &Configuration{Properties: Properties{
+		Core:    CoreProperties{Account: "a@example.com", DisableUsageReport: true},
+		Compute: ComputeProperties{Zone: "us-central1-a"},
+	}}
+
+	got := FlattenProperties(config)
+	want := map[string]string{
+		"core/account":                 "a@example.com",
+		"core/disable_usage_reporting": "True",
+		"compute/zone":                 "us-central1-a",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FlattenProperties() = %v, want %v", got, want)
+	}
+}
+
+func TestDiffConfigurations(t *testing.T) {
+	from := &Configuration{Name: "prod", Properties: Properties{Sections: map[string]map[string]any{
+		"core":    {"account": "admin@example.com", "project": "prod-project"},
+		"billing": {"quota_project": "billing-prod"},
+		"auth":    {"impersonate_service_account": "sa@prod.iam.gserviceaccount.com"},
+	}}}
+	to := &Configuration{Name: "staging", Properties: Properties{Sections: map[string]map[string]any{
+		"core":      {"account": "admin@example.com", "project": "staging-project"},
+		"billing":   {"quota_project": "billing-prod"},
+		"container": {"use_client_certificate": false},
+	}}}
+
+	got := DiffConfigurations(from, to)
+	want := []PropertyDiff{
+		{Property: "auth/impersonate_service_account", From: "sa@prod.iam.gserviceaccount.com", To: ""},
+		{Property: "container/use_client_certificate", From: "", To: "False"},
+		{Property: "core/project", From: "prod-project", To: "staging-project"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffConfigurations() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffConfigurationsIdentical(t *testing.T) {
+	config := &Configuration{Properties: Properties{Core: CoreProperties{Project: "same"}}}
+	if diffs := DiffConfigurations(config, config); len(diffs) != 0 {
+		t.Errorf("DiffConfigurations() of identical configurations = %+v, want none", diffs)
+	}
+}
@@ -0,0 +1,26 @@
+// Package gcloud provides functionality to interact with Google Cloud SDK configurations.
+// It wraps the gcloud CLI commands and provides a convenient Go interface for managing
+// configurations, activating them, and synchronizing Application Default Credentials.
+//
+// Programs should use a Client, created with NewClient and the WithConfigRoot,
+// WithRunner, and WithCacheTTL options. Client methods never print anything;
+// failures are returned as errors that match the Err* values with errors.Is,
+// and failed gcloud commands as a *CommandError. Switching configurations
+// takes a few lines:
+//
+//	c := gcloud.NewClient()
+//	if err := c.Activate("staging"); errors.Is(err, gcloud.ErrConfigurationNotFound) {
+//		return fmt.Errorf("no staging configuration: %w", err)
+//	}
+//
+// # Compatibility
+//
+// Client, its options, the types used in its methods (Configuration,
+// Properties, PropertySetting, PropertyDiff, Runner, CommandError), and the
+// Err* values are stable within a major version of the module. The surface is
+// recorded in testdata/api.golden and checked by the tests.
+//
+// The remaining package-level functions and setters, such as SetRunner,
+// SetConfigRoot, and SetRetries, serve the gcloudctx CLI and may change in a
+// minor release.
+package gcloud
@@ -0,0 +1,186 @@
+package gcloud
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// Kinds of gcloud failures recognized in its output, for use with errors.Is
+var (
+	ErrConfigurationNotFound = errors.New("configuration not found")
+	ErrPermissionDenied      = errors.New("permission denied")
+	ErrReauthRequired        = errors.New("reauthentication required")
+	ErrNetworkUnreachable    = errors.New("network unreachable")
+	ErrInvalidPropertyValue  = errors.New("invalid property value")
+	ErrServiceUnavailable    = errors.New("service unavailable")
+)
+
+// Errors of configuration operations refused before gcloud runs, for use with errors.Is
+// A missing configuration is reported as ErrConfigurationNotFound
+var (
+	ErrConfigurationExists   = errors.New("configuration already exists")
+	ErrActiveConfiguration   = errors.New("configuration is active")
+	ErrNoActiveConfiguration = errors.New("no active configuration found")
+	// ErrNoConfigurations means gcloud has no configuration at all, as before
+	// 'gcloud init' first runs; it also matches ErrNoActiveConfiguration
+	ErrNoConfigurations = fmt.Errorf("no configurations exist: %w", ErrNoActiveConfiguration)
+)
+
+// kindError is an error of one of the kinds above with its own message
+type kindError struct {
+	kind    error
+	message string
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// kindErrorf returns an error matching kind with errors.Is and a formatted message
+func kindErrorf(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, message: fmt.Sprintf(format, args...)}
+}
+
+// errorPattern maps gcloud output to the kind of failure and how to fix it
+type errorPattern struct {
+	kind    error
+	pattern *regexp.Regexp
+	hint    string
+	// transient failures often go away when the command is run again
+	transient bool
+}
+
+// errorPatterns are checked in order; the first match classifies the output
+// Reauthentication comes before permissions since expired tokens are often
+// reported together with a denied request
+var errorPatterns = []errorPattern{
+	{
+		kind:    ErrReauthRequired,
+		pattern: regexp.MustCompile(`(?i)reauthentication (failed|required)|problem refreshing your current auth tokens|invalid_grant|you do not currently have an active account`),
+		hint:    "run 'gcloud auth login'",
+	},
+	{
+		kind:    ErrConfigurationNotFound,
+		pattern: regexp.MustCompile(`(?i)configuration \[[^\]]*\],? (it )?does not exist|configuration named \[[^\]]*\] does not exist`),
+		hint:    "run 'gcloudctx -l' to see the available configurations",
+	},
+	{
+		kind:      ErrNetworkUnreachable,
+		pattern:   regexp.MustCompile(`(?i)network is unreachable|connection refused|max retries exceeded|failed to establish a new connection|unable to find the server|name or service not known|nodename nor servname|temporary failure in name resolution|timed out`),
+		hint:      "check your network connection and proxy settings",
+		transient: true,
+	},
+	{
+		kind:      ErrServiceUnavailable,
+		pattern:   regexp.MustCompile(`(?i)service unavailable|backend ?error|internal error encountered|\b50[0234]\b|try again later|deadline exceeded`),
+		hint:      "Google Cloud did not answer; try again in a moment",
+		transient: true,
+	},
+	{
+		kind:    ErrPermissionDenied,
+		pattern: regexp.MustCompile(`(?i)permission_denied|permission denied|does not have permission|\b403\b`),
+		hint:    "check that the active account (gcloud auth list) has access",
+	},
+	{
+		kind:    ErrInvalidPropertyValue,
+		pattern: regexp.MustCompile(`(?i)has no property|invalid value for property|is not a valid|must be a valid|invalid property`),
+		hint:    "see 'gcloud topic configurations' for the available properties and values",
+	},
+}
+
+// verboseErrors includes gcloud's raw output in CommandError messages, set via SetVerboseErrors
+var verboseErrors atomic.Bool
+
+// SetVerboseErrors includes gcloud's full output in the messages of failed commands
+func SetVerboseErrors(verbose bool) {
+	verboseErrors.Store(verbose)
+}
+
+// CommandError is a failed gcloud command
+// Its message is the reason gcloud gave plus a hint when the failure is
+// recognized; the raw output is only included with SetVerboseErrors
+type CommandError struct {
+	// Command is the gcloud command without flags, e.g. "config configurations activate dev"
+	Command string
+	// Kind is one of the Err* kinds above, or nil when the failure is not recognized
+	Kind error
+	// Reason is gcloud's own error message
+	Reason string
+	// Hint suggests how to fix a recognized failure
+	Hint string
+	// Transient reports a failure that running the command again may not hit
+	Transient bool
+	// Output is gcloud's raw combined output
+	Output string
+	// Binary is the gcloud executable that ran, when known
+	Binary string
+	// Err is the error of running the process
+	Err error
+}
+
+func (e *CommandError) Error() string {
+	message := e.Reason
+	if message == "" {
+		message = "gcloud " + e.Command + " failed: " + e.Err.Error()
+	}
+	if e.Hint != "" {
+		message += " (" + e.Hint + ")"
+	}
+	if verboseErrors.Load() && e.Output != "" {
+		message += "\nOutput: " + e.Output
+	}
+	if verboseErrors.Load() && e.Binary != "" {
+		message += "\ngcloud: " + e.Binary
+	}
+	return message
+}
+
+// Unwrap exposes both the kind and the process error to errors.Is and errors.As
+func (e *CommandError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// errorPrefix matches the "ERROR: (gcloud.config.set) " prefix of gcloud errors
+var errorPrefix = regexp.MustCompile(`^ERROR:\s*(\([^)]*\)\s*)?`)
+
+// ClassifyError turns the failure of running gcloud with args into a *CommandError
+// whose message is the reason gcloud gave rather than its whole output
+func ClassifyError(args []string, output string, err error) error {
+	output = strings.TrimSpace(output)
+	e := &CommandError{
+		Command: strings.Join(commandWords(args), " "),
+		Reason:  errorReason(output),
+		Output:  output,
+		Err:     err,
+	}
+	for _, p := range errorPatterns {
+		if p.pattern.MatchString(output) {
+			e.Kind, e.Hint, e.Transient = p.kind, p.hint, p.transient
+			break
+		}
+	}
+	return e
+}
+
+// errorReason extracts gcloud's error message from its output: the line
+// starting with "ERROR:" without its prefix, or the last line when there is none
+func errorReason(output string) string {
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "ERROR:") {
+			return strings.TrimSpace(errorPrefix.ReplaceAllString(line, ""))
+		}
+	}
+	return strings.TrimSpace(lines[len(lines)-1])
+}
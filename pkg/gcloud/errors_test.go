@@ -0,0 +1,191 @@
+package gcloud
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// Output of the Google Cloud CLI for each kind of failure
+const (
+	activateMissingOutput = `ERROR: (gcloud.config.configurations.activate) Cannot activate configuration [staging], it does not exist.`
+
+	describeMissingOutput = `ERROR: (gcloud.config.configurations.describe) The configuration [staging] does not exist.`
+
+	permissionDeniedOutput = `ERROR: (gcloud.projects.describe) [ops@example.com] does not have permission to access projects instance [prod-project] (or it may not exist): The caller does not have permission. This command is authenticated as ops@example.com which is the active account specified by the [core/account] property.`
+
+	reauthOutput = `ERROR: (gcloud.projects.list) There was a problem refreshing your current auth tokens: Reauthentication failed. cannot prompt during non-interactive execution.
+Please run:
+
+  $ gcloud auth login
+
+to obtain new credentials.
+
+If you have already logged in with a different account, run:
+
+  $ gcloud config set account ACCOUNT
+
+to select an already authenticated account to use.`
+
+	networkOutput = `ERROR: gcloud crashed (TransportError): HTTPSConnectionPool(host='oauth2.googleapis.com', port=443): Max retries exceeded with url: /token (Caused by NewConnectionError('<urllib3.connection.HTTPSConnection object at 0x7f1c2a3b4c50>: Failed to establish a new connection: [Errno 101] Network is unreachable'))
+
+If you would like to report this issue, please run the following command:
+  gcloud feedback
+
+To check gcloud for common problems, please run the following command:
+  gcloud info --run-diagnostics`
+
+	serviceUnavailableOutput = `ERROR: (gcloud.projects.list) HttpError accessing <https://cloudresourcemanager.googleapis.com/v1/projects?alt=json>: response: <{'status': '503'}>, content <The service is currently unavailable.>
+This may be due to network connectivity issues. Please check your network settings, and the status of the service you are trying to reach.`
+
+	invalidPropertyOutput = `ERROR: (gcloud.config.set) Section [core] has no property [projcet].`
+
+	invalidValueOutput = `ERROR: (gcloud.config.set) Invalid value for property [compute/region]: The region "us central1" is not a valid region name.`
+
+	unknownOutput = `WARNING: Python 3.8 is no longer officially supported by the Google Cloud CLI
+Something unexpected happened`
+)
+
+func TestClassifyError(t *testing.T) {
+	exitErr := &exec.ExitError{}
+
+	tests := []struct {
+		name       string
+		args       []string
+		output     string
+		wantKind   error
+		wantReason string
+		wantHint   string
+		transient  bool
+	}{
+		{
+			name:       "activate missing configuration",
+			args:       []string{"config", "configurations", "activate", "staging"},
+			output:     activateMissingOutput,
+			wantKind:   ErrConfigurationNotFound,
+			wantReason: "Cannot activate configuration [staging], it does not exist.",
+			wantHint:   "gcloudctx -l",
+		},
+		{
+			name:       "describe missing configuration",
+			args:       []string{"config", "configurations", "describe", "staging", "--format=json"},
+			output:     describeMissingOutput,
+			wantKind:   ErrConfigurationNotFound,
+			wantReason: "The configuration [staging] does not exist.",
+			wantHint:   "gcloudctx -l",
+		},
+		{
+			name:       "permission denied",
+			args:       []string{"projects", "describe", "prod-project"},
+			output:     permissionDeniedOutput,
+			wantKind:   ErrPermissionDenied,
+			wantReason: "[ops@example.com] does not have permission to access projects instance [prod-project]",
+			wantHint:   "gcloud auth list",
+		},
+		{
+			name:       "reauthentication required",
+			args:       []string{"projects", "list", "--format=json"},
+			output:     reauthOutput,
+			wantKind:   ErrReauthRequired,
+			wantReason: "There was a problem refreshing your current auth tokens: Reauthentication failed.",
+			wantHint:   "gcloud auth login",
+		},
+		{
+			name:       "network unreachable",
+			args:       []string{"projects", "list", "--format=json"},
+			output:     networkOutput,
+			wantKind:   ErrNetworkUnreachable,
+			wantReason: "gcloud crashed (TransportError)",
+			wantHint:   "network connection",
+			transient:  true,
+		},
+		{
+			name:       "service unavailable",
+			args:       []string{"projects", "list", "--format=json"},
+			output:     serviceUnavailableOutput,
+			wantKind:   ErrServiceUnavailable,
+			wantReason: "HttpError accessing",
+			wantHint:   "try again",
+			transient:  true,
+		},
+		{
+			name:       "unknown property",
+			args:       []string{"config", "set", "projcet", "p", "--configuration", "dev"},
+			output:     invalidPropertyOutput,
+			wantKind:   ErrInvalidPropertyValue,
+			wantReason: "Section [core] has no property [projcet].",
+			wantHint:   "gcloud topic configurations",
+		},
+		{
+			name:       "invalid property value",
+			args:       []string{"config", "set", "compute/region", "us central1"},
+			output:     invalidValueOutput,
+			wantKind:   ErrInvalidPropertyValue,
+			wantReason: "Invalid value for property [compute/region]",
+			wantHint:   "gcloud topic configurations",
+		},
+		{
+			name:       "unrecognized",
+			args:       []string{"info"},
+			output:     unknownOutput,
+			wantReason: "Something unexpected happened",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ClassifyError(tt.args, tt.output, exitErr)
+
+			var commandErr *CommandError
+			if !errors.As(err, &commandErr) {
+				t.Fatalf("ClassifyError() = %T, want *CommandError", err)
+			}
+			if commandErr.Kind != tt.wantKind {
+				t.Errorf("Kind = %v, want %v", commandErr.Kind, tt.wantKind)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("errors.Is(err, %v) = false", tt.wantKind)
+			}
+			if !errors.Is(err, exitErr) {
+				t.Error("the process error is not wrapped")
+			}
+			if !strings.HasPrefix(commandErr.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, want prefix %q", commandErr.Reason, tt.wantReason)
+			}
+			if !strings.Contains(commandErr.Hint, tt.wantHint) {
+				t.Errorf("Hint = %q, want it to mention %q", commandErr.Hint, tt.wantHint)
+			}
+			if commandErr.Transient != tt.transient {
+				t.Errorf("Transient = %v, want %v", commandErr.Transient, tt.transient)
+			}
+			if commandErr.Output != strings.TrimSpace(tt.output) {
+				t.Error("raw output was not kept")
+			}
+			if strings.Contains(err.Error(), "\n") {
+				t.Errorf("message spans several lines: %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestCommandErrorVerbose(t *testing.T) {
+	defer SetVerboseErrors(false)
+
+	err := ClassifyError([]string{"projects", "list"}, reauthOutput, &exec.ExitError{})
+	if strings.Contains(err.Error(), "$ gcloud config set account") {
+		t.Errorf("raw output shown without verbose errors: %q", err.Error())
+	}
+
+	SetVerboseErrors(true)
+	if !strings.Contains(err.Error(), "\nOutput: "+strings.TrimSpace(reauthOutput)) {
+		t.Errorf("verbose message = %q, want the raw output", err.Error())
+	}
+}
+
+func TestCommandErrorWithoutOutput(t *testing.T) {
+	err := ClassifyError([]string{"config", "configurations", "activate", "dev", "--quiet"}, "", errors.New("exit status 1"))
+	if got, want := err.Error(), "gcloud config configurations activate dev failed: exit status 1"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
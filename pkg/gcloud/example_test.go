@@ -0,0 +1,49 @@
+package gcloud_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// fakeRunner stands in for the gcloud binary; real programs leave out WithRunner
+type fakeRunner struct {
+	active string
+}
+
+func (f *fakeRunner) Run(args ...string) (string, error) {
+	if strings.Join(args[:3], " ") == "config configurations list" {
+		return fmt.Sprintf(`[{"name": "dev", "is_active": %t}, {"name": "prod", "is_active": %t}]`, f.active == "dev", f.active == "prod"), nil
+	}
+	return "", nil
+}
+
+func (f *fakeRunner) RunQuiet(args ...string) error {
+	f.active = args[len(args)-1]
+	return nil
+}
+
+func ExampleClient() {
+	c := gcloud.NewClient(gcloud.WithRunner(&fakeRunner{active: "dev"}))
+
+	if err := c.Activate("prod"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	active, err := c.Active()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("active:", active.Name)
+
+	if err := c.Delete("prod"); errors.Is(err, gcloud.ErrActiveConfiguration) {
+		fmt.Println("refused:", err)
+	}
+
+	// Output:
+	// active: prod
+	// refused: cannot delete active configuration "prod"
+}
@@ -4,48 +4,121 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"sync"
 )
 
-// CheckGcloudInstalled checks if gcloud CLI is installed
-func CheckGcloudInstalled() error {
-	_, err := exec.LookPath("gcloud")
-	if err != nil {
-		return fmt.Errorf("gcloud CLI is not installed or not in PATH")
+// Runner executes gcloud commands
+// The default runner shells out to the gcloud binary; tests install a fake with SetRunner
+type Runner interface {
+	// Run executes a gcloud command and returns its trimmed combined output
+	Run(args ...string) (string, error)
+	// RunQuiet executes a gcloud command, discarding its output on success
+	RunQuiet(args ...string) error
+}
+
+var (
+	runnerMu      sync.RWMutex
+	currentRunner Runner = execRunner{}
+)
+
+// SetRunner replaces the runner used for gcloud commands and returns a function restoring the previous one
+func SetRunner(r Runner) (restore func()) {
+	runnerMu.Lock()
+	previous := currentRunner
+	currentRunner = r
+	runnerMu.Unlock()
+
+	return func() {
+		runnerMu.Lock()
+		currentRunner = previous
+		runnerMu.Unlock()
 	}
-	return nil
 }
 
-// RunGcloudCommand executes a gcloud command with the given arguments
-func RunGcloudCommand(args ...string) (string, error) {
-	if err := CheckGcloudInstalled(); err != nil {
-		return "", err
+// runner returns the runner used for gcloud commands
+func runner() Runner {
+	runnerMu.RLock()
+	defer runnerMu.RUnlock()
+	return currentRunner
+}
+
+// gcloudExecutableNames lists the names the gcloud executable may have on goos
+// On Windows the SDK installs gcloud.cmd, which a bare "gcloud" only resolves
+// to when PATHEXT includes .CMD; some shells (e.g. Git Bash) leave it out
+func gcloudExecutableNames(goos string) []string {
+	if goos == "windows" {
+		return []string{"gcloud", "gcloud.cmd", "gcloud.exe"}
 	}
+	return []string{"gcloud"}
+}
 
-	cmd := exec.Command("gcloud", args...)
-	output, err := cmd.CombinedOutput()
+// findGcloud returns the first gcloud executable name lookPath resolves on goos
+func findGcloud(goos string, lookPath func(string) (string, error)) (string, error) {
+	for _, name := range gcloudExecutableNames(goos) {
+		if path, err := lookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("gcloud CLI is not installed or not in PATH")
+}
+
+// GcloudPath returns the path of the gcloud executable, $GCLOUDCTX_GCLOUD_PATH
+// or the first one in PATH (see ResolveGcloudBinary)
+// Every gcloud command is run through this path so the check and the run agree
+func GcloudPath() (string, error) {
+	bin, err := ResolveGcloudBinary()
 	if err != nil {
-		return "", fmt.Errorf("failed to run gcloud command: %w\nOutput: %s", err, string(output))
+		return "", err
 	}
+	return bin.Path, nil
+}
 
-	return strings.TrimSpace(string(output)), nil
+// CheckGcloudInstalled checks if gcloud CLI is installed
+func CheckGcloudInstalled() error {
+	_, err := GcloudPath()
+	return err
+}
+
+// RunGcloudCommand executes a gcloud command with the given arguments
+// In read-only mode, commands that change gcloud's configuration are refused;
+// read-only commands failing transiently are retried (see SetRetries)
+func RunGcloudCommand(args ...string) (string, error) {
+	return defaultClient.run(args...)
 }
 
 // RunGcloudCommandQuiet executes a gcloud command and suppresses output
-// On error, the stderr output is included in the error message for debugging
+// On error, gcloud's reason is returned as a *CommandError
 func RunGcloudCommandQuiet(args ...string) error {
-	if err := CheckGcloudInstalled(); err != nil {
-		return err
+	return defaultClient.runQuiet(args...)
+}
+
+// execRunner runs commands with the gcloud binary of GcloudPath
+// on configRoot, or the directory set with SetConfigRoot when it is empty
+type execRunner struct {
+	configRoot string
+}
+
+func (r execRunner) Run(args ...string) (string, error) {
+	gcloudPath, err := GcloudPath()
+	if err != nil {
+		return "", err
 	}
 
-	cmd := exec.Command("gcloud", args...)
+	cmd := exec.Command(gcloudPath, args...)
+	cmd.Env = commandEnv(r.configRoot)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		// Include stderr in error message for better debugging
-		if len(output) > 0 {
-			return fmt.Errorf("failed to run gcloud command: %w\nOutput: %s", err, strings.TrimSpace(string(output)))
+		err = ClassifyError(args, string(output), err)
+		if cmdErr, ok := err.(*CommandError); ok {
+			cmdErr.Binary = gcloudPath
 		}
-		return fmt.Errorf("failed to run gcloud command: %w", err)
+		return "", err
 	}
 
-	return nil
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (r execRunner) RunQuiet(args ...string) error {
+	_, err := r.Run(args...)
+	return err
 }
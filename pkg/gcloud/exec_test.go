@@ -1,6 +1,8 @@
 package gcloud
 
 import (
+	"os/exec"
+	"slices"
 	"testing"
 )
 
@@ -42,3 +44,45 @@ func TestRunGcloudCommandInvalid(t *testing.T) {
 		t.Error("Expected error for invalid command, got nil")
 	}
 }
+
+func TestFindGcloud(t *testing.T) {
+	// lookPath resolving only the names in found
+	lookPathFor := func(found ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			if slices.Contains(found, name) {
+				return "/sdk/bin/" + name, nil
+			}
+			return "", exec.ErrNotFound
+		}
+	}
+
+	tests := []struct {
+		name    string
+		goos    string
+		found   []string
+		want    string
+		wantErr bool
+	}{
+		{name: "unix", goos: "linux", found: []string{"gcloud"}, want: "/sdk/bin/gcloud"},
+		{name: "unix ignores windows names", goos: "darwin", found: []string{"gcloud.cmd"}, wantErr: true},
+		{name: "windows with PATHEXT", goos: "windows", found: []string{"gcloud", "gcloud.cmd"}, want: "/sdk/bin/gcloud"},
+		{name: "windows without PATHEXT", goos: "windows", found: []string{"gcloud.cmd"}, want: "/sdk/bin/gcloud.cmd"},
+		{name: "windows exe", goos: "windows", found: []string{"gcloud.exe"}, want: "/sdk/bin/gcloud.exe"},
+		{name: "not installed", goos: "windows", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findGcloud(tt.goos, lookPathFor(tt.found...))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("findGcloud() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("findGcloud() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
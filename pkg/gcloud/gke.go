@@ -0,0 +1,58 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Cluster is a GKE cluster as returned by gcloud container clusters list
+type Cluster struct {
+	Name string `json:"name"`
+	// Location is the region or zone of the cluster
+	Location string `json:"location"`
+}
+
+// String returns the cluster as "<name>@<location>", the form ParseCluster reads
+func (c Cluster) String() string {
+	return c.Name + "@" + c.Location
+}
+
+// ParseCluster parses a cluster given as "<name>@<location>"
+func ParseCluster(s string) (Cluster, error) {
+	name, location, found := strings.Cut(strings.TrimSpace(s), "@")
+	if !found || name == "" || location == "" || strings.Contains(location, "@") {
+		return Cluster{}, fmt.Errorf("invalid cluster %q (want <cluster>@<location>, e.g. main@europe-west1)", s)
+	}
+	return Cluster{Name: name, Location: location}, nil
+}
+
+// ListClusters returns the GKE clusters of project
+// This call goes over the network and can take several seconds
+func ListClusters(project string) ([]Cluster, error) {
+	output, err := RunGcloudCommand("container", "clusters", "list", "--project", project, "--format=json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GKE clusters of %q: %w", project, err)
+	}
+
+	var clusters []Cluster
+	if err := json.Unmarshal([]byte(output), &clusters); err != nil {
+		return nil, fmt.Errorf("failed to parse GKE clusters: %w", err)
+	}
+	return clusters, nil
+}
+
+// ClusterCredentialsArgs returns the gcloud arguments fetching kubeconfig
+// credentials for cluster in project
+func ClusterCredentialsArgs(cluster Cluster, project string) []string {
+	return []string{"container", "clusters", "get-credentials", cluster.Name, "--location", cluster.Location, "--project", project}
+}
+
+// GetClusterCredentials fetches kubeconfig credentials for cluster in project,
+// making it kubectl's current context
+func GetClusterCredentials(cluster Cluster, project string) error {
+	if err := RunGcloudCommandQuiet(ClusterCredentialsArgs(cluster, project)...); err != nil {
+		return fmt.Errorf("failed to get credentials for GKE cluster %s: %w", cluster, err)
+	}
+	return nil
+}
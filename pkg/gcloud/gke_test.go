@@ -0,0 +1,28 @@
+package gcloud
+
+import "testing"
+
+func TestParseCluster(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Cluster
+		wantErr bool
+	}{
+		{input: "main@europe-west1", want: Cluster{Name: "main", Location: "europe-west1"}},
+		{input: " main@europe-west1-b ", want: Cluster{Name: "main", Location: "europe-west1-b"}},
+		{input: "main", wantErr: true},
+		{input: "@europe-west1", wantErr: true},
+		{input: "main@", wantErr: true},
+		{input: "main@europe@west1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCluster(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseCluster(%q) = %+v, %v; want %+v, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+		if err == nil && got.String() != "main@"+tt.want.Location {
+			t.Errorf("ParseCluster(%q).String() = %q", tt.input, got.String())
+		}
+	}
+}
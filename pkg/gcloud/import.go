@@ -0,0 +1,30 @@
+package gcloud
+
+// PropertySetting is a single property assignment, keyed by "section/name" (e.g. "core/project")
+type PropertySetting struct {
+	Property string
+	Value    string
+}
+
+// SetProperties applies property settings to a configuration in order
+func SetProperties(configName string, settings []PropertySetting) error {
+	return defaultClient.SetProperties(configName, settings)
+}
+
+// VerifyProperties checks that every setting is present on the named configuration
+func VerifyProperties(configName string, settings []PropertySetting) error {
+	return defaultClient.VerifyProperties(configName, settings)
+}
+
+// ImportConfiguration creates a configuration with the given properties
+// A new configuration is created, populated, and verified in place; it is removed again on failure
+func ImportConfiguration(name string, settings []PropertySetting) error {
+	return defaultClient.Import(name, settings)
+}
+
+// OverwriteConfiguration replaces the properties of an existing configuration with the given ones
+// The configuration is changed in place under a snapshot, so a failed import
+// leaves the existing configuration exactly as it was
+func OverwriteConfiguration(name string, settings []PropertySetting) error {
+	return defaultClient.Overwrite(name, settings)
+}
@@ -0,0 +1,71 @@
+package gcloud
+
+import (
+	"maps"
+	"testing"
+)
+
+var importSettings = []PropertySetting{
+	{Property: "core/account", Value: "new@example.com"},
+	{Property: "core/project", Value: "new-project"},
+	{Property: "compute/region", Value: "europe-west1"},
+}
+
+func TestImportConfiguration(t *testing.T) {
+	fake := newFakeGcloud(t)
+	fake.add("default", map[string]string{})
+	fake.active = "default"
+
+	if err := ImportConfiguration("imported", importSettings); err != nil {
+		t.Fatalf("ImportConfiguration failed: %v", err)
+	}
+
+	want := map[string]string{"core/account": "new@example.com", "core/project": "new-project", "compute/region": "europe-west1"}
+	if !maps.Equal(fake.configs["imported"], want) {
+		t.Errorf("imported properties = %v, want %v", fake.configs["imported"], want)
+	}
+}
+
+func TestImportConfigurationFailureCleansUp(t *testing.T) {
+	fake := newFakeGcloud(t)
+	fake.add("default", map[string]string{})
+	fake.active = "default"
+	fake.failSet = "compute/region"
+
+	if err := ImportConfiguration("imported", importSettings); err == nil {
+		t.Fatal("expected ImportConfiguration to fail")
+	}
+	if _, ok := fake.configs["imported"]; ok {
+		t.Error("partially imported configuration was not cleaned up")
+	}
+}
+
+func TestOverwriteConfiguration(t *testing.T) {
+	for _, active := range []bool{false, true} {
+		name := "inactive"
+		if active {
+			name = "active"
+		}
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeGcloud(t)
+			fake.add("default", map[string]string{})
+			fake.add("prod", map[string]string{"core/account": "old@example.com", "core/project": "old-project", "compute/zone": "us-east1-b"})
+			fake.active = "default"
+			if active {
+				fake.active = "prod"
+			}
+
+			if err := OverwriteConfiguration("prod", importSettings); err != nil {
+				t.Fatalf("OverwriteConfiguration failed: %v", err)
+			}
+
+			want := map[string]string{"core/account": "new@example.com", "core/project": "new-project", "compute/region": "europe-west1"}
+			if !maps.Equal(fake.configs["prod"], want) {
+				t.Errorf("prod properties = %v, want %v", fake.configs["prod"], want)
+			}
+			if active && fake.active != "prod" {
+				t.Errorf("active configuration = %q, want prod", fake.active)
+			}
+		})
+	}
+}
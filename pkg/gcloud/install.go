@@ -0,0 +1,204 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/cache"
+)
+
+// EnvGcloudPath names the gcloud executable to run instead of the first one in PATH,
+// for machines with several Cloud SDK installs such as a snap package and a tarball
+const EnvGcloudPath = "GCLOUDCTX_GCLOUD_PATH"
+
+// GcloudBinary is the gcloud executable gcloudctx runs
+type GcloudBinary struct {
+	// Path is the executable every gcloud command runs
+	Path string
+	// FromEnv reports that $GCLOUDCTX_GCLOUD_PATH chose it rather than PATH
+	FromEnv bool
+	// Others lists the other gcloud executables in PATH; each may be another
+	// SDK install keeping its configurations elsewhere
+	Others []string
+}
+
+// ResolveGcloudBinary returns the gcloud executable every command runs:
+// $GCLOUDCTX_GCLOUD_PATH when set, otherwise the first gcloud in PATH
+func ResolveGcloudBinary() (*GcloudBinary, error) {
+	return resolveGcloudBinary(runtime.GOOS, os.Getenv, exec.LookPath)
+}
+
+// resolveGcloudBinary is ResolveGcloudBinary for the given GOOS, environment and PATH lookup
+func resolveGcloudBinary(goos string, getenv func(string) string, lookPath func(string) (string, error)) (*GcloudBinary, error) {
+	bin := &GcloudBinary{}
+	if override := getenv(EnvGcloudPath); override != "" {
+		path, err := lookPath(override)
+		if err != nil {
+			return nil, fmt.Errorf("$%s does not name a gcloud executable: %w", EnvGcloudPath, err)
+		}
+		bin.Path, bin.FromEnv = path, true
+	} else {
+		path, err := findGcloud(goos, lookPath)
+		if err != nil {
+			return nil, err
+		}
+		bin.Path = path
+	}
+
+	for _, path := range gcloudsInPath(goos, filepath.SplitList(getenv("PATH"))) {
+		if !sameFile(path, bin.Path) && !slices.ContainsFunc(bin.Others, func(other string) bool { return sameFile(path, other) }) {
+			bin.Others = append(bin.Others, path)
+		}
+	}
+	return bin, nil
+}
+
+// gcloudsInPath returns every gcloud executable in the directories of PATH, in order
+func gcloudsInPath(goos string, dirs []string) []string {
+	var found []string
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		for _, name := range gcloudExecutableNames(goos) {
+			path := filepath.Join(dir, name)
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if goos != "windows" && info.Mode().Perm()&0o111 == 0 {
+				continue
+			}
+			found = append(found, path)
+		}
+	}
+	return found
+}
+
+// sameFile reports whether two paths are the same executable, following
+// symlinks such as /usr/bin/gcloud pointing into the SDK
+func sameFile(a, b string) bool {
+	if a == b {
+		return true
+	}
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// installInfo is the part of 'gcloud info --format=json' gcloudctx reads
+type installInfo struct {
+	Config struct {
+		Paths struct {
+			GlobalConfigDir string `json:"global_config_dir"`
+		} `json:"paths"`
+	} `json:"config"`
+}
+
+// InstalledConfigDir asks gcloud which configuration directory it uses, which
+// differs from ~/.config/gcloud for a snap-packaged SDK among others
+// It runs 'gcloud info', which takes a second or more; ConfigDir caches the answer
+func InstalledConfigDir() (string, error) {
+	output, err := RunGcloudCommand("info", "--format=json")
+	if err != nil {
+		return "", fmt.Errorf("failed to run gcloud info: %w", err)
+	}
+
+	var info installInfo
+	if err := json.Unmarshal([]byte(output), &info); err != nil {
+		return "", fmt.Errorf("failed to parse gcloud info: %w", err)
+	}
+	if info.Config.Paths.GlobalConfigDir == "" {
+		return "", fmt.Errorf("gcloud info did not report a configuration directory")
+	}
+	return info.Config.Paths.GlobalConfigDir, nil
+}
+
+// installedConfigDirCacheKey is the cache entry of the directory gcloud reported
+const installedConfigDirCacheKey = "gcloud-config-dir"
+
+// installedConfigDirTTL is how long the directory gcloud reported is reused
+// The entry is dropped earlier when the gcloud binary changes, as on an SDK update
+const installedConfigDirTTL = 7 * 24 * time.Hour
+
+// installedConfigDirEntry is the cached answer of InstalledConfigDir
+type installedConfigDirEntry struct {
+	Binary    string    `json:"binary"`
+	ModTime   time.Time `json:"mod_time"`
+	Home      string    `json:"home"`
+	ConfigDir string    `json:"config_dir"`
+}
+
+var (
+	installedConfigDirMu sync.Mutex
+	// installedConfigDirs remembers the answers of this process by binary and home
+	installedConfigDirs = map[installedConfigDirKey]string{}
+)
+
+// installedConfigDirKey identifies a gcloud binary run for a home directory
+type installedConfigDirKey struct {
+	binary string
+	home   string
+}
+
+// cachedInstalledConfigDir returns InstalledConfigDir for the current gcloud
+// binary and home, asking gcloud only when the cached answer is stale
+// ok is false when there is no gcloud to ask or it cannot answer
+func cachedInstalledConfigDir(home string) (dir string, ok bool) {
+	bin, err := ResolveGcloudBinary()
+	if err != nil {
+		return "", false
+	}
+	stat, err := os.Stat(bin.Path)
+	if err != nil {
+		return "", false
+	}
+
+	installedConfigDirMu.Lock()
+	defer installedConfigDirMu.Unlock()
+
+	key := installedConfigDirKey{binary: bin.Path, home: home}
+	if dir, ok := installedConfigDirs[key]; ok {
+		return dir, true
+	}
+
+	var entry installedConfigDirEntry
+	found, err := cache.Load(installedConfigDirCacheKey, installedConfigDirTTL, &entry)
+	if err == nil && found && entry.Binary == bin.Path && entry.Home == home && entry.ModTime.Equal(stat.ModTime()) {
+		installedConfigDirs[key] = entry.ConfigDir
+		return entry.ConfigDir, true
+	}
+
+	dir, err = InstalledConfigDir()
+	if err != nil {
+		return "", false
+	}
+	installedConfigDirs[key] = dir
+	_ = cache.Save(installedConfigDirCacheKey, installedConfigDirEntry{Binary: bin.Path, ModTime: stat.ModTime(), Home: home, ConfigDir: dir})
+	return dir, true
+}
+
+// SDKVersion returns the version of the Google Cloud SDK gcloud belongs to
+func SDKVersion() (string, error) {
+	output, err := RunGcloudCommand("version", "--format=json")
+	if err != nil {
+		return "", fmt.Errorf("failed to run gcloud version: %w", err)
+	}
+
+	var components map[string]any
+	if err := json.Unmarshal([]byte(output), &components); err != nil {
+		return "", fmt.Errorf("failed to parse gcloud version: %w", err)
+	}
+	version, ok := components["Google Cloud SDK"].(string)
+	if !ok || version == "" {
+		return "", fmt.Errorf("gcloud version did not report the SDK version")
+	}
+	return version, nil
+}
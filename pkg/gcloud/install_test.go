@@ -0,0 +1,192 @@
+package gcloud
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeGcloudStub writes an executable gcloud into dir and returns its path
+func writeGcloudStub(t *testing.T, dir string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "gcloud")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// resetInstalledConfigDirs forgets the directories gcloud reported to this process
+func resetInstalledConfigDirs(t *testing.T) {
+	t.Helper()
+	forget := func() {
+		installedConfigDirMu.Lock()
+		defer installedConfigDirMu.Unlock()
+		installedConfigDirs = map[installedConfigDirKey]string{}
+	}
+	forget()
+	t.Cleanup(forget)
+}
+
+func TestResolveGcloudBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("gcloud stubs are shell scripts")
+	}
+	root := t.TempDir()
+	first := writeGcloudStub(t, filepath.Join(root, "first"))
+	snap := writeGcloudStub(t, filepath.Join(root, "snap"))
+	// A symlink to the first install, as /usr/bin/gcloud often is
+	linkDir := filepath.Join(root, "link")
+	if err := os.MkdirAll(linkDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(first, filepath.Join(linkDir, "gcloud")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", strings.Join([]string{filepath.Dir(first), linkDir, filepath.Dir(snap)}, string(os.PathListSeparator)))
+
+	tests := []struct {
+		name        string
+		override    string
+		want        string
+		wantFromEnv bool
+		wantOthers  []string
+		wantErr     bool
+	}{
+		{name: "first in PATH", want: first, wantOthers: []string{snap}},
+		{name: "override", override: snap, want: snap, wantFromEnv: true, wantOthers: []string{first}},
+		{name: "override missing", override: filepath.Join(root, "missing", "gcloud"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvGcloudPath, tt.override)
+
+			got, err := ResolveGcloudBinary()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ResolveGcloudBinary() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveGcloudBinary() error = %v", err)
+			}
+			if got.Path != tt.want || got.FromEnv != tt.wantFromEnv || !slices.Equal(got.Others, tt.wantOthers) {
+				t.Errorf("ResolveGcloudBinary() = %+v, want path %q, from env %v, others %q", got, tt.want, tt.wantFromEnv, tt.wantOthers)
+			}
+		})
+	}
+}
+
+func TestInstalledConfigDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    string
+		want    string
+		wantErr bool
+	}{
+		{name: "snap", info: `{"config": {"paths": {"global_config_dir": "/home/me/snap/google-cloud-cli/common/.config/gcloud"}}}`, want: "/home/me/snap/google-cloud-cli/common/.config/gcloud"},
+		{name: "missing", info: `{"config": {}}`, wantErr: true},
+		{name: "invalid", info: `not json`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(SetRunner(&fakeRunner{outputs: map[string]string{"info --format=json": tt.info}}))
+
+			got, err := InstalledConfigDir()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("InstalledConfigDir() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("InstalledConfigDir() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigDirFollowsInstalledConfigDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("gcloud stubs are shell scripts")
+	}
+	root := t.TempDir()
+	gcloudPath := writeGcloudStub(t, filepath.Join(root, "bin"))
+	t.Setenv("PATH", filepath.Dir(gcloudPath))
+	t.Setenv(EnvGcloudPath, "")
+	t.Setenv("HOME", filepath.Join(root, "home"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(root, "cache"))
+	t.Setenv(EnvConfigDir, "")
+	if err := os.Unsetenv(EnvConfigDir); err != nil {
+		t.Fatal(err)
+	}
+	resetInstalledConfigDirs(t)
+
+	const snapDir = "/snap/gcloud/config"
+	fake := &fakeRunner{outputs: map[string]string{
+		"info --format=json": `{"config": {"paths": {"global_config_dir": "` + snapDir + `"}}}`,
+	}}
+	t.Cleanup(SetRunner(fake))
+
+	configDir := func() string {
+		t.Helper()
+		dir, err := ConfigDir()
+		if err != nil {
+			t.Fatalf("ConfigDir() error = %v", err)
+		}
+		return dir
+	}
+
+	if got := configDir(); got != snapDir {
+		t.Errorf("ConfigDir() = %q, want the directory gcloud reported %q", got, snapDir)
+	}
+	if got := configDir(); got != snapDir || len(fake.calls) != 1 {
+		t.Errorf("ConfigDir() = %q after %d gcloud runs, want %q from one run", got, len(fake.calls), snapDir)
+	}
+
+	// A new process reads the answer from the disk cache
+	resetInstalledConfigDirs(t)
+	if got := configDir(); got != snapDir || len(fake.calls) != 1 {
+		t.Errorf("ConfigDir() = %q after %d gcloud runs, want %q from the cache", got, len(fake.calls), snapDir)
+	}
+
+	// An updated gcloud is asked again
+	resetInstalledConfigDirs(t)
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(gcloudPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if configDir(); len(fake.calls) != 2 {
+		t.Errorf("gcloud ran %d times, want it asked again after the binary changed", len(fake.calls))
+	}
+
+	// An explicit directory wins without asking gcloud
+	t.Setenv(EnvConfigDir, "/explicit")
+	if got := configDir(); got != "/explicit" || len(fake.calls) != 2 {
+		t.Errorf("ConfigDir() = %q after %d gcloud runs, want $%s", got, len(fake.calls), EnvConfigDir)
+	}
+}
+
+func TestCommandErrorVerboseBinary(t *testing.T) {
+	defer SetVerboseErrors(false)
+
+	err := &CommandError{Command: "gcloud config list", Reason: "failed", Output: "ERROR: failed", Binary: "/snap/bin/gcloud"}
+	if strings.Contains(err.Error(), "/snap/bin/gcloud") {
+		t.Errorf("message = %q, want the binary only when verbose", err.Error())
+	}
+
+	SetVerboseErrors(true)
+	if !strings.Contains(err.Error(), "\ngcloud: /snap/bin/gcloud") {
+		t.Errorf("verbose message = %q, want the binary", err.Error())
+	}
+}
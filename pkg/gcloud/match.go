@@ -0,0 +1,23 @@
+package gcloud
+
+import (
+	"fmt"
+	"path"
+)
+
+// MatchConfigurations returns the configurations whose name matches the glob
+// pattern, in order: * matches any run of characters, ? any one character and
+// [...] one of a class, as in shell globs. A pattern without them matches the
+// configuration of that name only.
+func MatchConfigurations(configs []Configuration, pattern string) ([]Configuration, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	var matches []Configuration
+	for _, config := range configs {
+		if ok, _ := path.Match(pattern, config.Name); ok {
+			matches = append(matches, config)
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,54 @@
+package gcloud
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchConfigurations(t *testing.T) {
+	configs := []Configuration{{Name: "dev"}, {Name: "prod"}, {Name: "prod-eu"}, {Name: "staging-eu"}}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"prod*", []string{"prod", "prod-eu"}},
+		{"*-eu", []string{"prod-eu", "staging-eu"}},
+		{"prod", []string{"prod"}},
+		{"pro", nil},
+		{"?ev", []string{"dev"}},
+		{"[ds]*", []string{"dev", "staging-eu"}},
+		{"*", []string{"dev", "prod", "prod-eu", "staging-eu"}},
+	}
+	for _, tt := range tests {
+		matches, err := MatchConfigurations(configs, tt.pattern)
+		if err != nil {
+			t.Errorf("MatchConfigurations(%q): %v", tt.pattern, err)
+			continue
+		}
+		var got []string
+		for _, config := range matches {
+			got = append(got, config.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MatchConfigurations(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+
+	if _, err := MatchConfigurations(configs, "prod["); err == nil {
+		t.Error("MatchConfigurations(\"prod[\") succeeded, want an invalid pattern error")
+	}
+}
+
+func TestParseConfigurationsSortsByName(t *testing.T) {
+	configs, err := ParseConfigurations(`[{"name": "prod"}, {"name": "dev"}, {"name": "acme"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, config := range configs {
+		names = append(names, config.Name)
+	}
+	if want := []string{"acme", "dev", "prod"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+}
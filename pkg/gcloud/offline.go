@@ -0,0 +1,63 @@
+package gcloud
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// EnvOffline turns on offline mode when set to a true value such as "1"
+const EnvOffline = "GCLOUDCTX_OFFLINE"
+
+// ErrOffline is returned for operations that need the network in offline mode
+var ErrOffline = errors.New("offline mode")
+
+// offlineSetting is the --offline flag or offline setting, set via SetOffline
+var offlineSetting atomic.Bool
+
+// SetOffline turns offline mode on or off; GCLOUDCTX_OFFLINE turns it on regardless
+func SetOffline(offline bool) {
+	offlineSetting.Store(offline)
+}
+
+// Offline reports whether offline mode is on
+func Offline() bool {
+	if offlineSetting.Load() {
+		return true
+	}
+	enabled, err := strconv.ParseBool(os.Getenv(EnvOffline))
+	return err == nil && enabled
+}
+
+// networkCommands are the gcloud commands that talk to Google Cloud; in
+// offline mode they are refused instead of waiting for a connection to time out
+var networkCommands = [][]string{
+	{"auth", "application-default", "login"},
+	{"auth", "application-default", "print-access-token"},
+	{"auth", "login"},
+	{"auth", "print-access-token"},
+	{"config", "config-helper"},
+	{"container"},
+	{"projects"},
+}
+
+// NeedsNetwork reports whether running gcloud with args talks to Google Cloud
+func NeedsNetwork(args []string) bool {
+	words := commandWords(args)
+	return slices.ContainsFunc(networkCommands, func(command []string) bool {
+		return len(words) >= len(command) && slices.Equal(words[:len(command)], command)
+	})
+}
+
+// CheckNetwork returns an error wrapping ErrOffline when running gcloud with
+// args needs the network in offline mode
+func CheckNetwork(args []string) error {
+	if !Offline() || !NeedsNetwork(args) {
+		return nil
+	}
+	return fmt.Errorf("%w: 'gcloud %s' needs the network (unset %s or drop --offline to run it)", ErrOffline, strings.Join(commandWords(args), " "), EnvOffline)
+}
@@ -0,0 +1,131 @@
+package gcloud
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestOffline(t *testing.T) {
+	defer SetOffline(false)
+
+	tests := []struct {
+		name string
+		env  string
+		flag bool
+		want bool
+	}{
+		{"off", "", false, false},
+		{"env", "1", false, true},
+		{"env zero", "0", false, false},
+		{"flag", "", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvOffline, tt.env)
+			SetOffline(tt.flag)
+			if got := Offline(); got != tt.want {
+				t.Errorf("Offline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsNetwork(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"projects", "list", "--format=json"}, true},
+		{[]string{"container", "clusters", "get-credentials", "main"}, true},
+		{[]string{"config", "config-helper", "--format=json"}, true},
+		{[]string{"auth", "application-default", "login"}, true},
+		{[]string{"auth", "list", "--format=json"}, false},
+		{[]string{"config", "configurations", "list", "--format=json"}, false},
+		{[]string{"config", "set", "project", "p"}, false},
+		{[]string{"info", "--format=value(config.paths.global_config_dir)"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := NeedsNetwork(tt.args); got != tt.want {
+			t.Errorf("NeedsNetwork(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestOfflineRefusesNetworkCommands(t *testing.T) {
+	t.Setenv(EnvOffline, "1")
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"projects", func() error { _, err := ListProjects(); return err }},
+		{"clusters", func() error { _, err := ListClusters("p"); return err }},
+		{"token", func() error { _, err := AccessTokenExpiry(false); return err }},
+		{"adc", func() error { return SyncADC("", false) }},
+		{"login", Login},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := installFakeRunner(t, fakeConfigurationsJSON)
+
+			err := tt.run()
+			if !errors.Is(err, ErrOffline) {
+				t.Fatalf("error = %v, want ErrOffline", err)
+			}
+			if len(fake.calls) != 0 {
+				t.Errorf("gcloud commands run offline: %q", fake.calls)
+			}
+		})
+	}
+}
+
+func TestOfflineListReadsFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, dir)
+	t.Setenv(EnvActiveConfigName, "")
+	t.Setenv(EnvOffline, "1")
+	fake := installFakeRunner(t, fakeConfigurationsJSON)
+
+	files := map[string]string{
+		ActiveConfigFileName:         "prod\n",
+		"configurations/config_prod": "[core]\nproject = prod-project\n",
+		"configurations/config_dev":  "[core]\nproject = dev-project\naccount = dev@example.com\n",
+		"configurations/config_dev~": "[core]\nproject = ignored\n",
+		"configurations/notes.txt":   "not a configuration\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configs, err := ListConfigurations()
+	if err != nil {
+		t.Fatalf("ListConfigurations() error = %v", err)
+	}
+	var got []string
+	for _, config := range configs {
+		got = append(got, fmt.Sprintf("%s %s %v", config.Name, config.Properties.Core.Project, config.IsActive))
+	}
+	want := []string{"dev dev-project false", "prod prod-project true"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ListConfigurations() = %q, want %q", got, want)
+	}
+	if configs[0].Properties.Core.Account != "dev@example.com" {
+		t.Errorf("dev account = %q, want dev@example.com", configs[0].Properties.Core.Account)
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("gcloud commands run offline: %q", fake.calls)
+	}
+}
@@ -0,0 +1,183 @@
+package gcloud
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	// EnvConfigDir overrides gcloud's configuration directory
+	EnvConfigDir = "CLOUDSDK_CONFIG"
+
+	// EnvActiveConfigName overrides the active configuration for a single shell
+	EnvActiveConfigName = "CLOUDSDK_ACTIVE_CONFIG_NAME"
+
+	// ADCFileName is the file in the configuration directory holding Application Default Credentials
+	ADCFileName = "application_default_credentials.json"
+
+	// ActiveConfigFileName is the file in the configuration directory naming the active configuration
+	ActiveConfigFileName = "active_config"
+
+	// configurationsDirName is the directory in the configuration directory holding one file per configuration
+	configurationsDirName = "configurations"
+)
+
+var (
+	configRootMu sync.RWMutex
+	configRoot   string
+)
+
+// SetConfigRoot points gcloudctx at another gcloud configuration directory, such
+// as a mounted backup, for both the files it reads and the gcloud commands it
+// runs; "" restores the default
+func SetConfigRoot(dir string) {
+	configRootMu.Lock()
+	defer configRootMu.Unlock()
+	configRoot = dir
+}
+
+// ConfigRoot returns the directory set with SetConfigRoot, or "" when none is set
+func ConfigRoot() string {
+	configRootMu.RLock()
+	defer configRootMu.RUnlock()
+	return configRoot
+}
+
+// commandEnv returns the environment for gcloud child processes
+// It is nil (inherit) unless root, or else SetConfigRoot, chose another configuration directory
+func commandEnv(root string) []string {
+	if root == "" {
+		root = ConfigRoot()
+	}
+	if root == "" {
+		return nil
+	}
+	return append(os.Environ(), EnvConfigDir+"="+root)
+}
+
+// ConfigDirFor returns gcloud's configuration directory for the given home directory
+// A directory set with SetConfigRoot wins, then $CLOUDSDK_CONFIG, then the platform default
+func ConfigDirFor(home string) string {
+	if root := ConfigRoot(); root != "" {
+		return root
+	}
+	return configDirFor(runtime.GOOS, home, os.Getenv)
+}
+
+// DefaultConfigDir returns the platform default configuration directory for
+// the given home directory, ignoring SetConfigRoot and $CLOUDSDK_CONFIG
+func DefaultConfigDir(home string) string {
+	return configDirFor(runtime.GOOS, home, func(key string) string {
+		if key == EnvConfigDir {
+			return ""
+		}
+		return os.Getenv(key)
+	})
+}
+
+// configDirFor is ConfigDirFor for the given GOOS and environment
+// On Windows gcloud keeps its configuration in %APPDATA%\gcloud
+func configDirFor(goos, home string, getenv func(string) string) string {
+	if dir := getenv(EnvConfigDir); dir != "" {
+		return dir
+	}
+	if goos == "windows" {
+		if appData := getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, "gcloud")
+		}
+	}
+	return filepath.Join(home, ".config", "gcloud")
+}
+
+// ConfigDir returns gcloud's configuration directory
+// A directory set with SetConfigRoot wins, then $CLOUDSDK_CONFIG, then the
+// directory the gcloud binary reports (see InstalledConfigDir, cached), so the
+// files read directly are the ones gcloud uses even for a snap-packaged SDK.
+// Without a gcloud to ask, the platform default is assumed.
+func ConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	if ConfigRoot() == "" && os.Getenv(EnvConfigDir) == "" {
+		if dir, ok := cachedInstalledConfigDir(home); ok {
+			return dir, nil
+		}
+	}
+	return ConfigDirFor(home), nil
+}
+
+// ActiveConfigName returns the name of the active configuration without running gcloud
+// It honors $CLOUDSDK_ACTIVE_CONFIG_NAME and otherwise reads gcloud's active_config file,
+// which makes it cheap enough for shell prompts
+func ActiveConfigName() (string, error) {
+	if name := os.Getenv(EnvActiveConfigName); name != "" {
+		return name, nil
+	}
+
+	name, err := GlobalActiveConfigName()
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		// gcloud falls back to "default" until a configuration is activated
+		return ReservedConfigurationName, nil
+	}
+	return name, nil
+}
+
+// GlobalActiveConfigName returns the configuration gcloud's active_config file
+// names, ignoring $CLOUDSDK_ACTIVE_CONFIG_NAME; it is "" while no configuration
+// has been activated and the file does not exist
+func GlobalActiveConfigName() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ActiveConfigFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read active configuration: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ConfigurationsStamp returns a fingerprint of gcloud's configuration files
+// It changes whenever a configuration is created, deleted, renamed or edited,
+// so a cached configuration list is reusable while the stamp is unchanged.
+// Which configuration is active is not part of the stamp. Without the
+// configurations directory there is nothing to fingerprint and an error is returned.
+func ConfigurationsStamp() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return configurationsStampIn(filepath.Join(dir, configurationsDirName))
+}
+
+// configurationsStampIn is ConfigurationsStamp for the configurations directory dir
+func configurationsStampIn(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read configurations: %w", err)
+	}
+
+	var b strings.Builder
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			// Removed while reading; the next stamp will differ anyway
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
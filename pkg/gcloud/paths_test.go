@@ -0,0 +1,228 @@
+package gcloud
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestConfigDirFor(t *testing.T) {
+	t.Setenv(EnvConfigDir, "")
+	if got, want := ConfigDirFor("/home/user"), filepath.Join("/home/user", ".config", "gcloud"); got != want {
+		t.Errorf("ConfigDirFor() = %q, want %q", got, want)
+	}
+
+	t.Setenv(EnvConfigDir, "/custom/gcloud")
+	if got := ConfigDirFor("/home/user"); got != "/custom/gcloud" {
+		t.Errorf("ConfigDirFor() = %q, want $%s", got, EnvConfigDir)
+	}
+}
+
+func TestConfigDirForPlatform(t *testing.T) {
+	appData := filepath.Join("C:", "Users", "me", "AppData", "Roaming")
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want string
+	}{
+		{name: "unix", goos: "linux", env: map[string]string{"APPDATA": appData}, want: filepath.Join("/home/user", ".config", "gcloud")},
+		{name: "windows", goos: "windows", env: map[string]string{"APPDATA": appData}, want: filepath.Join(appData, "gcloud")},
+		{name: "windows without APPDATA", goos: "windows", want: filepath.Join("/home/user", ".config", "gcloud")},
+		{name: "override", goos: "windows", env: map[string]string{"APPDATA": appData, EnvConfigDir: "/custom/gcloud"}, want: "/custom/gcloud"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			if got := configDirFor(tt.goos, "/home/user", getenv); got != tt.want {
+				t.Errorf("configDirFor(%q) = %q, want %q", tt.goos, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveConfigName(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, dir)
+	t.Setenv(EnvActiveConfigName, "")
+
+	name, err := ActiveConfigName()
+	if err != nil || name != "default" {
+		t.Errorf("ActiveConfigName() without active_config = %q, %v; want default", name, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ActiveConfigFileName), []byte("prod\n"), 0o600); err != nil {
+		t.Fatalf("failed to write active_config: %v", err)
+	}
+	name, err = ActiveConfigName()
+	if err != nil || name != "prod" {
+		t.Errorf("ActiveConfigName() = %q, %v; want prod", name, err)
+	}
+
+	t.Setenv(EnvActiveConfigName, "override")
+	name, err = ActiveConfigName()
+	if err != nil || name != "override" {
+		t.Errorf("ActiveConfigName() with $%s = %q, %v; want override", EnvActiveConfigName, name, err)
+	}
+}
+
+func TestConfigurationsStamp(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "configurations")
+
+	if stamp, err := configurationsStampIn(dir); err == nil {
+		t.Fatalf("configurationsStampIn() without a directory = %q, want an error", stamp)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string, modTime time.Time) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	write("config_default", "[core]\nproject = p0\n", at)
+	first, err := configurationsStampIn(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := configurationsStampIn(dir); again != first {
+		t.Errorf("stamp changed without changes: %q then %q", first, again)
+	}
+
+	write("config_prod", "", at)
+	added, _ := configurationsStampIn(dir)
+	if added == first {
+		t.Error("stamp did not change after a configuration was added")
+	}
+
+	write("config_default", "[core]\nproject = p1\n", at.Add(time.Second))
+	if edited, _ := configurationsStampIn(dir); edited == added {
+		t.Error("stamp did not change after a configuration was edited")
+	}
+}
+
+// writeFakeGcloud puts a gcloud script printing $CLOUDSDK_CONFIG first in PATH
+func writeFakeGcloud(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake gcloud is a shell script")
+	}
+
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho \"$" + EnvConfigDir + "\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "gcloud"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestSetConfigRootIsolatesTrees(t *testing.T) {
+	envTree, rootTree := t.TempDir(), t.TempDir()
+	for dir, active := range map[string]string{envTree: "env-config", rootTree: "root-config"} {
+		if err := os.WriteFile(filepath.Join(dir, ActiveConfigFileName), []byte(active+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv(EnvConfigDir, envTree)
+	t.Setenv(EnvActiveConfigName, "")
+	writeFakeGcloud(t)
+
+	// Without a config root, $CLOUDSDK_CONFIG decides
+	if name, err := ActiveConfigName(); err != nil || name != "env-config" {
+		t.Errorf("ActiveConfigName() = %q, %v; want env-config", name, err)
+	}
+	if out, err := RunGcloudCommand("info"); err != nil || out != envTree {
+		t.Errorf("gcloud ran with %s=%q, %v; want %q", EnvConfigDir, out, err, envTree)
+	}
+
+	SetConfigRoot(rootTree)
+	t.Cleanup(func() { SetConfigRoot("") })
+
+	if dir, err := ConfigDir(); err != nil || dir != rootTree {
+		t.Errorf("ConfigDir() = %q, %v; want %q", dir, err, rootTree)
+	}
+	if name, err := ActiveConfigName(); err != nil || name != "root-config" {
+		t.Errorf("ActiveConfigName() = %q, %v; want root-config", name, err)
+	}
+	if out, err := RunGcloudCommand("info"); err != nil || out != rootTree {
+		t.Errorf("gcloud ran with %s=%q, %v; want %q", EnvConfigDir, out, err, rootTree)
+	}
+
+	// The environment tree is left alone
+	data, err := os.ReadFile(filepath.Join(envTree, ActiveConfigFileName))
+	if err != nil || string(data) != "env-config\n" {
+		t.Errorf("env tree active_config = %q, %v; want unchanged", data, err)
+	}
+
+	SetConfigRoot("")
+	if name, err := ActiveConfigName(); err != nil || name != "env-config" {
+		t.Errorf("ActiveConfigName() after reset = %q, %v; want env-config", name, err)
+	}
+}
+
+func TestGetActiveConfigurationFast(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, dir)
+	t.Setenv(EnvActiveConfigName, "")
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(ActiveConfigFileName, "prod\n")
+	write("configurations/config_prod", "[core]\nproject = prod-project\n")
+	write("configurations/config_dev", "[core]\nproject = dev-project\n")
+	fake := installFakeRunner(t, `[{"name": "dev", "is_active": true, "properties": {"core": {"project": "dev-project"}}}]`)
+
+	tests := []struct {
+		name        string
+		envName     string
+		activeFile  string
+		want        string
+		wantProject string
+		wantCalls   int
+	}{
+		{name: "active_config", want: "prod", wantProject: "prod-project"},
+		{name: "environment override", envName: "dev", want: "dev", wantProject: "dev-project"},
+		// active_config names a deleted configuration, so gcloud is asked
+		{name: "dangling active_config", activeFile: "deleted", want: "dev", wantProject: "dev-project", wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvActiveConfigName, tt.envName)
+			if tt.activeFile != "" {
+				write(ActiveConfigFileName, tt.activeFile)
+				t.Cleanup(func() { write(ActiveConfigFileName, "prod\n") })
+			}
+			fake.calls = nil
+
+			got, err := GetActiveConfigurationFast()
+			if err != nil {
+				t.Fatalf("GetActiveConfigurationFast() error = %v", err)
+			}
+			if got.Name != tt.want || !got.IsActive || got.Properties.Core.Project != tt.wantProject {
+				t.Errorf("GetActiveConfigurationFast() = %+v, want %s with project %s", got, tt.want, tt.wantProject)
+			}
+			if len(fake.calls) != tt.wantCalls {
+				t.Errorf("gcloud ran %d times, want %d: %q", len(fake.calls), tt.wantCalls, fake.calls)
+			}
+		})
+	}
+}
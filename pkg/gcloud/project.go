@@ -0,0 +1,44 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ListProjects returns all projects accessible to the active account
+// This call goes over the network and can take several seconds
+func ListProjects() ([]Project, error) {
+	output, err := RunGcloudCommand("projects", "list", "--format=json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	return parseProjects(output)
+}
+
+// parseProjects parses the JSON output of gcloud projects list
+func parseProjects(output string) ([]Project, error) {
+	var projects []Project
+	if err := json.Unmarshal([]byte(output), &projects); err != nil {
+		return nil, fmt.Errorf("failed to parse projects: %w", err)
+	}
+	return projects, nil
+}
+
+// SetProject sets core/project on the active configuration
+func SetProject(projectID string) error {
+	if err := RunGcloudCommandQuiet("config", "set", "project", projectID); err != nil {
+		return fmt.Errorf("failed to set project %q: %w", projectID, err)
+	}
+	return nil
+}
+
+// FindProjectByID finds a project by ID from a list
+func FindProjectByID(projects []Project, projectID string) (*Project, bool) {
+	for i := range projects {
+		if projects[i].ProjectID == projectID {
+			return &projects[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,65 @@
+package gcloud
+
+import (
+	"testing"
+)
+
+func TestParseProjects(t *testing.T) {
+	output := `[
+  {
+    "createTime": "2020-01-01T00:00:00.000Z",
+    "labels": {"env": "prod"},
+    "lifecycleState": "ACTIVE",
+    "name": "Production",
+    "parent": {"id": "123", "type": "folder"},
+    "projectId": "prod-project",
+    "projectNumber": "111111111111"
+  },
+  {
+    "name": "Sandbox",
+    "projectId": "sandbox",
+    "projectNumber": "222222222222"
+  }
+]`
+
+	projects, err := parseProjects(output)
+	if err != nil {
+		t.Fatalf("parseProjects failed: %v", err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("parseProjects() returned %d projects, want 2", len(projects))
+	}
+
+	prod := projects[0]
+	if prod.ProjectID != "prod-project" || prod.Name != "Production" || prod.ProjectNumber != "111111111111" {
+		t.Errorf("unexpected project: %+v", prod)
+	}
+	if prod.Parent == nil || prod.Parent.Type != "folder" || prod.Parent.ID != "123" {
+		t.Errorf("unexpected parent: %+v", prod.Parent)
+	}
+	if prod.Labels["env"] != "prod" {
+		t.Errorf("unexpected labels: %v", prod.Labels)
+	}
+	if projects[1].Parent != nil {
+		t.Errorf("expected nil parent, got %+v", projects[1].Parent)
+	}
+}
+
+func TestParseProjectsInvalid(t *testing.T) {
+	if _, err := parseProjects("not json"); err == nil {
+		t.Error("parseProjects() expected error for invalid JSON")
+	}
+}
+
+func TestFindProjectByID(t *testing.T) {
+	projects := []Project{{ProjectID: "a"}, {ProjectID: "b", Name: "B"}}
+
+	project, found := FindProjectByID(projects, "b")
+	if !found || project.Name != "B" {
+		t.Errorf("FindProjectByID(b) = %+v, %v", project, found)
+	}
+
+	if _, found := FindProjectByID(projects, "missing"); found {
+		t.Error("FindProjectByID(missing) should not be found")
+	}
+}
@@ -0,0 +1,90 @@
+package gcloud
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// CommonProperties are properties offered by completion even when no
+// configuration sets them yet
+var CommonProperties = []string{
+	"core/account",
+	"core/project",
+	"compute/region",
+	"compute/zone",
+	"auth/impersonate_service_account",
+	"billing/quota_project",
+	"container/cluster",
+	"run/region",
+}
+
+// NormalizeProperty returns property in "section/name" form
+// A bare name such as "project" belongs to the core section, as in gcloud
+func NormalizeProperty(property string) (string, error) {
+	section, name, found := strings.Cut(property, "/")
+	if !found {
+		section, name = "core", property
+	}
+	if section == "" || name == "" || strings.Contains(name, "/") || strings.ContainsAny(property, " \t\n") {
+		return "", fmt.Errorf("invalid property %q (use SECTION/NAME, e.g. compute/region)", property)
+	}
+	return section + "/" + name, nil
+}
+
+// PropertyValue returns the value of a "section/name" property of config and
+// whether it is set
+func PropertyValue(config *Configuration, property string) (string, bool) {
+	value, ok := FlattenProperties(config)[property]
+	return value, ok && value != ""
+}
+
+// SetPropertyNames returns the "section/name" properties set on config, sorted
+func SetPropertyNames(config *Configuration) []string {
+	return slices.Sorted(maps.Keys(FlattenProperties(config)))
+}
+
+// PropertyCopy is the plan for copying properties from one configuration to another
+type PropertyCopy struct {
+	// Set lists the properties to write to the target, in the requested order
+	Set []PropertySetting
+	// Unset lists the properties to remove from the target
+	Unset []string
+	// Skipped lists the properties left alone because the source does not set them
+	Skipped []string
+}
+
+// PlanPropertyCopy decides how to copy properties from source to target
+// Properties the source does not set are skipped, or unset on the target when
+// exact is true (only those the target actually sets, so nothing runs needlessly)
+func PlanPropertyCopy(source, target *Configuration, properties []string, exact bool) PropertyCopy {
+	var plan PropertyCopy
+	for _, property := range properties {
+		if value, ok := PropertyValue(source, property); ok {
+			plan.Set = append(plan.Set, PropertySetting{Property: property, Value: value})
+			continue
+		}
+		if !exact {
+			plan.Skipped = append(plan.Skipped, property)
+			continue
+		}
+		if _, ok := PropertyValue(target, property); ok {
+			plan.Unset = append(plan.Unset, property)
+		}
+	}
+	return plan
+}
+
+// UnsetProperties removes properties from a configuration in order
+func UnsetProperties(configName string, properties []string) error {
+	return defaultClient.UnsetProperties(configName, properties)
+}
+
+// ApplyPropertyCopy writes a copy plan to the named target configuration
+func ApplyPropertyCopy(targetName string, plan PropertyCopy) error {
+	if err := SetProperties(targetName, plan.Set); err != nil {
+		return err
+	}
+	return UnsetProperties(targetName, plan.Unset)
+}
@@ -0,0 +1,115 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeProperty(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "compute/region", want: "compute/region"},
+		{input: "project", want: "core/project"},
+		{input: "auth/impersonate_service_account", want: "auth/impersonate_service_account"},
+		{input: "", wantErr: true},
+		{input: "compute/", wantErr: true},
+		{input: "/region", wantErr: true},
+		{input: "a/b/c", wantErr: true},
+		{input: "compute/re gion", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeProperty(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NormalizeProperty(%q) = %q, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("NormalizeProperty(%q) = %q, %v; want %q", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// configFromJSON decodes a configuration the way gcloud reports it
+func configFromJSON(t *testing.T, data string) *Configuration {
+	t.Helper()
+	var config Configuration
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+	return &config
+}
+
+func TestPlanPropertyCopy(t *testing.T) {
+	source := configFromJSON(t, `{"name": "src", "properties": {
+		"compute": {"region": "us-central1"},
+		"run": {"region": "us-east1"}
+	}}`)
+	target := configFromJSON(t, `{"name": "dst", "properties": {
+		"compute": {"region": "europe-west1", "zone": "europe-west1-b"}
+	}}`)
+	properties := []string{"compute/region", "compute/zone", "run/region", "container/cluster"}
+
+	plan := PlanPropertyCopy(source, target, properties, false)
+	want := PropertyCopy{
+		Set: []PropertySetting{
+			{Property: "compute/region", Value: "us-central1"},
+			{Property: "run/region", Value: "us-east1"},
+		},
+		Skipped: []string{"compute/zone", "container/cluster"},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("PlanPropertyCopy() = %+v, want %+v", plan, want)
+	}
+
+	// With exact, only properties the target sets are unset
+	plan = PlanPropertyCopy(source, target, properties, true)
+	want = PropertyCopy{Set: want.Set, Unset: []string{"compute/zone"}}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("PlanPropertyCopy(exact) = %+v, want %+v", plan, want)
+	}
+}
+
+func TestApplyPropertyCopy(t *testing.T) {
+	fake := &fakeRunner{outputs: map[string]string{
+		"config set compute/region us-central1 --configuration dst": "",
+		"config unset compute/zone --configuration dst":             "",
+	}}
+	t.Cleanup(SetRunner(fake))
+
+	plan := PropertyCopy{
+		Set:   []PropertySetting{{Property: "compute/region", Value: "us-central1"}},
+		Unset: []string{"compute/zone"},
+	}
+	if err := ApplyPropertyCopy("dst", plan); err != nil {
+		t.Fatalf("ApplyPropertyCopy failed: %v", err)
+	}
+
+	want := []string{
+		"config set compute/region us-central1 --configuration dst",
+		"config unset compute/zone --configuration dst",
+	}
+	if !reflect.DeepEqual(fake.calls, want) {
+		t.Errorf("gcloud calls = %v, want %v", fake.calls, want)
+	}
+}
+
+func TestSetPropertyNames(t *testing.T) {
+	config := configFromJSON(t, `{"name": "src", "properties": {
+		"core": {"project": "p", "account": "a@example.com"},
+		"compute": {"zone": "us-central1-a"}
+	}}`)
+
+	want := []string{"compute/zone", "core/account", "core/project"}
+	if got := SetPropertyNames(config); !reflect.DeepEqual(got, want) {
+		t.Errorf("SetPropertyNames() = %v, want %v", got, want)
+	}
+}
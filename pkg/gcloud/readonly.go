@@ -0,0 +1,106 @@
+package gcloud
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// EnvReadOnly turns on read-only mode when set to a true value such as "1"
+const EnvReadOnly = "GCLOUDCTX_READONLY"
+
+// ErrReadOnly is returned for operations that would change gcloud's configuration in read-only mode
+var ErrReadOnly = errors.New("read-only mode")
+
+// readOnlySetting is the read_only setting, set via SetReadOnly
+var readOnlySetting atomic.Bool
+
+// SetReadOnly turns read-only mode on or off; GCLOUDCTX_READONLY turns it on regardless
+func SetReadOnly(readOnly bool) {
+	readOnlySetting.Store(readOnly)
+}
+
+// ReadOnly reports whether read-only mode is on
+func ReadOnly() bool {
+	if readOnlySetting.Load() {
+		return true
+	}
+	enabled, err := strconv.ParseBool(os.Getenv(EnvReadOnly))
+	return err == nil && enabled
+}
+
+// readOnlyCommands are the gcloud commands that leave its configuration alone;
+// in read-only mode every other command is refused
+var readOnlyCommands = [][]string{
+	{"auth", "application-default", "print-access-token"},
+	{"auth", "list"},
+	{"auth", "print-access-token"},
+	{"config", "config-helper"},
+	{"config", "configurations", "describe"},
+	{"config", "configurations", "list"},
+	{"config", "get"},
+	{"config", "get-value"},
+	{"config", "list"},
+	{"container", "clusters", "list"},
+	{"info"},
+	{"projects", "describe"},
+	{"projects", "list"},
+	{"version"},
+}
+
+// commandWords returns the command and subcommands of a gcloud invocation,
+// i.e. the arguments before the first flag
+func commandWords(args []string) []string {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return args[:i]
+		}
+	}
+	return args
+}
+
+// isReadOnlyCommand reports whether running gcloud with args leaves its configuration alone
+func isReadOnlyCommand(args []string) bool {
+	words := commandWords(args)
+	return slices.ContainsFunc(readOnlyCommands, func(command []string) bool {
+		return len(words) >= len(command) && slices.Equal(words[:len(command)], command)
+	})
+}
+
+// writeAttempts counts the changes to gcloud's configuration attempted in this process
+var writeAttempts atomic.Uint64
+
+// WriteAttempts returns how many changes to gcloud's configuration have been
+// attempted in this process, refused ones included, so a caller can tell
+// whether an operation tried to change anything
+func WriteAttempts() uint64 {
+	return writeAttempts.Load()
+}
+
+// CheckWritable returns an error wrapping ErrReadOnly in read-only mode
+// operation describes what was refused, e.g. "editing configuration files"
+// Every call counts as an attempted change (see WriteAttempts)
+func CheckWritable(operation string) error {
+	writeAttempts.Add(1)
+	if !ReadOnly() {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not allowed (unset %s or the read_only setting to make changes)", ErrReadOnly, operation, EnvReadOnly)
+}
+
+// checkCommand refuses gcloud commands that change its configuration in
+// read-only mode, and those that need the network in offline mode
+// Every gcloud invocation goes through it before anything is run
+func checkCommand(args []string) error {
+	if err := CheckNetwork(args); err != nil {
+		return err
+	}
+	if isReadOnlyCommand(args) {
+		return nil
+	}
+	return CheckWritable(fmt.Sprintf("'gcloud %s'", strings.Join(commandWords(args), " ")))
+}
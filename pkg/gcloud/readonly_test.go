@@ -0,0 +1,108 @@
+package gcloud
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	defer SetReadOnly(false)
+
+	tests := []struct {
+		name    string
+		env     string
+		setting bool
+		want    bool
+	}{
+		{"off", "", false, false},
+		{"env", "1", false, true},
+		{"env true", "true", false, true},
+		{"env zero", "0", false, false},
+		{"setting", "", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvReadOnly, tt.env)
+			SetReadOnly(tt.setting)
+			if got := ReadOnly(); got != tt.want {
+				t.Errorf("ReadOnly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsReadOnlyCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"config", "configurations", "list", "--format=json"}, true},
+		{[]string{"config", "get-value", "project"}, true},
+		{[]string{"projects", "list", "--format=json"}, true},
+		{[]string{"auth", "application-default", "print-access-token"}, true},
+		{[]string{"config", "configurations", "activate", "prod"}, false},
+		{[]string{"config", "set", "project", "p", "--configuration", "prod"}, false},
+		{[]string{"auth", "application-default", "login"}, false},
+		{[]string{"--verbosity=debug", "config", "configurations", "list"}, false},
+		{[]string{"config"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := isReadOnlyCommand(tt.args); got != tt.want {
+			t.Errorf("isReadOnlyCommand(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestReadOnlyRefusesMutations(t *testing.T) {
+	t.Setenv(EnvReadOnly, "1")
+
+	settings := []PropertySetting{{Property: "core/project", Value: "p"}}
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"activate", func() error { return ActivateConfiguration("dev") }},
+		{"create", func() error { return CreateConfiguration("new") }},
+		{"delete", func() error { return DeleteConfiguration("dev") }},
+		{"clone", func() error { return CloneConfiguration("dev", "new") }},
+		{"rename", func() error { return RenameConfiguration("dev", "new") }},
+		{"import", func() error { return ImportConfiguration("new", settings) }},
+		{"overwrite", func() error { return OverwriteConfiguration("dev", settings) }},
+		{"set", func() error { return SetProperties("dev", settings) }},
+		{"unset", func() error { return UnsetProperties("dev", []string{"core/project"}) }},
+		{"project", func() error { return SetProject("p") }},
+		{"adc", func() error { return SyncADC("", false) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := installFakeRunner(t, fakeConfigurationsJSON)
+
+			err := tt.run()
+			if !errors.Is(err, ErrReadOnly) {
+				t.Fatalf("error = %v, want ErrReadOnly", err)
+			}
+			for _, call := range fake.calls {
+				if !isReadOnlyCommand(strings.Fields(call)) {
+					t.Errorf("mutating gcloud command attempted: %s", call)
+				}
+			}
+		})
+	}
+}
+
+func TestReadOnlyAllowsReads(t *testing.T) {
+	t.Setenv(EnvReadOnly, "1")
+	installFakeRunner(t, fakeConfigurationsJSON)
+
+	configs, err := ListConfigurations()
+	if err != nil {
+		t.Fatalf("ListConfigurations() error = %v", err)
+	}
+	if len(configs) != 2 {
+		t.Errorf("ListConfigurations() returned %d configurations, want 2", len(configs))
+	}
+}
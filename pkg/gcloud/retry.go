@@ -0,0 +1,62 @@
+package gcloud
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRetries is how often a read-only command failing transiently is run again
+const DefaultRetries = 2
+
+// retryBackoff is the wait before the first retry; it doubles with every retry
+const retryBackoff = 200 * time.Millisecond
+
+// retries is set via SetRetries
+var retries atomic.Int32
+
+func init() {
+	retries.Store(DefaultRetries)
+}
+
+// SetRetries sets how often a read-only command failing transiently is run again
+// Zero turns retrying off
+func SetRetries(n int) {
+	retries.Store(int32(max(n, 0)))
+}
+
+// retrySleep waits between attempts; tests substitute a fake
+var retrySleep = time.Sleep
+
+// retryLog receives a line per retry when errors are verbose
+var retryLog io.Writer = os.Stderr
+
+// withRetries calls run and, when args is a read-only command failing with a
+// transient *CommandError, calls it again up to the configured number of times
+// Commands that change the configuration are never retried, since a failure
+// does not tell whether gcloud applied the change
+func withRetries(args []string, run func() error) error {
+	err := run()
+	if !isReadOnlyCommand(args) {
+		return err
+	}
+
+	limit := int(retries.Load())
+	wait := retryBackoff
+	for attempt := 1; attempt <= limit; attempt++ {
+		var commandErr *CommandError
+		if !errors.As(err, &commandErr) || !commandErr.Transient {
+			break
+		}
+		if verboseErrors.Load() {
+			fmt.Fprintf(retryLog, "Retrying 'gcloud %s' in %s (retry %d of %d): %v\n", commandErr.Command, wait, attempt, limit, commandErr.Reason)
+		}
+		retrySleep(wait)
+		wait *= 2
+		err = run()
+	}
+	return err
+}
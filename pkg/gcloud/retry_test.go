@@ -0,0 +1,127 @@
+package gcloud
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyRunner fails every command with output a given number of times before succeeding
+type flakyRunner struct {
+	failures int
+	output   string
+	calls    int
+}
+
+func (f *flakyRunner) Run(args ...string) (string, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return "", ClassifyError(args, f.output, &exec.ExitError{})
+	}
+	return "ok", nil
+}
+
+func (f *flakyRunner) RunQuiet(args ...string) error {
+	_, err := f.Run(args...)
+	return err
+}
+
+// installFlakyRunner installs a flakyRunner and records the waits between attempts
+func installFlakyRunner(t *testing.T, failures int, output string) (*flakyRunner, *[]time.Duration) {
+	t.Helper()
+	fake := &flakyRunner{failures: failures, output: output}
+	t.Cleanup(SetRunner(fake))
+
+	var waits []time.Duration
+	sleep := retrySleep
+	retrySleep = func(d time.Duration) { waits = append(waits, d) }
+	t.Cleanup(func() { retrySleep = sleep })
+	t.Cleanup(func() { SetRetries(DefaultRetries) })
+	return fake, &waits
+}
+
+func TestRetriesTransientReadOnlyCommand(t *testing.T) {
+	fake, waits := installFlakyRunner(t, 2, networkOutput)
+
+	output, err := RunGcloudCommand("config", "configurations", "list", "--format=json")
+	if err != nil {
+		t.Fatalf("RunGcloudCommand() error = %v", err)
+	}
+	if output != "ok" || fake.calls != 3 {
+		t.Errorf("output = %q after %d calls, want \"ok\" after 3", output, fake.calls)
+	}
+	if want := []time.Duration{retryBackoff, 2 * retryBackoff}; len(*waits) != 2 || (*waits)[0] != want[0] || (*waits)[1] != want[1] {
+		t.Errorf("waits = %v, want %v", *waits, want)
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	fake, _ := installFlakyRunner(t, 5, serviceUnavailableOutput)
+
+	_, err := RunGcloudCommand("projects", "list", "--format=json")
+	if !errors.Is(err, ErrServiceUnavailable) {
+		t.Errorf("error = %v, want ErrServiceUnavailable", err)
+	}
+	if fake.calls != DefaultRetries+1 {
+		t.Errorf("calls = %d, want %d", fake.calls, DefaultRetries+1)
+	}
+}
+
+func TestRetriesSkipped(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		run    func() error
+	}{
+		{"mutating command", networkOutput, func() error { return ActivateConfiguration("dev") }},
+		{"permanent failure", permissionDeniedOutput, func() error {
+			_, err := RunGcloudCommand("projects", "describe", "prod-project")
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, waits := installFlakyRunner(t, 1, tt.output)
+			if err := tt.run(); err == nil {
+				t.Fatal("expected the failure to be returned")
+			}
+			if fake.calls != 1 || len(*waits) != 0 {
+				t.Errorf("calls = %d, waits = %v, want a single attempt", fake.calls, *waits)
+			}
+		})
+	}
+}
+
+func TestSetRetriesZero(t *testing.T) {
+	fake, _ := installFlakyRunner(t, 1, networkOutput)
+	SetRetries(0)
+
+	if err := RunGcloudCommandQuiet("config", "configurations", "list"); err == nil {
+		t.Fatal("expected the failure to be returned")
+	}
+	if fake.calls != 1 {
+		t.Errorf("calls = %d, want 1", fake.calls)
+	}
+}
+
+func TestRetriesVerboseLog(t *testing.T) {
+	installFlakyRunner(t, 1, networkOutput)
+	defer SetVerboseErrors(false)
+	SetVerboseErrors(true)
+
+	var log bytes.Buffer
+	w := retryLog
+	retryLog = &log
+	defer func() { retryLog = w }()
+
+	if _, err := RunGcloudCommand("projects", "list", "--format=json"); err != nil {
+		t.Fatalf("RunGcloudCommand() error = %v", err)
+	}
+	if !strings.HasPrefix(log.String(), "Retrying 'gcloud projects list' in 200ms (retry 1 of 2)") {
+		t.Errorf("log = %q", log.String())
+	}
+}
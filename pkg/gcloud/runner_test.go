@@ -0,0 +1,209 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeRunner answers gcloud commands from canned output and counts invocations
+type fakeRunner struct {
+	outputs map[string]string
+	calls   []string
+}
+
+func (f *fakeRunner) Run(args ...string) (string, error) {
+	key := strings.Join(args, " ")
+	f.calls = append(f.calls, key)
+	output, ok := f.outputs[key]
+	if !ok {
+		return "", fmt.Errorf("unexpected gcloud command: %s", key)
+	}
+	return output, nil
+}
+
+func (f *fakeRunner) RunQuiet(args ...string) error {
+	_, err := f.Run(args...)
+	return err
+}
+
+// installFakeRunner installs a fake runner listing the given configurations for the duration of the test
+func installFakeRunner(t *testing.T, listJSON string) *fakeRunner {
+	t.Helper()
+	fake := &fakeRunner{outputs: map[string]string{
+		"config configurations list --format=json": listJSON,
+	}}
+	t.Cleanup(SetRunner(fake))
+	return fake
+}
+
+const fakeConfigurationsJSON = `[
+  {"name": "prod", "is_active": true, "properties": {"core": {"project": "prod-project"}}},
+  {"name": "dev", "is_active": false, "properties": {"core": {"project": "dev-project"}}}
+]`
+
+func TestResolveSwitchSingleListCall(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantActive string
+		wantFound  bool
+	}{
+		{"already active", "prod", "prod", true},
+		{"other configuration", "dev", "prod", true},
+		{"missing configuration", "missing", "prod", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := installFakeRunner(t, fakeConfigurationsJSON)
+
+			active, found, err := ResolveSwitch(tt.target)
+			if err != nil {
+				t.Fatalf("ResolveSwitch(%q) failed: %v", tt.target, err)
+			}
+			if active.Name != tt.wantActive {
+				t.Errorf("active = %q, want %q", active.Name, tt.wantActive)
+			}
+			if (found != nil) != tt.wantFound {
+				t.Errorf("found = %v, want found %v", found, tt.wantFound)
+			}
+			if found != nil && found.Name != tt.target {
+				t.Errorf("found = %q, want %q", found.Name, tt.target)
+			}
+			if len(fake.calls) != 1 {
+				t.Errorf("expected a single gcloud invocation, got %d: %v", len(fake.calls), fake.calls)
+			}
+		})
+	}
+}
+
+func TestResolveSwitchNoActive(t *testing.T) {
+	installFakeRunner(t, `[{"name": "dev", "is_active": false}]`)
+
+	if _, _, err := ResolveSwitch("dev"); err == nil {
+		t.Error("expected error when no configuration is active")
+	}
+}
+
+func TestSetRunnerRestore(t *testing.T) {
+	fake := &fakeRunner{outputs: map[string]string{"version": "fake"}}
+	restore := SetRunner(fake)
+
+	output, err := RunGcloudCommand("version")
+	if err != nil || output != "fake" {
+		t.Errorf("RunGcloudCommand() = %q, %v; want fake output", output, err)
+	}
+
+	restore()
+	if _, ok := runner().(execRunner); !ok {
+		t.Errorf("expected the exec runner after restore, got %T", runner())
+	}
+}
+
+// fakeGcloud simulates gcloud's configuration commands against in-memory state
+type fakeGcloud struct {
+	configs map[string]map[string]string // name -> "section/name" -> value
+	order   []string
+	active  string
+	// failSet makes "config set" fail for this property
+	failSet string
+	calls   []string
+}
+
+func newFakeGcloud(t *testing.T) *fakeGcloud {
+	t.Helper()
+	fake := &fakeGcloud{configs: map[string]map[string]string{}}
+	t.Cleanup(SetRunner(fake))
+	return fake
+}
+
+// add registers a configuration with properties
+func (f *fakeGcloud) add(name string, properties map[string]string) {
+	f.configs[name] = properties
+	f.order = append(f.order, name)
+}
+
+func (f *fakeGcloud) Run(args ...string) (string, error) {
+	f.calls = append(f.calls, strings.Join(args, " "))
+
+	switch {
+	case len(args) >= 3 && args[0] == "config" && args[1] == "configurations" && args[2] == "list":
+		return f.listJSON()
+	case len(args) >= 4 && args[0] == "config" && args[1] == "configurations" && args[2] == "create":
+		if _, ok := f.configs[args[3]]; ok {
+			return "", fmt.Errorf("configuration %s already exists", args[3])
+		}
+		f.add(args[3], map[string]string{})
+		return "", nil
+	case len(args) >= 4 && args[0] == "config" && args[1] == "configurations" && args[2] == "delete":
+		if _, ok := f.configs[args[3]]; !ok {
+			return "", fmt.Errorf("configuration %s does not exist", args[3])
+		}
+		delete(f.configs, args[3])
+		f.order = slices.DeleteFunc(f.order, func(n string) bool { return n == args[3] })
+		return "", nil
+	case len(args) >= 4 && args[0] == "config" && args[1] == "configurations" && args[2] == "activate":
+		if _, ok := f.configs[args[3]]; !ok {
+			return "", fmt.Errorf("configuration %s does not exist", args[3])
+		}
+		f.active = args[3]
+		return "", nil
+	case len(args) == 6 && args[0] == "config" && args[1] == "set" && args[4] == "--configuration":
+		if args[2] == f.failSet {
+			return "", fmt.Errorf("invalid value for %s", args[2])
+		}
+		properties, ok := f.configs[args[5]]
+		if !ok {
+			return "", fmt.Errorf("configuration %s does not exist", args[5])
+		}
+		// gcloud treats properties without a section as core properties
+		property := args[2]
+		if !strings.Contains(property, "/") {
+			property = "core/" + property
+		}
+		properties[property] = args[3]
+		return "", nil
+	case len(args) == 5 && args[0] == "config" && args[1] == "unset" && args[3] == "--configuration":
+		properties, ok := f.configs[args[4]]
+		if !ok {
+			return "", fmt.Errorf("configuration %s does not exist", args[4])
+		}
+		delete(properties, args[2])
+		return "", nil
+	}
+
+	return "", fmt.Errorf("unexpected gcloud command: %s", strings.Join(args, " "))
+}
+
+func (f *fakeGcloud) RunQuiet(args ...string) error {
+	_, err := f.Run(args...)
+	return err
+}
+
+// listJSON renders the configurations as 'gcloud config configurations list --format=json' does
+func (f *fakeGcloud) listJSON() (string, error) {
+	type entry struct {
+		Name       string                       `json:"name"`
+		IsActive   bool                         `json:"is_active"`
+		Properties map[string]map[string]string `json:"properties"`
+	}
+
+	entries := []entry{}
+	for _, name := range f.order {
+		sections := map[string]map[string]string{}
+		for key, value := range f.configs[name] {
+			section, property, _ := strings.Cut(key, "/")
+			if sections[section] == nil {
+				sections[section] = map[string]string{}
+			}
+			sections[section][property] = value
+		}
+		entries = append(entries, entry{Name: name, IsActive: name == f.active, Properties: sections})
+	}
+
+	data, err := json.Marshal(entries)
+	return string(data), err
+}
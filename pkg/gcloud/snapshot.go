@@ -0,0 +1,164 @@
+package gcloud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WithSnapshot runs fn and, when it fails, restores the named configuration
+// and the active configuration to their state before fn ran
+func WithSnapshot(configName string, fn func() error) error {
+	return defaultClient.WithSnapshot(configName, fn)
+}
+
+// WithSnapshot runs fn and, when it fails, restores the named configuration
+// and the active configuration to their state before fn ran
+// gcloud's files are captured before fn runs and written back byte for byte,
+// each replaced atomically, so a failure halfway through a series of property
+// changes leaves nothing half-applied. A configuration that did not exist
+// before is removed again.
+func (c *Client) WithSnapshot(configName string, fn func() error) error {
+	return c.withSnapshot([]string{configName}, fn)
+}
+
+// withSnapshot is WithSnapshot for several configurations at once
+func (c *Client) withSnapshot(names []string, fn func() error) error {
+	snap, err := c.takeSnapshot(names)
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	if err == nil {
+		return nil
+	}
+	defer c.invalidate()
+	if restoreErr := snap.restore(); restoreErr != nil {
+		return fmt.Errorf("%w (restoring the previous state also failed: %v)", err, restoreErr)
+	}
+	return err
+}
+
+// fileState is the contents of a file at the time of a snapshot
+type fileState struct {
+	exists bool
+	data   []byte
+	mode   os.FileMode
+}
+
+// snapshot holds gcloud's files as they were before a multi-step operation
+type snapshot struct {
+	// paths lists the captured files in the order they are restored
+	paths []string
+	files map[string]fileState
+}
+
+// configDir returns the configuration directory the client's gcloud commands change
+func (c *Client) configDir() (string, error) {
+	if c.configRoot != "" {
+		return c.configRoot, nil
+	}
+	return ConfigDir()
+}
+
+// takeSnapshot captures the files of the named configurations and active_config
+func (c *Client) takeSnapshot(names []string) (*snapshot, error) {
+	dir, err := c.configDir()
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{files: map[string]fileState{}}
+	for _, name := range names {
+		// The name becomes part of a path, so it must not leave the directory
+		if err := ValidateExistingConfigurationName(name); err != nil {
+			return nil, fmt.Errorf("invalid configuration name %q: %w", name, err)
+		}
+		snap.paths = append(snap.paths, filepath.Join(dir, configurationsDirName, configFilePrefix+name))
+	}
+	snap.paths = append(snap.paths, filepath.Join(dir, ActiveConfigFileName))
+
+	for _, path := range snap.paths {
+		state, err := readFileState(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot %s: %w", path, err)
+		}
+		snap.files[path] = state
+	}
+	return snap, nil
+}
+
+// restore writes every captured file back, removing those that did not exist
+// Files already in their captured state are left alone
+func (s *snapshot) restore() error {
+	var errs []error
+	for _, path := range s.paths {
+		want := s.files[path]
+		current, err := readFileState(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if current.exists == want.exists && bytes.Equal(current.data, want.data) {
+			continue
+		}
+
+		if !want.exists {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := writeConfigFile(path, want.data, want.mode); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// readFileState returns the contents of the file at path, which may not exist
+func readFileState(path string) (fileState, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return fileState{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{exists: true, data: data, mode: info.Mode().Perm()}, nil
+}
+
+// writeConfigFile replaces a file in gcloud's configuration directory with data
+// The data is written to a temporary file in the same directory that is then
+// renamed over path, so gcloud never reads a partially written file
+func writeConfigFile(path string, data []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
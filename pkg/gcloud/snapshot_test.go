@@ -0,0 +1,203 @@
+package gcloud_test
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/gcloudtest"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+// installSnapshotFake runs gcloud through a fake mirroring dev (active) and
+// prod to a new configuration directory, and returns the fake and the directory
+func installSnapshotFake(t *testing.T) (*gcloudtest.FakeRunner, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(gcloud.EnvConfigDir, dir)
+	fake := gcloudtest.Install(t, dir)
+	fake.Add("dev", map[string]string{"core/project": "dev-project"})
+	fake.Add("prod", map[string]string{
+		"core/account":      "ops@example.com",
+		"core/project":      "prod-project",
+		"compute/zone":      "europe-west1-b",
+		"container/cluster": "main",
+	})
+	return fake, dir
+}
+
+// readTree returns the contents of every file under dir by relative path
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", dir, err)
+	}
+	return files
+}
+
+// assertTree fails the test unless dir holds exactly the files of want
+func assertTree(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	got := readTree(t, dir)
+	if maps.Equal(got, want) {
+		return
+	}
+	for path, contents := range want {
+		if got[path] != contents {
+			t.Errorf("%s = %q, want %q", path, got[path], contents)
+		}
+	}
+	for path := range got {
+		if _, ok := want[path]; !ok {
+			t.Errorf("%s was left behind", path)
+		}
+	}
+}
+
+func TestWithSnapshotRestoresFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(gcloud.EnvConfigDir, dir)
+	configPath := filepath.Join(dir, "configurations", "config_prod")
+	activePath := filepath.Join(dir, gcloud.ActiveConfigFileName)
+	// Comments and spacing gcloud would not write itself survive the rollback
+	original := "# production, careful\n[core]\nproject=prod-project\n\n[compute]\nzone = europe-west1-b\n"
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte(original), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(activePath, []byte("prod"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	before := readTree(t, dir)
+
+	failure := errors.New("step 2 failed")
+	err := gcloud.WithSnapshot("prod", func() error {
+		if err := os.WriteFile(configPath, []byte("[core]\nproject = half-done\n"), 0o600); err != nil {
+			return err
+		}
+		if err := os.Remove(activePath); err != nil {
+			return err
+		}
+		return failure
+	})
+
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithSnapshot() = %v, want the failure of the operation", err)
+	}
+	assertTree(t, dir, before)
+	if info, err := os.Stat(configPath); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("config_prod mode = %v, %v; want 0640 restored", info.Mode().Perm(), err)
+	}
+}
+
+func TestWithSnapshotKeepsSuccess(t *testing.T) {
+	fake, dir := installSnapshotFake(t)
+
+	err := gcloud.WithSnapshot("prod", func() error {
+		return gcloud.SetProperties("prod", []gcloud.PropertySetting{{Property: "core/project", Value: "new-project"}})
+	})
+
+	if err != nil {
+		t.Fatalf("WithSnapshot() error = %v", err)
+	}
+	if properties, _ := fake.Properties("prod"); properties["core/project"] != "new-project" {
+		t.Errorf("prod project = %q, want the change kept", properties["core/project"])
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "configurations", "config_prod"))
+	if !slices.Contains(strings.Split(string(data), "\n"), "project = new-project") {
+		t.Errorf("config_prod = %q, want the change kept", data)
+	}
+}
+
+func TestWithSnapshotRemovesCreatedConfiguration(t *testing.T) {
+	_, dir := installSnapshotFake(t)
+	before := readTree(t, dir)
+
+	failure := errors.New("population failed")
+	err := gcloud.WithSnapshot("staging", func() error {
+		if err := gcloud.CreateConfiguration("staging"); err != nil {
+			return err
+		}
+		return failure
+	})
+
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithSnapshot() = %v, want the failure of the operation", err)
+	}
+	assertTree(t, dir, before)
+}
+
+func TestWithSnapshotInvalidName(t *testing.T) {
+	installSnapshotFake(t)
+	ran := false
+	err := gcloud.WithSnapshot("../prod", func() error {
+		ran = true
+		return nil
+	})
+	if err == nil || ran {
+		t.Errorf("WithSnapshot(../prod) = %v and ran = %v; want an error before running", err, ran)
+	}
+}
+
+func TestRenameConfigurationFailureRestores(t *testing.T) {
+	tests := []struct {
+		name string
+		// fail is the gcloud command that fails partway through the rename
+		fail string
+	}{
+		{name: "failure mid-copy", fail: "config set compute/zone"},
+		{name: "failure after activation", fail: "config configurations delete prod"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, dir := installSnapshotFake(t)
+			fake.Activate("prod")
+			before := readTree(t, dir)
+			fake.Fail(tt.fail, "ERROR: (gcloud) simulated failure")
+
+			if err := gcloud.RenameConfiguration("prod", "staging"); err == nil {
+				t.Fatal("RenameConfiguration() succeeded, want the simulated failure")
+			}
+			assertTree(t, dir, before)
+		})
+	}
+}
+
+func TestOverwriteConfigurationFailureRestores(t *testing.T) {
+	fake, dir := installSnapshotFake(t)
+	before := readTree(t, dir)
+	fake.Fail("config set compute/region", "ERROR: (gcloud.config.set) simulated failure")
+
+	// container/cluster is unset and the account and project are set before
+	// the region fails
+	err := gcloud.OverwriteConfiguration("prod", []gcloud.PropertySetting{
+		{Property: "core/account", Value: "new@example.com"},
+		{Property: "core/project", Value: "new-project"},
+		{Property: "compute/region", Value: "europe-west1"},
+	})
+
+	if err == nil {
+		t.Fatal("OverwriteConfiguration() succeeded, want the simulated failure")
+	}
+	assertTree(t, dir, before)
+}
@@ -1,8 +1,10 @@
-// Package gcloud provides functionality to interact with Google Cloud SDK configurations.
-// It wraps the gcloud CLI commands and provides a convenient Go interface for managing
-// configurations, activating them, and synchronizing Application Default Credentials.
 package gcloud
 
+import (
+	"encoding/json"
+	"strconv"
+)
+
 // Configuration represents a gcloud configuration
 type Configuration struct {
 	Name       string     `json:"name"`
@@ -14,6 +16,29 @@ type Configuration struct {
 type Properties struct {
 	Core    CoreProperties    `json:"core,omitempty"`
 	Compute ComputeProperties `json:"compute,omitempty"`
+
+	// Sections holds every property section as reported by gcloud, including
+	// sections without typed fields (auth, billing, container, ...)
+	Sections map[string]map[string]any `json:"-"`
+}
+
+// UnmarshalJSON decodes the typed sections and keeps all raw sections
+func (p *Properties) UnmarshalJSON(data []byte) error {
+	// typedProperties has no methods, so decoding into it does not recurse
+	type typedProperties Properties
+	var typed typedProperties
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return err
+	}
+
+	var sections map[string]map[string]any
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return err
+	}
+
+	*p = Properties(typed)
+	p.Sections = sections
+	return nil
 }
 
 // CoreProperties represents core configuration properties
@@ -23,8 +48,63 @@ type CoreProperties struct {
 	DisableUsageReport bool   `json:"disable_usage_reporting,omitempty"`
 }
 
+// UnmarshalJSON decodes the core properties, accepting disable_usage_reporting
+// both as a boolean and as the string gcloud reports ("True" or "False")
+func (c *CoreProperties) UnmarshalJSON(data []byte) error {
+	// typedCore has no methods, so decoding into it does not recurse; the outer
+	// field takes disable_usage_reporting from it
+	type typedCore CoreProperties
+	var raw struct {
+		typedCore
+		DisableUsageReport any `json:"disable_usage_reporting"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c = CoreProperties(raw.typedCore)
+	switch v := raw.DisableUsageReport.(type) {
+	case bool:
+		c.DisableUsageReport = v
+	case string:
+		c.DisableUsageReport, _ = strconv.ParseBool(v)
+	}
+	return nil
+}
+
+// UsageReportingDisabled returns core/disable_usage_reporting and whether it
+// is set at all; configurations built without raw sections only know it is set
+// when it is true
+func (p Properties) UsageReportingDisabled() (disabled, set bool) {
+	if p.Sections == nil {
+		return p.Core.DisableUsageReport, p.Core.DisableUsageReport
+	}
+	raw, ok := p.Sections["core"]["disable_usage_reporting"]
+	if !ok {
+		return false, false
+	}
+	disabled, err := strconv.ParseBool(formatPropertyValue(raw))
+	return disabled, err == nil
+}
+
 // ComputeProperties represents compute configuration properties
 type ComputeProperties struct {
 	Region string `json:"region,omitempty"`
 	Zone   string `json:"zone,omitempty"`
 }
+
+// Project represents a Google Cloud project as returned by gcloud projects list
+type Project struct {
+	ProjectID      string            `json:"projectId"`
+	Name           string            `json:"name,omitempty"`
+	ProjectNumber  string            `json:"projectNumber,omitempty"`
+	LifecycleState string            `json:"lifecycleState,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Parent         *ProjectParent    `json:"parent,omitempty"`
+}
+
+// ProjectParent represents the organization or folder a project belongs to
+type ProjectParent struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
@@ -0,0 +1,99 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lastActiveFileName records the active configuration gcloudctx last saw,
+// keyed by gcloud configuration directory
+const lastActiveFileName = ".gcloudctx_last_active"
+
+// GetLastActiveFilePath returns the path to the file recording the active
+// configuration gcloudctx last saw
+func GetLastActiveFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return LastActiveFilePathIn(homeDir), nil
+}
+
+// LastActiveFilePathIn returns the path of the last-active file kept in a state directory
+func LastActiveFilePathIn(dir string) string {
+	return filepath.Join(dir, lastActiveFileName)
+}
+
+// RememberActiveAt records active as the active configuration of configDir in
+// the last-active file at path
+func RememberActiveAt(path, configDir, active string) error {
+	seen, err := readLastActive(path)
+	if err != nil {
+		return err
+	}
+	if seen[configDir] == active {
+		return nil
+	}
+	seen[configDir] = active
+	return writeLastActive(path, seen)
+}
+
+// ReconcileActiveAt compares active with the active configuration of configDir
+// recorded in the last-active file at lastActivePath. When they differ, the
+// configuration was switched without gcloudctx: the switch is recorded in the
+// history file at historyPath as SourceExternal and the configuration switched
+// away from is returned. Nothing is recorded the first time a directory is seen.
+func ReconcileActiveAt(lastActivePath, historyPath, configDir, active string) (string, error) {
+	seen, err := readLastActive(lastActivePath)
+	if err != nil {
+		return "", err
+	}
+	last, known := seen[configDir]
+	if known && last == active {
+		return "", nil
+	}
+
+	if known {
+		if err := RecordExternalSwitchAt(historyPath, last, active); err != nil {
+			return "", err
+		}
+	}
+	seen[configDir] = active
+	if err := writeLastActive(lastActivePath, seen); err != nil {
+		return "", err
+	}
+	if !known {
+		return "", nil
+	}
+	return last, nil
+}
+
+// readLastActive reads the directory-to-configuration map of the last-active file
+func readLastActive(path string) (map[string]string, error) {
+	seen := map[string]string{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return seen, nil
+		}
+		return nil, fmt.Errorf("failed to read last active configuration: %w", err)
+	}
+	if err := json.Unmarshal(data, &seen); err != nil {
+		return nil, fmt.Errorf("failed to parse last active configuration: %w", err)
+	}
+	return seen, nil
+}
+
+// writeLastActive writes the directory-to-configuration map of the last-active file
+func writeLastActive(path string, seen map[string]string) error {
+	data, err := json.MarshalIndent(seen, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode last active configuration: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save last active configuration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package history
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestReconcileActive(t *testing.T) {
+	dir := t.TempDir()
+	lastActive := LastActiveFilePathIn(dir)
+	historyPath := HistoryFilePathIn(dir)
+
+	// The first sighting of a directory records nothing
+	if from, err := ReconcileActiveAt(lastActive, historyPath, "/gcloud", "dev"); err != nil || from != "" {
+		t.Fatalf("ReconcileActiveAt() = %q, %v; want nothing on first sight", from, err)
+	}
+	if from, err := ReconcileActiveAt(lastActive, historyPath, "/gcloud", "dev"); err != nil || from != "" {
+		t.Fatalf("ReconcileActiveAt() = %q, %v; want nothing when unchanged", from, err)
+	}
+
+	from, err := ReconcileActiveAt(lastActive, historyPath, "/gcloud", "prod")
+	if err != nil || from != "dev" {
+		t.Fatalf("ReconcileActiveAt() = %q, %v; want dev", from, err)
+	}
+	entries, err := readEntries(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Entry{{Name: "dev", Source: SourceExternal}}; !reflect.DeepEqual(entries, want) {
+		t.Errorf("history = %+v, want %+v", entries, want)
+	}
+
+	// Directories are tracked separately
+	if from, err := ReconcileActiveAt(lastActive, historyPath, "/other", "qa"); err != nil || from != "" {
+		t.Errorf("ReconcileActiveAt(other dir) = %q, %v; want nothing on first sight", from, err)
+	}
+}
+
+func TestRememberActive(t *testing.T) {
+	dir := t.TempDir()
+	lastActive := LastActiveFilePathIn(dir)
+	historyPath := HistoryFilePathIn(dir)
+
+	if err := RememberActiveAt(lastActive, "/gcloud", "dev"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RememberActiveAt(lastActive, "/gcloud", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if from, err := ReconcileActiveAt(lastActive, historyPath, "/gcloud", "prod"); err != nil || from != "" {
+		t.Errorf("ReconcileActiveAt() = %q, %v; want nothing after a remembered switch", from, err)
+	}
+	if _, err := os.Stat(historyPath); !os.IsNotExist(err) {
+		t.Errorf("history written for a remembered switch: %v", err)
+	}
+}
+
+func TestEntriesSourceRoundTrip(t *testing.T) {
+	path := HistoryFilePathIn(t.TempDir())
+	if err := SavePreviousConfigAt(path, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordExternalSwitchAt(path, "prod", "qa"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readEntries(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{{Name: "prod", Source: SourceExternal}, {Name: "dev", Source: SourceGcloudctx}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries = %+v, want %+v", entries, want)
+	}
+
+	// Names-only files from before sources were recorded read as gcloudctx switches
+	if err := os.WriteFile(path, []byte("staging\ndev"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = readEntries(path)
+	if err != nil || len(entries) != 2 || entries[0].Source != SourceGcloudctx {
+		t.Errorf("legacy entries = %+v, %v; want gcloudctx sources", entries, err)
+	}
+}
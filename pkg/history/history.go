@@ -1,15 +1,62 @@
 // Package history manages the history of previously used gcloud configurations.
-// It stores the last active configuration to enable quick switching with the "-" shorthand.
+// It stores the recently active configurations, most recent first, to enable quick
+// switching with the "-" and "-N" shorthands.
 package history
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
-const historyFileName = ".gcloudctx_previous"
+const (
+	historyFileName = ".gcloudctx_previous"
+
+	// maxHistoryEntries is the number of previous configurations remembered
+	maxHistoryEntries = 10
+)
+
+// ErrNoPreviousConfig is returned when the history has no usable entry
+var ErrNoPreviousConfig = errors.New("no previous configuration found")
+
+// ErrHistoryDisabled is returned when reading the history while it is disabled
+var ErrHistoryDisabled = errors.New("history is disabled (history.enabled is false in ~/.gcloudctx.yaml)")
+
+// enabled is whether switches are tracked; see SetEnabled
+var enabled = true
+
+// SetEnabled turns tracking switches on or off for the rest of the process
+// While it is off, RecordSwitch and RecordUsage write nothing and reading the
+// history fails with ErrHistoryDisabled. The functions taking a path are not affected.
+func SetEnabled(on bool) {
+	enabled = on
+}
+
+// Enabled reports whether switches are tracked
+func Enabled() bool {
+	return enabled
+}
+
+// Source is what made the switch away from a configuration in the history
+type Source string
+
+// Sources of history entries
+const (
+	// SourceGcloudctx marks switches made through gcloudctx
+	SourceGcloudctx Source = "gcloudctx"
+	// SourceExternal marks switches made without gcloudctx, such as
+	// 'gcloud config configurations activate', noticed the next time it ran
+	SourceExternal Source = "external"
+)
+
+// Entry is a previous configuration in the history
+type Entry struct {
+	Name   string `json:"name" yaml:"name"`
+	Source Source `json:"source" yaml:"source"`
+}
 
 // GetHistoryFilePath returns the path to the history file
 func GetHistoryFilePath() (string, error) {
@@ -17,44 +64,232 @@ func GetHistoryFilePath() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
-	return filepath.Join(homeDir, historyFileName), nil
+	return HistoryFilePathIn(homeDir), nil
+}
+
+// HistoryFilePathIn returns the path of the history file kept in a state directory
+func HistoryFilePathIn(dir string) string {
+	return filepath.Join(dir, historyFileName)
 }
 
-// SavePreviousConfig saves the previous configuration name to the history file
+// SavePreviousConfig records name as the most recent previous configuration
+// Older entries are kept (without duplicates) up to maxHistoryEntries; an empty name clears the history
 func SavePreviousConfig(name string) error {
 	path, err := GetHistoryFilePath()
 	if err != nil {
 		return err
 	}
+	return SavePreviousConfigAt(path, name)
+}
 
-	if err := os.WriteFile(path, []byte(name), 0o600); err != nil {
-		return fmt.Errorf("failed to save previous configuration: %w", err)
+// SavePreviousConfigAt is SavePreviousConfig for the history file at path
+func SavePreviousConfigAt(path, name string) error {
+	if name == "" {
+		return writeHistory(path, nil)
 	}
+	return pushEntry(path, Entry{Name: name, Source: SourceGcloudctx})
+}
 
-	return nil
+// pushEntry puts entry at the top of the history file at path, dropping older
+// entries for the same configuration and those beyond maxHistoryEntries
+func pushEntry(path string, entry Entry) error {
+	entries, err := readEntries(path)
+	if err != nil {
+		return err
+	}
+
+	updated := []Entry{entry}
+	for _, existing := range entries {
+		if existing.Name != entry.Name && len(updated) < maxHistoryEntries {
+			updated = append(updated, existing)
+		}
+	}
+
+	return writeHistory(path, updated)
 }
 
-// GetPreviousConfig retrieves the previous configuration name from the history file
-func GetPreviousConfig() (string, error) {
+// RecordSwitch records a switch from one configuration to another in the history
+// Nothing is written for a no-op switch (from equals to) or when from is unknown,
+// so the toggle target of '-' is never replaced by the configuration itself
+func RecordSwitch(from, to string) error {
+	if !enabled {
+		return nil
+	}
 	path, err := GetHistoryFilePath()
+	if err != nil {
+		return err
+	}
+	return RecordSwitchAt(path, from, to)
+}
+
+// RecordSwitchAt is RecordSwitch for the history file at path
+func RecordSwitchAt(path, from, to string) error {
+	if from == "" || from == to {
+		return nil
+	}
+	return SavePreviousConfigAt(path, from)
+}
+
+// RecordExternalSwitchAt is RecordSwitchAt for a switch made without gcloudctx
+func RecordExternalSwitchAt(path, from, to string) error {
+	if from == "" || from == to {
+		return nil
+	}
+	return pushEntry(path, Entry{Name: from, Source: SourceExternal})
+}
+
+// GetPreviousConfig retrieves the most recent previous configuration name from the history file
+func GetPreviousConfig() (string, error) {
+	entries, err := GetHistory()
 	if err != nil {
 		return "", err
 	}
 
+	if len(entries) == 0 {
+		return "", ErrNoPreviousConfig
+	}
+
+	return entries[0], nil
+}
+
+// GetHistory returns the previous configuration names, most recent first
+// A missing history file yields an empty history
+func GetHistory() ([]string, error) {
+	if !enabled {
+		return nil, ErrHistoryDisabled
+	}
+	path, err := GetHistoryFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return readHistory(path)
+}
+
+// GetEntries returns the previous configurations with what switched away from
+// them, most recent first
+func GetEntries() ([]Entry, error) {
+	if !enabled {
+		return nil, ErrHistoryDisabled
+	}
+	path, err := GetHistoryFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return readEntries(path)
+}
+
+// readHistory reads the configuration names of the history file at path
+func readHistory(path string) ([]string, error) {
+	entries, err := readEntries(path)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name
+	}
+	return names, nil
+}
+
+// readEntries reads the history entries from the file at path
+// Each line holds a name, followed by a tab and the source unless gcloudctx
+// made the switch; files written before sources were recorded hold names only
+func readEntries(path string) ([]Entry, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", fmt.Errorf("no previous configuration found")
+			return nil, nil
 		}
-		return "", fmt.Errorf("failed to read previous configuration: %w", err)
+		return nil, fmt.Errorf("failed to read previous configuration: %w", err)
 	}
 
-	name := strings.TrimSpace(string(data))
-	if name == "" {
-		return "", fmt.Errorf("no previous configuration found")
+	var entries []Entry
+	for _, line := range strings.Split(string(data), "\n") {
+		name, source, _ := strings.Cut(line, "\t")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		entry := Entry{Name: name, Source: SourceGcloudctx}
+		if source := strings.TrimSpace(source); source != "" {
+			entry.Source = Source(source)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// RemoveFromHistory drops every entry for the given configuration names
+func RemoveFromHistory(names ...string) error {
+	path, err := GetHistoryFilePath()
+	if err != nil {
+		return err
+	}
+	entries, err := readEntries(path)
+	if err != nil {
+		return err
+	}
+
+	var kept []Entry
+	for _, entry := range entries {
+		if !slices.Contains(names, entry.Name) {
+			kept = append(kept, entry)
+		}
 	}
 
-	return name, nil
+	if len(kept) == len(entries) {
+		return nil
+	}
+
+	return writeHistory(path, kept)
+}
+
+// ResolvePrevious returns the n-th (1-based) previous configuration that still exists
+// Entries for which exists reports false are skipped and returned as stale so the
+// caller can report and remove them; entries equal to current are skipped as well
+func ResolvePrevious(entries []string, n int, current string, exists func(string) bool) (string, []string, error) {
+	if n < 1 {
+		return "", nil, fmt.Errorf("invalid history position %d", n)
+	}
+
+	var stale []string
+	position := 0
+	for _, entry := range entries {
+		if entry == current {
+			continue
+		}
+		if !exists(entry) {
+			stale = append(stale, entry)
+			continue
+		}
+
+		position++
+		if position == n {
+			return entry, stale, nil
+		}
+	}
+
+	if n == 1 || position == 0 {
+		return "", stale, ErrNoPreviousConfig
+	}
+	return "", stale, fmt.Errorf("only %d previous configuration(s) in history", position)
+}
+
+// writeHistory writes the history entries to the file at path
+func writeHistory(path string, entries []Entry) error {
+	lines := make([]string, len(entries))
+	for i, entry := range entries {
+		lines[i] = entry.Name
+		if entry.Source != "" && entry.Source != SourceGcloudctx {
+			lines[i] += "\t" + string(entry.Source)
+		}
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		return fmt.Errorf("failed to save previous configuration: %w", err)
+	}
+
+	return nil
 }
 
 // ClearHistory removes the history file
@@ -1,8 +1,10 @@
 package history
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -136,3 +138,254 @@ func TestSaveEmptyConfig(t *testing.T) {
 		t.Error("Expected error when retrieving empty config, got nil")
 	}
 }
+
+// fakeConfigList reports which configuration names exist, standing in for gcloud
+func fakeConfigList(names ...string) func(string) bool {
+	return func(name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func TestSavePreviousConfigKeepsMultipleEntries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, name := range []string{"a", "b", "c", "a"} {
+		if err := SavePreviousConfig(name); err != nil {
+			t.Fatalf("SavePreviousConfig(%q) failed: %v", name, err)
+		}
+	}
+
+	entries, err := GetHistory()
+	if err != nil {
+		t.Fatalf("GetHistory failed: %v", err)
+	}
+	want := []string{"a", "c", "b"}
+	if !slices.Equal(entries, want) {
+		t.Errorf("GetHistory() = %v, want %v", entries, want)
+	}
+}
+
+func TestSavePreviousConfigCapsEntries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for i := range maxHistoryEntries + 5 {
+		if err := SavePreviousConfig(fmt.Sprintf("config-%d", i)); err != nil {
+			t.Fatalf("SavePreviousConfig failed: %v", err)
+		}
+	}
+
+	entries, err := GetHistory()
+	if err != nil {
+		t.Fatalf("GetHistory failed: %v", err)
+	}
+	if len(entries) != maxHistoryEntries {
+		t.Errorf("len(GetHistory()) = %d, want %d", len(entries), maxHistoryEntries)
+	}
+}
+
+func TestGetHistoryLegacySingleEntry(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := os.WriteFile(filepath.Join(home, historyFileName), []byte("legacy\n"), 0o600); err != nil {
+		t.Fatalf("failed to write history: %v", err)
+	}
+
+	name, err := GetPreviousConfig()
+	if err != nil {
+		t.Fatalf("GetPreviousConfig failed: %v", err)
+	}
+	if name != "legacy" {
+		t.Errorf("GetPreviousConfig() = %q, want %q", name, "legacy")
+	}
+}
+
+func TestRemoveFromHistory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := SavePreviousConfig(name); err != nil {
+			t.Fatalf("SavePreviousConfig failed: %v", err)
+		}
+	}
+
+	if err := RemoveFromHistory("b", "missing"); err != nil {
+		t.Fatalf("RemoveFromHistory failed: %v", err)
+	}
+
+	entries, err := GetHistory()
+	if err != nil {
+		t.Fatalf("GetHistory failed: %v", err)
+	}
+	want := []string{"c", "a"}
+	if !slices.Equal(entries, want) {
+		t.Errorf("GetHistory() = %v, want %v", entries, want)
+	}
+}
+
+func TestResolvePrevious(t *testing.T) {
+	tests := []struct {
+		name      string
+		entries   []string
+		n         int
+		current   string
+		existing  []string
+		want      string
+		wantStale []string
+		wantErr   bool
+	}{
+		{
+			name:     "previous exists",
+			entries:  []string{"dev", "prod"},
+			n:        1,
+			existing: []string{"dev", "prod"},
+			want:     "dev",
+		},
+		{
+			name:      "previous deleted falls back to next entry",
+			entries:   []string{"old-name", "prod"},
+			n:         1,
+			existing:  []string{"prod", "new-name"},
+			want:      "prod",
+			wantStale: []string{"old-name"},
+		},
+		{
+			name:      "only entry deleted",
+			entries:   []string{"old-name"},
+			n:         1,
+			existing:  []string{"prod"},
+			wantStale: []string{"old-name"},
+			wantErr:   true,
+		},
+		{
+			name:      "numeric jump skips stale entries",
+			entries:   []string{"a", "gone", "b", "c"},
+			n:         2,
+			existing:  []string{"a", "b", "c"},
+			want:      "b",
+			wantStale: []string{"gone"},
+		},
+		{
+			name:     "numeric jump beyond history",
+			entries:  []string{"a", "b"},
+			n:        3,
+			existing: []string{"a", "b"},
+			wantErr:  true,
+		},
+		{
+			name:     "current configuration is skipped",
+			entries:  []string{"prod", "dev"},
+			n:        1,
+			current:  "prod",
+			existing: []string{"prod", "dev"},
+			want:     "dev",
+		},
+		{
+			name:    "empty history",
+			n:       1,
+			wantErr: true,
+		},
+		{
+			name:     "invalid position",
+			entries:  []string{"a"},
+			n:        0,
+			existing: []string{"a"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stale, err := ResolvePrevious(tt.entries, tt.n, tt.current, fakeConfigList(tt.existing...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolvePrevious() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolvePrevious() = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(stale, tt.wantStale) {
+				t.Errorf("ResolvePrevious() stale = %v, want %v", stale, tt.wantStale)
+			}
+		})
+	}
+}
+
+func TestRecordSwitchSkipsNoOps(t *testing.T) {
+	path := HistoryFilePathIn(t.TempDir())
+
+	if err := RecordSwitchAt(path, "prod", "prod"); err != nil {
+		t.Fatalf("RecordSwitchAt failed: %v", err)
+	}
+	if err := RecordSwitchAt(path, "", "prod"); err != nil {
+		t.Fatalf("RecordSwitchAt failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("history should not be written for no-op switches")
+	}
+}
+
+func TestRecordSwitchToggle(t *testing.T) {
+	path := HistoryFilePathIn(t.TempDir())
+	exists := func(string) bool { return true }
+
+	active := "a"
+	switchTo := func(target string) {
+		t.Helper()
+		if err := RecordSwitchAt(path, active, target); err != nil {
+			t.Fatalf("RecordSwitchAt failed: %v", err)
+		}
+		active = target
+	}
+	// toggle resolves '-' and switches to it, like 'gcloudctx -'
+	toggle := func() string {
+		t.Helper()
+		entries, err := readHistory(path)
+		if err != nil {
+			t.Fatalf("readHistory failed: %v", err)
+		}
+		previous, _, err := ResolvePrevious(entries, 1, active, exists)
+		if err != nil {
+			t.Fatalf("ResolvePrevious failed: %v", err)
+		}
+		switchTo(previous)
+		return previous
+	}
+
+	// A→B→B→A, then '-' must keep alternating between the pair
+	switchTo("b")
+	switchTo("b")
+	switchTo("a")
+	for i, want := range []string{"b", "a", "b", "a"} {
+		if got := toggle(); got != want {
+			t.Fatalf("toggle %d landed on %q, want %q", i+1, got, want)
+		}
+	}
+
+	entries, err := readHistory(path)
+	if err != nil {
+		t.Fatalf("readHistory failed: %v", err)
+	}
+	if len(entries) > 0 && entries[0] == active {
+		t.Errorf("history %v starts with the active configuration %q", entries, active)
+	}
+}
+
+func BenchmarkGetHistory(b *testing.B) {
+	b.Setenv("HOME", b.TempDir())
+	for i := range maxHistoryEntries {
+		if err := SavePreviousConfig(fmt.Sprintf("config-%d", i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for b.Loop() {
+		if _, err := GetHistory(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
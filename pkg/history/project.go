@@ -0,0 +1,102 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const projectHistoryFileName = ".gcloudctx_previous_projects"
+
+// GetProjectHistoryFilePath returns the path to the per-configuration project history file
+func GetProjectHistoryFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, projectHistoryFileName), nil
+}
+
+// loadProjectHistory reads the configuration-to-project map from the history file
+func loadProjectHistory() (map[string]string, error) {
+	path, err := GetProjectHistoryFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	projects := map[string]string{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return projects, nil
+		}
+		return nil, fmt.Errorf("failed to read project history: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &projects); err != nil {
+		return nil, fmt.Errorf("failed to parse project history: %w", err)
+	}
+
+	return projects, nil
+}
+
+// saveProjectHistory writes the configuration-to-project map to the history file
+func saveProjectHistory(projects map[string]string) error {
+	path, err := GetProjectHistoryFilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(projects, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode project history: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save project history: %w", err)
+	}
+
+	return nil
+}
+
+// SavePreviousProject saves the previous project of a configuration
+func SavePreviousProject(configName, project string) error {
+	projects, err := loadProjectHistory()
+	if err != nil {
+		return err
+	}
+
+	projects[configName] = project
+	return saveProjectHistory(projects)
+}
+
+// GetPreviousProject retrieves the previous project of a configuration
+func GetPreviousProject(configName string) (string, error) {
+	projects, err := loadProjectHistory()
+	if err != nil {
+		return "", err
+	}
+
+	project := projects[configName]
+	if project == "" {
+		return "", fmt.Errorf("no previous project found for configuration %q", configName)
+	}
+
+	return project, nil
+}
+
+// ClearPreviousProject removes the previous project entry of a configuration
+func ClearPreviousProject(configName string) error {
+	projects, err := loadProjectHistory()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := projects[configName]; !ok {
+		return nil
+	}
+
+	delete(projects, configName)
+	return saveProjectHistory(projects)
+}
@@ -0,0 +1,86 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetProjectHistoryFilePath(t *testing.T) {
+	path, err := GetProjectHistoryFilePath()
+	if err != nil {
+		t.Fatalf("GetProjectHistoryFilePath failed: %v", err)
+	}
+	if !filepath.IsAbs(path) {
+		t.Error("Expected absolute path")
+	}
+}
+
+func TestSaveAndGetPreviousProject(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := SavePreviousProject("review", "project-a"); err != nil {
+		t.Fatalf("SavePreviousProject failed: %v", err)
+	}
+	if err := SavePreviousProject("other", "project-x"); err != nil {
+		t.Fatalf("SavePreviousProject failed: %v", err)
+	}
+
+	retrieved, err := GetPreviousProject("review")
+	if err != nil {
+		t.Fatalf("GetPreviousProject failed: %v", err)
+	}
+	if retrieved != "project-a" {
+		t.Errorf("Expected %q, got %q", "project-a", retrieved)
+	}
+
+	// Overwriting one configuration must not affect others
+	if err := SavePreviousProject("review", "project-b"); err != nil {
+		t.Fatalf("SavePreviousProject failed: %v", err)
+	}
+	if retrieved, _ := GetPreviousProject("review"); retrieved != "project-b" {
+		t.Errorf("Expected %q, got %q", "project-b", retrieved)
+	}
+	if retrieved, _ := GetPreviousProject("other"); retrieved != "project-x" {
+		t.Errorf("Expected %q, got %q", "project-x", retrieved)
+	}
+}
+
+func TestGetPreviousProjectNotFound(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := GetPreviousProject("missing"); err == nil {
+		t.Error("Expected error when no previous project exists, got nil")
+	}
+}
+
+func TestClearPreviousProject(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := SavePreviousProject("deleted", "project-a"); err != nil {
+		t.Fatalf("SavePreviousProject failed: %v", err)
+	}
+	if err := ClearPreviousProject("deleted"); err != nil {
+		t.Fatalf("ClearPreviousProject failed: %v", err)
+	}
+	if _, err := GetPreviousProject("deleted"); err == nil {
+		t.Error("Expected error after ClearPreviousProject, got nil")
+	}
+
+	// Clearing a missing entry is not an error
+	if err := ClearPreviousProject("never-saved"); err != nil {
+		t.Errorf("ClearPreviousProject on missing entry failed: %v", err)
+	}
+}
+
+func TestLoadProjectHistoryCorrupt(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := os.WriteFile(filepath.Join(home, projectHistoryFileName), []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("failed to write corrupt history: %v", err)
+	}
+	if _, err := GetPreviousProject("any"); err == nil {
+		t.Error("Expected error for corrupt project history, got nil")
+	}
+}
@@ -0,0 +1,256 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	usageFileName = ".gcloudctx_usage"
+
+	// maxUsageEntries bounds the usage file; the least recently used
+	// configurations are forgotten first
+	maxUsageEntries = 500
+)
+
+// Usage records how a configuration has been used through gcloudctx
+type Usage struct {
+	// LastUsed is when gcloudctx last switched to the configuration
+	LastUsed time.Time `json:"last_used"`
+	// Switches counts how many times gcloudctx switched to the configuration
+	Switches int `json:"switches"`
+}
+
+// UnmarshalJSON also accepts a bare timestamp, the format of files that
+// recorded only the last-used time
+func (u *Usage) UnmarshalJSON(data []byte) error {
+	var at time.Time
+	if err := json.Unmarshal(data, &at); err == nil {
+		*u = Usage{LastUsed: at}
+		return nil
+	}
+
+	type plain Usage
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+// GetUsageFilePath returns the path to the file recording how each configuration was used
+func GetUsageFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return UsageFilePathIn(homeDir), nil
+}
+
+// UsageFilePathIn returns the path of the usage file kept in a state directory
+func UsageFilePathIn(dir string) string {
+	return filepath.Join(dir, usageFileName)
+}
+
+// readUsage reads the configuration-to-usage map from the usage file at path
+func readUsage(path string) (map[string]Usage, error) {
+	usage := map[string]Usage{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return usage, nil
+		}
+		return nil, fmt.Errorf("failed to read usage history: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, fmt.Errorf("failed to parse usage history: %w", err)
+	}
+
+	return usage, nil
+}
+
+// writeUsage writes the configuration-to-usage map to the usage file at path
+// The file is replaced atomically so a concurrent reader never sees a partial write
+func writeUsage(path string, usage map[string]Usage) error {
+	capUsage(usage, maxUsageEntries)
+
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode usage history: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), usageFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save usage history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save usage history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save usage history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save usage history: %w", err)
+	}
+
+	return nil
+}
+
+// capUsage drops the least recently used entries beyond limit
+func capUsage(usage map[string]Usage, limit int) {
+	if len(usage) <= limit {
+		return
+	}
+
+	names := slices.SortedFunc(maps.Keys(usage), func(a, b string) int {
+		return usage[b].LastUsed.Compare(usage[a].LastUsed)
+	})
+	for _, name := range names[limit:] {
+		delete(usage, name)
+	}
+}
+
+// RecordUsage records that gcloudctx switched to a configuration at the given time
+func RecordUsage(configName string, at time.Time) error {
+	if !enabled {
+		return nil
+	}
+	path, err := GetUsageFilePath()
+	if err != nil {
+		return err
+	}
+	return RecordUsageAt(path, configName, at)
+}
+
+// RecordUsageAt is RecordUsage for the usage file at path
+func RecordUsageAt(path, configName string, at time.Time) error {
+	usage, err := readUsage(path)
+	if err != nil {
+		return err
+	}
+
+	entry := usage[configName]
+	entry.LastUsed = at.UTC()
+	entry.Switches++
+	usage[configName] = entry
+	return writeUsage(path, usage)
+}
+
+// LoadUsage returns how each configuration has been used through gcloudctx
+// Configurations never switched to are absent from the map
+func LoadUsage() (map[string]Usage, error) {
+	path, err := GetUsageFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return readUsage(path)
+}
+
+// LastUsed returns when gcloudctx last switched to each configuration
+// Configurations never switched to are absent from the map
+func LastUsed() (map[string]time.Time, error) {
+	usage, err := LoadUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	lastUsed := make(map[string]time.Time, len(usage))
+	for name, entry := range usage {
+		lastUsed[name] = entry.LastUsed
+	}
+	return lastUsed, nil
+}
+
+// RemoveUsage drops the usage entries of the given configurations
+func RemoveUsage(configNames ...string) error {
+	path, err := GetUsageFilePath()
+	if err != nil {
+		return err
+	}
+
+	usage, err := readUsage(path)
+	if err != nil {
+		return err
+	}
+
+	removed := false
+	for _, name := range configNames {
+		if _, ok := usage[name]; ok {
+			delete(usage, name)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+
+	return writeUsage(path, usage)
+}
+
+// RenameUsage moves the usage entry of oldName to newName
+func RenameUsage(oldName, newName string) error {
+	path, err := GetUsageFilePath()
+	if err != nil {
+		return err
+	}
+
+	usage, err := readUsage(path)
+	if err != nil {
+		return err
+	}
+
+	entry, ok := usage[oldName]
+	if !ok {
+		return nil
+	}
+
+	delete(usage, oldName)
+	usage[newName] = entry
+	return writeUsage(path, usage)
+}
+
+// ClearUsage forgets all usage, e.g. to restart switch counts
+func ClearUsage() error {
+	path, err := GetUsageFilePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear usage history: %w", err)
+	}
+
+	return nil
+}
+
+// UsageEntry is the usage of one configuration
+type UsageEntry struct {
+	Name  string
+	Usage Usage
+}
+
+// RankBySwitches orders usage by switch count, most switched first; ties go to
+// the most recently used configuration, then to the name
+func RankBySwitches(usage map[string]Usage) []UsageEntry {
+	entries := make([]UsageEntry, 0, len(usage))
+	for name, u := range usage {
+		entries = append(entries, UsageEntry{Name: name, Usage: u})
+	}
+
+	slices.SortFunc(entries, func(a, b UsageEntry) int {
+		if a.Usage.Switches != b.Usage.Switches {
+			return b.Usage.Switches - a.Usage.Switches
+		}
+		if c := b.Usage.LastUsed.Compare(a.Usage.LastUsed); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return entries
+}
@@ -0,0 +1,190 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordUsageAndLastUsed(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	usage, err := LastUsed()
+	if err != nil {
+		t.Fatalf("LastUsed failed: %v", err)
+	}
+	if len(usage) != 0 {
+		t.Errorf("Expected no usage before any switch, got %v", usage)
+	}
+
+	first := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	second := first.Add(48 * time.Hour)
+	if err := RecordUsage("prod", first); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+	if err := RecordUsage("dev", first); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+	if err := RecordUsage("prod", second); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+
+	usage, err = LastUsed()
+	if err != nil {
+		t.Fatalf("LastUsed failed: %v", err)
+	}
+	if !usage["prod"].Equal(second) {
+		t.Errorf("prod last used %v, want %v", usage["prod"], second)
+	}
+	if !usage["dev"].Equal(first) {
+		t.Errorf("dev last used %v, want %v", usage["dev"], first)
+	}
+	if _, ok := usage["never"]; ok {
+		t.Error("Expected no entry for a configuration never switched to")
+	}
+}
+
+func TestRemoveAndRenameUsage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for _, name := range []string{"old", "deleted", "kept"} {
+		if err := RecordUsage(name, at); err != nil {
+			t.Fatalf("RecordUsage failed: %v", err)
+		}
+	}
+
+	if err := RemoveUsage("deleted", "missing"); err != nil {
+		t.Fatalf("RemoveUsage failed: %v", err)
+	}
+	if err := RenameUsage("old", "new"); err != nil {
+		t.Fatalf("RenameUsage failed: %v", err)
+	}
+	if err := RenameUsage("missing", "other"); err != nil {
+		t.Fatalf("RenameUsage of a missing entry failed: %v", err)
+	}
+
+	usage, err := LastUsed()
+	if err != nil {
+		t.Fatalf("LastUsed failed: %v", err)
+	}
+	for _, gone := range []string{"old", "deleted", "other"} {
+		if _, ok := usage[gone]; ok {
+			t.Errorf("Expected no entry for %q", gone)
+		}
+	}
+	for _, present := range []string{"new", "kept"} {
+		if !usage[present].Equal(at) {
+			t.Errorf("%s last used %v, want %v", present, usage[present], at)
+		}
+	}
+}
+
+func TestRecordUsageCountsSwitches(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		if err := RecordUsage("prod", at.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("RecordUsage failed: %v", err)
+		}
+	}
+	if err := RecordUsage("dev", at); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+
+	usage, err := LoadUsage()
+	if err != nil {
+		t.Fatalf("LoadUsage failed: %v", err)
+	}
+	if got := usage["prod"].Switches; got != 3 {
+		t.Errorf("prod switches = %d, want 3", got)
+	}
+	if got := usage["dev"].Switches; got != 1 {
+		t.Errorf("dev switches = %d, want 1", got)
+	}
+
+	if err := ClearUsage(); err != nil {
+		t.Fatalf("ClearUsage failed: %v", err)
+	}
+	if usage, _ := LoadUsage(); len(usage) != 0 {
+		t.Errorf("Expected no usage after ClearUsage, got %v", usage)
+	}
+	if err := ClearUsage(); err != nil {
+		t.Errorf("ClearUsage without a usage file failed: %v", err)
+	}
+}
+
+func TestReadUsageAcceptsTimestampOnlyEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), usageFileName)
+	if err := os.WriteFile(path, []byte(`{"prod": "2026-03-01T09:00:00Z"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := readUsage(path)
+	if err != nil {
+		t.Fatalf("readUsage failed: %v", err)
+	}
+	want := Usage{LastUsed: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	if !usage["prod"].LastUsed.Equal(want.LastUsed) || usage["prod"].Switches != 0 {
+		t.Errorf("usage = %+v, want %+v", usage["prod"], want)
+	}
+}
+
+func TestWriteUsageIsCapped(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, usageFileName)
+
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	usage := map[string]Usage{}
+	for i := range maxUsageEntries + 5 {
+		usage[fmt.Sprintf("config-%03d", i)] = Usage{LastUsed: at.Add(time.Duration(i) * time.Minute), Switches: 1}
+	}
+	if err := writeUsage(path, usage); err != nil {
+		t.Fatalf("writeUsage failed: %v", err)
+	}
+
+	got, err := readUsage(path)
+	if err != nil {
+		t.Fatalf("readUsage failed: %v", err)
+	}
+	if len(got) != maxUsageEntries {
+		t.Errorf("kept %d entries, want %d", len(got), maxUsageEntries)
+	}
+	// The least recently used entries are the ones dropped
+	for i := range 5 {
+		if _, ok := got[fmt.Sprintf("config-%03d", i)]; ok {
+			t.Errorf("expected config-%03d to be dropped", i)
+		}
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the usage file in %s, found %d entries", dir, len(entries))
+	}
+}
+
+func TestRankBySwitches(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	usage := map[string]Usage{
+		"dev":     {LastUsed: at, Switches: 2},
+		"prod":    {LastUsed: at, Switches: 7},
+		"staging": {LastUsed: at.Add(time.Hour), Switches: 2},
+		"beta":    {LastUsed: at, Switches: 2},
+	}
+
+	var got []string
+	for _, entry := range RankBySwitches(usage) {
+		got = append(got, entry.Name)
+	}
+	want := []string{"prod", "staging", "beta", "dev"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("RankBySwitches() = %v, want %v", got, want)
+	}
+}
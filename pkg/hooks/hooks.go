@@ -0,0 +1,172 @@
+// Package hooks runs user-defined commands around configuration switches.
+// Hooks are configured in the settings file, globally and per configuration,
+// and run through the platform shell with the switch described in GCLOUDCTX_*
+// environment variables, e.g. to select the matching kubectl context.
+// Pre-switch hooks are guards: one exiting non-zero vetoes the switch.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Environment variables describing the switch to hook commands
+const (
+	EnvNew     = "GCLOUDCTX_NEW"
+	EnvOld     = "GCLOUDCTX_OLD"
+	EnvProject = "GCLOUDCTX_PROJECT"
+)
+
+// DefaultGuardTimeout is how long a pre-switch guard may run when guard_timeout is not set
+const DefaultGuardTimeout = 10 * time.Second
+
+// Hooks lists the commands run around a switch
+type Hooks struct {
+	// PreSwitch runs before the switch; a non-zero exit vetoes it
+	PreSwitch []string `yaml:"pre_switch"`
+	// PostSwitch runs after the new configuration is activated
+	PostSwitch []string `yaml:"post_switch"`
+}
+
+// Config is the hooks section of the settings file
+type Config struct {
+	// Global hooks run for every configuration, before its own hooks
+	Hooks `yaml:",inline"`
+
+	// FailOnHookError switches back to the previous configuration when a
+	// post-switch hook fails; by default failures are only reported
+	FailOnHookError bool `yaml:"fail_on_hook_error"`
+
+	// GuardTimeout limits each pre-switch guard (DefaultGuardTimeout when zero);
+	// a guard that runs longer vetoes the switch
+	GuardTimeout time.Duration `yaml:"guard_timeout"`
+
+	// GuardsWarnOnly turns a veto into a warning, as --force does for one switch
+	GuardsWarnOnly bool `yaml:"guards_warn_only"`
+
+	// Configurations holds the hooks of individual configurations
+	Configurations map[string]Hooks `yaml:"configurations"`
+}
+
+// PreSwitch returns the pre-switch guards for switching to name
+func (c *Config) PreSwitch(name string) []string {
+	return slices.Concat(c.Hooks.PreSwitch, c.Configurations[name].PreSwitch)
+}
+
+// Timeout returns how long each pre-switch guard may run
+func (c *Config) Timeout() time.Duration {
+	if c.GuardTimeout <= 0 {
+		return DefaultGuardTimeout
+	}
+	return c.GuardTimeout
+}
+
+// PostSwitch returns the post-switch commands for switching to name
+func (c *Config) PostSwitch(name string) []string {
+	return slices.Concat(c.Hooks.PostSwitch, c.Configurations[name].PostSwitch)
+}
+
+// Event describes a switch to hook commands
+type Event struct {
+	Old     string
+	New     string
+	Project string
+}
+
+// Environ returns the environment of a hook process for the event
+func (e Event) Environ() []string {
+	return append(os.Environ(),
+		EnvOld+"="+e.Old,
+		EnvNew+"="+e.New,
+		EnvProject+"="+e.Project,
+	)
+}
+
+// shellCommand returns the shell invocation running command on goos
+func shellCommand(goos, command string) []string {
+	if goos == "windows" {
+		return []string{"cmd", "/C", command}
+	}
+	return []string{"sh", "-c", command}
+}
+
+// Run runs one hook command for the event and waits for it
+func Run(command string, event Event, stdout, stderr io.Writer) error {
+	if err := run(context.Background(), command, event, stdout, stderr); err != nil {
+		return fmt.Errorf("hook %q failed: %w", command, err)
+	}
+	return nil
+}
+
+// run runs command through the platform shell until it exits or ctx is done
+func run(ctx context.Context, command string, event Event, stdout, stderr io.Writer) error {
+	args := shellCommand(runtime.GOOS, command)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = event.Environ()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Do not wait for grandchildren holding the output open once the guard is killed
+	cmd.WaitDelay = time.Second
+	return cmd.Run()
+}
+
+// RunAll runs every command in order, including those after a failing one,
+// and returns the failures joined
+func RunAll(commands []string, event Event, stdout, stderr io.Writer) error {
+	var errs []error
+	for _, command := range commands {
+		if err := Run(command, event, stdout, stderr); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// VetoError is returned by Guard when a pre-switch guard refuses a switch
+type VetoError struct {
+	Command string
+	// Reason is what the guard wrote to stderr, if anything
+	Reason string
+	Err    error
+}
+
+func (e *VetoError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("switch refused by guard %q: %s", e.Command, e.Reason)
+	}
+	return fmt.Sprintf("switch refused by guard %q: %v", e.Command, e.Err)
+}
+
+func (e *VetoError) Unwrap() error {
+	return e.Err
+}
+
+// Guard runs pre-switch guards in order and returns a *VetoError for the first
+// one that exits non-zero or runs longer than timeout; later guards do not run
+func Guard(commands []string, event Event, timeout time.Duration, stdout io.Writer) error {
+	for _, command := range commands {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		var stderr bytes.Buffer
+		err := run(ctx, command, event, stdout, &stderr)
+		timedOut := ctx.Err() == context.DeadlineExceeded
+		cancel()
+
+		if err == nil {
+			continue
+		}
+		if timedOut {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		return &VetoError{Command: command, Reason: strings.TrimSpace(stderr.String()), Err: err}
+	}
+	return nil
+}
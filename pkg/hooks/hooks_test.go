@@ -0,0 +1,171 @@
+package hooks
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigPostSwitch(t *testing.T) {
+	data := `
+post_switch:
+  - echo global
+fail_on_hook_error: true
+configurations:
+  prod:
+    post_switch:
+      - kubectl config use-context prod
+`
+	var config Config
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if !config.FailOnHookError {
+		t.Error("FailOnHookError = false, want true")
+	}
+	if got, want := config.PostSwitch("prod"), []string{"echo global", "kubectl config use-context prod"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PostSwitch(prod) = %q, want %q", got, want)
+	}
+	if got, want := config.PostSwitch("dev"), []string{"echo global"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PostSwitch(dev) = %q, want %q", got, want)
+	}
+	if got := (&Config{}).PostSwitch("dev"); len(got) != 0 {
+		t.Errorf("PostSwitch() without hooks = %q, want none", got)
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	if got := shellCommand("linux", "echo hi"); !reflect.DeepEqual(got, []string{"sh", "-c", "echo hi"}) {
+		t.Errorf("shellCommand(linux) = %q", got)
+	}
+	if got := shellCommand("windows", "echo hi"); !reflect.DeepEqual(got, []string{"cmd", "/C", "echo hi"}) {
+		t.Errorf("shellCommand(windows) = %q", got)
+	}
+}
+
+func TestRunPassesEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook command uses sh syntax")
+	}
+
+	out := filepath.Join(t.TempDir(), "hook.out")
+	command := `echo "$GCLOUDCTX_OLD $GCLOUDCTX_NEW $GCLOUDCTX_PROJECT" > ` + out
+	event := Event{Old: "dev", New: "prod", Project: "prod-project"}
+
+	var stdout, stderr bytes.Buffer
+	if err := Run(command, event, &stdout, &stderr); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "dev prod prod-project" {
+		t.Errorf("hook saw %q, want %q", got, "dev prod prod-project")
+	}
+}
+
+func TestRunAllContinuesAfterFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use sh syntax")
+	}
+
+	var stdout, stderr bytes.Buffer
+	err := RunAll([]string{"echo first", "exit 3", "echo >&2 last"}, Event{New: "prod"}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), `hook "exit 3" failed`) {
+		t.Errorf("RunAll() error = %v, want the failing hook reported", err)
+	}
+	if stdout.String() != "first\n" || stderr.String() != "last\n" {
+		t.Errorf("RunAll() output = %q / %q, want every hook to run", stdout.String(), stderr.String())
+	}
+}
+
+func TestConfigPreSwitch(t *testing.T) {
+	data := `
+pre_switch:
+  - check-freeze
+guard_timeout: 3s
+configurations:
+  prod:
+    pre_switch:
+      - check-working-hours
+`
+	var config Config
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := config.PreSwitch("prod"), []string{"check-freeze", "check-working-hours"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PreSwitch(prod) = %q, want %q", got, want)
+	}
+	if got := config.Timeout(); got != 3*time.Second {
+		t.Errorf("Timeout() = %v, want 3s", got)
+	}
+	if got := (&Config{}).Timeout(); got != DefaultGuardTimeout {
+		t.Errorf("Timeout() without guard_timeout = %v, want %v", got, DefaultGuardTimeout)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("guard commands use sh syntax")
+	}
+
+	event := Event{Old: "dev", New: "prod", Project: "prod-project"}
+	marker := filepath.Join(t.TempDir(), "ran")
+
+	tests := []struct {
+		name       string
+		commands   []string
+		wantReason string
+		wantVeto   bool
+	}{
+		{name: "all pass", commands: []string{"true", `test "$GCLOUDCTX_NEW" = prod`}},
+		{name: "veto with reason", commands: []string{`echo "change freeze until Monday" >&2; exit 1`}, wantVeto: true, wantReason: "change freeze until Monday"},
+		{name: "veto without reason", commands: []string{"exit 4"}, wantVeto: true},
+		{name: "stops at first veto", commands: []string{"false", "touch " + marker}, wantVeto: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Guard(tt.commands, event, time.Minute, &bytes.Buffer{})
+			var veto *VetoError
+			if got := errors.As(err, &veto); got != tt.wantVeto {
+				t.Fatalf("Guard() error = %v, want veto %v", err, tt.wantVeto)
+			}
+			if veto != nil && veto.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", veto.Reason, tt.wantReason)
+			}
+		})
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("a guard after the vetoing one ran")
+	}
+}
+
+func TestGuardTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("guard command uses sh syntax")
+	}
+
+	start := time.Now()
+	err := Guard([]string{"sleep 30"}, Event{New: "prod"}, 100*time.Millisecond, &bytes.Buffer{})
+	var veto *VetoError
+	if !errors.As(err, &veto) || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Fatalf("Guard() error = %v, want a timeout veto", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Guard() took %v, want it to stop at the timeout", elapsed)
+	}
+}
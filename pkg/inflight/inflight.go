@@ -0,0 +1,226 @@
+// Package inflight records mutating batch operations while they run.
+// Each operation writes a plan with a lease timestamp into the state directory;
+// concurrent invocations consult active plans so completion and name resolution
+// do not offer names that are about to disappear. Expired leases are ignored,
+// so a crashed operation cannot hide names forever.
+package inflight
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const stateDirName = ".gcloudctx_inflight"
+
+// DefaultLease is how long a plan stays active without being renewed
+const DefaultLease = 2 * time.Minute
+
+// PendingDescription is the completion description of names a plan will create
+const PendingDescription = "(pending)"
+
+// ErrPendingRemoval is returned when a name is about to be deleted or renamed
+var ErrPendingRemoval = errors.New("configuration is being changed by an in-flight operation")
+
+// Rename describes a configuration rename within a plan
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Plan describes the configuration names a batch operation will change
+type Plan struct {
+	ID             string    `json:"id"`
+	Operation      string    `json:"operation"`
+	Creates        []string  `json:"creates,omitempty"`
+	Deletes        []string  `json:"deletes,omitempty"`
+	Renames        []Rename  `json:"renames,omitempty"`
+	StartedAt      time.Time `json:"started_at"`
+	LeaseExpiresAt time.Time `json:"lease_expires_at"`
+}
+
+// Expired reports whether the plan's lease has expired at the given time
+func (p *Plan) Expired(now time.Time) bool {
+	return !now.Before(p.LeaseExpiresAt)
+}
+
+// Store manages plan files in a directory
+type Store struct {
+	dir string
+	now func() time.Time
+}
+
+// NewStore returns a store keeping plans in dir, using now as its clock
+func NewStore(dir string, now func() time.Time) *Store {
+	return &Store{dir: dir, now: now}
+}
+
+// GetStateDir returns the directory holding in-flight plans
+func GetStateDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, stateDirName), nil
+}
+
+// DefaultStore returns the store in the user's home directory
+func DefaultStore() (*Store, error) {
+	dir, err := GetStateDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(dir, time.Now), nil
+}
+
+// Begin records a plan with a lease and returns the stored plan
+func (s *Store) Begin(plan Plan, lease time.Duration) (*Plan, error) {
+	now := s.now()
+	if plan.ID == "" {
+		plan.ID = fmt.Sprintf("%d-%d", os.Getpid(), now.UnixNano())
+	}
+	plan.StartedAt = now
+	plan.LeaseExpiresAt = now.Add(lease)
+
+	if err := s.write(&plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// Renew extends the lease of a running plan
+func (s *Store) Renew(plan *Plan, lease time.Duration) error {
+	plan.LeaseExpiresAt = s.now().Add(lease)
+	return s.write(plan)
+}
+
+// End removes a plan once its operation has finished
+func (s *Store) End(plan *Plan) error {
+	if err := os.Remove(s.planPath(plan.ID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove in-flight plan: %w", err)
+	}
+	return nil
+}
+
+// Active returns all plans whose lease has not expired
+// Unreadable plan files are skipped rather than failing completion
+func (s *Store) Active() ([]Plan, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read in-flight plans: %w", err)
+	}
+
+	now := s.now()
+	var plans []Plan
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		var plan Plan
+		if err := json.Unmarshal(data, &plan); err != nil {
+			continue
+		}
+		if plan.Expired(now) {
+			continue
+		}
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+func (s *Store) planPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *Store) write(plan *Plan) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create in-flight directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode in-flight plan: %w", err)
+	}
+
+	// Write atomically so readers never see a partial plan
+	path := s.planPath(plan.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write in-flight plan: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write in-flight plan: %w", err)
+	}
+
+	return nil
+}
+
+// Pending summarizes the effect of active plans on configuration names
+type Pending struct {
+	// Removed maps names slated for removal to a human-readable reason
+	Removed map[string]string
+	// Added lists names active plans will create
+	Added []string
+}
+
+// Summarize combines active plans into the set of pending name changes
+func Summarize(plans []Plan) Pending {
+	pending := Pending{Removed: map[string]string{}}
+	for _, plan := range plans {
+		for _, name := range plan.Deletes {
+			pending.Removed[name] = "being deleted"
+		}
+		for _, rename := range plan.Renames {
+			pending.Removed[rename.From] = fmt.Sprintf("being renamed to %q", rename.To)
+			pending.Added = append(pending.Added, rename.To)
+		}
+		pending.Added = append(pending.Added, plan.Creates...)
+	}
+	return pending
+}
+
+// FilterCompletions removes names slated for removal and appends upcoming names
+// with a "(pending)" description in cobra's "name\tdescription" form
+func (p Pending) FilterCompletions(names []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, entry := range names {
+		// Entries may carry a tab-separated description
+		name, _, _ := strings.Cut(entry, "\t")
+		if _, removed := p.Removed[name]; removed {
+			continue
+		}
+		seen[name] = true
+		result = append(result, entry)
+	}
+	for _, name := range p.Added {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name+"\t"+PendingDescription)
+	}
+	return result
+}
+
+// CheckName returns an error wrapping ErrPendingRemoval if name is about to be removed
+func (p Pending) CheckName(name string) error {
+	if reason, removed := p.Removed[name]; removed {
+		return fmt.Errorf("%w: %q is %s", ErrPendingRemoval, name, reason)
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package inflight
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for lease tests
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestPlanJSONFormat(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	plan := Plan{
+		ID:             "42-1",
+		Operation:      "rename",
+		Renames:        []Rename{{From: "old", To: "new"}},
+		StartedAt:      start,
+		LeaseExpiresAt: start.Add(DefaultLease),
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	want := `{"id":"42-1","operation":"rename","renames":[{"from":"old","to":"new"}],` +
+		`"started_at":"2025-01-01T12:00:00Z","lease_expires_at":"2025-01-01T12:02:00Z"}`
+	if string(data) != want {
+		t.Errorf("plan JSON = %s, want %s", data, want)
+	}
+}
+
+func TestBeginActiveEnd(t *testing.T) {
+	clock := newFakeClock()
+	store := NewStore(t.TempDir(), clock.Now)
+
+	plan, err := store.Begin(Plan{Operation: "delete", Deletes: []string{"old"}}, time.Minute)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if plan.ID == "" {
+		t.Error("Begin should assign an ID")
+	}
+	if !plan.LeaseExpiresAt.Equal(clock.Now().Add(time.Minute)) {
+		t.Errorf("LeaseExpiresAt = %v, want %v", plan.LeaseExpiresAt, clock.Now().Add(time.Minute))
+	}
+
+	plans, err := store.Active()
+	if err != nil {
+		t.Fatalf("Active failed: %v", err)
+	}
+	if len(plans) != 1 || plans[0].ID != plan.ID {
+		t.Fatalf("Active() = %+v, want the started plan", plans)
+	}
+
+	if err := store.End(plan); err != nil {
+		t.Fatalf("End failed: %v", err)
+	}
+	plans, err = store.Active()
+	if err != nil {
+		t.Fatalf("Active failed: %v", err)
+	}
+	if len(plans) != 0 {
+		t.Errorf("Active() after End = %+v, want none", plans)
+	}
+}
+
+func TestLeaseExpiryAndRenew(t *testing.T) {
+	clock := newFakeClock()
+	store := NewStore(t.TempDir(), clock.Now)
+
+	plan, err := store.Begin(Plan{Operation: "delete", Deletes: []string{"old"}}, time.Minute)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+
+	clock.Advance(50 * time.Second)
+	if err := store.Renew(plan, time.Minute); err != nil {
+		t.Fatalf("Renew failed: %v", err)
+	}
+
+	// Past the original lease but within the renewed one
+	clock.Advance(30 * time.Second)
+	if plans, _ := store.Active(); len(plans) != 1 {
+		t.Errorf("renewed plan should still be active, got %+v", plans)
+	}
+
+	// Past the renewed lease
+	clock.Advance(time.Minute)
+	if plans, _ := store.Active(); len(plans) != 0 {
+		t.Errorf("expired plan should be ignored, got %+v", plans)
+	}
+}
+
+func TestActiveMissingDirAndCorruptFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	store := NewStore(dir, time.Now)
+
+	plans, err := store.Active()
+	if err != nil || len(plans) != 0 {
+		t.Errorf("Active() on missing dir = %+v, %v", plans, err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte("{"), 0o600); err != nil {
+		t.Fatalf("failed to write corrupt plan: %v", err)
+	}
+	plans, err = store.Active()
+	if err != nil || len(plans) != 0 {
+		t.Errorf("Active() with corrupt plan = %+v, %v", plans, err)
+	}
+}
+
+func TestSummarizeAndFilterCompletions(t *testing.T) {
+	pending := Summarize([]Plan{
+		{Deletes: []string{"doomed"}},
+		{Renames: []Rename{{From: "dev", To: "development"}}, Creates: []string{"fresh"}},
+	})
+
+	got := pending.FilterCompletions([]string{"default", "doomed", "dev", "prod"})
+	want := []string{"default", "prod", "development\t(pending)", "fresh\t(pending)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterCompletions() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterCompletionsNoDuplicatePending(t *testing.T) {
+	pending := Summarize([]Plan{{Creates: []string{"already-there"}}})
+
+	got := pending.FilterCompletions([]string{"already-there"})
+	want := []string{"already-there"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterCompletions() = %v, want %v", got, want)
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	pending := Summarize([]Plan{
+		{Deletes: []string{"doomed"}},
+		{Renames: []Rename{{From: "dev", To: "development"}}},
+	})
+
+	if err := pending.CheckName("prod"); err != nil {
+		t.Errorf("CheckName(prod) = %v, want nil", err)
+	}
+
+	err := pending.CheckName("dev")
+	if !errors.Is(err, ErrPendingRemoval) {
+		t.Fatalf("CheckName(dev) = %v, want ErrPendingRemoval", err)
+	}
+	if got := err.Error(); got != `configuration is being changed by an in-flight operation: "dev" is being renamed to "development"` {
+		t.Errorf("CheckName(dev) error = %q", got)
+	}
+}
+
+// TestBatchInterleavedWithCompletion runs a fake long-running batch in a
+// goroutine and queries completions between its steps, as a concurrent
+// gcloudctx invocation would
+func TestBatchInterleavedWithCompletion(t *testing.T) {
+	clock := newFakeClock()
+	dir := t.TempDir()
+	batchStore := NewStore(dir, clock.Now)
+	completionStore := NewStore(dir, clock.Now)
+
+	existing := []string{"alpha", "beta", "gamma"}
+	complete := func() []string {
+		plans, err := completionStore.Active()
+		if err != nil {
+			t.Errorf("Active failed: %v", err)
+		}
+		return Summarize(plans).FilterCompletions(existing)
+	}
+
+	started := make(chan struct{})
+	renewed := make(chan struct{})
+	proceed := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		plan, err := batchStore.Begin(Plan{
+			Operation: "bulk-rename",
+			Renames: []Rename{
+				{From: "alpha", To: "team-alpha"},
+				{From: "beta", To: "team-beta"},
+			},
+		}, time.Minute)
+		if err != nil {
+			t.Errorf("Begin failed: %v", err)
+			return
+		}
+		close(started)
+
+		// Long-running step: renew the lease midway
+		<-proceed
+		if err := batchStore.Renew(plan, time.Minute); err != nil {
+			t.Errorf("Renew failed: %v", err)
+		}
+		close(renewed)
+
+		<-proceed
+		if err := batchStore.End(plan); err != nil {
+			t.Errorf("End failed: %v", err)
+		}
+	}()
+
+	if got := complete(); !reflect.DeepEqual(got, existing) {
+		t.Errorf("before batch: completions = %v, want %v", got, existing)
+	}
+
+	<-started
+	during := []string{"gamma", "team-alpha\t(pending)", "team-beta\t(pending)"}
+	if got := complete(); !reflect.DeepEqual(got, during) {
+		t.Errorf("during batch: completions = %v, want %v", got, during)
+	}
+
+	// The lease would expire without the renewal
+	clock.Advance(45 * time.Second)
+	proceed <- struct{}{}
+	<-renewed
+	clock.Advance(45 * time.Second)
+	if got := complete(); !reflect.DeepEqual(got, during) {
+		t.Errorf("after renewal: completions = %v, want %v", got, during)
+	}
+
+	// After the batch finishes, the real listing reflects the renames
+	proceed <- struct{}{}
+	<-finished
+	existing = []string{"gamma", "team-alpha", "team-beta"}
+	if got := complete(); !reflect.DeepEqual(got, existing) {
+		t.Errorf("after batch: completions = %v, want %v", got, existing)
+	}
+}
+
+func TestCrashedBatchLeaseExpires(t *testing.T) {
+	clock := newFakeClock()
+	store := NewStore(t.TempDir(), clock.Now)
+
+	// A batch that never calls End (e.g. killed mid-run)
+	if _, err := store.Begin(Plan{Deletes: []string{"alpha"}}, time.Minute); err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+
+	clock.Advance(2 * time.Minute)
+	plans, err := store.Active()
+	if err != nil {
+		t.Fatalf("Active failed: %v", err)
+	}
+	if got := Summarize(plans).FilterCompletions([]string{"alpha"}); !reflect.DeepEqual(got, []string{"alpha"}) {
+		t.Errorf("completions after expired lease = %v, want [alpha]", got)
+	}
+}
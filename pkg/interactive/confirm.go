@@ -0,0 +1,25 @@
+package interactive
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/prompt"
+)
+
+// ConfirmTyped asks on out for expected to be typed back and reads the answer from in
+// It reports whether the answer matched exactly; end of input counts as a refusal
+func ConfirmTyped(in io.Reader, out io.Writer, question, expected string) (bool, error) {
+	return prompt.ConfirmTyped(out, in, question, expected)
+}
+
+// Confirm asks a yes/no question on out and reads the answer from in
+// Only "y" and "yes" (in any case) confirm; end of input counts as a refusal
+func Confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	return prompt.Confirm(out, in, question, true)
+}
+
+// ConfirmProtectedSwitch asks whether to switch to the protected configuration name
+func ConfirmProtectedSwitch(in io.Reader, out io.Writer, name string) (bool, error) {
+	return Confirm(in, out, fmt.Sprintf("Switch to PROTECTED configuration '%s'?", name))
+}
@@ -0,0 +1,68 @@
+package interactive
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfirmTyped(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "exact", input: "default\n", want: true},
+		{name: "surrounding spaces", input: "  default \n", want: true},
+		{name: "yes is not enough", input: "y\n", want: false},
+		{name: "different case", input: "Default\n", want: false},
+		{name: "end of input", input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := ConfirmTyped(strings.NewReader(tt.input), &out, "Delete it?", "default")
+			if err != nil {
+				t.Fatalf("ConfirmTyped failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConfirmTyped() = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(out.String(), `Type "default" to confirm: `) {
+				t.Errorf("prompt = %q, want it to ask for the name", out.String())
+			}
+		})
+	}
+}
+
+func TestConfirmProtectedSwitch(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "y", input: "y\n", want: true},
+		{name: "yes in capitals", input: " YES \n", want: true},
+		{name: "empty answer", input: "\n", want: false},
+		{name: "no", input: "n\n", want: false},
+		{name: "name is not yes", input: "prod\n", want: false},
+		{name: "end of input", input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := ConfirmProtectedSwitch(strings.NewReader(tt.input), &out, "prod")
+			if err != nil {
+				t.Fatalf("ConfirmProtectedSwitch failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConfirmProtectedSwitch() = %v, want %v", got, tt.want)
+			}
+			if !strings.HasPrefix(out.String(), "Switch to PROTECTED configuration 'prod'? (y/N): ") {
+				t.Errorf("prompt = %q", out.String())
+			}
+		})
+	}
+}
@@ -4,7 +4,7 @@ import "errors"
 
 // Environment variable names for customizing fzf behavior
 const (
-	// EnvIgnoreFzf skips fzf and shows current config when no args are provided
+	// EnvIgnoreFzf skips fzf and the numbered picker and shows current config when no args are provided
 	EnvIgnoreFzf = "GCLOUDCTX_IGNORE_FZF"
 
 	// EnvFzfHeight controls the height of the fzf window
@@ -18,18 +18,50 @@ const (
 
 	// EnvFzfOptions allows additional fzf options to be specified
 	EnvFzfOptions = "GCLOUDCTX_FZF_OPTIONS"
+
+	// EnvFzfTmux controls tmux popups: "0" disables them, "1" forces them (default: auto-detect $TMUX)
+	EnvFzfTmux = "GCLOUDCTX_FZF_TMUX"
+
+	// EnvFzfTmuxPopup controls the tmux popup size as "WIDTH,HEIGHT" (e.g. "80%,60%")
+	EnvFzfTmuxPopup = "GCLOUDCTX_FZF_TMUX_POPUP"
 )
 
 // Default values for fzf options
 const (
 	DefaultFzfHeight        = "40%"
 	DefaultFzfPreviewWindow = "right:50%:wrap"
+	DefaultFzfTmuxPopup     = "80%,60%"
+)
+
+// MaxDisplayNameLength is the longest configuration name shown as-is in the picker;
+// longer names are shortened with a middle ellipsis so the layout does not wrap
+const MaxDisplayNameLength = 40
+
+// nameFieldDelimiter separates the hidden full-name field from the displayed picker line
+const nameFieldDelimiter = "\t"
+
+// Key bindings available inside the fzf picker
+const (
+	// KeyDelete deletes the highlighted configuration
+	KeyDelete = "ctrl-d"
+
+	// KeyToggleDetails toggles the configuration details preview
+	KeyToggleDetails = "ctrl-i"
 )
 
 // Command names
 const (
 	// PreviewCommand is the internal command used for fzf preview
 	PreviewCommand = "__preview"
+
+	// ProjectPreviewCommand is the internal command used for the project picker preview
+	ProjectPreviewCommand = "__project-preview"
+
+	// DeleteCommand is the internal command used by the fzf delete binding
+	DeleteCommand = "__delete"
+
+	// ListCommand is the internal command used to reload the fzf list
+	ListCommand = "__list"
 )
 
 // Sentinel errors for interactive package
@@ -43,6 +75,12 @@ var (
 	// ErrNoConfigurations is returned when there are no configurations available
 	ErrNoConfigurations = errors.New("no configurations available")
 
+	// ErrNoProjects is returned when there are no projects available
+	ErrNoProjects = errors.New("no projects available")
+
+	// ErrNoDirectories is returned when there are no directories to jump to
+	ErrNoDirectories = errors.New("no directories available")
+
 	// ErrNoSelection is returned when no configuration is selected
 	ErrNoSelection = errors.New("no configuration selected")
 )
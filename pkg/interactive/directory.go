@@ -0,0 +1,80 @@
+package interactive
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DirectoryEntry is a directory with a .gcloudctx file offered by the directory picker
+type DirectoryEntry struct {
+	// Dir is the absolute path of the directory
+	Dir string
+	// Display is the path as shown, e.g. with the home directory as "~"
+	Display string
+	// Configuration is the configuration the file names, if any
+	Configuration string
+	// Project is the project the file sets, if any
+	Project string
+}
+
+// SelectDirectoryInteractive allows the user to select a directory using fzf
+// It returns the absolute path of the selected directory
+func SelectDirectoryInteractive(entries []DirectoryEntry) (string, error) {
+	if !IsFzfInstalled() {
+		return "", ErrFzfNotInstalled
+	}
+
+	if len(entries) == 0 {
+		return "", ErrNoDirectories
+	}
+
+	fzfArgs, err := buildDirectoryFzfArgs()
+	if err != nil {
+		return "", err
+	}
+	selected, err := runFzf(FormatDirectoryLines(entries), fzfArgs, installedFzfVersion())
+	if err != nil {
+		return "", err
+	}
+
+	return ParseDirectory(selected)
+}
+
+// FormatDirectoryLines builds the fzf input lines for the given directories
+// Each line has the format "dir\tdisplay  configuration [project]"; the first
+// tab-separated field holds the absolute path and is hidden by fzf
+func FormatDirectoryLines(entries []DirectoryEntry) string {
+	width := 0
+	for _, entry := range entries {
+		width = max(width, len(entry.Display))
+	}
+
+	var builder strings.Builder
+	for _, entry := range entries {
+		line := fmt.Sprintf("%-*s  %s", width, entry.Display, entry.Configuration)
+		if entry.Configuration == "" {
+			line = fmt.Sprintf("%-*s  -", width, entry.Display)
+		}
+		if entry.Project != "" {
+			line += fmt.Sprintf(" [%s]", entry.Project)
+		}
+		builder.WriteString(entry.Dir + nameFieldDelimiter + line + "\n")
+	}
+	return builder.String()
+}
+
+// ParseDirectory extracts the absolute path from a directory picker line
+func ParseDirectory(line string) (string, error) {
+	dir, _, found := strings.Cut(line, nameFieldDelimiter)
+	if !found || dir == "" {
+		return "", fmt.Errorf("could not parse directory from line: %q", line)
+	}
+	return dir, nil
+}
+
+// buildDirectoryFzfArgs builds the fzf command arguments for the directory picker
+func buildDirectoryFzfArgs() ([]string, error) {
+	// Hide the absolute path field; it is only used to return the exact selection
+	extraArgs := []string{"--delimiter", nameFieldDelimiter, "--with-nth", "2.."}
+	return buildPickerArgs("Jump to a directory (its .gcloudctx applies after cd):", "dir> ", "", extraArgs)
+}
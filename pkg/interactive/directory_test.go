@@ -0,0 +1,44 @@
+package interactive
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFormatDirectoryLines(t *testing.T) {
+	entries := []DirectoryEntry{
+		{Dir: "/home/me/src/acme", Display: "~/src/acme", Configuration: "acme", Project: "acme-dev"},
+		{Dir: "/srv/tools", Display: "/srv/tools"},
+	}
+
+	got := FormatDirectoryLines(entries)
+	want := "/home/me/src/acme\t~/src/acme  acme [acme-dev]\n/srv/tools\t/srv/tools  -\n"
+	if got != want {
+		t.Errorf("FormatDirectoryLines() = %q, want %q", got, want)
+	}
+}
+
+func TestParseDirectory(t *testing.T) {
+	dir, err := ParseDirectory("/home/me/my dir\t~/my dir  dev")
+	if err != nil || dir != "/home/me/my dir" {
+		t.Errorf("ParseDirectory() = %q, %v; want the hidden path", dir, err)
+	}
+	if _, err := ParseDirectory("~/my dir  dev"); err == nil {
+		t.Error("ParseDirectory() of a line without the path field succeeded")
+	}
+}
+
+func TestBuildDirectoryFzfArgs(t *testing.T) {
+	t.Setenv(EnvFzfOptions, "")
+
+	args, err := buildDirectoryFzfArgs()
+	if err != nil {
+		t.Fatalf("buildDirectoryFzfArgs() error = %v", err)
+	}
+	if slices.Contains(args, "--preview") || slices.Contains(args, "--bind") {
+		t.Errorf("directory picker should have no preview or bindings: %v", args)
+	}
+	if i := slices.Index(args, "--with-nth"); i < 0 || args[i+1] != "2.." {
+		t.Errorf("directory picker should hide the path field: %v", args)
+	}
+}
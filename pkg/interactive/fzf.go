@@ -9,9 +9,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
 	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
 )
 
 // IsFzfInstalled checks if fzf is installed
@@ -20,14 +24,19 @@ func IsFzfInstalled() bool {
 	return err == nil
 }
 
-// getSelfCommand returns the path to the current executable
+// getSelfCommand returns the path to the current executable, quoted for the
+// shell fzf runs preview and bind commands with
 func getSelfCommand() (string, error) {
 	executable, err := os.Executable()
 	if err != nil {
 		return "", err
 	}
 	// Resolve symlinks
-	return filepath.EvalSymlinks(executable)
+	path, err := filepath.EvalSymlinks(executable)
+	if err != nil {
+		return "", err
+	}
+	return quoteShellWord(path, runtime.GOOS), nil
 }
 
 // SelectConfigurationInteractive allows the user to select a configuration using fzf
@@ -42,23 +51,7 @@ func SelectConfigurationInteractive(configs []gcloud.Configuration, currentConfi
 	}
 
 	// Build the input data for fzf (format: "* name (account) [project]")
-	var inputBuilder strings.Builder
-	for _, config := range configs {
-		marker := " "
-		if config.Name == currentConfig {
-			marker = "*"
-		}
-
-		line := fmt.Sprintf("%s %s", marker, config.Name)
-		if config.Properties.Core.Account != "" {
-			line += fmt.Sprintf(" (%s)", config.Properties.Core.Account)
-		}
-		if config.Properties.Core.Project != "" {
-			line += fmt.Sprintf(" [%s]", config.Properties.Core.Project)
-		}
-
-		inputBuilder.WriteString(line + "\n")
-	}
+	input := FormatConfigurationLines(configs, currentConfig)
 
 	// Get the path to the current executable for preview
 	selfCmd, err := getSelfCommand()
@@ -68,18 +61,56 @@ func SelectConfigurationInteractive(configs []gcloud.Configuration, currentConfi
 	}
 
 	// Build fzf command arguments (preview uses Go command, no shell!)
-	fzfArgs := buildFzfArgs(selfCmd)
-	cmd := exec.Command("fzf", fzfArgs...)
+	fzfArgs, err := buildFzfArgs(selfCmd)
+	if err != nil {
+		return "", err
+	}
+	version := installedFzfVersion()
+	selected, err := runFzf(input, withAcceptNameField(fzfArgs, version), version)
+	if err != nil {
+		return "", err
+	}
+
+	// Extract the configuration name from the name field or, with an older
+	// fzf, from the formatted line
+	return ParseConfigurationName(selected)
+}
+
+// fzf gained --accept-nth in 0.60.0
+const (
+	acceptNthMajor = 0
+	acceptNthMinor = 60
+)
+
+// withAcceptNameField makes an fzf of the given version print only the hidden
+// name field of the selection, leaving nothing of the display line to parse
+// Older or unknown versions get args unchanged. The option comes after
+// GCLOUDCTX_FZF_OPTIONS so a custom --accept-nth cannot break selection.
+func withAcceptNameField(args []string, version fzfVersion) []string {
+	if !version.atLeast(acceptNthMajor, acceptNthMinor) {
+		return args
+	}
+	return append(args, "--accept-nth", "1")
+}
+
+// runFzf runs fzf, of the given version, with the given input lines and
+// arguments and returns the selected line
+func runFzf(input string, fzfArgs []string, version fzfVersion) (string, error) {
+	// Open in a tmux popup when running inside tmux
+	// GCLOUDCTX_FZF_OPTIONS was checked when the arguments were built
+	custom, _ := customFzfOptions()
+	binary, fzfArgs := applyTmuxMode(detectTmuxMode(version), fzfArgs, custom, tmuxPopupSize())
+	cmd := exec.Command(binary, fzfArgs...)
 
 	// Pass data via stdin (no FZF_DEFAULT_COMMAND needed)
-	cmd.Stdin = strings.NewReader(inputBuilder.String())
+	cmd.Stdin = strings.NewReader(input)
 	cmd.Stderr = os.Stderr
 
 	var output bytes.Buffer
 	cmd.Stdout = &output
 
 	if err := cmd.Run(); err != nil {
-		// User canceled (ESC or Ctrl+C)
+		// User canceled (ESC or Ctrl+C); fzf-tmux propagates fzf's exit code
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			if exitErr.ExitCode() == 130 {
 				return "", ErrSelectionCanceled
@@ -88,21 +119,104 @@ func SelectConfigurationInteractive(configs []gcloud.Configuration, currentConfi
 		return "", fmt.Errorf("fzf selection failed: %w", err)
 	}
 
-	// Parse the selected line to extract the configuration name
 	selected := strings.TrimSpace(output.String())
 	if selected == "" {
 		return "", ErrNoSelection
 	}
 
-	// Extract the configuration name from the formatted line
-	return ParseConfigurationName(selected)
+	return selected, nil
+}
+
+// FormatConfigurationLines builds the fzf input lines for the given configurations
+// Each line has the format "name\t* display-name (account) [project]", where "*" marks
+// currentConfig; the first tab-separated field holds the full name and is hidden by fzf,
+// while the displayed name is shortened with a middle ellipsis when it is very long.
+// The visible part follows the picker_format setting (see output.FormatPickerLine)
+// Names are padded to the display width of the widest, emoji included, so what
+// follows them lines up however wide their characters are.
+func FormatConfigurationLines(configs []gcloud.Configuration, currentConfig string) string {
+	displayNames := make([]string, len(configs))
+	column := 0
+	for i := range configs {
+		displayNames[i] = output.TruncateMiddle(configs[i].Name, MaxDisplayNameLength)
+		column = max(column, emojiWidth(configs[i].Name)+linefmt.DisplayWidth(displayNames[i]))
+	}
+
+	var builder strings.Builder
+	for i := range configs {
+		config := &configs[i]
+		displayName := linefmt.PadRight(displayNames[i], column-emojiWidth(config.Name))
+		line := output.FormatPickerLine(config, displayName, config.Name == currentConfig)
+
+		builder.WriteString(config.Name + nameFieldDelimiter + strings.TrimRight(line, " ") + "\n")
+	}
+	return builder.String()
+}
+
+// emojiWidth returns the display width of the emoji shown before a
+// configuration's name and the space after it, 0 when none is set
+func emojiWidth(name string) int {
+	if emoji := output.DisplayEmoji(name); emoji != "" {
+		return linefmt.DisplayWidth(emoji) + 1
+	}
+	return 0
+}
+
+// fzfPreviewColumns is set by fzf for preview commands to the width of the preview window
+const fzfPreviewColumns = "FZF_PREVIEW_COLUMNS"
+
+// PreviewWidth returns the width of the fzf preview window a preview command
+// prints into, or output.DefaultPreviewWidth outside fzf
+func PreviewWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv(fzfPreviewColumns)); err == nil && columns > 0 {
+		return columns
+	}
+	return output.DefaultPreviewWidth
 }
 
-// buildFzfArgs builds the fzf command arguments
+// bindingsDisabled is the fzf.disable_bindings setting (see SetBindingsDisabled)
+var bindingsDisabled bool
+
+// SetBindingsDisabled applies the fzf.disable_bindings setting, which turns off
+// the picker's extra key bindings (delete, toggle details)
+func SetBindingsDisabled(disabled bool) {
+	bindingsDisabled = disabled
+}
+
+// buildFzfArgs builds the fzf command arguments for the configuration picker
 // Preview is handled by a Go command (no shell scripts!)
-func buildFzfArgs(selfCmd string) []string {
-	// Get custom fzf options from environment
-	customOpts := os.Getenv(EnvFzfOptions)
+func buildFzfArgs(selfCmd string) ([]string, error) {
+	previewEnabled := os.Getenv(EnvDisablePreview) != "1"
+	bindingsEnabled := !bindingsDisabled
+
+	// Use Go command for preview
+	// Pipe the entire fzf selection line to our preview command rather than
+	// passing it as an argument, whose quoting some Windows shells mangle
+	// It will parse the configuration name internally
+	previewCmd := ""
+	if previewEnabled {
+		previewCmd = fmt.Sprintf(`echo {} | %s %s`, selfCmd, PreviewCommand)
+	}
+
+	// Hide the full-name field; it is only used to return the exact selection
+	extraArgs := []string{"--delimiter", nameFieldDelimiter, "--with-nth", "2.."}
+
+	// Add key bindings for in-picker actions unless disabled
+	if bindingsEnabled {
+		extraArgs = append(extraArgs, buildBindArgs(selfCmd, previewEnabled)...)
+	}
+
+	return buildPickerArgs(buildHeader(previewEnabled, bindingsEnabled), "gcloud> ", previewCmd, extraArgs)
+}
+
+// buildPickerArgs builds the fzf arguments shared by all pickers
+// An empty previewCmd disables the preview window. Options from
+// GCLOUDCTX_FZF_OPTIONS come last and replace the defaults they conflict with.
+func buildPickerArgs(header, prompt, previewCmd string, extraArgs []string) ([]string, error) {
+	customArgs, err := customFzfOptions()
+	if err != nil {
+		return nil, err
+	}
 
 	// Default options
 	args := []string{
@@ -110,28 +224,108 @@ func buildFzfArgs(selfCmd string) []string {
 		"--height", getEnvOrDefault(EnvFzfHeight, DefaultFzfHeight),
 		"--reverse",
 		"--border",
-		"--header", "Select a configuration:",
-		"--prompt", "gcloud> ",
+		"--header", header,
+		"--prompt", prompt,
 	}
 
-	// Add preview unless disabled
-	if os.Getenv(EnvDisablePreview) != "1" {
-		// Use Go command for preview (100% Go, no shell commands at all!)
-		// Pass the entire fzf selection line to our preview command
-		// It will parse the configuration name internally
-		previewCmd := fmt.Sprintf(`%s %s {}`, selfCmd, PreviewCommand)
+	if previewCmd != "" {
 		args = append(args,
 			"--preview", previewCmd,
 			"--preview-window", getEnvOrDefault(EnvFzfPreviewWindow, DefaultFzfPreviewWindow),
 		)
 	}
 
-	// Add custom options if provided
-	if customOpts != "" {
-		customArgs := strings.Fields(customOpts)
-		args = append(args, customArgs...)
+	args = append(args, extraArgs...)
+
+	// Custom --bind entries are added to ours; fzf lets later ones win per key
+	return append(withoutOverridden(args, customArgs), customArgs...), nil
+}
+
+// customFzfOptions splits GCLOUDCTX_FZF_OPTIONS into arguments, honoring
+// shell quoting so values such as --header "pick a config" stay one argument
+func customFzfOptions() ([]string, error) {
+	args, err := splitShellWords(os.Getenv(EnvFzfOptions))
+	if err != nil {
+		return nil, fmt.Errorf("invalid $%s: %w", EnvFzfOptions, err)
 	}
+	return args, nil
+}
+
+// defaultOptionArity tells whether each option gcloudctx passes to fzf takes a value
+var defaultOptionArity = map[string]bool{
+	"--ansi":           false,
+	"--border":         false,
+	"--delimiter":      true,
+	"--header":         true,
+	"--height":         true,
+	"--preview":        true,
+	"--preview-window": true,
+	"--prompt":         true,
+	"--reverse":        false,
+	"--with-nth":       true,
+}
 
+// withoutOverridden drops the default options that custom sets, negates
+// (--no-ansi) or replaces (--layout for --reverse), along with their values
+func withoutOverridden(defaults, custom []string) []string {
+	names := optionNames(custom)
+
+	overridden := func(name string) bool {
+		return names[name] || names["--no-"+strings.TrimPrefix(name, "--")] || (name == "--reverse" && names["--layout"])
+	}
+
+	result := make([]string, 0, len(defaults))
+	for i := 0; i < len(defaults); i++ {
+		takesValue, known := defaultOptionArity[defaults[i]]
+		if known && overridden(defaults[i]) {
+			if takesValue {
+				i++
+			}
+			continue
+		}
+		result = append(result, defaults[i])
+		if known && takesValue && i+1 < len(defaults) {
+			i++
+			result = append(result, defaults[i])
+		}
+	}
+	return result
+}
+
+// optionNames returns the names of the long options in args, without values
+func optionNames(args []string) map[string]bool {
+	names := map[string]bool{}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			name, _, _ := strings.Cut(arg, "=")
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// buildHeader returns the fzf header text, advertising the available key bindings
+func buildHeader(previewEnabled, bindingsEnabled bool) string {
+	if !bindingsEnabled {
+		return "Select a configuration:"
+	}
+
+	hints := []string{KeyDelete + ": delete"}
+	if previewEnabled {
+		hints = append(hints, KeyToggleDetails+": toggle details")
+	}
+	return fmt.Sprintf("Select a configuration (%s):", strings.Join(hints, ", "))
+}
+
+// buildBindArgs builds the --bind arguments for in-picker actions
+// Delete runs the internal delete command (which asks for confirmation) and reloads the list
+func buildBindArgs(selfCmd string, previewEnabled bool) []string {
+	args := []string{
+		"--bind", fmt.Sprintf("%s:execute(%s %s {})+reload(%s %s)", KeyDelete, selfCmd, DeleteCommand, selfCmd, ListCommand),
+	}
+	if previewEnabled {
+		args = append(args, "--bind", KeyToggleDetails+":toggle-preview")
+	}
 	return args
 }
 
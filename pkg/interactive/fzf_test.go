@@ -2,7 +2,13 @@ package interactive
 
 import (
 	"os"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
 )
 
 func TestIsFzfInstalled(t *testing.T) {
@@ -58,6 +64,16 @@ func TestGetEnvOrDefault(t *testing.T) {
 	}
 }
 
+// mustBuildFzfArgs returns buildFzfArgs(selfCmd), failing the test on error
+func mustBuildFzfArgs(t *testing.T, selfCmd string) []string {
+	t.Helper()
+	args, err := buildFzfArgs(selfCmd)
+	if err != nil {
+		t.Fatalf("buildFzfArgs() error = %v", err)
+	}
+	return args
+}
+
 func TestBuildFzfArgs(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -116,15 +132,15 @@ func TestBuildFzfArgs(t *testing.T) {
 				EnvDisablePreview: "0",
 			},
 			checkArgs: func(args []string) bool {
-				// Should contain --preview
-				for _, arg := range args {
-					if arg == "--preview" {
-						return true
+				// Should pipe the selection line to the preview command
+				for i, arg := range args {
+					if arg == "--preview" && i+1 < len(args) {
+						return args[i+1] == "echo {} | gcloudctx "+PreviewCommand
 					}
 				}
 				return false
 			},
-			description: "should include preview",
+			description: "should pipe the selection to the preview command",
 		},
 	}
 
@@ -136,7 +152,7 @@ func TestBuildFzfArgs(t *testing.T) {
 				defer os.Unsetenv(key)
 			}
 
-			args := buildFzfArgs("gcloudctx") // Pass dummy command path
+			args := mustBuildFzfArgs(t, "gcloudctx") // Pass dummy command path
 
 			if !tt.checkArgs(args) {
 				t.Errorf("buildFzfArgs() %s\nGot args: %v", tt.description, args)
@@ -146,7 +162,7 @@ func TestBuildFzfArgs(t *testing.T) {
 }
 
 func TestBuildFzfArgsContainsRequiredOptions(t *testing.T) {
-	args := buildFzfArgs("gcloudctx") // Pass dummy command path
+	args := mustBuildFzfArgs(t, "gcloudctx") // Pass dummy command path
 
 	requiredArgs := []string{
 		"--ansi",
@@ -170,3 +186,341 @@ func TestBuildFzfArgsContainsRequiredOptions(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildFzfArgsBindings(t *testing.T) {
+	tests := []struct {
+		name            string
+		envSettings     map[string]string
+		disableBindings bool
+		selfCmd         string
+		wantBinds       []string
+		wantNoBind      bool
+		headerSubstr    string
+	}{
+		{
+			name:        "default bindings",
+			envSettings: map[string]string{},
+			wantBinds: []string{
+				"ctrl-d:execute(gcloudctx __delete {})+reload(gcloudctx __list)",
+				"ctrl-i:toggle-preview",
+			},
+			headerSubstr: "ctrl-d: delete, ctrl-i: toggle details",
+		},
+		{
+			name: "preview disabled drops toggle binding",
+			envSettings: map[string]string{
+				EnvDisablePreview: "1",
+			},
+			wantBinds: []string{
+				"ctrl-d:execute(gcloudctx __delete {})+reload(gcloudctx __list)",
+			},
+			headerSubstr: "(ctrl-d: delete)",
+		},
+		{
+			name:            "bindings disabled by the setting",
+			envSettings:     map[string]string{},
+			disableBindings: true,
+			wantNoBind:      true,
+			headerSubstr:    "Select a configuration:",
+		},
+		{
+			name:        "quoted install path",
+			envSettings: map[string]string{},
+			selfCmd:     quoteShellWord("/Applications/My Tools/gcloudctx", "linux"),
+			wantBinds: []string{
+				"ctrl-d:execute('/Applications/My Tools/gcloudctx' __delete {})+reload('/Applications/My Tools/gcloudctx' __list)",
+				"ctrl-i:toggle-preview",
+			},
+			headerSubstr: "ctrl-d: delete",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.envSettings {
+				t.Setenv(key, value)
+			}
+			SetBindingsDisabled(tt.disableBindings)
+			t.Cleanup(func() { SetBindingsDisabled(false) })
+			selfCmd := tt.selfCmd
+			if selfCmd == "" {
+				selfCmd = "gcloudctx"
+			}
+
+			args := mustBuildFzfArgs(t, selfCmd)
+
+			var binds []string
+			header := ""
+			for i, arg := range args {
+				if i+1 >= len(args) {
+					break
+				}
+				switch arg {
+				case "--bind":
+					binds = append(binds, args[i+1])
+				case "--header":
+					header = args[i+1]
+				}
+			}
+
+			if tt.wantNoBind && len(binds) != 0 {
+				t.Errorf("buildFzfArgs() should not include --bind, got %v", binds)
+			}
+			if len(binds) != len(tt.wantBinds) {
+				t.Fatalf("buildFzfArgs() binds = %v, want %v", binds, tt.wantBinds)
+			}
+			for i := range binds {
+				if binds[i] != tt.wantBinds[i] {
+					t.Errorf("bind %d = %q, want %q", i, binds[i], tt.wantBinds[i])
+				}
+			}
+			if !strings.Contains(header, tt.headerSubstr) {
+				t.Errorf("header = %q, want to contain %q", header, tt.headerSubstr)
+			}
+		})
+	}
+}
+
+func TestFormatConfigurationLines(t *testing.T) {
+	configs := []gcloud.Configuration{
+		{Name: "default", Properties: gcloud.Properties{
+			Core: gcloud.CoreProperties{Account: "user@example.com", Project: "my-project"},
+		}},
+		{Name: "bare"},
+	}
+
+	got := FormatConfigurationLines(configs, "default")
+	want := "default\t* default (user@example.com) [my-project]\nbare\t  bare\n"
+	if got != want {
+		t.Errorf("FormatConfigurationLines() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatConfigurationLinesLongNames(t *testing.T) {
+	// Generated names can reach gcloud's 63-character limit and share long prefixes
+	names := []string{
+		"team-platform-sandbox-environment-us-central1-generated-a1b2c3d",
+		"team-platform-sandbox-environment-us-central1-generated-e4f5a6b",
+	}
+	configs := []gcloud.Configuration{{Name: names[0]}, {Name: names[1]}}
+
+	lines := strings.Split(strings.TrimSuffix(FormatConfigurationLines(configs, names[0]), "\n"), "\n")
+	if len(lines) != len(names) {
+		t.Fatalf("expected %d lines, got %d", len(names), len(lines))
+	}
+
+	for i, line := range lines {
+		if len(names[i]) != 63 {
+			t.Fatalf("fixture name must be 63 characters, got %d", len(names[i]))
+		}
+
+		// fzf displays everything after the hidden name field
+		_, display, found := strings.Cut(line, "\t")
+		if !found {
+			t.Fatalf("line %q has no hidden name field", line)
+		}
+		displayName := strings.Fields(display)[len(strings.Fields(display))-1]
+		if got := len([]rune(displayName)); got != MaxDisplayNameLength {
+			t.Errorf("display name %q has %d characters, want %d", displayName, got, MaxDisplayNameLength)
+		}
+		if !strings.HasSuffix(displayName, names[i][len(names[i])-7:]) {
+			t.Errorf("display name %q lost the distinctive suffix of %q", displayName, names[i])
+		}
+
+		// Selecting the line must return the exact, untruncated name
+		selected, err := ParseConfigurationName(line)
+		if err != nil {
+			t.Fatalf("ParseConfigurationName(%q) failed: %v", line, err)
+		}
+		if selected != names[i] {
+			t.Errorf("ParseConfigurationName() = %q, want %q", selected, names[i])
+		}
+	}
+}
+
+func TestFormatConfigurationLinesPickerFormat(t *testing.T) {
+	// A picker_format putting the name last, with a tab and a line break in it
+	picker, err := linefmt.Parse("picker_format", "{{with .Project}}{{.}}\t{{end}}{{.Marker}}\n{{.Name}}")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	output.SetLineFormats(nil, picker, "=>")
+	defer output.SetLineFormats(nil, nil, "")
+
+	configs := []gcloud.Configuration{
+		{Name: "prod", Properties: gcloud.Properties{Core: gcloud.CoreProperties{Project: "prod-project"}}},
+		{Name: "dev"},
+	}
+
+	got := FormatConfigurationLines(configs, "prod")
+	want := "prod\tprod-project => prod\ndev\t   dev\n"
+	if got != want {
+		t.Errorf("FormatConfigurationLines() = %q, want %q", got, want)
+	}
+
+	for i, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+		selected, err := ParseConfigurationName(line)
+		if err != nil {
+			t.Fatalf("ParseConfigurationName(%q) failed: %v", line, err)
+		}
+		if selected != configs[i].Name {
+			t.Errorf("ParseConfigurationName() = %q, want %q", selected, configs[i].Name)
+		}
+	}
+}
+
+func TestBuildFzfArgsHidesNameField(t *testing.T) {
+	t.Setenv(EnvFzfOptions, "")
+
+	args := strings.Join(mustBuildFzfArgs(t, "gcloudctx"), " ")
+	if !strings.Contains(args, "--delimiter \t --with-nth 2..") {
+		t.Errorf("expected the name field to be hidden, got args: %s", args)
+	}
+}
+
+func TestWithAcceptNameField(t *testing.T) {
+	base := []string{"--delimiter", nameFieldDelimiter, "--with-nth", "2.."}
+	tests := []struct {
+		name         string
+		major, minor int
+		ok           bool
+		want         bool
+	}{
+		{name: "0.60", major: 0, minor: 60, ok: true, want: true},
+		{name: "1.0", major: 1, minor: 0, ok: true, want: true},
+		{name: "0.59", major: 0, minor: 59, ok: true, want: false},
+		{name: "unknown", ok: false, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := withAcceptNameField(slices.Clone(base), fzfVersion{major: tt.major, minor: tt.minor, ok: tt.ok})
+			got := slices.Contains(args, "--accept-nth")
+			if got != tt.want {
+				t.Errorf("withAcceptNameField() = %q, want --accept-nth %v", args, tt.want)
+			}
+			if got && args[len(args)-1] != "1" {
+				t.Errorf("withAcceptNameField() = %q, want the name field accepted", args)
+			}
+		})
+	}
+}
+
+func TestBuildFzfArgsCustomOptions(t *testing.T) {
+	// valueOf returns the values of every occurrence of option in args
+	valueOf := func(args []string, option string) []string {
+		var values []string
+		for i, arg := range args {
+			if arg == option && i+1 < len(args) {
+				values = append(values, args[i+1])
+			}
+			if value, ok := strings.CutPrefix(arg, option+"="); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+
+	t.Run("quoted header replaces ours", func(t *testing.T) {
+		t.Setenv(EnvFzfOptions, `--header "pick a config" --cycle`)
+		args := mustBuildFzfArgs(t, "gcloudctx")
+		if got := valueOf(args, "--header"); !slices.Equal(got, []string{"pick a config"}) {
+			t.Errorf("--header values = %q, want only the custom one", got)
+		}
+		if args[len(args)-1] != "--cycle" {
+			t.Errorf("args = %q, want custom options last", args)
+		}
+	})
+
+	t.Run("equals with spaces", func(t *testing.T) {
+		t.Setenv(EnvFzfOptions, `--prompt='my gcloud> ' --height=90%`)
+		args := mustBuildFzfArgs(t, "gcloudctx")
+		if got := valueOf(args, "--prompt"); !slices.Equal(got, []string{"my gcloud> "}) {
+			t.Errorf("--prompt values = %q, want only the custom one", got)
+		}
+		if got := valueOf(args, "--height"); !slices.Equal(got, []string{"90%"}) {
+			t.Errorf("--height values = %q, want only the custom one", got)
+		}
+	})
+
+	t.Run("negated and replaced flags", func(t *testing.T) {
+		t.Setenv(EnvFzfOptions, "--no-ansi --layout=default")
+		args := mustBuildFzfArgs(t, "gcloudctx")
+		if slices.Contains(args, "--ansi") || slices.Contains(args, "--reverse") {
+			t.Errorf("args = %q, want --ansi and --reverse dropped", args)
+		}
+	})
+
+	t.Run("bindings are kept", func(t *testing.T) {
+		t.Setenv(EnvFzfOptions, `--bind "ctrl-y:execute(echo {})"`)
+		args := mustBuildFzfArgs(t, "gcloudctx")
+		if got := valueOf(args, "--bind"); len(got) < 2 || got[len(got)-1] != "ctrl-y:execute(echo {})" {
+			t.Errorf("--bind values = %q, want ours followed by the custom one", got)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Setenv(EnvFzfOptions, "")
+		withEmpty := mustBuildFzfArgs(t, "gcloudctx")
+		t.Setenv(EnvFzfOptions, `  `)
+		if args := mustBuildFzfArgs(t, "gcloudctx"); !slices.Equal(args, withEmpty) {
+			t.Errorf("blank options changed the args: %q", args)
+		}
+	})
+
+	t.Run("unbalanced quote", func(t *testing.T) {
+		t.Setenv(EnvFzfOptions, `--header "pick a config`)
+		_, err := buildFzfArgs("gcloudctx")
+		if err == nil || !strings.Contains(err.Error(), EnvFzfOptions) || !strings.Contains(err.Error(), "unterminated double quote") {
+			t.Errorf("buildFzfArgs() error = %v, want the unbalanced quote in %s reported", err, EnvFzfOptions)
+		}
+	})
+}
+
+func TestFormatConfigurationLinesMultibyte(t *testing.T) {
+	output.SetDisplayStyles(map[string]output.DisplayStyle{"prod": {Emoji: "🔥"}})
+	defer output.SetDisplayStyles(nil)
+
+	configs := []gcloud.Configuration{
+		{Name: "dev", Properties: gcloud.Properties{Core: gcloud.CoreProperties{Account: "開発@example.com", Project: "dev-project"}}},
+		{Name: "prod", Properties: gcloud.Properties{Core: gcloud.CoreProperties{Account: "ops@example.com", Project: "prod-project"}}},
+		{Name: "staging-eu", Properties: gcloud.Properties{Core: gcloud.CoreProperties{Account: "qa@example.com"}}},
+	}
+
+	lines := strings.Split(strings.TrimSuffix(FormatConfigurationLines(configs, "prod"), "\n"), "\n")
+	want := []string{
+		"dev\t  dev        (開発@example.com) [dev-project]",
+		"prod\t* 🔥 prod    (ops@example.com) [prod-project]",
+		"staging-eu\t  staging-eu (qa@example.com)",
+	}
+	if !slices.Equal(lines, want) {
+		t.Fatalf("FormatConfigurationLines() = %q, want %q", lines, want)
+	}
+
+	// The accounts start in the same terminal column on every line
+	for _, line := range lines {
+		_, display, _ := strings.Cut(line, "\t")
+		before, _, _ := strings.Cut(display, "(")
+		if width := linefmt.DisplayWidth(before); width != 13 {
+			t.Errorf("%q: account starts at column %d, want 13", display, width)
+		}
+	}
+}
+
+func TestPreviewWidth(t *testing.T) {
+	tests := []struct {
+		columns string
+		want    int
+	}{
+		{"", output.DefaultPreviewWidth},
+		{"72", 72},
+		{"0", output.DefaultPreviewWidth},
+		{"wide", output.DefaultPreviewWidth},
+	}
+	for _, tt := range tests {
+		t.Setenv("FZF_PREVIEW_COLUMNS", tt.columns)
+		if got := PreviewWidth(); got != tt.want {
+			t.Errorf("PreviewWidth() with FZF_PREVIEW_COLUMNS=%q = %d, want %d", tt.columns, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package interactive
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/mattn/go-isatty"
+)
+
+// BareAction is what a bare 'gcloudctx' (no arguments, no flags) does
+type BareAction int
+
+const (
+	// ShowCurrent prints the active configuration name
+	ShowCurrent BareAction = iota
+	// PickWithFzf opens the fzf picker
+	PickWithFzf
+	// PickNumbered opens the built-in numbered picker
+	PickNumbered
+)
+
+// BareOptions are the inputs deciding what a bare invocation does
+type BareOptions struct {
+	// IgnoreFzf is set by GCLOUDCTX_IGNORE_FZF=1 and restores printing the active configuration
+	IgnoreFzf bool
+	// DisableNumberedPicker is the disable_numbered_picker setting
+	DisableNumberedPicker bool
+	// FzfInstalled reports whether fzf is in PATH
+	FzfInstalled func() bool
+	// IsTerminal reports whether the user can answer a prompt
+	IsTerminal func() bool
+}
+
+// ChooseBareAction decides what a bare invocation does
+// fzf is preferred; without it, the numbered picker is only offered on a
+// terminal so scripts piping 'gcloudctx' keep getting the active name and
+// never wait for input
+func ChooseBareAction(opts BareOptions) BareAction {
+	if opts.IgnoreFzf {
+		return ShowCurrent
+	}
+	if opts.FzfInstalled() {
+		return PickWithFzf
+	}
+	if !opts.DisableNumberedPicker && opts.IsTerminal() {
+		return PickNumbered
+	}
+	return ShowCurrent
+}
+
+// IsTerminal reports whether both stdin and stdout are terminals
+func IsTerminal() bool {
+	return isTTY(os.Stdin) && isTTY(os.Stdout)
+}
+
+// isTTY reports whether f is a terminal, including Cygwin and MSYS terminals
+func isTTY(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// SelectConfigurationNumbered lists configs with numbers on out and reads the
+// choice from in, either a number or a configuration name
+// An empty answer or end of input cancels with ErrSelectionCanceled; an
+// invalid answer asks again
+func SelectConfigurationNumbered(in io.Reader, out io.Writer, configs []gcloud.Configuration, currentConfig string) (string, error) {
+	if len(configs) == 0 {
+		return "", ErrNoConfigurations
+	}
+
+	width := len(strconv.Itoa(len(configs)))
+	for i := range configs {
+		config := &configs[i]
+		displayName := output.TruncateMiddle(config.Name, MaxDisplayNameLength)
+		line := output.FormatPickerLine(config, displayName, config.Name == currentConfig)
+		fmt.Fprintf(out, "%*d) %s\n", width, i+1, line)
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "Select a configuration [1-%d] (Enter to cancel): ", len(configs))
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			if err := scanner.Err(); err != nil {
+				return "", fmt.Errorf("failed to read selection: %w", err)
+			}
+			return "", ErrSelectionCanceled
+		}
+
+		answer := strings.TrimSpace(scanner.Text())
+		if answer == "" {
+			return "", ErrSelectionCanceled
+		}
+		if name, ok := numberedChoice(configs, answer); ok {
+			return name, nil
+		}
+		fmt.Fprintf(out, "%q is not a number between 1 and %d or a configuration name\n", answer, len(configs))
+	}
+}
+
+// numberedChoice resolves an answer to the numbered picker
+func numberedChoice(configs []gcloud.Configuration, answer string) (string, bool) {
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n >= 1 && n <= len(configs) {
+			return configs[n-1].Name, true
+		}
+		return "", false
+	}
+	for _, config := range configs {
+		if config.Name == answer {
+			return config.Name, true
+		}
+	}
+	return "", false
+}
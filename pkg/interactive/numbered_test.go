@@ -0,0 +1,101 @@
+package interactive
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+func TestChooseBareAction(t *testing.T) {
+	yes := func() bool { return true }
+	no := func() bool { return false }
+
+	tests := []struct {
+		name string
+		opts BareOptions
+		want BareAction
+	}{
+		{name: "fzf", opts: BareOptions{FzfInstalled: yes, IsTerminal: yes}, want: PickWithFzf},
+		{name: "no fzf on a terminal", opts: BareOptions{FzfInstalled: no, IsTerminal: yes}, want: PickNumbered},
+		{name: "no fzf in a pipe", opts: BareOptions{FzfInstalled: no, IsTerminal: no}, want: ShowCurrent},
+		{name: "ignore fzf", opts: BareOptions{IgnoreFzf: true, FzfInstalled: yes, IsTerminal: yes}, want: ShowCurrent},
+		{name: "ignore fzf without fzf", opts: BareOptions{IgnoreFzf: true, FzfInstalled: no, IsTerminal: yes}, want: ShowCurrent},
+		{name: "numbered picker disabled", opts: BareOptions{DisableNumberedPicker: true, FzfInstalled: no, IsTerminal: yes}, want: ShowCurrent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChooseBareAction(tt.opts); got != tt.want {
+				t.Errorf("ChooseBareAction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectConfigurationNumbered(t *testing.T) {
+	configs := []gcloud.Configuration{
+		{Name: "default", Properties: gcloud.Properties{
+			Core: gcloud.CoreProperties{Account: "user@example.com", Project: "my-project"},
+		}},
+		{Name: "bare"},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "number", input: "2\n", want: "bare"},
+		{name: "name", input: " default \n", want: "default"},
+		{name: "retry after invalid answers", input: "3\nprod\n1\n", want: "default"},
+		{name: "empty answer", input: "\n", wantErr: ErrSelectionCanceled},
+		{name: "end of input", input: "", wantErr: ErrSelectionCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := SelectConfigurationNumbered(strings.NewReader(tt.input), &out, configs, "default")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("SelectConfigurationNumbered() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("SelectConfigurationNumbered() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectConfigurationNumberedOutput(t *testing.T) {
+	configs := []gcloud.Configuration{
+		{Name: "default", Properties: gcloud.Properties{
+			Core: gcloud.CoreProperties{Account: "user@example.com", Project: "my-project"},
+		}},
+		{Name: "bare"},
+	}
+
+	var out bytes.Buffer
+	if _, err := SelectConfigurationNumbered(strings.NewReader("0\n1\n"), &out, configs, "default"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "1) * default (user@example.com) [my-project]\n" +
+		"2)   bare\n" +
+		"Select a configuration [1-2] (Enter to cancel): " +
+		"\"0\" is not a number between 1 and 2 or a configuration name\n" +
+		"Select a configuration [1-2] (Enter to cancel): "
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	if _, err := SelectConfigurationNumbered(strings.NewReader(""), &out, nil, ""); !errors.Is(err, ErrNoConfigurations) {
+		t.Errorf("SelectConfigurationNumbered() without configurations error = %v, want %v", err, ErrNoConfigurations)
+	}
+}
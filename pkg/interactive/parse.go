@@ -3,13 +3,29 @@ package interactive
 import (
 	"fmt"
 	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/output"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
 )
 
 // ParseConfigurationName extracts the configuration name from a formatted line
-// Expected formats:
+// Escape sequences fzf leaves in the line are removed and only the first line of
+// a multi-line selection is read. Expected formats:
+//   - "config-name" (the hidden name field alone, as printed with --accept-nth)
+//   - "config-name\t* display-name (account) [project]" (picker line with hidden full name)
 //   - "* config-name (account) [project]" (active)
+//   - "^ config-name (account) [project]" (previous)
 //   - "  config-name (account) [project]" (non-active)
 func ParseConfigurationName(line string) (string, error) {
+	line = firstLine(output.StripANSI(line))
+
+	// The hidden first field holds the exact name even when the display name is truncated
+	if name, _, found := strings.Cut(line, nameFieldDelimiter); found {
+		if name = strings.TrimSpace(name); name != "" {
+			return name, nil
+		}
+	}
+
 	line = strings.TrimSpace(line)
 	if line == "" {
 		return "", fmt.Errorf("empty line")
@@ -23,8 +39,8 @@ func ParseConfigurationName(line string) (string, error) {
 
 	// Find the configuration name (skip marker and parenthesized/bracketed fields)
 	for _, part := range parts {
-		// Skip marker and fields that start with ( or [
-		if part == "*" || strings.HasPrefix(part, "(") || strings.HasPrefix(part, "[") {
+		// Skip markers and fields that start with ( or [
+		if part == linefmt.DefaultActiveMarker || part == linefmt.PreviousMarker || strings.HasPrefix(part, "(") || strings.HasPrefix(part, "[") {
 			continue
 		}
 		// This should be the configuration name
@@ -33,3 +49,13 @@ func ParseConfigurationName(line string) (string, error) {
 
 	return "", fmt.Errorf("could not extract configuration name")
 }
+
+// firstLine returns the first non-blank line of s, for multi-line fzf selections
+func firstLine(s string) string {
+	for line := range strings.Lines(s) {
+		if strings.TrimSpace(line) != "" {
+			return strings.TrimRight(line, "\r\n")
+		}
+	}
+	return ""
+}
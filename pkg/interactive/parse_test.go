@@ -59,6 +59,72 @@ func TestParseConfigurationName(t *testing.T) {
 			expected:    "my_test_config",
 			shouldError: false,
 		},
+		{
+			name:        "previous configuration",
+			input:       "^ staging (staging@example.com) [staging-project]",
+			expected:    "staging",
+			shouldError: false,
+		},
+		{
+			name:        "previous configuration with hidden name field",
+			input:       "my-config\t^ my-config (test@example.com)",
+			expected:    "my-config",
+			shouldError: false,
+		},
+		{
+			name:        "only previous marker",
+			input:       "^",
+			expected:    "",
+			shouldError: true,
+		},
+		{
+			name:        "name field alone",
+			input:       "my-config",
+			expected:    "my-config",
+			shouldError: false,
+		},
+		{
+			name:        "name field with trailing delimiter",
+			input:       "my-config\t",
+			expected:    "my-config",
+			shouldError: false,
+		},
+		{
+			name:        "colored picker line",
+			input:       "\x1b[33m*\x1b[0m \x1b[31mprod\x1b[0m (admin@example.com) [prod-project]",
+			expected:    "prod",
+			shouldError: false,
+		},
+		{
+			name:        "highlighted match",
+			input:       "  de\x1b[7mv\x1b[27m (dev@example.com)",
+			expected:    "dev",
+			shouldError: false,
+		},
+		{
+			name:        "escape sequence in name field",
+			input:       "\x1b[0mmy-config\x1b[K\t* my-con…fig (test@example.com)",
+			expected:    "my-config",
+			shouldError: false,
+		},
+		{
+			name:        "multi-line selection",
+			input:       "dev\t* dev (dev@example.com)\nprod\t  prod (admin@example.com)\n",
+			expected:    "dev",
+			shouldError: false,
+		},
+		{
+			name:        "multi-line selection without name fields",
+			input:       "\n  staging [staging-project]\r\n* prod [prod-project]",
+			expected:    "staging",
+			shouldError: false,
+		},
+		{
+			name:        "only escape sequences",
+			input:       "\x1b[0m\x1b[K",
+			expected:    "",
+			shouldError: true,
+		},
 		{
 			name:        "empty line",
 			input:       "",
@@ -107,3 +173,26 @@ func TestParseConfigurationName(t *testing.T) {
 		})
 	}
 }
+
+func TestParseProjectID(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"* prod-project (Production) [123456789012]", "prod-project"},
+		{"  dev-project (Dev Sandbox With Spaces) [42]", "dev-project"},
+		{"  bare-project", "bare-project"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseProjectID(tt.input)
+			if err != nil {
+				t.Fatalf("ParseProjectID(%q) unexpected error: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseProjectID(%q) = %q; want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
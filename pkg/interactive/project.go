@@ -0,0 +1,94 @@
+package interactive
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
+)
+
+// SelectProjectInteractive allows the user to select a project using fzf
+// It returns the selected project ID
+func SelectProjectInteractive(projects []gcloud.Project, currentProject string) (string, error) {
+	if !IsFzfInstalled() {
+		return "", ErrFzfNotInstalled
+	}
+
+	if len(projects) == 0 {
+		return "", ErrNoProjects
+	}
+
+	selfCmd, err := getSelfCommand()
+	if err != nil {
+		// Fallback to "gcloudctx" if we can't get the executable path
+		selfCmd = "gcloudctx"
+	}
+
+	fzfArgs, err := buildProjectFzfArgs(selfCmd)
+	if err != nil {
+		return "", err
+	}
+	selected, err := runFzf(FormatProjectLines(projects, currentProject), fzfArgs, installedFzfVersion())
+	if err != nil {
+		return "", err
+	}
+
+	return ParseProjectID(selected)
+}
+
+// FormatProjectLines builds the fzf input lines for the given projects
+// Each line has the format "* project-id (Project Name) [project-number]", where "*" marks currentProject
+// IDs and names are padded to the display width of the widest, so the columns
+// line up when names have wide characters such as CJK or emoji
+func FormatProjectLines(projects []gcloud.Project, currentProject string) string {
+	idWidth, nameWidth := 0, 0
+	for _, project := range projects {
+		idWidth = max(idWidth, linefmt.DisplayWidth(project.ProjectID))
+		nameWidth = max(nameWidth, linefmt.DisplayWidth(projectNameField(project)))
+	}
+
+	var builder strings.Builder
+	for _, project := range projects {
+		marker := " "
+		if project.ProjectID == currentProject {
+			marker = "*"
+		}
+
+		line := fmt.Sprintf("%s %s", marker, linefmt.PadRight(project.ProjectID, idWidth))
+		if nameWidth > 0 {
+			line += " " + linefmt.PadRight(projectNameField(project), nameWidth)
+		}
+		if project.ProjectNumber != "" {
+			line += fmt.Sprintf(" [%s]", project.ProjectNumber)
+		}
+
+		builder.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return builder.String()
+}
+
+// projectNameField returns the "(Project Name)" field of a project line, empty without a name
+func projectNameField(project gcloud.Project) string {
+	if project.Name == "" {
+		return ""
+	}
+	return "(" + project.Name + ")"
+}
+
+// ParseProjectID extracts the project ID from a formatted project line
+// The project ID always comes first, so names containing spaces are handled
+func ParseProjectID(line string) (string, error) {
+	return ParseConfigurationName(line)
+}
+
+// buildProjectFzfArgs builds the fzf command arguments for the project picker
+func buildProjectFzfArgs(selfCmd string) ([]string, error) {
+	previewCmd := ""
+	if os.Getenv(EnvDisablePreview) != "1" {
+		previewCmd = fmt.Sprintf(`%s %s {}`, selfCmd, ProjectPreviewCommand)
+	}
+
+	return buildPickerArgs("Select a project:", "project> ", previewCmd, nil)
+}
@@ -0,0 +1,70 @@
+package interactive
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
+)
+
+func TestFormatProjectLines(t *testing.T) {
+	projects := []gcloud.Project{
+		{ProjectID: "prod-project", Name: "Production", ProjectNumber: "123"},
+		{ProjectID: "bare-project"},
+	}
+
+	got := FormatProjectLines(projects, "prod-project")
+	want := "* prod-project (Production) [123]\n  bare-project\n"
+	if got != want {
+		t.Errorf("FormatProjectLines() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildProjectFzfArgs(t *testing.T) {
+	t.Setenv(EnvDisablePreview, "")
+
+	args, err := buildProjectFzfArgs("gcloudctx")
+	if err != nil {
+		t.Fatalf("buildProjectFzfArgs() error = %v", err)
+	}
+
+	foundPreview := false
+	for i, arg := range args {
+		if arg == "--preview" && i+1 < len(args) {
+			foundPreview = args[i+1] == "gcloudctx "+ProjectPreviewCommand+" {}"
+		}
+		if arg == "--bind" {
+			t.Errorf("project picker should not include configuration bindings: %v", args)
+		}
+	}
+	if !foundPreview {
+		t.Errorf("buildProjectFzfArgs() missing project preview: %v", args)
+	}
+}
+
+func TestFormatProjectLinesMultibyte(t *testing.T) {
+	projects := []gcloud.Project{
+		{ProjectID: "shop-prod", Name: "本番ショップ", ProjectNumber: "111"},
+		{ProjectID: "analytics", Name: "Analytics 📊", ProjectNumber: "222"},
+		{ProjectID: "sandbox", ProjectNumber: "333"},
+		{ProjectID: "scratch-project"},
+	}
+
+	got := FormatProjectLines(projects, "shop-prod")
+	want := "* shop-prod       (本番ショップ) [111]\n" +
+		"  analytics       (Analytics 📊) [222]\n" +
+		"  sandbox                        [333]\n" +
+		"  scratch-project\n"
+	if got != want {
+		t.Fatalf("FormatProjectLines() = %q, want %q", got, want)
+	}
+
+	// The project numbers start in the same terminal column on every line with one
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n")[:3] {
+		before, _, _ := strings.Cut(line, "[")
+		if width := linefmt.DisplayWidth(before); width != 33 {
+			t.Errorf("%q: number starts at column %d, want 33", line, width)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package interactive
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// splitShellWords splits s into words the way a POSIX shell does, without
+// expansions: whitespace separates words, single quotes keep everything
+// literally, double quotes keep everything but backslash escapes of \ " $ and `,
+// and a backslash outside quotes escapes the next character
+func splitShellWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	// inWord is set once a word has started, so "" yields an empty word
+	inWord := false
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("trailing backslash at position %d", i+1)
+			}
+			i++
+			// A backslash before a line break joins the lines
+			if runes[i] != '\n' {
+				word.WriteRune(runes[i])
+				inWord = true
+			}
+
+		case r == '\'':
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote at position %d", i+1)
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			inWord = true
+			i = end
+
+		case r == '"':
+			start := i
+			inWord = true
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated double quote at position %d", start+1)
+				}
+				if runes[i] == '"' {
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\\\"$`\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// indexRune returns the index of the first r in runes at or after from, or -1
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// safeShellWord matches words that need no quoting in a POSIX shell or cmd.exe
+var safeShellWord = regexp.MustCompile(`^[A-Za-z0-9_./:@+,-]+$`)
+
+// quoteShellWord quotes s as one word for the shell fzf runs preview and bind
+// commands with: cmd.exe on windows (goos), a POSIX shell elsewhere
+// Words that need no quoting, such as most executable paths, are kept as is
+func quoteShellWord(s, goos string) string {
+	if safeShellWord.MatchString(s) {
+		return s
+	}
+	if goos == "windows" {
+		// Windows paths cannot contain double quotes
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
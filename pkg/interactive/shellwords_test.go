@@ -0,0 +1,76 @@
+package interactive
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: nil},
+		{name: "blank", input: " \t\n ", want: nil},
+		{name: "plain", input: "--cycle  --no-mouse", want: []string{"--cycle", "--no-mouse"}},
+		{name: "double quoted header", input: `--header "pick a config"`, want: []string{"--header", "pick a config"}},
+		{name: "single quoted header", input: `--header 'pick a "config"'`, want: []string{"--header", `pick a "config"`}},
+		{name: "equals with spaces", input: `--header="pick a config" --prompt='gcloud > '`, want: []string{"--header=pick a config", "--prompt=gcloud > "}},
+		{name: "empty argument", input: `--header ""`, want: []string{"--header", ""}},
+		{name: "escaped space", input: `--prompt gcloud\ \>`, want: []string{"--prompt", "gcloud >"}},
+		{name: "escapes in double quotes", input: `--header "say \"hi\" for \$5 \n"`, want: []string{"--header", `say "hi" for $5 \n`}},
+		{name: "backslash in single quotes", input: `--delimiter '\t'`, want: []string{"--delimiter", `\t`}},
+		{name: "adjacent quotes", input: `--bind 'ctrl-y:execute(echo '"{}"')'`, want: []string{"--bind", "ctrl-y:execute(echo {})"}},
+		{name: "line continuation", input: "--cycle \\\n--no-mouse", want: []string{"--cycle", "--no-mouse"}},
+		{name: "multi-byte", input: `--prompt "→ "`, want: []string{"--prompt", "→ "}},
+		{name: "unterminated double quote", input: `--header "pick a config`, wantErr: true},
+		{name: "unterminated single quote", input: `--header 'pick`, wantErr: true},
+		{name: "trailing backslash", input: `--cycle \`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitShellWords(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("splitShellWords(%q) = %q, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil || !slices.Equal(got, tt.want) {
+				t.Errorf("splitShellWords(%q) = %q, %v; want %q", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteShellWord(t *testing.T) {
+	tests := []struct {
+		name string
+		word string
+		goos string
+		want string
+	}{
+		{name: "plain path", word: "/usr/local/bin/gcloudctx", goos: "linux", want: "/usr/local/bin/gcloudctx"},
+		{name: "path with spaces", word: "/Users/me/My Tools/gcloudctx", goos: "darwin", want: "'/Users/me/My Tools/gcloudctx'"},
+		{name: "single quote", word: "/home/o'brien/bin/gcloudctx", goos: "linux", want: `'/home/o'\''brien/bin/gcloudctx'`},
+		{name: "windows path", word: `C:\Program Files\gcloudctx\gcloudctx.exe`, goos: "windows", want: `"C:\Program Files\gcloudctx\gcloudctx.exe"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteShellWord(tt.word, tt.goos); got != tt.want {
+				t.Errorf("quoteShellWord(%q, %s) = %s, want %s", tt.word, tt.goos, got, tt.want)
+			}
+			if tt.goos == "windows" {
+				return
+			}
+			// The shell fzf runs the command with reads it back as one word
+			if words, err := splitShellWords(quoteShellWord(tt.word, tt.goos)); err != nil || !slices.Equal(words, []string{tt.word}) {
+				t.Errorf("splitShellWords(quoteShellWord(%q)) = %q, %v", tt.word, words, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package interactive
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// tmuxMode describes how fzf is launched inside tmux
+type tmuxMode int
+
+const (
+	// tmuxNone runs fzf inline in the current terminal
+	tmuxNone tmuxMode = iota
+	// tmuxNative uses fzf's own --tmux flag
+	tmuxNative
+	// tmuxScript uses the fzf-tmux wrapper script with a popup
+	tmuxScript
+)
+
+// fzf gained the --tmux flag in 0.53.0
+const (
+	nativeTmuxMajor = 0
+	nativeTmuxMinor = 53
+)
+
+// detectTmuxMode decides whether to open fzf, of the given version, in a tmux popup.
+// GCLOUDCTX_FZF_TMUX=0 disables popups, =1 forces them; otherwise they are used
+// when running inside tmux ($TMUX is set).
+func detectTmuxMode(version fzfVersion) tmuxMode {
+	switch os.Getenv(EnvFzfTmux) {
+	case "0":
+		return tmuxNone
+	case "1":
+	default:
+		if os.Getenv("TMUX") == "" {
+			return tmuxNone
+		}
+	}
+
+	if version.atLeast(nativeTmuxMajor, nativeTmuxMinor) {
+		return tmuxNative
+	}
+	if _, err := exec.LookPath("fzf-tmux"); err == nil {
+		return tmuxScript
+	}
+	return tmuxNone
+}
+
+// fzfVersion is the major and minor version of the installed fzf; ok is false
+// when it could not be determined
+type fzfVersion struct {
+	major, minor int
+	ok           bool
+}
+
+// atLeast reports whether the version is known and wantMajor.wantMinor or later
+func (v fzfVersion) atLeast(wantMajor, wantMinor int) bool {
+	return v.ok && versionAtLeast(v.major, v.minor, wantMajor, wantMinor)
+}
+
+// installedFzfVersion runs `fzf --version` and returns its version
+// Pickers run it once and pass the result to every check that depends on it
+func installedFzfVersion() fzfVersion {
+	out, err := exec.Command("fzf", "--version").Output()
+	if err != nil {
+		return fzfVersion{}
+	}
+	major, minor, ok := parseFzfVersion(string(out))
+	return fzfVersion{major: major, minor: minor, ok: ok}
+}
+
+// versionAtLeast reports whether major.minor is wantMajor.wantMinor or later
+func versionAtLeast(major, minor, wantMajor, wantMinor int) bool {
+	return major > wantMajor || (major == wantMajor && minor >= wantMinor)
+}
+
+// parseFzfVersion extracts the major and minor version from `fzf --version` output
+// Expected formats: "0.54.0 (brew)", "0.44.1 (d7d2ac3)", "0.29.0"
+func parseFzfVersion(output string) (major, minor int, ok bool) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return 0, 0, false
+	}
+
+	parts := strings.Split(fields[0], ".")
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// tmuxPopupSetting is the fzf.tmux_popup setting (see SetTmuxPopupSize)
+var tmuxPopupSetting string
+
+// SetTmuxPopupSize applies the fzf.tmux_popup setting, the "WIDTH,HEIGHT" of
+// the tmux popup; GCLOUDCTX_FZF_TMUX_POPUP takes precedence over it
+func SetTmuxPopupSize(size string) {
+	tmuxPopupSetting = size
+}
+
+// tmuxPopupSize returns the size of the tmux popup from GCLOUDCTX_FZF_TMUX_POPUP,
+// then the fzf.tmux_popup setting, then DefaultFzfTmuxPopup
+func tmuxPopupSize() string {
+	if size := os.Getenv(EnvFzfTmuxPopup); size != "" {
+		return size
+	}
+	if tmuxPopupSetting != "" {
+		return tmuxPopupSetting
+	}
+	return DefaultFzfTmuxPopup
+}
+
+// applyTmuxMode returns the binary and arguments used to launch fzf for the given mode.
+// Popups size themselves, so the default inline --height and --border options
+// are dropped; the same options set in custom (GCLOUDCTX_FZF_OPTIONS) are kept.
+func applyTmuxMode(mode tmuxMode, args, custom []string, popupSize string) (binary string, result []string) {
+	if mode == tmuxNone {
+		return "fzf", args
+	}
+
+	result = stripInlineOptions(args, custom)
+	switch mode {
+	case tmuxNative:
+		return "fzf", append([]string{"--tmux", "center," + popupSize}, result...)
+	default:
+		// fzf-tmux expects its own options before the fzf options
+		return "fzf-tmux", append([]string{"-p", popupSize}, result...)
+	}
+}
+
+// stripInlineOptions removes the default options that only make sense for
+// inline fzf, unless custom sets them; withoutOverridden already dropped the
+// defaults custom replaces, so what remains of an option custom sets is its own
+func stripInlineOptions(args, custom []string) []string {
+	names := optionNames(custom)
+	result := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--height" && !names["--height"] && i+1 < len(args):
+			i++ // Skip the value as well
+		case args[i] == "--border" && !names["--border"]:
+		default:
+			result = append(result, args[i])
+		}
+	}
+	return result
+}
@@ -0,0 +1,153 @@
+package interactive
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFzfVersion(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantMajor int
+		wantMinor int
+		wantOK    bool
+	}{
+		{"0.54.0 (brew)", 0, 54, true},
+		{"0.44.1 (d7d2ac3)\n", 0, 44, true},
+		{"0.29.0", 0, 29, true},
+		{"1.2", 1, 2, true},
+		{"", 0, 0, false},
+		{"unknown", 0, 0, false},
+		{"x.y.z", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			major, minor, ok := parseFzfVersion(tt.input)
+			if ok != tt.wantOK || major != tt.wantMajor || minor != tt.wantMinor {
+				t.Errorf("parseFzfVersion(%q) = (%d, %d, %v); want (%d, %d, %v)",
+					tt.input, major, minor, ok, tt.wantMajor, tt.wantMinor, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestApplyTmuxMode(t *testing.T) {
+	args := []string{"--ansi", "--height", "40%", "--reverse", "--border", "--prompt", "gcloud> "}
+
+	tests := []struct {
+		name       string
+		mode       tmuxMode
+		wantBinary string
+		wantArgs   []string
+	}{
+		{
+			name:       "inline",
+			mode:       tmuxNone,
+			wantBinary: "fzf",
+			wantArgs:   args,
+		},
+		{
+			name:       "native tmux flag",
+			mode:       tmuxNative,
+			wantBinary: "fzf",
+			wantArgs:   []string{"--tmux", "center,80%,60%", "--ansi", "--reverse", "--prompt", "gcloud> "},
+		},
+		{
+			name:       "fzf-tmux script",
+			mode:       tmuxScript,
+			wantBinary: "fzf-tmux",
+			wantArgs:   []string{"-p", "80%,60%", "--ansi", "--reverse", "--prompt", "gcloud> "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binary, gotArgs := applyTmuxMode(tt.mode, args, nil, "80%,60%")
+			if binary != tt.wantBinary {
+				t.Errorf("binary = %q, want %q", binary, tt.wantBinary)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestApplyTmuxModeKeepsCustomOptions(t *testing.T) {
+	// --border came from GCLOUDCTX_FZF_OPTIONS, so withoutOverridden dropped the default one
+	custom := []string{"--border", "rounded", "--height=50%"}
+	args := []string{"--ansi", "--height", "40%", "--reverse", "--border", "rounded", "--height=50%"}
+
+	_, got := applyTmuxMode(tmuxNative, args, custom, "80%,60%")
+	want := []string{"--tmux", "center,80%,60%", "--ansi", "--height", "40%", "--reverse", "--border", "rounded", "--height=50%"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %v, want %v", got, want)
+	}
+
+	_, got = applyTmuxMode(tmuxNative, []string{"--ansi", "--height", "40%", "--border", "--cycle"}, []string{"--cycle"}, "80%,60%")
+	want = []string{"--tmux", "center,80%,60%", "--ansi", "--cycle"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args with unrelated custom options = %v, want %v", got, want)
+	}
+}
+
+func TestTmuxPopupSize(t *testing.T) {
+	t.Cleanup(func() { SetTmuxPopupSize("") })
+
+	t.Setenv(EnvFzfTmuxPopup, "")
+	SetTmuxPopupSize("")
+	if got := tmuxPopupSize(); got != DefaultFzfTmuxPopup {
+		t.Errorf("tmuxPopupSize() = %q, want the default", got)
+	}
+
+	SetTmuxPopupSize("90%,70%")
+	if got := tmuxPopupSize(); got != "90%,70%" {
+		t.Errorf("tmuxPopupSize() = %q, want the setting", got)
+	}
+
+	t.Setenv(EnvFzfTmuxPopup, "100%,100%")
+	if got := tmuxPopupSize(); got != "100%,100%" {
+		t.Errorf("tmuxPopupSize() = %q, want the environment over the setting", got)
+	}
+}
+
+func TestDetectTmuxMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		version fzfVersion
+		want    tmuxMode
+	}{
+		{name: "fzf with --tmux", version: fzfVersion{major: 0, minor: 53, ok: true}, want: tmuxNative},
+		{name: "forced", env: "1", version: fzfVersion{major: 0, minor: 60, ok: true}, want: tmuxNative},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+			t.Setenv(EnvFzfTmux, tt.env)
+			if mode := detectTmuxMode(tt.version); mode != tt.want {
+				t.Errorf("detectTmuxMode(%+v) = %v, want %v", tt.version, mode, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectTmuxModeDisabled(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+	t.Setenv(EnvFzfTmux, "0")
+
+	if mode := detectTmuxMode(fzfVersion{major: 0, minor: 60, ok: true}); mode != tmuxNone {
+		t.Errorf("detectTmuxMode() = %v, want tmuxNone when %s=0", mode, EnvFzfTmux)
+	}
+}
+
+func TestDetectTmuxModeOutsideTmux(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv(EnvFzfTmux, "")
+
+	if mode := detectTmuxMode(fzfVersion{major: 0, minor: 60, ok: true}); mode != tmuxNone {
+		t.Errorf("detectTmuxMode() = %v, want tmuxNone outside tmux", mode)
+	}
+}
@@ -0,0 +1,248 @@
+// Package linefmt renders one line per configuration from a user-supplied Go template.
+// It backs the list_format and picker_format settings; templates are validated when
+// parsed so mistakes surface when the settings are loaded, not halfway through a list.
+package linefmt
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/fatih/color"
+)
+
+// Defaults reproducing the built-in output
+const (
+	// DefaultActiveMarker marks the active configuration
+	DefaultActiveMarker = "*"
+
+	// PreviousMarker marks the configuration 'gcloudctx -' switches to
+	PreviousMarker = "^"
+
+	// DefaultListFormat is the line of the default list view
+	DefaultListFormat = `{{.Marker}} {{with .Emoji}}{{.}} {{end}}` +
+		`{{if .Color}}{{if .IsActive}}{{paint (print .Color " bold") .Name}}{{else}}{{paint .Color .Name}}{{end}}` +
+		`{{else if .Protected}}{{red .Name}}{{else if .IsActive}}{{yellow .Name}}{{else}}{{cyan .Name}}{{end}}` +
+		`{{with .Account}} {{gray (printf "(%s)" .)}}{{end}}{{with .Project}} {{gray (printf "[%s]" .)}}{{end}}`
+
+	// DefaultPickerFormat is the visible part of an fzf picker line
+	DefaultPickerFormat = `{{.Marker}} {{with .Emoji}}{{.}} {{end}}` +
+		`{{if .Color}}{{paint .Color .Name}}{{else if .Protected}}{{red .Name}}{{else}}{{.Name}}{{end}}` +
+		`{{with .Account}} ({{.}}){{end}}{{with .Project}} [{{.}}]{{end}}`
+)
+
+// Fields are the values available to a line template
+type Fields struct {
+	Name     string
+	Account  string
+	Project  string
+	Region   string
+	Zone     string
+	IsActive bool
+	// IsPrevious reports whether 'gcloudctx -' switches to the configuration
+	IsPrevious bool
+	// Pinned reports whether the current terminal is pinned to the configuration
+	Pinned bool
+	// Protected reports whether switching to the configuration asks for confirmation
+	Protected bool
+	// Note is a free-form note about the configuration, empty when none is recorded
+	Note string
+	// Color is the display color set for the configuration, empty when none is set
+	Color string
+	// Emoji is the display emoji set for the configuration, empty when none is set
+	Emoji string
+	// Marker is the active marker for the active configuration, PreviousMarker for
+	// the previous one and padding otherwise
+	Marker string
+}
+
+// Template is a parsed and validated line template
+type Template struct {
+	tmpl *template.Template
+}
+
+// Error describes an invalid template and where the mistake is
+type Error struct {
+	// Setting is the name of the setting holding the template
+	Setting string
+	// Line and Column locate the mistake; Column is 0 when unknown
+	Line   int
+	Column int
+	Reason string
+}
+
+func (e *Error) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("invalid %s at line %d, column %d: %s", e.Setting, e.Line, e.Column, e.Reason)
+	}
+	return fmt.Sprintf("invalid %s at line %d: %s", e.Setting, e.Line, e.Reason)
+}
+
+// funcs are the helpers templates may call; colors honor color.NoColor at render time
+var funcs = template.FuncMap{
+	"cyan":    colorFunc(color.FgCyan),
+	"yellow":  colorFunc(color.FgYellow, color.Bold),
+	"gray":    colorFunc(color.FgHiBlack),
+	"green":   colorFunc(color.FgGreen),
+	"red":     colorFunc(color.FgRed),
+	"bold":    colorFunc(color.Bold),
+	"reverse": colorFunc(color.ReverseVideo),
+	"paint":   Paint,
+}
+
+// colors maps the names accepted by the display setting to their attributes
+var colors = map[string]color.Attribute{
+	"black":          color.FgBlack,
+	"red":            color.FgRed,
+	"green":          color.FgGreen,
+	"yellow":         color.FgYellow,
+	"blue":           color.FgBlue,
+	"magenta":        color.FgMagenta,
+	"cyan":           color.FgCyan,
+	"white":          color.FgWhite,
+	"gray":           color.FgHiBlack,
+	"bright-red":     color.FgHiRed,
+	"bright-green":   color.FgHiGreen,
+	"bright-yellow":  color.FgHiYellow,
+	"bright-blue":    color.FgHiBlue,
+	"bright-magenta": color.FgHiMagenta,
+	"bright-cyan":    color.FgHiCyan,
+	"bright-white":   color.FgHiWhite,
+}
+
+// ColorNames returns the color names accepted by ValidateColor, sorted
+func ColorNames() []string {
+	return slices.Sorted(maps.Keys(colors))
+}
+
+// ValidateColor checks that name is one of ColorNames
+func ValidateColor(name string) error {
+	if _, ok := colors[name]; !ok {
+		return fmt.Errorf("unknown color %q (valid colors: %s)", name, strings.Join(ColorNames(), ", "))
+	}
+	return nil
+}
+
+// Paint wraps v in the attributes of spec, a space-separated list of color
+// names and "bold"; unknown words are ignored and an empty spec leaves v plain
+func Paint(spec string, v any) string {
+	var attrs []color.Attribute
+	for _, word := range strings.Fields(spec) {
+		if word == "bold" {
+			attrs = append(attrs, color.Bold)
+		} else if attr, ok := colors[word]; ok {
+			attrs = append(attrs, attr)
+		}
+	}
+	if len(attrs) == 0 {
+		return fmt.Sprint(v)
+	}
+	return color.New(attrs...).Sprint(v)
+}
+
+// colorFunc returns a template function wrapping its argument in the given attributes
+func colorFunc(attrs ...color.Attribute) func(any) string {
+	return func(v any) string {
+		return color.New(attrs...).Sprint(v)
+	}
+}
+
+// sampleFields exercise every field and both branches of IsActive and Protected during validation
+var sampleFields = []Fields{
+	{Name: "sample", Account: "user@example.com", Project: "sample-project", Region: "us-central1", Zone: "us-central1-a", IsActive: true, Pinned: true, Protected: true, Note: "note", Color: "red", Emoji: "🔥", Marker: DefaultActiveMarker},
+	{Name: "sample", IsPrevious: true, Marker: PreviousMarker},
+	{Name: "sample", Marker: " "},
+}
+
+// Parse parses text as the template of the named setting and validates it by
+// rendering sample configurations, so unknown fields and functions are reported
+func Parse(setting, text string) (*Template, error) {
+	tmpl, err := template.New(setting).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, templateError(setting, err)
+	}
+
+	t := &Template{tmpl: tmpl}
+	for _, fields := range sampleFields {
+		if _, err := t.Render(fields); err != nil {
+			return nil, templateError(setting, err)
+		}
+	}
+	return t, nil
+}
+
+// MustParse is like Parse but panics on error; it is meant for the built-in defaults
+func MustParse(setting, text string) *Template {
+	t, err := Parse(setting, text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render renders the line for fields
+func (t *Template) Render(fields Fields) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, fields); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Marker returns activeMarker for the active configuration and padding of the
+// same width otherwise, so names line up whatever marker is chosen
+func Marker(activeMarker string, active bool) string {
+	if active {
+		return activeMarker
+	}
+	return strings.Repeat(" ", DisplayWidth(activeMarker))
+}
+
+// StateMarker is Marker, but marks the previous configuration with PreviousMarker,
+// padded to the width of activeMarker
+func StateMarker(activeMarker string, active, previous bool) string {
+	if !active && previous {
+		width := DisplayWidth(activeMarker)
+		return PreviousMarker + strings.Repeat(" ", max(width-1, 0))
+	}
+	return Marker(activeMarker, active)
+}
+
+// ValidateMarker checks that an active marker fits on a single line
+func ValidateMarker(marker string) error {
+	if strings.ContainsAny(marker, "\t\r\n") {
+		return fmt.Errorf("invalid active_marker: must not contain tabs or line breaks")
+	}
+	return nil
+}
+
+// templateErrorPattern matches text/template errors: "template: NAME:LINE[:COL]: REASON"
+var templateErrorPattern = regexp.MustCompile(`^template: [^:]*:(\d+)(?::(\d+))?: (.*)$`)
+
+// executingPattern matches the verbose prefix of execution errors
+var executingPattern = regexp.MustCompile(`^executing "[^"]*" `)
+
+// templateError converts a text/template error into an Error with its position
+func templateError(setting string, err error) error {
+	m := templateErrorPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return fmt.Errorf("invalid %s: %w", setting, err)
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	column, _ := strconv.Atoi(m[2])
+	if m[2] != "" {
+		// text/template counts columns from zero
+		column++
+	}
+	return &Error{
+		Setting: setting,
+		Line:    line,
+		Column:  column,
+		Reason:  executingPattern.ReplaceAllString(m[3], ""),
+	}
+}
@@ -0,0 +1,189 @@
+package linefmt
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/golden"
+	"github.com/fatih/color"
+)
+
+// goldenConfigurations are rendered by every template in TestRenderGolden
+var goldenConfigurations = []Fields{
+	{Name: "prod", Account: "ops@example.com", Project: "prod-project", Region: "us-central1", Zone: "us-central1-a", IsActive: true, Pinned: true},
+	{Name: "dev", Account: "dev@example.com", Project: "dev-project"},
+	{Name: "bare"},
+}
+
+func TestRenderGolden(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+
+	tests := []struct {
+		name   string
+		marker string
+		format string
+		color  bool
+	}{
+		{"default", DefaultActiveMarker, DefaultListFormat, false},
+		{"picker_default", DefaultActiveMarker, DefaultPickerFormat, false},
+		{"project_first", DefaultActiveMarker, `{{.Marker}} {{with .Project}}[{{.}}] {{end}}{{.Name}}{{with .Account}} ({{.}}){{end}}`, false},
+		{"arrow_marker", "▶", DefaultListFormat, false},
+		{"full_line", "", `{{if .IsActive}}{{reverse (printf "%-10s %s" .Name .Project)}}{{else}}{{printf "%-10s %s" .Name .Project}}{{end}}`, false},
+		{"full_line_color", "", `{{if .IsActive}}{{reverse (printf "%-10s %s" .Name .Project)}}{{else}}{{printf "%-10s %s" .Name .Project}}{{end}}`, true},
+		{"pinned_location", DefaultActiveMarker, `{{.Marker}} {{.Name}}{{if .Pinned}} 📌{{end}}{{with .Region}} {{.}}{{end}}{{with .Zone}}/{{.}}{{end}}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			color.NoColor = !tt.color
+
+			tmpl, err := Parse("list_format", tt.format)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			var b strings.Builder
+			for _, fields := range goldenConfigurations {
+				fields.Marker = Marker(tt.marker, fields.IsActive)
+				line, err := tmpl.Render(fields)
+				if err != nil {
+					t.Fatalf("Render() error = %v", err)
+				}
+				b.WriteString(line + "\n")
+			}
+
+			golden.Assert(t, filepath.Join("testdata", "render", tt.name+".golden"), b.String())
+		})
+	}
+}
+
+func TestParseErrorsGolden(t *testing.T) {
+	templates := []string{
+		`{{.Marker}} {{.Nmae}}`,
+		"{{.Marker}} {{.Name}}\n{{.Name}} {{.Bogus}}",
+		`{{.Marker}} {{.Name`,
+		`{{.Marker}} {{blue .Name}}`,
+		`{{if .IsActive}}{{.Name}}`,
+		`{{.Name}} {{end}}`,
+		`{{template "other"}}`,
+	}
+
+	var b strings.Builder
+	for _, text := range templates {
+		_, err := Parse("list_format", text)
+		if err == nil {
+			t.Fatalf("Parse(%q) succeeded, want error", text)
+		}
+		var templateErr *Error
+		if !errors.As(err, &templateErr) {
+			t.Errorf("Parse(%q) error %v is not an *Error", text, err)
+		}
+		b.WriteString(strings.ReplaceAll(text, "\n", `\n`) + "\n  " + err.Error() + "\n")
+	}
+
+	golden.Assert(t, filepath.Join("testdata", "errors.golden"), b.String())
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	_, err := Parse("picker_format", "{{.Name}}\n  {{.Missing}}")
+	var templateErr *Error
+	if !errors.As(err, &templateErr) {
+		t.Fatalf("Parse() error = %v, want *Error", err)
+	}
+	if templateErr.Setting != "picker_format" || templateErr.Line != 2 || templateErr.Column != 5 {
+		t.Errorf("error position = %s line %d column %d, want picker_format line 2 column 5",
+			templateErr.Setting, templateErr.Line, templateErr.Column)
+	}
+}
+
+func TestRenderDisplayGolden(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	configurations := []Fields{
+		{Name: "prod", Project: "prod-project", IsActive: true, Protected: true, Color: "red", Emoji: "🔥", Marker: DefaultActiveMarker},
+		{Name: "staging", Project: "staging-project", Color: "bright-yellow", Marker: " "},
+		{Name: "dev", Emoji: "🧪", Marker: " "},
+	}
+	for _, tt := range []struct{ name, format string }{
+		{"display_list", DefaultListFormat},
+		{"display_picker", DefaultPickerFormat},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := MustParse("list_format", tt.format)
+			var b strings.Builder
+			for _, fields := range configurations {
+				line, err := tmpl.Render(fields)
+				if err != nil {
+					t.Fatalf("Render() error = %v", err)
+				}
+				b.WriteString(line + "\n")
+			}
+			golden.Assert(t, filepath.Join("testdata", "render", tt.name+".golden"), b.String())
+		})
+	}
+}
+
+func TestValidateColor(t *testing.T) {
+	for _, name := range ColorNames() {
+		if err := ValidateColor(name); err != nil {
+			t.Errorf("ValidateColor(%q) error = %v", name, err)
+		}
+	}
+	err := ValidateColor("crimson")
+	if err == nil || !strings.Contains(err.Error(), "bright-red") {
+		t.Errorf("ValidateColor(crimson) error = %v, want the valid colors listed", err)
+	}
+}
+
+func TestPaint(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"red", "\x1b[31mprod\x1b[0m"},
+		{"red bold", "\x1b[31;1mprod\x1b[0;22m"},
+		{"bright-blue", "\x1b[94mprod\x1b[0m"},
+		{"", "prod"},
+		{"crimson", "prod"},
+	}
+	for _, tt := range tests {
+		if got := Paint(tt.spec, "prod"); got != tt.want {
+			t.Errorf("Paint(%q) = %q, want %q", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestMarker(t *testing.T) {
+	tests := []struct {
+		marker string
+		active bool
+		want   string
+	}{
+		{"*", true, "*"},
+		{"*", false, " "},
+		{"▶", false, " "},
+		{"=>", false, "  "},
+		{"👉", false, "  "},
+		{"", true, ""},
+	}
+	for _, tt := range tests {
+		if got := Marker(tt.marker, tt.active); got != tt.want {
+			t.Errorf("Marker(%q, %v) = %q, want %q", tt.marker, tt.active, got, tt.want)
+		}
+	}
+}
+
+func TestValidateMarker(t *testing.T) {
+	if err := ValidateMarker("→"); err != nil {
+		t.Errorf("ValidateMarker(→) error = %v", err)
+	}
+	if err := ValidateMarker("a\tb"); err == nil {
+		t.Error("ValidateMarker with a tab succeeded, want error")
+	}
+}
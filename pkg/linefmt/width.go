@@ -0,0 +1,37 @@
+package linefmt
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DisplayWidth returns how many terminal columns s takes: emoji and East Asian
+// wide characters take two, joiners, variation selectors and combining marks none
+// s must not contain escape sequences
+func DisplayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case r == '\u200d' || (r >= '\ufe00' && r <= '\ufe0f') || unicode.Is(unicode.Mn, r):
+		case isWideRune(r):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// isWideRune reports whether r takes two terminal columns
+func isWideRune(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115f) || (r >= 0x2e80 && r <= 0xa4cf) ||
+		(r >= 0xac00 && r <= 0xd7a3) || (r >= 0xf900 && r <= 0xfaff) ||
+		(r >= 0xfe30 && r <= 0xfe4f) || (r >= 0xff00 && r <= 0xff60) ||
+		(r >= 0xffe0 && r <= 0xffe6) || (r >= 0x1f300 && r <= 0x1faff) ||
+		(r >= 0x20000 && r <= 0x3fffd)
+}
+
+// PadRight pads s with spaces to take width terminal columns
+func PadRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-DisplayWidth(s), 0))
+}
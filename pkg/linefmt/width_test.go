@@ -0,0 +1,48 @@
+package linefmt
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"prod", 4},
+		{"", 0},
+		{"本番", 4},
+		{"개발 환경", 9},
+		{"ｆｕｌｌ", 8},
+		{"🚀", 2},
+		{"🚀 prod", 7},
+		{"👩‍💻", 4},
+		{"café", 4},
+		{"cafe\u0301", 4},
+	}
+	for _, tt := range tests {
+		if got := DisplayWidth(tt.s); got != tt.want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestPadRight(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"dev", 6, "dev   "},
+		{"本番", 6, "本番  "},
+		{"🚀x", 6, "🚀x   "},
+		{"production", 6, "production"},
+	}
+	for _, tt := range tests {
+		got := PadRight(tt.s, tt.width)
+		if got != tt.want {
+			t.Errorf("PadRight(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+		if width := DisplayWidth(got); width < tt.width {
+			t.Errorf("PadRight(%q, %d) is %d columns wide", tt.s, tt.width, width)
+		}
+	}
+}
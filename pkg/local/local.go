@@ -4,18 +4,41 @@
 package local
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
 )
 
 // ConfigFileName is the name of the local configuration file
 const ConfigFileName = ".gcloudctx"
 
-// FindLocalConfig searches for a .gcloudctx file starting from the current directory
-// and walking up to the root. Returns the configuration name and the directory where
-// it was found, or an error if not found.
+// MaxConfigFileSize is the largest .gcloudctx file that is read
+// A configuration name plus a newline is far smaller; anything bigger is not a gcloudctx file
+const MaxConfigFileSize = 4096
+
+var (
+	// ErrNoLocalConfig is returned when no .gcloudctx file exists up to the root
+	ErrNoLocalConfig = errors.New("no " + ConfigFileName + " file found")
+
+	// ErrInvalidConfigFile is returned when a .gcloudctx file exists but cannot be used
+	ErrInvalidConfigFile = errors.New("invalid " + ConfigFileName + " file")
+)
+
+// remediationHint tells the user how to replace an unusable .gcloudctx file
+const remediationHint = "remove it or run 'gcloudctx use <configuration>' in its directory"
+
+// FindLocalConfig searches for a .gcloudctx file naming a configuration, starting
+// from the current directory and walking up to the root. Returns the configuration
+// name and the directory where it was found, or an error if not found.
+// Files that only set a project are skipped (see EffectiveProject).
 func FindLocalConfig() (configName, dir string, err error) {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -26,42 +49,134 @@ func FindLocalConfig() (configName, dir string, err error) {
 }
 
 // findLocalConfigInPath searches for .gcloudctx file starting from the given path
+// The nearest file applies; files farther up the tree are shadowed (see ClassifyShadowing)
 func findLocalConfigInPath(startPath string) (configName, dir string, err error) {
-	dir = startPath
-
-	for {
-		configPath := filepath.Join(dir, ConfigFileName)
-		if _, err := os.Stat(configPath); err == nil {
-			// Found the file, read its contents
-			data, err := os.ReadFile(configPath)
-			if err != nil {
-				return "", "", fmt.Errorf("failed to read %s: %w", configPath, err)
-			}
+	effective, _ := ClassifyShadowing(collectMatches(startPath))
+	if effective.Dir == "" {
+		return "", "", ErrNoLocalConfig
+	}
+	if effective.Err != nil {
+		return "", "", effective.Err
+	}
+	return effective.Config, effective.Dir, nil
+}
 
-			name := strings.TrimSpace(string(data))
-			if name == "" {
-				return "", "", fmt.Errorf("%s is empty", configPath)
-			}
+// File is what a .gcloudctx file asks for; either field may be empty, not both
+//
+// The original format is a single configuration name. The keyed format sets
+// the configuration, the project, or both:
+//
+//	configuration: dev
+//	project: my-service
+type File struct {
+	// Config is the configuration to activate
+	Config string
+	// Project is the project to set on the active configuration
+	Project string
+}
+
+// Keys of the keyed .gcloudctx format
+const (
+	configurationKey = "configuration"
+	projectKey       = "project"
+)
+
+// projectIDPattern matches project IDs, including legacy domain-scoped ones (example.com:project)
+var projectIDPattern = regexp.MustCompile(`^([a-z0-9.-]+:)?[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+
+// readConfigFile reads and validates a .gcloudctx file
+// At most MaxConfigFileSize bytes are read, and the file's contents never appear
+// in errors, so a binary file committed by mistake cannot flood the terminal
+func readConfigFile(configPath string) (File, error) {
+	f, err := os.Open(configPath)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, MaxConfigFileSize+1))
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
 
-			return name, dir, nil
+	if len(data) > MaxConfigFileSize {
+		return File{}, fmt.Errorf("%w: %s is larger than %d bytes and does not look like a gcloudctx file (%s)", ErrInvalidConfigFile, configPath, MaxConfigFileSize, remediationHint)
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return File{}, fmt.Errorf("%w: %s contains binary data and does not look like a gcloudctx file (%s)", ErrInvalidConfigFile, configPath, remediationHint)
+	}
+
+	// A colon means the keyed format; a configuration named with one needs it too
+	if bytes.IndexByte(data, ':') >= 0 {
+		return parseKeyedFile(configPath, string(data))
+	}
+
+	name := strings.TrimSpace(string(data))
+	if name == "" {
+		return File{}, fmt.Errorf("%w: %s is empty (%s)", ErrInvalidConfigFile, configPath, remediationHint)
+	}
+
+	if err := gcloud.ValidateExistingConfigurationName(name); err != nil {
+		return File{}, fmt.Errorf("%w: %s does not contain a valid configuration name: %v (%s)", ErrInvalidConfigFile, configPath, err, remediationHint)
+	}
+
+	return File{Config: name}, nil
+}
+
+// parseKeyedFile parses the "key: value" format of a .gcloudctx file
+// Blank lines and lines starting with # are ignored
+func parseKeyedFile(configPath, text string) (File, error) {
+	invalid := func(format string, args ...any) (File, error) {
+		return File{}, fmt.Errorf("%w: %s %s (%s)", ErrInvalidConfigFile, configPath, fmt.Sprintf(format, args...), remediationHint)
+	}
+
+	var file File
+	seen := map[string]bool{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		key, value = strings.TrimSpace(key), strings.Trim(strings.TrimSpace(value), `"'`)
+		if !ok || (key != configurationKey && key != projectKey) {
+			return invalid("line %d: expected %q or %q", i+1, configurationKey+": NAME", projectKey+": PROJECT_ID")
 		}
+		if seen[key] {
+			return invalid("line %d: %s is set more than once", i+1, key)
+		}
+		seen[key] = true
 
-		// Move to parent directory
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			// Reached root
-			break
+		switch key {
+		case configurationKey:
+			if err := gcloud.ValidateExistingConfigurationName(value); err != nil {
+				return invalid("line %d: does not contain a valid configuration name: %v", i+1, err)
+			}
+			file.Config = value
+		case projectKey:
+			if !projectIDPattern.MatchString(value) {
+				return invalid("line %d: does not contain a valid project ID", i+1)
+			}
+			file.Project = value
 		}
-		dir = parent
 	}
 
-	return "", "", fmt.Errorf("no %s file found", ConfigFileName)
+	if file.Config == "" && file.Project == "" {
+		return invalid("sets neither a configuration nor a project")
+	}
+	return file, nil
 }
 
 // WriteLocalConfig writes a configuration name to a .gcloudctx file in the specified directory
+// A project set by the file it replaces is kept
 func WriteLocalConfig(dir, configName string) error {
 	configPath := filepath.Join(dir, ConfigFileName)
-	if err := os.WriteFile(configPath, []byte(configName+"\n"), 0o600); err != nil {
+	content := configName + "\n"
+	if existing, err := readConfigFile(configPath); err == nil && existing.Project != "" {
+		content = fmt.Sprintf("%s: %s\n%s: %s\n", configurationKey, configName, projectKey, existing.Project)
+	}
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
 		return fmt.Errorf("failed to write %s: %w", configPath, err)
 	}
 	return nil
@@ -1,8 +1,12 @@
 package local
 
 import (
+	"bytes"
+	"errors"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -78,8 +82,8 @@ func TestFindLocalConfigNotFound(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	_, _, err = findLocalConfigInPath(tmpDir)
-	if err == nil {
-		t.Error("expected error when no config file exists")
+	if !errors.Is(err, ErrNoLocalConfig) {
+		t.Errorf("findLocalConfigInPath() error = %v, want ErrNoLocalConfig", err)
 	}
 }
 
@@ -141,3 +145,170 @@ func TestFindLocalConfigEmptyFile(t *testing.T) {
 		t.Error("expected error for empty config file")
 	}
 }
+
+// writeRawConfig writes data as the .gcloudctx file of a new temporary directory
+func writeRawConfig(t *testing.T, data []byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ConfigFileName), data, 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return dir
+}
+
+func TestFindLocalConfigRejectsUnusableFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"NUL byte", []byte("prod\x00config\n")},
+		{"invalid UTF-8", []byte("prod\xff\xfe\n")},
+		{"oversized single line", bytes.Repeat([]byte("a"), MaxConfigFileSize+1)},
+		{"tarball header", append([]byte("ustar\x0000"), make([]byte, 512)...)},
+		{"invalid name", []byte("../../etc/passwd\n")},
+		{"multiple lines", []byte("prod\nstaging\n")},
+		{"shell injection", []byte("prod; rm -rf ~\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeRawConfig(t, tt.data)
+
+			name, _, err := findLocalConfigInPath(dir)
+			if !errors.Is(err, ErrInvalidConfigFile) {
+				t.Fatalf("findLocalConfigInPath() = %q, %v, want ErrInvalidConfigFile", name, err)
+			}
+			if !strings.Contains(err.Error(), filepath.Join(dir, ConfigFileName)) {
+				t.Errorf("error %q should name the file", err)
+			}
+			if !strings.Contains(err.Error(), "gcloudctx use") {
+				t.Errorf("error %q should include a remediation hint", err)
+			}
+		})
+	}
+}
+
+func TestFindLocalConfigAcceptsMaxSize(t *testing.T) {
+	// Trailing whitespace up to the cap is still fine
+	data := append([]byte("prod\n"), bytes.Repeat([]byte("\n"), MaxConfigFileSize-5)...)
+	dir := writeRawConfig(t, data)
+
+	name, _, err := findLocalConfigInPath(dir)
+	if err != nil || name != "prod" {
+		t.Errorf("findLocalConfigInPath() = %q, %v, want prod", name, err)
+	}
+}
+
+func TestFindLocalConfigRandomBinary(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		size := rng.Intn(3 * MaxConfigFileSize)
+		data := make([]byte, size)
+		rng.Read(data)
+		dir := writeRawConfig(t, data)
+
+		name, _, err := findLocalConfigInPath(dir)
+		if err == nil {
+			// Random bytes may rarely form a valid name; it must then be clean
+			if strings.ContainsAny(name, "\x00\n ") {
+				t.Errorf("blob %d: accepted unclean name %q", i, name)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidConfigFile) {
+			t.Errorf("blob %d: error = %v, want ErrInvalidConfigFile", i, err)
+		}
+		// The blob's bytes must never leak into the message
+		if len(err.Error()) > 1024 || !isPrintable(err.Error()) {
+			t.Errorf("blob %d: error message leaks file contents: %q", i, err)
+		}
+	}
+}
+
+func TestFindLocalConfigHugeFileBoundedRead(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFileName)
+
+	// A sparse 1 GiB file: reading it fully would be obvious in time and memory
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if err := f.Truncate(1 << 30); err != nil {
+		f.Close()
+		t.Skipf("sparse files not supported: %v", err)
+	}
+	f.Close()
+
+	_, _, err = findLocalConfigInPath(dir)
+	if !errors.Is(err, ErrInvalidConfigFile) {
+		t.Errorf("findLocalConfigInPath() error = %v, want ErrInvalidConfigFile", err)
+	}
+}
+
+// isPrintable reports whether s contains no control characters
+func isPrintable(s string) bool {
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f || r == 0xfffd {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReadConfigFileKeyed(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     File
+		wantErr  bool
+	}{
+		{name: "plain name", contents: "dev\n", want: File{Config: "dev"}},
+		{name: "configuration and project", contents: "configuration: dev\nproject: my-service\n", want: File{Config: "dev", Project: "my-service"}},
+		{name: "project only", contents: "# set by the team\nproject: \"my-service\"\n", want: File{Project: "my-service"}},
+		{name: "domain-scoped project", contents: "project: example.com:my-service\n", want: File{Project: "example.com:my-service"}},
+		{name: "unknown key", contents: "region: us-central1\n", wantErr: true},
+		{name: "set twice", contents: "project: my-service\nproject: other-service\n", wantErr: true},
+		{name: "invalid project", contents: "project: My Service\n", wantErr: true},
+		{name: "invalid configuration", contents: "configuration: -dev\n", wantErr: true},
+		{name: "only comments", contents: "# nothing: here\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ConfigFileName)
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readConfigFile(path)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidConfigFile) {
+					t.Errorf("readConfigFile() = %+v, %v; want ErrInvalidConfigFile", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("readConfigFile() = %+v, %v; want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteLocalConfigKeepsProject(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFileName)
+	if err := os.WriteFile(path, []byte("project: my-service\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteLocalConfig(dir, "dev"); err != nil {
+		t.Fatalf("WriteLocalConfig failed: %v", err)
+	}
+
+	got, err := readConfigFile(path)
+	if want := (File{Config: "dev", Project: "my-service"}); err != nil || got != want {
+		t.Errorf("after WriteLocalConfig the file reads %+v, %v; want %+v", got, err, want)
+	}
+}
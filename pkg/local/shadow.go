@@ -0,0 +1,161 @@
+package local
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxNestedSearchDirs bounds how many directories FindNestedConfigs visits,
+// so running 'gcloudctx use' at the top of a huge tree stays fast
+const MaxNestedSearchDirs = 10000
+
+// Match is a .gcloudctx file found while searching for local configurations
+type Match struct {
+	// Dir is the directory containing the file
+	Dir string
+	// Config is the configuration name, empty when the file only sets a project or Err is set
+	Config string
+	// Project is the project the file sets, if any
+	Project string
+	// Err is set when the file exists but cannot be used
+	Err error
+}
+
+// Path returns the path of the .gcloudctx file
+func (m Match) Path() string {
+	return filepath.Join(m.Dir, ConfigFileName)
+}
+
+// Shadowed is a .gcloudctx file that is ignored because a nearer one applies
+type Shadowed struct {
+	Match
+	// Disagrees is true when the file names a different configuration than the
+	// one in effect (or cannot be read), i.e. ignoring it changes the outcome
+	Disagrees bool
+}
+
+// FindLocalConfigs returns every .gcloudctx file from the current directory up to
+// the root, nearest first. The first match is the one in effect.
+func FindLocalConfigs() ([]Match, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return collectMatches(cwd), nil
+}
+
+// collectMatches walks from startPath up to the root and collects every .gcloudctx file, nearest first
+func collectMatches(startPath string) []Match {
+	var matches []Match
+	for dir := startPath; ; {
+		if match, ok := ReadMatch(dir); ok {
+			matches = append(matches, match)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return matches
+		}
+		dir = parent
+	}
+}
+
+// ReadMatch reads the .gcloudctx file in dir, if there is one
+func ReadMatch(dir string) (Match, bool) {
+	configPath := filepath.Join(dir, ConfigFileName)
+	if _, err := os.Stat(configPath); err != nil {
+		return Match{}, false
+	}
+	file, err := readConfigFile(configPath)
+	return Match{Dir: dir, Config: file.Config, Project: file.Project, Err: err}, true
+}
+
+// ClassifyShadowing splits matches (nearest first) into the one in effect for the
+// configuration and the ones it shadows. Files that only set a project take no
+// part; unreadable files do, since they might have named a configuration.
+// It returns a zero Match and no shadowed files when no file names a configuration.
+func ClassifyShadowing(matches []Match) (Match, []Shadowed) {
+	var effective Match
+	var shadowed []Shadowed
+	for _, match := range matches {
+		if match.Config == "" && match.Err == nil {
+			continue
+		}
+		if effective.Dir == "" {
+			effective = match
+			continue
+		}
+		shadowed = append(shadowed, Shadowed{
+			Match:     match,
+			Disagrees: match.Err != nil || effective.Err != nil || match.Config != effective.Config,
+		})
+	}
+	return effective, shadowed
+}
+
+// EffectiveProject returns the nearest of matches (nearest first) that sets a
+// project, which applies independently of the configuration; ok is false when
+// no file sets one
+func EffectiveProject(matches []Match) (match Match, ok bool) {
+	for _, match := range matches {
+		if match.Project != "" {
+			return match, true
+		}
+	}
+	return Match{}, false
+}
+
+// Disagreeing returns the shadowed files that name a different configuration
+func Disagreeing(shadowed []Shadowed) []Shadowed {
+	var result []Shadowed
+	for _, s := range shadowed {
+		if s.Disagrees {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// ErrNestedSearchTruncated is returned by FindNestedConfigs when it stopped at MaxNestedSearchDirs
+var ErrNestedSearchTruncated = errors.New("search for nested " + ConfigFileName + " files stopped early")
+
+// FindNestedConfigs returns the .gcloudctx files in directories below root,
+// which take precedence over a file in root itself. Hidden directories (such as
+// .git) are skipped, and at most maxDirs directories are visited; the matches found
+// so far are returned with ErrNestedSearchTruncated when the limit is reached.
+func FindNestedConfigs(root string, maxDirs int) ([]Match, error) {
+	var matches []Match
+	visited := 0
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories cannot hold files gcloudctx would read either
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+
+		visited++
+		if visited > maxDirs {
+			return ErrNestedSearchTruncated
+		}
+		if match, ok := ReadMatch(path); ok {
+			matches = append(matches, match)
+		}
+		return nil
+	})
+	return matches, err
+}
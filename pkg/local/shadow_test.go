@@ -0,0 +1,198 @@
+package local
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates dirs under root and writes a .gcloudctx file for every
+// entry of configs (relative directory -> file contents)
+func writeTree(t *testing.T, root string, dirs []string, configs map[string]string) {
+	t.Helper()
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	for dir, contents := range configs {
+		path := filepath.Join(root, dir, ConfigFileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+}
+
+func TestCollectMatchesNearestFirst(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, []string{"monorepo/vendor/inner/src"}, map[string]string{
+		"monorepo":              "outer\n",
+		"monorepo/vendor/inner": "inner\n",
+	})
+
+	matches := collectMatches(filepath.Join(root, "monorepo", "vendor", "inner", "src"))
+	if len(matches) != 2 {
+		t.Fatalf("collectMatches() returned %d matches, want 2: %+v", len(matches), matches)
+	}
+	if matches[0].Config != "inner" || matches[0].Dir != filepath.Join(root, "monorepo", "vendor", "inner") {
+		t.Errorf("nearest match = %+v, want inner", matches[0])
+	}
+	if matches[1].Config != "outer" || matches[1].Dir != filepath.Join(root, "monorepo") {
+		t.Errorf("farther match = %+v, want outer", matches[1])
+	}
+
+	// The nearest file is still the one in effect
+	name, dir, err := findLocalConfigInPath(filepath.Join(root, "monorepo", "vendor", "inner", "src"))
+	if err != nil || name != "inner" || dir != matches[0].Dir {
+		t.Errorf("findLocalConfigInPath() = %q, %q, %v; want inner from %s", name, dir, err, matches[0].Dir)
+	}
+}
+
+func TestCollectMatchesKeepsUnusableFarFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, []string{"a/b"}, map[string]string{
+		"a":   "\x00\x01binary",
+		"a/b": "inner\n",
+	})
+
+	matches := collectMatches(filepath.Join(root, "a", "b"))
+	if len(matches) != 2 {
+		t.Fatalf("collectMatches() returned %d matches, want 2", len(matches))
+	}
+	if !errors.Is(matches[1].Err, ErrInvalidConfigFile) {
+		t.Errorf("far match error = %v, want ErrInvalidConfigFile", matches[1].Err)
+	}
+
+	// A broken file farther up does not break the nearest one
+	if name, _, err := findLocalConfigInPath(filepath.Join(root, "a", "b")); err != nil || name != "inner" {
+		t.Errorf("findLocalConfigInPath() = %q, %v; want inner", name, err)
+	}
+}
+
+func TestClassifyShadowing(t *testing.T) {
+	invalid := errors.New("invalid")
+	tests := []struct {
+		name          string
+		matches       []Match
+		wantEffective string
+		wantDisagrees []bool
+	}{
+		{"none", nil, "", nil},
+		{"single", []Match{{Dir: "/a/b", Config: "x"}}, "x", []bool{}},
+		{"agree", []Match{{Dir: "/a/b", Config: "x"}, {Dir: "/a", Config: "x"}}, "x", []bool{false}},
+		{"disagree", []Match{{Dir: "/a/b", Config: "inner"}, {Dir: "/a", Config: "outer"}}, "inner", []bool{true}},
+		{"mixed", []Match{{Dir: "/a/b/c", Config: "x"}, {Dir: "/a/b", Config: "x"}, {Dir: "/a", Config: "y"}}, "x", []bool{false, true}},
+		{"unreadable shadowed", []Match{{Dir: "/a/b", Config: "x"}, {Dir: "/a", Err: invalid}}, "x", []bool{true}},
+		{"project only skipped", []Match{{Dir: "/a/b", Project: "my-service"}, {Dir: "/a", Config: "x"}}, "x", []bool{}},
+		{"project only alone", []Match{{Dir: "/a", Project: "my-service"}}, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			effective, shadowed := ClassifyShadowing(tt.matches)
+			if effective.Config != tt.wantEffective {
+				t.Errorf("effective = %q, want %q", effective.Config, tt.wantEffective)
+			}
+			if len(shadowed) != len(tt.wantDisagrees) {
+				t.Fatalf("got %d shadowed, want %d", len(shadowed), len(tt.wantDisagrees))
+			}
+			for i, s := range shadowed {
+				if s.Disagrees != tt.wantDisagrees[i] {
+					t.Errorf("shadowed[%d] (%s) disagrees = %v, want %v", i, s.Dir, s.Disagrees, tt.wantDisagrees[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEffectiveProject(t *testing.T) {
+	matches := []Match{
+		{Dir: "/a/b/c", Config: "x"},
+		{Dir: "/a/b", Project: "inner-service"},
+		{Dir: "/a", Config: "y", Project: "outer-service"},
+	}
+	if got, ok := EffectiveProject(matches); !ok || got.Dir != "/a/b" || got.Project != "inner-service" {
+		t.Errorf("EffectiveProject() = %+v, %v; want inner-service from /a/b", got, ok)
+	}
+	if got, ok := EffectiveProject(matches[:1]); ok {
+		t.Errorf("EffectiveProject() = %+v, want none", got)
+	}
+}
+
+func TestDisagreeing(t *testing.T) {
+	shadowed := []Shadowed{
+		{Match: Match{Dir: "/a", Config: "x"}},
+		{Match: Match{Dir: "/", Config: "y"}, Disagrees: true},
+	}
+	got := Disagreeing(shadowed)
+	if len(got) != 1 || got[0].Dir != "/" {
+		t.Errorf("Disagreeing() = %+v, want only /", got)
+	}
+}
+
+func TestFindNestedConfigs(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, []string{"plain", ".git/hooks"}, map[string]string{
+		"services/api":      "api\n",
+		"services/api/deep": "deep\n",
+		".git/hooks":        "hidden\n",
+		"":                  "root\n",
+	})
+
+	matches, err := FindNestedConfigs(root, MaxNestedSearchDirs)
+	if err != nil {
+		t.Fatalf("FindNestedConfigs() error = %v", err)
+	}
+
+	got := map[string]string{}
+	for _, match := range matches {
+		rel, _ := filepath.Rel(root, match.Dir)
+		got[rel] = match.Config
+	}
+	want := map[string]string{
+		filepath.Join("services", "api"):         "api",
+		filepath.Join("services", "api", "deep"): "deep",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("FindNestedConfigs() = %v, want %v", got, want)
+	}
+	for dir, config := range want {
+		if got[dir] != config {
+			t.Errorf("nested %s = %q, want %q", dir, got[dir], config)
+		}
+	}
+}
+
+func TestFindNestedConfigsBounded(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, []string{"a", "b", "c", "d"}, nil)
+
+	_, err := FindNestedConfigs(root, 2)
+	if !errors.Is(err, ErrNestedSearchTruncated) {
+		t.Errorf("FindNestedConfigs() error = %v, want ErrNestedSearchTruncated", err)
+	}
+}
+
+// Discovery runs on every cd with the shell hook, walking up from deep directories
+func BenchmarkCollectMatches(b *testing.B) {
+	root := b.TempDir()
+	deep := filepath.Join(root, "monorepo", "services", "api", "internal", "handlers", "v1")
+	if err := os.MkdirAll(deep, 0o755); err != nil {
+		b.Fatal(err)
+	}
+	for _, dir := range []string{"monorepo", filepath.Join("monorepo", "services", "api")} {
+		if err := os.WriteFile(filepath.Join(root, dir, ConfigFileName), []byte("dev\n"), 0o600); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for b.Loop() {
+		if matches := collectMatches(deep); len(matches) != 2 {
+			b.Fatalf("collectMatches() found %d files, want 2", len(matches))
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package manifest reconciles gcloud configurations with a team manifest.
+// A manifest is a YAML or JSON list of entries in the export format (see
+// package configfile), typically kept in git and describing every environment
+// of a team. Planning compares it with the local configurations and is free
+// of side effects, so 'apply' and 'diff-file' share it.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"gopkg.in/yaml.v3"
+)
+
+// Action is what reconciling does with a configuration
+type Action string
+
+// Reconcile actions
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionUnchanged Action = "unchanged"
+	// ActionExtra marks a local configuration the manifest does not list
+	ActionExtra Action = "extra"
+)
+
+// Change is a property whose local value differs from the manifest
+type Change struct {
+	// Name is the manifest field, such as "project"
+	Name     string `json:"name" yaml:"name"`
+	Property string `json:"property" yaml:"property"`
+	From     string `json:"from" yaml:"from"`
+	To       string `json:"to" yaml:"to"`
+}
+
+// Item is the plan for one configuration
+type Item struct {
+	Name    string   `json:"name" yaml:"name"`
+	Action  Action   `json:"action" yaml:"action"`
+	Changes []Change `json:"changes,omitempty" yaml:"changes,omitempty"`
+
+	// Entry is the manifest entry; nil for extra configurations
+	Entry *configfile.Resolved `json:"-" yaml:"-"`
+}
+
+// Settings returns the properties to write for the item: all managed ones for
+// a new configuration, only the changed ones for an update
+func (item *Item) Settings() []gcloud.PropertySetting {
+	var settings []gcloud.PropertySetting
+	switch item.Action {
+	case ActionCreate:
+		for _, p := range managedProperties {
+			if value := p.value(item.Entry); value != "" {
+				settings = append(settings, gcloud.PropertySetting{Property: p.property, Value: value})
+			}
+		}
+	case ActionUpdate:
+		for _, change := range item.Changes {
+			settings = append(settings, gcloud.PropertySetting{Property: change.Property, Value: change.To})
+		}
+	}
+	return settings
+}
+
+// managedProperty maps a manifest field to the gcloud property it sets
+type managedProperty struct {
+	name     string
+	property string
+	value    func(*configfile.Resolved) string
+}
+
+// managedProperties are the properties a manifest controls; a field left
+// empty in the manifest leaves the local value alone
+var managedProperties = []managedProperty{
+	{"account", "core/account", func(r *configfile.Resolved) string { return r.Account }},
+	{"project", "core/project", func(r *configfile.Resolved) string { return r.Project }},
+	{"region", "compute/region", func(r *configfile.Resolved) string { return r.Region }},
+	{"zone", "compute/zone", func(r *configfile.Resolved) string { return r.Zone }},
+}
+
+// Load reads a manifest file and resolves its valueFrom references with lookup
+// (typically os.LookupEnv)
+func Load(path string, lookup func(string) (string, bool)) ([]configfile.Resolved, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var entries []configfile.Config
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &entries)
+	} else {
+		err = yaml.Unmarshal(data, &entries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest (expected a list of configurations): %w", err)
+	}
+
+	resolved := make([]configfile.Resolved, 0, len(entries))
+	seen := map[string]bool{}
+	for i := range entries {
+		entry, err := configfile.Resolve(&entries[i], lookup)
+		if err != nil {
+			return nil, fmt.Errorf("manifest entry %d: %w", i+1, err)
+		}
+		// Entries may list existing configurations gcloud created under names
+		// that cannot be created anew; applying refuses to create those
+		if err := gcloud.ValidateExistingConfigurationName(entry.Name); err != nil {
+			return nil, fmt.Errorf("manifest entry %d: %w", i+1, err)
+		}
+		if seen[entry.Name] {
+			return nil, fmt.Errorf("configuration %q is listed more than once", entry.Name)
+		}
+		seen[entry.Name] = true
+		resolved = append(resolved, *entry)
+	}
+	return resolved, nil
+}
+
+// Plan compares the manifest entries with the local configurations
+// Items follow the manifest order, followed by extra local configurations sorted
+// by name; the reserved configuration is never reported as extra
+func Plan(entries []configfile.Resolved, configs []gcloud.Configuration) []Item {
+	local := map[string]*gcloud.Configuration{}
+	for i := range configs {
+		local[configs[i].Name] = &configs[i]
+	}
+
+	var items []Item
+	listed := map[string]bool{}
+	for i := range entries {
+		entry := &entries[i]
+		listed[entry.Name] = true
+
+		config, exists := local[entry.Name]
+		if !exists {
+			items = append(items, Item{Name: entry.Name, Action: ActionCreate, Entry: entry})
+			continue
+		}
+
+		item := Item{Name: entry.Name, Action: ActionUnchanged, Entry: entry}
+		current := gcloud.FlattenProperties(config)
+		for _, p := range managedProperties {
+			want := p.value(entry)
+			if want != "" && current[p.property] != want {
+				item.Changes = append(item.Changes, Change{Name: p.name, Property: p.property, From: current[p.property], To: want})
+			}
+		}
+		if len(item.Changes) > 0 {
+			item.Action = ActionUpdate
+		}
+		items = append(items, item)
+	}
+
+	var extra []Item
+	for _, config := range configs {
+		if !listed[config.Name] && !gcloud.IsReservedName(config.Name) {
+			extra = append(extra, Item{Name: config.Name, Action: ActionExtra})
+		}
+	}
+	slices.SortFunc(extra, func(a, b Item) int { return strings.Compare(a.Name, b.Name) })
+
+	return append(items, extra...)
+}
+
+// FormatChanges renders changes as "project: a → b, region: c → d"
+// A value that is not set locally is shown as "(unset)"
+func FormatChanges(changes []Change) string {
+	parts := make([]string, len(changes))
+	for i, change := range changes {
+		from := change.From
+		if from == "" {
+			from = "(unset)"
+		}
+		parts[i] = fmt.Sprintf("%s: %s → %s", change.Name, from, change.To)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DriftKind is how a configuration differs from the manifest
+type DriftKind string
+
+// Drift kinds
+const (
+	DriftChanged DriftKind = "changed"
+	DriftMissing DriftKind = "missing"
+	// DriftExtra marks a local configuration the manifest does not list;
+	// it is reported but does not count as drift
+	DriftExtra DriftKind = "extra"
+)
+
+// Drift is a configuration that does not match the manifest
+type Drift struct {
+	Name       string          `json:"name" yaml:"name"`
+	Kind       DriftKind       `json:"kind" yaml:"kind"`
+	Properties []PropertyDrift `json:"properties,omitempty" yaml:"properties,omitempty"`
+}
+
+// PropertyDrift is a property whose local value differs from the manifest
+type PropertyDrift struct {
+	Property string `json:"property" yaml:"property"`
+	Expected string `json:"expected" yaml:"expected"`
+	Actual   string `json:"actual" yaml:"actual"`
+}
+
+// Drifts returns the drift records of a plan, in plan order
+func Drifts(items []Item) []Drift {
+	var drifts []Drift
+	for _, item := range items {
+		switch item.Action {
+		case ActionCreate:
+			drifts = append(drifts, Drift{Name: item.Name, Kind: DriftMissing})
+		case ActionExtra:
+			drifts = append(drifts, Drift{Name: item.Name, Kind: DriftExtra})
+		case ActionUpdate:
+			drift := Drift{Name: item.Name, Kind: DriftChanged}
+			for _, change := range item.Changes {
+				drift.Properties = append(drift.Properties, PropertyDrift{Property: change.Property, Expected: change.To, Actual: change.From})
+			}
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts
+}
+
+// HasDrift reports whether any record other than an extra configuration is present
+func HasDrift(drifts []Drift) bool {
+	return slices.ContainsFunc(drifts, func(d Drift) bool { return d.Kind != DriftExtra })
+}
@@ -0,0 +1,174 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+)
+
+func localConfig(name, account, project, region string) gcloud.Configuration {
+	return gcloud.Configuration{Name: name, Properties: gcloud.Properties{
+		Core:    gcloud.CoreProperties{Account: account, Project: project},
+		Compute: gcloud.ComputeProperties{Region: region},
+	}}
+}
+
+func TestPlan(t *testing.T) {
+	entries := []configfile.Resolved{
+		{Name: "prod", Account: "a@example.com", Project: "prod-b", Region: "europe-west1"},
+		{Name: "staging", Project: "staging"},
+		{Name: "dev", Project: "dev", Zone: "europe-west1-b"},
+	}
+	configs := []gcloud.Configuration{
+		localConfig("default", "a@example.com", "p", ""),
+		localConfig("zeta", "", "z", ""),
+		localConfig("prod", "a@example.com", "prod-a", ""),
+		localConfig("staging", "someone@example.com", "staging", "us-east1"),
+		localConfig("alpha", "", "a", ""),
+	}
+
+	items := Plan(entries, configs)
+
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Name+" "+string(item.Action)+" "+FormatChanges(item.Changes))
+	}
+	want := []string{
+		"prod update project: prod-a → prod-b, region: (unset) → europe-west1",
+		"staging unchanged ",
+		"dev create ",
+		"alpha extra ",
+		"zeta extra ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestItemSettings(t *testing.T) {
+	entry := &configfile.Resolved{Name: "dev", Project: "dev", Zone: "europe-west1-b"}
+
+	create := Item{Name: "dev", Action: ActionCreate, Entry: entry}
+	want := []gcloud.PropertySetting{
+		{Property: "core/project", Value: "dev"},
+		{Property: "compute/zone", Value: "europe-west1-b"},
+	}
+	if got := create.Settings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Settings(create) = %v, want %v", got, want)
+	}
+
+	update := Item{Name: "dev", Action: ActionUpdate, Entry: entry, Changes: []Change{
+		{Name: "zone", Property: "compute/zone", From: "us-east1-b", To: "europe-west1-b"},
+	}}
+	want = []gcloud.PropertySetting{{Property: "compute/zone", Value: "europe-west1-b"}}
+	if got := update.Settings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Settings(update) = %v, want %v", got, want)
+	}
+
+	if got := (&Item{Name: "x", Action: ActionExtra}).Settings(); got != nil {
+		t.Errorf("Settings(extra) = %v, want none", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	lookup := func(key string) (string, bool) {
+		if key == "TEAM_ACCOUNT" {
+			return "me@example.com", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []configfile.Resolved
+		wantErr string
+	}{
+		{
+			name: "yaml",
+			file: "team.yaml",
+			content: `- name: prod
+  project: prod
+  account:
+    valueFrom: {env: TEAM_ACCOUNT}
+- name: staging
+  region: europe-west1
+`,
+			want: []configfile.Resolved{
+				{Name: "prod", Project: "prod", Account: "me@example.com"},
+				{Name: "staging", Region: "europe-west1"},
+			},
+		},
+		{
+			name:    "json",
+			file:    "team.json",
+			content: `[{"name": "prod", "project": "prod"}]`,
+			want:    []configfile.Resolved{{Name: "prod", Project: "prod"}},
+		},
+		{name: "not a list", file: "a.yaml", content: "name: prod\n", wantErr: "expected a list"},
+		{name: "duplicate", file: "b.yaml", content: "- name: prod\n- name: prod\n", wantErr: "more than once"},
+		{name: "invalid name", file: "c.yaml", content: "- name: ../prod\n", wantErr: "entry 1"},
+		{name: "unresolved env", file: "d.yaml", content: "- name: prod\n  project: {valueFrom: {env: NOPE}}\n", wantErr: "NOPE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Load(path, lookup)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDrifts(t *testing.T) {
+	items := []Item{
+		{Name: "prod", Action: ActionUpdate, Changes: []Change{
+			{Name: "project", Property: "core/project", From: "hand-edited", To: "team-prod"},
+		}},
+		{Name: "staging", Action: ActionUnchanged},
+		{Name: "dev", Action: ActionCreate},
+		{Name: "sandbox", Action: ActionExtra},
+	}
+
+	drifts := Drifts(items)
+	want := []Drift{
+		{Name: "prod", Kind: DriftChanged, Properties: []PropertyDrift{
+			{Property: "core/project", Expected: "team-prod", Actual: "hand-edited"},
+		}},
+		{Name: "dev", Kind: DriftMissing},
+		{Name: "sandbox", Kind: DriftExtra},
+	}
+	if !reflect.DeepEqual(drifts, want) {
+		t.Errorf("Drifts() = %+v, want %+v", drifts, want)
+	}
+	if !HasDrift(drifts) {
+		t.Error("HasDrift() = false, want true")
+	}
+
+	// Extra configurations alone are not drift
+	if HasDrift(Drifts(items[1:2])) || HasDrift([]Drift{{Name: "sandbox", Kind: DriftExtra}}) {
+		t.Error("HasDrift() = true without changed or missing configurations")
+	}
+}
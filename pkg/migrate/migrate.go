@@ -0,0 +1,427 @@
+// Package migrate finds raw gcloud configuration-management invocations in
+// shell scripts and rc files and translates them into equivalent gcloudctx commands.
+// Translation is deliberately conservative: an invocation is only marked as
+// rewritable when it maps onto a gcloudctx command with identical behavior.
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/shellrc"
+)
+
+// Finding describes a raw gcloud invocation found in a script
+type Finding struct {
+	Path        string `json:"path"`
+	Line        int    `json:"line"`
+	Original    string `json:"original"`
+	Replacement string `json:"replacement,omitempty"`
+	Note        string `json:"note,omitempty"`
+	Rewritable  bool   `json:"rewritable"`
+
+	// start and end are byte offsets of Original within the physical line
+	start, end int
+}
+
+// token is a single shell word of a gcloud invocation
+type token struct {
+	raw   string // text as written, including quotes
+	value string // text with quotes removed
+}
+
+// translator converts the arguments following a matched subcommand into a
+// gcloudctx command. It returns false when no certain translation exists.
+type translator func(args []token) (string, bool)
+
+// rule maps a gcloud subcommand onto its gcloudctx equivalent
+type rule struct {
+	subcommand []string
+	translate  translator
+	note       string
+}
+
+// rules is the pattern table used by TranslateCommand
+var rules = []rule{
+	{
+		subcommand: []string{"config", "configurations", "activate"},
+		translate:  translateActivate,
+		note:       "only a single configuration name without flags can be translated",
+	},
+	{
+		subcommand: []string{"config", "configurations", "list"},
+		translate:  translateList,
+		note:       "list flags have no gcloudctx equivalent; use 'gcloudctx -l -o json' for scripting",
+	},
+	{
+		subcommand: []string{"config", "configurations", "create"},
+		translate:  translateCreate,
+		note:       "only a single configuration name and --activate/--no-activate can be translated",
+	},
+	{
+		subcommand: []string{"config", "configurations", "delete"},
+		translate:  translateDelete,
+		note:       "only a single configuration name and --quiet can be translated",
+	},
+	{
+		subcommand: []string{"config", "configurations", "rename"},
+		translate:  translateRename,
+		note:       "only 'rename OLD --new-name=NEW' can be translated",
+	},
+	{
+		subcommand: []string{"config", "configurations", "describe"},
+		note:       "no direct equivalent; 'gcloudctx --info' describes the active configuration",
+	},
+	{
+		subcommand: []string{"config", "set", "project"},
+		note:       "no direct equivalent; consider a dedicated configuration per project",
+	},
+	{
+		subcommand: []string{"config", "set", "account"},
+		note:       "no direct equivalent; consider a dedicated configuration per account",
+	},
+}
+
+// invocationRegex locates the start of a gcloud config invocation
+var invocationRegex = regexp.MustCompile("(^|[\\s;&|(`'\"])gcloud\\s+config\\s")
+
+// TranslateCommand translates the words of a single gcloud invocation (excluding
+// the leading "gcloud") into a gcloudctx command. It returns the replacement,
+// a note explaining why no certain translation exists, and whether the
+// invocation is a configuration-management command at all.
+func TranslateCommand(words []string) (replacement, note string, matched bool) {
+	tokens := make([]token, len(words))
+	for i, w := range words {
+		tokens[i] = token{raw: w, value: w}
+	}
+	return translateTokens(tokens)
+}
+
+func translateTokens(tokens []token) (replacement, note string, matched bool) {
+	for _, r := range rules {
+		if !hasSubcommand(tokens, r.subcommand) {
+			continue
+		}
+		args := tokens[len(r.subcommand):]
+		if r.translate != nil {
+			if replacement, ok := r.translate(args); ok {
+				return replacement, "", true
+			}
+		}
+		return "", r.note, true
+	}
+
+	if hasSubcommand(tokens, []string{"config", "configurations"}) {
+		return "", "unrecognized configurations subcommand", true
+	}
+
+	return "", "", false
+}
+
+func hasSubcommand(tokens []token, subcommand []string) bool {
+	if len(tokens) < len(subcommand) {
+		return false
+	}
+	for i, word := range subcommand {
+		if tokens[i].value != word {
+			return false
+		}
+	}
+	return true
+}
+
+// splitArgs separates positional arguments from flags
+func splitArgs(args []token) (positional, flags []token) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg.value, "-") {
+			flags = append(flags, arg)
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	return positional, flags
+}
+
+func translateActivate(args []token) (string, bool) {
+	positional, flags := splitArgs(args)
+	if len(positional) != 1 || len(flags) != 0 {
+		return "", false
+	}
+	return "gcloudctx " + positional[0].raw, true
+}
+
+func translateList(args []token) (string, bool) {
+	if len(args) != 0 {
+		return "", false
+	}
+	return "gcloudctx -l", true
+}
+
+func translateCreate(args []token) (string, bool) {
+	positional, flags := splitArgs(args)
+	if len(positional) != 1 {
+		return "", false
+	}
+	// gcloud activates a new configuration unless told not to; gcloudctx
+	// create only does with --activate
+	activate := true
+	for _, flag := range flags {
+		switch flag.value {
+		case "--activate":
+			activate = true
+		case "--no-activate":
+			activate = false
+		default:
+			return "", false
+		}
+	}
+	result := "gcloudctx create " + positional[0].raw
+	if activate {
+		result += " --activate"
+	}
+	return result, true
+}
+
+func translateDelete(args []token) (string, bool) {
+	positional, flags := splitArgs(args)
+	if len(positional) != 1 {
+		return "", false
+	}
+	result := "gcloudctx delete " + positional[0].raw
+	for _, flag := range flags {
+		switch flag.value {
+		case "--quiet", "-q":
+			result += " --force"
+		default:
+			return "", false
+		}
+	}
+	return result, true
+}
+
+func translateRename(args []token) (string, bool) {
+	var oldName, newName string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg.value, "--new-name="):
+			newName = strings.TrimPrefix(arg.raw, "--new-name=")
+		case arg.value == "--new-name" && i+1 < len(args):
+			newName = args[i+1].raw
+			i++
+		case strings.HasPrefix(arg.value, "-"), oldName != "":
+			return "", false
+		default:
+			oldName = arg.raw
+		}
+	}
+	if oldName == "" || newName == "" {
+		return "", false
+	}
+	return fmt.Sprintf("gcloudctx rename %s %s", oldName, newName), true
+}
+
+// Scan searches script content for raw gcloud configuration-management invocations.
+// Lines continued with a trailing backslash are joined before matching; findings
+// spanning several physical lines are reported but never marked rewritable.
+func Scan(path, content string) []Finding {
+	var findings []Finding
+	lines := strings.Split(content, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		first := i
+		logical := lines[i]
+		for strings.HasSuffix(logical, "\\") && i+1 < len(lines) {
+			i++
+			logical = strings.TrimSuffix(logical, "\\") + " " + strings.TrimLeft(lines[i], " \t")
+		}
+		multiline := i != first
+
+		if strings.HasPrefix(strings.TrimSpace(logical), "#") {
+			continue
+		}
+
+		for _, f := range scanLine(logical) {
+			f.Path = path
+			f.Line = first + 1
+			if multiline {
+				f.Rewritable = false
+				if f.Replacement != "" {
+					f.Note = "spans multiple lines; rewrite manually"
+				}
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	return findings
+}
+
+// scanLine finds all invocations on a single logical line
+func scanLine(line string) []Finding {
+	var findings []Finding
+
+	offset := 0
+	for offset < len(line) {
+		loc := invocationRegex.FindStringSubmatchIndex(line[offset:])
+		if loc == nil {
+			break
+		}
+		start := offset + loc[3] // end of the delimiter group is the start of "gcloud"
+		var enclosing byte
+		if loc[3] > loc[2] {
+			enclosing = line[offset+loc[2]]
+		}
+
+		tokens, end, ok := tokenize(line, start+len("gcloud"), enclosing)
+		offset = max(end, start+len("gcloud"))
+
+		replacement, note, matched := translateTokens(tokens)
+		if !matched {
+			continue
+		}
+		if !ok {
+			replacement = ""
+			note = "could not parse quoting; rewrite manually"
+		}
+
+		findings = append(findings, Finding{
+			Original:    line[start:end],
+			Replacement: replacement,
+			Note:        note,
+			Rewritable:  replacement != "",
+			start:       start,
+			end:         end,
+		})
+	}
+
+	return findings
+}
+
+// tokenize splits shell words starting at pos until a command terminator.
+// enclosing is the quote character the invocation is nested in (e.g. an alias
+// body), or zero. It returns the tokens, the end offset and whether quoting was
+// well formed.
+func tokenize(line string, pos int, enclosing byte) (tokens []token, end int, ok bool) {
+	i := pos
+	for {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		if i >= len(line) || isTerminator(line[i]) || (enclosing != 0 && line[i] == enclosing) {
+			return tokens, trimEnd(line, pos, i), true
+		}
+
+		var raw, value strings.Builder
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' && !isTerminator(line[i]) {
+			c := line[i]
+			if c == enclosing {
+				break
+			}
+			if c == '\'' || c == '"' {
+				closing := strings.IndexByte(line[i+1:], c)
+				if closing < 0 {
+					return tokens, len(line), false
+				}
+				raw.WriteString(line[i : i+closing+2])
+				value.WriteString(line[i+1 : i+1+closing])
+				i += closing + 2
+				continue
+			}
+			raw.WriteByte(c)
+			value.WriteByte(c)
+			i++
+		}
+		tokens = append(tokens, token{raw: raw.String(), value: value.String()})
+	}
+}
+
+func isTerminator(c byte) bool {
+	switch c {
+	case ';', '&', '|', ')', '`', '#':
+		return true
+	}
+	return false
+}
+
+// trimEnd returns the end offset with trailing whitespace removed
+func trimEnd(line string, start, end int) int {
+	for end > start && (line[end-1] == ' ' || line[end-1] == '\t') {
+		end--
+	}
+	return end
+}
+
+// Rewrite applies all rewritable findings to content and returns the result.
+// Findings must come from Scan on the same content.
+func Rewrite(content string, findings []Finding) string {
+	lines := strings.Split(content, "\n")
+
+	// Apply from the end of each line so earlier offsets stay valid
+	for i := len(findings) - 1; i >= 0; i-- {
+		f := findings[i]
+		if !f.Rewritable || f.Line < 1 || f.Line > len(lines) {
+			continue
+		}
+		line := lines[f.Line-1]
+		if f.end > len(line) || line[f.start:f.end] != f.Original {
+			continue
+		}
+		lines[f.Line-1] = line[:f.start] + f.Replacement + line[f.end:]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ScanFile reads a file and scans it for raw gcloud invocations
+func ScanFile(path string) ([]Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return Scan(path, string(data)), nil
+}
+
+// RewriteFile rewrites a file in place after saving a timestamped backup copy
+// next to it with shellrc.WriteBackup, so earlier backups are never overwritten.
+// It returns the number of rewritten invocations and the backup path.
+func RewriteFile(path string) (rewritten int, backupPath string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	content := string(data)
+	findings := Scan(path, content)
+	for _, f := range findings {
+		if f.Rewritable {
+			rewritten++
+		}
+	}
+	if rewritten == 0 {
+		return 0, "", nil
+	}
+
+	backupPath, err = shellrc.WriteBackup(path, data, info.Mode().Perm())
+	if err != nil {
+		return 0, "", err
+	}
+
+	if err := os.WriteFile(path, []byte(Rewrite(content, findings)), info.Mode().Perm()); err != nil {
+		return 0, backupPath, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return rewritten, backupPath, nil
+}
+
+// DefaultRCFiles returns the shell rc files under home that exist
+func DefaultRCFiles(home string) []string {
+	return shellrc.DefaultRCFiles(home)
+}
@@ -0,0 +1,305 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTranslateCommand(t *testing.T) {
+	tests := []struct {
+		name            string
+		words           []string
+		wantReplacement string
+		wantMatched     bool
+	}{
+		{
+			name:            "activate",
+			words:           []string{"config", "configurations", "activate", "prod"},
+			wantReplacement: "gcloudctx prod",
+			wantMatched:     true,
+		},
+		{
+			name:        "activate with extra flag",
+			words:       []string{"config", "configurations", "activate", "prod", "--verbosity=debug"},
+			wantMatched: true,
+		},
+		{
+			name:        "activate multiple names",
+			words:       []string{"config", "configurations", "activate", "a", "b"},
+			wantMatched: true,
+		},
+		{
+			name:            "list",
+			words:           []string{"config", "configurations", "list"},
+			wantReplacement: "gcloudctx -l",
+			wantMatched:     true,
+		},
+		{
+			name:            "create activates by default",
+			words:           []string{"config", "configurations", "create", "sandbox"},
+			wantReplacement: "gcloudctx create sandbox --activate",
+			wantMatched:     true,
+		},
+		{
+			name:            "create with activate",
+			words:           []string{"config", "configurations", "create", "sandbox", "--activate"},
+			wantReplacement: "gcloudctx create sandbox --activate",
+			wantMatched:     true,
+		},
+		{
+			name:            "create with no-activate",
+			words:           []string{"config", "configurations", "create", "sandbox", "--no-activate"},
+			wantReplacement: "gcloudctx create sandbox",
+			wantMatched:     true,
+		},
+		{
+			name:            "delete with short quiet flag",
+			words:           []string{"config", "configurations", "delete", "old", "-q"},
+			wantReplacement: "gcloudctx delete old --force",
+			wantMatched:     true,
+		},
+		{
+			name:        "delete multiple names",
+			words:       []string{"config", "configurations", "delete", "a", "b"},
+			wantMatched: true,
+		},
+		{
+			name:            "rename with separate flag value",
+			words:           []string{"config", "configurations", "rename", "old", "--new-name", "new"},
+			wantReplacement: "gcloudctx rename old new",
+			wantMatched:     true,
+		},
+		{
+			name:        "rename without new name",
+			words:       []string{"config", "configurations", "rename", "old"},
+			wantMatched: true,
+		},
+		{
+			name:        "set project has no equivalent",
+			words:       []string{"config", "set", "project", "my-project"},
+			wantMatched: true,
+		},
+		{
+			name:        "unknown configurations subcommand",
+			words:       []string{"config", "configurations", "frobnicate"},
+			wantMatched: true,
+		},
+		{
+			name:        "unrelated gcloud command",
+			words:       []string{"config", "get-value", "project"},
+			wantMatched: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replacement, note, matched := TranslateCommand(tt.words)
+			if matched != tt.wantMatched {
+				t.Fatalf("TranslateCommand(%v) matched = %v, want %v", tt.words, matched, tt.wantMatched)
+			}
+			if replacement != tt.wantReplacement {
+				t.Errorf("TranslateCommand(%v) = %q, want %q", tt.words, replacement, tt.wantReplacement)
+			}
+			if matched && replacement == "" && note == "" {
+				t.Errorf("TranslateCommand(%v) returned no note for an untranslatable command", tt.words)
+			}
+		})
+	}
+}
+
+func TestScanLineQuoting(t *testing.T) {
+	tests := []struct {
+		name           string
+		line           string
+		wantOriginal   string
+		wantRewritable bool
+	}{
+		{
+			name:           "single-quoted alias body",
+			line:           "alias g='gcloud config configurations activate prod'",
+			wantOriginal:   "gcloud config configurations activate prod",
+			wantRewritable: true,
+		},
+		{
+			name:           "quoted argument",
+			line:           `gcloud config configurations activate 'my config'`,
+			wantOriginal:   `gcloud config configurations activate 'my config'`,
+			wantRewritable: true,
+		},
+		{
+			name:           "command substitution",
+			line:           "configs=$(gcloud config configurations list)",
+			wantOriginal:   "gcloud config configurations list",
+			wantRewritable: true,
+		},
+		{
+			name:           "unterminated quote",
+			line:           `gcloud config configurations activate "prod`,
+			wantOriginal:   `gcloud config configurations activate "prod`,
+			wantRewritable: false,
+		},
+		{
+			name:           "trailing comment",
+			line:           "gcloud config configurations activate prod # switch",
+			wantOriginal:   "gcloud config configurations activate prod",
+			wantRewritable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := scanLine(tt.line)
+			if len(findings) != 1 {
+				t.Fatalf("scanLine(%q) returned %d findings, want 1", tt.line, len(findings))
+			}
+			if findings[0].Original != tt.wantOriginal {
+				t.Errorf("Original = %q, want %q", findings[0].Original, tt.wantOriginal)
+			}
+			if findings[0].Rewritable != tt.wantRewritable {
+				t.Errorf("Rewritable = %v, want %v", findings[0].Rewritable, tt.wantRewritable)
+			}
+		})
+	}
+}
+
+func TestScanFixture(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "aliases.sh"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	findings := Scan("aliases.sh", string(data))
+
+	wantLines := []int{3, 4, 5, 7, 8, 9, 10, 11, 12, 13, 13, 14, 16, 17}
+	if len(findings) != len(wantLines) {
+		for _, f := range findings {
+			t.Logf("line %d: %q", f.Line, f.Original)
+		}
+		t.Fatalf("Scan() returned %d findings, want %d", len(findings), len(wantLines))
+	}
+	for i, f := range findings {
+		if f.Line != wantLines[i] {
+			t.Errorf("finding %d: line = %d, want %d", i, f.Line, wantLines[i])
+		}
+		if f.Path != "aliases.sh" {
+			t.Errorf("finding %d: path = %q, want %q", i, f.Path, "aliases.sh")
+		}
+	}
+
+	// The continued invocation must be reported but never rewritten
+	multiline := findings[11]
+	if multiline.Rewritable {
+		t.Errorf("multiline finding should not be rewritable: %+v", multiline)
+	}
+}
+
+func TestRewriteFixture(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "aliases.sh"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "aliases.sh.golden"))
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+
+	content := string(data)
+	got := Rewrite(content, Scan("aliases.sh", content))
+	if got != string(want) {
+		t.Errorf("Rewrite() mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRewriteFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "script.sh")
+	original := "gcloud config configurations activate prod\ngcloud config set project x\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	rewritten, backupPath, err := RewriteFile(path)
+	if err != nil {
+		t.Fatalf("RewriteFile failed: %v", err)
+	}
+	if rewritten != 1 {
+		t.Errorf("rewritten = %d, want 1", rewritten)
+	}
+
+	backup, err := os.ReadFile(backupPath)
+	if err != nil {
+		t.Fatalf("failed to read backup: %v", err)
+	}
+	if string(backup) != original {
+		t.Errorf("backup contents = %q, want %q", backup, original)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read rewritten script: %v", err)
+	}
+	want := "gcloudctx prod\ngcloud config set project x\n"
+	if string(data) != want {
+		t.Errorf("rewritten contents = %q, want %q", data, want)
+	}
+}
+
+func TestRewriteFileNothingToRewrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "script.sh")
+	if err := os.WriteFile(path, []byte("echo hello\n"), 0o600); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	rewritten, backupPath, err := RewriteFile(path)
+	if err != nil {
+		t.Fatalf("RewriteFile failed: %v", err)
+	}
+	if rewritten != 0 || backupPath != "" {
+		t.Errorf("RewriteFile() = (%d, %q), want (0, \"\")", rewritten, backupPath)
+	}
+	if backups, _ := filepath.Glob(path + ".*.bak"); len(backups) != 0 {
+		t.Errorf("backup files %v should not be created when nothing is rewritten", backups)
+	}
+}
+
+func TestRewriteFileKeepsExistingBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "script.sh")
+	if err := os.WriteFile(path, []byte("gcloud config configurations activate prod\n"), 0o600); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	// A backup the user made by hand, and one from an earlier run
+	for _, name := range []string{path + ".bak", path + ".20250101-120000.bak"} {
+		if err := os.WriteFile(name, []byte("keep me\n"), 0o600); err != nil {
+			t.Fatalf("failed to write backup: %v", err)
+		}
+	}
+
+	_, backupPath, err := RewriteFile(path)
+	if err != nil {
+		t.Fatalf("RewriteFile failed: %v", err)
+	}
+
+	if backupPath == path+".bak" || backupPath == path+".20250101-120000.bak" {
+		t.Errorf("backup path = %s, want a new file", backupPath)
+	}
+	for _, name := range []string{path + ".bak", path + ".20250101-120000.bak"} {
+		if data, _ := os.ReadFile(name); string(data) != "keep me\n" {
+			t.Errorf("%s = %q, want it untouched", name, data)
+		}
+	}
+}
+
+func TestDefaultRCFiles(t *testing.T) {
+	home := t.TempDir()
+	if err := os.WriteFile(filepath.Join(home, ".zshrc"), []byte(""), 0o600); err != nil {
+		t.Fatalf("failed to write rc file: %v", err)
+	}
+
+	files := DefaultRCFiles(home)
+	if len(files) != 1 || files[0] != filepath.Join(home, ".zshrc") {
+		t.Errorf("DefaultRCFiles() = %v, want [%s]", files, filepath.Join(home, ".zshrc"))
+	}
+}
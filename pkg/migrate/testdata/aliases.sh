@@ -0,0 +1,18 @@
+# Shell aliases for switching gcloud configurations
+# gcloud config configurations activate commented-out
+alias gprod='gcloud config configurations activate production'
+alias gdev="gcloud config configurations activate dev"
+alias gls='gcloud config configurations list'
+
+gcloud config configurations activate "staging"
+gcloud config configurations create sandbox --activate
+gcloud config configurations delete old-config --quiet
+gcloud config configurations rename legacy --new-name=modern
+gcloud config configurations describe production
+gcloud config set project my-project
+gcloud config configurations activate prod && gcloud config set project prod-123
+gcloud config configurations activate \
+  multiline-config
+gcloud config configurations list --format=json
+gcloud config configurations activate "$TARGET_CONFIG"
+echo "done"
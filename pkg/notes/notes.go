@@ -0,0 +1,206 @@
+// Package notes keeps free-text notes about configurations, such as the client
+// a configuration belongs to or when its access expires. Notes live in
+// gcloudctx's metadata file in the home directory, keyed by configuration
+// name, since gcloud's own files have no place for them.
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const metadataFileName = ".gcloudctx_metadata"
+
+// Note is the note of one configuration
+type Note struct {
+	Text string `json:"text"`
+	// UpdatedAt is when the note was last set
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// metadata is what the metadata file holds about one configuration
+type metadata struct {
+	Note *Note `json:"note,omitempty"`
+}
+
+// GetMetadataFilePath returns the path to the file holding the notes of configurations
+func GetMetadataFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return MetadataFilePathIn(homeDir), nil
+}
+
+// MetadataFilePathIn returns the path of the metadata file kept in a state directory
+func MetadataFilePathIn(dir string) string {
+	return filepath.Join(dir, metadataFileName)
+}
+
+// Normalize trims a note and drops trailing spaces from its lines
+// A note that is empty afterwards removes the configuration's note
+func Normalize(text string) string {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n")), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// readMetadata reads the configuration-to-metadata map from the file at path
+func readMetadata(path string) (map[string]metadata, error) {
+	entries := map[string]metadata{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("failed to read configuration notes: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration notes: %w", err)
+	}
+	return entries, nil
+}
+
+// writeMetadata writes the configuration-to-metadata map to the file at path,
+// removing the file when nothing is left in it
+// The file is replaced atomically so a concurrent reader never sees a partial write
+func writeMetadata(path string, entries map[string]metadata) error {
+	for name, entry := range entries {
+		if entry.Note == nil {
+			delete(entries, name)
+		}
+	}
+	if len(entries) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to save configuration notes: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration notes: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), metadataFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save configuration notes: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save configuration notes: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save configuration notes: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save configuration notes: %w", err)
+	}
+	return nil
+}
+
+// update applies change to the metadata file in the home directory
+func update(change func(entries map[string]metadata) bool) error {
+	path, err := GetMetadataFilePath()
+	if err != nil {
+		return err
+	}
+	entries, err := readMetadata(path)
+	if err != nil {
+		return err
+	}
+	if !change(entries) {
+		return nil
+	}
+	return writeMetadata(path, entries)
+}
+
+// Load returns the note text of each configuration that has one
+func Load() (map[string]string, error) {
+	path, err := GetMetadataFilePath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make(map[string]string, len(entries))
+	for name, entry := range entries {
+		if entry.Note != nil {
+			texts[name] = entry.Note.Text
+		}
+	}
+	return texts, nil
+}
+
+// Set sets the note of a configuration at the given time; an empty text removes it
+func Set(configName, text string, at time.Time) error {
+	text = Normalize(text)
+	return update(func(entries map[string]metadata) bool {
+		entry := entries[configName]
+		if text == "" {
+			if entry.Note == nil {
+				return false
+			}
+			entry.Note = nil
+		} else {
+			entry.Note = &Note{Text: text, UpdatedAt: at.UTC()}
+		}
+		entries[configName] = entry
+		return true
+	})
+}
+
+// Remove removes the note of a configuration, reporting whether it had one
+func Remove(configName string) (removed bool, err error) {
+	err = update(func(entries map[string]metadata) bool {
+		if entries[configName].Note == nil {
+			return false
+		}
+		delete(entries, configName)
+		removed = true
+		return true
+	})
+	return removed, err
+}
+
+// Rename moves the note of oldName to newName
+func Rename(oldName, newName string) error {
+	return update(func(entries map[string]metadata) bool {
+		entry, ok := entries[oldName]
+		if !ok {
+			return false
+		}
+		delete(entries, oldName)
+		entries[newName] = entry
+		return true
+	})
+}
+
+// Prune removes the notes of configurations not in existing and returns their
+// names, sorted
+func Prune(existing []string) (pruned []string, err error) {
+	err = update(func(entries map[string]metadata) bool {
+		for name := range entries {
+			if !slices.Contains(existing, name) {
+				pruned = append(pruned, name)
+				delete(entries, name)
+			}
+		}
+		return len(pruned) > 0
+	})
+	slices.Sort(pruned)
+	return pruned, err
+}
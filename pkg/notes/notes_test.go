@@ -0,0 +1,80 @@
+package notes
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSetRemoveAndRename(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	if err := Set("prod", "  client X, billing acct 42  \r\nexpires Q3 \n", at); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := Set("old", "renamed soon", at); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := Rename("old", "new"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	texts, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if texts["prod"] != "client X, billing acct 42\nexpires Q3" {
+		t.Errorf("prod note = %q, want it trimmed", texts["prod"])
+	}
+	if _, ok := texts["old"]; ok || texts["new"] != "renamed soon" {
+		t.Errorf("notes = %q, want old's note moved to new", texts)
+	}
+
+	removed, err := Remove("prod")
+	if err != nil || !removed {
+		t.Fatalf("Remove(prod) = %v, %v; want true", removed, err)
+	}
+	if removed, err := Remove("prod"); err != nil || removed {
+		t.Errorf("Remove(prod) again = %v, %v; want false", removed, err)
+	}
+
+	// An empty note removes the note, and the file with the last one
+	if err := Set("new", " \n ", at); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	path, err := GetMetadataFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("metadata file still exists without notes: %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for _, name := range []string{"prod", "gone", "dev", "deleted"} {
+		if err := Set(name, "note of "+name, at); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	pruned, err := Prune([]string{"dev", "prod", "staging"})
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if !slices.Equal(pruned, []string{"deleted", "gone"}) {
+		t.Errorf("Prune() = %q, want deleted and gone", pruned)
+	}
+
+	texts, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(texts) != 2 || texts["dev"] != "note of dev" || texts["prod"] != "note of prod" {
+		t.Errorf("notes after pruning = %q, want dev and prod", texts)
+	}
+}
@@ -0,0 +1,186 @@
+// Package paths resolves every file and directory gcloudctx reads or writes.
+// Each path comes from the same function the owning package uses, so the
+// listing shown by 'gcloudctx paths' cannot disagree with what gcloudctx does.
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/activity"
+	"github.com/Okabe-Junya/gcloudctx/pkg/adc"
+	"github.com/Okabe-Junya/gcloudctx/pkg/cache"
+	"github.com/Okabe-Junya/gcloudctx/pkg/gcloud"
+	"github.com/Okabe-Junya/gcloudctx/pkg/history"
+	"github.com/Okabe-Junya/gcloudctx/pkg/inflight"
+	"github.com/Okabe-Junya/gcloudctx/pkg/notes"
+	"github.com/Okabe-Junya/gcloudctx/pkg/session"
+	"github.com/Okabe-Junya/gcloudctx/pkg/settings"
+	"github.com/Okabe-Junya/gcloudctx/pkg/statelock"
+	"github.com/Okabe-Junya/gcloudctx/pkg/templates"
+)
+
+// Status describes the state of a path on disk
+type Status string
+
+// Path statuses
+const (
+	StatusExists     Status = "exists"
+	StatusMissing    Status = "missing"
+	StatusUnwritable Status = "unwritable"
+)
+
+// Sources a path can be resolved from
+const (
+	SourceDefault     = "default"
+	SourceEnv         = "env"
+	SourceHome        = "home directory"
+	SourceUserCache   = "user cache directory"
+	SourceGcloudDir   = "gcloud config dir"
+	SourceConfigRoot  = "--config-root"
+	SourceGcloudInfo  = "gcloud info"
+	sourceEnvTemplate = "$%s"
+)
+
+// Entry is a resolved path with where it came from
+type Entry struct {
+	Key         string `json:"key" yaml:"key"`
+	Description string `json:"description" yaml:"description"`
+	Path        string `json:"path" yaml:"path"`
+	Source      string `json:"source" yaml:"source"`
+	Status      Status `json:"status" yaml:"status"`
+}
+
+// ErrUnknownKey is returned by Lookup for keys that are not resolved
+var ErrUnknownKey = errors.New("unknown path key")
+
+// homePath pairs a key with a path getter rooted in the home directory
+type homePath struct {
+	key         string
+	description string
+	path        func() (string, error)
+}
+
+// homePaths lists gcloudctx's own state in the home directory
+var homePaths = []homePath{
+	{"settings", "settings file", settings.GetSettingsFilePath},
+	{"history", "configuration history", history.GetHistoryFilePath},
+	{"last_active", "last seen active configuration", history.GetLastActiveFilePath},
+	{"project_history", "project history", history.GetProjectHistoryFilePath},
+	{"usage", "usage history", history.GetUsageFilePath},
+	{"notes", "configuration notes", notes.GetMetadataFilePath},
+	{"activity", "activity log", activity.GetLogFilePath},
+	{"pins", "terminal pins", session.GetPinsFilePath},
+	{"adc_hints", "ADC hints", adc.GetHintsFilePath},
+	{"adc_snapshots", "ADC snapshots", adc.GetSnapshotsDir},
+	{"inflight", "in-flight operations", inflight.GetStateDir},
+	{"lock", "state lock", statelock.GetLockFilePath},
+	{"templates", "configuration templates", templates.GetTemplatesDir},
+}
+
+// Resolve returns every path gcloudctx uses, in a stable order
+func Resolve() ([]Entry, error) {
+	configDir, err := gcloud.ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{
+		{Key: "gcloud_config_dir", Description: "gcloud configuration directory", Path: configDir, Source: configDirSource(configDir)},
+		{Key: "active_config", Description: "active configuration marker", Path: filepath.Join(configDir, gcloud.ActiveConfigFileName), Source: SourceGcloudDir},
+		{Key: "adc", Description: "Application Default Credentials", Path: filepath.Join(configDir, gcloud.ADCFileName), Source: SourceGcloudDir},
+	}
+
+	for _, p := range homePaths {
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{Key: p.key, Description: p.description, Path: path, Source: SourceHome})
+	}
+
+	cacheDir, err := cache.GetCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, Entry{Key: "cache", Description: "cache directory", Path: cacheDir, Source: cacheDirSource()})
+
+	for i := range entries {
+		entries[i].Status = StatusOf(entries[i].Path)
+	}
+	return entries, nil
+}
+
+// Lookup returns the entry for key from entries
+func Lookup(entries []Entry, key string) (*Entry, error) {
+	for i := range entries {
+		if entries[i].Key == key {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownKey, key)
+}
+
+// Keys returns the keys of entries in order
+func Keys(entries []Entry) []string {
+	keys := make([]string, len(entries))
+	for i, entry := range entries {
+		keys[i] = entry.Key
+	}
+	return keys
+}
+
+// configDirSource explains where gcloud.ConfigDir got its answer dir
+func configDirSource(dir string) string {
+	if gcloud.ConfigRoot() != "" {
+		return SourceConfigRoot
+	}
+	if os.Getenv(gcloud.EnvConfigDir) != "" {
+		return fmt.Sprintf(sourceEnvTemplate, gcloud.EnvConfigDir)
+	}
+	if home, err := os.UserHomeDir(); err == nil && dir != gcloud.ConfigDirFor(home) {
+		return SourceGcloudInfo
+	}
+	if runtime.GOOS == "windows" && os.Getenv("APPDATA") != "" {
+		return fmt.Sprintf(sourceEnvTemplate, "APPDATA")
+	}
+	return SourceDefault
+}
+
+// cacheDirSource explains where os.UserCacheDir got its answer
+func cacheDirSource() string {
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "plan9":
+	default:
+		if os.Getenv("XDG_CACHE_HOME") != "" {
+			return fmt.Sprintf(sourceEnvTemplate, "XDG_CACHE_HOME")
+		}
+	}
+	return SourceUserCache
+}
+
+// StatusOf reports whether path exists, and whether gcloudctx could write it
+// A missing path is unwritable when its nearest existing parent is
+func StatusOf(path string) Status {
+	if _, err := os.Stat(path); err == nil {
+		if writable(path) {
+			return StatusExists
+		}
+		return StatusUnwritable
+	}
+
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			if writable(dir) {
+				return StatusMissing
+			}
+			return StatusUnwritable
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return StatusMissing
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package paths
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/golden"
+)
+
+// setupHome points every resolver at a temporary home directory
+func setupHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	t.Setenv("CLOUDSDK_CONFIG", "")
+	return home
+}
+
+func TestResolveJSONGolden(t *testing.T) {
+	home := setupHome(t)
+
+	entries, err := Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to marshal entries: %v", err)
+	}
+	got := strings.ReplaceAll(string(data), home, "$HOME") + "\n"
+
+	golden.Assert(t, filepath.Join("testdata", "paths.json.golden"), got)
+}
+
+func TestResolveConfigDirSource(t *testing.T) {
+	home := setupHome(t)
+	configDir := filepath.Join(home, "gcloud-custom")
+	t.Setenv("CLOUDSDK_CONFIG", configDir)
+
+	entries, err := Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	entry, err := Lookup(entries, "gcloud_config_dir")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if entry.Path != configDir || entry.Source != "$CLOUDSDK_CONFIG" {
+		t.Errorf("gcloud_config_dir = %+v, want path %s from $CLOUDSDK_CONFIG", entry, configDir)
+	}
+
+	active, err := Lookup(entries, "active_config")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if want := filepath.Join(configDir, "active_config"); active.Path != want {
+		t.Errorf("active_config path = %s, want %s", active.Path, want)
+	}
+}
+
+func TestLookupUnknownKey(t *testing.T) {
+	setupHome(t)
+
+	entries, err := Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if _, err := Lookup(entries, "nope"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Lookup(nope) error = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := StatusOf(file); got != StatusExists {
+		t.Errorf("StatusOf(existing file) = %s, want %s", got, StatusExists)
+	}
+	if got := StatusOf(filepath.Join(dir, "a", "b", "missing")); got != StatusMissing {
+		t.Errorf("StatusOf(missing file) = %s, want %s", got, StatusMissing)
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks do not apply to root")
+	}
+	readOnly := filepath.Join(dir, "readonly")
+	if err := os.Mkdir(readOnly, 0o500); err != nil {
+		t.Fatal(err)
+	}
+	if got := StatusOf(readOnly); got != StatusUnwritable {
+		t.Errorf("StatusOf(read-only dir) = %s, want %s", got, StatusUnwritable)
+	}
+	if got := StatusOf(filepath.Join(readOnly, "missing")); got != StatusUnwritable {
+		t.Errorf("StatusOf(missing file in read-only dir) = %s, want %s", got, StatusUnwritable)
+	}
+}
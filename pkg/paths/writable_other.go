@@ -0,0 +1,23 @@
+//go:build !unix
+
+package paths
+
+import "os"
+
+// writable reports whether path can be opened for writing
+// Directories cannot be probed this way and are assumed writable
+func writable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if info.IsDir() {
+		return true
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
@@ -0,0 +1,10 @@
+//go:build unix
+
+package paths
+
+import "golang.org/x/sys/unix"
+
+// writable reports whether the current user may write path
+func writable(path string) bool {
+	return unix.Access(path, unix.W_OK) == nil
+}
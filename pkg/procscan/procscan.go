@@ -0,0 +1,106 @@
+// Package procscan finds running gcloud, gsutil and bq processes of the
+// current user, which read the active configuration while they run.
+// The process table is read through a Lister so callers and tests can supply
+// their own; System returns the one for the current platform.
+package procscan
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Process is a running process
+type Process struct {
+	PID     int
+	Command string
+}
+
+// Lister lists the processes of the current user
+type Lister interface {
+	List() ([]Process, error)
+}
+
+// ListerFunc adapts a function to a Lister
+type ListerFunc func() ([]Process, error)
+
+// List calls f
+func (f ListerFunc) List() ([]Process, error) {
+	return f()
+}
+
+// ToolNames are the Cloud SDK tools whose processes Find reports
+var ToolNames = []string{"gcloud", "gsutil", "bq"}
+
+// interpreters run the Cloud SDK tools, which are scripts
+var interpreters = []string{"sh", "bash", "dash", "zsh", "python", "python3"}
+
+// Find returns the Cloud SDK processes lister reports, except the current process
+func Find(lister Lister) ([]Process, error) {
+	processes, err := lister.List()
+	if err != nil {
+		return nil, err
+	}
+
+	self := os.Getpid()
+	var found []Process
+	for _, process := range processes {
+		if process.PID != self && IsCloudSDKCommand(process.Command) {
+			found = append(found, process)
+		}
+	}
+	return found, nil
+}
+
+// IsCloudSDKCommand reports whether command line runs gcloud, gsutil or bq,
+// directly or through an interpreter such as "python3 .../gcloud.py"
+func IsCloudSDKCommand(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return false
+	}
+	if isTool(fields[0]) {
+		return true
+	}
+	if len(fields) > 1 && slices.Contains(interpreters, programName(fields[0])) {
+		return isTool(fields[1])
+	}
+	return false
+}
+
+// isTool reports whether path names one of ToolNames
+func isTool(path string) bool {
+	return slices.Contains(ToolNames, programName(path))
+}
+
+// programName returns the name of the program at path without its extension
+// or a version suffix such as python3.12's
+func programName(path string) string {
+	name := filepath.Base(path)
+	for _, ext := range []string{".py", ".sh", ".cmd", ".exe"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if strings.HasPrefix(name, "python") {
+		return strings.TrimRight(name, "0123456789.")
+	}
+	return name
+}
+
+// parsePS parses the output of 'ps -o pid=,args=', skipping malformed lines
+func parsePS(output string) []Process {
+	var processes []Process
+	for _, line := range strings.Split(output, "\n") {
+		pid, command, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(pid)
+		if err != nil {
+			continue
+		}
+		processes = append(processes, Process{PID: n, Command: strings.TrimSpace(command)})
+	}
+	return processes
+}
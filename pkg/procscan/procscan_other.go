@@ -0,0 +1,11 @@
+//go:build !unix
+
+package procscan
+
+// System returns a Lister that reports no processes; the process table is
+// not read on Windows yet
+func System() Lister {
+	return ListerFunc(func() ([]Process, error) {
+		return nil, nil
+	})
+}
@@ -0,0 +1,73 @@
+package procscan
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestIsCloudSDKCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"gcloud deploy releases create r1", true},
+		{"/opt/google-cloud-sdk/bin/gsutil -m cp a b", true},
+		{"bq query SELECT 1", true},
+		{"gcloud.cmd config list", true},
+		{"/usr/bin/python3.12 /opt/google-cloud-sdk/lib/gcloud.py run deploy", true},
+		{"bash /opt/google-cloud-sdk/bin/gcloud app deploy", true},
+		{"vim gcloud", false},
+		{"gcloudctx prod", false},
+		{"terraform apply", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsCloudSDKCommand(tt.command); got != tt.want {
+			t.Errorf("IsCloudSDKCommand(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	lister := ListerFunc(func() ([]Process, error) {
+		return []Process{
+			{PID: 100, Command: "zsh"},
+			{PID: 200, Command: "gcloud builds submit"},
+			{PID: os.Getpid(), Command: "gcloud config list"},
+			{PID: 300, Command: "python3 /sdk/platform/gsutil/gsutil.py ls"},
+		}, nil
+	})
+
+	got, err := Find(lister)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Process{
+		{PID: 200, Command: "gcloud builds submit"},
+		{PID: 300, Command: "python3 /sdk/platform/gsutil/gsutil.py ls"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFindError(t *testing.T) {
+	boom := errors.New("boom")
+	if _, err := Find(ListerFunc(func() ([]Process, error) { return nil, boom })); !errors.Is(err, boom) {
+		t.Errorf("Find() error = %v, want %v", err, boom)
+	}
+}
+
+func TestParsePS(t *testing.T) {
+	output := "    1 /sbin/init\n  4242 gcloud run deploy --source .\nbogus\n\n  77 bq\n"
+	want := []Process{
+		{PID: 1, Command: "/sbin/init"},
+		{PID: 4242, Command: "gcloud run deploy --source ."},
+		{PID: 77, Command: "bq"},
+	}
+	if got := parsePS(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePS() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package procscan
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// System returns the Lister reading the process table with ps, which Linux
+// and macOS both provide
+func System() Lister {
+	return ListerFunc(listWithPS)
+}
+
+// listWithPS lists the processes of the current user with ps
+func listWithPS() ([]Process, error) {
+	out, err := exec.Command("ps", "-U", strconv.Itoa(os.Getuid()), "-o", "pid=,args=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	return parsePS(string(out)), nil
+}
@@ -0,0 +1,146 @@
+// Package promptgen generates prompt segments for starship and powerlevel10k
+// that show the active configuration by running 'gcloudctx prompt'.
+// The snippets are templates embedded in the binary, filled in with the prompt
+// format and color chosen by the user, so adopting one is a single append.
+package promptgen
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
+)
+
+// DefaultColor is the color of the segment when none is chosen
+const DefaultColor = "blue"
+
+//go:embed templates
+var templateFiles embed.FS
+
+// templates are the parsed snippet templates, by file name
+var templates = template.Must(template.ParseFS(templateFiles, "templates/*.tmpl"))
+
+// starshipColors are starship's names for the linefmt colors
+var starshipColors = map[string]string{
+	"black":          "black",
+	"red":            "red",
+	"green":          "green",
+	"yellow":         "yellow",
+	"blue":           "blue",
+	"magenta":        "purple",
+	"cyan":           "cyan",
+	"white":          "white",
+	"gray":           "bright-black",
+	"bright-red":     "bright-red",
+	"bright-green":   "bright-green",
+	"bright-yellow":  "bright-yellow",
+	"bright-blue":    "bright-blue",
+	"bright-magenta": "bright-purple",
+	"bright-cyan":    "bright-cyan",
+	"bright-white":   "bright-white",
+}
+
+// zshColors are the zsh color numbers of the linefmt colors, as p10k segment -f takes them
+var zshColors = map[string]int{
+	"black":          0,
+	"red":            1,
+	"green":          2,
+	"yellow":         3,
+	"blue":           4,
+	"magenta":        5,
+	"cyan":           6,
+	"white":          7,
+	"gray":           8,
+	"bright-red":     9,
+	"bright-green":   10,
+	"bright-yellow":  11,
+	"bright-blue":    12,
+	"bright-magenta": 13,
+	"bright-cyan":    14,
+	"bright-white":   15,
+}
+
+// Options parameterize a generated segment
+type Options struct {
+	// Format is passed to 'gcloudctx prompt --format'; empty uses the default segment
+	Format string
+	// Color is one of linefmt.ColorNames; empty uses DefaultColor
+	Color string
+}
+
+// values are what the snippet templates are filled in with
+type values struct {
+	Command     string
+	CommandTOML string
+	StyleTOML   string
+	ZshColor    int
+}
+
+// Starship returns a custom starship module showing the active configuration
+func Starship(opts Options) (string, error) {
+	return render("starship.toml.tmpl", opts)
+}
+
+// P10k returns a powerlevel10k segment function showing the active configuration
+func P10k(opts Options) (string, error) {
+	return render("p10k.zsh.tmpl", opts)
+}
+
+// render fills in the named template for opts
+func render(name string, opts Options) (string, error) {
+	colorName := opts.Color
+	if colorName == "" {
+		colorName = DefaultColor
+	}
+	if err := linefmt.ValidateColor(colorName); err != nil {
+		return "", err
+	}
+
+	command := PromptCommand(opts.Format)
+	v := values{
+		Command:     command,
+		CommandTOML: tomlString(command),
+		StyleTOML:   tomlString("bold " + starshipColors[colorName]),
+		ZshColor:    zshColors[colorName],
+	}
+
+	var b strings.Builder
+	if err := templates.ExecuteTemplate(&b, name, v); err != nil {
+		return "", fmt.Errorf("failed to generate prompt segment: %w", err)
+	}
+	return b.String(), nil
+}
+
+// PromptCommand returns the shell command printing the prompt segment
+func PromptCommand(format string) string {
+	if format == "" {
+		return "gcloudctx prompt"
+	}
+	return "gcloudctx prompt --format " + shellQuote(format)
+}
+
+// shellQuote quotes s for POSIX shells and zsh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// tomlString returns s as a TOML basic string
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package promptgen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/golden"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
+)
+
+func TestGenerate(t *testing.T) {
+	generators := map[string]func(Options) (string, error){
+		"starship": Starship,
+		"p10k":     P10k,
+	}
+	cases := map[string]Options{
+		"default": {},
+		"custom":  {Format: `{emoji}{name} "{project}" it's`, Color: "bright-magenta"},
+	}
+
+	for generator, generate := range generators {
+		for name, opts := range cases {
+			t.Run(generator+"/"+name, func(t *testing.T) {
+				got, err := generate(opts)
+				if err != nil {
+					t.Fatalf("%s(%+v) error = %v", generator, opts, err)
+				}
+				golden.Assert(t, filepath.Join("testdata", generator, name+".golden"), got)
+			})
+		}
+	}
+}
+
+func TestGenerateInvalidColor(t *testing.T) {
+	if _, err := Starship(Options{Color: "octarine"}); err == nil {
+		t.Error("Starship() with an unknown color succeeded, want an error")
+	}
+	if _, err := P10k(Options{Color: "octarine"}); err == nil {
+		t.Error("P10k() with an unknown color succeeded, want an error")
+	}
+}
+
+func TestEveryColorMapped(t *testing.T) {
+	for _, name := range linefmt.ColorNames() {
+		if _, ok := starshipColors[name]; !ok {
+			t.Errorf("no starship color for %q", name)
+		}
+		if _, ok := zshColors[name]; !ok {
+			t.Errorf("no zsh color for %q", name)
+		}
+	}
+}
+
+func TestTOMLString(t *testing.T) {
+	tests := map[string]string{
+		`plain`:        `"plain"`,
+		`say "hi"`:     `"say \"hi\""`,
+		`back\slash`:   `"back\\slash"`,
+		"tab\there":    `"tab\u0009here"`,
+		"🚀 {name}":     `"🚀 {name}"`,
+		`'single' 'q'`: `"'single' 'q'"`,
+	}
+	for input, want := range tests {
+		if got := tomlString(input); got != want {
+			t.Errorf("tomlString(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
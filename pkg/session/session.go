@@ -0,0 +1,209 @@
+// Package session identifies terminal sessions and keeps per-session state.
+// A session is identified by the GCLOUDCTX_SESSION environment variable, which
+// the shell sets once per terminal (e.g. `export GCLOUDCTX_SESSION=$$`).
+// Session state expires a TTL after it was last used, so closed terminals do not
+// leave pins behind forever.
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/statelock"
+)
+
+// EnvSession holds the identifier of the current terminal session
+const EnvSession = "GCLOUDCTX_SESSION"
+
+// DefaultTTL is how long session state survives after it was last used
+const DefaultTTL = 24 * time.Hour
+
+// TouchInterval is how long Touch waits before writing a pin's LastSeen again,
+// so a shell hook running on every cd does not rewrite the pins file each time
+const TouchInterval = time.Hour
+
+const pinsFileName = ".gcloudctx_pins"
+
+// ErrNoSession is returned when the current terminal has no session identifier
+var ErrNoSession = fmt.Errorf("no terminal session found (add 'export %s=$$' to your shell rc file)", EnvSession)
+
+// ID returns the identifier of the current terminal session
+func ID() (string, error) {
+	id := os.Getenv(EnvSession)
+	if id == "" {
+		return "", ErrNoSession
+	}
+	return id, nil
+}
+
+// Pin pins a terminal session to a configuration
+type Pin struct {
+	Config   string    `json:"config"`
+	PinnedAt time.Time `json:"pinned_at"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Expired reports whether the pin was last used more than ttl ago
+func (p *Pin) Expired(now time.Time, ttl time.Duration) bool {
+	return now.Sub(p.LastSeen) >= ttl
+}
+
+// PinStore manages terminal pins in a JSON file keyed by session identifier
+type PinStore struct {
+	path string
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// NewPinStore returns a store keeping pins in path, expiring them ttl after they were last used
+func NewPinStore(path string, ttl time.Duration, now func() time.Time) *PinStore {
+	return &PinStore{path: path, ttl: ttl, now: now}
+}
+
+// GetPinsFilePath returns the path to the terminal pins file
+func GetPinsFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, pinsFileName), nil
+}
+
+// DefaultPinStore returns the pin store in the user's home directory
+func DefaultPinStore() (*PinStore, error) {
+	path, err := GetPinsFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return NewPinStore(path, DefaultTTL, time.Now), nil
+}
+
+// Set pins a session to a configuration; pinning again renews the pin
+func (s *PinStore) Set(sessionID, config string) error {
+	return s.update(func(pins map[string]Pin) {
+		now := s.now()
+		pins[sessionID] = Pin{Config: config, PinnedAt: now, LastSeen: now}
+	})
+}
+
+// Unset releases the pin of a session; releasing an unpinned session is not an error
+func (s *PinStore) Unset(sessionID string) error {
+	return s.update(func(pins map[string]Pin) {
+		delete(pins, sessionID)
+	})
+}
+
+// Get returns the pin of a session, or nil when the session is not pinned or its pin expired
+// It only reads the pins file; expired pins are removed by the next Set or Unset
+func (s *PinStore) Get(sessionID string) (*Pin, error) {
+	pins, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	pin, ok := pins[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	return &pin, nil
+}
+
+// Touch marks the pin of a session as used now, so a pin in use does not expire
+// An unpinned session is left alone, and a pin seen within TouchInterval is not
+// written again
+func (s *PinStore) Touch(sessionID string) error {
+	pin, err := s.Get(sessionID)
+	if err != nil || pin == nil || s.now().Sub(pin.LastSeen) < TouchInterval {
+		return err
+	}
+	return s.update(func(pins map[string]Pin) {
+		if pin, ok := pins[sessionID]; ok {
+			pin.LastSeen = s.now()
+			pins[sessionID] = pin
+		}
+	})
+}
+
+// update applies change to the pins under the state lock, so two terminals
+// pinning at the same time do not drop each other's pin
+func (s *PinStore) update(change func(pins map[string]Pin)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), statelock.DefaultWait)
+	defer cancel()
+	lock, err := statelock.Acquire(ctx, statelock.LockFilePathIn(filepath.Dir(s.path)))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	pins, err := s.load()
+	if err != nil {
+		return err
+	}
+	change(pins)
+	return s.save(pins)
+}
+
+// load reads the pins file, dropping expired pins
+// A missing or corrupt file yields no pins so a broken state file never blocks auto-switching
+func (s *PinStore) load() (map[string]Pin, error) {
+	pins := map[string]Pin{}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return pins, nil
+		}
+		return nil, fmt.Errorf("failed to read terminal pins: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return map[string]Pin{}, nil
+	}
+
+	now := s.now()
+	for id, pin := range pins {
+		if pin.Expired(now, s.ttl) {
+			delete(pins, id)
+		}
+	}
+	return pins, nil
+}
+
+// save writes the pins file, removing it when no pins remain
+func (s *PinStore) save(pins map[string]Pin) error {
+	if len(pins) == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to save terminal pins: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode terminal pins: %w", err)
+	}
+
+	// Replace the file atomically so a concurrent Get never sees a partial write
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), pinsFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save terminal pins: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save terminal pins: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save terminal pins: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save terminal pins: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a controllable time source
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestStore(t *testing.T) (*PinStore, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	return NewPinStore(filepath.Join(t.TempDir(), pinsFileName), DefaultTTL, clock.Now), clock
+}
+
+func TestID(t *testing.T) {
+	t.Setenv(EnvSession, "")
+	if _, err := ID(); !errors.Is(err, ErrNoSession) {
+		t.Errorf("ID() error = %v, want ErrNoSession", err)
+	}
+
+	t.Setenv(EnvSession, "12345")
+	id, err := ID()
+	if err != nil || id != "12345" {
+		t.Errorf("ID() = %q, %v; want 12345", id, err)
+	}
+}
+
+func TestPinStorePinned(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	if err := store.Set("tty1", "prod"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	pin, err := store.Get("tty1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if pin == nil || pin.Config != "prod" {
+		t.Errorf("Get(tty1) = %+v, want pin to prod", pin)
+	}
+}
+
+func TestPinStoreUnpinned(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	if err := store.Set("tty1", "prod"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Other sessions are not affected by the pin
+	pin, err := store.Get("tty2")
+	if err != nil || pin != nil {
+		t.Errorf("Get(tty2) = %+v, %v; want no pin", pin, err)
+	}
+
+	if err := store.Unset("tty1"); err != nil {
+		t.Fatalf("Unset failed: %v", err)
+	}
+	pin, err = store.Get("tty1")
+	if err != nil || pin != nil {
+		t.Errorf("Get(tty1) after Unset = %+v, %v; want no pin", pin, err)
+	}
+	if _, err := os.Stat(store.path); !os.IsNotExist(err) {
+		t.Error("expected the pins file to be removed once empty")
+	}
+}
+
+func TestPinStoreExpiredSession(t *testing.T) {
+	store, clock := newTestStore(t)
+
+	if err := store.Set("tty1", "prod"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := store.Set("tty2", "dev"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Pinning again renews a pin past the TTL measured from the first pin
+	clock.now = clock.now.Add(DefaultTTL - time.Minute)
+	if err := store.Set("tty2", "dev"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	clock.now = clock.now.Add(2 * time.Minute)
+	pin, err := store.Get("tty1")
+	if err != nil || pin != nil {
+		t.Errorf("Get(tty1) after TTL = %+v, %v; want expired", pin, err)
+	}
+	if pin, _ := store.Get("tty2"); pin == nil {
+		t.Error("expected renewed tty2 pin to survive")
+	}
+
+	// The expired session is garbage collected from the file by the next update
+	if err := store.Set("tty3", "staging"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		t.Fatalf("failed to read pins file: %v", err)
+	}
+	var pins map[string]Pin
+	if err := json.Unmarshal(data, &pins); err != nil {
+		t.Fatalf("failed to parse pins file: %v", err)
+	}
+	if _, ok := pins["tty1"]; ok {
+		t.Error("expected expired pin to be removed")
+	}
+}
+
+func TestPinStoreGetDoesNotWrite(t *testing.T) {
+	store, clock := newTestStore(t)
+
+	if err := store.Set("tty1", "prod"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	before, err := os.ReadFile(store.path)
+	if err != nil {
+		t.Fatalf("failed to read pins file: %v", err)
+	}
+
+	clock.now = clock.now.Add(time.Hour)
+	if pin, err := store.Get("tty1"); err != nil || pin == nil {
+		t.Fatalf("Get(tty1) = %+v, %v; want pin to prod", pin, err)
+	}
+
+	after, err := os.ReadFile(store.path)
+	if err != nil {
+		t.Fatalf("failed to read pins file: %v", err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("Get rewrote the pins file:\n%s\nwant:\n%s", after, before)
+	}
+}
+
+func TestPinStoreTouch(t *testing.T) {
+	store, clock := newTestStore(t)
+
+	if err := store.Set("tty1", "prod"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	before, err := os.ReadFile(store.path)
+	if err != nil {
+		t.Fatalf("failed to read pins file: %v", err)
+	}
+
+	// A pin seen recently is not written again
+	clock.now = clock.now.Add(TouchInterval - time.Minute)
+	if err := store.Touch("tty1"); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	if after, _ := os.ReadFile(store.path); string(after) != string(before) {
+		t.Errorf("Touch within TouchInterval rewrote the pins file:\n%s", after)
+	}
+
+	// A pin in use survives past the TTL measured from when it was set
+	for range 2 * int(DefaultTTL/TouchInterval) {
+		clock.now = clock.now.Add(TouchInterval)
+		if err := store.Touch("tty1"); err != nil {
+			t.Fatalf("Touch failed: %v", err)
+		}
+	}
+	pin, err := store.Get("tty1")
+	if err != nil || pin == nil || !pin.LastSeen.Equal(clock.now) || pin.PinnedAt.Equal(pin.LastSeen) {
+		t.Errorf("Get(tty1) after touching = %+v, %v; want the pin seen now", pin, err)
+	}
+
+	// Touching an unpinned session does not pin it
+	if err := store.Touch("tty2"); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	if pin, _ := store.Get("tty2"); pin != nil {
+		t.Errorf("Get(tty2) after Touch = %+v, want no pin", pin)
+	}
+}
+
+func TestPinStoreConcurrentSet(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := store.Set(fmt.Sprintf("tty%d", i), "prod"); err != nil {
+				t.Errorf("Set failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range 10 {
+		if pin, err := store.Get(fmt.Sprintf("tty%d", i)); err != nil || pin == nil {
+			t.Errorf("Get(tty%d) = %+v, %v; want pin to prod", i, pin, err)
+		}
+	}
+}
+
+func TestPinStoreCorruptFile(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := os.WriteFile(store.path, []byte("not json"), 0o600); err != nil {
+		t.Fatalf("failed to write pins file: %v", err)
+	}
+
+	pin, err := store.Get("tty1")
+	if err != nil || pin != nil {
+		t.Errorf("Get() with corrupt file = %+v, %v; want no pin and no error", pin, err)
+	}
+}
@@ -0,0 +1,156 @@
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// protectedKey is the settings key listing protected configurations
+const protectedKey = "protected"
+
+// Protect adds name to the protected configurations in the settings file
+// It reports whether the file changed, i.e. false when name was already protected
+func Protect(name string) (bool, error) {
+	path, err := GetSettingsFilePath()
+	if err != nil {
+		return false, err
+	}
+	return setProtected(path, name, true)
+}
+
+// Unprotect removes name from the protected configurations in the settings file
+// It reports whether the file changed, i.e. false when name was not protected
+func Unprotect(name string) (bool, error) {
+	path, err := GetSettingsFilePath()
+	if err != nil {
+		return false, err
+	}
+	return setProtected(path, name, false)
+}
+
+// setProtected adds name to or removes it from the protected list of the
+// settings file at path
+// The file is edited as a YAML node tree so comments and other settings survive
+func setProtected(path, name string, protected bool) (bool, error) {
+	doc, err := readDocument(path)
+	if err != nil {
+		return false, err
+	}
+	root := doc.Content[0]
+
+	list := mappingValue(root, protectedKey)
+	if list == nil || list.Kind != yaml.SequenceNode {
+		if !protected {
+			return false, nil
+		}
+		if list == nil {
+			list = &yaml.Node{}
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: protectedKey}, list)
+		}
+		// Replaces an empty value such as "protected:"
+		*list = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+
+	index := -1
+	for i, item := range list.Content {
+		if item.Kind == yaml.ScalarNode && item.Value == name {
+			index = i
+			break
+		}
+	}
+	switch {
+	case protected && index >= 0, !protected && index < 0:
+		return false, nil
+	case protected:
+		list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name})
+	default:
+		list.Content = append(list.Content[:index], list.Content[index+1:]...)
+	}
+
+	if err := writeDocument(path, doc); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// readDocument reads the settings file at path as a YAML node tree whose
+// content is a mapping; a missing or empty file yields an empty mapping
+func readDocument(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		// Missing or empty file
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse %s: settings must be a mapping", path)
+	}
+	return &doc, nil
+}
+
+// writeDocument encodes doc and replaces the settings file at path with it
+func writeDocument(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+
+	if err := writeFileAtomic(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil when it is absent
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file at path with data, keeping its permissions
+// A concurrent reader never sees a partial write
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), settingsFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
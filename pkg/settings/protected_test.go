@@ -0,0 +1,96 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetProtected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	original := "# my settings\naccessible: true # screen reader\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	for _, name := range []string{"prod", "prod-eu", "prod"} {
+		if _, err := setProtected(path, name, true); err != nil {
+			t.Fatalf("setProtected(%q, true) error = %v", name, err)
+		}
+	}
+
+	settings, err := loadFromPath(path)
+	if err != nil {
+		t.Fatalf("loadFromPath failed: %v", err)
+	}
+	if !settings.Accessible {
+		t.Error("other settings were lost")
+	}
+	if got := strings.Join(settings.Protected, ","); got != "prod,prod-eu" {
+		t.Errorf("Protected = %q, want prod,prod-eu", got)
+	}
+	if !settings.IsProtected("prod-eu") || settings.IsProtected("dev") {
+		t.Errorf("IsProtected disagrees with Protected = %q", settings.Protected)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# my settings") || !strings.Contains(string(data), "# screen reader") {
+		t.Errorf("comments were lost:\n%s", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	changed, err := setProtected(path, "prod", false)
+	if err != nil || !changed {
+		t.Fatalf("setProtected(prod, false) = %v, %v; want true, nil", changed, err)
+	}
+	changed, err = setProtected(path, "prod", false)
+	if err != nil || changed {
+		t.Errorf("setProtected(prod, false) again = %v, %v; want false, nil", changed, err)
+	}
+
+	settings, _ = loadFromPath(path)
+	if got := strings.Join(settings.Protected, ","); got != "prod-eu" {
+		t.Errorf("Protected = %q, want prod-eu", got)
+	}
+}
+
+func TestSetProtectedMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+
+	changed, err := setProtected(path, "prod", false)
+	if err != nil || changed {
+		t.Errorf("setProtected(prod, false) = %v, %v; want false, nil", changed, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("unprotecting created the settings file")
+	}
+
+	if _, err := setProtected(path, "prod", true); err != nil {
+		t.Fatalf("setProtected(prod, true) error = %v", err)
+	}
+	settings, err := loadFromPath(path)
+	if err != nil {
+		t.Fatalf("loadFromPath failed: %v", err)
+	}
+	if !settings.IsProtected("prod") {
+		t.Errorf("Protected = %q, want prod", settings.Protected)
+	}
+}
+
+func TestLoadFromPathAutoAllowProtected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	if err := os.WriteFile(path, []byte("protected: [prod]\nauto:\n  allow_protected: true\n"), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	settings, err := loadFromPath(path)
+	if err != nil {
+		t.Fatalf("loadFromPath failed: %v", err)
+	}
+	if !settings.Auto.AllowProtected || !settings.IsProtected("prod") {
+		t.Errorf("settings = %+v, want prod protected and auto.allow_protected", settings)
+	}
+}
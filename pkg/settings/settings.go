@@ -0,0 +1,273 @@
+// Package settings loads user preferences for gcloudctx.
+// Settings are read from a YAML file in the user's home directory; a missing
+// file is not an error and yields the default settings.
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/hooks"
+	"github.com/Okabe-Junya/gcloudctx/pkg/linefmt"
+	"gopkg.in/yaml.v3"
+)
+
+const settingsFileName = ".gcloudctx.yaml"
+
+// Settings holds user preferences
+type Settings struct {
+	// Accessible switches human-readable output to labeled prose for screen readers
+	Accessible bool `yaml:"accessible"`
+
+	// ListFormat is a Go template rendering each line of the default list view
+	// (see linefmt.Fields for the available fields; empty uses linefmt.DefaultListFormat)
+	ListFormat string `yaml:"list_format"`
+
+	// ActiveMarker marks the active configuration (empty uses linefmt.DefaultActiveMarker)
+	ActiveMarker string `yaml:"active_marker"`
+
+	// PickerFormat is a Go template rendering the visible part of each fzf picker line
+	// (empty uses linefmt.DefaultPickerFormat)
+	PickerFormat string `yaml:"picker_format"`
+
+	// ShowHeader prints the default list as a table with a header row
+	ShowHeader bool `yaml:"show_header"`
+
+	// HighlightActive highlights the whole line of the active configuration in the default list
+	HighlightActive bool `yaml:"highlight_active"`
+
+	// DimMissingProject dims configurations without a project in the default list
+	DimMissingProject bool `yaml:"dim_missing_project"`
+
+	// DisableNumberedPicker makes a bare 'gcloudctx' without fzf print the active
+	// configuration instead of offering the built-in numbered picker
+	DisableNumberedPicker bool `yaml:"disable_numbered_picker"`
+
+	// DisableUpdateCheck stops 'gcloudctx --version' and 'gcloudctx doctor' from
+	// looking up the latest release
+	DisableUpdateCheck bool `yaml:"disable_update_check"`
+
+	// ReadOnly refuses every change to gcloud's configuration, as GCLOUDCTX_READONLY does
+	ReadOnly bool `yaml:"read_only"`
+
+	// Hooks are commands run around configuration switches
+	Hooks hooks.Config `yaml:"hooks"`
+
+	// Protected lists the configurations that ask for confirmation before
+	// being switched to (see Protect and Unprotect)
+	Protected []string `yaml:"protected"`
+
+	// Auto holds the settings of 'gcloudctx auto'
+	Auto AutoSettings `yaml:"auto"`
+
+	// Switch holds the settings of configuration switches
+	Switch SwitchSettings `yaml:"switch"`
+
+	// History holds the settings of the switch history
+	History HistorySettings `yaml:"history"`
+
+	// GKE holds the settings of the GKE credentials integration run after a switch
+	GKE GKESettings `yaml:"gke"`
+
+	// Fzf holds the settings of the fzf picker
+	Fzf FzfSettings `yaml:"fzf"`
+
+	// Display overrides how individual configurations are shown, keyed by name
+	Display map[string]Display `yaml:"display"`
+
+	// Workspaces are named configurations with a project and region applied on
+	// top (see SaveWorkspace and DeleteWorkspace)
+	Workspaces map[string]Workspace `yaml:"workspaces"`
+
+	listTemplate   *linefmt.Template
+	pickerTemplate *linefmt.Template
+}
+
+// AutoSettings are the settings of 'gcloudctx auto'
+type AutoSettings struct {
+	// AllowProtected lets auto switch to protected configurations, which it
+	// otherwise refuses since nobody is asked to confirm
+	AllowProtected bool `yaml:"allow_protected"`
+}
+
+// SwitchSettings are the settings of configuration switches
+type SwitchSettings struct {
+	// CheckRunningGcloud asks for confirmation before switching while gcloud,
+	// gsutil or bq processes of the user are running
+	CheckRunningGcloud bool `yaml:"check_running_gcloud"`
+}
+
+// FzfSettings are the settings of the fzf picker
+type FzfSettings struct {
+	// DisableBindings turns off the picker's extra key bindings (delete,
+	// toggle details)
+	DisableBindings bool `yaml:"disable_bindings"`
+
+	// TmuxPopup is the "WIDTH,HEIGHT" of the tmux popup the picker opens in
+	// inside tmux (e.g. "90%,70%"); GCLOUDCTX_FZF_TMUX_POPUP takes precedence
+	TmuxPopup string `yaml:"tmux_popup"`
+}
+
+// HistorySettings are the settings of the switch history
+type HistorySettings struct {
+	// Enabled tracks switches for '-' and usage statistics; unset means enabled
+	Enabled *bool `yaml:"enabled"`
+}
+
+// IsEnabled reports whether switches are tracked
+func (h *HistorySettings) IsEnabled() bool {
+	return h.Enabled == nil || *h.Enabled
+}
+
+// Display is how a configuration is shown in lists, pickers, prompts and messages
+type Display struct {
+	// Color colors the configuration's name (see linefmt.ColorNames); the active
+	// configuration is shown in bold
+	Color string `yaml:"color"`
+
+	// Emoji is shown before the configuration's name
+	Emoji string `yaml:"emoji"`
+}
+
+// GKESettings are the settings of the GKE credentials integration, which runs
+// after a configuration is switched to
+type GKESettings struct {
+	// Suggest prints the get-credentials commands for the clusters in the
+	// project of every configuration switched to
+	Suggest bool `yaml:"suggest"`
+
+	// Configurations holds the GKE settings of individual configurations
+	Configurations map[string]GKEConfiguration `yaml:"configurations"`
+}
+
+// GKEConfiguration is the GKE integration of one configuration
+type GKEConfiguration struct {
+	// Suggest prints the get-credentials commands for the clusters in the project
+	Suggest bool `yaml:"suggest"`
+
+	// AutoCredentials names a cluster as "<cluster>@<location>" whose
+	// credentials are fetched right after the switch
+	AutoCredentials string `yaml:"auto_credentials"`
+}
+
+// For returns the GKE integration of the named configuration, with the global
+// suggest setting applied
+func (g *GKESettings) For(name string) GKEConfiguration {
+	config := g.Configurations[name]
+	config.Suggest = config.Suggest || g.Suggest
+	return config
+}
+
+// IsProtected reports whether the named configuration is protected
+func (s *Settings) IsProtected(name string) bool {
+	return slices.Contains(s.Protected, name)
+}
+
+// ListTemplate returns the parsed list_format, or nil when it is not set
+func (s *Settings) ListTemplate() *linefmt.Template {
+	return s.listTemplate
+}
+
+// PickerTemplate returns the parsed picker_format, or nil when it is not set
+func (s *Settings) PickerTemplate() *linefmt.Template {
+	return s.pickerTemplate
+}
+
+// GetSettingsFilePath returns the path to the settings file
+func GetSettingsFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return SettingsFilePathIn(homeDir), nil
+}
+
+// SettingsFilePathIn returns the path of the settings file kept in a state directory
+func SettingsFilePathIn(dir string) string {
+	return filepath.Join(dir, settingsFileName)
+}
+
+// Load reads the settings file, returning default settings if it does not exist
+func Load() (*Settings, error) {
+	path, err := GetSettingsFilePath()
+	if err != nil {
+		return &Settings{}, err
+	}
+	return loadFromPath(path)
+}
+
+// LoadFrom reads the settings file of a state directory, returning default
+// settings if it does not exist
+func LoadFrom(dir string) (*Settings, error) {
+	return loadFromPath(SettingsFilePathIn(dir))
+}
+
+// loadFromPath reads settings from the given file
+func loadFromPath(path string) (*Settings, error) {
+	settings := &Settings{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return settings, nil
+		}
+		return settings, fmt.Errorf("failed to read settings: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, settings); err != nil {
+		return &Settings{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if err := settings.parseFormats(); err != nil {
+		return settings, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return settings, nil
+}
+
+// parseFormats validates the format and display settings, resetting invalid ones to their
+// defaults so that a typo in a template does not discard the other settings
+func (s *Settings) parseFormats() error {
+	var errs []error
+
+	if err := linefmt.ValidateMarker(s.ActiveMarker); err != nil {
+		s.ActiveMarker = ""
+		errs = append(errs, err)
+	}
+
+	var err error
+	if s.ListFormat != "" {
+		if s.listTemplate, err = linefmt.Parse("list_format", s.ListFormat); err != nil {
+			s.ListFormat = ""
+			errs = append(errs, err)
+		}
+	}
+	if s.PickerFormat != "" {
+		if s.pickerTemplate, err = linefmt.Parse("picker_format", s.PickerFormat); err != nil {
+			s.PickerFormat = ""
+			errs = append(errs, err)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Display)) {
+		display := s.Display[name]
+		if display.Color != "" {
+			if err := linefmt.ValidateColor(display.Color); err != nil {
+				display.Color = ""
+				errs = append(errs, fmt.Errorf("invalid display color of %q: %w", name, err))
+			}
+		}
+		if strings.ContainsAny(display.Emoji, "\t\r\n") {
+			display.Emoji = ""
+			errs = append(errs, fmt.Errorf("invalid display emoji of %q: must not contain tabs or line breaks", name))
+		}
+		s.Display[name] = display
+	}
+
+	return errors.Join(errs...)
+}
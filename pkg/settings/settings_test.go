@@ -0,0 +1,209 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetSettingsFilePath(t *testing.T) {
+	path, err := GetSettingsFilePath()
+	if err != nil {
+		t.Fatalf("GetSettingsFilePath failed: %v", err)
+	}
+	if !filepath.IsAbs(path) {
+		t.Error("Expected absolute path")
+	}
+}
+
+func TestLoadFromPathMissingFile(t *testing.T) {
+	settings, err := loadFromPath(filepath.Join(t.TempDir(), settingsFileName))
+	if err != nil {
+		t.Fatalf("loadFromPath failed: %v", err)
+	}
+	if settings.Accessible {
+		t.Error("Expected accessible to default to false")
+	}
+}
+
+func TestLoadFromPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	if err := os.WriteFile(path, []byte("accessible: true\n"), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	settings, err := loadFromPath(path)
+	if err != nil {
+		t.Fatalf("loadFromPath failed: %v", err)
+	}
+	if !settings.Accessible {
+		t.Error("Expected accessible to be true")
+	}
+}
+
+func TestLoadFromPathHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	data := "hooks:\n  post_switch: [echo all]\n  configurations:\n    prod:\n      post_switch: [echo prod]\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	settings, err := loadFromPath(path)
+	if err != nil {
+		t.Fatalf("loadFromPath failed: %v", err)
+	}
+	if got := settings.Hooks.PostSwitch("prod"); len(got) != 2 || got[1] != "echo prod" {
+		t.Errorf("Hooks.PostSwitch(prod) = %q, want the global and prod hooks", got)
+	}
+}
+
+func TestLoadFromPathHistory(t *testing.T) {
+	dir := t.TempDir()
+	for data, want := range map[string]bool{
+		"accessible: true\n":           true,
+		"history:\n  enabled: true\n":  true,
+		"history:\n  enabled: false\n": false,
+	} {
+		path := filepath.Join(dir, settingsFileName)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("failed to write settings: %v", err)
+		}
+
+		settings, err := loadFromPath(path)
+		if err != nil {
+			t.Fatalf("loadFromPath failed: %v", err)
+		}
+		if got := settings.History.IsEnabled(); got != want {
+			t.Errorf("History.IsEnabled() with %q = %v, want %v", data, got, want)
+		}
+	}
+}
+
+func TestLoadFromPathGKE(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	data := "gke:\n  configurations:\n    prod:\n      auto_credentials: main@europe-west1\n    dev:\n      suggest: true\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	settings, err := loadFromPath(path)
+	if err != nil {
+		t.Fatalf("loadFromPath failed: %v", err)
+	}
+	if got := settings.GKE.For("prod"); got.Suggest || got.AutoCredentials != "main@europe-west1" {
+		t.Errorf("GKE.For(prod) = %+v, want only auto_credentials", got)
+	}
+	if got := settings.GKE.For("dev"); !got.Suggest {
+		t.Errorf("GKE.For(dev) = %+v, want suggest", got)
+	}
+	if got := settings.GKE.For("staging"); got.Suggest || got.AutoCredentials != "" {
+		t.Errorf("GKE.For(staging) = %+v, want nothing", got)
+	}
+
+	settings.GKE.Suggest = true
+	if got := settings.GKE.For("staging"); !got.Suggest {
+		t.Errorf("GKE.For(staging) with the global suggest = %+v, want suggest", got)
+	}
+}
+
+func TestLoadFromPathFzf(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	if err := os.WriteFile(path, []byte("fzf:\n  disable_bindings: true\n  tmux_popup: 90%,70%\n"), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	settings, err := loadFromPath(path)
+	if err != nil {
+		t.Fatalf("loadFromPath failed: %v", err)
+	}
+	if !settings.Fzf.DisableBindings {
+		t.Error("Expected fzf.disable_bindings to be true")
+	}
+	if settings.Fzf.TmuxPopup != "90%,70%" {
+		t.Errorf("fzf.tmux_popup = %q, want 90%%,70%%", settings.Fzf.TmuxPopup)
+	}
+}
+
+func TestLoadFromPathInvalidYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	if err := os.WriteFile(path, []byte("accessible: [unterminated\n"), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	settings, err := loadFromPath(path)
+	if err == nil {
+		t.Error("Expected error for invalid YAML")
+	}
+	if settings == nil {
+		t.Error("Expected default settings on error")
+	}
+}
+
+func TestLoadFromPathFormats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	data := "list_format: '{{.Marker}} {{.Project}} {{.Name}}'\nactive_marker: '→'\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	settings, err := loadFromPath(path)
+	if err != nil {
+		t.Fatalf("loadFromPath failed: %v", err)
+	}
+	if settings.ListTemplate() == nil {
+		t.Error("Expected list_format to be parsed")
+	}
+	if settings.PickerTemplate() != nil {
+		t.Error("Expected no picker template when picker_format is unset")
+	}
+	if settings.ActiveMarker != "→" {
+		t.Errorf("ActiveMarker = %q, want →", settings.ActiveMarker)
+	}
+}
+
+func TestLoadFromPathInvalidFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	data := "accessible: true\nlist_format: '{{.Marker}} {{.Nmae}}'\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	settings, err := loadFromPath(path)
+	if err == nil {
+		t.Fatal("Expected error for invalid list_format")
+	}
+	want := "invalid list_format at line 1, column 15: at <.Nmae>: can't evaluate field Nmae"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %q, want it to contain %q", err, want)
+	}
+
+	// The other settings survive, and the broken template falls back to the default
+	if !settings.Accessible {
+		t.Error("Expected accessible to be kept")
+	}
+	if settings.ListFormat != "" || settings.ListTemplate() != nil {
+		t.Error("Expected the invalid list_format to be reset")
+	}
+}
+
+func TestLoadFromPathDisplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	data := "display:\n  prod: {color: red, emoji: \"🔥\"}\n  dev: {color: crimson, emoji: \"🧪\"}\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	settings, err := loadFromPath(path)
+	if err == nil || !strings.Contains(err.Error(), `invalid display color of "dev": unknown color "crimson"`) {
+		t.Fatalf("loadFromPath error = %v, want the unknown color reported", err)
+	}
+
+	// The invalid color is dropped; everything else is kept
+	if got := settings.Display["prod"]; got != (Display{Color: "red", Emoji: "🔥"}) {
+		t.Errorf("Display[prod] = %+v", got)
+	}
+	if got := settings.Display["dev"]; got != (Display{Emoji: "🧪"}) {
+		t.Errorf("Display[dev] = %+v, want the color reset and the emoji kept", got)
+	}
+}
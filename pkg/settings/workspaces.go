@@ -0,0 +1,122 @@
+package settings
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// workspacesKey is the settings key holding the workspaces
+const workspacesKey = "workspaces"
+
+// workspaceNameRegex matches valid workspace names
+var workspaceNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Workspace is a configuration with a project and region applied on top of it,
+// lighter than a configuration of its own per client
+type Workspace struct {
+	Configuration string `yaml:"configuration"`
+	Project       string `yaml:"project,omitempty"`
+	Region        string `yaml:"region,omitempty"`
+}
+
+// ValidateWorkspaceName checks that name can be saved as a workspace
+func ValidateWorkspaceName(name string) error {
+	if !workspaceNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid workspace name %q (use letters, digits, '.', '_' and '-', starting with a letter or digit)", name)
+	}
+	return nil
+}
+
+// SaveWorkspace saves workspace under name in the settings file, replacing a
+// workspace of the same name
+// It reports whether a workspace was replaced
+func SaveWorkspace(name string, workspace Workspace) (bool, error) {
+	path, err := GetSettingsFilePath()
+	if err != nil {
+		return false, err
+	}
+	return saveWorkspace(path, name, workspace)
+}
+
+// DeleteWorkspace removes the named workspace from the settings file
+// It reports whether the file changed, i.e. false when there was no such workspace
+func DeleteWorkspace(name string) (bool, error) {
+	path, err := GetSettingsFilePath()
+	if err != nil {
+		return false, err
+	}
+	return deleteWorkspace(path, name)
+}
+
+// saveWorkspace saves workspace under name in the settings file at path
+// The file is edited as a YAML node tree so comments and other settings survive
+func saveWorkspace(path, name string, workspace Workspace) (bool, error) {
+	if err := ValidateWorkspaceName(name); err != nil {
+		return false, err
+	}
+
+	doc, err := readDocument(path)
+	if err != nil {
+		return false, err
+	}
+	root := doc.Content[0]
+
+	workspaces := mappingValue(root, workspacesKey)
+	if workspaces == nil {
+		workspaces = &yaml.Node{}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: workspacesKey}, workspaces)
+	}
+	if workspaces.Kind != yaml.MappingNode {
+		// Replaces an empty value such as "workspaces:"
+		*workspaces = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	var value yaml.Node
+	if err := value.Encode(workspace); err != nil {
+		return false, fmt.Errorf("failed to encode workspace: %w", err)
+	}
+
+	replaced := false
+	if existing := mappingValue(workspaces, name); existing != nil {
+		*existing = value
+		replaced = true
+	} else {
+		workspaces.Content = append(workspaces.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, &value)
+	}
+
+	if err := writeDocument(path, doc); err != nil {
+		return false, err
+	}
+	return replaced, nil
+}
+
+// deleteWorkspace removes the named workspace from the settings file at path
+func deleteWorkspace(path, name string) (bool, error) {
+	doc, err := readDocument(path)
+	if err != nil {
+		return false, err
+	}
+
+	workspaces := mappingValue(doc.Content[0], workspacesKey)
+	if workspaces == nil || workspaces.Kind != yaml.MappingNode {
+		return false, nil
+	}
+	index := -1
+	for i := 0; i+1 < len(workspaces.Content); i += 2 {
+		if workspaces.Content[i].Value == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return false, nil
+	}
+	workspaces.Content = append(workspaces.Content[:index], workspaces.Content[index+2:]...)
+
+	if err := writeDocument(path, doc); err != nil {
+		return false, err
+	}
+	return true, nil
+}
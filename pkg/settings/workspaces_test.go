@@ -0,0 +1,96 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveWorkspace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	original := "# my settings\nprotected: [prod] # careful\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	replaced, err := saveWorkspace(path, "acme", Workspace{Configuration: "dev", Project: "acme-dev", Region: "europe-west1"})
+	if err != nil || replaced {
+		t.Fatalf("saveWorkspace(acme) = %v, %v; want false, nil", replaced, err)
+	}
+	if _, err := saveWorkspace(path, "globex", Workspace{Configuration: "prod"}); err != nil {
+		t.Fatalf("saveWorkspace(globex) error = %v", err)
+	}
+	replaced, err = saveWorkspace(path, "acme", Workspace{Configuration: "dev", Project: "acme-staging"})
+	if err != nil || !replaced {
+		t.Fatalf("saveWorkspace(acme) again = %v, %v; want true, nil", replaced, err)
+	}
+
+	settings, err := loadFromPath(path)
+	if err != nil {
+		t.Fatalf("loadFromPath failed: %v", err)
+	}
+	if !settings.IsProtected("prod") {
+		t.Error("other settings were lost")
+	}
+	want := map[string]Workspace{
+		"acme":   {Configuration: "dev", Project: "acme-staging"},
+		"globex": {Configuration: "prod"},
+	}
+	if len(settings.Workspaces) != len(want) {
+		t.Fatalf("Workspaces = %+v, want %+v", settings.Workspaces, want)
+	}
+	for name, workspace := range want {
+		if settings.Workspaces[name] != workspace {
+			t.Errorf("Workspaces[%q] = %+v, want %+v", name, settings.Workspaces[name], workspace)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# my settings") || !strings.Contains(string(data), "# careful") {
+		t.Errorf("comments were lost:\n%s", data)
+	}
+}
+
+func TestSaveWorkspaceInvalidName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	for _, name := range []string{"", "-", "my workspace", ".hidden"} {
+		if _, err := saveWorkspace(path, name, Workspace{Configuration: "dev"}); err == nil {
+			t.Errorf("saveWorkspace(%q) succeeded, want an error", name)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("an invalid name created the settings file")
+	}
+}
+
+func TestDeleteWorkspace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+
+	changed, err := deleteWorkspace(path, "acme")
+	if err != nil || changed {
+		t.Errorf("deleteWorkspace on a missing file = %v, %v; want false, nil", changed, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("deleting created the settings file")
+	}
+
+	for _, name := range []string{"acme", "globex"} {
+		if _, err := saveWorkspace(path, name, Workspace{Configuration: "dev"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changed, err = deleteWorkspace(path, "acme")
+	if err != nil || !changed {
+		t.Fatalf("deleteWorkspace(acme) = %v, %v; want true, nil", changed, err)
+	}
+	changed, err = deleteWorkspace(path, "acme")
+	if err != nil || changed {
+		t.Errorf("deleteWorkspace(acme) again = %v, %v; want false, nil", changed, err)
+	}
+
+	settings, _ := loadFromPath(path)
+	if _, ok := settings.Workspaces["globex"]; !ok || len(settings.Workspaces) != 1 {
+		t.Errorf("Workspaces = %+v, want only globex", settings.Workspaces)
+	}
+}
@@ -0,0 +1,116 @@
+// Package shellenv renders environment variable statements for interactive shells.
+// It is used by 'gcloudctx env' to scope a configuration to a single terminal
+// through variables such as CLOUDSDK_ACTIVE_CONFIG_NAME, without changing the
+// globally active gcloud configuration.
+package shellenv
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Shell identifies the syntax of the generated statements
+type Shell string
+
+// Supported shells
+const (
+	Bash       Shell = "bash"
+	Zsh        Shell = "zsh"
+	Fish       Shell = "fish"
+	PowerShell Shell = "powershell"
+)
+
+// Shells lists the supported shells in the order shown to users
+var Shells = []Shell{Bash, Zsh, Fish, PowerShell}
+
+// Var is an environment variable assignment
+type Var struct {
+	Name  string
+	Value string
+}
+
+// varNamePattern matches names every supported shell accepts unquoted
+var varNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseShell returns the shell named by s, accepting "pwsh" for PowerShell
+func ParseShell(s string) (Shell, error) {
+	switch strings.ToLower(s) {
+	case "bash", "sh":
+		return Bash, nil
+	case "zsh":
+		return Zsh, nil
+	case "fish":
+		return Fish, nil
+	case "powershell", "pwsh":
+		return PowerShell, nil
+	default:
+		return "", fmt.Errorf("unsupported shell: %s (supported: bash, zsh, fish, powershell)", s)
+	}
+}
+
+// DetectShell guesses the shell from a $SHELL value, defaulting to bash
+func DetectShell(shellPath string) Shell {
+	name := strings.TrimSuffix(filepath.Base(shellPath), ".exe")
+	if shell, err := ParseShell(name); err == nil {
+		return shell
+	}
+	return Bash
+}
+
+// Set returns the statements assigning vars in the given shell, one per line
+func Set(shell Shell, vars []Var) (string, error) {
+	var b strings.Builder
+	for _, v := range vars {
+		if !varNamePattern.MatchString(v.Name) {
+			return "", fmt.Errorf("invalid environment variable name %q", v.Name)
+		}
+
+		switch shell {
+		case Fish:
+			fmt.Fprintf(&b, "set -gx %s %s;\n", v.Name, fishQuote(v.Value))
+		case PowerShell:
+			fmt.Fprintf(&b, "$env:%s = %s\n", v.Name, powerShellQuote(v.Value))
+		default:
+			fmt.Fprintf(&b, "export %s=%s\n", v.Name, posixQuote(v.Value))
+		}
+	}
+	return b.String(), nil
+}
+
+// Unset returns the statements removing the named variables in the given shell
+func Unset(shell Shell, names []string) (string, error) {
+	var b strings.Builder
+	for _, name := range names {
+		if !varNamePattern.MatchString(name) {
+			return "", fmt.Errorf("invalid environment variable name %q", name)
+		}
+
+		switch shell {
+		case Fish:
+			fmt.Fprintf(&b, "set -e %s;\n", name)
+		case PowerShell:
+			fmt.Fprintf(&b, "Remove-Item Env:%s -ErrorAction SilentlyContinue\n", name)
+		default:
+			fmt.Fprintf(&b, "unset %s\n", name)
+		}
+	}
+	return b.String(), nil
+}
+
+// posixQuote single-quotes s for bash and zsh; single quotes disable every expansion
+func posixQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes s for fish, where only backslash and single quote are special
+func fishQuote(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + replacer.Replace(s) + "'"
+}
+
+// powerShellQuote single-quotes s for PowerShell, where a quote is escaped by doubling it
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
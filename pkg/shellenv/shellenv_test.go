@@ -0,0 +1,78 @@
+package shellenv
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/golden"
+)
+
+func goldenVars() []Var {
+	return []Var{
+		{Name: "CLOUDSDK_ACTIVE_CONFIG_NAME", Value: "prod"},
+		{Name: "CLOUDSDK_CORE_PROJECT", Value: "it's-$weird`proj`\\"},
+	}
+}
+
+func TestSetGolden(t *testing.T) {
+	for _, shell := range Shells {
+		t.Run(string(shell), func(t *testing.T) {
+			got, err := Set(shell, goldenVars())
+			if err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+			golden.Assert(t, filepath.Join("testdata", "set", string(shell)+".golden"), got)
+		})
+	}
+}
+
+func TestUnsetGolden(t *testing.T) {
+	names := []string{"CLOUDSDK_ACTIVE_CONFIG_NAME", "CLOUDSDK_CORE_PROJECT"}
+	for _, shell := range Shells {
+		t.Run(string(shell), func(t *testing.T) {
+			got, err := Unset(shell, names)
+			if err != nil {
+				t.Fatalf("Unset failed: %v", err)
+			}
+			golden.Assert(t, filepath.Join("testdata", "unset", string(shell)+".golden"), got)
+		})
+	}
+}
+
+func TestSetInvalidName(t *testing.T) {
+	if _, err := Set(Bash, []Var{{Name: "X; rm -rf ~", Value: "v"}}); err == nil {
+		t.Error("Set() should reject invalid variable names")
+	}
+	if _, err := Unset(Fish, []string{"1X"}); err == nil {
+		t.Error("Unset() should reject invalid variable names")
+	}
+}
+
+func TestParseShell(t *testing.T) {
+	tests := map[string]Shell{"bash": Bash, "sh": Bash, "ZSH": Zsh, "fish": Fish, "pwsh": PowerShell, "powershell": PowerShell}
+	for input, want := range tests {
+		got, err := ParseShell(input)
+		if err != nil || got != want {
+			t.Errorf("ParseShell(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseShell("tcsh"); err == nil {
+		t.Error("ParseShell(tcsh) should fail")
+	}
+}
+
+func TestDetectShell(t *testing.T) {
+	tests := map[string]Shell{
+		"/bin/zsh":            Zsh,
+		"/usr/local/bin/fish": Fish,
+		"/bin/bash":           Bash,
+		"pwsh.exe":            PowerShell,
+		"/bin/tcsh":           Bash,
+		"":                    Bash,
+	}
+	for input, want := range tests {
+		if got := DetectShell(input); got != want {
+			t.Errorf("DetectShell(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
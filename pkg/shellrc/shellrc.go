@@ -0,0 +1,225 @@
+// Package shellrc manages gcloudctx-owned blocks in shell rc files.
+// Every snippet gcloudctx writes into an rc file is wrapped in begin/end marker
+// comments so it can later be found, replaced, or removed without touching
+// anything the user wrote themselves.
+package shellrc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Marker comments delimiting a managed block; %s is the block name
+const (
+	beginMarkerFormat = "# >>> gcloudctx %s >>>"
+	endMarkerFormat   = "# <<< gcloudctx %s <<<"
+)
+
+var (
+	beginMarkerPattern = regexp.MustCompile(`^\s*# >>> gcloudctx (\S+) >>>\s*$`)
+	endMarkerPattern   = regexp.MustCompile(`^\s*# <<< gcloudctx (\S+) <<<\s*$`)
+	// referencePattern matches lines mentioning gcloudctx in any case, e.g.
+	// 'eval "$(gcloudctx widget zsh)"', 'export GCLOUDCTX_SESSION=$$' or
+	// PowerShell's Set-LocationGcloudctx
+	referencePattern = regexp.MustCompile(`(?i)gcloudctx`)
+)
+
+// Block is a managed block found in an rc file
+type Block struct {
+	Name      string
+	StartLine int // 1-based line of the begin marker
+	EndLine   int // 1-based line of the end marker
+}
+
+// BeginMarker returns the begin marker line of the named block
+func BeginMarker(name string) string {
+	return fmt.Sprintf(beginMarkerFormat, name)
+}
+
+// EndMarker returns the end marker line of the named block
+func EndMarker(name string) string {
+	return fmt.Sprintf(endMarkerFormat, name)
+}
+
+// FindBlocks returns the managed blocks in content
+// A begin marker without a matching end marker is ignored so a damaged
+// block never causes the rest of the file to be treated as ours
+func FindBlocks(content string) []Block {
+	var blocks []Block
+	var open *Block
+
+	for i, line := range strings.Split(content, "\n") {
+		if m := beginMarkerPattern.FindStringSubmatch(line); m != nil {
+			open = &Block{Name: m[1], StartLine: i + 1}
+			continue
+		}
+		if m := endMarkerPattern.FindStringSubmatch(line); m != nil && open != nil && m[1] == open.Name {
+			open.EndLine = i + 1
+			blocks = append(blocks, *open)
+			open = nil
+		}
+	}
+
+	return blocks
+}
+
+// FindReferences returns the 1-based numbers of the lines outside managed
+// blocks that mention gcloudctx, such as snippets pasted from the README
+// Comment lines are skipped. These lines were written by the user, so they are
+// only ever reported, never removed.
+func FindReferences(content string) []int {
+	blocks := FindBlocks(content)
+	inBlock := func(line int) bool {
+		for _, block := range blocks {
+			if line >= block.StartLine && line <= block.EndLine {
+				return true
+			}
+		}
+		return false
+	}
+
+	var lines []int
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") || !referencePattern.MatchString(trimmed) || inBlock(i+1) {
+			continue
+		}
+		lines = append(lines, i+1)
+	}
+	return lines
+}
+
+// RemoveBlocks removes every managed block from content and returns the result
+// with the number of blocks removed
+func RemoveBlocks(content string) (string, int) {
+	blocks := FindBlocks(content)
+	if len(blocks) == 0 {
+		return content, 0
+	}
+
+	lines := strings.Split(content, "\n")
+	var kept []string
+	next := 0
+	for _, block := range blocks {
+		kept = append(kept, lines[next:block.StartLine-1]...)
+		next = block.EndLine
+	}
+	kept = append(kept, lines[next:]...)
+
+	return strings.Join(kept, "\n"), len(blocks)
+}
+
+// UpsertBlock replaces the body of the named block, or appends the block when it is missing
+func UpsertBlock(content, name, body string) string {
+	block := BeginMarker(name) + "\n" + strings.TrimRight(body, "\n") + "\n" + EndMarker(name)
+
+	for _, existing := range FindBlocks(content) {
+		if existing.Name != name {
+			continue
+		}
+		lines := strings.Split(content, "\n")
+		updated := append([]string{}, lines[:existing.StartLine-1]...)
+		updated = append(updated, block)
+		updated = append(updated, lines[existing.EndLine:]...)
+		return strings.Join(updated, "\n")
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + block + "\n"
+}
+
+// RemoveBlocksFromFile removes every managed block from the file at path
+// A backup copy is written with WriteBackup before the file is modified
+func RemoveBlocksFromFile(path string) (removed int, backupPath string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	updated, removed := RemoveBlocks(string(data))
+	if removed == 0 {
+		return 0, "", nil
+	}
+
+	backupPath, err = WriteBackup(path, data, info.Mode().Perm())
+	if err != nil {
+		return 0, "", err
+	}
+
+	if err := os.WriteFile(path, []byte(updated), info.Mode().Perm()); err != nil {
+		return 0, backupPath, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return removed, backupPath, nil
+}
+
+// WriteBackup writes data to a new backup file next to path, named after it and
+// the current time (e.g. ".zshrc.20250101-120000.bak"), and returns its path
+// An existing file, such as an earlier backup, is never overwritten
+func WriteBackup(path string, data []byte, perm os.FileMode) (string, error) {
+	base := path + "." + time.Now().Format("20060102-150405")
+	for n := 1; ; n++ {
+		backupPath := base + ".bak"
+		if n > 1 {
+			backupPath = fmt.Sprintf("%s-%d.bak", base, n)
+		}
+
+		f, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write backup %s: %w", backupPath, err)
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(backupPath)
+			return "", fmt.Errorf("failed to write backup %s: %w", backupPath, err)
+		}
+		return backupPath, nil
+	}
+}
+
+// powerShellProfile is the file name of PowerShell's current-user, current-host profile
+const powerShellProfile = "Microsoft.PowerShell_profile.ps1"
+
+// DefaultRCFiles returns the shell rc files under home that exist
+func DefaultRCFiles(home string) []string {
+	candidates := []string{
+		".bashrc",
+		".bash_profile",
+		".bash_aliases",
+		".profile",
+		".zshrc",
+		".zprofile",
+		filepath.Join(".config", "fish", "config.fish"),
+		// PowerShell profiles: pwsh on Linux and macOS, then pwsh and Windows PowerShell on Windows
+		filepath.Join(".config", "powershell", powerShellProfile),
+		filepath.Join("Documents", "PowerShell", powerShellProfile),
+		filepath.Join("Documents", "WindowsPowerShell", powerShellProfile),
+	}
+
+	var files []string
+	for _, name := range candidates {
+		path := filepath.Join(home, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	return files
+}
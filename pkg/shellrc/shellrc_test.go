@@ -0,0 +1,163 @@
+package shellrc
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const rcWithBlocks = `export PATH="$HOME/bin:$PATH"
+# >>> gcloudctx hook >>>
+cd() { builtin cd "$@" && gcloudctx auto 2>/dev/null; }
+# <<< gcloudctx hook <<<
+alias k=kubectl
+# >>> gcloudctx completion >>>
+source <(gcloudctx completion bash)
+# <<< gcloudctx completion <<<
+`
+
+func TestFindBlocks(t *testing.T) {
+	blocks := FindBlocks(rcWithBlocks)
+	want := []Block{
+		{Name: "hook", StartLine: 2, EndLine: 4},
+		{Name: "completion", StartLine: 6, EndLine: 8},
+	}
+
+	if len(blocks) != len(want) {
+		t.Fatalf("FindBlocks() returned %d blocks, want %d", len(blocks), len(want))
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("block %d = %+v, want %+v", i, blocks[i], want[i])
+		}
+	}
+}
+
+func TestFindBlocksIgnoresUnterminated(t *testing.T) {
+	content := "# >>> gcloudctx hook >>>\necho hi\n# <<< gcloudctx other <<<\n"
+	if blocks := FindBlocks(content); len(blocks) != 0 {
+		t.Errorf("expected no blocks for unterminated marker, got %+v", blocks)
+	}
+}
+
+func TestRemoveBlocks(t *testing.T) {
+	got, removed := RemoveBlocks(rcWithBlocks)
+	if removed != 2 {
+		t.Errorf("RemoveBlocks() removed %d blocks, want 2", removed)
+	}
+
+	want := "export PATH=\"$HOME/bin:$PATH\"\nalias k=kubectl\n"
+	if got != want {
+		t.Errorf("RemoveBlocks() = %q, want %q", got, want)
+	}
+}
+
+func TestUpsertBlock(t *testing.T) {
+	t.Run("appends missing block", func(t *testing.T) {
+		got := UpsertBlock("alias k=kubectl", "hook", "eval \"$(gcloudctx hook)\"\n")
+		want := "alias k=kubectl\n# >>> gcloudctx hook >>>\neval \"$(gcloudctx hook)\"\n# <<< gcloudctx hook <<<\n"
+		if got != want {
+			t.Errorf("UpsertBlock() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("replaces existing block", func(t *testing.T) {
+		got := UpsertBlock(rcWithBlocks, "hook", "new body")
+		blocks := FindBlocks(got)
+		if len(blocks) != 2 {
+			t.Fatalf("expected 2 blocks after upsert, got %d", len(blocks))
+		}
+		want := "export PATH=\"$HOME/bin:$PATH\"\n# >>> gcloudctx hook >>>\nnew body\n# <<< gcloudctx hook <<<\nalias k=kubectl\n"
+		if got[:len(want)] != want {
+			t.Errorf("UpsertBlock() = %q, want prefix %q", got, want)
+		}
+	})
+}
+
+func TestRemoveBlocksFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".bashrc")
+	if err := os.WriteFile(path, []byte(rcWithBlocks), 0o644); err != nil {
+		t.Fatalf("failed to write rc file: %v", err)
+	}
+
+	removed, backupPath, err := RemoveBlocksFromFile(path)
+	if err != nil {
+		t.Fatalf("RemoveBlocksFromFile failed: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+
+	backup, err := os.ReadFile(backupPath)
+	if err != nil {
+		t.Fatalf("failed to read backup: %v", err)
+	}
+	if string(backup) != rcWithBlocks {
+		t.Error("backup does not match the original file")
+	}
+
+	// A second pass has nothing to remove and writes no backup
+	removed, backupPath, err = RemoveBlocksFromFile(path)
+	if err != nil || removed != 0 || backupPath != "" {
+		t.Errorf("second pass = (%d, %q, %v), want (0, \"\", nil)", removed, backupPath, err)
+	}
+}
+
+func TestFindReferences(t *testing.T) {
+	content := rcWithBlocks + `# gcloudctx widget, see the README
+export GCLOUDCTX_SESSION=$$
+eval "$(gcloudctx widget zsh)"
+function Set-LocationGcloudctx { Set-Location @args }
+`
+	got := FindReferences(content)
+	want := []int{10, 11, 12}
+	if !slices.Equal(got, want) {
+		t.Errorf("FindReferences() = %v, want %v", got, want)
+	}
+}
+
+func TestWriteBackupKeepsExistingFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zshrc")
+
+	first, err := WriteBackup(path, []byte("first"), 0o600)
+	if err != nil {
+		t.Fatalf("WriteBackup failed: %v", err)
+	}
+	second, err := WriteBackup(path, []byte("second"), 0o600)
+	if err != nil {
+		t.Fatalf("WriteBackup failed: %v", err)
+	}
+	if first == second {
+		t.Fatalf("both backups were written to %s", first)
+	}
+	for backupPath, want := range map[string]string{first: "first", second: "second"} {
+		if data, err := os.ReadFile(backupPath); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", backupPath, data, err, want)
+		}
+	}
+}
+
+func TestDefaultRCFiles(t *testing.T) {
+	home := t.TempDir()
+	if err := os.WriteFile(filepath.Join(home, ".zshrc"), nil, 0o644); err != nil {
+		t.Fatalf("failed to write rc file: %v", err)
+	}
+
+	files := DefaultRCFiles(home)
+	if len(files) != 1 || files[0] != filepath.Join(home, ".zshrc") {
+		t.Errorf("DefaultRCFiles() = %v, want only .zshrc", files)
+	}
+
+	profile := filepath.Join(home, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1")
+	if err := os.MkdirAll(filepath.Dir(profile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(profile, nil, 0o644); err != nil {
+		t.Fatalf("failed to write profile: %v", err)
+	}
+	files = DefaultRCFiles(home)
+	if len(files) != 2 || files[1] != profile {
+		t.Errorf("DefaultRCFiles() = %v, want .zshrc and the PowerShell profile", files)
+	}
+}
@@ -0,0 +1,58 @@
+// Package statedir tells failures to read or write gcloudctx's state apart
+// when they come from the directories holding it being unusable.
+// In minimal containers HOME may be unset or point to a directory that does
+// not exist, and distroless images often have a read-only home. gcloudctx
+// works without its history, cache and settings there, so such failures are
+// expected: Warn keeps quiet about them unless verbose output was asked for.
+package statedir
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync/atomic"
+	"syscall"
+)
+
+// verbose is whether unavailable state is noted; see SetVerbose
+var verbose atomic.Bool
+
+// noted is whether unavailable state was noted since SetVerbose
+var noted atomic.Bool
+
+// SetVerbose makes Warn note, once per command, that state is unavailable
+func SetVerbose(on bool) {
+	verbose.Store(on)
+	noted.Store(false)
+}
+
+// Unavailable reports whether err comes from the state directories being
+// unusable: the home or cache directory is not set or does not exist, is
+// not a directory, or cannot be written
+func Unavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, homeErr := os.UserHomeDir(); homeErr != nil {
+		return true
+	}
+	if _, cacheErr := os.UserCacheDir(); cacheErr != nil {
+		return true
+	}
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) ||
+		errors.Is(err, syscall.ENOTDIR) || errors.Is(err, syscall.EROFS)
+}
+
+// Warn prints err as a warning on w, unless the state directories are
+// unavailable; then only one note is printed per command, and only when verbose
+func Warn(w io.Writer, err error) {
+	if !Unavailable(err) {
+		fmt.Fprintf(w, "Warning: %v\n", err)
+		return
+	}
+	if verbose.Load() && noted.CompareAndSwap(false, true) {
+		fmt.Fprintf(w, "Note: gcloudctx state is unavailable and not saved: %v\n", err)
+	}
+}
@@ -0,0 +1,60 @@
+package statedir
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestUnavailable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	_, notDir := os.ReadFile(filepath.Join(os.Args[0], "state"))
+	tests := map[error]bool{
+		nil: false,
+		fmt.Errorf("failed to save history: %w", fs.ErrPermission):   true,
+		fmt.Errorf("failed to lock: %w", fs.ErrNotExist):             true,
+		&fs.PathError{Op: "open", Path: "/home", Err: syscall.EROFS}: true,
+		notDir:                                 true,
+		errors.New("failed to parse settings"): false,
+	}
+	for err, want := range tests {
+		if got := Unavailable(err); got != want {
+			t.Errorf("Unavailable(%v) = %v, want %v", err, got, want)
+		}
+	}
+
+	t.Setenv("HOME", "")
+	if !Unavailable(errors.New("failed to get home directory")) {
+		t.Error("Unavailable() = false without a home directory, want true")
+	}
+}
+
+func TestWarn(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Cleanup(func() { SetVerbose(false) })
+	unavailable := fmt.Errorf("failed to save history: %w", fs.ErrPermission)
+
+	var out strings.Builder
+	SetVerbose(false)
+	Warn(&out, errors.New("failed to parse settings"))
+	Warn(&out, unavailable)
+	if out.String() != "Warning: failed to parse settings\n" {
+		t.Errorf("Warn() printed %q, want only the other warning", out.String())
+	}
+
+	out.Reset()
+	SetVerbose(true)
+	Warn(&out, unavailable)
+	Warn(&out, unavailable)
+	if got := strings.Count(out.String(), "Note: "); got != 1 || !strings.Contains(out.String(), "permission denied") {
+		t.Errorf("Warn() with verbose printed %q, want one note", out.String())
+	}
+}
@@ -0,0 +1,81 @@
+// Package statelock serializes updates to gcloudctx's state across processes.
+// A switch reads the active configuration, activates another one and writes the
+// history; holding the lock around that sequence keeps two terminals switching
+// at the same time from recording a history that matches neither switch.
+package statelock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const lockFileName = ".gcloudctx.lock"
+
+// DefaultWait is how long callers typically wait for another holder
+const DefaultWait = 10 * time.Second
+
+// retryInterval is how often a held lock is retried
+const retryInterval = 25 * time.Millisecond
+
+// ErrLocked is returned when the lock is still held when the context is done
+var ErrLocked = errors.New("another gcloudctx process is updating its state")
+
+// Lock is a held state lock
+type Lock struct {
+	path    string
+	release func() error
+}
+
+// GetLockFilePath returns the path of the state lock in the home directory
+func GetLockFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return LockFilePathIn(homeDir), nil
+}
+
+// LockFilePathIn returns the path of the state lock kept in a state directory
+func LockFilePathIn(dir string) string {
+	return filepath.Join(dir, lockFileName)
+}
+
+// Acquire takes the lock at path, waiting until it is free or ctx is done
+// The returned error wraps ErrLocked when ctx ended while another process held the lock
+func Acquire(ctx context.Context, path string) (*Lock, error) {
+	for {
+		release, err := tryLock(path)
+		if err == nil {
+			return &Lock{path: path, release: release}, nil
+		}
+		if !errors.Is(err, errHeld) {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w (lock file %s)", ErrLocked, path)
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// Unlock releases the lock; it is safe to call more than once
+func (l *Lock) Unlock() error {
+	if l == nil || l.release == nil {
+		return nil
+	}
+	release := l.release
+	l.release = nil
+	if err := release(); err != nil {
+		return fmt.Errorf("failed to unlock %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// errHeld is returned by tryLock when another holder has the lock
+var errHeld = errors.New("lock held")
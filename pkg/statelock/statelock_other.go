@@ -0,0 +1,32 @@
+//go:build !unix && !windows
+
+package statelock
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// staleAfter is how old a lock file must be before it is considered abandoned
+// by a process that crashed while holding it
+const staleAfter = time.Minute
+
+// tryLock creates path exclusively; the lock is held while the file exists
+func tryLock(path string) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleAfter {
+			_ = os.Remove(path)
+		}
+		return nil, errHeld
+	}
+	f.Close()
+
+	return func() error {
+		return os.Remove(path)
+	}, nil
+}
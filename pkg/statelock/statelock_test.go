@@ -0,0 +1,79 @@
+package statelock
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAcquireExcludesOtherHolders(t *testing.T) {
+	path := LockFilePathIn(t.TempDir())
+
+	lock, err := Acquire(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := Acquire(ctx, path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second Acquire error = %v, want ErrLocked", err)
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Errorf("second Unlock failed: %v", err)
+	}
+
+	again, err := Acquire(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Acquire after Unlock failed: %v", err)
+	}
+	_ = again.Unlock()
+}
+
+func TestAcquireSerializesCriticalSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		inside  int
+		overlap bool
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := Acquire(context.Background(), path)
+			if err != nil {
+				t.Errorf("Acquire failed: %v", err)
+				return
+			}
+			defer lock.Unlock()
+
+			mu.Lock()
+			inside++
+			if inside > 1 {
+				overlap = true
+			}
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			inside--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if overlap {
+		t.Error("two holders were inside the critical section at once")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+package statelock
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// tryLock takes an advisory flock on path without blocking
+// The kernel drops the lock when the process exits, so a crash never leaves it held
+func tryLock(path string) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, unix.EWOULDBLOCK) {
+			return nil, errHeld
+		}
+		return nil, err
+	}
+
+	return func() error {
+		// Closing the descriptor releases the flock
+		return f.Close()
+	}, nil
+}
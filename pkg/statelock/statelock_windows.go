@@ -0,0 +1,36 @@
+//go:build windows
+
+package statelock
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// tryLock takes an exclusive byte-range lock on path without blocking
+// Windows releases the lock when the handle is closed or the process exits,
+// so a crash never leaves it held
+func tryLock(path string) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	handle := windows.Handle(f.Fd())
+	ol := new(windows.Overlapped)
+	flags := uint32(windows.LOCKFILE_EXCLUSIVE_LOCK | windows.LOCKFILE_FAIL_IMMEDIATELY)
+	if err := windows.LockFileEx(handle, flags, 0, 1, 0, ol); err != nil {
+		f.Close()
+		if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+			return nil, errHeld
+		}
+		return nil, err
+	}
+
+	return func() error {
+		_ = windows.UnlockFileEx(handle, 0, 1, 0, ol)
+		return f.Close()
+	}, nil
+}
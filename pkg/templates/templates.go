@@ -0,0 +1,220 @@
+// Package templates renders configuration presets shared by a team.
+// A template is a file in the export format (see package configfile) whose
+// values may contain Go template placeholders such as {{.User}} and
+// {{.Param "env"}}; rendering it yields the properties of a new configuration.
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
+	"gopkg.in/yaml.v3"
+)
+
+// templatesDirName is the templates directory relative to the home directory
+var templatesDirName = filepath.Join(".config", "gcloudctx", "templates")
+
+// templateExtensions are the file extensions recognized as templates, in lookup order
+var templateExtensions = []string{".yaml", ".yml"}
+
+// ErrNotFound is returned by Load for templates that do not exist
+var ErrNotFound = errors.New("template not found")
+
+// Template is a template file
+type Template struct {
+	Name string
+	Path string
+	// Source is the unrendered file content
+	Source []byte
+}
+
+// Data is what placeholders of a template can refer to
+type Data struct {
+	// Name is the name of the configuration being created
+	Name string
+	// User is the login name of the current user
+	User string
+	// Params holds the values given with --param or at the prompt
+	Params map[string]string
+}
+
+// Param returns the value of a parameter, failing the render when it is missing
+func (d Data) Param(name string) (string, error) {
+	value, ok := d.Params[name]
+	if !ok {
+		return "", fmt.Errorf("missing parameter %q", name)
+	}
+	return value, nil
+}
+
+// GetTemplatesDir returns the directory templates are read from
+func GetTemplatesDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, templatesDirName), nil
+}
+
+// CurrentUser returns the login name {{.User}} expands to, without any Windows domain
+func CurrentUser() (string, error) {
+	current, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current user: %w", err)
+	}
+	name := current.Username
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return name, nil
+}
+
+// List returns the templates in dir sorted by name
+// A missing directory has no templates
+func List(dir string) ([]Template, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+
+	var list []Template
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		name := strings.TrimSuffix(entry.Name(), ext)
+		if entry.IsDir() || !slices.Contains(templateExtensions, ext) || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		tmpl, err := Load(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *tmpl)
+	}
+
+	slices.SortFunc(list, func(a, b Template) int { return strings.Compare(a.Name, b.Name) })
+	return list, nil
+}
+
+// Load reads the named template from dir
+func Load(dir, name string) (*Template, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+
+	for _, ext := range templateExtensions {
+		path := filepath.Join(dir, name+ext)
+		source, err := os.ReadFile(path)
+		if err == nil {
+			return &Template{Name: name, Path: path, Source: source}, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read template %q: %w", name, err)
+		}
+	}
+	return nil, fmt.Errorf("%w: %q (looked in %s)", ErrNotFound, name, dir)
+}
+
+// parse parses the placeholders of the template
+func (t *Template) parse() (*template.Template, error) {
+	parsed, err := template.New(t.Name).Option("missingkey=error").Parse(string(t.Source))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %w", t.Name, err)
+	}
+	return parsed, nil
+}
+
+// Params returns the parameters the template asks for, in the order they first appear
+// Parameters only used in branches the given data does not take are not reported
+func (t *Template) Params(data Data) ([]string, error) {
+	parsed, err := t.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	// Render with every parameter answered by a recorder
+	recorder := &paramRecorder{data: data}
+	if err := parsed.Execute(&bytes.Buffer{}, recorder); err != nil {
+		return nil, fmt.Errorf("failed to render template %q: %w", t.Name, err)
+	}
+	return recorder.names, nil
+}
+
+// paramRecorder stands in for Data while discovering parameters
+type paramRecorder struct {
+	data  Data
+	names []string
+}
+
+// Name, User, Params and Param mirror Data
+func (r *paramRecorder) Name() string              { return r.data.Name }
+func (r *paramRecorder) User() string              { return r.data.User }
+func (r *paramRecorder) Params() map[string]string { return r.data.Params }
+func (r *paramRecorder) Param(name string) string {
+	if !slices.Contains(r.names, name) {
+		r.names = append(r.names, name)
+	}
+	return r.data.Params[name]
+}
+
+// Render fills in the placeholders and decodes the result as an export file
+// Keys the export format does not know are rejected so typos are not silently dropped
+func (t *Template) Render(data Data) (*configfile.Config, error) {
+	parsed, err := t.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered bytes.Buffer
+	if err := parsed.Execute(&rendered, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %q: %w", t.Name, err)
+	}
+
+	var config configfile.Config
+	decoder := yaml.NewDecoder(&rendered)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("template %q renders to an empty configuration", t.Name)
+		}
+		return nil, fmt.Errorf("template %q does not render to a valid configuration: %w", t.Name, err)
+	}
+	return &config, nil
+}
+
+// Validate checks the rendered values of a template
+func Validate(resolved *configfile.Resolved) error {
+	for _, value := range []struct{ name, value string }{
+		{"account", resolved.Account},
+		{"project", resolved.Project},
+		{"region", resolved.Region},
+		{"zone", resolved.Zone},
+		{"impersonate_service_account", resolved.ImpersonateServiceAccount},
+	} {
+		if strings.ContainsAny(value.value, " \t\r\n") {
+			return fmt.Errorf("%s %q contains whitespace", value.name, value.value)
+		}
+	}
+
+	if resolved.Account != "" && !strings.Contains(resolved.Account, "@") {
+		return fmt.Errorf("account %q is not an email address", resolved.Account)
+	}
+	if resolved.Region != "" && resolved.Zone != "" && !strings.HasPrefix(resolved.Zone, resolved.Region+"-") {
+		return fmt.Errorf("zone %q is not in region %q", resolved.Zone, resolved.Region)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package templates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/pkg/configfile"
+)
+
+const teamDefault = `account: "{{.User}}@example.com"
+project: "team-{{.Param "env"}}"
+region: europe-west1
+zone: europe-west1-b
+{{- if eq (.Param "env") "prod"}}
+impersonate_service_account: "deployer@team-prod.iam.gserviceaccount.com"
+{{- end}}
+`
+
+func writeTemplate(t *testing.T, dir, file, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "team-default.yaml", teamDefault)
+	writeTemplate(t, dir, "sandbox.yml", "region: us-central1\n")
+	writeTemplate(t, dir, "notes.txt", "not a template")
+	if err := os.Mkdir(filepath.Join(dir, "archive.yaml"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := List(dir)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var names []string
+	for _, tmpl := range list {
+		names = append(names, tmpl.Name)
+	}
+	if want := []string{"sandbox", "team-default"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List() names = %v, want %v", names, want)
+	}
+
+	list, err = List(filepath.Join(dir, "missing"))
+	if err != nil || len(list) != 0 {
+		t.Errorf("List(missing dir) = %v, %v; want no templates", list, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "team-default.yaml", teamDefault)
+
+	tmpl, err := Load(dir, "team-default")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if tmpl.Path != filepath.Join(dir, "team-default.yaml") {
+		t.Errorf("Path = %q", tmpl.Path)
+	}
+
+	if _, err := Load(dir, "nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load(nope) error = %v, want ErrNotFound", err)
+	}
+	if _, err := Load(dir, "../team-default"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Load(../team-default) error = %v, want an invalid name error", err)
+	}
+}
+
+func TestParams(t *testing.T) {
+	tmpl := &Template{Name: "team-default", Source: []byte(teamDefault + `{{.Param "owner"}}{{.Param "env"}}` + "\n")}
+
+	params, err := tmpl.Params(Data{User: "alice"})
+	if err != nil {
+		t.Fatalf("Params failed: %v", err)
+	}
+	if want := []string{"env", "owner"}; !reflect.DeepEqual(params, want) {
+		t.Errorf("Params() = %v, want %v", params, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tmpl := &Template{Name: "team-default", Source: []byte(teamDefault)}
+
+	tests := []struct {
+		name string
+		env  string
+		want configfile.Config
+	}{
+		{
+			name: "staging",
+			env:  "staging",
+			want: configfile.Config{
+				Account: configfile.Literal("alice@example.com"),
+				Project: configfile.Literal("team-staging"),
+				Region:  configfile.Literal("europe-west1"),
+				Zone:    configfile.Literal("europe-west1-b"),
+			},
+		},
+		{
+			name: "prod impersonates",
+			env:  "prod",
+			want: configfile.Config{
+				Account: configfile.Literal("alice@example.com"),
+				Project: configfile.Literal("team-prod"),
+				Region:  configfile.Literal("europe-west1"),
+				Zone:    configfile.Literal("europe-west1-b"),
+
+				ImpersonateServiceAccount: configfile.Literal("deployer@team-prod.iam.gserviceaccount.com"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := tmpl.Render(Data{Name: "mine", User: "alice", Params: map[string]string{"env": tt.env}})
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if !reflect.DeepEqual(*config, tt.want) {
+				t.Errorf("Render() = %+v, want %+v", *config, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{name: "missing param", source: teamDefault, wantErr: `missing parameter "env"`},
+		{name: "bad placeholder", source: "project: {{.Param\n", wantErr: "invalid template"},
+		{name: "unknown key", source: "projcet: p\n", wantErr: "field projcet not found"},
+		{name: "empty", source: "{{/* nothing */}}", wantErr: "empty configuration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := &Template{Name: tt.name, Source: []byte(tt.source)}
+			_, err := tmpl.Render(Data{User: "alice"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Render() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolved configfile.Resolved
+		wantErr  bool
+	}{
+		{name: "valid", resolved: configfile.Resolved{Account: "a@example.com", Region: "europe-west1", Zone: "europe-west1-b"}},
+		{name: "empty", resolved: configfile.Resolved{}},
+		{name: "account without domain", resolved: configfile.Resolved{Account: "alice"}, wantErr: true},
+		{name: "zone outside region", resolved: configfile.Resolved{Region: "europe-west1", Zone: "us-east1-b"}, wantErr: true},
+		{name: "whitespace", resolved: configfile.Resolved{Project: "team staging"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(&tt.resolved); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
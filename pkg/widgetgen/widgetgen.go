@@ -0,0 +1,98 @@
+// Package widgetgen generates zsh and bash key bindings that open the
+// gcloudctx picker without leaving the command line being typed.
+// The bindings run 'gcloudctx --widget', which draws fzf below the prompt and
+// prints at most one line, shown under the prompt before it is redrawn.
+package widgetgen
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultKey is the key the widget is bound to when none is chosen
+const DefaultKey = "ctrl-g"
+
+// Shell identifies the shell a widget is generated for
+type Shell string
+
+// Supported shells
+const (
+	Zsh  Shell = "zsh"
+	Bash Shell = "bash"
+)
+
+// Shells lists the supported shells in the order shown to users
+var Shells = []Shell{Zsh, Bash}
+
+//go:embed templates
+var templateFiles embed.FS
+
+// templates are the parsed widget templates, by file name
+var templates = template.Must(template.ParseFS(templateFiles, "templates/*.tmpl"))
+
+// Options parameterize a generated widget
+type Options struct {
+	// Key is "ctrl-X" or "alt-X" for a letter X; empty uses DefaultKey
+	Key string
+}
+
+// values are what the widget templates are filled in with
+type values struct {
+	Key     string
+	Command string
+	ZshKey  string
+	BashKey string
+}
+
+// Generate returns the widget script for shell
+func Generate(shell Shell, opts Options) (string, error) {
+	key := opts.Key
+	if key == "" {
+		key = DefaultKey
+	}
+	modifier, letter, err := parseKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	key = modifier + "-" + letter
+	v := values{Key: key, Command: "gcloudctx widget " + string(shell)}
+	if key != DefaultKey {
+		v.Command += " --key " + key
+	}
+	switch modifier {
+	case "ctrl":
+		v.ZshKey = "^" + strings.ToUpper(letter)
+		v.BashKey = `\C-` + letter
+	case "alt":
+		v.ZshKey = "^[" + letter
+		v.BashKey = `\e` + letter
+	}
+
+	var name string
+	switch shell {
+	case Zsh:
+		name = "zsh.tmpl"
+	case Bash:
+		name = "bash.tmpl"
+	default:
+		return "", fmt.Errorf("unsupported shell %q (supported: zsh, bash)", shell)
+	}
+
+	var b strings.Builder
+	if err := templates.ExecuteTemplate(&b, name, v); err != nil {
+		return "", fmt.Errorf("failed to generate widget: %w", err)
+	}
+	return b.String(), nil
+}
+
+// parseKey splits a key such as "ctrl-g" into its modifier and lowercase letter
+func parseKey(key string) (modifier, letter string, err error) {
+	modifier, letter, found := strings.Cut(strings.ToLower(key), "-")
+	if !found || (modifier != "ctrl" && modifier != "alt") || len(letter) != 1 || letter[0] < 'a' || letter[0] > 'z' {
+		return "", "", fmt.Errorf("invalid key %q (use ctrl-X or alt-X for a letter X)", key)
+	}
+	return modifier, letter, nil
+}
@@ -0,0 +1,38 @@
+package widgetgen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Okabe-Junya/gcloudctx/internal/golden"
+)
+
+func TestGenerate(t *testing.T) {
+	cases := map[string]Options{
+		"default": {},
+		"alt":     {Key: "Alt-K"},
+	}
+
+	for _, shell := range Shells {
+		for name, opts := range cases {
+			t.Run(string(shell)+"/"+name, func(t *testing.T) {
+				got, err := Generate(shell, opts)
+				if err != nil {
+					t.Fatalf("Generate(%s, %+v) error = %v", shell, opts, err)
+				}
+				golden.Assert(t, filepath.Join("testdata", string(shell), name+".golden"), got)
+			})
+		}
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	for _, key := range []string{"g", "ctrl-", "ctrl-gg", "shift-g", "ctrl-1"} {
+		if _, err := Generate(Zsh, Options{Key: key}); err == nil {
+			t.Errorf("Generate() with key %q succeeded, want an error", key)
+		}
+	}
+	if _, err := Generate("fish", Options{}); err == nil {
+		t.Error("Generate() for fish succeeded, want an error")
+	}
+}